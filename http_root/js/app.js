@@ -455,6 +455,27 @@ function loginPageInit() {
     if (window.location.search.includes("wrongCreds=1")) {
         $('.wrong-creds').show();
     }
+    if (window.location.search.includes("tooManyAttempts=1")) {
+        $('.too-many-attempts').show();
+    }
+    if (window.location.search.includes("ssoFailed=1")) {
+        $('.sso-failed').show();
+    }
+
+    $.ajax({
+        url: '/login/options/',
+        dataType: 'json'
+    }).done(function (options) {
+        if (!options.oidc) {
+            return;
+        }
+        var returnTo = new URLSearchParams(window.location.search).get('return_to');
+        var link = $('.oidc-login');
+        if (returnTo) {
+            link.attr('href', '/login/oidc/?return_to=' + encodeURIComponent(returnTo));
+        }
+        link.show();
+    });
 }
 
 function addDevicePageInit() {
@@ -0,0 +1,12 @@
+-- +migrate Up
+create table if not exists `tracks_lyrics` (
+    `track_id` integer unique not null,
+    `plain` text null, -- unsynchronised lyrics as found in the file tags
+    `synced` text null, -- synchronised lyrics in the LRC format
+    `language` text null, -- ISO 639-2 language code
+    `updated_at` integer not null, -- Unix timestamp in seconds
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- +migrate Down
+drop table if exists `tracks_lyrics`;
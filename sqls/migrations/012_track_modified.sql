@@ -0,0 +1,5 @@
+-- +migrate Up
+alter table tracks add column fs_modified integer null; -- unix timestamp of the file's last modification
+
+-- +migrate Down
+alter table tracks drop column fs_modified;
@@ -0,0 +1,13 @@
+-- +migrate Up
+create table if not exists `devices` (
+    `id` integer not null primary key,
+    `token_id` text not null unique,
+    `name` text not null default '',
+    `user_agent` text not null default '',
+    `created_at` integer not null,
+    `last_seen_at` integer not null,
+    `revoked_at` integer null
+);
+
+-- +migrate Down
+drop table if exists `devices`;
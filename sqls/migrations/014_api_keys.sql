@@ -0,0 +1,13 @@
+-- +migrate Up
+create table if not exists `api_keys` (
+    `id` integer not null primary key,
+    `name` text not null,
+    `key_hash` text not null unique, -- hex encoded SHA-256 of the key secret
+    `key_prefix` text not null,
+    `scopes` text not null, -- space separated list of scopes
+    `created_at` integer not null,
+    `last_used_at` integer null
+);
+
+-- +migrate Down
+drop table if exists `api_keys`;
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- The source is where the image was found. For example "local", "embedded", "upload"
+-- or the name of the provider on the internet.
+alter table `albums_artworks` add column `source` text default null;
+alter table `artists_images` add column `source` text default null;
+
+-- +migrate Down
+alter table `artists_images` drop column `source`;
+alter table `albums_artworks` drop column `source`;
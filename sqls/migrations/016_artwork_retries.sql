@@ -0,0 +1,23 @@
+-- +migrate Up
+-- Images which were not found are searched for again at `next_retry_at`. The time
+-- between retries grows with the number of failed attempts.
+alter table `albums_artworks` add column `not_found_attempts` integer not null default 0;
+alter table `albums_artworks` add column `next_retry_at` integer default null;
+alter table `artists_images` add column `not_found_attempts` integer not null default 0;
+alter table `artists_images` add column `next_retry_at` integer default null;
+
+update `albums_artworks` set
+    `not_found_attempts` = 1,
+    `next_retry_at` = `updated_at` + 3600
+where `artwork_cover` is null;
+
+update `artists_images` set
+    `not_found_attempts` = 1,
+    `next_retry_at` = `updated_at` + 3600
+where `image` is null;
+
+-- +migrate Down
+alter table `artists_images` drop column `next_retry_at`;
+alter table `artists_images` drop column `not_found_attempts`;
+alter table `albums_artworks` drop column `next_retry_at`;
+alter table `albums_artworks` drop column `not_found_attempts`;
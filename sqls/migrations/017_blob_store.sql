@@ -0,0 +1,115 @@
+-- +migrate Up
+-- Images are stored in files named after their SHA-256 hashes and the tables only
+-- keep the hashes. The blobs in the old columns are moved into the files when the
+-- library is initialized. The number of references to every blob is kept up to
+-- date by the triggers below so that blobs which are no longer used could be
+-- removed.
+create table if not exists `blobs` (
+    `hash` text not null primary key,
+    `size` integer not null,
+    `refs` integer not null default 0,
+    `created_at` integer not null
+);
+
+create index if not exists blobs_refs on `blobs` (`refs`);
+
+alter table `albums_artworks` add column `artwork_cover_hash` text default null;
+alter table `albums_artworks` add column `artwork_cover_small_hash` text default null;
+alter table `artists_images` add column `image_hash` text default null;
+alter table `artists_images` add column `image_small_hash` text default null;
+alter table `playlists_images` add column `image_hash` text default null;
+
+-- +migrate StatementBegin
+create trigger if not exists `albums_artworks_blobs_insert` after insert on `albums_artworks`
+begin
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`artwork_cover_hash`;
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`artwork_cover_small_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `albums_artworks_blobs_update`
+after update of `artwork_cover_hash`, `artwork_cover_small_hash` on `albums_artworks`
+begin
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`artwork_cover_hash`;
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`artwork_cover_small_hash`;
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`artwork_cover_hash`;
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`artwork_cover_small_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `albums_artworks_blobs_delete` after delete on `albums_artworks`
+begin
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`artwork_cover_hash`;
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`artwork_cover_small_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `artists_images_blobs_insert` after insert on `artists_images`
+begin
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`image_hash`;
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`image_small_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `artists_images_blobs_update`
+after update of `image_hash`, `image_small_hash` on `artists_images`
+begin
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`image_hash`;
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`image_small_hash`;
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`image_hash`;
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`image_small_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `artists_images_blobs_delete` after delete on `artists_images`
+begin
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`image_hash`;
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`image_small_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `playlists_images_blobs_insert` after insert on `playlists_images`
+begin
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`image_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `playlists_images_blobs_update`
+after update of `image_hash` on `playlists_images`
+begin
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`image_hash`;
+    update `blobs` set `refs` = `refs` + 1 where `hash` = new.`image_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+create trigger if not exists `playlists_images_blobs_delete` after delete on `playlists_images`
+begin
+    update `blobs` set `refs` = `refs` - 1 where `hash` = old.`image_hash`;
+end;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- Blobs which were moved out of the database are not moved back.
+drop trigger if exists `playlists_images_blobs_delete`;
+drop trigger if exists `playlists_images_blobs_update`;
+drop trigger if exists `playlists_images_blobs_insert`;
+drop trigger if exists `artists_images_blobs_delete`;
+drop trigger if exists `artists_images_blobs_update`;
+drop trigger if exists `artists_images_blobs_insert`;
+drop trigger if exists `albums_artworks_blobs_delete`;
+drop trigger if exists `albums_artworks_blobs_update`;
+drop trigger if exists `albums_artworks_blobs_insert`;
+alter table `playlists_images` drop column `image_hash`;
+alter table `artists_images` drop column `image_small_hash`;
+alter table `artists_images` drop column `image_hash`;
+alter table `albums_artworks` drop column `artwork_cover_small_hash`;
+alter table `albums_artworks` drop column `artwork_cover_hash`;
+drop table if exists `blobs`;
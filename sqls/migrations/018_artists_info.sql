@@ -0,0 +1,17 @@
+-- +migrate Up
+-- Information about the artists found on the internet. It is searched for again
+-- once it is older than the configured TTL. Rows with nothing but `updated_at`
+-- mean that nothing was found.
+create table if not exists `artists_info` (
+    `artist_id` integer unique not null,
+    `musicbrainz_id` text null,
+    `biography` text null,
+    `lastfm_url` text null,
+    `links` text null, -- JSON list of {"type": ..., "url": ...} objects
+    `similar` text null, -- JSON list of {"artist": ..., "musicbrainz_id": ...} objects
+    `updated_at` integer not null, -- Unix timestamp in seconds
+    FOREIGN KEY(artist_id) REFERENCES artists(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- +migrate Down
+drop table if exists `artists_info`;
@@ -0,0 +1,36 @@
+-- +migrate Up
+-- Release information about the albums as found in the tags of their tracks.
+create table if not exists `tracks_release` (
+    `track_id` integer unique not null,
+    `label` text null,
+    `catalog_number` text null,
+    `original_date` text null, -- YYYY[-MM[-DD]]
+    `release_types` text null, -- lower case types separated with ";"
+    `barcode` text null,
+    `musicbrainz_album_id` text null,
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- Information about the album releases found on the internet. It fills in what
+-- is missing from the tags and is searched for again once it is older than the
+-- configured TTL. Rows with nothing but `updated_at` mean that nothing was found.
+create table if not exists `albums_info` (
+    `album_id` integer unique not null,
+    `musicbrainz_id` text null,
+    `notes` text null,
+    `label` text null,
+    `catalog_number` text null,
+    `original_date` text null, -- YYYY[-MM[-DD]]
+    `release_types` text null, -- lower case types separated with ";"
+    `barcode` text null,
+    `updated_at` integer not null, -- Unix timestamp in seconds
+    FOREIGN KEY(album_id) REFERENCES albums(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- Scans skip the files which have not changed since they were last read. Tracks
+-- which were added before the release tags were read have to be read again.
+update `tracks` set `fs_modified` = null;
+
+-- +migrate Down
+drop table if exists `albums_info`;
+drop table if exists `tracks_release`;
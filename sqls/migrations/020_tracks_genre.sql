@@ -0,0 +1,13 @@
+-- +migrate Up
+-- The genre of the track as found in its tags. Tracks which were added before it
+-- get their genre on the next full library scan.
+alter table `tracks` add column `genre` text null;
+create index if not exists `tracks_genre` on `tracks` (`genre`);
+
+-- Scans skip the files which have not changed since they were last read. This
+-- makes the next one read all tracks again so that they get their genres.
+update `tracks` set `fs_modified` = null;
+
+-- +migrate Down
+drop index if exists `tracks_genre`;
+alter table `tracks` drop column `genre`;
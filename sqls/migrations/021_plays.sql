@@ -0,0 +1,39 @@
+-- +migrate Up
+-- The history of plays. A row is appended every time a track starts playing. Only
+-- the duration of a play is updated afterwards while the track is still being
+-- played. `last_played` and `play_count` in `user_stats` are a cache which is
+-- updated together with this table.
+create table if not exists `plays` (
+    `id` integer not null primary key,
+    `track_id` integer not null,
+    `played_at` integer not null, -- Unix timestamp at which the track started playing
+    `client` text null, -- the application which played the track
+    `user` text null,
+    `duration_played` integer null, -- in milliseconds, null when not known
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+create index if not exists `plays_played_at` on `plays` (`played_at`);
+create index if not exists `plays_track_id` on `plays` (`track_id`, `played_at`);
+
+-- Tracks played before the history existed get as many plays as their play count
+-- so that the recently and frequently played lists stay the same after an upgrade.
+-- All of them are at the time the track was last played. When and for how long
+-- the earlier plays were is not known.
+with recursive `seed`(`track_id`, `played_at`, `remaining`) as (
+    select `track_id`, `last_played`, max(`play_count`, 1)
+    from `user_stats`
+    where `last_played` is not null and `last_played` > 0
+    union all
+    select `track_id`, `played_at`, `remaining` - 1
+    from `seed`
+    where `remaining` > 1
+)
+insert into `plays` (`track_id`, `played_at`)
+    select `track_id`, `played_at`
+    from `seed`;
+
+-- +migrate Down
+drop index if exists `plays_track_id`;
+drop index if exists `plays_played_at`;
+drop table if exists `plays`;
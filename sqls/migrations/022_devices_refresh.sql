@@ -0,0 +1,7 @@
+-- +migrate Up
+-- The ID of the refresh token which the device could use next. It is null when
+-- the device has not refreshed its tokens yet.
+alter table `devices` add column `refresh_token_id` text null;
+
+-- +migrate Down
+alter table `devices` drop column `refresh_token_id`;
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- Similar tracks are searched for among the tracks of the same artists and
+-- albums.
+create index if not exists `tracks_artist_id` on `tracks` (`artist_id`);
+create index if not exists `tracks_album_id` on `tracks` (`album_id`);
+
+-- +migrate Down
+drop index if exists `tracks_album_id`;
+drop index if exists `tracks_artist_id`;
@@ -0,0 +1,65 @@
+// Package albuminfo finds information about the releases of albums on the internet.
+// This is their record labels, catalog numbers, release dates and types, barcodes
+// and notes about them.
+//
+// At the moment the only source is the MusicBrainz API:
+// https://musicbrainz.org/doc/MusicBrainz_API
+package albuminfo
+
+import (
+	"context"
+	"errors"
+)
+
+// ProviderMusicBrainz is the name of the MusicBrainz finder. It is the same as the
+// name used for configuring it.
+const ProviderMusicBrainz = "musicbrainz"
+
+// ErrNotFound is returned when nothing is known about an album.
+var ErrNotFound = errors.New("album info not found")
+
+// Query identifies the album for which information is searched for.
+type Query struct {
+	Artist string
+	Album  string
+
+	// MusicBrainzID is the MusicBrainz ID of the release when it is known from
+	// the tags of the album tracks. Searching by artist and album is not needed
+	// then.
+	MusicBrainzID string
+}
+
+// Info is what is known about the release of an album.
+type Info struct {
+	// MusicBrainzID is the ID of the release in the MusicBrainz database.
+	MusicBrainzID string
+
+	// Notes is free text about the release.
+	Notes string
+
+	// Label is the name of the record label which released the album.
+	Label string
+
+	// CatalogNumber is the number given to the release by its label.
+	CatalogNumber string
+
+	// OriginalReleaseDate is the date at which the album was first released
+	// in the YYYY[-MM[-DD]] format.
+	OriginalReleaseDate string
+
+	// ReleaseTypes are such as "album", "ep", "single", "live" or "compilation".
+	// The primary type is first.
+	ReleaseTypes []string
+
+	// Barcode is the UPC or EAN code of the release.
+	Barcode string
+}
+
+// Finder finds information about albums.
+//
+//counterfeiter:generate . Finder
+type Finder interface {
+	// GetAlbumInfo returns what is known about the album described by `query`.
+	// ErrNotFound is returned when nothing is known about it.
+	GetAlbumInfo(ctx context.Context, query Query) (Info, error)
+}
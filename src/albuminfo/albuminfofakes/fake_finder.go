@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package albuminfofakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/albuminfo"
+)
+
+type FakeFinder struct {
+	GetAlbumInfoStub        func(context.Context, albuminfo.Query) (albuminfo.Info, error)
+	getAlbumInfoMutex       sync.RWMutex
+	getAlbumInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 albuminfo.Query
+	}
+	getAlbumInfoReturns struct {
+		result1 albuminfo.Info
+		result2 error
+	}
+	getAlbumInfoReturnsOnCall map[int]struct {
+		result1 albuminfo.Info
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeFinder) GetAlbumInfo(arg1 context.Context, arg2 albuminfo.Query) (albuminfo.Info, error) {
+	fake.getAlbumInfoMutex.Lock()
+	ret, specificReturn := fake.getAlbumInfoReturnsOnCall[len(fake.getAlbumInfoArgsForCall)]
+	fake.getAlbumInfoArgsForCall = append(fake.getAlbumInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 albuminfo.Query
+	}{arg1, arg2})
+	stub := fake.GetAlbumInfoStub
+	fakeReturns := fake.getAlbumInfoReturns
+	fake.recordInvocation("GetAlbumInfo", []interface{}{arg1, arg2})
+	fake.getAlbumInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFinder) GetAlbumInfoCallCount() int {
+	fake.getAlbumInfoMutex.RLock()
+	defer fake.getAlbumInfoMutex.RUnlock()
+	return len(fake.getAlbumInfoArgsForCall)
+}
+
+func (fake *FakeFinder) GetAlbumInfoCalls(stub func(context.Context, albuminfo.Query) (albuminfo.Info, error)) {
+	fake.getAlbumInfoMutex.Lock()
+	defer fake.getAlbumInfoMutex.Unlock()
+	fake.GetAlbumInfoStub = stub
+}
+
+func (fake *FakeFinder) GetAlbumInfoArgsForCall(i int) (context.Context, albuminfo.Query) {
+	fake.getAlbumInfoMutex.RLock()
+	defer fake.getAlbumInfoMutex.RUnlock()
+	argsForCall := fake.getAlbumInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFinder) GetAlbumInfoReturns(result1 albuminfo.Info, result2 error) {
+	fake.getAlbumInfoMutex.Lock()
+	defer fake.getAlbumInfoMutex.Unlock()
+	fake.GetAlbumInfoStub = nil
+	fake.getAlbumInfoReturns = struct {
+		result1 albuminfo.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFinder) GetAlbumInfoReturnsOnCall(i int, result1 albuminfo.Info, result2 error) {
+	fake.getAlbumInfoMutex.Lock()
+	defer fake.getAlbumInfoMutex.Unlock()
+	fake.GetAlbumInfoStub = nil
+	if fake.getAlbumInfoReturnsOnCall == nil {
+		fake.getAlbumInfoReturnsOnCall = make(map[int]struct {
+			result1 albuminfo.Info
+			result2 error
+		})
+	}
+	fake.getAlbumInfoReturnsOnCall[i] = struct {
+		result1 albuminfo.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFinder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getAlbumInfoMutex.RLock()
+	defer fake.getAlbumInfoMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeFinder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ albuminfo.Finder = new(FakeFinder)
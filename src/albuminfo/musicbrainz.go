@@ -0,0 +1,72 @@
+package albuminfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// MusicBrainz is a Finder which looks up releases in the MusicBrainz database.
+type MusicBrainz struct {
+	client *art.Client
+}
+
+// NewMusicBrainz returns a MusicBrainz finder which makes its requests with
+// `client`. This way they are throttled together with the requests for images.
+func NewMusicBrainz(client *art.Client) *MusicBrainz {
+	return &MusicBrainz{
+		client: client,
+	}
+}
+
+// GetAlbumInfo implements Finder. The release with the MusicBrainz ID from the
+// query is used when there is one. Otherwise the best match for the artist and
+// album is used.
+func (m *MusicBrainz) GetAlbumInfo(ctx context.Context, query Query) (Info, error) {
+	mbID := query.MusicBrainzID
+	if mbID == "" {
+		mbIDs, err := m.client.MusicBrainzReleaseIDs(ctx, query.Artist, query.Album)
+		if errors.Is(err, art.ErrImageNotFound) {
+			return Info{}, ErrNotFound
+		} else if err != nil {
+			return Info{}, fmt.Errorf("searching MusicBrainz releases: %w", err)
+		}
+		mbID = mbIDs[0]
+	}
+
+	release, err := m.client.MusicBrainzRelease(ctx, mbID)
+	if errors.Is(err, art.ErrImageNotFound) {
+		return Info{}, ErrNotFound
+	} else if err != nil {
+		return Info{}, fmt.Errorf("getting MusicBrainz release: %w", err)
+	}
+
+	info := Info{
+		MusicBrainzID:       release.ID,
+		Notes:               release.Annotation,
+		OriginalReleaseDate: release.FirstReleaseDate,
+		Barcode:             release.Barcode,
+	}
+	if info.MusicBrainzID == "" {
+		info.MusicBrainzID = mbID
+	}
+	if info.OriginalReleaseDate == "" {
+		info.OriginalReleaseDate = release.Date
+	}
+	for _, releaseType := range release.Types {
+		info.ReleaseTypes = append(info.ReleaseTypes, strings.ToLower(releaseType))
+	}
+	for _, label := range release.Labels {
+		if info.Label == "" {
+			info.Label = label.Name
+		}
+		if info.CatalogNumber == "" {
+			info.CatalogNumber = label.CatalogNumber
+		}
+	}
+
+	return info, nil
+}
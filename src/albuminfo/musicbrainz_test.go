@@ -0,0 +1,125 @@
+package albuminfo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/albuminfo"
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// TestMusicBrainz checks that releases are found by artist and album or directly
+// by their MusicBrainz ID and that their labels, dates and types are returned.
+func TestMusicBrainz(t *testing.T) {
+	const releaseMBID = "6fd8d6e4-3e2f-3ae2-b1ec-7e1d5dc2b3a1"
+
+	var lookups []string
+	mbrainz := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			switch {
+			case req.URL.Path == "/ws/2/release/" &&
+				req.URL.Query().Get("query") ==
+					"release:The Number of the Beast AND artist:Iron Maiden":
+				fmt.Fprintf(w, `
+					<metadata>
+					<release-list count="1" offset="0">
+						<release id="%s" ns2:score="100">
+							<title>The Number of the Beast</title>
+						</release>
+					</release-list>
+					</metadata>
+				`, releaseMBID)
+			case req.URL.Path == "/ws/2/release/":
+				fmt.Fprint(w, `<metadata><release-list count="0"></release-list></metadata>`)
+			case req.URL.Path == "/ws/2/release/"+releaseMBID:
+				lookups = append(lookups, req.URL.Query().Get("inc"))
+				fmt.Fprintf(w, `
+					<metadata>
+					<release id="%s">
+						<title>The Number of the Beast</title>
+						<date>1982-03-29</date>
+						<barcode>077779675127</barcode>
+						<annotation><text>First album with Bruce Dickinson.</text></annotation>
+						<label-info-list count="2">
+							<label-info>
+								<catalog-number>EMC 3400</catalog-number>
+								<label id="label-1"><name>EMI</name></label>
+							</label-info>
+							<label-info>
+								<label id="label-2"><name>Harvest</name></label>
+							</label-info>
+						</label-info-list>
+						<release-group id="group-id" type="Album">
+							<first-release-date>1982-03-22</first-release-date>
+							<primary-type>Album</primary-type>
+							<secondary-type-list>
+								<secondary-type>Remix</secondary-type>
+							</secondary-type-list>
+						</release-group>
+					</release>
+					</metadata>
+				`, releaseMBID)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	))
+	defer mbrainz.Close()
+
+	client := art.NewClient("euterpe/testing", 0, "")
+	client.SetMusicBrainzAPIURL(mbrainz.URL)
+	finder := albuminfo.NewMusicBrainz(client)
+
+	ctx := context.Background()
+	info, err := finder.GetAlbumInfo(ctx, albuminfo.Query{
+		Artist: "Iron Maiden",
+		Album:  "The Number of the Beast",
+	})
+	if err != nil {
+		t.Fatalf("getting album info: %s", err)
+	}
+
+	expected := albuminfo.Info{
+		MusicBrainzID:       releaseMBID,
+		Notes:               "First album with Bruce Dickinson.",
+		Label:               "EMI",
+		CatalogNumber:       "EMC 3400",
+		OriginalReleaseDate: "1982-03-22",
+		ReleaseTypes:        []string{"album", "remix"},
+		Barcode:             "077779675127",
+	}
+	if info.MusicBrainzID != expected.MusicBrainzID || info.Notes != expected.Notes ||
+		info.Label != expected.Label || info.CatalogNumber != expected.CatalogNumber ||
+		info.OriginalReleaseDate != expected.OriginalReleaseDate ||
+		info.Barcode != expected.Barcode ||
+		!slices.Equal(info.ReleaseTypes, expected.ReleaseTypes) {
+		t.Errorf("expected album info %+v but got %+v", expected, info)
+	}
+
+	if len(lookups) != 1 || lookups[0] != "labels release-groups annotation" {
+		t.Errorf("unexpected release lookups: %q", lookups)
+	}
+
+	_, err = finder.GetAlbumInfo(ctx, albuminfo.Query{MusicBrainzID: releaseMBID})
+	if err != nil {
+		t.Errorf("getting album info by MusicBrainz ID: %s", err)
+	}
+	if len(lookups) != 2 {
+		t.Errorf("expected the release to be looked up directly by its ID")
+	}
+
+	_, err = finder.GetAlbumInfo(ctx, albuminfo.Query{Artist: "Unknown", Album: "Album"})
+	if !errors.Is(err, albuminfo.ErrNotFound) {
+		t.Errorf("expected not found error for unknown album but got %v", err)
+	}
+
+	_, err = finder.GetAlbumInfo(ctx, albuminfo.Query{MusicBrainzID: "missing"})
+	if !errors.Is(err, albuminfo.ErrNotFound) {
+		t.Errorf("expected not found error for missing release but got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Package apikeys manages long-lived API keys meant for scripts and other
+// automation. Keys are stored hashed and every one of them is limited to a set
+// of scopes.
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+//counterfeiter:generate . Keys
+
+// Keys is the interface for creating, checking and removing API keys.
+type Keys interface {
+	// Create generates a new API key with name `name` which allows the operations
+	// in `scopes`. It returns the stored key and its secret. The secret is not
+	// stored anywhere and cannot be retrieved later.
+	Create(ctx context.Context, name string, scopes []Scope) (Key, string, error)
+
+	// Check returns the key for `secret`. ErrNotFound is returned when there is no
+	// such key. On success the last used time of the key is updated.
+	Check(ctx context.Context, secret string) (Key, error)
+
+	// List returns all stored API keys.
+	List(ctx context.Context) ([]Key, error)
+
+	// Delete removes the API key with ID `keyID`. Its secret stops working.
+	Delete(ctx context.Context, keyID int64) error
+}
+
+// Key is a single API key. It does not contain the secret itself.
+type Key struct {
+	// ID is a unique identifier for the key.
+	ID int64
+
+	// Name is a human readable description of the key.
+	Name string
+
+	// Prefix is the beginning of the key secret. It helps with telling keys apart
+	// without exposing the whole secret.
+	Prefix string
+
+	// Scopes is the list of operations permitted with this key.
+	Scopes []Scope
+
+	// CreatedAt is the time at which the key was created.
+	CreatedAt time.Time
+
+	// LastUsedAt is the last time the key was used. It is nil for keys which
+	// have never been used.
+	LastUsedAt *time.Time
+}
+
+// Allows returns true when the key could be used for operations which require
+// `scope`. Keys with the admin scope are allowed everything.
+func (k Key) Allows(scope Scope) bool {
+	return slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Scopes, scope)
+}
+
+// Scope is a group of operations which could be permitted for an API key.
+type Scope string
+
+// All the supported scopes.
+const (
+	// ScopeRead permits browsing and searching the library without changing it.
+	ScopeRead Scope = "read"
+
+	// ScopeStream permits streaming and downloading media files.
+	ScopeStream Scope = "stream"
+
+	// ScopePlaylistsWrite permits creating, changing and removing playlists.
+	ScopePlaylistsWrite Scope = "playlists:write"
+
+	// ScopeAdmin permits everything, including managing API keys and devices.
+	ScopeAdmin Scope = "admin"
+)
+
+var allScopes = []Scope{ScopeRead, ScopeStream, ScopePlaylistsWrite, ScopeAdmin}
+
+// ParseScope returns the scope with name `name` or an error if there is no such.
+func ParseScope(name string) (Scope, error) {
+	scope := Scope(name)
+	if !slices.Contains(allScopes, scope) {
+		return "", fmt.Errorf("unknown scope `%s`", name)
+	}
+
+	return scope, nil
+}
+
+// ErrNotFound is returned when an API key was not found for a given operation.
+var ErrNotFound = errors.New("API key not found")
@@ -0,0 +1,135 @@
+package apikeys_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/apikeys"
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// TestKeysManager uses the Keys interface to test creating, checking and removing
+// API keys.
+func TestKeysManager(t *testing.T) {
+	ctx := context.Background()
+
+	lib := getLibrary(ctx, t)
+	defer func() {
+		_ = lib.Truncate()
+	}()
+	keys := apikeys.NewManager(lib.ExecuteDBJobAndWait)
+
+	if _, _, err := keys.Create(ctx, "", []apikeys.Scope{apikeys.ScopeRead}); err == nil {
+		t.Errorf("Expected error when creating a key without a name")
+	}
+	if _, _, err := keys.Create(ctx, "no scopes", nil); err == nil {
+		t.Errorf("Expected error when creating a key without scopes")
+	}
+	if _, _, err := keys.Create(ctx, "bad", []apikeys.Scope{"baba"}); err == nil {
+		t.Errorf("Expected error when creating a key with unknown scope")
+	}
+
+	scopes := []apikeys.Scope{apikeys.ScopeRead, apikeys.ScopeStream}
+	created, secret, err := keys.Create(ctx, "Backup script", scopes)
+	if err != nil {
+		t.Fatalf("Failed to create API key: %s", err)
+	}
+
+	if secret == "" || !strings.HasPrefix(secret, created.Prefix) {
+		t.Errorf("Secret `%s` does not start with key prefix `%s`", secret, created.Prefix)
+	}
+
+	checked, err := keys.Check(ctx, secret)
+	if err != nil {
+		t.Fatalf("Failed to check the created key: %s", err)
+	}
+	if checked.ID != created.ID || checked.Name != "Backup script" {
+		t.Errorf("Checked key %+v differs from the created one %+v", checked, created)
+	}
+	if !slices.Equal(checked.Scopes, scopes) {
+		t.Errorf("Expected scopes %v but got %v", scopes, checked.Scopes)
+	}
+	if !checked.Allows(apikeys.ScopeStream) || checked.Allows(apikeys.ScopeAdmin) {
+		t.Errorf("Key has wrong permissions: %v", checked.Scopes)
+	}
+
+	if _, err := keys.Check(ctx, secret+"x"); !errors.Is(err, apikeys.ErrNotFound) {
+		t.Errorf("Expected not found error for wrong secret but got: %v", err)
+	}
+
+	all, err := keys.List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list keys: %s", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("Expected one key but got %d", len(all))
+	}
+	if all[0].LastUsedAt == nil {
+		t.Errorf("Expected last used time to be set after checking the key")
+	}
+
+	if err := keys.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Failed to delete key: %s", err)
+	}
+	if err := keys.Delete(ctx, created.ID); !errors.Is(err, apikeys.ErrNotFound) {
+		t.Errorf("Expected not found error when deleting twice but got: %v", err)
+	}
+	if _, err := keys.Check(ctx, secret); !errors.Is(err, apikeys.ErrNotFound) {
+		t.Errorf("Expected deleted key to be rejected but got: %v", err)
+	}
+}
+
+// TestKeyAllows checks that the admin scope allows everything and the other
+// scopes only themselves.
+func TestKeyAllows(t *testing.T) {
+	admin := apikeys.Key{Scopes: []apikeys.Scope{apikeys.ScopeAdmin}}
+	reader := apikeys.Key{Scopes: []apikeys.Scope{apikeys.ScopeRead}}
+
+	for _, scope := range []apikeys.Scope{
+		apikeys.ScopeRead,
+		apikeys.ScopeStream,
+		apikeys.ScopePlaylistsWrite,
+		apikeys.ScopeAdmin,
+	} {
+		if !admin.Allows(scope) {
+			t.Errorf("Expected admin key to allow `%s`", scope)
+		}
+		if reader.Allows(scope) != (scope == apikeys.ScopeRead) {
+			t.Errorf("Wrong permission of read key for `%s`", scope)
+		}
+	}
+
+	if _, err := apikeys.ParseScope("playlists:write"); err != nil {
+		t.Errorf("Parsing playlists:write scope failed: %s", err)
+	}
+	if _, err := apikeys.ParseScope("write"); err == nil {
+		t.Errorf("Expected error for unknown scope")
+	}
+}
+
+// getTestMigrationFiles returns the SQLs directory used by the application itself
+// normally. This way tests will be done with the exact same files which will be
+// bundled into the binary on build.
+func getTestMigrationFiles() fs.FS {
+	return os.DirFS("../../sqls")
+}
+
+// It is the caller's responsibility to remove the library SQLite database file
+func getLibrary(ctx context.Context, t *testing.T) *library.LocalLibrary {
+	lib, err := library.NewLocalLibrary(ctx, library.SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = lib.Initialize()
+	if err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	return lib
+}
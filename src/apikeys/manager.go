@@ -0,0 +1,258 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+const (
+	// secretPrefix is prepended to all generated secrets so that they are easily
+	// recognizable, for example by secret scanning tools.
+	secretPrefix = "eut_"
+
+	// shownPrefixLen is the number of characters from the beginning of the
+	// secret which are stored as plain text.
+	shownPrefixLen = len(secretPrefix) + 4
+
+	// lastUsedResolution is the minimal time between two updates of the last
+	// used time of a key.
+	lastUsedResolution = time.Minute
+)
+
+// manager implements the Keys interface by just requiring a function for
+// sending database work.
+type manager struct {
+	executeDBJobAndWait func(library.DatabaseExecutable) error
+}
+
+// NewManager returns a Keys interface which will use the `sendDBWork` to
+// execute its database queries.
+func NewManager(sendDBWork func(library.DatabaseExecutable) error) Keys {
+	return &manager{
+		executeDBJobAndWait: sendDBWork,
+	}
+}
+
+// Create implements the Keys interface.
+func (m *manager) Create(
+	ctx context.Context,
+	name string,
+	scopes []Scope,
+) (Key, string, error) {
+	if name == "" {
+		return Key{}, "", fmt.Errorf("name cannot be empty")
+	}
+	if len(scopes) == 0 {
+		return Key{}, "", fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if _, err := ParseScope(string(scope)); err != nil {
+			return Key{}, "", err
+		}
+	}
+
+	secret := secretPrefix + rand.Text()
+	key := Key{
+		Name:      name,
+		Prefix:    secret[:shownPrefixLen],
+		Scopes:    scopes,
+		CreatedAt: time.Unix(time.Now().Unix(), 0),
+	}
+
+	query := `
+		INSERT INTO
+			api_keys (name, key_hash, key_prefix, scopes, created_at)
+		VALUES
+			(@name, @hash, @prefix, @scopes, @createdAt)
+	`
+
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query,
+			sql.Named("name", key.Name),
+			sql.Named("hash", hashSecret(secret)),
+			sql.Named("prefix", key.Prefix),
+			sql.Named("scopes", joinScopes(key.Scopes)),
+			sql.Named("createdAt", key.CreatedAt.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert API key: %w", err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("cannot get last insert ID for API key: %w", err)
+		}
+
+		key.ID = id
+		return nil
+	}
+
+	if err := m.executeDBJobAndWait(work); err != nil {
+		return Key{}, "", err
+	}
+
+	return key, secret, nil
+}
+
+// Check implements the Keys interface.
+func (m *manager) Check(ctx context.Context, secret string) (Key, error) {
+	var key Key
+
+	selectQuery := `
+		SELECT id, name, key_prefix, scopes, created_at, last_used_at
+		FROM api_keys
+		WHERE key_hash = @hash
+	`
+	updateQuery := `
+		UPDATE api_keys
+		SET last_used_at = @now
+		WHERE id = @id
+	`
+
+	work := func(db *sql.DB) error {
+		row := db.QueryRowContext(ctx, selectQuery, sql.Named("hash", hashSecret(secret)))
+		found, err := scanKey(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("could not query the database: %w", err)
+		}
+		key = found
+
+		now := time.Now()
+		if key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < lastUsedResolution {
+			return nil
+		}
+
+		_, err = db.ExecContext(ctx, updateQuery,
+			sql.Named("id", key.ID),
+			sql.Named("now", now.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update API key last used time: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := m.executeDBJobAndWait(work); err != nil {
+		return Key{}, err
+	}
+
+	return key, nil
+}
+
+// List implements the Keys interface.
+func (m *manager) List(ctx context.Context) ([]Key, error) {
+	var keys []Key
+	query := `
+		SELECT id, name, key_prefix, scopes, created_at, last_used_at
+		FROM api_keys
+		ORDER BY id
+	`
+
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("could not query the database: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			key, err := scanKey(rows)
+			if err != nil {
+				return fmt.Errorf("error scanning API key: %w", err)
+			}
+
+			keys = append(keys, key)
+		}
+
+		return rows.Err()
+	}
+	if err := m.executeDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Delete implements the Keys interface.
+func (m *manager) Delete(ctx context.Context, keyID int64) error {
+	query := `
+		DELETE FROM
+			api_keys
+		WHERE
+			id = @id
+	`
+
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query, sql.Named("id", keyID))
+		if err != nil {
+			return fmt.Errorf("failed to delete API key: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("cannot get the number of affected API keys: %w", err)
+		}
+
+		if affected != 1 {
+			return ErrNotFound
+		}
+
+		return nil
+	}
+
+	return m.executeDBJobAndWait(work)
+}
+
+// scanKey reads a key from a row with columns id, name, key_prefix, scopes,
+// created_at and last_used_at in this order.
+func scanKey(row interface{ Scan(...any) error }) (Key, error) {
+	var (
+		key       Key
+		scopes    string
+		createdAt int64
+		lastUsed  sql.NullInt64
+	)
+
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &createdAt, &lastUsed)
+	if err != nil {
+		return Key{}, err
+	}
+
+	for _, scope := range strings.Fields(scopes) {
+		key.Scopes = append(key.Scopes, Scope(scope))
+	}
+	key.CreatedAt = time.Unix(createdAt, 0)
+	if lastUsed.Valid {
+		lastUsedAt := time.Unix(lastUsed.Int64, 0)
+		key.LastUsedAt = &lastUsedAt
+	}
+
+	return key, nil
+}
+
+// hashSecret returns the hex encoded SHA-256 sum of `secret`. Secrets are long
+// random strings so a fast hash is enough for storing them.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func joinScopes(scopes []Scope) string {
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, string(scope))
+	}
+	return strings.Join(names, " ")
+}
@@ -3,17 +3,23 @@ package art
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/ironsmile/euterpe/src/httpapi"
 	cca "gopkg.in/mineo/gocaa.v1"
 )
 
 const (
-	musicBrainzReleaseEndpint    = "%s/ws/2/release/"
-	musicBrainzReleaseQueryValue = "release:%s AND artist:%s"
+	musicBrainzReleaseEndpint       = "%s/ws/2/release/"
+	musicBrainzReleaseLookupEndpint = "%s/ws/2/release/%s?inc=labels+release-groups+annotation"
+	musicBrainzReleaseQueryValue    = "release:%s AND artist:%s"
 )
 
 // GetFrontImage returns the front image for particular `album` from `artist`.
@@ -57,6 +63,55 @@ func (c *Client) getMusicBrainzReleaseID(
 	artist,
 	album string,
 ) ([]string, error) {
+	releases, err := c.searchMusicBrainzReleases(ctx, artist, album)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseIDs := make([]string, 0, len(releases))
+	for _, release := range releases {
+		releaseIDs = append(releaseIDs, release.ID)
+	}
+
+	return releaseIDs, nil
+}
+
+// getMusicBrainzReleaseGroupIDs is like getMusicBrainzReleaseID but returns the
+// IDs of the release groups instead. A release group holds all the releases of
+// an album. Every group is returned only once.
+func (c *Client) getMusicBrainzReleaseGroupIDs(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]string, error) {
+	releases, err := c.searchMusicBrainzReleases(ctx, artist, album)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupIDs []string
+	for _, release := range releases {
+		groupID := release.ReleaseGroup.ID
+		if groupID == "" || slices.Contains(groupIDs, groupID) {
+			continue
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+
+	if len(groupIDs) < 1 {
+		return nil, ErrImageNotFound
+	}
+
+	return groupIDs, nil
+}
+
+// searchMusicBrainzReleases returns the releases which match `artist` and `album`
+// with score of at least MinScore.
+func (c *Client) searchMusicBrainzReleases(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]mbRelease, error) {
 	c.Lock()
 	defer c.Unlock()
 
@@ -99,18 +154,147 @@ func (c *Client) getMusicBrainzReleaseID(
 		return nil, ErrImageNotFound
 	}
 
-	var releaseIDs []string
+	var releases []mbRelease
 	for _, release := range root.RelaseList.Relases {
 		if release.Score >= c.MinScore {
-			releaseIDs = append(releaseIDs, release.ID)
+			releases = append(releases, release)
 		}
 	}
 
-	if len(releaseIDs) < 1 {
+	if len(releases) < 1 {
 		return nil, ErrImageNotFound
 	}
 
-	return releaseIDs, nil
+	return releases, nil
+}
+
+// Release is an album release in the MusicBrainz database.
+type Release struct {
+	// ID is the MusicBrainz ID of the release.
+	ID string
+
+	// Date is the date of this release in the YYYY[-MM[-DD]] format.
+	Date string
+
+	// FirstReleaseDate is the date of the earliest release of the album
+	// in the YYYY[-MM[-DD]] format.
+	FirstReleaseDate string
+
+	// Types are the primary type of the release group such as "Album" or "EP"
+	// followed by its secondary types such as "Live" or "Compilation".
+	Types []string
+
+	// Labels are the record labels which released the album.
+	Labels []ReleaseLabel
+
+	Barcode string
+
+	// Annotation is the free text with notes about the release.
+	Annotation string
+}
+
+// ReleaseLabel is a record label which released an album.
+type ReleaseLabel struct {
+	Name          string
+	CatalogNumber string
+}
+
+// MusicBrainzReleaseIDs returns the MusicBrainz IDs of the releases which match
+// `artist` and `album` with a score above MinScore. The best matches are first.
+// Since the Client is used for finding images ErrImageNotFound is returned when
+// there are no matches.
+func (c *Client) MusicBrainzReleaseIDs(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]string, error) {
+	return c.getMusicBrainzReleaseID(ctx, artist, album)
+}
+
+// MusicBrainzRelease returns the release with MusicBrainz ID `releaseMBid`
+// together with its labels and release group. ErrImageNotFound is returned when
+// there is no such release.
+func (c *Client) MusicBrainzRelease(
+	ctx context.Context,
+	releaseMBid string,
+) (Release, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	<-c.delayer.C
+	defer c.delayer.Reset(c.delay)
+
+	endpointURL := fmt.Sprintf(
+		musicBrainzReleaseLookupEndpint,
+		c.musicBrainzAPIHost,
+		url.PathEscape(releaseMBid),
+	)
+	root := mbReleaseData{}
+	err := httpapi.GetXML(ctx, c.useragent, endpointURL, &root)
+	if errors.Is(err, httpapi.ErrNotFound) {
+		return Release{}, ErrImageNotFound
+	} else if err != nil {
+		return Release{}, fmt.Errorf("release XML API (MusicBrainz): %w", err)
+	}
+
+	mbRel := root.Release
+	release := Release{
+		ID:               mbRel.ID,
+		Date:             strings.TrimSpace(mbRel.Date),
+		FirstReleaseDate: strings.TrimSpace(mbRel.ReleaseGroup.FirstReleaseDate),
+		Barcode:          strings.TrimSpace(mbRel.Barcode),
+		Annotation:       mbRel.Annotation.String(),
+	}
+	if primary := strings.TrimSpace(mbRel.ReleaseGroup.PrimaryType); primary != "" {
+		release.Types = append(release.Types, primary)
+	}
+	for _, secondary := range mbRel.ReleaseGroup.SecondaryTypes {
+		if secondary = strings.TrimSpace(secondary); secondary != "" {
+			release.Types = append(release.Types, secondary)
+		}
+	}
+	for _, labelInfo := range mbRel.LabelInfoList {
+		label := ReleaseLabel{
+			Name:          strings.TrimSpace(labelInfo.Label.Name),
+			CatalogNumber: strings.TrimSpace(labelInfo.CatalogNumber),
+		}
+		if label.Name == "" && label.CatalogNumber == "" {
+			continue
+		}
+		release.Labels = append(release.Labels, label)
+	}
+
+	return release, nil
+}
+
+// NewCAAProvider returns a Provider which finds album artwork in the Cover Art
+// Archive with the help of `c`. It does not have artist images.
+func NewCAAProvider(c *Client) Provider {
+	return caaProvider{client: c}
+}
+
+type caaProvider struct {
+	client *Client
+}
+
+// Name implements Provider.
+func (p caaProvider) Name() string {
+	return ProviderCAA
+}
+
+// GetFrontImage implements Finder.
+func (p caaProvider) GetFrontImage(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]byte, error) {
+	return p.client.GetFrontImage(ctx, artist, album)
+}
+
+// GetArtistImage implements Finder. There are no artist images in the Cover
+// Art Archive.
+func (p caaProvider) GetArtistImage(context.Context, string) ([]byte, error) {
+	return nil, ErrImageNotFound
 }
 
 // The following are structures only used to decode the XML response from MusicBrainz
@@ -124,7 +308,75 @@ type mbReleaseList struct {
 }
 
 type mbRelease struct {
-	ID    string `xml:"id,attr"`
-	Score int    `xml:"score,attr"`
-	Title string `xml:"title"`
+	ID           string         `xml:"id,attr"`
+	Score        int            `xml:"score,attr"`
+	Title        string         `xml:"title"`
+	ReleaseGroup mbReleaseGroup `xml:"release-group"`
+}
+
+type mbReleaseGroup struct {
+	ID               string   `xml:"id,attr"`
+	FirstReleaseDate string   `xml:"first-release-date"`
+	PrimaryType      string   `xml:"primary-type"`
+	SecondaryTypes   []string `xml:"secondary-type-list>secondary-type"`
+}
+
+/*
+mbReleaseData represents the response from the MusicBrainz release XML. Truncated
+example:
+
+<metadata>
+
+	<release id="id">
+	    <title>The Number of the Beast</title>
+	    <date>1982-03-22</date>
+	    <barcode>077779675127</barcode>
+	    <annotation>Notes about the release.</annotation>
+	    <label-info-list count="1">
+	        <label-info>
+	            <catalog-number>EMC 3400</catalog-number>
+	            <label id="label-id"><name>EMI</name></label>
+	        </label-info>
+	    </label-info-list>
+	    <release-group id="group-id" type="Album">
+	        <first-release-date>1982-03-22</first-release-date>
+	        <primary-type>Album</primary-type>
+	    </release-group>
+	</release>
+
+</metadata>
+*/
+type mbReleaseData struct {
+	Release mbReleaseDetails `xml:"release"`
+}
+
+type mbReleaseDetails struct {
+	ID            string         `xml:"id,attr"`
+	Date          string         `xml:"date"`
+	Barcode       string         `xml:"barcode"`
+	Annotation    mbAnnotation   `xml:"annotation"`
+	LabelInfoList []mbLabelInfo  `xml:"label-info-list>label-info"`
+	ReleaseGroup  mbReleaseGroup `xml:"release-group"`
+}
+
+type mbLabelInfo struct {
+	CatalogNumber string `xml:"catalog-number"`
+	Label         struct {
+		Name string `xml:"name"`
+	} `xml:"label"`
+}
+
+// mbAnnotation is the annotation of an entity. Its text is either directly in
+// the element or in a <text> child element.
+type mbAnnotation struct {
+	Text     string `xml:"text"`
+	CharData string `xml:",chardata"`
+}
+
+// String returns the text of the annotation.
+func (a mbAnnotation) String() string {
+	if text := strings.TrimSpace(a.Text); text != "" {
+		return text
+	}
+	return strings.TrimSpace(a.CharData)
 }
@@ -0,0 +1,267 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package artfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+type FakeProvider struct {
+	GetArtistImageStub        func(context.Context, string) ([]byte, error)
+	getArtistImageMutex       sync.RWMutex
+	getArtistImageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getArtistImageReturns struct {
+		result1 []byte
+		result2 error
+	}
+	getArtistImageReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	GetFrontImageStub        func(context.Context, string, string) ([]byte, error)
+	getFrontImageMutex       sync.RWMutex
+	getFrontImageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	getFrontImageReturns struct {
+		result1 []byte
+		result2 error
+	}
+	getFrontImageReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeProvider) GetArtistImage(arg1 context.Context, arg2 string) ([]byte, error) {
+	fake.getArtistImageMutex.Lock()
+	ret, specificReturn := fake.getArtistImageReturnsOnCall[len(fake.getArtistImageArgsForCall)]
+	fake.getArtistImageArgsForCall = append(fake.getArtistImageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetArtistImageStub
+	fakeReturns := fake.getArtistImageReturns
+	fake.recordInvocation("GetArtistImage", []interface{}{arg1, arg2})
+	fake.getArtistImageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) GetArtistImageCallCount() int {
+	fake.getArtistImageMutex.RLock()
+	defer fake.getArtistImageMutex.RUnlock()
+	return len(fake.getArtistImageArgsForCall)
+}
+
+func (fake *FakeProvider) GetArtistImageCalls(stub func(context.Context, string) ([]byte, error)) {
+	fake.getArtistImageMutex.Lock()
+	defer fake.getArtistImageMutex.Unlock()
+	fake.GetArtistImageStub = stub
+}
+
+func (fake *FakeProvider) GetArtistImageArgsForCall(i int) (context.Context, string) {
+	fake.getArtistImageMutex.RLock()
+	defer fake.getArtistImageMutex.RUnlock()
+	argsForCall := fake.getArtistImageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) GetArtistImageReturns(result1 []byte, result2 error) {
+	fake.getArtistImageMutex.Lock()
+	defer fake.getArtistImageMutex.Unlock()
+	fake.GetArtistImageStub = nil
+	fake.getArtistImageReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) GetArtistImageReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.getArtistImageMutex.Lock()
+	defer fake.getArtistImageMutex.Unlock()
+	fake.GetArtistImageStub = nil
+	if fake.getArtistImageReturnsOnCall == nil {
+		fake.getArtistImageReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.getArtistImageReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) GetFrontImage(arg1 context.Context, arg2 string, arg3 string) ([]byte, error) {
+	fake.getFrontImageMutex.Lock()
+	ret, specificReturn := fake.getFrontImageReturnsOnCall[len(fake.getFrontImageArgsForCall)]
+	fake.getFrontImageArgsForCall = append(fake.getFrontImageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.GetFrontImageStub
+	fakeReturns := fake.getFrontImageReturns
+	fake.recordInvocation("GetFrontImage", []interface{}{arg1, arg2, arg3})
+	fake.getFrontImageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) GetFrontImageCallCount() int {
+	fake.getFrontImageMutex.RLock()
+	defer fake.getFrontImageMutex.RUnlock()
+	return len(fake.getFrontImageArgsForCall)
+}
+
+func (fake *FakeProvider) GetFrontImageCalls(stub func(context.Context, string, string) ([]byte, error)) {
+	fake.getFrontImageMutex.Lock()
+	defer fake.getFrontImageMutex.Unlock()
+	fake.GetFrontImageStub = stub
+}
+
+func (fake *FakeProvider) GetFrontImageArgsForCall(i int) (context.Context, string, string) {
+	fake.getFrontImageMutex.RLock()
+	defer fake.getFrontImageMutex.RUnlock()
+	argsForCall := fake.getFrontImageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeProvider) GetFrontImageReturns(result1 []byte, result2 error) {
+	fake.getFrontImageMutex.Lock()
+	defer fake.getFrontImageMutex.Unlock()
+	fake.GetFrontImageStub = nil
+	fake.getFrontImageReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) GetFrontImageReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.getFrontImageMutex.Lock()
+	defer fake.getFrontImageMutex.Unlock()
+	fake.GetFrontImageStub = nil
+	if fake.getFrontImageReturnsOnCall == nil {
+		fake.getFrontImageReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.getFrontImageReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeProvider) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeProvider) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeProvider) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeProvider) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getArtistImageMutex.RLock()
+	defer fake.getArtistImageMutex.RUnlock()
+	fake.getFrontImageMutex.RLock()
+	defer fake.getFrontImageMutex.RUnlock()
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeProvider) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ art.Provider = new(FakeProvider)
@@ -152,6 +152,59 @@ func (c *Client) getDiscogsArtistID(
 	ctx context.Context,
 	artistMBid string,
 ) (string, error) {
+	artistURLs, err := c.MusicBrainzArtistURLs(ctx, artistMBid)
+	if err != nil {
+		return "", err
+	}
+
+	for _, artistURL := range artistURLs {
+		if artistURL.Type != "discogs" {
+			continue
+		}
+
+		discogsURL, err := url.Parse(artistURL.URL)
+		if err != nil {
+			return "", fmt.Errorf("error parsing Discogs artist URL: %w", err)
+		}
+
+		discogsID := strings.TrimPrefix(discogsURL.Path, "/artist/")
+		discogsID = strings.TrimSuffix(discogsID, "/")
+
+		if discogsID == "" {
+			return "", fmt.Errorf("unrecognised Discogs artist URL format: %s",
+				artistURL.URL)
+		}
+
+		return discogsID, nil
+	}
+
+	return "", errNoDiscogsRel
+}
+
+// ArtistURL is a link to a page about an artist somewhere on the internet.
+type ArtistURL struct {
+	// Type is the MusicBrainz type of the relation such as "wikidata",
+	// "discogs" or "official homepage".
+	Type string
+
+	// URL is the address of the page.
+	URL string
+}
+
+// MusicBrainzArtistIDs returns the MusicBrainz IDs of the artists which match
+// `artist` with a score above MinScore. The best matches are first. Since the
+// Client is used for finding images ErrImageNotFound is returned when there are
+// no matches.
+func (c *Client) MusicBrainzArtistIDs(ctx context.Context, artist string) ([]string, error) {
+	return c.getMusicBrainzArtistID(ctx, artist)
+}
+
+// MusicBrainzArtistURLs returns the URL relations of the artist with MusicBrainz
+// ID `artistMBid`.
+func (c *Client) MusicBrainzArtistURLs(
+	ctx context.Context,
+	artistMBid string,
+) ([]ArtistURL, error) {
 	c.Lock()
 	defer c.Unlock()
 
@@ -165,7 +218,7 @@ func (c *Client) getDiscogsArtistID(
 	)
 	req, err := http.NewRequest(http.MethodGet, endpointURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("error creating MusicBrainz XML API req: %w", err)
+		return nil, fmt.Errorf("error creating MusicBrainz XML API req: %w", err)
 	}
 	req.Header.Set("User-Agent", c.useragent)
 
@@ -175,12 +228,12 @@ func (c *Client) getDiscogsArtistID(
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf(
+		return nil, fmt.Errorf(
 			"artist XML API (MusicBrainz) returned HTTP %d",
 			resp.StatusCode,
 		)
@@ -190,31 +243,18 @@ func (c *Client) getDiscogsArtistID(
 	dec := xml.NewDecoder(resp.Body)
 
 	if err := dec.Decode(&root); err != nil {
-		return "", fmt.Errorf("decoding MusicBrainz artist XML API response: %w", err)
+		return nil, fmt.Errorf("decoding MusicBrainz artist XML API response: %w", err)
 	}
 
-	for _, artistXML := range root.Artist.RelationsList.Relations {
-		if artistXML.Type != "discogs" {
-			continue
-		}
-
-		discogsURL, err := url.Parse(artistXML.Target)
-		if err != nil {
-			return "", fmt.Errorf("error parsing Discogs artist URL: %w", err)
-		}
-
-		discogsID := strings.TrimPrefix(discogsURL.Path, "/artist/")
-		discogsID = strings.TrimSuffix(discogsID, "/")
-
-		if discogsID == "" {
-			return "", fmt.Errorf("unrecognised Discogs artist URL format: %s",
-				artistXML.Target)
-		}
-
-		return discogsID, nil
+	var artistURLs []ArtistURL
+	for _, relation := range root.Artist.RelationsList.Relations {
+		artistURLs = append(artistURLs, ArtistURL{
+			Type: relation.Type,
+			URL:  strings.TrimSpace(relation.Target),
+		})
 	}
 
-	return "", errNoDiscogsRel
+	return artistURLs, nil
 }
 
 func (c *Client) getDiscogsArtistImage(
@@ -317,6 +357,31 @@ func (c *Client) downloadDiscogsImage(
 	return imgBytes, nil
 }
 
+// NewDiscogsProvider returns a Provider which finds artist images in the Discogs
+// database with the help of `c`. It does not have album artwork.
+func NewDiscogsProvider(c *Client) Provider {
+	return discogsProvider{client: c}
+}
+
+type discogsProvider struct {
+	client *Client
+}
+
+// Name implements Provider.
+func (p discogsProvider) Name() string {
+	return ProviderDiscogs
+}
+
+// GetFrontImage implements Finder. Album artwork is not taken from Discogs.
+func (p discogsProvider) GetFrontImage(context.Context, string, string) ([]byte, error) {
+	return nil, ErrImageNotFound
+}
+
+// GetArtistImage implements Finder.
+func (p discogsProvider) GetArtistImage(ctx context.Context, artist string) ([]byte, error) {
+	return p.client.GetArtistImage(ctx, artist)
+}
+
 // The following are structures only used to decode the XML response from MusicBrainz
 // API. And only the stuff we are interested and nothing more.
 type mbArtistSearchData struct {
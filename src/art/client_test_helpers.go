@@ -15,3 +15,18 @@ func (c *Client) SetMusicBrainzAPIURL(apiURL string) {
 func (c *Client) SetDiscogsAPIURL(apiURL string) {
 	c.discogsAPIHost = apiURL
 }
+
+// SetAPIURL sets the Deezer API URL. Only useful for tests.
+func (d *Deezer) SetAPIURL(apiURL string) {
+	d.apiHost = apiURL
+}
+
+// SetAPIURL sets the iTunes Search API URL. Only useful for tests.
+func (i *ITunes) SetAPIURL(apiURL string) {
+	i.apiHost = apiURL
+}
+
+// SetAPIURL sets the fanart.tv API URL. Only useful for tests.
+func (f *FanartTV) SetAPIURL(apiURL string) {
+	f.apiHost = apiURL
+}
@@ -0,0 +1,120 @@
+package art
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	deezerAlbumSearchEndpoint  = "%s/search/album"
+	deezerArtistSearchEndpoint = "%s/search/artist"
+)
+
+// Deezer is a Provider which finds album artwork and artist images using the
+// search in the public Deezer API. It does not require authentication. Only
+// results whose names match exactly, ignoring the case, are accepted. It is
+// safe for concurrent use.
+//
+// More info: https://developers.deezer.com/api/search
+type Deezer struct {
+	useragent  string
+	apiHost    string
+	maxResults int
+}
+
+// NewDeezer returns a Deezer provider which represents itself with `useragent`.
+func NewDeezer(useragent string) *Deezer {
+	return &Deezer{
+		useragent:  useragent,
+		apiHost:    "https://api.deezer.com",
+		maxResults: 10,
+	}
+}
+
+// Name implements Provider.
+func (d *Deezer) Name() string {
+	return ProviderDeezer
+}
+
+// GetFrontImage implements Finder.
+func (d *Deezer) GetFrontImage(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]byte, error) {
+	query := fmt.Sprintf(`artist:"%s" album:"%s"`, artist, album)
+
+	var result dzAlbumSearch
+	if err := getJSON(ctx, d.useragent, d.searchURL(deezerAlbumSearchEndpoint, query), &result); err != nil {
+		return nil, fmt.Errorf("searching Deezer albums: %w", err)
+	}
+
+	for _, found := range result.Data {
+		if !strings.EqualFold(found.Title, album) ||
+			!strings.EqualFold(found.Artist.Name, artist) ||
+			found.CoverXL == "" {
+			continue
+		}
+
+		return downloadImage(ctx, d.useragent, found.CoverXL)
+	}
+
+	return nil, ErrImageNotFound
+}
+
+// GetArtistImage implements Finder.
+func (d *Deezer) GetArtistImage(ctx context.Context, artist string) ([]byte, error) {
+	query := fmt.Sprintf(`artist:"%s"`, artist)
+
+	var result dzArtistSearch
+	if err := getJSON(ctx, d.useragent, d.searchURL(deezerArtistSearchEndpoint, query), &result); err != nil {
+		return nil, fmt.Errorf("searching Deezer artists: %w", err)
+	}
+
+	for _, found := range result.Data {
+		if !strings.EqualFold(found.Name, artist) || found.PictureXL == "" {
+			continue
+		}
+
+		// Artists without a picture have a generic placeholder with an empty
+		// hash in its path.
+		if strings.Contains(found.PictureXL, "/artist//") {
+			return nil, ErrImageNotFound
+		}
+
+		return downloadImage(ctx, d.useragent, found.PictureXL)
+	}
+
+	return nil, ErrImageNotFound
+}
+
+func (d *Deezer) searchURL(endpoint, query string) string {
+	values := url.Values{}
+	values.Set("q", query)
+	values.Set("limit", fmt.Sprint(d.maxResults))
+
+	return fmt.Sprintf(endpoint, d.apiHost) + "?" + values.Encode()
+}
+
+// The following are structures only used to decode the JSON responses from the
+// Deezer API. And only the stuff we are interested and nothing more.
+type dzAlbumSearch struct {
+	Data []dzAlbum `json:"data"`
+}
+
+type dzAlbum struct {
+	Title   string   `json:"title"`
+	CoverXL string   `json:"cover_xl"`
+	Artist  dzArtist `json:"artist"`
+}
+
+type dzArtistSearch struct {
+	Data []dzArtist `json:"data"`
+}
+
+type dzArtist struct {
+	Name      string `json:"name"`
+	PictureXL string `json:"picture_xl"`
+}
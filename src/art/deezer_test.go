@@ -0,0 +1,95 @@
+package art_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// TestDeezer checks that the Deezer provider searches for albums and artists and
+// downloads the images of the matching results.
+func TestDeezer(t *testing.T) {
+	ctx := context.Background()
+
+	var apiURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/album", func(w http.ResponseWriter, req *http.Request) {
+		if q := req.URL.Query().Get("q"); q != `artist:"Iron Maiden" album:"Killers"` {
+			fmt.Fprint(w, `{"data": []}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data": [
+			{"title": "Killers (Live)", "cover_xl": "%[1]s/wrong.jpg",
+				"artist": {"name": "Iron Maiden"}},
+			{"title": "killers", "cover_xl": "%[1]s/killers.jpg",
+				"artist": {"name": "IRON MAIDEN"}}
+		]}`, apiURL)
+	})
+	mux.HandleFunc("/search/artist", func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("q") {
+		case `artist:"Iron Maiden"`:
+			fmt.Fprintf(w, `{"data": [
+				{"name": "Iron Maiden", "picture_xl": "%s/maiden.jpg"}
+			]}`, apiURL)
+		case `artist:"Nobody"`:
+			fmt.Fprintf(w, `{"data": [
+				{"name": "Nobody", "picture_xl": "%s/images/artist//1000x1000.jpg"}
+			]}`, apiURL)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/killers.jpg", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "killers image")
+	})
+	mux.HandleFunc("/maiden.jpg", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "maiden image")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	apiURL = srv.URL
+
+	deezer := art.NewDeezer("euterpe/testing")
+	deezer.SetAPIURL(srv.URL)
+
+	if deezer.Name() != art.ProviderDeezer {
+		t.Errorf("wrong name: %s", deezer.Name())
+	}
+
+	img, err := deezer.GetFrontImage(ctx, "Iron Maiden", "Killers")
+	if err != nil {
+		t.Fatalf("getting album image: %s", err)
+	}
+	if !bytes.Equal(img, []byte("killers image")) {
+		t.Errorf("wrong album image: %q", img)
+	}
+
+	_, err = deezer.GetFrontImage(ctx, "Iron Maiden", "Senjutsu")
+	if !errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected not found for missing album but got: %v", err)
+	}
+
+	img, err = deezer.GetArtistImage(ctx, "Iron Maiden")
+	if err != nil {
+		t.Fatalf("getting artist image: %s", err)
+	}
+	if !bytes.Equal(img, []byte("maiden image")) {
+		t.Errorf("wrong artist image: %q", img)
+	}
+
+	_, err = deezer.GetArtistImage(ctx, "Nobody")
+	if !errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected not found for placeholder image but got: %v", err)
+	}
+
+	_, err = deezer.GetArtistImage(ctx, "Broken")
+	if err == nil || errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected an error for HTTP 500 but got: %v", err)
+	}
+}
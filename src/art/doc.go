@@ -8,10 +8,18 @@ for the corresponding album front art.
 
 Artist images are found using the MusicBrainz database and Discogs.
 
+Every source of images is a Provider and a Chain tries a list of them in order until
+one finds an image. Besides the Cover Art Archive and Discogs there are providers for
+Deezer, iTunes, fanart.tv and for URLs made from a user supplied template. Throttle
+limits how often requests are made to a particular provider.
+
 The following APIs are used to achieve this packages' objective:
 
  * MusicBrainz API: https://musicbrainz.org/doc/Development/XML_Web_Service/Version_2
  * Cover Art Archive: https://musicbrainz.org/doc/Cover_Art_Archive/
  * Discogs API: https://www.discogs.com/developers/
+ * Deezer API: https://developers.deezer.com/api
+ * iTunes Search API: https://developer.apple.com/library/archive/documentation/AudioVideo/Conceptual/iTuneSearchAPI/
+ * fanart.tv API: https://fanarttv.docs.apiary.io/
 */
 package art
@@ -0,0 +1,137 @@
+package art
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	fanartTVArtistEndpoint = "%s/v3/music/%s"
+	fanartTVAlbumEndpoint  = "%s/v3/music/albums/%s"
+)
+
+// ErrNoFanartTVKey signals that there is no fanart.tv API key in the
+// configuration. Without it no requests could be made.
+var ErrNoFanartTVKey = errors.New("fanart.tv API key is not configured")
+
+// FanartTV is a Provider which finds album artwork and artist images in the
+// fanart.tv database. It requires a personal API key. Images there are keyed
+// by MusicBrainz IDs so the MusicBrainz API is queried first using a Client.
+// It is safe for concurrent use.
+//
+// More info: https://fanarttv.docs.apiary.io/
+type FanartTV struct {
+	mb        *Client
+	useragent string
+	apiKey    string
+	apiHost   string
+}
+
+// NewFanartTV returns a FanartTV provider. It uses `mb` for finding the
+// MusicBrainz IDs and is throttled by it.
+func NewFanartTV(useragent, apiKey string, mb *Client) *FanartTV {
+	return &FanartTV{
+		mb:        mb,
+		useragent: useragent,
+		apiKey:    apiKey,
+		apiHost:   "https://webservice.fanart.tv",
+	}
+}
+
+// Name implements Provider.
+func (f *FanartTV) Name() string {
+	return ProviderFanartTV
+}
+
+// GetFrontImage implements Finder.
+func (f *FanartTV) GetFrontImage(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]byte, error) {
+	if f.apiKey == "" {
+		return nil, ErrNoFanartTVKey
+	}
+
+	groupIDs, err := f.mb.getMusicBrainzReleaseGroupIDs(ctx, artist, album)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, groupID := range groupIDs {
+		var result ftAlbums
+		err := getJSON(ctx, f.useragent, f.endpointURL(fanartTVAlbumEndpoint, groupID), &result)
+		if errors.Is(err, ErrImageNotFound) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("getting fanart.tv album: %w", err)
+		}
+
+		for _, image := range result.Albums[groupID].AlbumCover {
+			if image.URL == "" {
+				continue
+			}
+			return downloadImage(ctx, f.useragent, image.URL)
+		}
+	}
+
+	return nil, ErrImageNotFound
+}
+
+// GetArtistImage implements Finder.
+func (f *FanartTV) GetArtistImage(ctx context.Context, artist string) ([]byte, error) {
+	if f.apiKey == "" {
+		return nil, ErrNoFanartTVKey
+	}
+
+	artistIDs, err := f.mb.getMusicBrainzArtistID(ctx, artist)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, artistID := range artistIDs {
+		var result ftArtist
+		err := getJSON(ctx, f.useragent, f.endpointURL(fanartTVArtistEndpoint, artistID), &result)
+		if errors.Is(err, ErrImageNotFound) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("getting fanart.tv artist: %w", err)
+		}
+
+		for _, image := range result.ArtistThumb {
+			if image.URL == "" {
+				continue
+			}
+			return downloadImage(ctx, f.useragent, image.URL)
+		}
+	}
+
+	return nil, ErrImageNotFound
+}
+
+func (f *FanartTV) endpointURL(endpoint, mbid string) string {
+	values := url.Values{}
+	values.Set("api_key", f.apiKey)
+
+	return fmt.Sprintf(endpoint, f.apiHost, url.PathEscape(mbid)) + "?" + values.Encode()
+}
+
+// The following are structures only used to decode the JSON responses from the
+// fanart.tv API. And only the stuff we are interested and nothing more.
+type ftArtist struct {
+	ArtistThumb []ftImage `json:"artistthumb"`
+}
+
+type ftAlbums struct {
+	Albums map[string]ftAlbum `json:"albums"`
+}
+
+type ftAlbum struct {
+	AlbumCover []ftImage `json:"albumcover"`
+}
+
+type ftImage struct {
+	URL string `json:"url"`
+}
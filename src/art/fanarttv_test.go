@@ -0,0 +1,142 @@
+package art_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// TestFanartTV checks that the fanart.tv provider finds the MusicBrainz IDs first
+// and then uses them for getting images from fanart.tv.
+func TestFanartTV(t *testing.T) {
+	const (
+		apiKey       = "fanart-key"
+		artistMBID   = "ca891d65-d9b0-4258-89f7-e6ba29d83767"
+		releaseGroup = "c5d2cba0-e7b7-3bf3-a4d6-10e1dbf2cc4b"
+	)
+
+	ctx := context.Background()
+
+	mbrainz := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case "/ws/2/release/":
+				fmt.Fprintf(w, `
+					<metadata>
+					<release-list count="2" offset="0">
+						<release id="dd65beff-0bfb-4425-81af-ed4cb1945c7f" ns2:score="98">
+							<title>Killers</title>
+							<release-group id="%[1]s"></release-group>
+						</release>
+						<release id="6518fd52-58bf-44a3-8150-00e7c3ffcae5" ns2:score="99">
+							<title>Killers</title>
+							<release-group id="%[1]s"></release-group>
+						</release>
+					</release-list>
+					</metadata>
+				`, releaseGroup)
+			case "/ws/2/artist/":
+				fmt.Fprintf(w, `
+					<metadata>
+					<artist-list count="1" offset="0">
+						<artist id="%s" ns2:score="100">
+							<name>Iron Maiden</name>
+						</artist>
+					</artist-list>
+					</metadata>
+				`, artistMBID)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	))
+	defer mbrainz.Close()
+
+	var (
+		fanartURL      string
+		albumRequests  int
+		serverErrors   []string
+		fanartRequests int
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/music/albums/", func(w http.ResponseWriter, req *http.Request) {
+		albumRequests++
+		if req.URL.Path != "/v3/music/albums/"+releaseGroup {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"albums": {"%s": {"albumcover": [{"url": "%s/cover.jpg"}]}}}`,
+			releaseGroup, fanartURL)
+	})
+	mux.HandleFunc("/v3/music/"+artistMBID, func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `{"artistthumb": [{"url": ""}, {"url": "%s/thumb.jpg"}]}`,
+			fanartURL)
+	})
+	mux.HandleFunc("/cover.jpg", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "cover image")
+	})
+	mux.HandleFunc("/thumb.jpg", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "artist image")
+	})
+	fanart := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			fanartRequests++
+			if key := req.URL.Query().Get("api_key"); key != apiKey &&
+				req.URL.Path != "/cover.jpg" && req.URL.Path != "/thumb.jpg" {
+				serverErrors = append(serverErrors, "wrong api key: "+key)
+			}
+			mux.ServeHTTP(w, req)
+		},
+	))
+	defer fanart.Close()
+	fanartURL = fanart.URL
+
+	mbClient := art.NewClient("euterpe/testing", 0, "")
+	mbClient.SetMusicBrainzAPIURL(mbrainz.URL)
+
+	provider := art.NewFanartTV("euterpe/testing", apiKey, mbClient)
+	provider.SetAPIURL(fanart.URL)
+
+	if provider.Name() != art.ProviderFanartTV {
+		t.Errorf("wrong name: %s", provider.Name())
+	}
+
+	img, err := provider.GetFrontImage(ctx, "Iron Maiden", "Killers")
+	if err != nil {
+		t.Fatalf("getting album image: %s", err)
+	}
+	if !bytes.Equal(img, []byte("cover image")) {
+		t.Errorf("wrong album image: %q", img)
+	}
+	if albumRequests != 1 {
+		t.Errorf("expected one request for the release group but got %d", albumRequests)
+	}
+
+	img, err = provider.GetArtistImage(ctx, "Iron Maiden")
+	if err != nil {
+		t.Fatalf("getting artist image: %s", err)
+	}
+	if !bytes.Equal(img, []byte("artist image")) {
+		t.Errorf("wrong artist image: %q", img)
+	}
+
+	for _, serverErr := range serverErrors {
+		t.Errorf("server error: %s", serverErr)
+	}
+
+	requestsBefore := fanartRequests
+	noKey := art.NewFanartTV("euterpe/testing", "", mbClient)
+	noKey.SetAPIURL(fanart.URL)
+	if _, err := noKey.GetArtistImage(ctx, "Iron Maiden"); !errors.Is(err, art.ErrNoFanartTVKey) {
+		t.Errorf("expected missing key error but got: %v", err)
+	}
+	if fanartRequests != requestsBefore {
+		t.Errorf("requests were made without API key")
+	}
+}
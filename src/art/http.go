@@ -0,0 +1,67 @@
+package art
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/httpapi"
+)
+
+// maxImageSize is the biggest image which is downloaded. Bigger ones are
+// rejected with ErrImageTooBig.
+const maxImageSize = 1024 * 1024 * 2
+
+// getJSON makes a GET request to `apiURL` and decodes the JSON in the response
+// into `dst`. HTTP 404 is reported as ErrImageNotFound since then there is
+// nothing to get an image from.
+func getJSON(ctx context.Context, useragent, apiURL string, dst any) error {
+	err := httpapi.GetJSON(ctx, useragent, apiURL, dst)
+	if errors.Is(err, httpapi.ErrNotFound) {
+		return ErrImageNotFound
+	}
+	return err
+}
+
+// downloadImage returns the image at `imageURL`. HTTP 404 is reported as
+// ErrImageNotFound.
+func downloadImage(ctx context.Context, useragent, imageURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("malformed image URL (%s): %w", imageURL, err)
+	}
+	req.Header.Set("User-Agent", useragent)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request for image failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrImageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("image request returned HTTP %d", resp.StatusCode)
+	}
+
+	imgBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize))
+	if (err == nil || errors.Is(err, io.EOF)) && len(imgBytes) == maxImageSize {
+		return nil, ErrImageTooBig
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting image failed: %w", err)
+	}
+	if len(imgBytes) == 0 {
+		return nil, ErrImageNotFound
+	}
+
+	return imgBytes, nil
+}
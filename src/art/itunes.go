@@ -0,0 +1,96 @@
+package art
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const iTunesSearchEndpoint = "%s/search"
+
+// iTunesArtworkSize is the size of the artwork which is requested. The search
+// API returns URLs for 100x100 pixels images but bigger ones are available by
+// changing the size in the URL.
+const iTunesArtworkSize = "600x600bb"
+
+// ITunes is a Provider which finds album artwork using the iTunes Search API.
+// It does not require authentication but the API allows only about twenty
+// requests per minute. The API has no artist images. Only results whose names
+// match exactly, ignoring the case, are accepted. It is safe for concurrent use.
+//
+// More info: https://developer.apple.com/library/archive/documentation/AudioVideo/Conceptual/iTuneSearchAPI/
+type ITunes struct {
+	useragent  string
+	apiHost    string
+	maxResults int
+}
+
+// NewITunes returns an ITunes provider which represents itself with `useragent`.
+func NewITunes(useragent string) *ITunes {
+	return &ITunes{
+		useragent:  useragent,
+		apiHost:    "https://itunes.apple.com",
+		maxResults: 10,
+	}
+}
+
+// Name implements Provider.
+func (i *ITunes) Name() string {
+	return ProviderITunes
+}
+
+// GetFrontImage implements Finder.
+func (i *ITunes) GetFrontImage(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]byte, error) {
+	values := url.Values{}
+	values.Set("term", artist+" "+album)
+	values.Set("media", "music")
+	values.Set("entity", "album")
+	values.Set("limit", fmt.Sprint(i.maxResults))
+	searchURL := fmt.Sprintf(iTunesSearchEndpoint, i.apiHost) + "?" + values.Encode()
+
+	var result itSearch
+	if err := getJSON(ctx, i.useragent, searchURL, &result); err != nil {
+		return nil, fmt.Errorf("searching iTunes albums: %w", err)
+	}
+
+	for _, found := range result.Results {
+		if !strings.EqualFold(found.CollectionName, album) ||
+			!strings.EqualFold(found.ArtistName, artist) ||
+			found.ArtworkURL100 == "" {
+			continue
+		}
+
+		artworkURL := strings.Replace(
+			found.ArtworkURL100,
+			"100x100bb",
+			iTunesArtworkSize,
+			1,
+		)
+		return downloadImage(ctx, i.useragent, artworkURL)
+	}
+
+	return nil, ErrImageNotFound
+}
+
+// GetArtistImage implements Finder. The iTunes Search API does not have artist
+// images.
+func (i *ITunes) GetArtistImage(context.Context, string) ([]byte, error) {
+	return nil, ErrImageNotFound
+}
+
+// The following are structures only used to decode the JSON responses from the
+// iTunes Search API. And only the stuff we are interested and nothing more.
+type itSearch struct {
+	Results []itAlbum `json:"results"`
+}
+
+type itAlbum struct {
+	ArtistName     string `json:"artistName"`
+	CollectionName string `json:"collectionName"`
+	ArtworkURL100  string `json:"artworkUrl100"`
+}
@@ -0,0 +1,70 @@
+package art_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// TestITunes checks that the iTunes provider finds the matching album in the
+// search results and downloads a bigger version of its artwork.
+func TestITunes(t *testing.T) {
+	ctx := context.Background()
+
+	var apiURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if query.Get("entity") != "album" || query.Get("media") != "music" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if query.Get("term") != "Iron Maiden Killers" {
+			fmt.Fprint(w, `{"resultCount": 0, "results": []}`)
+			return
+		}
+		fmt.Fprintf(w, `{"resultCount": 2, "results": [
+			{"artistName": "Iron Maiden", "collectionName": "Killers (Remastered)",
+				"artworkUrl100": "%[1]s/remastered/100x100bb.jpg"},
+			{"artistName": "Iron Maiden", "collectionName": "Killers",
+				"artworkUrl100": "%[1]s/killers/100x100bb.jpg"}
+		]}`, apiURL)
+	})
+	mux.HandleFunc("/killers/600x600bb.jpg", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "big image")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	apiURL = srv.URL
+
+	itunes := art.NewITunes("euterpe/testing")
+	itunes.SetAPIURL(srv.URL)
+
+	if itunes.Name() != art.ProviderITunes {
+		t.Errorf("wrong name: %s", itunes.Name())
+	}
+
+	img, err := itunes.GetFrontImage(ctx, "Iron Maiden", "Killers")
+	if err != nil {
+		t.Fatalf("getting album image: %s", err)
+	}
+	if !bytes.Equal(img, []byte("big image")) {
+		t.Errorf("wrong album image: %q", img)
+	}
+
+	_, err = itunes.GetFrontImage(ctx, "Iron Maiden", "Senjutsu")
+	if !errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected not found for missing album but got: %v", err)
+	}
+
+	_, err = itunes.GetArtistImage(ctx, "Iron Maiden")
+	if !errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected not found for artist but got: %v", err)
+	}
+}
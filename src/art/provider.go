@@ -0,0 +1,239 @@
+package art
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Names of the providers in this package. They are the same as the names used for
+// configuring them.
+const (
+	ProviderCAA         = "caa"
+	ProviderDiscogs     = "discogs"
+	ProviderDeezer      = "deezer"
+	ProviderITunes      = "itunes"
+	ProviderFanartTV    = "fanarttv"
+	ProviderURLTemplate = "url_template"
+)
+
+//counterfeiter:generate . Provider
+
+// Provider is a single source of images. Providers which have images only for
+// albums or only for artists return ErrImageNotFound for the other kind.
+type Provider interface {
+	Finder
+
+	// Name returns a short name which identifies the provider. It is stored
+	// alongside the images found by it.
+	Name() string
+}
+
+// Image is an image found by some provider.
+type Image struct {
+	// Data is the encoded image.
+	Data []byte
+
+	// Provider is the name of the provider which found the image.
+	Provider string
+}
+
+// ProviderFinder is a Finder which reports which provider has found every image.
+type ProviderFinder interface {
+	Finder
+
+	// FindFrontImage is the same as GetFrontImage but the result includes
+	// the name of the provider.
+	FindFrontImage(ctx context.Context, artist, album string) (Image, error)
+
+	// FindArtistImage is the same as GetArtistImage but the result includes
+	// the name of the provider.
+	FindArtistImage(ctx context.Context, artist string) (Image, error)
+}
+
+// CandidateFinder is a Finder which could return the images found by every one of
+// its providers instead of only the first one.
+type CandidateFinder interface {
+	Finder
+
+	// FrontImages returns the front album artwork found by the providers with
+	// `names` or by all of them when no names are given. Providers which have
+	// not found an image are skipped.
+	FrontImages(ctx context.Context, artist, album string, names ...string) ([]Image, error)
+}
+
+// Chain tries a list of providers in order until one of them finds an image.
+// Errors from particular providers are logged and the next one is tried. It is
+// safe for concurrent use as long as its providers are.
+//
+// It implements ProviderFinder and CandidateFinder.
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain returns a Chain which tries `providers` in the given order.
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{
+		providers: providers,
+	}
+}
+
+// Providers returns the names of the providers in the chain in the order in which
+// they are tried.
+func (c *Chain) Providers() []string {
+	names := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// GetFrontImage implements Finder.
+func (c *Chain) GetFrontImage(ctx context.Context, artist, album string) ([]byte, error) {
+	img, err := c.FindFrontImage(ctx, artist, album)
+	return img.Data, err
+}
+
+// GetArtistImage implements Finder.
+func (c *Chain) GetArtistImage(ctx context.Context, artist string) ([]byte, error) {
+	img, err := c.FindArtistImage(ctx, artist)
+	return img.Data, err
+}
+
+// FindFrontImage implements ProviderFinder.
+func (c *Chain) FindFrontImage(ctx context.Context, artist, album string) (Image, error) {
+	return c.find(ctx, func(p Provider) ([]byte, error) {
+		return p.GetFrontImage(ctx, artist, album)
+	})
+}
+
+// FindArtistImage implements ProviderFinder.
+func (c *Chain) FindArtistImage(ctx context.Context, artist string) (Image, error) {
+	return c.find(ctx, func(p Provider) ([]byte, error) {
+		return p.GetArtistImage(ctx, artist)
+	})
+}
+
+// FrontImages implements CandidateFinder. The providers are asked one after
+// another in the order of the chain.
+func (c *Chain) FrontImages(
+	ctx context.Context,
+	artist, album string,
+	names ...string,
+) ([]Image, error) {
+	var images []Image
+	for _, provider := range c.providers {
+		if len(names) > 0 && !slices.Contains(names, provider.Name()) {
+			continue
+		}
+
+		data, err := provider.GetFrontImage(ctx, artist, album)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err == nil && len(data) > 0 {
+			images = append(images, Image{Data: data, Provider: provider.Name()})
+			continue
+		}
+
+		if err != nil &&
+			!errors.Is(err, ErrImageNotFound) &&
+			!errors.Is(err, ErrNoDiscogsAuth) {
+			log.Printf("Artwork provider %s error: %s\n", provider.Name(), err)
+		}
+	}
+
+	return images, nil
+}
+
+func (c *Chain) find(
+	ctx context.Context,
+	get func(Provider) ([]byte, error),
+) (Image, error) {
+	for _, provider := range c.providers {
+		data, err := get(provider)
+		if err == nil && len(data) > 0 {
+			return Image{Data: data, Provider: provider.Name()}, nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Image{}, ctxErr
+		}
+
+		if err != nil &&
+			!errors.Is(err, ErrImageNotFound) &&
+			!errors.Is(err, ErrNoDiscogsAuth) {
+			log.Printf("Artwork provider %s error: %s\n", provider.Name(), err)
+		}
+	}
+
+	return Image{}, ErrImageNotFound
+}
+
+// Throttle returns a Provider which makes no more than one request to `p` per
+// `delay`. Requests which have to wait are made one after another. When the
+// delay is not positive `p` is returned as is.
+func Throttle(p Provider, delay time.Duration) Provider {
+	if delay <= 0 {
+		return p
+	}
+
+	return &throttledProvider{
+		Provider: p,
+		delay:    delay,
+	}
+}
+
+type throttledProvider struct {
+	Provider
+
+	mu    sync.Mutex
+	delay time.Duration
+	next  time.Time
+}
+
+// GetFrontImage implements Finder.
+func (t *throttledProvider) GetFrontImage(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]byte, error) {
+	if err := t.wait(ctx); err != nil {
+		return nil, err
+	}
+	return t.Provider.GetFrontImage(ctx, artist, album)
+}
+
+// GetArtistImage implements Finder.
+func (t *throttledProvider) GetArtistImage(
+	ctx context.Context,
+	artist string,
+) ([]byte, error) {
+	if err := t.wait(ctx); err != nil {
+		return nil, err
+	}
+	return t.Provider.GetArtistImage(ctx, artist)
+}
+
+// wait blocks until a request could be made or until `ctx` is done.
+func (t *throttledProvider) wait(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if wait := time.Until(t.next); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	t.next = time.Now().Add(t.delay)
+	return nil
+}
@@ -0,0 +1,132 @@
+package art_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/art/artfakes"
+)
+
+// TestChain checks that the chain tries its providers in order and reports the
+// one which found the image.
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+
+	failing := &artfakes.FakeProvider{}
+	failing.NameReturns("failing")
+	failing.GetFrontImageReturns(nil, errors.New("service unavailable"))
+	failing.GetArtistImageReturns(nil, errors.New("service unavailable"))
+
+	albumsOnly := &artfakes.FakeProvider{}
+	albumsOnly.NameReturns("albums")
+	albumsOnly.GetFrontImageReturns([]byte("album image"), nil)
+	albumsOnly.GetArtistImageReturns(nil, art.ErrImageNotFound)
+
+	artists := &artfakes.FakeProvider{}
+	artists.NameReturns("artists")
+	artists.GetFrontImageReturns([]byte("other album image"), nil)
+	artists.GetArtistImageReturns([]byte("artist image"), nil)
+
+	chain := art.NewChain(failing, albumsOnly, artists)
+
+	if names := chain.Providers(); !slices.Equal(names, []string{"failing", "albums", "artists"}) {
+		t.Errorf("wrong providers order: %v", names)
+	}
+
+	img, err := chain.FindFrontImage(ctx, "Iron Maiden", "Killers")
+	if err != nil {
+		t.Fatalf("finding album image: %s", err)
+	}
+	if img.Provider != "albums" || !bytes.Equal(img.Data, []byte("album image")) {
+		t.Errorf("expected album image from albums but got %q from %s",
+			img.Data, img.Provider)
+	}
+	if artists.GetFrontImageCallCount() != 0 {
+		t.Errorf("providers after the one which found the image were called")
+	}
+
+	_, artist, album := failing.GetFrontImageArgsForCall(0)
+	if artist != "Iron Maiden" || album != "Killers" {
+		t.Errorf("wrong arguments for the provider: %s, %s", artist, album)
+	}
+
+	img, err = chain.FindArtistImage(ctx, "Iron Maiden")
+	if err != nil {
+		t.Fatalf("finding artist image: %s", err)
+	}
+	if img.Provider != "artists" || !bytes.Equal(img.Data, []byte("artist image")) {
+		t.Errorf("expected artist image from artists but got %q from %s",
+			img.Data, img.Provider)
+	}
+
+	images, err := chain.FrontImages(ctx, "Iron Maiden", "Killers")
+	if err != nil {
+		t.Fatalf("getting all album images: %s", err)
+	}
+	if len(images) != 2 || images[0].Provider != "albums" || images[1].Provider != "artists" {
+		t.Errorf("expected images from albums and artists but got %+v", images)
+	}
+
+	images, err = chain.FrontImages(ctx, "Iron Maiden", "Killers", "artists")
+	if err != nil {
+		t.Fatalf("getting album images from one provider: %s", err)
+	}
+	if len(images) != 1 || !bytes.Equal(images[0].Data, []byte("other album image")) {
+		t.Errorf("expected only the image from artists but got %+v", images)
+	}
+
+	empty := art.NewChain(failing)
+	if _, err := empty.GetFrontImage(ctx, "Iron Maiden", "Killers"); !errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected image not found but got: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := empty.GetArtistImage(cancelled, "Iron Maiden"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context error but got: %v", err)
+	}
+}
+
+// TestThrottle makes sure that throttled providers are not called more often than
+// the delay allows.
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+
+	provider := &artfakes.FakeProvider{}
+	provider.NameReturns("fake")
+	provider.GetFrontImageReturns([]byte("image"), nil)
+
+	if art.Throttle(provider, 0) != art.Provider(provider) {
+		t.Errorf("expected the same provider when there is no delay")
+	}
+
+	const delay = 50 * time.Millisecond
+	throttled := art.Throttle(provider, delay)
+	if throttled.Name() != "fake" {
+		t.Errorf("throttled provider has wrong name %s", throttled.Name())
+	}
+
+	start := time.Now()
+	for range 3 {
+		if _, err := throttled.GetFrontImage(ctx, "artist", "album"); err != nil {
+			t.Fatalf("getting image: %s", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*delay {
+		t.Errorf("three requests were made in %s", elapsed)
+	}
+
+	waiting, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := throttled.GetArtistImage(waiting, "artist"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context error while waiting but got: %v", err)
+	}
+	if provider.GetArtistImageCallCount() != 0 {
+		t.Errorf("provider was called after the context was cancelled")
+	}
+}
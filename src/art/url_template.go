@@ -0,0 +1,69 @@
+package art
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// URLTemplate is a Provider which downloads images from URLs made by replacing
+// the placeholders {artist} and {album} in user supplied templates. The values
+// are escaped so that they could be used both in the path and in the query. The
+// URLs must return the image itself. It is safe for concurrent use.
+//
+// For example "https://images.example.com/{artist}/{album}/cover.jpg".
+type URLTemplate struct {
+	useragent string
+	albumURL  string
+	artistURL string
+}
+
+// NewURLTemplate returns a URLTemplate provider. An empty template means that
+// the provider has no images of this kind.
+func NewURLTemplate(useragent, albumURL, artistURL string) *URLTemplate {
+	return &URLTemplate{
+		useragent: useragent,
+		albumURL:  albumURL,
+		artistURL: artistURL,
+	}
+}
+
+// Name implements Provider.
+func (u *URLTemplate) Name() string {
+	return ProviderURLTemplate
+}
+
+// GetFrontImage implements Finder.
+func (u *URLTemplate) GetFrontImage(
+	ctx context.Context,
+	artist,
+	album string,
+) ([]byte, error) {
+	if u.albumURL == "" {
+		return nil, ErrImageNotFound
+	}
+
+	return downloadImage(ctx, u.useragent, u.expand(u.albumURL, artist, album))
+}
+
+// GetArtistImage implements Finder.
+func (u *URLTemplate) GetArtistImage(ctx context.Context, artist string) ([]byte, error) {
+	if u.artistURL == "" {
+		return nil, ErrImageNotFound
+	}
+
+	return downloadImage(ctx, u.useragent, u.expand(u.artistURL, artist, ""))
+}
+
+func (u *URLTemplate) expand(template, artist, album string) string {
+	return strings.NewReplacer(
+		"{artist}", escapeTemplateValue(artist),
+		"{album}", escapeTemplateValue(album),
+	).Replace(template)
+}
+
+// escapeTemplateValue escapes `value` so that it is safe both in a URL path and
+// in a query. Spaces are %20 since "+" means space only in queries.
+func escapeTemplateValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
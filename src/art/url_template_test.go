@@ -0,0 +1,73 @@
+package art_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// TestURLTemplate checks that the placeholders in the templates are replaced
+// with escaped values and that the image at the resulting URL is returned.
+func TestURLTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			requested = append(requested, req.URL.RequestURI())
+			if req.URL.Query().Get("artist") == "AC/DC & Friends" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, "image")
+		},
+	))
+	defer srv.Close()
+
+	provider := art.NewURLTemplate(
+		"euterpe/testing",
+		srv.URL+"/covers/{artist}/{album}.jpg",
+		srv.URL+"/artist?artist={artist}",
+	)
+
+	if provider.Name() != art.ProviderURLTemplate {
+		t.Errorf("wrong name: %s", provider.Name())
+	}
+
+	img, err := provider.GetFrontImage(ctx, "Iron Maiden", "Fear of the Dark?")
+	if err != nil {
+		t.Fatalf("getting album image: %s", err)
+	}
+	if !bytes.Equal(img, []byte("image")) {
+		t.Errorf("wrong album image: %q", img)
+	}
+
+	_, err = provider.GetArtistImage(ctx, "AC/DC & Friends")
+	if !errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected not found for HTTP 404 but got: %v", err)
+	}
+
+	expected := []string{
+		"/covers/Iron%20Maiden/Fear%20of%20the%20Dark%3F.jpg",
+		"/artist?artist=AC%2FDC%20%26%20Friends",
+	}
+	if len(requested) != len(expected) {
+		t.Fatalf("expected requests %v but got %v", expected, requested)
+	}
+	for i := range expected {
+		if requested[i] != expected[i] {
+			t.Errorf("expected request %s but got %s", expected[i], requested[i])
+		}
+	}
+
+	albumsOnly := art.NewURLTemplate("euterpe/testing", srv.URL+"/{album}", "")
+	if _, err := albumsOnly.GetArtistImage(ctx, "Iron Maiden"); !errors.Is(err, art.ErrImageNotFound) {
+		t.Errorf("expected not found without artist template but got: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+// Package artistinfo finds information about artists on the internet. This is their
+// biographies, their MusicBrainz IDs, links to pages about them and similar artists.
+//
+// Every source of information is a Provider and a Chain asks all of them in order.
+// Every provider adds what it knows to what the previous ones have found. Some of
+// them need what was found before them. Wikipedia biographies are found with the
+// Wikidata links from MusicBrainz for example.
+//
+// The following APIs are used:
+//
+//   - MusicBrainz API: https://musicbrainz.org/doc/MusicBrainz_API
+//   - Wikidata: https://www.wikidata.org/wiki/Wikidata:Data_access
+//   - Wikipedia REST API: https://en.wikipedia.org/api/rest_v1/
+//   - Last.fm API: https://www.last.fm/api
+package artistinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// Names of the providers in this package. They are the same as the names used for
+// configuring them.
+const (
+	ProviderMusicBrainz = "musicbrainz"
+	ProviderWikipedia   = "wikipedia"
+	ProviderLastFM      = "lastfm"
+)
+
+// ErrNotFound is returned when nothing is known about an artist.
+var ErrNotFound = errors.New("artist info not found")
+
+// Info is what is known about an artist.
+type Info struct {
+	// MusicBrainzID is the ID of the artist in the MusicBrainz database.
+	MusicBrainzID string
+
+	// Biography is a short plain text description of the artist.
+	Biography string
+
+	// LastFMURL is the page of the artist on Last.fm or a compatible service.
+	LastFMURL string
+
+	// Links are pages about the artist such as their official homepage or
+	// their Wikipedia article.
+	Links []Link
+
+	// Similar are other artists which are similar to this one. The most
+	// similar are first.
+	Similar []SimilarArtist
+}
+
+// IsEmpty returns true when nothing at all is known about the artist.
+func (i Info) IsEmpty() bool {
+	return i.MusicBrainzID == "" && i.Biography == "" && i.LastFMURL == "" &&
+		len(i.Links) == 0 && len(i.Similar) == 0
+}
+
+// addLink appends a link to the info unless there is one with the same URL.
+func (i *Info) addLink(link Link) {
+	if link.URL == "" || slices.ContainsFunc(i.Links, func(l Link) bool {
+		return l.URL == link.URL
+	}) {
+		return
+	}
+	i.Links = append(i.Links, link)
+}
+
+// Link is a page about an artist somewhere on the internet.
+type Link struct {
+	// Type is the kind of the page. It is one of the MusicBrainz URL relation
+	// types such as "wikidata", "wikipedia", "discogs" or "official homepage".
+	Type string `json:"type"`
+
+	// URL is the address of the page.
+	URL string `json:"url"`
+}
+
+// SimilarArtist is an artist which is similar to another one.
+type SimilarArtist struct {
+	Name          string `json:"name"`
+	MusicBrainzID string `json:"musicbrainz_id,omitempty"`
+}
+
+//counterfeiter:generate . Finder
+
+// Finder defines a type which is capable of finding information about artists.
+type Finder interface {
+	// GetArtistInfo returns what is known about `artist`. ErrNotFound is
+	// returned when nothing is known.
+	GetArtistInfo(ctx context.Context, artist string) (Info, error)
+}
+
+//counterfeiter:generate . Provider
+
+// Provider is a single source of information about artists.
+type Provider interface {
+	// Name returns a short name which identifies the provider.
+	Name() string
+
+	// AddArtistInfo adds what the provider knows about `artist` to `info`. It
+	// may use what is in `info` already. Only empty fields of `info` are set
+	// while links and similar artists are appended. ErrNotFound is returned
+	// when the provider knows nothing about the artist.
+	AddArtistInfo(ctx context.Context, artist string, info *Info) error
+}
+
+// Chain asks a list of providers in order for information about artists. Every one
+// of them adds to what the previous ones have found. Errors from particular
+// providers are logged and the next one is asked. When nothing is found ErrNotFound
+// is returned only if no provider has failed. Otherwise the last error is. It is
+// safe for concurrent use as long as its providers are.
+//
+// It implements Finder.
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain returns a Chain which asks `providers` in the given order.
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{
+		providers: providers,
+	}
+}
+
+// Providers returns the names of the providers in the chain in the order in which
+// they are asked.
+func (c *Chain) Providers() []string {
+	names := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// GetArtistInfo implements Finder.
+func (c *Chain) GetArtistInfo(ctx context.Context, artist string) (Info, error) {
+	var (
+		info    Info
+		lastErr error
+	)
+	for _, provider := range c.providers {
+		err := provider.AddArtistInfo(ctx, artist, &info)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Info{}, ctxErr
+		}
+
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("Artist info provider %s error: %s\n", provider.Name(), err)
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+		}
+	}
+
+	// Nothing is known about the artist only when no provider has failed.
+	// Otherwise the information could be missing because of the errors.
+	if info.IsEmpty() && lastErr != nil {
+		return Info{}, lastErr
+	} else if info.IsEmpty() {
+		return Info{}, ErrNotFound
+	}
+
+	return info, nil
+}
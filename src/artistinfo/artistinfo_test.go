@@ -0,0 +1,66 @@
+package artistinfo_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+	"github.com/ironsmile/euterpe/src/artistinfo/artistinfofakes"
+)
+
+// TestChain checks that every provider in the chain adds to what the previous ones
+// have found, that failing providers do not stop the chain and that their errors
+// are not mistaken for nothing being found.
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+
+	ids := &artistinfofakes.FakeProvider{}
+	ids.NameReturns("ids")
+	ids.AddArtistInfoStub = func(_ context.Context, _ string, info *artistinfo.Info) error {
+		info.MusicBrainzID = "ca891d65-d9b0-4258-89f7-e6ba29d83767"
+		return nil
+	}
+
+	failing := &artistinfofakes.FakeProvider{}
+	failing.NameReturns("failing")
+	failing.AddArtistInfoReturns(errors.New("service unavailable"))
+
+	bios := &artistinfofakes.FakeProvider{}
+	bios.NameReturns("bios")
+	bios.AddArtistInfoStub = func(_ context.Context, _ string, info *artistinfo.Info) error {
+		if info.MusicBrainzID == "" {
+			return artistinfo.ErrNotFound
+		}
+		info.Biography = "An English heavy metal band."
+		return nil
+	}
+
+	chain := artistinfo.NewChain(ids, failing, bios)
+	if names := chain.Providers(); !slices.Equal(names, []string{"ids", "failing", "bios"}) {
+		t.Errorf("wrong providers order: %v", names)
+	}
+
+	info, err := chain.GetArtistInfo(ctx, "Iron Maiden")
+	if err != nil {
+		t.Fatalf("getting artist info: %s", err)
+	}
+	if info.MusicBrainzID != "ca891d65-d9b0-4258-89f7-e6ba29d83767" ||
+		info.Biography != "An English heavy metal band." {
+		t.Errorf("info from all providers was expected but got %+v", info)
+	}
+	if _, artist, _ := failing.AddArtistInfoArgsForCall(0); artist != "Iron Maiden" {
+		t.Errorf("wrong artist for the provider: %s", artist)
+	}
+
+	_, err = artistinfo.NewChain(bios).GetArtistInfo(ctx, "Iron Maiden")
+	if !errors.Is(err, artistinfo.ErrNotFound) {
+		t.Errorf("expected not found error when nothing is found but got %v", err)
+	}
+
+	_, err = artistinfo.NewChain(failing, bios).GetArtistInfo(ctx, "Iron Maiden")
+	if err == nil || errors.Is(err, artistinfo.ErrNotFound) {
+		t.Errorf("expected the provider error when nothing is found but got %v", err)
+	}
+}
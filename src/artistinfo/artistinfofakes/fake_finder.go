@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package artistinfofakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+)
+
+type FakeFinder struct {
+	GetArtistInfoStub        func(context.Context, string) (artistinfo.Info, error)
+	getArtistInfoMutex       sync.RWMutex
+	getArtistInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getArtistInfoReturns struct {
+		result1 artistinfo.Info
+		result2 error
+	}
+	getArtistInfoReturnsOnCall map[int]struct {
+		result1 artistinfo.Info
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeFinder) GetArtistInfo(arg1 context.Context, arg2 string) (artistinfo.Info, error) {
+	fake.getArtistInfoMutex.Lock()
+	ret, specificReturn := fake.getArtistInfoReturnsOnCall[len(fake.getArtistInfoArgsForCall)]
+	fake.getArtistInfoArgsForCall = append(fake.getArtistInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetArtistInfoStub
+	fakeReturns := fake.getArtistInfoReturns
+	fake.recordInvocation("GetArtistInfo", []interface{}{arg1, arg2})
+	fake.getArtistInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFinder) GetArtistInfoCallCount() int {
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	return len(fake.getArtistInfoArgsForCall)
+}
+
+func (fake *FakeFinder) GetArtistInfoCalls(stub func(context.Context, string) (artistinfo.Info, error)) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = stub
+}
+
+func (fake *FakeFinder) GetArtistInfoArgsForCall(i int) (context.Context, string) {
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	argsForCall := fake.getArtistInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFinder) GetArtistInfoReturns(result1 artistinfo.Info, result2 error) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = nil
+	fake.getArtistInfoReturns = struct {
+		result1 artistinfo.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFinder) GetArtistInfoReturnsOnCall(i int, result1 artistinfo.Info, result2 error) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = nil
+	if fake.getArtistInfoReturnsOnCall == nil {
+		fake.getArtistInfoReturnsOnCall = make(map[int]struct {
+			result1 artistinfo.Info
+			result2 error
+		})
+	}
+	fake.getArtistInfoReturnsOnCall[i] = struct {
+		result1 artistinfo.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFinder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeFinder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ artistinfo.Finder = new(FakeFinder)
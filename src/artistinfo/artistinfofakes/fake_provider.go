@@ -0,0 +1,181 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package artistinfofakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+)
+
+type FakeProvider struct {
+	AddArtistInfoStub        func(context.Context, string, *artistinfo.Info) error
+	addArtistInfoMutex       sync.RWMutex
+	addArtistInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *artistinfo.Info
+	}
+	addArtistInfoReturns struct {
+		result1 error
+	}
+	addArtistInfoReturnsOnCall map[int]struct {
+		result1 error
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeProvider) AddArtistInfo(arg1 context.Context, arg2 string, arg3 *artistinfo.Info) error {
+	fake.addArtistInfoMutex.Lock()
+	ret, specificReturn := fake.addArtistInfoReturnsOnCall[len(fake.addArtistInfoArgsForCall)]
+	fake.addArtistInfoArgsForCall = append(fake.addArtistInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *artistinfo.Info
+	}{arg1, arg2, arg3})
+	stub := fake.AddArtistInfoStub
+	fakeReturns := fake.addArtistInfoReturns
+	fake.recordInvocation("AddArtistInfo", []interface{}{arg1, arg2, arg3})
+	fake.addArtistInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) AddArtistInfoCallCount() int {
+	fake.addArtistInfoMutex.RLock()
+	defer fake.addArtistInfoMutex.RUnlock()
+	return len(fake.addArtistInfoArgsForCall)
+}
+
+func (fake *FakeProvider) AddArtistInfoCalls(stub func(context.Context, string, *artistinfo.Info) error) {
+	fake.addArtistInfoMutex.Lock()
+	defer fake.addArtistInfoMutex.Unlock()
+	fake.AddArtistInfoStub = stub
+}
+
+func (fake *FakeProvider) AddArtistInfoArgsForCall(i int) (context.Context, string, *artistinfo.Info) {
+	fake.addArtistInfoMutex.RLock()
+	defer fake.addArtistInfoMutex.RUnlock()
+	argsForCall := fake.addArtistInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeProvider) AddArtistInfoReturns(result1 error) {
+	fake.addArtistInfoMutex.Lock()
+	defer fake.addArtistInfoMutex.Unlock()
+	fake.AddArtistInfoStub = nil
+	fake.addArtistInfoReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) AddArtistInfoReturnsOnCall(i int, result1 error) {
+	fake.addArtistInfoMutex.Lock()
+	defer fake.addArtistInfoMutex.Unlock()
+	fake.AddArtistInfoStub = nil
+	if fake.addArtistInfoReturnsOnCall == nil {
+		fake.addArtistInfoReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addArtistInfoReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeProvider) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeProvider) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeProvider) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeProvider) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.addArtistInfoMutex.RLock()
+	defer fake.addArtistInfoMutex.RUnlock()
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeProvider) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ artistinfo.Provider = new(FakeProvider)
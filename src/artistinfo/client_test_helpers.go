@@ -0,0 +1,7 @@
+package artistinfo
+
+// SetAPIURLs sets the Wikidata and Wikipedia API URLs. Only useful for tests.
+func (w *Wikipedia) SetAPIURLs(wikidataURL, wikipediaURL string) {
+	w.wikidataAPIHost = wikidataURL
+	w.wikipediaAPIHost = wikipediaURL
+}
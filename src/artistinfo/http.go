@@ -0,0 +1,18 @@
+package artistinfo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ironsmile/euterpe/src/httpapi"
+)
+
+// getJSON makes a GET request to `apiURL` and decodes the JSON in the response
+// into `dst`. HTTP 404 is reported as ErrNotFound.
+func getJSON(ctx context.Context, useragent, apiURL string, dst any) error {
+	err := httpapi.GetJSON(ctx, useragent, apiURL, dst)
+	if errors.Is(err, httpapi.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package artistinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/httpapi"
+)
+
+// lastFMArtistNotFound is the code of the Last.fm API error for unknown artists.
+const lastFMArtistNotFound = 6
+
+// ErrNoLastFMKey signals that there is no Last.fm API key in the configuration.
+// Without it no requests could be made.
+var ErrNoLastFMKey = errors.New("Last.fm API key is not configured")
+
+var (
+	htmlTags       = regexp.MustCompile(`<[^>]*>`)
+	lastFMReadMore = regexp.MustCompile(`(?s)<a [^>]*>Read more on Last\.fm</a>\.?`)
+)
+
+// LastFM is a Provider which finds the Last.fm page of artists and similar artists
+// using the Last.fm API. It works with any service which has a compatible API, such
+// as Libre.fm. A Last.fm biography is used only when no other provider has found
+// one. It requires an API key. It is safe for concurrent use.
+//
+// More info: https://www.last.fm/api
+type LastFM struct {
+	useragent string
+	apiKey    string
+	apiURL    string
+
+	// similarLimit is the maximum number of similar artists which are added.
+	similarLimit int
+}
+
+// NewLastFM returns a LastFM provider which makes requests to the API at `apiURL`
+// with `apiKey`. When `apiURL` is empty the Last.fm API is used.
+func NewLastFM(useragent, apiURL, apiKey string) *LastFM {
+	if apiURL == "" {
+		apiURL = "https://ws.audioscrobbler.com/2.0/"
+	}
+
+	return &LastFM{
+		useragent:    useragent,
+		apiKey:       apiKey,
+		apiURL:       apiURL,
+		similarLimit: 50,
+	}
+}
+
+// Name implements Provider.
+func (l *LastFM) Name() string {
+	return ProviderLastFM
+}
+
+// AddArtistInfo implements Provider.
+func (l *LastFM) AddArtistInfo(ctx context.Context, artist string, info *Info) error {
+	if l.apiKey == "" {
+		return ErrNoLastFMKey
+	}
+
+	var artistInfo lfmArtistInfo
+	if err := l.call(ctx, "artist.getinfo", artist, nil, &artistInfo); err != nil {
+		return fmt.Errorf("getting Last.fm artist: %w", err)
+	}
+
+	if info.MusicBrainzID == "" {
+		info.MusicBrainzID = artistInfo.Artist.MBID
+	}
+	if info.LastFMURL == "" {
+		info.LastFMURL = artistInfo.Artist.URL
+	}
+	if info.Biography == "" {
+		bio := lastFMReadMore.ReplaceAllString(artistInfo.Artist.Bio.Summary, "")
+		bio = html.UnescapeString(htmlTags.ReplaceAllString(bio, ""))
+		info.Biography = strings.TrimSpace(bio)
+	}
+
+	var similar lfmSimilarArtists
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(l.similarLimit))
+	if err := l.call(ctx, "artist.getsimilar", artist, params, &similar); err != nil {
+		return fmt.Errorf("getting Last.fm similar artists: %w", err)
+	}
+
+	for _, similarArtist := range similar.SimilarArtists.Artists {
+		if similarArtist.Name == "" {
+			continue
+		}
+
+		info.Similar = append(info.Similar, SimilarArtist{
+			Name:          similarArtist.Name,
+			MusicBrainzID: similarArtist.MBID,
+		})
+	}
+
+	return nil
+}
+
+// call calls the API `method` for `artist` and decodes the response into `dst`.
+// Unknown artists are reported as ErrNotFound.
+func (l *LastFM) call(
+	ctx context.Context,
+	method, artist string,
+	params url.Values,
+	dst any,
+) error {
+	values := url.Values{}
+	for key, value := range params {
+		values[key] = value
+	}
+	values.Set("method", method)
+	values.Set("artist", artist)
+	values.Set("autocorrect", "1")
+	values.Set("api_key", l.apiKey)
+	values.Set("format", "json")
+
+	var raw json.RawMessage
+	status, err := httpapi.FetchJSON(ctx, l.useragent, l.apiURL+"?"+values.Encode(), &raw)
+	if err != nil {
+		return err
+	}
+
+	// Errors are returned as JSON objects, sometimes even with HTTP 200.
+	var apiErr lfmError
+	_ = json.Unmarshal(raw, &apiErr)
+	if apiErr.Error == lastFMArtistNotFound {
+		return ErrNotFound
+	} else if apiErr.Error != 0 {
+		return fmt.Errorf("API error %d: %s", apiErr.Error, apiErr.Message)
+	} else if status != http.StatusOK {
+		return fmt.Errorf("API returned HTTP %d", status)
+	}
+
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("decoding JSON API response: %w", err)
+	}
+
+	return nil
+}
+
+// The following are structures only used to decode the JSON responses from the
+// Last.fm API. And only the stuff we are interested and nothing more.
+type lfmError struct {
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+}
+
+type lfmArtistInfo struct {
+	Artist struct {
+		MBID string `json:"mbid"`
+		URL  string `json:"url"`
+		Bio  struct {
+			Summary string `json:"summary"`
+		} `json:"bio"`
+	} `json:"artist"`
+}
+
+type lfmSimilarArtists struct {
+	SimilarArtists struct {
+		Artists []lfmArtist `json:"artist"`
+	} `json:"similarartists"`
+}
+
+type lfmArtist struct {
+	Name string `json:"name"`
+	MBID string `json:"mbid"`
+}
@@ -0,0 +1,105 @@
+package artistinfo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+)
+
+// TestLastFM checks that the Last.fm page, biography and similar artists are found
+// and that errors returned by the API are recognised.
+func TestLastFM(t *testing.T) {
+	const apiKey = "lastfm-key"
+
+	lastfm := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			query := req.URL.Query()
+			if query.Get("api_key") != apiKey || query.Get("format") != "json" {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"error": 10, "message": "Invalid API key"}`)
+				return
+			}
+
+			if query.Get("artist") != "Iron Maiden" {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error": 6, "message": "The artist you supplied could not be found"}`)
+				return
+			}
+
+			switch query.Get("method") {
+			case "artist.getinfo":
+				fmt.Fprint(w, `{"artist": {
+					"name": "Iron Maiden",
+					"mbid": "ca891d65-d9b0-4258-89f7-e6ba29d83767",
+					"url": "https://www.last.fm/music/Iron+Maiden",
+					"bio": {"summary": "Iron Maiden are a band &amp; more. <a href=\"https://www.last.fm/music/Iron+Maiden\">Read more on Last.fm</a>"}
+				}}`)
+			case "artist.getsimilar":
+				if query.Get("limit") != "50" {
+					t.Errorf("unexpected limit of similar artists: %s", query.Get("limit"))
+				}
+				fmt.Fprint(w, `{"similarartists": {"artist": [
+					{"name": "Judas Priest", "mbid": "6b335658-22c8-485d-93de-0bc29a1d0349"},
+					{"name": ""},
+					{"name": "Saxon", "mbid": ""}
+				]}}`)
+			default:
+				fmt.Fprint(w, `{"error": 3, "message": "Invalid Method"}`)
+			}
+		},
+	))
+	defer lastfm.Close()
+
+	ctx := context.Background()
+	provider := artistinfo.NewLastFM("euterpe/testing", lastfm.URL, apiKey)
+
+	info := artistinfo.Info{MusicBrainzID: "from-musicbrainz"}
+	if err := provider.AddArtistInfo(ctx, "Iron Maiden", &info); err != nil {
+		t.Fatalf("adding artist info: %s", err)
+	}
+
+	if info.MusicBrainzID != "from-musicbrainz" {
+		t.Errorf("the MusicBrainz ID found before was changed to %s", info.MusicBrainzID)
+	}
+	if info.LastFMURL != "https://www.last.fm/music/Iron+Maiden" {
+		t.Errorf("unexpected Last.fm URL: %s", info.LastFMURL)
+	}
+	if info.Biography != "Iron Maiden are a band & more." {
+		t.Errorf("unexpected biography: %q", info.Biography)
+	}
+
+	expected := []artistinfo.SimilarArtist{
+		{Name: "Judas Priest", MusicBrainzID: "6b335658-22c8-485d-93de-0bc29a1d0349"},
+		{Name: "Saxon"},
+	}
+	if len(info.Similar) != len(expected) {
+		t.Fatalf("expected similar artists %+v but got %+v", expected, info.Similar)
+	}
+	for i, similar := range info.Similar {
+		if similar != expected[i] {
+			t.Errorf("similar artist %d: expected %+v but got %+v", i, expected[i], similar)
+		}
+	}
+
+	err := provider.AddArtistInfo(ctx, "Unknown", &artistinfo.Info{})
+	if !errors.Is(err, artistinfo.ErrNotFound) {
+		t.Errorf("expected not found error for unknown artist but got %v", err)
+	}
+
+	wrongKey := artistinfo.NewLastFM("euterpe/testing", lastfm.URL, "wrong-key")
+	err = wrongKey.AddArtistInfo(ctx, "Iron Maiden", &artistinfo.Info{})
+	if err == nil || errors.Is(err, artistinfo.ErrNotFound) {
+		t.Errorf("expected an error for a wrong API key but got %v", err)
+	}
+
+	noKey := artistinfo.NewLastFM("euterpe/testing", lastfm.URL, "")
+	err = noKey.AddArtistInfo(ctx, "Iron Maiden", &artistinfo.Info{})
+	if !errors.Is(err, artistinfo.ErrNoLastFMKey) {
+		t.Errorf("expected no API key error but got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package artistinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// MusicBrainz is a Provider which finds the MusicBrainz ID of artists and the links
+// to pages about them from their URL relations in the MusicBrainz database. It
+// should be first in a Chain since other providers use what it has found.
+type MusicBrainz struct {
+	client *art.Client
+}
+
+// NewMusicBrainz returns a MusicBrainz provider which makes its requests with
+// `client`. This way they are throttled together with the requests for images.
+func NewMusicBrainz(client *art.Client) *MusicBrainz {
+	return &MusicBrainz{
+		client: client,
+	}
+}
+
+// Name implements Provider.
+func (m *MusicBrainz) Name() string {
+	return ProviderMusicBrainz
+}
+
+// AddArtistInfo implements Provider. The best match for `artist` is used.
+func (m *MusicBrainz) AddArtistInfo(ctx context.Context, artist string, info *Info) error {
+	mbID := info.MusicBrainzID
+	if mbID == "" {
+		mbIDs, err := m.client.MusicBrainzArtistIDs(ctx, artist)
+		if errors.Is(err, art.ErrImageNotFound) {
+			return ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("searching MusicBrainz artists: %w", err)
+		}
+		mbID = mbIDs[0]
+	}
+
+	artistURLs, err := m.client.MusicBrainzArtistURLs(ctx, mbID)
+	if err != nil {
+		return fmt.Errorf("getting MusicBrainz artist URLs: %w", err)
+	}
+
+	info.MusicBrainzID = mbID
+	for _, artistURL := range artistURLs {
+		info.addLink(Link{Type: artistURL.Type, URL: artistURL.URL})
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package artistinfo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/artistinfo"
+)
+
+// TestMusicBrainz checks that the MusicBrainz ID of the best match is found and
+// that its URL relations are added as links.
+func TestMusicBrainz(t *testing.T) {
+	const artistMBID = "ca891d65-d9b0-4258-89f7-e6ba29d83767"
+
+	mbrainz := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			switch {
+			case req.URL.Path == "/ws/2/artist/" &&
+				req.URL.Query().Get("query") == "artist:Iron Maiden":
+				fmt.Fprintf(w, `
+					<metadata>
+					<artist-list count="2" offset="0">
+						<artist id="%s" ns2:score="100">
+							<name>Iron Maiden</name>
+						</artist>
+						<artist id="5fa4f0d5-0cf6-4fd4-8c8b-fb1aa7b0b6ac" ns2:score="96">
+							<name>Iron Maidens</name>
+						</artist>
+					</artist-list>
+					</metadata>
+				`, artistMBID)
+			case req.URL.Path == "/ws/2/artist/":
+				fmt.Fprint(w, `<metadata><artist-list count="0"></artist-list></metadata>`)
+			case req.URL.Path == "/ws/2/artist/"+artistMBID:
+				fmt.Fprint(w, `
+					<metadata>
+					<artist id="ca891d65-d9b0-4258-89f7-e6ba29d83767" type="Group">
+						<name>Iron Maiden</name>
+						<relation-list target-type="url">
+							<relation type="wikidata">
+								<target>https://www.wikidata.org/wiki/Q43182</target>
+							</relation>
+							<relation type="official homepage">
+								<target>https://www.ironmaiden.com/</target>
+							</relation>
+						</relation-list>
+					</artist>
+					</metadata>
+				`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	))
+	defer mbrainz.Close()
+
+	client := art.NewClient("euterpe/testing", 0, "")
+	client.SetMusicBrainzAPIURL(mbrainz.URL)
+	provider := artistinfo.NewMusicBrainz(client)
+
+	var info artistinfo.Info
+	if err := provider.AddArtistInfo(context.Background(), "Iron Maiden", &info); err != nil {
+		t.Fatalf("adding artist info: %s", err)
+	}
+
+	if info.MusicBrainzID != artistMBID {
+		t.Errorf("expected MusicBrainz ID %s but got %s", artistMBID, info.MusicBrainzID)
+	}
+
+	expected := []artistinfo.Link{
+		{Type: "wikidata", URL: "https://www.wikidata.org/wiki/Q43182"},
+		{Type: "official homepage", URL: "https://www.ironmaiden.com/"},
+	}
+	if len(info.Links) != len(expected) {
+		t.Fatalf("expected links %+v but got %+v", expected, info.Links)
+	}
+	for i, link := range info.Links {
+		if link != expected[i] {
+			t.Errorf("link %d: expected %+v but got %+v", i, expected[i], link)
+		}
+	}
+
+	err := provider.AddArtistInfo(context.Background(), "Unknown", &artistinfo.Info{})
+	if !errors.Is(err, artistinfo.ErrNotFound) {
+		t.Errorf("expected not found error for unknown artist but got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package artistinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	wikidataEntityEndpoint   = "%s/wiki/Special:EntityData/%s.json"
+	wikipediaSummaryEndpoint = "%s/api/rest_v1/page/summary/%s"
+)
+
+// Wikipedia is a Provider which uses the summary of the Wikipedia article about an
+// artist as their biography. The article is found with the "wikidata" or
+// "wikipedia" links in the info so it must come after a provider which finds them,
+// such as MusicBrainz. It does not require authentication.
+//
+// More info: https://en.wikipedia.org/api/rest_v1/
+type Wikipedia struct {
+	useragent string
+	language  string
+
+	wikidataAPIHost  string
+	wikipediaAPIHost string
+}
+
+// NewWikipedia returns a Wikipedia provider which uses the articles in the Wikipedia
+// for `language`. It is a code such as "en" or "de" and defaults to "en".
+func NewWikipedia(useragent, language string) *Wikipedia {
+	if language == "" {
+		language = "en"
+	}
+
+	return &Wikipedia{
+		useragent:        useragent,
+		language:         language,
+		wikidataAPIHost:  "https://www.wikidata.org",
+		wikipediaAPIHost: fmt.Sprintf("https://%s.wikipedia.org", language),
+	}
+}
+
+// Name implements Provider.
+func (w *Wikipedia) Name() string {
+	return ProviderWikipedia
+}
+
+// AddArtistInfo implements Provider. Nothing is done when the info has a biography
+// already.
+func (w *Wikipedia) AddArtistInfo(ctx context.Context, artist string, info *Info) error {
+	if info.Biography != "" {
+		return nil
+	}
+
+	title, err := w.articleTitle(ctx, info.Links)
+	if err != nil {
+		return err
+	}
+
+	var summary wpSummary
+	err = getJSON(ctx, w.useragent, fmt.Sprintf(
+		wikipediaSummaryEndpoint,
+		w.wikipediaAPIHost,
+		url.PathEscape(strings.ReplaceAll(title, " ", "_")),
+	), &summary)
+	if err != nil {
+		return fmt.Errorf("getting Wikipedia summary: %w", err)
+	}
+
+	if summary.Type == "disambiguation" || summary.Extract == "" {
+		return ErrNotFound
+	}
+
+	info.Biography = strings.TrimSpace(summary.Extract)
+	info.addLink(Link{Type: "wikipedia", URL: summary.ContentURLs.Desktop.Page})
+
+	return nil
+}
+
+// articleTitle returns the title of the article about the artist in the Wikipedia
+// for the language of the provider. It is taken from the Wikipedia links or found
+// in Wikidata.
+func (w *Wikipedia) articleTitle(ctx context.Context, links []Link) (string, error) {
+	for _, link := range links {
+		if link.Type != "wikipedia" {
+			continue
+		}
+
+		articleURL, err := url.Parse(link.URL)
+		if err != nil || articleURL.Host != w.language+".wikipedia.org" ||
+			!strings.HasPrefix(articleURL.Path, "/wiki/") {
+			continue
+		}
+
+		return strings.TrimPrefix(articleURL.Path, "/wiki/"), nil
+	}
+
+	for _, link := range links {
+		if link.Type != "wikidata" {
+			continue
+		}
+
+		title, err := w.wikidataTitle(ctx, path.Base(link.URL))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		return title, nil
+	}
+
+	return "", ErrNotFound
+}
+
+// wikidataTitle returns the title of the Wikipedia article for the Wikidata entity
+// with ID `entityID`.
+func (w *Wikipedia) wikidataTitle(ctx context.Context, entityID string) (string, error) {
+	var entities wdEntities
+	err := getJSON(ctx, w.useragent, fmt.Sprintf(
+		wikidataEntityEndpoint,
+		w.wikidataAPIHost,
+		url.PathEscape(entityID),
+	), &entities)
+	if err != nil {
+		return "", fmt.Errorf("getting Wikidata entity: %w", err)
+	}
+
+	// The entity could have been merged into another one. Then it is returned
+	// with its new ID.
+	for _, entity := range entities.Entities {
+		if sitelink, ok := entity.Sitelinks[w.language+"wiki"]; ok && sitelink.Title != "" {
+			return sitelink.Title, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+// The following are structures only used to decode the JSON responses from the
+// Wikidata and Wikipedia APIs. And only the stuff we are interested and nothing more.
+type wdEntities struct {
+	Entities map[string]wdEntity `json:"entities"`
+}
+
+type wdEntity struct {
+	Sitelinks map[string]wdSitelink `json:"sitelinks"`
+}
+
+type wdSitelink struct {
+	Title string `json:"title"`
+}
+
+type wpSummary struct {
+	Type        string `json:"type"`
+	Extract     string `json:"extract"`
+	ContentURLs struct {
+		Desktop struct {
+			Page string `json:"page"`
+		} `json:"desktop"`
+	} `json:"content_urls"`
+}
@@ -0,0 +1,88 @@
+package artistinfo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+)
+
+// TestWikipedia checks that the article about the artist is found through
+// Wikidata and that its summary becomes the biography.
+func TestWikipedia(t *testing.T) {
+	var wikipediaURL string
+
+	wikidata := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/wiki/Special:EntityData/Q43182.json" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, `{"entities": {"Q43182": {"sitelinks": {
+				"dewiki": {"title": "Iron Maiden (Band)"},
+				"enwiki": {"title": "Iron Maiden"}
+			}}}}`)
+		},
+	))
+	defer wikidata.Close()
+
+	wikipedia := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case "/api/rest_v1/page/summary/Iron_Maiden":
+				fmt.Fprintf(w, `{
+					"type": "standard",
+					"extract": "Iron Maiden are an English heavy metal band.",
+					"content_urls": {"desktop": {"page": "%s/wiki/Iron_Maiden"}}
+				}`, wikipediaURL)
+			case "/api/rest_v1/page/summary/Maiden":
+				fmt.Fprint(w, `{"type": "disambiguation", "extract": "Maiden may refer to:"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	))
+	defer wikipedia.Close()
+	wikipediaURL = wikipedia.URL
+
+	provider := artistinfo.NewWikipedia("euterpe/testing", "")
+	provider.SetAPIURLs(wikidata.URL, wikipedia.URL)
+
+	ctx := context.Background()
+	info := artistinfo.Info{
+		Links: []artistinfo.Link{
+			{Type: "official homepage", URL: "https://www.ironmaiden.com/"},
+			{Type: "wikidata", URL: "https://www.wikidata.org/wiki/Q43182"},
+		},
+	}
+	if err := provider.AddArtistInfo(ctx, "Iron Maiden", &info); err != nil {
+		t.Fatalf("adding artist info: %s", err)
+	}
+
+	if info.Biography != "Iron Maiden are an English heavy metal band." {
+		t.Errorf("unexpected biography: %q", info.Biography)
+	}
+	if len(info.Links) != 3 || info.Links[2].Type != "wikipedia" ||
+		info.Links[2].URL != wikipedia.URL+"/wiki/Iron_Maiden" {
+		t.Errorf("expected the Wikipedia article in the links but got %+v", info.Links)
+	}
+
+	disambiguation := artistinfo.Info{
+		Links: []artistinfo.Link{
+			{Type: "wikipedia", URL: "https://en.wikipedia.org/wiki/Maiden"},
+		},
+	}
+	err := provider.AddArtistInfo(ctx, "Maiden", &disambiguation)
+	if !errors.Is(err, artistinfo.ErrNotFound) || disambiguation.Biography != "" {
+		t.Errorf("disambiguation pages must not be biographies, got error %v", err)
+	}
+
+	err = provider.AddArtistInfo(ctx, "No Links", &artistinfo.Info{})
+	if !errors.Is(err, artistinfo.ErrNotFound) {
+		t.Errorf("expected not found error for artist without links but got %v", err)
+	}
+}
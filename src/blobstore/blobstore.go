@@ -0,0 +1,158 @@
+// Package blobstore keeps binary blobs such as images in files on disk. Every
+// blob is stored in a file named after the SHA-256 hash of its contents so that
+// identical blobs are stored only once.
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ErrInvalidHash is returned for hashes which are not hex encoded SHA-256 sums.
+var ErrInvalidHash = errors.New("invalid blob hash")
+
+// Store is a content-addressed store for blobs. Blobs are kept in sub-directories
+// named after the first two characters of their hashes so that no directory gets
+// too big. It is safe for concurrent use.
+type Store struct {
+	fs   afero.Fs
+	root string
+}
+
+// New returns a Store which keeps its blobs in the `root` directory of `fs`. The
+// directory is created when the first blob is stored.
+func New(fs afero.Fs, root string) *Store {
+	return &Store{
+		fs:   fs,
+		root: root,
+	}
+}
+
+// Hash returns the key under which `data` is stored.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Put stores `data` and returns its hash. Storing a blob which is already in the
+// store does nothing.
+func (s *Store) Put(data []byte) (string, error) {
+	hash := Hash(data)
+	blobPath, _ := s.path(hash)
+
+	if s.Exists(hash) {
+		return hash, nil
+	}
+
+	dir := filepath.Dir(blobPath)
+	if err := s.fs.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating blob directory: %w", err)
+	}
+
+	// The blob is written into a temporary file first so that partially written
+	// blobs are never visible under their hashes.
+	tmp, err := afero.TempFile(s.fs, dir, ".tmp-"+hash[:8]+"-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary blob file: %w", err)
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.fs.Rename(tmp.Name(), blobPath)
+	}
+	if err != nil {
+		_ = s.fs.Remove(tmp.Name())
+		return "", fmt.Errorf("writing blob %s: %w", hash, err)
+	}
+
+	return hash, nil
+}
+
+// Get returns the blob stored under `hash`. The returned error wraps
+// fs.ErrNotExist when there is no such blob.
+func (s *Store) Get(hash string) ([]byte, error) {
+	blobPath, err := s.path(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return afero.ReadFile(s.fs, blobPath)
+}
+
+// Exists returns true when there is a blob stored under `hash`.
+func (s *Store) Exists(hash string) bool {
+	blobPath, err := s.path(hash)
+	if err != nil {
+		return false
+	}
+
+	st, err := s.fs.Stat(blobPath)
+	return err == nil && st.Mode().IsRegular()
+}
+
+// Remove deletes the blob stored under `hash`. Removing a blob which is not in
+// the store is not an error.
+func (s *Store) Remove(hash string) error {
+	blobPath, err := s.path(hash)
+	if err != nil {
+		return err
+	}
+
+	if err := s.fs.Remove(blobPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Walk calls `fn` for every blob in the store with its hash and the time it was
+// stored. Files in the store directory which are not blobs are skipped.
+func (s *Store) Walk(fn func(hash string, stored time.Time) error) error {
+	err := afero.Walk(s.fs, s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		hash := filepath.Base(path)
+		if validHash(hash) {
+			return fn(hash, info.ModTime())
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Store) path(hash string) (string, error) {
+	if !validHash(hash) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidHash, hash)
+	}
+
+	return filepath.Join(s.root, hash[:2], hash), nil
+}
+
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 || strings.ToLower(hash) != hash {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
@@ -0,0 +1,95 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/ironsmile/euterpe/src/blobstore"
+)
+
+// TestStore checks storing, reading, listing and removing blobs.
+func TestStore(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	store := blobstore.New(memFS, "/data/blobs")
+
+	blob := []byte("some image")
+	hash, err := store.Put(blob)
+	if err != nil {
+		t.Fatalf("storing blob: %s", err)
+	}
+	if hash != blobstore.Hash(blob) {
+		t.Errorf("expected hash %s but got %s", blobstore.Hash(blob), hash)
+	}
+
+	expectedPath := filepath.Join("/data/blobs", hash[:2], hash)
+	if ok, _ := afero.Exists(memFS, expectedPath); !ok {
+		t.Errorf("blob was not stored in %s", expectedPath)
+	}
+
+	if again, err := store.Put(blob); err != nil || again != hash {
+		t.Errorf("storing the same blob again returned %s, %v", again, err)
+	}
+
+	stored, err := store.Get(hash)
+	if err != nil {
+		t.Fatalf("reading blob: %s", err)
+	}
+	if !bytes.Equal(stored, blob) {
+		t.Errorf("expected blob %q but got %q", blob, stored)
+	}
+
+	if _, err := store.Put([]byte("another image")); err != nil {
+		t.Fatalf("storing another blob: %s", err)
+	}
+	if err := afero.WriteFile(memFS, "/data/blobs/README", []byte("x"), 0644); err != nil {
+		t.Fatalf("writing a file which is not a blob: %s", err)
+	}
+
+	var walked []string
+	err = store.Walk(func(hash string, stored time.Time) error {
+		walked = append(walked, hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking the store: %s", err)
+	}
+	if len(walked) != 2 {
+		t.Errorf("expected two blobs but walked %v", walked)
+	}
+
+	if err := store.Remove(hash); err != nil {
+		t.Fatalf("removing blob: %s", err)
+	}
+	if store.Exists(hash) {
+		t.Errorf("blob exists after removing it")
+	}
+	if _, err := store.Get(hash); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected not exist error for removed blob but got %v", err)
+	}
+	if err := store.Remove(hash); err != nil {
+		t.Errorf("removing missing blob: %s", err)
+	}
+
+	if _, err := store.Get("../../etc/passwd"); !errors.Is(err, blobstore.ErrInvalidHash) {
+		t.Errorf("expected invalid hash error but got %v", err)
+	}
+}
+
+// TestStoreWalkEmpty makes sure walking a store which has never been written to
+// is not an error.
+func TestStoreWalkEmpty(t *testing.T) {
+	store := blobstore.New(afero.NewMemMapFs(), "/no/blobs/yet")
+	err := store.Walk(func(string, time.Time) error {
+		t.Errorf("walked a blob in an empty store")
+		return nil
+	})
+	if err != nil {
+		t.Errorf("walking empty store: %s", err)
+	}
+}
@@ -14,8 +14,11 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"time"
 
 	"github.com/ironsmile/euterpe/src/helpers"
@@ -50,6 +53,46 @@ var defaultConfig = Config{
 	ReadTimeout:    15,
 	WriteTimeout:   1200,
 	MaxHeadersSize: 1048576,
+	LoginLimits: LoginLimits{
+		FreeAttempts:    5,
+		Backoff:         time.Second,
+		MaxBackoff:      time.Minute,
+		LockoutAttempts: 20,
+		LockoutDuration: 15 * time.Minute,
+	},
+	ForwardAuth: ForwardAuth{
+		Header: "Remote-User",
+	},
+	OIDC: OIDC{
+		GroupsClaim: "groups",
+	},
+	ACME: ACME{
+		CacheDir:   "acme",
+		HTTPListen: ":80",
+	},
+	Artwork: Artwork{
+		Providers:   artworkProviders,
+		Local:       ArtworkProvider{Enable: true},
+		Embedded:    ArtworkProvider{Enable: true},
+		CAA:         ArtworkProvider{Enable: true},
+		Discogs:     ArtworkProvider{Enable: true},
+		Deezer:      ArtworkProvider{RateLimit: 200 * time.Millisecond},
+		ITunes:      ArtworkProvider{RateLimit: 3 * time.Second},
+		FanartTV:    ArtworkProvider{RateLimit: time.Second},
+		URLTemplate: ArtworkProvider{RateLimit: time.Second},
+		Sizes:       []int{60, 80, 120, 160, 200, 300, 400, 500, 600, 800, 1000, 1200},
+		CacheBytes:  64 * 1024 * 1024,
+		Selection:   DefaultArtworkSelection(),
+	},
+	ArtistInfo: ArtistInfo{
+		Providers: artistInfoProviders,
+		TTL:       30 * 24 * time.Hour,
+		Language:  "en",
+	},
+	AlbumInfo: AlbumInfo{
+		Providers: albumInfoProviders,
+		TTL:       30 * 24 * time.Hour,
+	},
 }
 
 // Config contains representation for everything in config.json
@@ -57,8 +100,13 @@ type Config struct {
 	Listen           string      `json:"listen,omitempty"`
 	SSL              bool        `json:"ssl,omitempty"`
 	SSLCertificate   Cert        `json:"ssl_certificate,omitempty"`
+	ACME             ACME        `json:"acme,omitempty"`
 	Auth             bool        `json:"basic_authenticate,omitempty"`
 	Authenticate     Auth        `json:"authentication,omitempty"`
+	LoginLimits      LoginLimits `json:"login_limits,omitempty"`
+	TrustedProxies   []string    `json:"trusted_proxies,omitempty"`
+	ForwardAuth      ForwardAuth `json:"forward_auth,omitempty"`
+	OIDC             OIDC        `json:"oidc,omitempty"`
 	Libraries        []string    `json:"libraries,omitempty"`
 	LibraryScan      ScanSection `json:"library_scan,omitempty"`
 	LogFile          string      `json:"log_file,omitempty"`
@@ -69,7 +117,14 @@ type Config struct {
 	MaxHeadersSize   int         `json:"max_header_bytes,omitempty"`
 	DownloadArtwork  bool        `json:"download_artwork,omitempty"`
 	DiscogsAuthToken string      `json:"discogs_auth_token,omitempty"`
+	Artwork          Artwork     `json:"artwork,omitempty"`
+	ArtistInfo       ArtistInfo  `json:"artist_info,omitempty"`
+	AlbumInfo        AlbumInfo   `json:"album_info,omitempty"`
 	AccessLog        bool        `json:"access_log,omitempty"`
+
+	// overrides are the environment variables and -set flags which set keys
+	// of the configuration, keyed by the configuration key.
+	overrides map[string]string
 }
 
 // ScanSection is used for merging the two configs. Its purpose is to essentially
@@ -79,6 +134,14 @@ type ScanSection struct {
 	FilesPerOperation int64         `json:"files_per_operation,omitempty"`
 	SleepPerOperation time.Duration `json:"sleep_after_operation,omitempty"`
 	InitialWait       time.Duration `json:"initial_wait_duration,omitempty"`
+
+	// ParseWorkers is the number of files which are read concurrently during a
+	// scan. Zero means one worker per CPU.
+	ParseWorkers int `json:"parse_workers,omitempty"`
+
+	// WriteBatchSize is the maximum number of tracks which are inserted into the
+	// database in a single transaction during a scan.
+	WriteBatchSize int `json:"write_batch_size,omitempty"`
 }
 
 // UnmarshalJSON parses a JSON and populets its ScanSection. Satisfies the
@@ -89,6 +152,8 @@ func (ss *ScanSection) UnmarshalJSON(input []byte) error {
 		FilesPerOperation int64  `json:"files_per_operation"`
 		SleepPerOperation string `json:"sleep_after_operation"`
 		InitialWait       string `json:"initial_wait_duration"`
+		ParseWorkers      int    `json:"parse_workers"`
+		WriteBatchSize    int    `json:"write_batch_size"`
 	}{}
 	if err := json.Unmarshal(input, ssProxy); err != nil {
 		return fmt.Errorf("wrong JSON value: %w", err)
@@ -96,6 +161,8 @@ func (ss *ScanSection) UnmarshalJSON(input []byte) error {
 
 	ss.Disable = ssProxy.Disable
 	ss.FilesPerOperation = ssProxy.FilesPerOperation
+	ss.ParseWorkers = ssProxy.ParseWorkers
+	ss.WriteBatchSize = ssProxy.WriteBatchSize
 
 	if ssProxy.SleepPerOperation != "" {
 		spo, err := time.ParseDuration(ssProxy.SleepPerOperation)
@@ -117,25 +184,520 @@ func (ss *ScanSection) UnmarshalJSON(input []byte) error {
 		return errors.New("files_per_operation must be a positive integer")
 	}
 
+	if ss.ParseWorkers < 0 {
+		return errors.New("parse_workers must be a positive integer")
+	}
+
+	if ss.WriteBatchSize < 0 {
+		return errors.New("write_batch_size must be a positive integer")
+	}
+
+	return nil
+}
+
+// LoginLimits configures how failed authentication attempts are throttled. Attempts
+// are counted separately for every pair of client address and username.
+type LoginLimits struct {
+	// Disable turns off the throttling of failed attempts.
+	Disable bool `json:"disable,omitempty"`
+
+	// FreeAttempts is the number of failed attempts allowed before the client
+	// has to wait between further attempts.
+	FreeAttempts int `json:"free_attempts,omitempty"`
+
+	// Backoff is the time to wait after the first failed attempt over the free
+	// ones. It doubles with every next failed attempt.
+	Backoff time.Duration `json:"backoff,omitempty"`
+
+	// MaxBackoff is the upper limit for the time to wait between attempts.
+	MaxBackoff time.Duration `json:"max_backoff,omitempty"`
+
+	// LockoutAttempts is the number of failed attempts after which the client is
+	// locked out for LockoutDuration.
+	LockoutAttempts int `json:"lockout_attempts,omitempty"`
+
+	// LockoutDuration is the time for which clients are locked out. It is also
+	// the time after which failed attempts are forgotten.
+	LockoutDuration time.Duration `json:"lockout_duration,omitempty"`
+}
+
+// UnmarshalJSON parses a JSON and populates its LoginLimits. Values missing from
+// the JSON are left unchanged. Satisfies the Unmrashaller interface.
+func (ll *LoginLimits) UnmarshalJSON(input []byte) error {
+	llProxy := &struct {
+		Disable         bool   `json:"disable"`
+		FreeAttempts    int    `json:"free_attempts"`
+		Backoff         string `json:"backoff"`
+		MaxBackoff      string `json:"max_backoff"`
+		LockoutAttempts int    `json:"lockout_attempts"`
+		LockoutDuration string `json:"lockout_duration"`
+	}{
+		Disable:         ll.Disable,
+		FreeAttempts:    ll.FreeAttempts,
+		LockoutAttempts: ll.LockoutAttempts,
+	}
+	if err := json.Unmarshal(input, llProxy); err != nil {
+		return fmt.Errorf("wrong JSON value: %w", err)
+	}
+
+	ll.Disable = llProxy.Disable
+	ll.FreeAttempts = llProxy.FreeAttempts
+	ll.LockoutAttempts = llProxy.LockoutAttempts
+
+	durations := []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"backoff", llProxy.Backoff, &ll.Backoff},
+		{"max_backoff", llProxy.MaxBackoff, &ll.MaxBackoff},
+		{"lockout_duration", llProxy.LockoutDuration, &ll.LockoutDuration},
+	}
+	for _, dur := range durations {
+		if dur.value == "" {
+			continue
+		}
+
+		parsed, err := time.ParseDuration(dur.value)
+		if err != nil {
+			return fmt.Errorf("wrong value for %s: %w", dur.name, err)
+		}
+		if parsed < 0 {
+			return fmt.Errorf("%s must not be negative", dur.name)
+		}
+		*dur.dest = parsed
+	}
+
+	if ll.FreeAttempts < 0 {
+		return errors.New("free_attempts must be a positive integer")
+	}
+
+	if ll.LockoutAttempts < 0 {
+		return errors.New("lockout_attempts must be a positive integer")
+	}
+
 	return nil
 }
 
+// ForwardAuth configures authentication by a reverse proxy in front of Euterpe.
+// The proxy authenticates the user and sets their name in a HTTP header.
+type ForwardAuth struct {
+	// Enable turns on the forward authentication.
+	Enable bool `json:"enable,omitempty"`
+
+	// Header is the name of the HTTP header which holds the user name.
+	Header string `json:"header,omitempty"`
+
+	// Proxies is a list of addresses or networks in CIDR notation. The header
+	// is trusted only for requests coming directly from them.
+	Proxies []string `json:"proxies,omitempty"`
+
+	// Users lists the user names set by the proxy which are accepted. All of them
+	// are mapped to the configured authentication user. When empty only that user
+	// is accepted. The special value "*" accepts every user.
+	Users []string `json:"users,omitempty"`
+}
+
+// OIDC configures logging in to the web UI with an OpenID Connect provider. It is
+// enabled when the Issuer is set.
+type OIDC struct {
+	// Issuer is the URL of the provider. Its configuration is discovered from
+	// the .well-known/openid-configuration document under it.
+	Issuer string `json:"issuer,omitempty"`
+
+	// ClientID and ClientSecret are the credentials of Euterpe as registered
+	// with the provider. The secret may be empty for public clients.
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+
+	// RedirectURL is the full URL of the Euterpe /login/oidc/callback endpoint
+	// as seen by the browser.
+	RedirectURL string `json:"redirect_url,omitempty"`
+
+	// Scopes are requested in addition to "openid".
+	Scopes []string `json:"scopes,omitempty"`
+
+	// AllowedSubjects lists the "sub" claims of the users allowed to log in.
+	AllowedSubjects []string `json:"allowed_subjects,omitempty"`
+
+	// AllowedGroups lists groups whose members are allowed to log in. Groups
+	// are read from the GroupsClaim of the ID token.
+	AllowedGroups []string `json:"allowed_groups,omitempty"`
+	GroupsClaim   string   `json:"groups_claim,omitempty"`
+}
+
 // Cert represents a configuration for TLS certificate
 type Cert struct {
 	Crt string `json:"crt,omitempty"`
 	Key string `json:"key,omitempty"`
 }
 
+// ACME configures obtaining and renewing TLS certificates automatically from an
+// ACME certificate authority such as Let's Encrypt. It is enabled when at least
+// one domain is set and then it is used instead of the SSLCertificate.
+type ACME struct {
+	// Domains are the host names for which certificates are requested.
+	Domains []string `json:"domains,omitempty"`
+
+	// Email is given to the certificate authority as contact for the account.
+	Email string `json:"email,omitempty"`
+
+	// DirectoryURL is the ACME directory of the certificate authority. Empty
+	// means the Let's Encrypt production directory.
+	DirectoryURL string `json:"directory_url,omitempty"`
+
+	// CacheDir is where the account key and the certificates are stored. When
+	// relative it is in the Euterpe user directory.
+	CacheDir string `json:"cache_dir,omitempty"`
+
+	// HTTPListen is the address on which the HTTP-01 challenges are answered.
+	// All other requests to it are redirected to HTTPS. When empty only the
+	// TLS-ALPN-01 challenge is used.
+	HTTPListen string `json:"http_listen,omitempty"`
+}
+
+// Names of the artwork providers as used in Artwork.Providers.
+const (
+	ArtworkLocal       = "local"
+	ArtworkEmbedded    = "embedded"
+	ArtworkCAA         = "caa"
+	ArtworkDiscogs     = "discogs"
+	ArtworkDeezer      = "deezer"
+	ArtworkITunes      = "itunes"
+	ArtworkFanartTV    = "fanarttv"
+	ArtworkURLTemplate = "url_template"
+)
+
+// artworkProviders are the names of all artwork providers.
+var artworkProviders = []string{
+	ArtworkLocal,
+	ArtworkEmbedded,
+	ArtworkCAA,
+	ArtworkDiscogs,
+	ArtworkDeezer,
+	ArtworkITunes,
+	ArtworkFanartTV,
+	ArtworkURLTemplate,
+}
+
+// Artwork configures where album artwork and artist images are found. Images from
+// the local files are always tried before the providers on the internet. And the
+// latter are used only when DownloadArtwork is true.
+type Artwork struct {
+	// Providers is the order in which the providers are tried. Providers which
+	// are not in it are not used even when enabled.
+	Providers []string `json:"providers,omitempty"`
+
+	// Local is for images in the directories of the albums.
+	Local ArtworkProvider `json:"local,omitempty"`
+
+	// Embedded is for images in the tags of the media files.
+	Embedded ArtworkProvider `json:"embedded,omitempty"`
+
+	// CAA is for album artwork from the Cover Art Archive.
+	CAA ArtworkProvider `json:"caa,omitempty"`
+
+	// Discogs is for artist images from Discogs. It requires an APIKey which is
+	// a personal Discogs token. When empty the DiscogsAuthToken is used.
+	Discogs ArtworkProvider `json:"discogs,omitempty"`
+
+	// Deezer is for album artwork and artist images from Deezer.
+	Deezer ArtworkProvider `json:"deezer,omitempty"`
+
+	// ITunes is for album artwork from the iTunes Store.
+	ITunes ArtworkProvider `json:"itunes,omitempty"`
+
+	// FanartTV is for album artwork and artist images from fanart.tv. It
+	// requires an APIKey.
+	FanartTV ArtworkProvider `json:"fanarttv,omitempty"`
+
+	// URLTemplate is for images downloaded from AlbumURL and ArtistURL.
+	URLTemplate ArtworkProvider `json:"url_template,omitempty"`
+
+	// Sizes are the widths in pixels in which images are served. Requested
+	// sizes are rounded up to the nearest one of them. Images are not resized
+	// when it is empty.
+	Sizes []int `json:"sizes,omitempty"`
+
+	// CacheBytes is the maximum size of the in-memory cache for images which
+	// were scaled or converted to other formats.
+	CacheBytes int64 `json:"cache_bytes,omitempty"`
+
+	// Selection is how album artwork is chosen among the images in the album
+	// directories.
+	Selection ArtworkSelection `json:"selection,omitempty"`
+}
+
+// ArtworkSelection scores the images in the directory of an album. The one with the
+// highest score is used as its artwork. Images in the album directory itself get
+// a bonus over the ones in its sub-directories and hidden files get a penalty.
+type ArtworkSelection struct {
+	// Patterns are matched against the lower-cased file names of the images in
+	// order. An image gets the score of the first one which matches. Images with
+	// no positive score are never used.
+	Patterns []ArtworkPattern `json:"patterns,omitempty"`
+
+	// Extensions are the extensions of the files which are considered images.
+	// The earlier ones are preferred when images have equal scores.
+	Extensions []string `json:"extensions,omitempty"`
+
+	// MinSize is the minimal width and height in pixels of the images which are
+	// used. Zero means any size.
+	MinSize int `json:"min_size,omitempty"`
+}
+
+// ArtworkPattern gives a score to images with matching file names.
+type ArtworkPattern struct {
+	// Pattern is a shell file name pattern as in path.Match.
+	Pattern string `json:"pattern"`
+	Score   int    `json:"score"`
+}
+
+// DefaultArtworkSelection returns the artwork selection which is used when none is
+// configured. It prefers images named "cover" or "front".
+func DefaultArtworkSelection() ArtworkSelection {
+	return ArtworkSelection{
+		Patterns: []ArtworkPattern{
+			{Pattern: "cover.*", Score: 15},
+			{Pattern: "front.*", Score: 15},
+			{Pattern: "*cover*", Score: 10},
+			{Pattern: "*front*", Score: 10},
+			{Pattern: "*artwork*", Score: 8},
+			{Pattern: "*", Score: 5},
+		},
+		Extensions: []string{"jpg", "jpeg", "png", "gif"},
+	}
+}
+
+// Provider returns the configuration of the artwork provider with `name`. The
+// second returned value is false when there is no such provider.
+func (a Artwork) Provider(name string) (ArtworkProvider, bool) {
+	switch name {
+	case ArtworkLocal:
+		return a.Local, true
+	case ArtworkEmbedded:
+		return a.Embedded, true
+	case ArtworkCAA:
+		return a.CAA, true
+	case ArtworkDiscogs:
+		return a.Discogs, true
+	case ArtworkDeezer:
+		return a.Deezer, true
+	case ArtworkITunes:
+		return a.ITunes, true
+	case ArtworkFanartTV:
+		return a.FanartTV, true
+	case ArtworkURLTemplate:
+		return a.URLTemplate, true
+	default:
+		return ArtworkProvider{}, false
+	}
+}
+
+// ArtworkProvider is the configuration of a single artwork provider.
+type ArtworkProvider struct {
+	// Enable turns on the provider.
+	Enable bool `json:"enable,omitempty"`
+
+	// RateLimit is the minimal time between two requests to the provider.
+	RateLimit time.Duration `json:"rate_limit,omitempty"`
+
+	// APIKey is the key or token for providers which require one.
+	APIKey string `json:"api_key,omitempty"`
+
+	// AlbumURL and ArtistURL are the templates of the URLs from which images are
+	// downloaded by the url_template provider. The placeholders {artist} and
+	// {album} are replaced with the names of the artist and the album.
+	AlbumURL  string `json:"album_url,omitempty"`
+	ArtistURL string `json:"artist_url,omitempty"`
+}
+
+// UnmarshalJSON parses a JSON and populates its ArtworkProvider. Values missing
+// from the JSON are left unchanged. Satisfies the Unmrashaller interface.
+func (ap *ArtworkProvider) UnmarshalJSON(input []byte) error {
+	apProxy := &struct {
+		Enable    bool   `json:"enable"`
+		RateLimit string `json:"rate_limit"`
+		APIKey    string `json:"api_key"`
+		AlbumURL  string `json:"album_url"`
+		ArtistURL string `json:"artist_url"`
+	}{
+		Enable:    ap.Enable,
+		APIKey:    ap.APIKey,
+		AlbumURL:  ap.AlbumURL,
+		ArtistURL: ap.ArtistURL,
+	}
+	if err := json.Unmarshal(input, apProxy); err != nil {
+		return fmt.Errorf("wrong JSON value: %w", err)
+	}
+
+	ap.Enable = apProxy.Enable
+	ap.APIKey = apProxy.APIKey
+	ap.AlbumURL = apProxy.AlbumURL
+	ap.ArtistURL = apProxy.ArtistURL
+
+	if apProxy.RateLimit != "" {
+		rateLimit, err := time.ParseDuration(apProxy.RateLimit)
+		if err != nil {
+			return fmt.Errorf("wrong value for rate_limit: %w", err)
+		}
+		if rateLimit < 0 {
+			return errors.New("rate_limit must not be negative")
+		}
+		ap.RateLimit = rateLimit
+	}
+
+	return nil
+}
+
+// Names of the artist info providers as used in ArtistInfo.Providers.
+const (
+	ArtistInfoMusicBrainz = "musicbrainz"
+	ArtistInfoWikipedia   = "wikipedia"
+	ArtistInfoLastFM      = "lastfm"
+)
+
+// artistInfoProviders are the names of all artist info providers.
+var artistInfoProviders = []string{
+	ArtistInfoMusicBrainz,
+	ArtistInfoWikipedia,
+	ArtistInfoLastFM,
+}
+
+// ArtistInfo configures finding biographies, similar artists and links to pages
+// about the artists on the internet. Like artwork it is searched for only when
+// DownloadArtwork is true.
+type ArtistInfo struct {
+	// Providers is the order in which the providers are asked. Every one of
+	// them adds to what the previous ones have found. Wikipedia needs the links
+	// found by MusicBrainz so it must be after it.
+	Providers []string `json:"providers,omitempty"`
+
+	// TTL is for how long the found information is used before it is searched
+	// for again.
+	TTL time.Duration `json:"ttl,omitempty"`
+
+	// Language is the code of the language of the Wikipedia from which the
+	// biographies are taken.
+	Language string `json:"language,omitempty"`
+
+	// LastFMAPIKey is required by the lastfm provider. It is not used when the
+	// key is empty. LastFMAPIURL could point to any service with an API which
+	// is compatible with the Last.fm one. Empty means the Last.fm API.
+	LastFMAPIKey string `json:"lastfm_api_key,omitempty"`
+	LastFMAPIURL string `json:"lastfm_api_url,omitempty"`
+}
+
+// UnmarshalJSON parses a JSON and populates its ArtistInfo. Values missing from
+// the JSON are left unchanged. Satisfies the Unmrashaller interface.
+func (ai *ArtistInfo) UnmarshalJSON(input []byte) error {
+	aiProxy := &struct {
+		Providers    []string `json:"providers"`
+		TTL          string   `json:"ttl"`
+		Language     string   `json:"language"`
+		LastFMAPIKey string   `json:"lastfm_api_key"`
+		LastFMAPIURL string   `json:"lastfm_api_url"`
+	}{
+		Providers:    ai.Providers,
+		Language:     ai.Language,
+		LastFMAPIKey: ai.LastFMAPIKey,
+		LastFMAPIURL: ai.LastFMAPIURL,
+	}
+	if err := json.Unmarshal(input, aiProxy); err != nil {
+		return fmt.Errorf("wrong JSON value: %w", err)
+	}
+
+	ai.Providers = aiProxy.Providers
+	ai.Language = aiProxy.Language
+	ai.LastFMAPIKey = aiProxy.LastFMAPIKey
+	ai.LastFMAPIURL = aiProxy.LastFMAPIURL
+
+	if aiProxy.TTL != "" {
+		ttl, err := time.ParseDuration(aiProxy.TTL)
+		if err != nil {
+			return fmt.Errorf("wrong value for ttl: %w", err)
+		}
+		if ttl < 0 {
+			return errors.New("ttl must not be negative")
+		}
+		ai.TTL = ttl
+	}
+
+	return nil
+}
+
+// AlbumInfoMusicBrainz is the name of the MusicBrainz album info provider as used
+// in AlbumInfo.Providers.
+const AlbumInfoMusicBrainz = "musicbrainz"
+
+// albumInfoProviders are the names of all album info providers.
+var albumInfoProviders = []string{
+	AlbumInfoMusicBrainz,
+}
+
+// AlbumInfo configures finding notes, labels, release dates and types of albums
+// on the internet. They fill in what is missing from the tags of the album tracks.
+// Like artwork it is searched for only when DownloadArtwork is true.
+type AlbumInfo struct {
+	// Providers are the providers which are used. Empty means that nothing
+	// is searched for.
+	Providers []string `json:"providers"`
+
+	// TTL is for how long the found information is used before it is searched
+	// for again.
+	TTL time.Duration `json:"ttl,omitempty"`
+}
+
+// UnmarshalJSON parses a JSON and populates its AlbumInfo. Values missing from
+// the JSON are left unchanged. Satisfies the Unmrashaller interface.
+func (ai *AlbumInfo) UnmarshalJSON(input []byte) error {
+	aiProxy := &struct {
+		Providers []string `json:"providers"`
+		TTL       string   `json:"ttl"`
+	}{
+		Providers: ai.Providers,
+	}
+	if err := json.Unmarshal(input, aiProxy); err != nil {
+		return fmt.Errorf("wrong JSON value: %w", err)
+	}
+
+	ai.Providers = aiProxy.Providers
+
+	if aiProxy.TTL != "" {
+		ttl, err := time.ParseDuration(aiProxy.TTL)
+		if err != nil {
+			return fmt.Errorf("wrong value for ttl: %w", err)
+		}
+		if ttl < 0 {
+			return errors.New("ttl must not be negative")
+		}
+		ai.TTL = ttl
+	}
+
+	return nil
+}
+
 // Auth represents a configuration HTTP Basic authentication
 type Auth struct {
 	User     string `json:"user,omitempty"`
 	Password string `json:"password,omitempty"`
 	Secret   string `json:"secret"`
+
+	// PreviousSecrets are secrets which were used before the current one. Tokens
+	// signed with them are still accepted so that the secret could be rotated
+	// without logging out everyone.
+	PreviousSecrets []string `json:"previous_secrets,omitempty"`
+
+	// LegacyTokens makes the token request endpoint return a long lived access
+	// token next to the short lived one. It is needed only by clients which do
+	// not know about refresh tokens.
+	LegacyTokens bool `json:"legacy_tokens,omitempty"`
 }
 
 // FindAndParse actually finds the configuration file, parsing it and merging it on
-// top the default configuration.
-func FindAndParse(appfs afero.Fs) (Config, error) {
+// top the default configuration. Then keys are overridden by the EUTERPE_*
+// environment variables and finally by `overrides`.
+func FindAndParse(appfs afero.Fs, overrides Overrides) (Config, error) {
 	if !userConfigExists(appfs) {
 		err := copyDefaultOverUser(appfs)
 		if err != nil {
@@ -146,6 +708,13 @@ func FindAndParse(appfs afero.Fs) (Config, error) {
 	cfg := defaultConfig
 	userCfgPath := UserConfigPath(appfs)
 
+	// Decoding into a slice reuses its array so the default must not be shared.
+	cfg.Artwork.Providers = slices.Clone(defaultConfig.Artwork.Providers)
+	cfg.Artwork.Sizes = slices.Clone(defaultConfig.Artwork.Sizes)
+	cfg.Artwork.Selection = DefaultArtworkSelection()
+	cfg.ArtistInfo.Providers = slices.Clone(defaultConfig.ArtistInfo.Providers)
+	cfg.AlbumInfo.Providers = slices.Clone(defaultConfig.AlbumInfo.Providers)
+
 	fh, err := appfs.Open(userCfgPath)
 	if err != nil {
 		return Config{}, fmt.Errorf("opening config: %w", err)
@@ -158,9 +727,53 @@ func FindAndParse(appfs afero.Fs) (Config, error) {
 		return Config{}, fmt.Errorf("decoding config: %w", err)
 	}
 
+	if err := applyEnv(&cfg, os.Environ()); err != nil {
+		return Config{}, err
+	}
+
+	if err := applySetFlags(&cfg, overrides); err != nil {
+		return Config{}, err
+	}
+
 	return cfg, nil
 }
 
+// restartSettings are the settings which are used only while starting Euterpe. They
+// are keyed by their names in the configuration file.
+var restartSettings = []struct {
+	name  string
+	value func(Config) any
+}{
+	{"listen", func(c Config) any { return c.Listen }},
+	{"ssl", func(c Config) any { return c.SSL }},
+	{"ssl_certificate", func(c Config) any { return c.SSLCertificate }},
+	{"acme", func(c Config) any { return c.ACME }},
+	{"log_file", func(c Config) any { return c.LogFile }},
+	{"sqlite_database", func(c Config) any { return c.SqliteDatabase }},
+	{"read_timeout", func(c Config) any { return c.ReadTimeout }},
+	{"write_timeout", func(c Config) any { return c.WriteTimeout }},
+	{"max_header_bytes", func(c Config) any { return c.MaxHeadersSize }},
+	{"download_artwork", func(c Config) any { return c.DownloadArtwork }},
+	{"discogs_auth_token", func(c Config) any { return c.DiscogsAuthToken }},
+	{"artwork", func(c Config) any { return c.Artwork }},
+	{"artist_info", func(c Config) any { return c.ArtistInfo }},
+	{"album_info", func(c Config) any { return c.AlbumInfo }},
+}
+
+// RestartRequired returns the names of the settings which are different in
+// `changed` when compared to `running` and which could not be applied without
+// restarting Euterpe. All other settings could be changed while it is running.
+func RestartRequired(running, changed Config) []string {
+	var names []string
+	for _, setting := range restartSettings {
+		if !reflect.DeepEqual(setting.value(running), setting.value(changed)) {
+			names = append(names, setting.name)
+		}
+	}
+
+	return names
+}
+
 // UserConfigPath returns the full path to the place where the user's configuration
 // file should be
 func UserConfigPath(appfs afero.Fs) string {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -20,7 +21,9 @@ func TestScanSectionUnmarshalJSON(t *testing.T) {
 			"disable": false,
 			"files_per_operation": 100,
 			"sleep_after_operation": "15ms",
-			"initial_wait_duration": "100ms"
+			"initial_wait_duration": "100ms",
+			"parse_workers": 4,
+			"write_batch_size": 250
 		}
 	`)
 
@@ -36,6 +39,8 @@ func TestScanSectionUnmarshalJSON(t *testing.T) {
 		FilesPerOperation: 100,
 		SleepPerOperation: 15 * time.Millisecond,
 		InitialWait:       100 * time.Millisecond,
+		ParseWorkers:      4,
+		WriteBatchSize:    250,
 	}
 
 	if ss != expected {
@@ -87,6 +92,22 @@ func TestScanSectionErrors(t *testing.T) {
 						}`,
 			errContains: "sleep_after_operation",
 		},
+		{
+			desc: "negative parse workers",
+			cfgString: `{
+							"files_per_operation": 100,
+							"parse_workers": -2
+						}`,
+			errContains: "parse_workers",
+		},
+		{
+			desc: "negative write batch size",
+			cfgString: `{
+							"files_per_operation": 100,
+							"write_batch_size": -200
+						}`,
+			errContains: "write_batch_size",
+		},
 	}
 
 	for _, test := range tests {
@@ -113,12 +134,199 @@ func TestScanSectionErrors(t *testing.T) {
 	}
 }
 
+// TestLoginLimitsUnmarshalJSON makes sure that decoding the "login_limits" section
+// changes only the values present in the JSON.
+func TestLoginLimitsUnmarshalJSON(t *testing.T) {
+	ll := config.LoginLimits{
+		FreeAttempts:    5,
+		Backoff:         time.Second,
+		MaxBackoff:      time.Minute,
+		LockoutAttempts: 20,
+		LockoutDuration: 15 * time.Minute,
+	}
+
+	err := json.Unmarshal([]byte(`{"free_attempts": 3, "max_backoff": "30s"}`), &ll)
+	if err != nil {
+		t.Fatalf("decoding LoginLimits JSON failed: %s", err)
+	}
+
+	expected := config.LoginLimits{
+		FreeAttempts:    3,
+		Backoff:         time.Second,
+		MaxBackoff:      30 * time.Second,
+		LockoutAttempts: 20,
+		LockoutDuration: 15 * time.Minute,
+	}
+	if ll != expected {
+		t.Errorf("expected `%+v` but got `%+v`", expected, ll)
+	}
+
+	for _, badJSON := range []string{
+		`{"backoff": "baba"}`,
+		`{"lockout_duration": "-1m"}`,
+		`{"free_attempts": -1}`,
+		`{"lockout_attempts": -5}`,
+	} {
+		var ll config.LoginLimits
+		if err := json.Unmarshal([]byte(badJSON), &ll); err == nil {
+			t.Errorf("expected error for `%s` but got none", badJSON)
+		}
+	}
+}
+
+// TestArtworkFindAndParse makes sure that decoding the "artwork" section changes
+// only the values present in the JSON and does not change the defaults.
+func TestArtworkFindAndParse(t *testing.T) {
+	testfs := afero.NewMemMapFs()
+	configPath := config.UserConfigPath(testfs)
+
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := afero.WriteFile(testfs, configPath, []byte(content), 0600); err != nil {
+			t.Fatalf("error writing config file: %s", err)
+		}
+	}
+
+	writeConfig(`{
+		"artwork": {
+			"providers": ["deezer", "local"],
+			"deezer": {"enable": true},
+			"fanarttv": {"api_key": "key", "rate_limit": "2s"},
+			"sizes": [100],
+			"selection": {
+				"patterns": [{"pattern": "folder.*", "score": 20}],
+				"min_size": 300
+			}
+		}
+	}`)
+
+	cfg, err := config.FindAndParse(testfs, nil)
+	if err != nil {
+		t.Fatalf("error finding and parsing configuration file: %s", err)
+	}
+
+	if !slices.Equal(cfg.Artwork.Providers, []string{"deezer", "local"}) {
+		t.Errorf("wrong providers: %v", cfg.Artwork.Providers)
+	}
+	if !cfg.Artwork.Deezer.Enable || cfg.Artwork.Deezer.RateLimit != 200*time.Millisecond {
+		t.Errorf("wrong deezer configuration: %+v", cfg.Artwork.Deezer)
+	}
+	if cfg.Artwork.FanartTV.Enable || cfg.Artwork.FanartTV.APIKey != "key" ||
+		cfg.Artwork.FanartTV.RateLimit != 2*time.Second {
+		t.Errorf("wrong fanarttv configuration: %+v", cfg.Artwork.FanartTV)
+	}
+	if !cfg.Artwork.Local.Enable {
+		t.Errorf("local provider was disabled")
+	}
+	if !slices.Equal(cfg.Artwork.Sizes, []int{100}) || cfg.Artwork.CacheBytes == 0 {
+		t.Errorf("wrong sizes %v or cache size %d",
+			cfg.Artwork.Sizes, cfg.Artwork.CacheBytes)
+	}
+	selection := cfg.Artwork.Selection
+	if len(selection.Patterns) != 1 || selection.Patterns[0].Pattern != "folder.*" ||
+		selection.MinSize != 300 || len(selection.Extensions) != 4 {
+		t.Errorf("wrong artwork selection: %+v", selection)
+	}
+
+	writeConfig(`{}`)
+
+	cfg, err = config.FindAndParse(testfs, nil)
+	if err != nil {
+		t.Fatalf("error finding and parsing configuration file: %s", err)
+	}
+	if len(cfg.Artwork.Providers) != 8 || cfg.Artwork.Providers[0] != "local" {
+		t.Errorf("default providers were changed: %v", cfg.Artwork.Providers)
+	}
+	if len(cfg.Artwork.Sizes) != 12 || cfg.Artwork.Sizes[0] != 60 {
+		t.Errorf("default sizes were changed: %v", cfg.Artwork.Sizes)
+	}
+	if len(cfg.Artwork.Selection.Patterns) != 6 {
+		t.Errorf("default selection patterns were changed: %v",
+			cfg.Artwork.Selection.Patterns)
+	}
+
+	var provider config.ArtworkProvider
+	if err := json.Unmarshal([]byte(`{"rate_limit": "-1s"}`), &provider); err == nil {
+		t.Errorf("expected error for negative rate limit but got none")
+	}
+}
+
+// TestArtistInfoFindAndParse makes sure that the "artist_info" section is decoded
+// on top of its defaults.
+func TestArtistInfoFindAndParse(t *testing.T) {
+	testfs := afero.NewMemMapFs()
+	configPath := config.UserConfigPath(testfs)
+
+	err := afero.WriteFile(testfs, configPath, []byte(`{
+		"artist_info": {
+			"ttl": "24h",
+			"lastfm_api_key": "key"
+		}
+	}`), 0600)
+	if err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+
+	cfg, err := config.FindAndParse(testfs, nil)
+	if err != nil {
+		t.Fatalf("error finding and parsing configuration file: %s", err)
+	}
+
+	info := cfg.ArtistInfo
+	if !slices.Equal(info.Providers, []string{"musicbrainz", "wikipedia", "lastfm"}) {
+		t.Errorf("default providers were changed: %v", info.Providers)
+	}
+	if info.TTL != 24*time.Hour || info.Language != "en" || info.LastFMAPIKey != "key" {
+		t.Errorf("wrong artist info configuration: %+v", info)
+	}
+
+	var artistInfo config.ArtistInfo
+	if err := json.Unmarshal([]byte(`{"ttl": "-1h"}`), &artistInfo); err == nil {
+		t.Errorf("expected error for negative TTL but got none")
+	}
+}
+
+// TestAlbumInfoFindAndParse makes sure that the "album_info" section is decoded
+// on top of its defaults and that searching could be turned off with an empty
+// list of providers.
+func TestAlbumInfoFindAndParse(t *testing.T) {
+	testfs := afero.NewMemMapFs()
+	configPath := config.UserConfigPath(testfs)
+
+	err := afero.WriteFile(testfs, configPath, []byte(`{
+		"album_info": {
+			"ttl": "48h"
+		}
+	}`), 0600)
+	if err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+
+	cfg, err := config.FindAndParse(testfs, nil)
+	if err != nil {
+		t.Fatalf("error finding and parsing configuration file: %s", err)
+	}
+
+	info := cfg.AlbumInfo
+	if !slices.Equal(info.Providers, []string{"musicbrainz"}) || info.TTL != 48*time.Hour {
+		t.Errorf("wrong album info configuration: %+v", info)
+	}
+
+	var albumInfo config.AlbumInfo
+	if err := json.Unmarshal([]byte(`{"providers": []}`), &albumInfo); err != nil {
+		t.Fatalf("decoding album info: %s", err)
+	}
+	if albumInfo.Providers == nil || len(albumInfo.Providers) != 0 {
+		t.Errorf("expected empty list of providers but got %#v", albumInfo.Providers)
+	}
+}
+
 // TestFindAndParseCreatesConfig makes sure that a new configuration file is created
 // when there was not when run.
 func TestFindAndParseCreatesConfig(t *testing.T) {
 	testfs := afero.NewMemMapFs()
 
-	_, err := config.FindAndParse(testfs)
+	_, err := config.FindAndParse(testfs, nil)
 	if err != nil {
 		t.Fatalf("error finding and parsing configuration file: %s", err)
 	}
@@ -167,7 +375,7 @@ func TestFindAndParse(t *testing.T) {
 		}`, listenAddress, user, pass, secret)
 	}()
 
-	cfg, err := config.FindAndParse(testfs)
+	cfg, err := config.FindAndParse(testfs, nil)
 	if err != nil {
 		t.Fatalf("error finding and parsing configuration file: %s", err)
 	}
@@ -191,4 +399,44 @@ func TestFindAndParse(t *testing.T) {
 	if cfg.Authenticate.Secret != secret {
 		t.Errorf("expected secret `%s` but got `%s`", cfg.Authenticate.Secret, secret)
 	}
+
+	cfg, err = config.FindAndParse(testfs, config.Overrides{"listen=:9996"})
+	if err != nil {
+		t.Fatalf("error parsing configuration with overrides: %s", err)
+	}
+
+	if cfg.Listen != ":9996" {
+		t.Errorf("expected listen address to be overridden but got `%s`", cfg.Listen)
+	}
+}
+
+// TestRestartRequired checks that only changes to the settings used while starting
+// are reported as requiring a restart.
+func TestRestartRequired(t *testing.T) {
+	running := config.Config{
+		Listen:    ":9996",
+		Libraries: []string{"/music"},
+		ACME: config.ACME{
+			Domains: []string{"music.example.com"},
+		},
+	}
+
+	changed := running
+	changed.Libraries = []string{"/music", "/more/music"}
+	changed.Auth = true
+	changed.AccessLog = true
+	changed.LibraryScan.Disable = true
+
+	if names := config.RestartRequired(running, changed); len(names) != 0 {
+		t.Errorf("expected no restart for live settings but got %v", names)
+	}
+
+	changed.Listen = ":443"
+	changed.ACME.Domains = []string{"music.example.com", "www.example.com"}
+
+	names := config.RestartRequired(running, changed)
+	expected := []string{"listen", "acme"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("expected restart because of %v but got %v", expected, names)
+	}
 }
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix is the prefix of the environment variables which override
+// configuration keys. The rest of the name is the key in upper case with
+// underscores in place of the dots. For example EUTERPE_LIBRARY_SCAN_DISABLE
+// overrides "library_scan.disable".
+const envPrefix = "EUTERPE_"
+
+// errUnknownKey is returned when an override is for a key which is not in the
+// configuration.
+var errUnknownKey = errors.New("unknown configuration key")
+
+// Overrides are configuration keys given as key=value where key is the path to
+// it in the configuration file with dots between the levels. They are applied
+// by FindAndParse after the EUTERPE_* environment variables. Overrides is also
+// a flag.Value which could be repeated.
+type Overrides []string
+
+// String implements flag.Value.
+func (f *Overrides) String() string {
+	return strings.Join(*f, " ")
+}
+
+// Set implements flag.Value.
+func (f *Overrides) Set(value string) error {
+	if _, _, ok := strings.Cut(value, "="); !ok {
+		return errors.New("expected key=value")
+	}
+
+	*f = append(*f, value)
+	return nil
+}
+
+// applyEnv overrides keys in `cfg` with the values of the EUTERPE_* variables in
+// `environ`. It is in the format of os.Environ. Variables which do not match any
+// key are ignored.
+func applyEnv(cfg *Config, environ []string) error {
+	keys := envKeys()
+
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, envPrefix) {
+			continue
+		}
+
+		key, ok := keys[name]
+		if !ok {
+			log.Printf("Ignoring environment variable %s: %s\n", name, errUnknownKey)
+			continue
+		}
+
+		if err := setOverride(cfg, key, value, "environment variable "+name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applySetFlags overrides keys in `cfg` with `overrides` which are in the format
+// of the -set flag.
+func applySetFlags(cfg *Config, overrides []string) error {
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return fmt.Errorf("flag -set %s: expected key=value", key)
+		}
+
+		if err := setOverride(cfg, key, value, "flag -set "+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envKeys returns all configuration keys mapped by the name of the environment
+// variable which overrides them.
+func envKeys() map[string]string {
+	keys := make(map[string]string)
+
+	var collect func(t reflect.Type, prefix string)
+	collect = func(t reflect.Type, prefix string) {
+		for i := range t.NumField() {
+			name := jsonName(t.Field(i))
+			if name == "" {
+				continue
+			}
+
+			key := prefix + name
+			if t.Field(i).Type.Kind() == reflect.Struct {
+				collect(t.Field(i).Type, key+".")
+				continue
+			}
+
+			envName := envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+			keys[envName] = key
+		}
+	}
+	collect(reflect.TypeFor[Config](), "")
+
+	return keys
+}
+
+// setOverride sets the configuration `key` in `cfg` to `value` and remembers
+// that it was set by `source` so that validation errors for it could name it.
+func setOverride(cfg *Config, key, value, source string) error {
+	if err := setKey(cfg, key, value); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	if cfg.overrides == nil {
+		cfg.overrides = make(map[string]string)
+	}
+	cfg.overrides[key] = source
+
+	return nil
+}
+
+// overrideSource returns the environment variable or the -set flag which set
+// `key` or the list or section which contains it. Returns an empty string when
+// the value is not overridden.
+func (c Config) overrideSource(key string) string {
+	for overridden, source := range c.overrides {
+		if key == overridden ||
+			strings.HasPrefix(key, overridden+"[") ||
+			strings.HasPrefix(key, overridden+".") {
+			return source
+		}
+	}
+
+	return ""
+}
+
+// setKey sets the configuration `key` in `cfg` to `value`.
+func setKey(cfg *Config, key, value string) error {
+	field := reflect.ValueOf(cfg).Elem()
+
+	for part := range strings.SplitSeq(key, ".") {
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("%w %q", errUnknownKey, key)
+		}
+
+		found := false
+		for i := range field.NumField() {
+			if jsonName(field.Type().Field(i)) == part {
+				field = field.Field(i)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("%w %q", errUnknownKey, key)
+		}
+	}
+
+	if err := setValue(field, value); err != nil {
+		return fmt.Errorf("wrong value for %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// setValue parses `value` according to the type of `field` and sets it.
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeFor[time.Duration]() {
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(dur))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Slice:
+		switch field.Type().Elem().Kind() {
+		case reflect.String:
+			list, err := parseList(value)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(list))
+		case reflect.Int:
+			list, err := parseIntList(value)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(list))
+		case reflect.Struct:
+			// Lists of sections could be given only as JSON arrays.
+			list := reflect.New(field.Type())
+			if err := json.Unmarshal([]byte(value), list.Interface()); err != nil {
+				return fmt.Errorf("expected a JSON array: %w", err)
+			}
+			field.Set(list.Elem())
+		default:
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+	case reflect.Struct:
+		return errors.New("it is a section, set its keys instead")
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// parseList parses a list of strings which is either separated with commas or
+// a JSON array.
+func parseList(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		var list []string
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+
+	var list []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list, nil
+}
+
+// parseIntList is like parseList but for lists of integers.
+func parseIntList(value string) ([]int, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		var list []int
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+
+	var list []int
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		number, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, number)
+	}
+
+	return list, nil
+}
+
+// jsonName returns the name of the struct field `f` in the configuration file.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOverrides checks that environment variables and -set flags override the
+// configuration in the right order.
+func TestOverrides(t *testing.T) {
+	cfg := defaultConfig
+	cfg.Libraries = []string{"/from/file"}
+
+	environ := []string{
+		"HOME=/root",
+		"EUTERPE_LISTEN=0.0.0.0:9996",
+		"EUTERPE_LIBRARIES=/music, /more/music",
+		"EUTERPE_LIBRARY_SCAN_DISABLE=true",
+		"EUTERPE_AUTHENTICATION_USER=env-user",
+		"EUTERPE_NOT_A_KEY=ignored",
+	}
+	if err := applyEnv(&cfg, environ); err != nil {
+		t.Fatalf("applying environment: %s", err)
+	}
+
+	sets := []string{
+		"authentication.user=flag-user",
+		"library_scan.sleep_after_operation=15ms",
+		`trusted_proxies=["10.0.0.0/8"]`,
+		"read_timeout=30",
+		"artwork.sizes=100, 500",
+		`artwork.selection.patterns=[{"pattern": "folder.*", "score": 20}]`,
+	}
+	if err := applySetFlags(&cfg, sets); err != nil {
+		t.Fatalf("applying -set flags: %s", err)
+	}
+
+	if cfg.Listen != "0.0.0.0:9996" {
+		t.Errorf("wrong listen address: %s", cfg.Listen)
+	}
+	if !slices.Equal(cfg.Libraries, []string{"/music", "/more/music"}) {
+		t.Errorf("wrong libraries: %v", cfg.Libraries)
+	}
+	if !cfg.LibraryScan.Disable {
+		t.Errorf("library scan was not disabled")
+	}
+	if cfg.LibraryScan.SleepPerOperation != 15*time.Millisecond {
+		t.Errorf("wrong sleep after operation: %s", cfg.LibraryScan.SleepPerOperation)
+	}
+	if cfg.Authenticate.User != "flag-user" {
+		t.Errorf("expected the flag to win over the environment but user is %s",
+			cfg.Authenticate.User)
+	}
+	if !slices.Equal(cfg.TrustedProxies, []string{"10.0.0.0/8"}) {
+		t.Errorf("wrong trusted proxies: %v", cfg.TrustedProxies)
+	}
+	if cfg.ReadTimeout != 30 {
+		t.Errorf("wrong read timeout: %d", cfg.ReadTimeout)
+	}
+	if !slices.Equal(cfg.Artwork.Sizes, []int{100, 500}) {
+		t.Errorf("wrong artwork sizes: %v", cfg.Artwork.Sizes)
+	}
+	expectedPatterns := []ArtworkPattern{{Pattern: "folder.*", Score: 20}}
+	if !slices.Equal(cfg.Artwork.Selection.Patterns, expectedPatterns) {
+		t.Errorf("wrong artwork selection patterns: %v", cfg.Artwork.Selection.Patterns)
+	}
+	if cfg.WriteTimeout != defaultConfig.WriteTimeout {
+		t.Errorf("write timeout was changed to %d", cfg.WriteTimeout)
+	}
+}
+
+// TestOverridesErrors makes sure that wrong overrides are rejected with errors
+// which name their source.
+func TestOverridesErrors(t *testing.T) {
+	envTests := []struct {
+		variable string
+		source   string
+	}{
+		{"EUTERPE_READ_TIMEOUT=soon", "environment variable EUTERPE_READ_TIMEOUT"},
+		{"EUTERPE_GZIP=maybe", "environment variable EUTERPE_GZIP"},
+		{"EUTERPE_LOGIN_LIMITS_BACKOFF=10", "environment variable EUTERPE_LOGIN_LIMITS_BACKOFF"},
+	}
+	for _, test := range envTests {
+		cfg := defaultConfig
+		err := applyEnv(&cfg, []string{test.variable})
+		if err == nil || !strings.HasPrefix(err.Error(), test.source) {
+			t.Errorf("expected error from %s but got %v", test.source, err)
+		}
+	}
+
+	setTests := []struct {
+		set    string
+		source string
+	}{
+		{"listen", "flag -set listen"},
+		{"no_such_key=1", "flag -set no_such_key"},
+		{"library_scan=true", "flag -set library_scan"},
+		{"listen.port=80", "flag -set listen.port"},
+		{`libraries=["unterminated`, "flag -set libraries"},
+		{"artwork.sizes=100,big", "flag -set artwork.sizes"},
+		{"artwork.selection.patterns=folder.*", "flag -set artwork.selection.patterns"},
+	}
+	for _, test := range setTests {
+		cfg := defaultConfig
+		err := applySetFlags(&cfg, []string{test.set})
+		if err == nil || !strings.HasPrefix(err.Error(), test.source) {
+			t.Errorf("expected error from %s but got %v", test.source, err)
+		}
+	}
+
+	cfg := defaultConfig
+	err := applySetFlags(&cfg, []string{"no_such_key=1"})
+	if !errors.Is(err, errUnknownKey) {
+		t.Errorf("expected unknown key error but got %v", err)
+	}
+}
+
+// TestOverridesInValidationErrors checks that validation errors for overridden
+// keys name the environment variable or the flag which set them.
+func TestOverridesInValidationErrors(t *testing.T) {
+	cfg := defaultConfig
+	if err := applyEnv(&cfg, []string{"EUTERPE_LISTEN=nowhere"}); err != nil {
+		t.Fatalf("applying environment: %s", err)
+	}
+	err := applySetFlags(&cfg, []string{
+		`artwork.selection.patterns=[{"pattern": "cover.*", "score": 5}, {"pattern": "[", "score": 3}]`,
+	})
+	if err != nil {
+		t.Fatalf("applying -set flags: %s", err)
+	}
+
+	expected := map[string]string{
+		"listen":                          "environment variable EUTERPE_LISTEN",
+		"artwork.selection.patterns[1]":   "flag -set artwork.selection.patterns",
+		"artwork.selection.extensions[0]": "",
+	}
+	cfg.Artwork.Selection.Extensions = []string{"."}
+
+	found := make(map[string]bool)
+	for _, fieldErr := range cfg.Validate() {
+		source, ok := expected[fieldErr.Key]
+		if !ok {
+			continue
+		}
+		found[fieldErr.Key] = true
+
+		if fieldErr.Source != source {
+			t.Errorf("%s: expected source `%s` but got `%s`", fieldErr.Key, source,
+				fieldErr.Source)
+		}
+		if source != "" && !strings.Contains(fieldErr.Error(), source) {
+			t.Errorf("%s: expected the source in the error `%s`", fieldErr.Key, fieldErr)
+		}
+	}
+
+	for key := range expected {
+		if !found[key] {
+			t.Errorf("expected a validation error for %s", key)
+		}
+	}
+}
+
+// TestEnvKeysAreUnique makes sure that every configuration key could be overridden
+// by its own environment variable.
+func TestEnvKeysAreUnique(t *testing.T) {
+	var leaves func(t reflect.Type) int
+	leaves = func(t reflect.Type) int {
+		var count int
+		for i := range t.NumField() {
+			if jsonName(t.Field(i)) == "" {
+				continue
+			}
+			if t.Field(i).Type.Kind() == reflect.Struct {
+				count += leaves(t.Field(i).Type)
+			} else {
+				count++
+			}
+		}
+		return count
+	}
+
+	keys := envKeys()
+	if expected := leaves(reflect.TypeFor[Config]()); len(keys) != expected {
+		t.Errorf("expected %d environment variables but there are %d",
+			expected, len(keys))
+	}
+
+	for _, key := range []string{
+		"listen",
+		"library_scan.disable",
+		"authentication.previous_secrets",
+		"acme.domains",
+	} {
+		if !slices.Contains(slices.Collect(maps.Values(keys)), key) {
+			t.Errorf("key %s cannot be overridden from the environment", key)
+		}
+	}
+}
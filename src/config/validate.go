@@ -0,0 +1,406 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// oidcCallbackPath is the path of the endpoint to which the OpenID Connect provider
+// redirects the browser after logging in.
+const oidcCallbackPath = "/login/oidc/callback"
+
+// FieldError is a problem with the value of a single configuration key.
+type FieldError struct {
+	// Key is the path to the value in the configuration file, with dots between
+	// the levels and indexes for list items. For example "libraries[1]".
+	Key string
+
+	// Message describes what is wrong with the value.
+	Message string
+
+	// Fatal is true for errors with which Euterpe cannot run. The rest are only
+	// warnings.
+	Fatal bool
+
+	// Source is the environment variable or the -set flag which set the value.
+	// It is empty when the value is from the configuration file or the defaults.
+	Source string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	if e.Source != "" {
+		return fmt.Sprintf("%s (set by %s): %s", e.Key, e.Source, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// ValidationErrors are all the problems found in a configuration.
+type ValidationErrors []FieldError
+
+// Error implements the error interface.
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, fieldErr := range v {
+		messages = append(messages, fieldErr.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Fatal returns true when at least one of the errors is fatal.
+func (v ValidationErrors) Fatal() bool {
+	for _, fieldErr := range v {
+		if fieldErr.Fatal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the configuration for values which are wrong or could not be
+// used. This includes checking the files and directories it points to. Relative
+// paths are checked against the working directory. Returns nil when no problems
+// were found.
+func (c Config) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	fatal := func(key, format string, args ...any) {
+		errs = append(errs, FieldError{
+			Key:     key,
+			Message: fmt.Sprintf(format, args...),
+			Fatal:   true,
+			Source:  c.overrideSource(key),
+		})
+	}
+	warn := func(key, format string, args ...any) {
+		errs = append(errs, FieldError{
+			Key:     key,
+			Message: fmt.Sprintf(format, args...),
+			Source:  c.overrideSource(key),
+		})
+	}
+
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		fatal("listen", "wrong address: %s", err)
+	}
+
+	if c.SSL && len(c.ACME.Domains) == 0 {
+		if c.SSLCertificate.Crt == "" || c.SSLCertificate.Key == "" {
+			fatal("ssl_certificate", "both crt and key are required when ssl is on")
+		} else if _, err := tls.LoadX509KeyPair(
+			c.SSLCertificate.Crt,
+			c.SSLCertificate.Key,
+		); err != nil {
+			fatal("ssl_certificate", "cannot load certificate: %s", err)
+		}
+	}
+
+	if len(c.ACME.Domains) > 0 && c.ACME.CacheDir == "" {
+		fatal("acme.cache_dir", "must not be empty when domains are set")
+	}
+	for i, domain := range c.ACME.Domains {
+		if err := CheckACMEDomain(strings.ToLower(strings.TrimSpace(domain))); err != nil {
+			fatal(fmt.Sprintf("acme.domains[%d]", i), "%s", err)
+		}
+	}
+
+	if c.Auth {
+		if c.Authenticate.User == "" {
+			fatal("authentication.user", "must not be empty when authentication is on")
+		}
+		if c.Authenticate.Password == "" {
+			fatal("authentication.password", "must not be empty when authentication is on")
+		}
+	}
+
+	if c.Authenticate.Secret == "" && (c.Auth || c.OIDC.Issuer != "") {
+		fatal("authentication.secret", "must not be empty since tokens are signed with it")
+	}
+
+	for i, proxy := range c.TrustedProxies {
+		if err := checkAddrOrPrefix(proxy); err != nil {
+			fatal(fmt.Sprintf("trusted_proxies[%d]", i), "%s", err)
+		}
+	}
+
+	if c.ForwardAuth.Enable {
+		if len(c.ForwardAuth.Proxies) == 0 {
+			fatal("forward_auth.proxies", "at least one proxy is required")
+		}
+		for i, proxy := range c.ForwardAuth.Proxies {
+			if err := checkAddrOrPrefix(proxy); err != nil {
+				fatal(fmt.Sprintf("forward_auth.proxies[%d]", i), "%s", err)
+			}
+		}
+		if err := checkHeaderName(c.ForwardAuth.Header); err != nil {
+			fatal("forward_auth.header", "%s", err)
+		}
+		for i, user := range c.ForwardAuth.Users {
+			if strings.TrimSpace(user) == "" {
+				fatal(fmt.Sprintf("forward_auth.users[%d]", i), "must not be empty")
+			}
+		}
+		if len(c.ForwardAuth.Users) == 0 && c.Authenticate.User == "" {
+			fatal("forward_auth.users", "must not be empty when authentication.user is not set")
+		}
+	}
+
+	if c.OIDC.Issuer != "" {
+		if issuer, err := parseHTTPURL(c.OIDC.Issuer); err != nil {
+			fatal("oidc.issuer", "%s", err)
+		} else if issuer.RawQuery != "" || issuer.Fragment != "" {
+			fatal("oidc.issuer", "must not have a query or a fragment")
+		}
+		if c.OIDC.ClientID == "" {
+			fatal("oidc.client_id", "must not be empty when issuer is set")
+		}
+		if c.OIDC.RedirectURL == "" {
+			fatal("oidc.redirect_url", "must not be empty when issuer is set")
+		} else if redirect, err := parseHTTPURL(c.OIDC.RedirectURL); err != nil {
+			fatal("oidc.redirect_url", "%s", err)
+		} else if !strings.HasSuffix(redirect.Path, oidcCallbackPath) {
+			warn("oidc.redirect_url", "should point to the %s path", oidcCallbackPath)
+		}
+		if len(c.OIDC.AllowedSubjects) == 0 && len(c.OIDC.AllowedGroups) == 0 {
+			fatal("oidc.allowed_subjects", "allowed_subjects or allowed_groups is required")
+		}
+		for i, subject := range c.OIDC.AllowedSubjects {
+			if subject == "" {
+				fatal(fmt.Sprintf("oidc.allowed_subjects[%d]", i), "must not be empty")
+			}
+		}
+		for i, group := range c.OIDC.AllowedGroups {
+			if group == "" {
+				fatal(fmt.Sprintf("oidc.allowed_groups[%d]", i), "must not be empty")
+			}
+		}
+	}
+
+	for i, library := range c.Libraries {
+		key := fmt.Sprintf("libraries[%d]", i)
+		if st, err := os.Stat(library); err != nil {
+			warn(key, "%s", err)
+		} else if !st.IsDir() {
+			warn(key, "%s is not a directory", library)
+		}
+	}
+
+	if err := checkWritableFile(c.SqliteDatabase); err != nil {
+		fatal("sqlite_database", "%s", err)
+	}
+
+	if c.LogFile != "" {
+		if err := checkWritableFile(c.LogFile); err != nil {
+			warn("log_file", "%s", err)
+		}
+	}
+
+	seenProviders := make(map[string]bool)
+	for i, name := range c.Artwork.Providers {
+		key := fmt.Sprintf("artwork.providers[%d]", i)
+		if _, ok := c.Artwork.Provider(name); !ok {
+			fatal(key, "unknown artwork provider %q", name)
+		} else if seenProviders[name] {
+			warn(key, "%s is listed more than once", name)
+		}
+		seenProviders[name] = true
+	}
+
+	if c.DownloadArtwork {
+		if c.Artwork.FanartTV.Enable && c.Artwork.FanartTV.APIKey == "" {
+			warn("artwork.fanarttv.api_key", "must not be empty when fanarttv is enabled")
+		}
+		if c.Artwork.URLTemplate.Enable &&
+			c.Artwork.URLTemplate.AlbumURL == "" &&
+			c.Artwork.URLTemplate.ArtistURL == "" {
+			warn("artwork.url_template", "album_url or artist_url is required when enabled")
+		}
+	}
+
+	for _, name := range artworkProviders {
+		if provider, _ := c.Artwork.Provider(name); provider.RateLimit < 0 {
+			fatal("artwork."+name+".rate_limit", "must not be negative")
+		}
+	}
+
+	for i, size := range c.Artwork.Sizes {
+		if size <= 0 {
+			fatal(fmt.Sprintf("artwork.sizes[%d]", i), "must be a positive integer")
+		}
+	}
+
+	for i, pattern := range c.Artwork.Selection.Patterns {
+		key := fmt.Sprintf("artwork.selection.patterns[%d]", i)
+		if pattern.Pattern == "" {
+			fatal(key, "pattern must not be empty")
+		} else if _, err := path.Match(pattern.Pattern, ""); err != nil {
+			fatal(key, "invalid pattern %q: %s", pattern.Pattern, err)
+		}
+	}
+
+	for i, ext := range c.Artwork.Selection.Extensions {
+		if strings.Trim(ext, ". ") == "" {
+			fatal(fmt.Sprintf("artwork.selection.extensions[%d]", i), "must not be empty")
+		}
+	}
+
+	seenProviders = make(map[string]bool)
+	for i, name := range c.ArtistInfo.Providers {
+		key := fmt.Sprintf("artist_info.providers[%d]", i)
+		if !slices.Contains(artistInfoProviders, name) {
+			fatal(key, "unknown artist info provider %q", name)
+		} else if seenProviders[name] {
+			warn(key, "%s is listed more than once", name)
+		}
+		seenProviders[name] = true
+	}
+
+	if wiki := slices.Index(c.ArtistInfo.Providers, ArtistInfoWikipedia); wiki >= 0 {
+		mb := slices.Index(c.ArtistInfo.Providers, ArtistInfoMusicBrainz)
+		if mb < 0 || mb > wiki {
+			warn(
+				fmt.Sprintf("artist_info.providers[%d]", wiki),
+				"wikipedia finds nothing unless musicbrainz is before it",
+			)
+		}
+	}
+
+	seenProviders = make(map[string]bool)
+	for i, name := range c.AlbumInfo.Providers {
+		key := fmt.Sprintf("album_info.providers[%d]", i)
+		if !slices.Contains(albumInfoProviders, name) {
+			fatal(key, "unknown album info provider %q", name)
+		} else if seenProviders[name] {
+			warn(key, "%s is listed more than once", name)
+		}
+		seenProviders[name] = true
+	}
+
+	for _, number := range []struct {
+		key   string
+		value int64
+	}{
+		{"read_timeout", int64(c.ReadTimeout)},
+		{"write_timeout", int64(c.WriteTimeout)},
+		{"max_header_bytes", int64(c.MaxHeadersSize)},
+		{"library_scan.files_per_operation", c.LibraryScan.FilesPerOperation},
+		{"library_scan.parse_workers", int64(c.LibraryScan.ParseWorkers)},
+		{"library_scan.write_batch_size", int64(c.LibraryScan.WriteBatchSize)},
+		{"artwork.cache_bytes", c.Artwork.CacheBytes},
+		{"artwork.selection.min_size", int64(c.Artwork.Selection.MinSize)},
+	} {
+		if number.value < 0 {
+			fatal(number.key, "must not be negative")
+		}
+	}
+
+	return errs
+}
+
+// checkAddrOrPrefix returns an error when `value` is neither an IP address nor
+// a network in CIDR notation.
+func checkAddrOrPrefix(value string) error {
+	if strings.Contains(value, "/") {
+		_, err := netip.ParsePrefix(value)
+		return err
+	}
+
+	_, err := netip.ParseAddr(value)
+	return err
+}
+
+// parseHTTPURL parses `value` and returns an error when it is not an absolute
+// HTTP or HTTPS URL.
+func parseHTTPURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("%s is not a HTTP or HTTPS URL", value)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("%s has no host", value)
+	}
+	return parsed, nil
+}
+
+// checkHeaderName returns an error when `name` could not be used as a name of
+// a HTTP header.
+func checkHeaderName(name string) error {
+	if name == "" {
+		return errors.New("header name must not be empty")
+	}
+
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("!#$%&'*+-.^_`|~", r) {
+			continue
+		}
+		return fmt.Errorf("header name %q contains the invalid character %q", name, r)
+	}
+
+	return nil
+}
+
+// CheckACMEDomain returns an error when `domain` is not something for which a
+// certificate could be issued by an ACME certificate authority.
+func CheckACMEDomain(domain string) error {
+	if domain == "" {
+		return errors.New("domain must not be empty")
+	}
+	if net.ParseIP(domain) != nil {
+		return fmt.Errorf("domain %s is an IP address", domain)
+	}
+	if !strings.Contains(strings.Trim(domain, "."), ".") {
+		return fmt.Errorf("domain %s is not fully qualified", domain)
+	}
+	return nil
+}
+
+// checkWritableFile returns an error when the file at `path` could not be
+// written. Files which do not exist yet must be possible to create.
+func checkWritableFile(path string) error {
+	if path == "" {
+		return errors.New("path must not be empty")
+	}
+
+	// SQLite URIs such as in-memory databases are not files.
+	if strings.HasPrefix(path, "file:") {
+		return nil
+	}
+
+	st, err := os.Stat(path)
+	if err == nil {
+		if st.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+
+		fh, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("cannot write: %w", err)
+		}
+		return fh.Close()
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	fh, err := os.CreateTemp(filepath.Dir(path), ".euterpe-check-*")
+	if err != nil {
+		return fmt.Errorf("cannot create: %w", err)
+	}
+	fh.Close()
+	return os.Remove(fh.Name())
+}
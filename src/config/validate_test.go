@@ -0,0 +1,356 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/config"
+)
+
+// TestValidate checks that problems in the configuration are reported for the
+// right keys and with the right severity.
+func TestValidate(t *testing.T) {
+	tmpDir := t.TempDir()
+	notADir := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(notADir, []byte("text"), 0600); err != nil {
+		t.Fatalf("creating file: %s", err)
+	}
+
+	valid := config.Config{
+		Listen:         "localhost:9996",
+		Libraries:      []string{tmpDir},
+		SqliteDatabase: filepath.Join(tmpDir, "euterpe.db"),
+		LogFile:        filepath.Join(tmpDir, "euterpe.log"),
+		Auth:           true,
+		Authenticate: config.Auth{
+			User:     "user",
+			Password: "pass",
+			Secret:   "secret",
+		},
+	}
+
+	if errs := valid.Validate(); len(errs) != 0 {
+		t.Fatalf("expected valid configuration but got: %s", errs)
+	}
+
+	validOIDC := config.OIDC{
+		Issuer:          "https://accounts.example.com",
+		ClientID:        "euterpe",
+		RedirectURL:     "https://music.example.com/login/oidc/callback",
+		AllowedSubjects: []string{"1234"},
+	}
+	withOIDC := valid
+	withOIDC.OIDC = validOIDC
+	if errs := withOIDC.Validate(); len(errs) != 0 {
+		t.Fatalf("expected valid OIDC configuration but got: %s", errs)
+	}
+
+	tests := []struct {
+		desc   string
+		change func(*config.Config)
+		key    string
+		fatal  bool
+	}{
+		{
+			desc:   "missing library",
+			change: func(c *config.Config) { c.Libraries = append(c.Libraries, "/no/such/dir") },
+			key:    "libraries[1]",
+		},
+		{
+			desc:   "library is a file",
+			change: func(c *config.Config) { c.Libraries = []string{notADir} },
+			key:    "libraries[0]",
+		},
+		{
+			desc: "unreadable TLS files",
+			change: func(c *config.Config) {
+				c.SSL = true
+				c.SSLCertificate = config.Cert{
+					Crt: filepath.Join(tmpDir, "missing.crt"),
+					Key: filepath.Join(tmpDir, "missing.key"),
+				}
+			},
+			key:   "ssl_certificate",
+			fatal: true,
+		},
+		{
+			desc: "ACME domain is an IP address",
+			change: func(c *config.Config) {
+				c.ACME = config.ACME{
+					Domains:  []string{"music.example.com", "127.0.0.1"},
+					CacheDir: tmpDir,
+				}
+			},
+			key:   "acme.domains[1]",
+			fatal: true,
+		},
+		{
+			desc: "ACME domain is not fully qualified",
+			change: func(c *config.Config) {
+				c.ACME = config.ACME{Domains: []string{"localhost"}, CacheDir: tmpDir}
+			},
+			key:   "acme.domains[0]",
+			fatal: true,
+		},
+		{
+			desc:   "empty secret",
+			change: func(c *config.Config) { c.Authenticate.Secret = "" },
+			key:    "authentication.secret",
+			fatal:  true,
+		},
+		{
+			desc: "unwritable database path",
+			change: func(c *config.Config) {
+				c.SqliteDatabase = filepath.Join(tmpDir, "no", "such", "dir", "euterpe.db")
+			},
+			key:   "sqlite_database",
+			fatal: true,
+		},
+		{
+			desc:   "database is a directory",
+			change: func(c *config.Config) { c.SqliteDatabase = tmpDir },
+			key:    "sqlite_database",
+			fatal:  true,
+		},
+		{
+			desc:   "wrong listen address",
+			change: func(c *config.Config) { c.Listen = "localhost" },
+			key:    "listen",
+			fatal:  true,
+		},
+		{
+			desc:   "wrong trusted proxy",
+			change: func(c *config.Config) { c.TrustedProxies = []string{"10.0.0.0/33"} },
+			key:    "trusted_proxies[0]",
+			fatal:  true,
+		},
+		{
+			desc: "forward auth without proxies",
+			change: func(c *config.Config) {
+				c.ForwardAuth = config.ForwardAuth{Enable: true, Header: "Remote-User"}
+			},
+			key:   "forward_auth.proxies",
+			fatal: true,
+		},
+		{
+			desc: "wrong forward auth proxy",
+			change: func(c *config.Config) {
+				c.ForwardAuth = config.ForwardAuth{
+					Enable:  true,
+					Header:  "Remote-User",
+					Proxies: []string{"127.0.0.1", "proxy.example.com"},
+				}
+			},
+			key:   "forward_auth.proxies[1]",
+			fatal: true,
+		},
+		{
+			desc: "empty forward auth header",
+			change: func(c *config.Config) {
+				c.ForwardAuth = config.ForwardAuth{Enable: true, Proxies: []string{"10.0.0.0/8"}}
+			},
+			key:   "forward_auth.header",
+			fatal: true,
+		},
+		{
+			desc: "wrong forward auth header",
+			change: func(c *config.Config) {
+				c.ForwardAuth = config.ForwardAuth{
+					Enable:  true,
+					Header:  "Remote User",
+					Proxies: []string{"10.0.0.0/8"},
+				}
+			},
+			key:   "forward_auth.header",
+			fatal: true,
+		},
+		{
+			desc: "empty forward auth user",
+			change: func(c *config.Config) {
+				c.ForwardAuth = config.ForwardAuth{
+					Enable:  true,
+					Header:  "Remote-User",
+					Proxies: []string{"10.0.0.0/8"},
+					Users:   []string{"alice", " "},
+				}
+			},
+			key:   "forward_auth.users[1]",
+			fatal: true,
+		},
+		{
+			desc: "forward auth without users",
+			change: func(c *config.Config) {
+				c.Auth = false
+				c.Authenticate.User = ""
+				c.Authenticate.Password = ""
+				c.ForwardAuth = config.ForwardAuth{
+					Enable:  true,
+					Header:  "Remote-User",
+					Proxies: []string{"10.0.0.0/8"},
+				}
+			},
+			key:   "forward_auth.users",
+			fatal: true,
+		},
+		{
+			desc: "OIDC issuer is not a URL",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.Issuer = "accounts.example.com"
+			},
+			key:   "oidc.issuer",
+			fatal: true,
+		},
+		{
+			desc: "OIDC issuer with a query",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.Issuer = "https://accounts.example.com/?realm=music"
+			},
+			key:   "oidc.issuer",
+			fatal: true,
+		},
+		{
+			desc: "OIDC without a client ID",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.ClientID = ""
+			},
+			key:   "oidc.client_id",
+			fatal: true,
+		},
+		{
+			desc: "OIDC redirect URL is not absolute",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.RedirectURL = "/login/oidc/callback"
+			},
+			key:   "oidc.redirect_url",
+			fatal: true,
+		},
+		{
+			desc: "OIDC redirect URL to another path",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.RedirectURL = "https://music.example.com/login/"
+			},
+			key: "oidc.redirect_url",
+		},
+		{
+			desc: "OIDC without allowed users",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.AllowedSubjects = nil
+			},
+			key:   "oidc.allowed_subjects",
+			fatal: true,
+		},
+		{
+			desc: "empty OIDC allowed subject",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.AllowedSubjects = []string{"1234", ""}
+			},
+			key:   "oidc.allowed_subjects[1]",
+			fatal: true,
+		},
+		{
+			desc: "empty OIDC allowed group",
+			change: func(c *config.Config) {
+				c.OIDC = validOIDC
+				c.OIDC.AllowedGroups = []string{""}
+			},
+			key:   "oidc.allowed_groups[0]",
+			fatal: true,
+		},
+		{
+			desc: "unknown artwork provider",
+			change: func(c *config.Config) {
+				c.Artwork.Providers = []string{"local", "no-such-provider"}
+			},
+			key:   "artwork.providers[1]",
+			fatal: true,
+		},
+		{
+			desc: "fanarttv without a key",
+			change: func(c *config.Config) {
+				c.DownloadArtwork = true
+				c.Artwork.FanartTV.Enable = true
+			},
+			key: "artwork.fanarttv.api_key",
+		},
+		{
+			desc:   "zero artwork size",
+			change: func(c *config.Config) { c.Artwork.Sizes = []int{60, 0} },
+			key:    "artwork.sizes[1]",
+			fatal:  true,
+		},
+		{
+			desc: "bad artwork selection pattern",
+			change: func(c *config.Config) {
+				c.Artwork.Selection.Patterns = []config.ArtworkPattern{
+					{Pattern: "cover.*", Score: 10},
+					{Pattern: "[cover", Score: 5},
+				}
+			},
+			key:   "artwork.selection.patterns[1]",
+			fatal: true,
+		},
+		{
+			desc:   "negative artwork minimal size",
+			change: func(c *config.Config) { c.Artwork.Selection.MinSize = -1 },
+			key:    "artwork.selection.min_size",
+			fatal:  true,
+		},
+		{
+			desc: "unknown artist info provider",
+			change: func(c *config.Config) {
+				c.ArtistInfo.Providers = []string{"musicbrainz", "allmusic"}
+			},
+			key:   "artist_info.providers[1]",
+			fatal: true,
+		},
+		{
+			desc: "wikipedia before musicbrainz",
+			change: func(c *config.Config) {
+				c.ArtistInfo.Providers = []string{"wikipedia", "musicbrainz"}
+			},
+			key: "artist_info.providers[0]",
+		},
+		{
+			desc: "unknown album info provider",
+			change: func(c *config.Config) {
+				c.AlbumInfo.Providers = []string{"discogs"}
+			},
+			key:   "album_info.providers[0]",
+			fatal: true,
+		},
+		{
+			desc:   "negative timeout",
+			change: func(c *config.Config) { c.WriteTimeout = -1 },
+			key:    "write_timeout",
+			fatal:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cfg := valid
+			cfg.Libraries = append([]string(nil), valid.Libraries...)
+			test.change(&cfg)
+
+			errs := cfg.Validate()
+			if len(errs) != 1 {
+				t.Fatalf("expected exactly one error but got %d: %s", len(errs), errs)
+			}
+
+			if errs[0].Key != test.key {
+				t.Errorf("expected error for key %s but got %s", test.key, errs[0].Key)
+			}
+			if errs[0].Fatal != test.fatal || errs.Fatal() != test.fatal {
+				t.Errorf("expected fatal to be %t but got %t", test.fatal, errs[0].Fatal)
+			}
+		})
+	}
+}
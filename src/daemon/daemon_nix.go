@@ -10,3 +10,9 @@ var StopSignals = []syscall.Signal{
 	syscall.SIGKILL,
 	syscall.SIGTERM,
 }
+
+// ReloadSignals contains all the signals which will make our daemon reload its
+// configuration
+var ReloadSignals = []syscall.Signal{
+	syscall.SIGHUP,
+}
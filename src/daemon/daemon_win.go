@@ -8,3 +8,6 @@ var StopSignals []os.Signal = []os.Signal{
 	os.Interrupt,
 	os.Kill,
 }
+
+// ReloadSignals is empty since there is no signal for reloading on Windows.
+var ReloadSignals []os.Signal
@@ -0,0 +1,84 @@
+// Package devices keeps track of the authentication tokens given out to devices
+// and browsers so that they could be listed and revoked one by one.
+package devices
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//counterfeiter:generate . Registry
+
+// Registry is the interface for storing the devices which were given access tokens.
+// Devices are identified by the unique ID of their token (the JWT "jti" claim).
+type Registry interface {
+	// Register stores a new device for the token with ID `new.TokenID`. The ID,
+	// CreatedAt, LastSeenAt and RevokedAt fields are ignored.
+	//
+	// Returns the ID of the newly created device when error is nil.
+	Register(ctx context.Context, new Device) (int64, error)
+
+	// Check makes sure the token with ID `tokenID` may be used. It returns
+	// ErrNotFound for unknown tokens and ErrRevoked for revoked ones. On success
+	// the last seen time of the device is updated.
+	Check(ctx context.Context, tokenID string) error
+
+	// Update changes the name and user agent of the device with token `tokenID`.
+	// Empty values leave the stored ones unchanged.
+	Update(ctx context.Context, tokenID, name, userAgent string) error
+
+	// List returns all devices, including the revoked ones.
+	List(ctx context.Context) ([]Device, error)
+
+	// Revoke makes the token of device with ID `deviceID` unusable.
+	Revoke(ctx context.Context, deviceID int64) error
+
+	// RevokeToken is like Revoke but the device is the one with token `tokenID`.
+	RevokeToken(ctx context.Context, tokenID string) error
+
+	// RotateRefreshToken records that the refresh token with ID `usedID` was
+	// exchanged for the one with ID `newID` by the device with token `tokenID`.
+	// Only the last refresh token given to a device could be used and only once.
+	// Returns ErrRefreshTokenUsed when `usedID` is not it and ErrNotFound for
+	// unknown devices.
+	RotateRefreshToken(ctx context.Context, tokenID, usedID, newID string) error
+}
+
+// Device represents a single device or browser with access to the server.
+type Device struct {
+	// ID is a unique identifier for the device.
+	ID int64
+
+	// TokenID is the unique ID of the access token given to the device.
+	TokenID string
+
+	// Name is a human readable name of the device.
+	Name string
+
+	// UserAgent is the user agent of the last client which registered or
+	// updated this device.
+	UserAgent string
+
+	// CreatedAt is the time at which the token was given out.
+	CreatedAt time.Time
+
+	// LastSeenAt is the last time at which the token was used.
+	LastSeenAt time.Time
+
+	// RevokedAt is the time at which the device was revoked. It is nil for devices
+	// which still have access.
+	RevokedAt *time.Time
+}
+
+var (
+	// ErrNotFound is returned when a device was not found for a given operation.
+	ErrNotFound = errors.New("device not found")
+
+	// ErrRevoked is returned when checking a token which has been revoked.
+	ErrRevoked = errors.New("device has been revoked")
+
+	// ErrRefreshTokenUsed is returned when a refresh token is used for a second
+	// time or after a newer one was given to its device.
+	ErrRefreshTokenUsed = errors.New("refresh token has been used already")
+)
@@ -0,0 +1,167 @@
+package devices_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// TestDevicesManager uses the Registry interface to test registering, checking and
+// revoking devices.
+func TestDevicesManager(t *testing.T) {
+	ctx := context.Background()
+
+	lib := getLibrary(ctx, t)
+	defer func() {
+		_ = lib.Truncate()
+	}()
+	registry := devices.NewManager(lib.ExecuteDBJobAndWait)
+
+	all, err := registry.List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %s", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("Expected no devices in the DB but there were %d", len(all))
+	}
+
+	if _, err := registry.Register(ctx, devices.Device{}); err == nil {
+		t.Errorf("Expected error when registering a device without a token ID")
+	}
+
+	deviceID, err := registry.Register(ctx, devices.Device{
+		TokenID:   "token-one",
+		Name:      "Phone",
+		UserAgent: "Test Agent/1.0",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register a device: %s", err)
+	}
+
+	if err := registry.Check(ctx, "token-one"); err != nil {
+		t.Errorf("Expected registered token to be valid but got: %s", err)
+	}
+
+	if err := registry.Check(ctx, "unknown-token"); !errors.Is(err, devices.ErrNotFound) {
+		t.Errorf("Expected not found error for unknown token but got: %v", err)
+	}
+
+	if err := registry.Update(ctx, "token-one", "Tablet", ""); err != nil {
+		t.Errorf("Failed to update device: %s", err)
+	}
+
+	err = registry.Update(ctx, "unknown-token", "Tablet", "")
+	if !errors.Is(err, devices.ErrNotFound) {
+		t.Errorf("Expected not found error when updating unknown token but got: %v", err)
+	}
+
+	all, err = registry.List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %s", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("Expected one device but got %d", len(all))
+	}
+
+	device := all[0]
+	if device.ID != deviceID {
+		t.Errorf("Expected device ID %d but got %d", deviceID, device.ID)
+	}
+	if device.Name != "Tablet" {
+		t.Errorf("Expected device name `Tablet` but got `%s`", device.Name)
+	}
+	if device.UserAgent != "Test Agent/1.0" {
+		t.Errorf("Expected user agent to be kept but got `%s`", device.UserAgent)
+	}
+	if device.CreatedAt.IsZero() || device.LastSeenAt.IsZero() {
+		t.Errorf("Expected created and last seen times to be set: %+v", device)
+	}
+	if device.RevokedAt != nil {
+		t.Errorf("Expected device not to be revoked but it was at %s", device.RevokedAt)
+	}
+
+	rotations := []struct {
+		usedID   string
+		newID    string
+		expected error
+	}{
+		{usedID: "refresh-login", newID: "refresh-one"},
+		{usedID: "refresh-one", newID: "refresh-two"},
+		{usedID: "refresh-one", newID: "refresh-three", expected: devices.ErrRefreshTokenUsed},
+		{usedID: "refresh-login", newID: "refresh-three", expected: devices.ErrRefreshTokenUsed},
+		{usedID: "refresh-two", newID: "refresh-three"},
+	}
+	for _, rotation := range rotations {
+		err := registry.RotateRefreshToken(ctx, "token-one", rotation.usedID, rotation.newID)
+		if !errors.Is(err, rotation.expected) {
+			t.Errorf("Expected error `%v` when using refresh token %s but got: %v",
+				rotation.expected, rotation.usedID, err)
+		}
+	}
+
+	err = registry.RotateRefreshToken(ctx, "unknown-token", "refresh-one", "refresh-two")
+	if !errors.Is(err, devices.ErrNotFound) {
+		t.Errorf("Expected not found error when refreshing unknown token but got: %v", err)
+	}
+
+	if err := registry.Revoke(ctx, deviceID); err != nil {
+		t.Fatalf("Failed to revoke device: %s", err)
+	}
+
+	if err := registry.Check(ctx, "token-one"); !errors.Is(err, devices.ErrRevoked) {
+		t.Errorf("Expected revoked error but got: %v", err)
+	}
+
+	if err := registry.Revoke(ctx, deviceID+1); !errors.Is(err, devices.ErrNotFound) {
+		t.Errorf("Expected not found error when revoking unknown device but got: %v", err)
+	}
+
+	if _, err := registry.Register(ctx, devices.Device{TokenID: "token-two"}); err != nil {
+		t.Fatalf("Failed to register a second device: %s", err)
+	}
+	if err := registry.RevokeToken(ctx, "token-two"); err != nil {
+		t.Errorf("Failed to revoke device by its token: %s", err)
+	}
+	if err := registry.Check(ctx, "token-two"); !errors.Is(err, devices.ErrRevoked) {
+		t.Errorf("Expected revoked error for the second device but got: %v", err)
+	}
+	err = registry.RevokeToken(ctx, "unknown-token")
+	if !errors.Is(err, devices.ErrNotFound) {
+		t.Errorf("Expected not found error when revoking unknown token but got: %v", err)
+	}
+
+	all, err = registry.List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %s", err)
+	}
+	if len(all) != 2 || all[0].RevokedAt == nil || all[1].RevokedAt == nil {
+		t.Errorf("Expected the revoked devices to be listed as revoked: %+v", all)
+	}
+}
+
+// getTestMigrationFiles returns the SQLs directory used by the application itself
+// normally. This way tests will be done with the exact same files which will be
+// bundled into the binary on build.
+func getTestMigrationFiles() fs.FS {
+	return os.DirFS("../../sqls")
+}
+
+// It is the caller's responsibility to remove the library SQLite database file
+func getLibrary(ctx context.Context, t *testing.T) *library.LocalLibrary {
+	lib, err := library.NewLocalLibrary(ctx, library.SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = lib.Initialize()
+	if err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	return lib
+}
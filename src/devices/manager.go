@@ -0,0 +1,344 @@
+package devices
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// lastSeenResolution is the minimal time between two updates of the last seen
+// time of a device. Tokens are checked on every request and writing into the
+// database each time would be wasteful.
+const lastSeenResolution = time.Minute
+
+// manager implements the Registry interface by just requiring a function for
+// sending database work.
+type manager struct {
+	executeDBJobAndWait func(library.DatabaseExecutable) error
+}
+
+// NewManager returns a Registry interface which will use the `sendDBWork` to
+// execute its database queries.
+func NewManager(sendDBWork func(library.DatabaseExecutable) error) Registry {
+	return &manager{
+		executeDBJobAndWait: sendDBWork,
+	}
+}
+
+// Register implements the Registry interface.
+func (m *manager) Register(ctx context.Context, new Device) (int64, error) {
+	if new.TokenID == "" {
+		return 0, fmt.Errorf("token ID cannot be empty")
+	}
+
+	var lastInsertID int64
+
+	query := `
+		INSERT INTO
+			devices (token_id, name, user_agent, created_at, last_seen_at)
+		VALUES
+			(@tokenID, @name, @userAgent, @now, @now)
+	`
+
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query,
+			sql.Named("tokenID", new.TokenID),
+			sql.Named("name", new.Name),
+			sql.Named("userAgent", new.UserAgent),
+			sql.Named("now", time.Now().Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert device: %w", err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("cannot get last insert ID for device: %w", err)
+		}
+
+		lastInsertID = id
+		return nil
+	}
+
+	if err := m.executeDBJobAndWait(work); err != nil {
+		return 0, err
+	}
+
+	return lastInsertID, nil
+}
+
+// Check implements the Registry interface.
+func (m *manager) Check(ctx context.Context, tokenID string) error {
+	selectQuery := `
+		SELECT last_seen_at, revoked_at
+		FROM devices
+		WHERE token_id = @tokenID
+	`
+	updateQuery := `
+		UPDATE devices
+		SET last_seen_at = @now
+		WHERE token_id = @tokenID
+	`
+
+	work := func(db *sql.DB) error {
+		var (
+			lastSeen  int64
+			revokedAt sql.NullInt64
+		)
+
+		row := db.QueryRowContext(ctx, selectQuery, sql.Named("tokenID", tokenID))
+		err := row.Scan(&lastSeen, &revokedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("could not query the database: %w", err)
+		}
+
+		if revokedAt.Valid {
+			return ErrRevoked
+		}
+
+		now := time.Now()
+		if now.Sub(time.Unix(lastSeen, 0)) < lastSeenResolution {
+			return nil
+		}
+
+		_, err = db.ExecContext(ctx, updateQuery,
+			sql.Named("tokenID", tokenID),
+			sql.Named("now", now.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update device last seen time: %w", err)
+		}
+
+		return nil
+	}
+
+	return m.executeDBJobAndWait(work)
+}
+
+// Update implements the Registry interface.
+func (m *manager) Update(
+	ctx context.Context,
+	tokenID, name, userAgent string,
+) error {
+	query := `
+		UPDATE
+			devices
+		SET
+			name = CASE WHEN @name = '' THEN name ELSE @name END,
+			user_agent = CASE WHEN @userAgent = '' THEN user_agent ELSE @userAgent END
+		WHERE
+			token_id = @tokenID
+	`
+
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query,
+			sql.Named("tokenID", tokenID),
+			sql.Named("name", name),
+			sql.Named("userAgent", userAgent),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update device: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("cannot get the number of affected devices: %w", err)
+		}
+
+		if affected != 1 {
+			return ErrNotFound
+		}
+
+		return nil
+	}
+
+	return m.executeDBJobAndWait(work)
+}
+
+// List implements the Registry interface.
+func (m *manager) List(ctx context.Context) ([]Device, error) {
+	var devices []Device
+	query := `
+		SELECT id, token_id, name, user_agent, created_at, last_seen_at, revoked_at
+		FROM devices
+		ORDER BY id
+	`
+
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("could not query the database: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				device    Device
+				createdAt int64
+				lastSeen  int64
+				revokedAt sql.NullInt64
+			)
+
+			err := rows.Scan(
+				&device.ID,
+				&device.TokenID,
+				&device.Name,
+				&device.UserAgent,
+				&createdAt,
+				&lastSeen,
+				&revokedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("error scanning device: %w", err)
+			}
+
+			device.CreatedAt = time.Unix(createdAt, 0)
+			device.LastSeenAt = time.Unix(lastSeen, 0)
+			if revokedAt.Valid {
+				revoked := time.Unix(revokedAt.Int64, 0)
+				device.RevokedAt = &revoked
+			}
+
+			devices = append(devices, device)
+		}
+
+		return rows.Err()
+	}
+	if err := m.executeDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// Revoke implements the Registry interface.
+func (m *manager) Revoke(ctx context.Context, deviceID int64) error {
+	query := `
+		UPDATE
+			devices
+		SET
+			revoked_at = COALESCE(revoked_at, @now)
+		WHERE
+			id = @id
+	`
+
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query,
+			sql.Named("id", deviceID),
+			sql.Named("now", time.Now().Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to revoke device: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("cannot get the number of affected devices: %w", err)
+		}
+
+		if affected != 1 {
+			return ErrNotFound
+		}
+
+		return nil
+	}
+
+	return m.executeDBJobAndWait(work)
+}
+
+// RevokeToken implements the Registry interface.
+func (m *manager) RevokeToken(ctx context.Context, tokenID string) error {
+	query := `
+		UPDATE
+			devices
+		SET
+			revoked_at = COALESCE(revoked_at, @now)
+		WHERE
+			token_id = @tokenID
+	`
+
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query,
+			sql.Named("tokenID", tokenID),
+			sql.Named("now", time.Now().Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to revoke device: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("cannot get the number of affected devices: %w", err)
+		}
+
+		if affected != 1 {
+			return ErrNotFound
+		}
+
+		return nil
+	}
+
+	return m.executeDBJobAndWait(work)
+}
+
+// RotateRefreshToken implements the Registry interface.
+func (m *manager) RotateRefreshToken(
+	ctx context.Context,
+	tokenID, usedID, newID string,
+) error {
+	// Devices which have not refreshed their tokens yet have only the refresh
+	// token given to them on login.
+	updateQuery := `
+		UPDATE
+			devices
+		SET
+			refresh_token_id = @newID
+		WHERE
+			token_id = @tokenID AND
+			(refresh_token_id IS NULL OR refresh_token_id = @usedID)
+	`
+	selectQuery := `
+		SELECT id
+		FROM devices
+		WHERE token_id = @tokenID
+	`
+
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, updateQuery,
+			sql.Named("tokenID", tokenID),
+			sql.Named("usedID", usedID),
+			sql.Named("newID", newID),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update device refresh token: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("cannot get the number of affected devices: %w", err)
+		}
+
+		if affected == 1 {
+			return nil
+		}
+
+		var id int64
+		row := db.QueryRowContext(ctx, selectQuery, sql.Named("tokenID", tokenID))
+		err = row.Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("could not query the database: %w", err)
+		}
+
+		return ErrRefreshTokenUsed
+	}
+
+	return m.executeDBJobAndWait(work)
+}
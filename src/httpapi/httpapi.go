@@ -0,0 +1,97 @@
+// Package httpapi makes requests to the HTTP APIs of the internet services from
+// which Euterpe gets images and information about artists and albums.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Timeout is the maximum duration of a single API request.
+const Timeout = 10 * time.Second
+
+// ErrNotFound is returned when an API responds with HTTP 404.
+var ErrNotFound = errors.New("not found")
+
+// GetJSON makes a GET request to `apiURL` and decodes the JSON in the response
+// into `dst`. HTTP 404 is reported as ErrNotFound and every other status but
+// HTTP 200 is an error.
+func GetJSON(ctx context.Context, useragent, apiURL string, dst any) error {
+	return get(ctx, useragent, apiURL, "application/json", func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(dst)
+	})
+}
+
+// GetXML is like GetJSON but decodes XML responses.
+func GetXML(ctx context.Context, useragent, apiURL string, dst any) error {
+	return get(ctx, useragent, apiURL, "application/xml", func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(dst)
+	})
+}
+
+// FetchJSON makes a GET request to `apiURL` and decodes the JSON in the response
+// into `dst` whatever its HTTP status is. The status is returned so that APIs which
+// describe their errors in JSON could be handled. Responses which could not be
+// decoded are an error only when their status is HTTP 200.
+func FetchJSON(ctx context.Context, useragent, apiURL string, dst any) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	resp, err := do(ctx, useragent, apiURL, "application/json")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(dst)
+	if err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("decoding JSON API response: %w", err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func get(
+	ctx context.Context,
+	useragent, apiURL, accept string,
+	decode func(io.Reader) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	resp, err := do(ctx, useragent, apiURL, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned HTTP %d", resp.StatusCode)
+	}
+
+	if err := decode(resp.Body); err != nil {
+		return fmt.Errorf("decoding API response: %w", err)
+	}
+
+	return nil
+}
+
+func do(ctx context.Context, useragent, apiURL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Set("User-Agent", useragent)
+	req.Header.Set("Accept", accept)
+
+	return http.DefaultClient.Do(req)
+}
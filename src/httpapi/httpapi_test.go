@@ -0,0 +1,79 @@
+package httpapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/httpapi"
+)
+
+// TestGetAndFetch checks that requests are made with the user agent and that
+// responses are decoded or turned into errors depending on their status.
+func TestGetAndFetch(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("User-Agent") != "euterpe/testing" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"name": "Iron Maiden"}`)
+	})
+	mux.HandleFunc("/xml", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `<artist><name>Iron Maiden</name></artist>`)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "no such artist"}`)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	type result struct {
+		Name  string `json:"name" xml:"name"`
+		Error string `json:"error"`
+	}
+
+	var found result
+	if err := httpapi.GetJSON(ctx, "euterpe/testing", srv.URL+"/ok", &found); err != nil {
+		t.Fatalf("getting JSON: %s", err)
+	}
+	if found.Name != "Iron Maiden" {
+		t.Errorf("wrong decoded name: %q", found.Name)
+	}
+
+	var fromXML result
+	if err := httpapi.GetXML(ctx, "euterpe/testing", srv.URL+"/xml", &fromXML); err != nil {
+		t.Fatalf("getting XML: %s", err)
+	}
+	if fromXML.Name != "Iron Maiden" {
+		t.Errorf("wrong decoded name from XML: %q", fromXML.Name)
+	}
+
+	err := httpapi.GetJSON(ctx, "euterpe/testing", srv.URL+"/missing", &result{})
+	if !errors.Is(err, httpapi.ErrNotFound) {
+		t.Errorf("expected not found error but got %v", err)
+	}
+
+	err = httpapi.GetJSON(ctx, "euterpe/testing", srv.URL+"/broken", &result{})
+	if err == nil || errors.Is(err, httpapi.ErrNotFound) {
+		t.Errorf("expected an error for HTTP 500 but got %v", err)
+	}
+
+	var missing result
+	status, err := httpapi.FetchJSON(ctx, "euterpe/testing", srv.URL+"/missing", &missing)
+	if err != nil {
+		t.Fatalf("fetching JSON: %s", err)
+	}
+	if status != http.StatusNotFound || missing.Error != "no such artist" {
+		t.Errorf("unexpected status %d and error %q", status, missing.Error)
+	}
+}
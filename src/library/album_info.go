@@ -0,0 +1,495 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+	"github.com/ironsmile/euterpe/src/albuminfo"
+)
+
+// defaultAlbumInfoTTL is for how long information about albums found on the
+// internet is used before it is searched for again when no other TTL is set.
+const defaultAlbumInfoTTL = 30 * 24 * time.Hour
+
+// releaseTypesSeparator separates the release types when they are stored in
+// the database.
+const releaseTypesSeparator = ";"
+
+// AlbumInfo is what is known about the release of an album. It is read from the
+// tags of the album tracks. What is missing from them is filled in with what was
+// found on the internet.
+type AlbumInfo struct {
+	// MusicBrainzID is the ID of the release in the MusicBrainz database.
+	MusicBrainzID string `json:"musicbrainz_id,omitempty"`
+
+	// Notes is free text about the release.
+	Notes string `json:"notes,omitempty"`
+
+	// Label is the name of the record label which released the album.
+	Label string `json:"label,omitempty"`
+
+	// CatalogNumber is the number given to the release by its label.
+	CatalogNumber string `json:"catalog_number,omitempty"`
+
+	// OriginalReleaseDate is the date at which the album was first released
+	// in the YYYY[-MM[-DD]] format.
+	OriginalReleaseDate string `json:"original_release_date,omitempty"`
+
+	// ReleaseTypes are lower case types such as "album", "ep", "single", "live"
+	// or "compilation". The primary type is first.
+	ReleaseTypes []string `json:"release_types,omitempty"`
+
+	// Barcode is the UPC or EAN code of the release.
+	Barcode string `json:"barcode,omitempty"`
+
+	// UpdatedAt is the Unix timestamp at which the information was searched
+	// for on the internet. It is zero when it has never been searched for.
+	UpdatedAt int64 `json:"info_updated_at,omitempty"`
+}
+
+// releaseTags is the information about the album release found in the tags of
+// a single track.
+type releaseTags struct {
+	label         string
+	catalogNumber string
+	originalDate  string
+	releaseTypes  string
+	barcode       string
+	musicBrainzID string
+}
+
+// isEmpty returns true when none of the release tags were found.
+func (rt releaseTags) isEmpty() bool {
+	return rt == releaseTags{}
+}
+
+// SetAlbumInfoFinder sets the finder which is used for searching for information
+// about albums on the internet. Found information is stored and used for `ttl`
+// before it is searched for again. When the TTL is not positive a default is used.
+func (lib *LocalLibrary) SetAlbumInfoFinder(finder albuminfo.Finder, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultAlbumInfoTTL
+	}
+
+	lib.albumInfoFinder = finder
+	lib.albumInfoTTL = ttl
+}
+
+// GetAlbumInfo returns what is known about the release of the album with ID
+// `albumID` without searching for anything on the internet. ErrAlbumNotFound is
+// returned when there is no such album.
+func (lib *LocalLibrary) GetAlbumInfo(ctx context.Context, albumID int64) (AlbumInfo, error) {
+	tags, found, err := lib.storedAlbumInfo(ctx, albumID)
+	if err != nil {
+		return AlbumInfo{}, err
+	}
+
+	return mergeAlbumInfo(tags, found), nil
+}
+
+// FindAlbumInfo is like GetAlbumInfo but the information is searched for on the
+// internet with the album info finder first when the stored one is missing or
+// older than the TTL. Stored information is returned even when it is old if
+// searching for it fails.
+func (lib *LocalLibrary) FindAlbumInfo(ctx context.Context, albumID int64) (AlbumInfo, error) {
+	tags, found, err := lib.storedAlbumInfo(ctx, albumID)
+	if err != nil {
+		return AlbumInfo{}, err
+	}
+
+	if lib.albumInfoFinder == nil ||
+		time.Since(time.Unix(found.UpdatedAt, 0)) <= lib.albumInfoTTL {
+		return mergeAlbumInfo(tags, found), nil
+	}
+
+	album, err := lib.GetAlbum(ctx, albumID)
+	if err != nil {
+		return AlbumInfo{}, err
+	}
+
+	info, err := lib.albumInfoFinder.GetAlbumInfo(ctx, albuminfo.Query{
+		Artist:        album.Artist,
+		Album:         album.Name,
+		MusicBrainzID: tags.MusicBrainzID,
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return AlbumInfo{}, ctxErr
+	}
+
+	if err == nil || errors.Is(err, albuminfo.ErrNotFound) {
+		found, err = lib.storeAlbumInfo(ctx, albumID, info)
+		if err != nil {
+			return AlbumInfo{}, fmt.Errorf("storing album info: %w", err)
+		}
+	} else {
+		log.Printf("Error getting info for album %d: %s\n", albumID, err)
+	}
+
+	return mergeAlbumInfo(tags, found), nil
+}
+
+// mergeAlbumInfo returns the information from the album tags with everything
+// missing from them taken from the information found on the internet.
+func mergeAlbumInfo(tags, found AlbumInfo) AlbumInfo {
+	info := tags
+	info.UpdatedAt = found.UpdatedAt
+	info.Notes = found.Notes
+
+	for _, field := range []struct {
+		value *string
+		found string
+	}{
+		{&info.MusicBrainzID, found.MusicBrainzID},
+		{&info.Label, found.Label},
+		{&info.CatalogNumber, found.CatalogNumber},
+		{&info.OriginalReleaseDate, found.OriginalReleaseDate},
+		{&info.Barcode, found.Barcode},
+	} {
+		if *field.value == "" {
+			*field.value = field.found
+		}
+	}
+
+	if len(info.ReleaseTypes) == 0 {
+		info.ReleaseTypes = found.ReleaseTypes
+	}
+
+	return info
+}
+
+// storedAlbumInfo returns the information about the release of the album with
+// `albumID` from the tags of its tracks and the information found on the internet
+// for it.
+func (lib *LocalLibrary) storedAlbumInfo(
+	ctx context.Context,
+	albumID int64,
+) (AlbumInfo, AlbumInfo, error) {
+	var (
+		tags      storedRelease
+		found     storedRelease
+		updatedAt sql.NullInt64
+	)
+
+	work := func(db *sql.DB) error {
+		row := db.QueryRowContext(ctx, `
+			SELECT
+				rel.musicbrainz_album_id,
+				rel.label,
+				rel.catalog_number,
+				rel.original_date,
+				rel.release_types,
+				rel.barcode,
+				ai.musicbrainz_id,
+				ai.notes,
+				ai.label,
+				ai.catalog_number,
+				ai.original_date,
+				ai.release_types,
+				ai.barcode,
+				ai.updated_at
+			FROM
+				albums al
+				LEFT JOIN (
+					SELECT
+						t.album_id,
+						MAX(tr.musicbrainz_album_id) as musicbrainz_album_id,
+						MAX(tr.label) as label,
+						MAX(tr.catalog_number) as catalog_number,
+						MIN(tr.original_date) as original_date,
+						MAX(tr.release_types) as release_types,
+						MAX(tr.barcode) as barcode
+					FROM
+						tracks t
+						JOIN tracks_release tr ON tr.track_id = t.id
+					WHERE
+						t.album_id = @albumID
+					GROUP BY
+						t.album_id
+				) rel ON rel.album_id = al.id
+				LEFT JOIN albums_info ai ON ai.album_id = al.id
+			WHERE
+				al.id = @albumID
+		`, sql.Named("albumID", albumID))
+
+		err := row.Scan(
+			&tags.mbID,
+			&tags.label,
+			&tags.catalogNumber,
+			&tags.originalDate,
+			&tags.releaseTypes,
+			&tags.barcode,
+			&found.mbID,
+			&found.notes,
+			&found.label,
+			&found.catalogNumber,
+			&found.originalDate,
+			&found.releaseTypes,
+			&found.barcode,
+			&updatedAt,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAlbumNotFound
+		} else if err != nil {
+			return fmt.Errorf("sql query for album info failed: %w", err)
+		}
+
+		return nil
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return AlbumInfo{}, AlbumInfo{}, err
+	}
+
+	fromInternet := found.albumInfo()
+	fromInternet.UpdatedAt = updatedAt.Int64
+
+	return tags.albumInfo(), fromInternet, nil
+}
+
+// storedRelease is used for reading release information from the database.
+type storedRelease struct {
+	mbID          sql.NullString
+	notes         sql.NullString
+	label         sql.NullString
+	catalogNumber sql.NullString
+	originalDate  sql.NullString
+	releaseTypes  sql.NullString
+	barcode       sql.NullString
+}
+
+// albumInfo returns the release information with the NULL values as empty ones.
+func (sr storedRelease) albumInfo() AlbumInfo {
+	return AlbumInfo{
+		MusicBrainzID:       sr.mbID.String,
+		Notes:               sr.notes.String,
+		Label:               sr.label.String,
+		CatalogNumber:       sr.catalogNumber.String,
+		OriginalReleaseDate: sr.originalDate.String,
+		ReleaseTypes:        splitReleaseTypes(sr.releaseTypes.String),
+		Barcode:             sr.barcode.String,
+	}
+}
+
+// storeAlbumInfo stores `found` as the information from the internet for the album
+// with `albumID` and returns it in the form in which it is used by FindAlbumInfo.
+func (lib *LocalLibrary) storeAlbumInfo(
+	ctx context.Context,
+	albumID int64,
+	found albuminfo.Info,
+) (AlbumInfo, error) {
+	releaseTypes := joinReleaseTypes(found.ReleaseTypes)
+	info := AlbumInfo{
+		MusicBrainzID:       strings.TrimSpace(found.MusicBrainzID),
+		Notes:               strings.TrimSpace(found.Notes),
+		Label:               strings.TrimSpace(found.Label),
+		CatalogNumber:       strings.TrimSpace(found.CatalogNumber),
+		OriginalReleaseDate: normalizeReleaseDate(found.OriginalReleaseDate),
+		ReleaseTypes:        splitReleaseTypes(releaseTypes),
+		Barcode:             strings.TrimSpace(found.Barcode),
+		UpdatedAt:           time.Now().Unix(),
+	}
+
+	work := func(db *sql.DB) error {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO albums_info (
+				album_id,
+				musicbrainz_id,
+				notes,
+				label,
+				catalog_number,
+				original_date,
+				release_types,
+				barcode,
+				updated_at
+			)
+			VALUES (
+				@albumID, @mbID, @notes, @label, @catalogNumber,
+				@originalDate, @releaseTypes, @barcode, @updatedAt
+			)
+			ON CONFLICT (album_id) DO
+			UPDATE SET
+				musicbrainz_id = @mbID,
+				notes = @notes,
+				label = @label,
+				catalog_number = @catalogNumber,
+				original_date = @originalDate,
+				release_types = @releaseTypes,
+				barcode = @barcode,
+				updated_at = @updatedAt
+		`,
+			sql.Named("albumID", albumID),
+			sql.Named("mbID", nullIfEmpty(info.MusicBrainzID)),
+			sql.Named("notes", nullIfEmpty(info.Notes)),
+			sql.Named("label", nullIfEmpty(info.Label)),
+			sql.Named("catalogNumber", nullIfEmpty(info.CatalogNumber)),
+			sql.Named("originalDate", nullIfEmpty(info.OriginalReleaseDate)),
+			sql.Named("releaseTypes", nullIfEmpty(releaseTypes)),
+			sql.Named("barcode", nullIfEmpty(info.Barcode)),
+			sql.Named("updatedAt", info.UpdatedAt),
+		)
+		return err
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return info, err
+	}
+
+	return info, nil
+}
+
+// storeReleaseTags saves the release tags of a track using `db`, which could be
+// either a database or a transaction. Previously stored tags for the track are
+// removed when `tags` is empty.
+func storeReleaseTags(db sqlExecer, trackID int64, tags releaseTags) error {
+	if tags.isEmpty() {
+		_, err := db.Exec(`
+			DELETE FROM tracks_release
+			WHERE track_id = ?
+		`, trackID)
+		return err
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO tracks_release (
+			track_id,
+			label,
+			catalog_number,
+			original_date,
+			release_types,
+			barcode,
+			musicbrainz_album_id
+		)
+		VALUES (
+			@trackID, @label, @catalogNumber, @originalDate,
+			@releaseTypes, @barcode, @mbID
+		)
+		ON CONFLICT (track_id) DO
+		UPDATE SET
+			label = @label,
+			catalog_number = @catalogNumber,
+			original_date = @originalDate,
+			release_types = @releaseTypes,
+			barcode = @barcode,
+			musicbrainz_album_id = @mbID
+	`,
+		sql.Named("trackID", trackID),
+		sql.Named("label", nullIfEmpty(tags.label)),
+		sql.Named("catalogNumber", nullIfEmpty(tags.catalogNumber)),
+		sql.Named("originalDate", nullIfEmpty(tags.originalDate)),
+		sql.Named("releaseTypes", nullIfEmpty(tags.releaseTypes)),
+		sql.Named("barcode", nullIfEmpty(tags.barcode)),
+		sql.Named("mbID", nullIfEmpty(tags.musicBrainzID)),
+	)
+	return err
+}
+
+// readReleaseTags returns the information about the album release found in the
+// tags of the media file at `trackPath`. Different taggers and formats use
+// different names for the same tags so all of the common ones are tried.
+func (lib *LocalLibrary) readReleaseTags(trackPath string) releaseTags {
+	fh, err := lib.fs.Open(trackPath)
+	if err != nil {
+		return releaseTags{}
+	}
+	defer fh.Close()
+
+	rs, ok := fh.(io.ReadSeeker)
+	if !ok {
+		return releaseTags{}
+	}
+
+	md, err := tag.ReadFrom(rs)
+	if err != nil {
+		return releaseTags{}
+	}
+
+	// Tag names are compared in lower case. User defined ID3 frames (TXXX) are
+	// known by their descriptions.
+	values := make(map[string]string)
+	for key, value := range md.Raw() {
+		switch value := value.(type) {
+		case string:
+			values[strings.ToLower(key)] = strings.TrimSpace(value)
+		case *tag.Comm:
+			if strings.HasPrefix(key, "TXX") {
+				values[strings.ToLower(value.Description)] = strings.TrimSpace(value.Text)
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := values[key]; value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	return releaseTags{
+		label:         first("label", "organization", "publisher", "tpub", "tpb"),
+		catalogNumber: first("catalognumber"),
+		originalDate: normalizeReleaseDate(first(
+			"originaldate", "tdor", "originalyear", "tory", "tor",
+		)),
+		releaseTypes: joinReleaseTypes(strings.FieldsFunc(
+			first("releasetype", "musicbrainz_albumtype", "musicbrainz album type"),
+			func(r rune) bool { return r == ';' || r == '/' || r == ',' },
+		)),
+		barcode:       first("barcode"),
+		musicBrainzID: first("musicbrainz_albumid", "musicbrainz album id"),
+	}
+}
+
+// normalizeReleaseDate returns the YYYY[-MM[-DD]] part of `date`. Anything
+// after the first part which does not match this format is dropped. An empty
+// string is returned when not even the year is valid.
+func normalizeReleaseDate(date string) string {
+	date = strings.TrimSpace(date)
+	if len(date) > len("YYYY-MM-DD") {
+		date = date[:len("YYYY-MM-DD")]
+	}
+
+	var parts []string
+	for i, part := range strings.SplitN(date, "-", 3) {
+		length := 2
+		if i == 0 {
+			length = 4
+		}
+		if len(part) != length || strings.Trim(part, "0123456789") != "" {
+			break
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// joinReleaseTypes returns the release types in the form in which they are
+// stored in the database.
+func joinReleaseTypes(releaseTypes []string) string {
+	var normalized []string
+	for _, releaseType := range releaseTypes {
+		releaseType = strings.ToLower(strings.TrimSpace(releaseType))
+		if releaseType == "" || slices.Contains(normalized, releaseType) {
+			continue
+		}
+		normalized = append(normalized, releaseType)
+	}
+
+	return strings.Join(normalized, releaseTypesSeparator)
+}
+
+// splitReleaseTypes is the opposite of joinReleaseTypes.
+func splitReleaseTypes(releaseTypes string) []string {
+	if releaseTypes == "" {
+		return nil
+	}
+	return strings.Split(releaseTypes, releaseTypesSeparator)
+}
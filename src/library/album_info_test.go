@@ -0,0 +1,230 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/albuminfo"
+	"github.com/ironsmile/euterpe/src/albuminfo/albuminfofakes"
+)
+
+// TestAlbumInfo checks that the release tags of the album tracks are stored and
+// that what is missing from them is searched for on the internet only when the
+// stored information is missing or old.
+func TestAlbumInfo(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	testLibraryPath, err := getTestLibraryPath()
+	if err != nil {
+		t.Fatalf("Failed to get test library path: %s", err)
+	}
+
+	mp3, err := os.ReadFile(filepath.Join(testLibraryPath, "test_file_one.mp3"))
+	if err != nil {
+		t.Fatalf("reading test mp3: %s", err)
+	}
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	tmpDir := t.TempDir()
+	for i, title := range []string{"Invaders", "Children of the Damned"} {
+		tagged := withID3v23Tag(mp3, []id3Frame{
+			{id: "TPUB", text: "EMI"},
+			{id: "TORY", text: "1982"},
+			{id: "TXXX", description: "CATALOGNUMBER", text: "EMC 3400"},
+			{id: "TXXX", description: "MusicBrainz Album Type", text: "Album/Remix"},
+			{id: "TXXX", description: "MusicBrainz Album Id", text: "release-mbid"},
+		})
+
+		fileName := filepath.Join(tmpDir, title+".mp3")
+		if err := os.WriteFile(fileName, tagged, 0600); err != nil {
+			t.Fatalf("creating test file %d: %s", i, err)
+		}
+
+		media := MockMedia{
+			artist: "Iron Maiden",
+			album:  "The Number of the Beast",
+			title:  title,
+			track:  i + 1,
+			length: 123 * time.Second,
+		}
+		info := fileInfo{
+			Size:     int64(len(tagged)),
+			FilePath: fileName,
+			Modified: time.Now(),
+		}
+		if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", i, err)
+		}
+	}
+
+	albumID, err := lib.GetAlbumID("The Number of the Beast", tmpDir)
+	if err != nil {
+		t.Fatalf("getting album ID: %s", err)
+	}
+
+	info, err := lib.GetAlbumInfo(ctx, albumID)
+	if err != nil {
+		t.Fatalf("getting album info: %s", err)
+	}
+
+	fromTags := AlbumInfo{
+		MusicBrainzID:       "release-mbid",
+		Label:               "EMI",
+		CatalogNumber:       "EMC 3400",
+		OriginalReleaseDate: "1982",
+		ReleaseTypes:        []string{"album", "remix"},
+	}
+	assertAlbumInfo(t, fromTags, info)
+
+	finder := &albuminfofakes.FakeFinder{}
+	finder.GetAlbumInfoReturns(albuminfo.Info{
+		MusicBrainzID:       "other-mbid",
+		Notes:               "First album with Bruce Dickinson.",
+		Label:               "Harvest",
+		OriginalReleaseDate: "1982-03-22",
+		ReleaseTypes:        []string{"Album"},
+		Barcode:             "077779675127",
+	}, nil)
+	lib.SetAlbumInfoFinder(finder, time.Hour)
+
+	if _, err := lib.GetAlbumInfo(ctx, albumID); err != nil {
+		t.Fatalf("getting album info: %s", err)
+	}
+	if finder.GetAlbumInfoCallCount() != 0 {
+		t.Errorf("GetAlbumInfo must not search on the internet")
+	}
+
+	for range 2 {
+		info, err = lib.FindAlbumInfo(ctx, albumID)
+		if err != nil {
+			t.Fatalf("finding album info: %s", err)
+		}
+	}
+
+	if finder.GetAlbumInfoCallCount() != 1 {
+		t.Errorf("expected stored info to be used but the finder was called %d times",
+			finder.GetAlbumInfoCallCount())
+	}
+	_, query := finder.GetAlbumInfoArgsForCall(0)
+	expectedQuery := albuminfo.Query{
+		Artist:        "Iron Maiden",
+		Album:         "The Number of the Beast",
+		MusicBrainzID: "release-mbid",
+	}
+	if query != expectedQuery {
+		t.Errorf("expected query %+v but got %+v", expectedQuery, query)
+	}
+
+	merged := fromTags
+	merged.Notes = "First album with Bruce Dickinson."
+	merged.Barcode = "077779675127"
+	assertAlbumInfo(t, merged, info)
+	if info.UpdatedAt == 0 {
+		t.Errorf("expected the time of the search to be set")
+	}
+
+	if _, err := lib.GetAlbumInfo(ctx, 8888); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("expected album not found error but got %v", err)
+	}
+	if _, err := lib.FindAlbumInfo(ctx, 8888); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("expected album not found error but got %v", err)
+	}
+}
+
+// TestNormalizeReleaseDate checks that only the YYYY[-MM[-DD]] part of release
+// dates found in tags is kept.
+func TestNormalizeReleaseDate(t *testing.T) {
+	tests := map[string]string{
+		"1982":                 "1982",
+		" 1982-03 ":            "1982-03",
+		"1982-03-22":           "1982-03-22",
+		"1982-03-22T10:00:00Z": "1982-03-22",
+		"1982-3-22":            "1982",
+		"82":                   "",
+		"March 1982":           "",
+		"":                     "",
+	}
+
+	for date, expected := range tests {
+		if actual := normalizeReleaseDate(date); actual != expected {
+			t.Errorf("%q: expected %q but got %q", date, expected, actual)
+		}
+	}
+}
+
+func assertAlbumInfo(t *testing.T, expected, actual AlbumInfo) {
+	t.Helper()
+
+	actualTypes := actual.ReleaseTypes
+	expectedTypes := expected.ReleaseTypes
+	actual.ReleaseTypes, expected.ReleaseTypes = nil, nil
+	actual.UpdatedAt = 0
+
+	if !slices.Equal(actualTypes, expectedTypes) {
+		t.Errorf("expected release types %q but got %q", expectedTypes, actualTypes)
+	}
+	if actual.MusicBrainzID != expected.MusicBrainzID || actual.Notes != expected.Notes ||
+		actual.Label != expected.Label || actual.CatalogNumber != expected.CatalogNumber ||
+		actual.OriginalReleaseDate != expected.OriginalReleaseDate ||
+		actual.Barcode != expected.Barcode {
+		t.Errorf("expected album info %+v but got %+v", expected, actual)
+	}
+}
+
+// id3Frame is an ID3v2.3 text frame. The description is used only for TXXX
+// frames.
+type id3Frame struct {
+	id          string
+	description string
+	text        string
+}
+
+// withID3v23Tag returns `mp3` with its ID3v2 tag replaced by one with `frames`.
+func withID3v23Tag(mp3 []byte, frames []id3Frame) []byte {
+	if len(mp3) > 10 && bytes.HasPrefix(mp3, []byte("ID3")) {
+		size := int(mp3[6])<<21 | int(mp3[7])<<14 | int(mp3[8])<<7 | int(mp3[9])
+		mp3 = mp3[10+size:]
+	}
+
+	var body bytes.Buffer
+	for _, frame := range frames {
+		data := []byte{0} // ISO-8859-1
+		if frame.id == "TXXX" {
+			data = append(data, frame.description...)
+			data = append(data, 0)
+		}
+		data = append(data, frame.text...)
+
+		body.WriteString(frame.id)
+		_ = binary.Write(&body, binary.BigEndian, uint32(len(data)))
+		body.Write([]byte{0, 0})
+		body.Write(data)
+	}
+
+	size := body.Len()
+	tag := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7f),
+		byte(size >> 14 & 0x7f),
+		byte(size >> 7 & 0x7f),
+		byte(size & 0x7f),
+	}
+
+	return append(append(tag, body.Bytes()...), mp3...)
+}
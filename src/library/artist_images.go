@@ -53,7 +53,7 @@ func (lib *LocalLibrary) FindAndSaveArtistImage(
 		return nil, fmt.Errorf("error scaling image: %w", err)
 	}
 
-	ret, _, err := lib.storeArtistImage(artistID, converted, size)
+	ret, _, err := lib.storeArtistImage(artistID, converted, size, "")
 	if err != nil {
 		return nil, err
 	}
@@ -84,9 +84,9 @@ func (lib *LocalLibrary) findAndSaveArtistImageOrOriginal(
 		return nil, size, err
 	}
 
-	reader, err = lib.artistImageFromInternet(ctx, artistID)
+	reader, source, err := lib.artistImageFromInternet(ctx, artistID)
 	if err == nil {
-		return lib.storeArtistImage(artistID, reader, OriginalImage)
+		return lib.storeArtistImage(artistID, reader, OriginalImage, source)
 	} else if err == ErrArtistNotFound {
 		return nil, size, ErrArtistNotFound
 	}
@@ -111,7 +111,7 @@ func (lib *LocalLibrary) artistImageFromDB(
 	artistID int64,
 	size ImageSize,
 ) (io.ReadCloser, ImageSize, error) {
-	buff, unixTime, err := lib.artistImageFromDBForSize(ctx, artistID, size)
+	buff, retryAt, err := lib.artistImageFromDBForSize(ctx, artistID, size)
 	if err != nil {
 		return nil, size, err
 	}
@@ -121,30 +121,30 @@ func (lib *LocalLibrary) artistImageFromDB(
 		return newBytesReadCloser(buff), size, nil
 	}
 
-	selectNotFound := func(lastChanged int64) (io.ReadCloser, ImageSize, error) {
-		if time.Now().Before(time.Unix(lastChanged, 0).Add(notFoundCacheTTL)) {
+	selectNotFound := func(retryAt int64) (io.ReadCloser, ImageSize, error) {
+		if time.Now().Before(time.Unix(retryAt, 0)) {
 			return nil, size, ErrCachedArtworkNotFound
 		}
 		return nil, size, ErrArtworkNotFound
 	}
 
-	// No image in the database. Is either a normal "not found" for images which
-	// haven't  been queried recently. For everything else it is "cached not found"
-	// which means that all the channels for obtaining the image have been tried out
+	// No image in the database. Is either a normal "not found" for images whose
+	// retry time has come. For everything else it is "cached not found" which
+	// means that all the channels for obtaining the image have been tried out
 	// recently and nothing has been found.
 	if size == OriginalImage {
-		return selectNotFound(unixTime)
+		return selectNotFound(retryAt)
 	}
 
 	// No image of the desired size was found. Let us try and see if the original image
 	// is in the database and use it to generate the desired size.
-	buff, unixTime, err = lib.artistImageFromDBForSize(ctx, artistID, OriginalImage)
+	buff, retryAt, err = lib.artistImageFromDBForSize(ctx, artistID, OriginalImage)
 	if err != nil {
 		return nil, size, err
 	}
 
 	if len(buff) < 1 {
-		return selectNotFound(unixTime)
+		return selectNotFound(retryAt)
 	}
 
 	return newBytesReadCloser(buff), OriginalImage, nil
@@ -155,57 +155,18 @@ func (lib *LocalLibrary) artistImageFromDBForSize(
 	artistID int64,
 	size ImageSize,
 ) ([]byte, int64, error) {
-
-	var (
-		buff          []byte
-		unixTime      int64
-		blobColumn    = "image"
-		imageSQLQuery = `
-			SELECT
-				%s,
-				updated_at
-			FROM
-				artists_images
-			WHERE
-				artist_id = ?
-		`
-	)
-	if size == SmallImage {
-		blobColumn = "image_small"
-	}
-
-	work := func(db *sql.DB) error {
-		smt, err := db.PrepareContext(ctx, fmt.Sprintf(imageSQLQuery, blobColumn))
-
-		if err != nil {
-			log.Printf("could not prepare album artwork sql statement: %s", err)
-			return err
-		}
-		defer smt.Close()
-
-		err = smt.QueryRowContext(ctx, artistID).Scan(&buff, &unixTime)
-		if err == sql.ErrNoRows {
-			return ErrArtworkNotFound
-		} else if err != nil {
-			log.Printf("error getting album cover from db: %s", err)
-			return err
-		}
-
-		return nil
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return nil, 0, err
-	}
-
-	return buff, unixTime, nil
+	return lib.imageFromDB(ctx, artistImageTable, artistID, size)
 }
 
+// artistImageFromInternet finds the image using the art.Finder of the library.
+// The second returned value is the name of the provider which found it when the
+// finder is an art.ProviderFinder and ArtworkSourceInternet otherwise.
 func (lib *LocalLibrary) artistImageFromInternet(
 	ctx context.Context,
 	artistID int64,
-) (io.ReadCloser, error) {
+) (io.ReadCloser, string, error) {
 	if lib.artFinder == nil {
-		return nil, ErrArtworkNotFound
+		return nil, "", ErrArtworkNotFound
 	}
 
 	var artistName string
@@ -239,24 +200,31 @@ func (lib *LocalLibrary) artistImageFromInternet(
 		return nil
 	}
 	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	cover, err := lib.artFinder.GetArtistImage(ctx, artistName)
+	image := art.Image{Provider: ArtworkSourceInternet}
+	var err error
+	if finder, ok := lib.artFinder.(art.ProviderFinder); ok {
+		image, err = finder.FindArtistImage(ctx, artistName)
+	} else {
+		image.Data, err = lib.artFinder.GetArtistImage(ctx, artistName)
+	}
 	if errors.Is(err, art.ErrImageNotFound) {
-		return nil, ErrArtworkNotFound
+		return nil, "", ErrArtworkNotFound
 	}
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return newBytesReadCloser(cover), nil
+	return newBytesReadCloser(image.Data), image.Provider, nil
 }
 
 func (lib *LocalLibrary) storeArtistImage(
 	albumID int64,
 	image io.ReadCloser,
 	size ImageSize,
+	source string,
 ) (io.ReadCloser, ImageSize, error) {
 	defer image.Close()
 
@@ -265,40 +233,8 @@ func (lib *LocalLibrary) storeArtistImage(
 		return nil, size, err
 	}
 
-	imageColumn := "image"
-	if size == SmallImage {
-		imageColumn = "image_small"
-	}
-
-	storeQuery := fmt.Sprintf(`
-		INSERT INTO
-			artists_images (artist_id, %s, updated_at)
-		VALUES				
-			($1, $2, $3)
-		ON CONFLICT (artist_id) DO
-		UPDATE SET
-			%s = $2,
-			updated_at = $3
-	`, imageColumn, imageColumn)
-
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(storeQuery)
-
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		_, err = stmt.Exec(albumID, buff, time.Now().Unix())
-
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+	err = lib.storeImage(artistImageTable, albumID, buff, size, source, false)
+	if err != nil {
 		log.Printf("Error executing save artist image query: %s", err)
 		return nil, size, err
 	}
@@ -306,29 +242,10 @@ func (lib *LocalLibrary) storeArtistImage(
 	return newBytesReadCloser(buff), size, nil
 }
 
+// saveArtistImageNotFound records that no image has been found for the artist.
+// It is searched for again later, each time waiting longer than the last.
 func (lib *LocalLibrary) saveArtistImageNotFound(artistID int64) error {
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(`
-				INSERT OR REPLACE INTO
-					artists_images (artist_id, updated_at)
-				VALUES
-					(?, ?)
-		`)
-
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		_, err = stmt.Exec(artistID, time.Now().Unix())
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+	if err := lib.saveImageNotFound(artistImageTable, artistID, true); err != nil {
 		log.Printf(
 			"Error executing save artist image not found query: %s",
 			err,
@@ -341,9 +258,9 @@ func (lib *LocalLibrary) saveArtistImageNotFound(artistID int64) error {
 
 // SaveArtistImage implements the ArtistImageManager interface for the local library.
 //
-// It saves the image in `r` in the database. It will read up to 5MB of data from
+// It saves the image in `r` in the blob store. It will read up to 5MB of data from
 // `r` and if this limit is reached, the image is considered too big and will not
-// be saved in the db.
+// be saved.
 func (lib *LocalLibrary) SaveArtistImage(
 	ctx context.Context,
 	artistID int64,
@@ -373,30 +290,17 @@ func (lib *LocalLibrary) SaveArtistImage(
 		return NewArtworkError(errors.New("uploaded artist image is empty"))
 	}
 
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(`
-			INSERT OR REPLACE INTO
-				artists_images (artist_id, image, updated_at)
-			VALUES
-				(?, ?, ?)
-		`)
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		_, err = stmt.Exec(artistID, buff, time.Now().Unix())
-		return err
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return err
-	}
-
-	return nil
+	return lib.storeImage(
+		artistImageTable,
+		artistID,
+		buff,
+		OriginalImage,
+		ArtworkSourceUpload,
+		true,
+	)
 }
 
 // RemoveArtistImage removes particular artist image from the database.
 func (lib *LocalLibrary) RemoveArtistImage(ctx context.Context, artistID int64) error {
-	return lib.saveArtistImageNotFound(artistID)
+	return lib.saveImageNotFound(artistImageTable, artistID, false)
 }
@@ -0,0 +1,300 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+)
+
+// defaultArtistInfoTTL is for how long information about artists is used before it
+// is searched for again when no other TTL is set.
+const defaultArtistInfoTTL = 30 * 24 * time.Hour
+
+// ArtistInfo is what is known about an artist from sources on the internet.
+type ArtistInfo struct {
+	// MusicBrainzID is the ID of the artist in the MusicBrainz database.
+	MusicBrainzID string `json:"musicbrainz_id,omitempty"`
+
+	// Biography is a short plain text description of the artist.
+	Biography string `json:"biography,omitempty"`
+
+	// LastFMURL is the page of the artist on Last.fm.
+	LastFMURL string `json:"lastfm_url,omitempty"`
+
+	// Links are pages about the artist such as their official homepage or
+	// their Wikipedia article.
+	Links []ArtistLink `json:"links,omitempty"`
+
+	// Similar are other artists which are similar to this one. The most
+	// similar are first.
+	Similar []SimilarArtist `json:"similar,omitempty"`
+
+	// UpdatedAt is the Unix timestamp at which the information was found. It
+	// is zero when it has never been searched for.
+	UpdatedAt int64 `json:"info_updated_at,omitempty"`
+}
+
+// ArtistLink is a page about an artist somewhere on the internet.
+type ArtistLink struct {
+	// Type is the kind of the page such as "wikipedia", "discogs" or
+	// "official homepage".
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// SimilarArtist is an artist which is similar to another one.
+type SimilarArtist struct {
+	// ID is the ID of the artist in the library. It is zero for artists
+	// which are not in the library.
+	ID int64 `json:"artist_id,omitempty"`
+
+	Name          string `json:"artist"`
+	MusicBrainzID string `json:"musicbrainz_id,omitempty"`
+}
+
+// SetArtistInfoFinder sets the finder which is used for searching for information
+// about artists on the internet. Found information is stored and used for `ttl`
+// before it is searched for again. When the TTL is not positive a default is used.
+func (lib *LocalLibrary) SetArtistInfoFinder(finder artistinfo.Finder, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultArtistInfoTTL
+	}
+
+	lib.artistInfoFinder = finder
+	lib.artistInfoTTL = ttl
+}
+
+// GetArtistInfo returns what is known about the artist with ID `artistID`. When
+// the stored information is missing or older than the TTL it is searched for with
+// the artist info finder first. Stored information is returned even when it is old
+// if searching for it fails. ErrArtistNotFound is returned when there is no such
+// artist.
+func (lib *LocalLibrary) GetArtistInfo(ctx context.Context, artistID int64) (ArtistInfo, error) {
+	name, info, err := lib.storedArtistInfo(ctx, artistID)
+	if err != nil {
+		return ArtistInfo{}, err
+	}
+
+	if lib.artistInfoFinder != nil &&
+		time.Since(time.Unix(info.UpdatedAt, 0)) > lib.artistInfoTTL {
+		found, err := lib.artistInfoFinder.GetArtistInfo(ctx, name)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ArtistInfo{}, ctxErr
+		}
+
+		if err == nil || errors.Is(err, artistinfo.ErrNotFound) {
+			info, err = lib.storeArtistInfo(ctx, artistID, found)
+			if err != nil {
+				return ArtistInfo{}, fmt.Errorf("storing artist info: %w", err)
+			}
+		} else {
+			log.Printf("Error getting info for artist %d: %s\n", artistID, err)
+		}
+	}
+
+	if err := lib.findSimilarArtists(ctx, artistID, info.Similar); err != nil {
+		return ArtistInfo{}, fmt.Errorf("finding similar artists: %w", err)
+	}
+
+	return info, nil
+}
+
+// storedArtistInfo returns the name of the artist with `artistID` and the
+// information stored for it.
+func (lib *LocalLibrary) storedArtistInfo(
+	ctx context.Context,
+	artistID int64,
+) (string, ArtistInfo, error) {
+	var (
+		name      string
+		mbID      sql.NullString
+		biography sql.NullString
+		lastFMURL sql.NullString
+		links     sql.NullString
+		similar   sql.NullString
+		updatedAt sql.NullInt64
+	)
+
+	work := func(db *sql.DB) error {
+		row := db.QueryRowContext(ctx, `
+			SELECT
+				ar.name,
+				ai.musicbrainz_id,
+				ai.biography,
+				ai.lastfm_url,
+				ai.links,
+				ai.similar,
+				ai.updated_at
+			FROM
+				artists ar
+				LEFT JOIN artists_info ai ON ai.artist_id = ar.id
+			WHERE
+				ar.id = ?
+		`, artistID)
+
+		err := row.Scan(&name, &mbID, &biography, &lastFMURL, &links, &similar, &updatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrArtistNotFound
+		} else if err != nil {
+			return fmt.Errorf("sql query for artist info failed: %w", err)
+		}
+
+		return nil
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return "", ArtistInfo{}, err
+	}
+
+	info := ArtistInfo{
+		MusicBrainzID: mbID.String,
+		Biography:     biography.String,
+		LastFMURL:     lastFMURL.String,
+		UpdatedAt:     updatedAt.Int64,
+	}
+	if links.Valid {
+		if err := json.Unmarshal([]byte(links.String), &info.Links); err != nil {
+			log.Printf("Malformed links for artist %d: %s\n", artistID, err)
+		}
+	}
+	if similar.Valid {
+		if err := json.Unmarshal([]byte(similar.String), &info.Similar); err != nil {
+			log.Printf("Malformed similar artists for artist %d: %s\n", artistID, err)
+		}
+	}
+
+	return name, info, nil
+}
+
+// storeArtistInfo stores `found` as the information for the artist with `artistID`
+// and returns it in the form in which it is returned by GetArtistInfo.
+func (lib *LocalLibrary) storeArtistInfo(
+	ctx context.Context,
+	artistID int64,
+	found artistinfo.Info,
+) (ArtistInfo, error) {
+	info := ArtistInfo{
+		MusicBrainzID: found.MusicBrainzID,
+		Biography:     found.Biography,
+		LastFMURL:     found.LastFMURL,
+		UpdatedAt:     time.Now().Unix(),
+	}
+	for _, link := range found.Links {
+		info.Links = append(info.Links, ArtistLink{
+			Type: link.Type,
+			URL:  link.URL,
+		})
+	}
+	for _, similar := range found.Similar {
+		info.Similar = append(info.Similar, SimilarArtist{
+			Name:          similar.Name,
+			MusicBrainzID: similar.MusicBrainzID,
+		})
+	}
+
+	var links, similar any
+	if len(info.Links) > 0 {
+		encoded, err := json.Marshal(info.Links)
+		if err != nil {
+			return info, err
+		}
+		links = string(encoded)
+	}
+	if len(info.Similar) > 0 {
+		encoded, err := json.Marshal(info.Similar)
+		if err != nil {
+			return info, err
+		}
+		similar = string(encoded)
+	}
+
+	work := func(db *sql.DB) error {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO artists_info (
+				artist_id,
+				musicbrainz_id,
+				biography,
+				lastfm_url,
+				links,
+				similar,
+				updated_at
+			)
+			VALUES (@artistID, @mbID, @biography, @lastFMURL, @links, @similar, @updatedAt)
+			ON CONFLICT (artist_id) DO
+			UPDATE SET
+				musicbrainz_id = @mbID,
+				biography = @biography,
+				lastfm_url = @lastFMURL,
+				links = @links,
+				similar = @similar,
+				updated_at = @updatedAt
+		`,
+			sql.Named("artistID", artistID),
+			sql.Named("mbID", nullIfEmpty(info.MusicBrainzID)),
+			sql.Named("biography", nullIfEmpty(info.Biography)),
+			sql.Named("lastFMURL", nullIfEmpty(info.LastFMURL)),
+			sql.Named("links", links),
+			sql.Named("similar", similar),
+			sql.Named("updatedAt", info.UpdatedAt),
+		)
+		return err
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return info, err
+	}
+
+	return info, nil
+}
+
+// findSimilarArtists sets the IDs of the similar artists which are in the library.
+// They are matched by name, ignoring the case. The artist with `artistID` is never
+// similar to itself.
+func (lib *LocalLibrary) findSimilarArtists(
+	ctx context.Context,
+	artistID int64,
+	similar []SimilarArtist,
+) error {
+	if len(similar) == 0 {
+		return nil
+	}
+
+	work := func(db *sql.DB) error {
+		stmt, err := db.PrepareContext(ctx, `
+			SELECT
+				id
+			FROM
+				artists
+			WHERE
+				name = ? COLLATE NOCASE AND
+				id != ?
+			ORDER BY
+				id
+			LIMIT 1
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i := range similar {
+			err := stmt.QueryRowContext(
+				ctx,
+				strings.TrimSpace(similar[i].Name),
+				artistID,
+			).Scan(&similar[i].ID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return lib.ExecuteDBJobAndWait(work)
+}
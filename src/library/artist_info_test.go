@@ -0,0 +1,246 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+	"github.com/ironsmile/euterpe/src/artistinfo/artistinfofakes"
+)
+
+// TestArtistInfo checks that information about artists is searched for only when
+// the stored one is missing or old and that similar artists are matched with the
+// ones in the library.
+func TestArtistInfo(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	for i, artist := range []string{"Iron Maiden", "Judas Priest"} {
+		media := MockMedia{
+			artist: artist,
+			album:  "Album",
+			title:  "Track",
+			track:  1,
+			length: 123,
+		}
+		info := fileInfo{
+			Size:     1024,
+			FilePath: "/path/to/" + artist + "/track.mp3",
+			Modified: time.Now(),
+		}
+		if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", i, err)
+		}
+	}
+
+	artistID, _ := lib.GetArtistID("Iron Maiden")
+	similarID, _ := lib.GetArtistID("Judas Priest")
+
+	info, err := lib.GetArtistInfo(ctx, artistID)
+	if err != nil {
+		t.Fatalf("getting info without a finder: %s", err)
+	}
+	if info.Biography != "" || info.UpdatedAt != 0 {
+		t.Errorf("expected no info without a finder but got %+v", info)
+	}
+
+	finder := &artistinfofakes.FakeFinder{}
+	finder.GetArtistInfoReturns(artistinfo.Info{
+		MusicBrainzID: "ca891d65-d9b0-4258-89f7-e6ba29d83767",
+		Biography:     "An English heavy metal band.",
+		Links: []artistinfo.Link{
+			{Type: "wikidata", URL: "https://www.wikidata.org/wiki/Q43182"},
+		},
+		Similar: []artistinfo.SimilarArtist{
+			{Name: "judas priest"},
+			{Name: "Saxon", MusicBrainzID: "saxon-mbid"},
+			{Name: "Iron Maiden"},
+		},
+	}, nil)
+	lib.SetArtistInfoFinder(finder, time.Hour)
+
+	for range 2 {
+		info, err = lib.GetArtistInfo(ctx, artistID)
+		if err != nil {
+			t.Fatalf("getting artist info: %s", err)
+		}
+	}
+
+	if finder.GetArtistInfoCallCount() != 1 {
+		t.Errorf("expected stored info to be used but the finder was called %d times",
+			finder.GetArtistInfoCallCount())
+	}
+	if _, name := finder.GetArtistInfoArgsForCall(0); name != "Iron Maiden" {
+		t.Errorf("info was searched for %s instead of Iron Maiden", name)
+	}
+
+	if info.MusicBrainzID != "ca891d65-d9b0-4258-89f7-e6ba29d83767" ||
+		info.Biography != "An English heavy metal band." || info.UpdatedAt == 0 {
+		t.Errorf("unexpected artist info: %+v", info)
+	}
+	if len(info.Links) != 1 || info.Links[0].Type != "wikidata" {
+		t.Errorf("unexpected links: %+v", info.Links)
+	}
+
+	expectedSimilar := []SimilarArtist{
+		{ID: similarID, Name: "judas priest"},
+		{Name: "Saxon", MusicBrainzID: "saxon-mbid"},
+		{Name: "Iron Maiden"},
+	}
+	if len(info.Similar) != len(expectedSimilar) {
+		t.Fatalf("expected similar artists %+v but got %+v", expectedSimilar, info.Similar)
+	}
+	for i, similar := range info.Similar {
+		if similar != expectedSimilar[i] {
+			t.Errorf("similar artist %d: expected %+v but got %+v",
+				i, expectedSimilar[i], similar)
+		}
+	}
+
+	// Old info is searched for again and kept when searching fails.
+	makeOld := func() {
+		t.Helper()
+		err := lib.ExecuteDBJobAndWait(func(db *sql.DB) error {
+			_, err := db.Exec(`
+				UPDATE artists_info
+				SET updated_at = ?
+				WHERE artist_id = ?
+			`, time.Now().Add(-2*time.Hour).Unix(), artistID)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("making info old: %s", err)
+		}
+	}
+
+	makeOld()
+	finder.GetArtistInfoReturns(artistinfo.Info{}, errors.New("service unavailable"))
+	info, err = lib.GetArtistInfo(ctx, artistID)
+	if err != nil {
+		t.Fatalf("getting artist info when the finder fails: %s", err)
+	}
+	if finder.GetArtistInfoCallCount() != 2 || info.Biography == "" {
+		t.Errorf("expected old info to be kept when searching fails but got %+v", info)
+	}
+
+	// Nothing found is stored too so that it is not searched for on every request.
+	finder.GetArtistInfoReturns(artistinfo.Info{}, artistinfo.ErrNotFound)
+	info, err = lib.GetArtistInfo(ctx, artistID)
+	if err != nil {
+		t.Fatalf("getting artist info which is not found: %s", err)
+	}
+	if info.Biography != "" || len(info.Similar) != 0 || info.UpdatedAt == 0 {
+		t.Errorf("expected empty info when nothing is found but got %+v", info)
+	}
+	if _, err := lib.GetArtistInfo(ctx, artistID); err != nil {
+		t.Fatalf("getting artist info again: %s", err)
+	}
+	if finder.GetArtistInfoCallCount() != 3 {
+		t.Errorf("expected 3 searches but got %d", finder.GetArtistInfoCallCount())
+	}
+
+	if _, err := lib.GetArtistInfo(ctx, 8888); !errors.Is(err, ErrArtistNotFound) {
+		t.Errorf("expected artist not found error but got %v", err)
+	}
+}
+
+// TestArtistInfoProvidersFailing checks that stored information about an artist
+// is kept when all providers of the chain fail, for example during a network
+// outage, and that it is searched for again on the next request.
+func TestArtistInfoProvidersFailing(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	media := MockMedia{
+		artist: "Iron Maiden",
+		album:  "Album",
+		title:  "Track",
+		track:  1,
+		length: 123,
+	}
+	err = lib.insertMediaIntoDatabase(&media, fileInfo{
+		Size:     1024,
+		FilePath: "/path/to/Iron Maiden/track.mp3",
+		Modified: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("inserting media file failed: %s", err)
+	}
+	artistID, _ := lib.GetArtistID("Iron Maiden")
+
+	bios := &artistinfofakes.FakeProvider{}
+	bios.NameReturns("bios")
+	bios.AddArtistInfoStub = func(_ context.Context, _ string, info *artistinfo.Info) error {
+		info.Biography = "An English heavy metal band."
+		return nil
+	}
+	lib.SetArtistInfoFinder(artistinfo.NewChain(bios), time.Hour)
+
+	info, err := lib.GetArtistInfo(ctx, artistID)
+	if err != nil || info.Biography == "" {
+		t.Fatalf("getting artist info: %+v, %v", info, err)
+	}
+
+	err = lib.ExecuteDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE artists_info
+			SET updated_at = ?
+			WHERE artist_id = ?
+		`, time.Now().Add(-2*time.Hour).Unix(), artistID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("making info old: %s", err)
+	}
+
+	offline := func(name string) *artistinfofakes.FakeProvider {
+		provider := &artistinfofakes.FakeProvider{}
+		provider.NameReturns(name)
+		provider.AddArtistInfoReturns(errors.New("dial tcp: network is unreachable"))
+		return provider
+	}
+	notFound := &artistinfofakes.FakeProvider{}
+	notFound.NameReturns("not-found")
+	notFound.AddArtistInfoReturns(artistinfo.ErrNotFound)
+
+	chain := artistinfo.NewChain(offline("musicbrainz"), notFound, offline("lastfm"))
+	lib.SetArtistInfoFinder(chain, time.Hour)
+
+	for range 2 {
+		info, err = lib.GetArtistInfo(ctx, artistID)
+		if err != nil {
+			t.Fatalf("getting artist info while offline: %s", err)
+		}
+		if info.Biography != "An English heavy metal band." {
+			t.Errorf("expected stored info to be kept but got %+v", info)
+		}
+	}
+
+	if calls := notFound.AddArtistInfoCallCount(); calls != 2 {
+		t.Errorf("expected info to be searched for on every request but was %d times",
+			calls)
+	}
+}
@@ -6,13 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
 	"log"
-	"path/filepath"
-	"regexp"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/dhowden/tag"
 	"github.com/ironsmile/euterpe/src/art"
 )
 
@@ -21,16 +21,11 @@ import (
 // filesystem or _on the internet_! This function returns ReadCloser and the caller
 // is responsible for freeing the used resources by calling Close().
 //
-// When an artwork is found it will be saved in the database and once there it will be
-// served from the db. Wait, wait! Serving binary files from the database?! Isn't that
-// slow? Apparently no with sqlite3. See the following:
-//
-// * https://www.sqlite.org/intern-v-extern-blob.html
-// * https://www.sqlite.org/fasterthanfs.html
-//
-// This behaviour have an additional bonus that artwork found on the internet will not
-// be saved on the filesystem and thus "pollute" it with unexpected files. It will be
-// nicely contained in the app's database.
+// When an artwork is found it will be saved in the library's blob store and once
+// there it will be served from it. The database keeps only the hash of the image.
+// This way artwork found on the internet will not be saved on the filesystem next
+// to the music and thus "pollute" it with unexpected files. And the database does
+// not grow with megabytes of images.
 //
 // !TODO: Make sure there is no race conditions while getting/saving artwork for
 // particular album. Wink, wink, the database.
@@ -67,7 +62,7 @@ func (lib *LocalLibrary) FindAndSaveAlbumArtwork(
 		return nil, fmt.Errorf("error scaling image: %w", err)
 	}
 
-	ret, _, err := lib.storeAlbumArtwork(albumID, converted, size)
+	ret, _, err := lib.storeAlbumArtwork(albumID, converted, size, "")
 	if err != nil {
 		return nil, err
 	}
@@ -98,22 +93,34 @@ func (lib *LocalLibrary) findAndSaveAlbumArtworkOrOriginal(
 		return nil, size, err
 	}
 
-	reader, err = lib.albumArtworkFromFS(ctx, albumID)
-	if err == nil {
-		return lib.storeAlbumArtwork(albumID, reader, OriginalImage)
-	} else if err != ErrArtworkNotFound {
-		return nil, size, err
-	}
+	for _, source := range lib.artworkSources {
+		var (
+			reader      io.ReadCloser
+			foundSource = source
+		)
 
-	reader, err = lib.albumArtworkFromInternet(ctx, albumID)
-	if err == nil {
-		return lib.storeAlbumArtwork(albumID, reader, OriginalImage)
-	} else if err == ErrAlbumNotFound {
-		return nil, size, ErrAlbumNotFound
-	}
+		switch source {
+		case ArtworkSourceLocal:
+			reader, err = lib.albumArtworkFromFS(ctx, albumID)
+		case ArtworkSourceEmbedded:
+			reader, err = lib.albumArtworkFromEmbedded(ctx, albumID)
+		case ArtworkSourceInternet:
+			reader, foundSource, err = lib.albumArtworkFromInternet(ctx, albumID)
+		default:
+			continue
+		}
 
-	if !errors.Is(err, art.ErrImageNotFound) && !errors.Is(err, ErrArtworkNotFound) {
-		log.Printf("Finding album %d artwork on the internet error: %s\n", albumID, err)
+		if err == nil {
+			return lib.storeAlbumArtwork(albumID, reader, OriginalImage, foundSource)
+		} else if err == ErrAlbumNotFound {
+			return nil, size, ErrAlbumNotFound
+		} else if source != ArtworkSourceInternet && err != ErrArtworkNotFound {
+			return nil, size, err
+		} else if !errors.Is(err, art.ErrImageNotFound) &&
+			!errors.Is(err, ErrArtworkNotFound) {
+			log.Printf("Finding album %d artwork on the internet error: %s\n",
+				albumID, err)
+		}
 	}
 
 	if err := lib.saveAlbumArtworkNotFound(albumID); err != nil {
@@ -142,6 +149,7 @@ func (lib *LocalLibrary) storeAlbumArtwork(
 	albumID int64,
 	artwork io.ReadCloser,
 	size ImageSize,
+	source string,
 ) (io.ReadCloser, ImageSize, error) {
 	defer artwork.Close()
 
@@ -150,40 +158,8 @@ func (lib *LocalLibrary) storeAlbumArtwork(
 		return nil, size, err
 	}
 
-	albumColumn := "artwork_cover"
-	if size == SmallImage {
-		albumColumn = "artwork_cover_small"
-	}
-
-	storeQuery := fmt.Sprintf(`
-		INSERT INTO
-			albums_artworks (album_id, %s, updated_at)
-		VALUES				
-			($1, $2, $3)
-		ON CONFLICT (album_id) DO
-		UPDATE SET
-			%s = $2,
-			updated_at = $3
-	`, albumColumn, albumColumn)
-
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(storeQuery)
-
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		_, err = stmt.Exec(albumID, buff, time.Now().Unix())
-
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+	err = lib.storeImage(albumArtworkTable, albumID, buff, size, source, false)
+	if err != nil {
 		log.Printf("Error executing save artwork query: %s", err)
 		return nil, size, err
 	}
@@ -191,29 +167,10 @@ func (lib *LocalLibrary) storeAlbumArtwork(
 	return newBytesReadCloser(buff), size, nil
 }
 
+// saveAlbumArtworkNotFound records that no artwork has been found for the album.
+// It is searched for again later, each time waiting longer than the last.
 func (lib *LocalLibrary) saveAlbumArtworkNotFound(albumID int64) error {
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(`
-				INSERT OR REPLACE INTO
-					albums_artworks (album_id, updated_at)
-				VALUES
-					(?, ?)
-		`)
-
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		_, err = stmt.Exec(albumID, time.Now().Unix())
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+	if err := lib.saveImageNotFound(albumArtworkTable, albumID, true); err != nil {
 		log.Printf("Error executing save artwork not found query: %s", err)
 		return err
 	}
@@ -221,14 +178,45 @@ func (lib *LocalLibrary) saveAlbumArtworkNotFound(albumID int64) error {
 	return nil
 }
 
+// albumArtworkFromInternet finds the artwork using the art.Finder of the library.
+// The second returned value is the name of the provider which found it when the
+// finder is an art.ProviderFinder and ArtworkSourceInternet otherwise.
 func (lib *LocalLibrary) albumArtworkFromInternet(
 	ctx context.Context,
 	albumID int64,
-) (io.ReadCloser, error) {
+) (io.ReadCloser, string, error) {
 	if lib.artFinder == nil {
-		return nil, ErrArtworkNotFound
+		return nil, "", ErrArtworkNotFound
 	}
 
+	artistName, albumName, err := lib.albumArtworkQuery(ctx, albumID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cover := art.Image{Provider: ArtworkSourceInternet}
+	if finder, ok := lib.artFinder.(art.ProviderFinder); ok {
+		cover, err = finder.FindFrontImage(ctx, artistName, albumName)
+	} else {
+		cover.Data, err = lib.artFinder.GetFrontImage(ctx, artistName, albumName)
+	}
+	if errors.Is(err, art.ErrImageNotFound) {
+		return nil, "", ErrArtworkNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return newBytesReadCloser(cover.Data), cover.Provider, nil
+}
+
+// albumArtworkQuery returns the names of the album and of its artist which are
+// used for searching for its artwork on the internet. The artist is the one with
+// the most tracks in the album.
+func (lib *LocalLibrary) albumArtworkQuery(
+	ctx context.Context,
+	albumID int64,
+) (string, string, error) {
 	var (
 		albumName  string
 		artistName string
@@ -290,18 +278,51 @@ func (lib *LocalLibrary) albumArtworkFromInternet(
 		return nil
 	}
 	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return nil, err
+		return "", "", err
 	}
 
-	cover, err := lib.artFinder.GetFrontImage(ctx, artistName, albumName)
-	if errors.Is(err, art.ErrImageNotFound) {
-		return nil, ErrArtworkNotFound
+	return artistName, albumName, nil
+}
+
+// albumTrackPaths returns the files of the tracks in an album ordered by their
+// numbers.
+func (lib *LocalLibrary) albumTrackPaths(
+	ctx context.Context,
+	albumID int64,
+) ([]string, error) {
+	var tracksPaths []string
+
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				fs_path
+			FROM
+				tracks
+			WHERE
+				album_id = ?
+			ORDER BY
+				number
+		`, albumID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var fsPath string
+			if err := rows.Scan(&fsPath); err != nil {
+				return err
+			}
+			tracksPaths = append(tracksPaths, fsPath)
+		}
+
+		return rows.Err()
 	}
-	if err != nil {
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
 		return nil, err
 	}
 
-	return newBytesReadCloser(cover), nil
+	return tracksPaths, nil
 }
 
 // albumArtworkFromDB returns the original image from the database if one is stored,
@@ -313,7 +334,7 @@ func (lib *LocalLibrary) albumArtworkFromDB(
 	size ImageSize,
 ) (io.ReadCloser, ImageSize, error) {
 
-	buff, unixTime, err := lib.albumArtworkFromDBForSize(ctx, albumID, size)
+	buff, retryAt, err := lib.albumArtworkFromDBForSize(ctx, albumID, size)
 	if err != nil {
 		return nil, size, err
 	}
@@ -323,30 +344,30 @@ func (lib *LocalLibrary) albumArtworkFromDB(
 		return newBytesReadCloser(buff), size, nil
 	}
 
-	selectNotFound := func(lastChanged int64) (io.ReadCloser, ImageSize, error) {
-		if time.Now().Before(time.Unix(lastChanged, 0).Add(notFoundCacheTTL)) {
+	selectNotFound := func(retryAt int64) (io.ReadCloser, ImageSize, error) {
+		if time.Now().Before(time.Unix(retryAt, 0)) {
 			return nil, size, ErrCachedArtworkNotFound
 		}
 		return nil, size, ErrArtworkNotFound
 	}
 
-	// No image in the database. Is either a normal "not found" for images which
-	// haven't  been queried recently. For everything else it is "cached not found"
-	// which means that all the channels for obtaining the image have been tried out
+	// No image in the database. Is either a normal "not found" for images whose
+	// retry time has come. For everything else it is "cached not found" which
+	// means that all the channels for obtaining the image have been tried out
 	// recently and nothing has been found.
 	if size == OriginalImage {
-		return selectNotFound(unixTime)
+		return selectNotFound(retryAt)
 	}
 
 	// No image of the desired size was found. Let us try and see if the original image
 	// is in the database and use it to generate the desired size.
-	buff, unixTime, err = lib.albumArtworkFromDBForSize(ctx, albumID, OriginalImage)
+	buff, retryAt, err = lib.albumArtworkFromDBForSize(ctx, albumID, OriginalImage)
 	if err != nil {
 		return nil, size, err
 	}
 
 	if len(buff) < 1 {
-		return selectNotFound(unixTime)
+		return selectNotFound(retryAt)
 	}
 
 	return newBytesReadCloser(buff), OriginalImage, nil
@@ -357,141 +378,148 @@ func (lib *LocalLibrary) albumArtworkFromDBForSize(
 	albumID int64,
 	size ImageSize,
 ) ([]byte, int64, error) {
+	return lib.imageFromDB(ctx, albumArtworkTable, albumID, size)
+}
 
-	var (
-		buff          []byte
-		unixTime      int64
-		blobColumn    = "artwork_cover"
-		imageSQLQuery = `
-			SELECT
-				%s,
-				updated_at
-			FROM
-				albums_artworks
-			WHERE
-				album_id = ?
-		`
-	)
-	if size == SmallImage {
-		blobColumn = "artwork_cover_small"
+func (lib *LocalLibrary) albumArtworkFromFS(
+	ctx context.Context,
+	albumID int64,
+) (io.ReadCloser, error) {
+	albumPath, err := lib.GetAlbumFSPathByID(albumID)
+
+	if err != nil {
+		return nil, err
 	}
 
-	work := func(db *sql.DB) error {
-		smt, err := db.PrepareContext(ctx, fmt.Sprintf(imageSQLQuery, blobColumn))
+	images, err := lib.localArtwork(ctx, albumPath)
+	if err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			log.Printf("could not prepare album artwork sql statement: %s", err)
-			return err
+	minSize := lib.artworkSelection.MinSize
+	for _, img := range images {
+		if img.score <= 0 {
+			break
 		}
-		defer smt.Close()
 
-		err = smt.QueryRowContext(ctx, albumID).Scan(&buff, &unixTime)
-		if err == sql.ErrNoRows {
-			return ErrArtworkNotFound
-		} else if err != nil {
-			log.Printf("error getting album cover from db: %s", err)
-			return err
+		if minSize > 0 {
+			width, height, _ := lib.localArtworkSize(img.path)
+			if width < minSize || height < minSize {
+				continue
+			}
 		}
 
-		return nil
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return nil, 0, err
+		log.Printf("Selected album [%d] artwork: %s", albumID, img.path)
+		return lib.fs.Open(img.path)
 	}
 
-	return buff, unixTime, nil
+	return nil, ErrArtworkNotFound
 }
 
-func (lib *LocalLibrary) albumArtworkFromFS(
+// embeddedFrontCoverType is the type of the embedded pictures which are front
+// covers as returned by the tag package.
+const embeddedFrontCoverType = "Cover (front)"
+
+// albumArtworkFromEmbedded looks for pictures embedded in the tags of the album's
+// tracks. A front cover is preferred but any other picture is used when none of
+// the tracks has a front cover.
+func (lib *LocalLibrary) albumArtworkFromEmbedded(
 	ctx context.Context,
 	albumID int64,
 ) (io.ReadCloser, error) {
-	albumPath, err := lib.GetAlbumFSPathByID(albumID)
-
+	tracksPaths, err := lib.albumTrackPaths(ctx, albumID)
 	if err != nil {
 		return nil, err
 	}
 
-	imagesRegexp := regexp.MustCompile(`(?i).*\.(png|gif|jpeg|jpg)$`)
-	var possibleArtworks []string
-
-	walkFn := func(path string, info fs.DirEntry, err error) error {
+	var fallback *tag.Picture
+	for _, trackPath := range tracksPaths {
 		if err := ctx.Err(); err != nil {
-			return err
+			return nil, err
 		}
 
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			// Skip directories
-			return nil
+		pic, isFront := lib.readEmbeddedPicture(trackPath)
+		if pic == nil {
+			continue
 		}
-		if imagesRegexp.MatchString(path) {
-			possibleArtworks = append(possibleArtworks, path)
+
+		if isFront {
+			log.Printf("Selected album [%d] artwork embedded in: %s", albumID, trackPath)
+			return newBytesReadCloser(pic.Data), nil
 		}
-		return nil
-	}
 
-	if err := fs.WalkDir(lib.fs, albumPath, walkFn); err != nil {
-		return nil, err
+		if fallback == nil {
+			fallback = pic
+		}
 	}
 
-	if len(possibleArtworks) < 1 {
+	if fallback == nil {
 		return nil, ErrArtworkNotFound
 	}
 
-	var (
-		selectedArtwork string
-		score           int
-	)
+	log.Printf("Selected album [%d] embedded artwork of type: %s", albumID, fallback.Type)
+	return newBytesReadCloser(fallback.Data), nil
+}
 
-	for _, path := range possibleArtworks {
-		if err := ctx.Err(); err != nil {
-			return nil, err
-		}
+// readEmbeddedPicture returns a picture stored in the tags of the media file at
+// `trackPath` and whether it is a front cover. It returns nil when there are no
+// pictures in the file.
+//
+// ID3v2 tags may have many attached pictures so all of them are checked for a front
+// cover. For other formats only the one returned by tag.Metadata.Picture is used.
+func (lib *LocalLibrary) readEmbeddedPicture(trackPath string) (*tag.Picture, bool) {
+	fh, err := lib.fs.Open(trackPath)
+	if err != nil {
+		return nil, false
+	}
+	defer fh.Close()
 
-		pathScore := 5
+	rs, ok := fh.(io.ReadSeeker)
+	if !ok {
+		return nil, false
+	}
 
-		fileBase := strings.ToLower(filepath.Base(path))
+	md, err := tag.ReadFrom(rs)
+	if err != nil {
+		return nil, false
+	}
 
-		if strings.HasPrefix(fileBase, "cover.") || strings.HasPrefix(fileBase, "front.") {
-			pathScore = 15
-		} else if strings.Contains(fileBase, "cover") || strings.Contains(fileBase, "front") {
-			pathScore = 10
-		} else if strings.Contains(fileBase, "artwork") {
-			pathScore = 8
+	picture := md.Picture()
+	if picture != nil && len(picture.Data) == 0 {
+		picture = nil
+	}
+	if picture != nil && picture.Type == embeddedFrontCoverType {
+		return picture, true
+	}
+
+	raw := md.Raw()
+	for _, frame := range slices.Sorted(maps.Keys(raw)) {
+		if !strings.HasPrefix(frame, "APIC") && !strings.HasPrefix(frame, "PIC") {
+			continue
 		}
 
-		if strings.HasPrefix(fileBase, ".") {
-			// Hidden file, it should have a lower score when compared to normal files.
-			pathScore -= 4
+		pic, ok := raw[frame].(*tag.Picture)
+		if !ok || len(pic.Data) == 0 {
+			continue
 		}
 
-		// Artwork which is in the exact directory of the album should have slight
-		// advantage. This is to cover cases where there are directories of albums
-		// inside other albums.
-		if filepath.Dir(path) == albumPath {
-			pathScore += 4
-		} else {
-			pathScore -= 4
+		if pic.Type == embeddedFrontCoverType {
+			return pic, true
 		}
 
-		if pathScore > score {
-			selectedArtwork = path
-			score = pathScore
+		if picture == nil {
+			picture = pic
 		}
 	}
 
-	log.Printf("Selected album [%d] artwork: %s", albumID, selectedArtwork)
-	return lib.fs.Open(selectedArtwork)
+	return picture, false
 }
 
 // SaveAlbumArtwork implements the ArtworkManager interface for the local library.
 //
-// It saves the artwork in `r` in the database. It will read up to 5MB of data from
+// It saves the artwork in `r` in the blob store. It will read up to 5MB of data from
 // `r` and if this limit is reached, the artwork is considered too big and will not
-// be saved in the db.
+// be saved.
 func (lib *LocalLibrary) SaveAlbumArtwork(
 	ctx context.Context,
 	albumID int64,
@@ -521,27 +549,14 @@ func (lib *LocalLibrary) SaveAlbumArtwork(
 		return NewArtworkError(errors.New("uploaded artwork is empty"))
 	}
 
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(`
-			INSERT OR REPLACE INTO
-				albums_artworks (album_id, artwork_cover, updated_at)
-			VALUES
-				(?, ?, ?)
-		`)
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		_, err = stmt.Exec(albumID, buff, time.Now().Unix())
-		return err
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return err
-	}
-
-	return nil
+	return lib.storeImage(
+		albumArtworkTable,
+		albumID,
+		buff,
+		OriginalImage,
+		ArtworkSourceUpload,
+		true,
+	)
 }
 
 // RemoveAlbumArtwork removes the artwork from the library database.
@@ -549,5 +564,5 @@ func (lib *LocalLibrary) SaveAlbumArtwork(
 // Note that this operation does not make sense for artwork which came from disk. Because
 // future requests will find it again and store in the database.
 func (lib *LocalLibrary) RemoveAlbumArtwork(ctx context.Context, albumID int64) error {
-	return lib.saveAlbumArtworkNotFound(albumID)
+	return lib.saveImageNotFound(albumArtworkTable, albumID, false)
 }
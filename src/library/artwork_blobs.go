@@ -0,0 +1,404 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/ironsmile/euterpe/src/blobstore"
+)
+
+const (
+	// blobGarbageAge is the minimum age of files in the blob store which are
+	// removed because they are not in the database. Younger files may belong to
+	// images which are being stored at the moment.
+	blobGarbageAge = time.Hour
+
+	// blobMoveBatch is the number of images which are moved from the database
+	// into the blob store at once.
+	blobMoveBatch = 20
+)
+
+// blobColumn is a database column which used to contain image blobs and the
+// column which now contains their hashes.
+type blobColumn struct {
+	table      string
+	blobColumn string
+	hashColumn string
+
+	// emptyBlob is the value left in the blob column once the blob is moved. It
+	// is needed for columns which do not allow NULL.
+	emptyBlob any
+}
+
+// movedBlobColumns are all the columns from which blobs are moved into the
+// blob store.
+var movedBlobColumns = []blobColumn{
+	{"albums_artworks", "artwork_cover", "artwork_cover_hash", nil},
+	{"albums_artworks", "artwork_cover_small", "artwork_cover_small_hash", nil},
+	{"artists_images", "image", "image_hash", nil},
+	{"artists_images", "image_small", "image_small_hash", nil},
+	{"playlists_images", "image", "image_hash", []byte{}},
+}
+
+// defaultBlobStore returns the blob store for a library with a database at
+// `databasePath`. Blobs are kept in memory together with in-memory databases and
+// in a directory next to the database file otherwise.
+func defaultBlobStore(databasePath string) *blobstore.Store {
+	if databasePath == SQLiteMemoryFile {
+		return blobstore.New(afero.NewMemMapFs(), "blobs")
+	}
+
+	return blobstore.New(
+		afero.NewOsFs(),
+		filepath.Join(filepath.Dir(databasePath), "blobs"),
+	)
+}
+
+// SetBlobStore sets the store in which the library keeps images. It must be
+// called before Initialize. By default images are kept in the "blobs" directory
+// next to the database file.
+func (lib *LocalLibrary) SetBlobStore(store *blobstore.Store) {
+	lib.blobs = store
+}
+
+// column returns the column of `t` with the hash of the image with `size`.
+func (t imageTable) column(size ImageSize) string {
+	if size == SmallImage {
+		return t.smallColumn
+	}
+	return t.imageColumn
+}
+
+// imageFromDB returns the image with `size` for `id` from `t`. The image is empty
+// when there is none. The second returned value is the time after which a missing
+// image should be searched for again. ErrArtworkNotFound is returned when there
+// is no record for `id` at all.
+func (lib *LocalLibrary) imageFromDB(
+	ctx context.Context,
+	t imageTable,
+	id int64,
+	size ImageSize,
+) ([]byte, int64, error) {
+	var (
+		hash    sql.NullString
+		retryAt int64
+	)
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s,
+			coalesce(next_retry_at, updated_at + ?)
+		FROM
+			%s
+		WHERE
+			%s = ?
+	`, t.column(size), t.table, t.idColumn)
+
+	work := func(db *sql.DB) error {
+		err := db.QueryRowContext(
+			ctx,
+			query,
+			int64(notFoundCacheTTL.Seconds()),
+			id,
+		).Scan(&hash, &retryAt)
+		if err == sql.ErrNoRows {
+			return ErrArtworkNotFound
+		} else if err != nil {
+			log.Printf("error getting image from %s: %s", t.table, err)
+			return err
+		}
+
+		return nil
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return nil, 0, err
+	}
+
+	if !hash.Valid {
+		return nil, retryAt, nil
+	}
+
+	buff, err := lib.blobs.Get(hash.String)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Image %s for %s %d is missing from the blob store\n",
+			hash.String, t.table, id)
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("reading image %s: %w", hash.String, err)
+	}
+
+	return buff, retryAt, nil
+}
+
+// storeImage stores `buff` as the image with `size` for `id` in `t`. When `replace`
+// is true all other images for `id` are removed. Otherwise only the one with
+// `size` is changed. Scaled images keep the source of the original so `source`
+// could be empty for them.
+func (lib *LocalLibrary) storeImage(
+	t imageTable,
+	id int64,
+	buff []byte,
+	size ImageSize,
+	source string,
+	replace bool,
+) error {
+	// The file is written before sending the job so that the database worker
+	// is not kept busy with it.
+	hash, err := lib.blobs.Put(buff)
+	if err != nil {
+		return err
+	}
+
+	column := t.column(size)
+	otherImages := ""
+	if replace {
+		otherImages = fmt.Sprintf("%s = NULL,", t.smallColumn)
+	}
+	sourceValue := sql.NullString{String: source, Valid: source != ""}
+
+	storeQuery := fmt.Sprintf(`
+		INSERT INTO
+			%s (%s, %s, updated_at, source)
+		VALUES
+			($1, $2, $3, $4)
+		ON CONFLICT (%s) DO
+		UPDATE SET
+			%s = $2,
+			%s
+			updated_at = $3,
+			source = coalesce($4, source),
+			not_found_attempts = 0,
+			next_retry_at = NULL
+	`, t.table, t.idColumn, column, t.idColumn, column, otherImages)
+
+	work := func(db *sql.DB) error {
+		if err := lib.addBlob(db, hash, buff); err != nil {
+			return err
+		}
+
+		_, err := db.Exec(storeQuery, id, hash, time.Now().Unix(), sourceValue)
+		return err
+	}
+
+	return lib.ExecuteDBJobAndWait(work)
+}
+
+// addBlob records a blob which is about to be referenced. It must be called in
+// the same database job as the query which references it. This way the blob
+// could not be collected as garbage in the meantime.
+func (lib *LocalLibrary) addBlob(db *sql.DB, hash string, buff []byte) error {
+	// The garbage collection may have removed the file after it was written
+	// but before this job has started.
+	if !lib.blobs.Exists(hash) {
+		if _, err := lib.blobs.Put(buff); err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO
+			blobs (hash, size, refs, created_at)
+		VALUES
+			(?, ?, 0, ?)
+	`, hash, len(buff), time.Now().Unix())
+	return err
+}
+
+// moveBlobsToStore moves the images which are still in the database into the blob
+// store and leaves their hashes in their place. The database is vacuumed
+// afterwards so that it shrinks.
+func (lib *LocalLibrary) moveBlobsToStore() error {
+	var moved int
+
+	for _, col := range movedBlobColumns {
+		selectQuery := fmt.Sprintf(`
+			SELECT
+				rowid, %s
+			FROM
+				%s
+			WHERE
+				%s IS NOT NULL AND
+				length(%s) > 0
+			LIMIT ?
+		`, col.blobColumn, col.table, col.blobColumn, col.blobColumn)
+
+		updateQuery := fmt.Sprintf(`
+			UPDATE %s SET
+				%s = ?,
+				%s = ?
+			WHERE
+				rowid = ?
+		`, col.table, col.hashColumn, col.blobColumn)
+
+		for {
+			n, err := lib.moveBlobsBatch(selectQuery, updateQuery, col.emptyBlob)
+			if err != nil {
+				return fmt.Errorf("moving %s.%s: %w", col.table, col.blobColumn, err)
+			}
+			moved += n
+
+			if n < blobMoveBatch {
+				break
+			}
+		}
+	}
+
+	if moved == 0 {
+		return nil
+	}
+
+	log.Printf("Moved %d images from the database into the blob store\n", moved)
+	if _, err := lib.db.Exec("VACUUM"); err != nil {
+		log.Printf("Error vacuuming the database: %s\n", err)
+	}
+
+	return nil
+}
+
+func (lib *LocalLibrary) moveBlobsBatch(
+	selectQuery, updateQuery string,
+	emptyBlob any,
+) (int, error) {
+	type row struct {
+		id   int64
+		blob []byte
+	}
+
+	rows, err := lib.db.Query(selectQuery, blobMoveBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var batch []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.blob); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range batch {
+		hash, err := lib.blobs.Put(r.blob)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := lib.addBlob(lib.db, hash, r.blob); err != nil {
+			return 0, err
+		}
+
+		if _, err := lib.db.Exec(updateQuery, hash, emptyBlob, r.id); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(batch), nil
+}
+
+// collectBlobGarbage removes the images which are no longer used from the blob
+// store.
+func (lib *LocalLibrary) collectBlobGarbage(ctx context.Context) error {
+	var removed int
+
+	// Blobs without references are removed in the database job so that no
+	// other job could reference them in the meantime.
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				hash
+			FROM
+				blobs
+			WHERE
+				refs <= 0
+		`)
+		if err != nil {
+			return err
+		}
+
+		var hashes []string
+		for rows.Next() {
+			var hash string
+			if err := rows.Scan(&hash); err != nil {
+				rows.Close()
+				return err
+			}
+			hashes = append(hashes, hash)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, hash := range hashes {
+			if err := lib.blobs.Remove(hash); err != nil {
+				return err
+			}
+			_, err := db.ExecContext(ctx, `DELETE FROM blobs WHERE hash = ?`, hash)
+			if err != nil {
+				return err
+			}
+			removed++
+		}
+
+		return nil
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return fmt.Errorf("removing unreferenced blobs: %w", err)
+	}
+
+	// Files which are not in the database at all are left from images which
+	// were not stored because of errors.
+	var orphans []string
+	err := lib.blobs.Walk(func(hash string, stored time.Time) error {
+		if time.Since(stored) >= blobGarbageAge {
+			orphans = append(orphans, hash)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return fmt.Errorf("listing blobs: %w", err)
+	}
+
+	for _, hash := range orphans {
+		work := func(db *sql.DB) error {
+			var known bool
+			err := db.QueryRowContext(ctx, `
+				SELECT
+					count(*) > 0
+				FROM
+					blobs
+				WHERE
+					hash = ?
+			`, hash).Scan(&known)
+			if err != nil || known {
+				return err
+			}
+
+			removed++
+			return lib.blobs.Remove(hash)
+		}
+		if err := lib.ExecuteDBJobAndWait(work); err != nil {
+			return fmt.Errorf("removing orphaned blob: %w", err)
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("Removed %d unused images from the blob store\n", removed)
+	}
+
+	return nil
+}
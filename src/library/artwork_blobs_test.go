@@ -0,0 +1,213 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/ironsmile/euterpe/src/blobstore"
+)
+
+// TestArtworkBlobStore checks that images stored in the database are moved into the
+// blob store, that the references to blobs are counted and that unused blobs are
+// removed.
+func TestArtworkBlobStore(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		legacyCover = []byte("cover from the database")
+		legacySmall = []byte("small cover from the database")
+		uploaded    = []byte("uploaded image")
+	)
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	memFS := afero.NewMemMapFs()
+	store := blobstore.New(memFS, "blobs")
+	lib.SetBlobStore(store)
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	const filePath = "path/to/blobs/track.mp3"
+	lib.fs = fstest.MapFS{
+		filePath: &fstest.MapFile{
+			Data:    []byte("track"),
+			ModTime: time.Now(),
+		},
+	}
+
+	media := MockMedia{
+		artist: "Blob Artist",
+		album:  "Blob Album",
+		title:  "Track",
+		track:  1,
+		length: 123,
+	}
+	info := fileInfo{
+		Size:     1024,
+		FilePath: filePath,
+		Modified: time.Now(),
+	}
+	if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+		t.Fatalf("inserting media file failed: %s", err)
+	}
+
+	albumID, err := lib.GetAlbumID(media.album, "path/to/blobs")
+	if err != nil {
+		t.Fatalf("error getting albumID: %s", err)
+	}
+	artistID, err := lib.GetArtistID(media.artist)
+	if err != nil {
+		t.Fatalf("error getting artistID: %s", err)
+	}
+
+	// Images stored by older versions are in the database itself.
+	_, err = lib.db.Exec(`
+		INSERT INTO
+			albums_artworks (album_id, artwork_cover, artwork_cover_small, updated_at)
+		VALUES
+			(?, ?, ?, ?)
+	`, albumID, legacyCover, legacySmall, time.Now().Unix())
+	if err != nil {
+		t.Fatalf("inserting legacy artwork: %s", err)
+	}
+
+	if err := lib.moveBlobsToStore(); err != nil {
+		t.Fatalf("moving blobs: %s", err)
+	}
+
+	assertRefs := func(data []byte, expected int64) {
+		t.Helper()
+
+		var refs int64
+		work := func(db *sql.DB) error {
+			return db.QueryRow(
+				`SELECT refs FROM blobs WHERE hash = ?`,
+				blobstore.Hash(data),
+			).Scan(&refs)
+		}
+		err := lib.ExecuteDBJobAndWait(work)
+		if err == sql.ErrNoRows {
+			refs = -1
+		} else if err != nil {
+			t.Fatalf("getting blob references: %s", err)
+		}
+
+		if refs != expected {
+			t.Errorf("expected %d references to %q but there are %d",
+				expected, data, refs)
+		}
+	}
+
+	assertImage := func(r io.ReadCloser, err error, expected []byte) {
+		t.Helper()
+
+		if err != nil {
+			t.Fatalf("getting image: %s", err)
+		}
+		defer r.Close()
+
+		data, _ := io.ReadAll(r)
+		if !bytes.Equal(data, expected) {
+			t.Errorf("expected image %q but got %q", expected, data)
+		}
+	}
+
+	var leftInDB int
+	err = lib.db.QueryRow(`
+		SELECT
+			count(*)
+		FROM
+			albums_artworks
+		WHERE
+			artwork_cover IS NOT NULL OR
+			artwork_cover_small IS NOT NULL
+	`).Scan(&leftInDB)
+	if err != nil {
+		t.Fatalf("counting images in the database: %s", err)
+	}
+	if leftInDB != 0 {
+		t.Errorf("images were left in the database after moving them")
+	}
+
+	assertRefs(legacyCover, 1)
+	assertRefs(legacySmall, 1)
+
+	r, err := lib.FindAndSaveAlbumArtwork(ctx, albumID, OriginalImage)
+	assertImage(r, err, legacyCover)
+	r, err = lib.FindAndSaveAlbumArtwork(ctx, albumID, SmallImage)
+	assertImage(r, err, legacySmall)
+
+	// Uploading replaces both the image and its small version.
+	if err := lib.SaveAlbumArtwork(ctx, albumID, bytes.NewReader(uploaded)); err != nil {
+		t.Fatalf("uploading artwork: %s", err)
+	}
+	if err := lib.SaveArtistImage(ctx, artistID, bytes.NewReader(uploaded)); err != nil {
+		t.Fatalf("uploading artist image: %s", err)
+	}
+
+	assertRefs(legacyCover, 0)
+	assertRefs(legacySmall, 0)
+	assertRefs(uploaded, 2)
+
+	r, err = lib.FindAndSaveArtistImage(ctx, artistID, OriginalImage)
+	assertImage(r, err, uploaded)
+
+	if err := lib.RemoveAlbumArtwork(ctx, albumID); err != nil {
+		t.Fatalf("removing artwork: %s", err)
+	}
+	assertRefs(uploaded, 1)
+
+	orphan, err := store.Put([]byte("never referenced"))
+	if err != nil {
+		t.Fatalf("storing orphaned blob: %s", err)
+	}
+	recent, err := store.Put([]byte("being stored right now"))
+	if err != nil {
+		t.Fatalf("storing recent blob: %s", err)
+	}
+	old := time.Now().Add(-2 * blobGarbageAge)
+	orphanPath := filepath.Join("blobs", orphan[:2], orphan)
+	if err := memFS.Chtimes(orphanPath, old, old); err != nil {
+		t.Fatalf("changing orphaned blob times: %s", err)
+	}
+
+	if err := lib.collectBlobGarbage(ctx); err != nil {
+		t.Fatalf("collecting garbage: %s", err)
+	}
+
+	for _, data := range [][]byte{legacyCover, legacySmall} {
+		if store.Exists(blobstore.Hash(data)) {
+			t.Errorf("unused blob %q was not removed", data)
+		}
+		assertRefs(data, -1)
+	}
+	if !store.Exists(blobstore.Hash(uploaded)) {
+		t.Errorf("used blob was removed")
+	}
+	if store.Exists(orphan) {
+		t.Errorf("orphaned blob was not removed")
+	}
+	if !store.Exists(recent) {
+		t.Errorf("recently stored blob was removed")
+	}
+
+	// Removing the last reference leaves the blob to the garbage collection.
+	if err := lib.RemoveArtistImage(ctx, artistID); err != nil {
+		t.Fatalf("removing artist image: %s", err)
+	}
+	assertRefs(uploaded, 0)
+}
@@ -0,0 +1,417 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"log"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/blobstore"
+)
+
+// Prefixes of the IDs of the artwork candidates. They are followed by the path
+// of the image relative to the album directory, the path of the track in which
+// it is embedded or the name of the provider on the internet.
+const (
+	candidateLocal    = "local:"
+	candidateEmbedded = "embedded:"
+	candidateProvider = "provider:"
+)
+
+// ArtworkCandidate is an image which could be used as the artwork of an album.
+type ArtworkCandidate struct {
+	// ID identifies the candidate for PinAlbumArtwork.
+	ID string `json:"id"`
+
+	// Source is one of ArtworkSourceLocal, ArtworkSourceEmbedded or the name of
+	// the provider on the internet which has found the image.
+	Source string `json:"source"`
+
+	// Path is the file of local images and the file of the track for embedded
+	// ones. It is relative to the album directory.
+	Path string `json:"path,omitempty"`
+
+	// Width and Height are the dimensions of the image in pixels. They are zero
+	// when the image could not be decoded.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// Format is the name of the image format such as "jpeg" or "png".
+	Format string `json:"format,omitempty"`
+
+	// Size is the size of the image in bytes.
+	Size int `json:"size"`
+
+	// Score is the score of local images according to the artwork selection.
+	// Images with no positive score are not chosen automatically.
+	Score int `json:"score,omitempty"`
+
+	// Current is true for the image which is the artwork of the album at the
+	// moment.
+	Current bool `json:"current"`
+}
+
+// localArtworkImage is an image in the directory of an album.
+type localArtworkImage struct {
+	path  string
+	score int
+
+	// extension is the index of the image's extension in the preferred
+	// extensions of the artwork selection.
+	extension int
+}
+
+// localArtwork returns the images in `albumPath` and its sub-directories ordered
+// from the best to the worst according to the artwork selection of the library.
+func (lib *LocalLibrary) localArtwork(
+	ctx context.Context,
+	albumPath string,
+) ([]localArtworkImage, error) {
+	selection := lib.artworkSelection
+	var images []localArtworkImage
+
+	walkFn := func(imgPath string, info fs.DirEntry, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// Skip directories
+			return nil
+		}
+
+		fileBase := strings.ToLower(filepath.Base(imgPath))
+		extension := slices.IndexFunc(selection.Extensions, func(ext string) bool {
+			return strings.TrimPrefix(filepath.Ext(fileBase), ".") ==
+				strings.ToLower(strings.Trim(ext, ". "))
+		})
+		if extension < 0 {
+			return nil
+		}
+
+		var score int
+		for _, pattern := range selection.Patterns {
+			if ok, _ := path.Match(pattern.Pattern, fileBase); ok {
+				score = pattern.Score
+				break
+			}
+		}
+
+		if strings.HasPrefix(fileBase, ".") {
+			// Hidden file, it should have a lower score when compared to normal files.
+			score -= 4
+		}
+
+		// Artwork which is in the exact directory of the album should have slight
+		// advantage. This is to cover cases where there are directories of albums
+		// inside other albums.
+		if filepath.Dir(imgPath) == albumPath {
+			score += 4
+		} else {
+			score -= 4
+		}
+
+		images = append(images, localArtworkImage{
+			path:      imgPath,
+			score:     score,
+			extension: extension,
+		})
+		return nil
+	}
+
+	if err := fs.WalkDir(lib.fs, albumPath, walkFn); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(images, func(a, b localArtworkImage) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return a.extension - b.extension
+	})
+
+	return images, nil
+}
+
+// localArtworkSize returns the width and height of the image at `imgPath`.
+func (lib *LocalLibrary) localArtworkSize(imgPath string) (int, int, error) {
+	fh, err := lib.fs.Open(imgPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer fh.Close()
+
+	cfg, _, err := image.DecodeConfig(fh)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return cfg.Width, cfg.Height, nil
+}
+
+// GetAlbumArtworkCandidates implements the ArtworkManager interface for the local
+// library. It lists the images in the album directory, the ones embedded in its
+// tracks and the ones found by every provider on the internet. Only the sources set
+// with SetArtworkSources are used. The local images are ordered by their scores.
+func (lib *LocalLibrary) GetAlbumArtworkCandidates(
+	ctx context.Context,
+	albumID int64,
+) ([]ArtworkCandidate, error) {
+	albumPath, err := lib.GetAlbumFSPathByID(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := lib.currentImageHash(ctx, albumArtworkTable, albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []ArtworkCandidate{}
+	add := func(candidate ArtworkCandidate, data []byte) {
+		candidate.Size = len(data)
+		candidate.Current = current != "" && blobstore.Hash(data) == current
+		if cfg, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			candidate.Width = cfg.Width
+			candidate.Height = cfg.Height
+			candidate.Format = format
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	for _, source := range lib.artworkSources {
+		switch source {
+		case ArtworkSourceLocal:
+			images, err := lib.localArtwork(ctx, albumPath)
+			if err != nil {
+				return nil, fmt.Errorf("listing local images: %w", err)
+			}
+
+			for _, img := range images {
+				data, err := fs.ReadFile(lib.fs, img.path)
+				if err != nil {
+					log.Printf("Error reading artwork candidate %s: %s\n", img.path, err)
+					continue
+				}
+
+				relPath := relativeAlbumPath(albumPath, img.path)
+				add(ArtworkCandidate{
+					ID:     candidateLocal + relPath,
+					Source: ArtworkSourceLocal,
+					Path:   relPath,
+					Score:  img.score,
+				}, data)
+			}
+		case ArtworkSourceEmbedded:
+			tracksPaths, err := lib.albumTrackPaths(ctx, albumID)
+			if err != nil {
+				return nil, fmt.Errorf("listing tracks: %w", err)
+			}
+
+			// Tracks in an album usually have the same pictures so every
+			// picture is listed only once.
+			seen := make(map[string]bool)
+			for _, trackPath := range tracksPaths {
+				pic, _ := lib.readEmbeddedPicture(trackPath)
+				if pic == nil || seen[blobstore.Hash(pic.Data)] {
+					continue
+				}
+				seen[blobstore.Hash(pic.Data)] = true
+
+				relPath := relativeAlbumPath(albumPath, trackPath)
+				add(ArtworkCandidate{
+					ID:     candidateEmbedded + relPath,
+					Source: ArtworkSourceEmbedded,
+					Path:   relPath,
+				}, pic.Data)
+			}
+		case ArtworkSourceInternet:
+			images, err := lib.albumArtworkCandidatesFromInternet(ctx, albumID)
+			if err != nil {
+				return nil, fmt.Errorf("searching the internet: %w", err)
+			}
+
+			for _, img := range images {
+				add(ArtworkCandidate{
+					ID:     candidateProvider + img.Provider,
+					Source: img.Provider,
+				}, img.Data)
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// PinAlbumArtwork implements the ArtworkManager interface for the local library.
+// It stores the candidate with `candidateID` as returned by GetAlbumArtworkCandidates
+// as the artwork of the album. It stays its artwork until it is removed or another
+// one is uploaded or pinned. ErrArtworkNotFound is returned when there is no such
+// candidate.
+func (lib *LocalLibrary) PinAlbumArtwork(
+	ctx context.Context,
+	albumID int64,
+	candidateID string,
+) error {
+	albumPath, err := lib.GetAlbumFSPathByID(albumID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		data   []byte
+		source string
+	)
+
+	switch {
+	case strings.HasPrefix(candidateID, candidateLocal):
+		relPath := strings.TrimPrefix(candidateID, candidateLocal)
+		if !fs.ValidPath(relPath) {
+			return ErrArtworkNotFound
+		}
+
+		images, err := lib.localArtwork(ctx, albumPath)
+		if err != nil {
+			return err
+		}
+		imgPath := filepath.Join(albumPath, filepath.FromSlash(relPath))
+		if !slices.ContainsFunc(images, func(img localArtworkImage) bool {
+			return img.path == imgPath
+		}) {
+			return ErrArtworkNotFound
+		}
+
+		data, err = fs.ReadFile(lib.fs, imgPath)
+		if err != nil {
+			return err
+		}
+		source = ArtworkSourceLocal
+	case strings.HasPrefix(candidateID, candidateEmbedded):
+		relPath := strings.TrimPrefix(candidateID, candidateEmbedded)
+
+		tracksPaths, err := lib.albumTrackPaths(ctx, albumID)
+		if err != nil {
+			return err
+		}
+		trackPath := filepath.Join(albumPath, filepath.FromSlash(relPath))
+		if !fs.ValidPath(relPath) || !slices.Contains(tracksPaths, trackPath) {
+			return ErrArtworkNotFound
+		}
+
+		pic, _ := lib.readEmbeddedPicture(trackPath)
+		if pic == nil {
+			return ErrArtworkNotFound
+		}
+		data = pic.Data
+		source = ArtworkSourceEmbedded
+	case strings.HasPrefix(candidateID, candidateProvider):
+		provider := strings.TrimPrefix(candidateID, candidateProvider)
+		images, err := lib.albumArtworkCandidatesFromInternet(ctx, albumID, provider)
+		if err != nil {
+			return err
+		}
+		if len(images) == 0 {
+			return ErrArtworkNotFound
+		}
+		data = images[0].Data
+		source = images[0].Provider
+	default:
+		return ErrArtworkNotFound
+	}
+
+	if len(data) == 0 {
+		return ErrArtworkNotFound
+	}
+
+	return lib.storeImage(albumArtworkTable, albumID, data, OriginalImage, source, true)
+}
+
+// albumArtworkCandidatesFromInternet returns the album artwork found by the
+// providers with `names` or by all of them when no names are given. When the art
+// finder of the library could not tell which provider has found an image there is
+// only one "provider" which is ArtworkSourceInternet.
+func (lib *LocalLibrary) albumArtworkCandidatesFromInternet(
+	ctx context.Context,
+	albumID int64,
+	names ...string,
+) ([]art.Image, error) {
+	if lib.artFinder == nil {
+		return nil, nil
+	}
+
+	artistName, albumName, err := lib.albumArtworkQuery(ctx, albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	if finder, ok := lib.artFinder.(art.CandidateFinder); ok {
+		return finder.FrontImages(ctx, artistName, albumName, names...)
+	}
+
+	if len(names) > 0 && !slices.Contains(names, ArtworkSourceInternet) {
+		return nil, nil
+	}
+
+	data, err := lib.artFinder.GetFrontImage(ctx, artistName, albumName)
+	if errors.Is(err, art.ErrImageNotFound) || len(data) == 0 {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []art.Image{{Data: data, Provider: ArtworkSourceInternet}}, nil
+}
+
+// currentImageHash returns the hash of the image for `id` in `t`. It is empty when
+// there is no image.
+func (lib *LocalLibrary) currentImageHash(
+	ctx context.Context,
+	t imageTable,
+	id int64,
+) (string, error) {
+	var hash sql.NullString
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s
+		FROM
+			%s
+		WHERE
+			%s = ?
+	`, t.imageColumn, t.table, t.idColumn)
+
+	work := func(db *sql.DB) error {
+		err := db.QueryRowContext(ctx, query, id).Scan(&hash)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return "", err
+	}
+
+	return hash.String, nil
+}
+
+// relativeAlbumPath returns `filePath` relative to the album directory with
+// slashes as separators.
+func relativeAlbumPath(albumPath, filePath string) string {
+	relPath, err := filepath.Rel(albumPath, filePath)
+	if err != nil {
+		return filepath.ToSlash(filePath)
+	}
+	return filepath.ToSlash(relPath)
+}
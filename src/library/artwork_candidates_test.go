@@ -0,0 +1,170 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/art/artfakes"
+	"github.com/ironsmile/euterpe/src/config"
+)
+
+// TestAlbumArtworkCandidates checks that the artwork selection is used for choosing
+// among the images in the album directory, that all candidates are listed and
+// that any of them could be pinned as the album artwork.
+func TestAlbumArtworkCandidates(t *testing.T) {
+	var (
+		ctx           = context.Background()
+		smallCover    = pngImage(t, 50, 50)
+		frontCover    = pngImage(t, 300, 200)
+		backCover     = pngImage(t, 200, 200)
+		embedded      = pngImage(t, 120, 120)
+		internetCover = []byte("internet-cover")
+	)
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	provider := &artfakes.FakeProvider{}
+	provider.NameReturns("fake-provider")
+	provider.GetFrontImageReturns(internetCover, nil)
+	lib.SetArtFinder(art.NewChain(provider))
+
+	lib.SetArtworkSelection(config.ArtworkSelection{
+		Patterns: []config.ArtworkPattern{
+			{Pattern: "cover.*", Score: 20},
+			{Pattern: "front.*", Score: 15},
+			{Pattern: "back.*", Score: -100},
+		},
+		Extensions: []string{"png"},
+		MinSize:    100,
+	})
+
+	const filePath = "path/to/album/track.mp3"
+	lib.fs = fstest.MapFS{
+		filePath: &fstest.MapFile{
+			Data:    id3v2WithPictures(map[byte][]byte{0x03: embedded}),
+			ModTime: time.Now(),
+		},
+		"path/to/album/cover.png": &fstest.MapFile{
+			Data:    smallCover,
+			ModTime: time.Now(),
+		},
+		"path/to/album/scans/front.png": &fstest.MapFile{
+			Data:    frontCover,
+			ModTime: time.Now(),
+		},
+		"path/to/album/back.png": &fstest.MapFile{
+			Data:    backCover,
+			ModTime: time.Now(),
+		},
+		"path/to/album/cover.jpg": &fstest.MapFile{
+			Data:    []byte("not one of the extensions"),
+			ModTime: time.Now(),
+		},
+	}
+
+	media := MockMedia{
+		artist: "Candidates Artist",
+		album:  "Candidates Album",
+		title:  "Track",
+		track:  1,
+		length: 123,
+	}
+	info := fileInfo{
+		Size:     1024,
+		FilePath: filePath,
+		Modified: time.Now(),
+	}
+	if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+		t.Fatalf("inserting media file failed: %s", err)
+	}
+
+	albumID, err := lib.GetAlbumID(media.album, "path/to/album")
+	if err != nil {
+		t.Fatalf("error getting albumID: %s", err)
+	}
+
+	// The cover is too small so the front in the sub-directory is used.
+	assertAlbumImage(t, lib, albumID, OriginalImage, frontCover)
+
+	candidates, err := lib.GetAlbumArtworkCandidates(ctx, albumID)
+	if err != nil {
+		t.Fatalf("getting candidates: %s", err)
+	}
+
+	expected := []ArtworkCandidate{
+		{ID: "local:cover.png", Source: "local", Path: "cover.png", Width: 50, Height: 50, Score: 24},
+		{ID: "local:scans/front.png", Source: "local", Path: "scans/front.png", Width: 300, Height: 200, Score: 11, Current: true},
+		{ID: "local:back.png", Source: "local", Path: "back.png", Width: 200, Height: 200, Score: -96},
+		{ID: "embedded:track.mp3", Source: "embedded", Path: "track.mp3", Width: 120, Height: 120},
+		{ID: "provider:fake-provider", Source: "fake-provider"},
+	}
+	if len(candidates) != len(expected) {
+		t.Fatalf("expected %d candidates but got %+v", len(expected), candidates)
+	}
+	for i, candidate := range candidates {
+		exp := expected[i]
+		if candidate.ID != exp.ID || candidate.Source != exp.Source ||
+			candidate.Path != exp.Path || candidate.Width != exp.Width ||
+			candidate.Height != exp.Height || candidate.Score != exp.Score ||
+			candidate.Current != exp.Current {
+			t.Errorf("candidate %d: expected %+v but got %+v", i, exp, candidate)
+		}
+		if candidate.Width > 0 && candidate.Format != "png" {
+			t.Errorf("candidate %d: expected png format but got %q", i, candidate.Format)
+		}
+	}
+
+	for _, pin := range []struct {
+		id       string
+		expected []byte
+	}{
+		{"local:back.png", backCover},
+		{"embedded:track.mp3", embedded},
+		{"provider:fake-provider", internetCover},
+	} {
+		if err := lib.PinAlbumArtwork(ctx, albumID, pin.id); err != nil {
+			t.Fatalf("pinning %s: %s", pin.id, err)
+		}
+		assertAlbumImage(t, lib, albumID, OriginalImage, pin.expected)
+	}
+
+	for _, id := range []string{
+		"local:../album/cover.jpg",
+		"local:cover.jpg",
+		"local:missing.png",
+		"embedded:cover.png",
+		"provider:no-such-provider",
+		"cover.png",
+	} {
+		if err := lib.PinAlbumArtwork(ctx, albumID, id); !errors.Is(err, ErrArtworkNotFound) {
+			t.Errorf("pinning %s: expected artwork not found but got %v", id, err)
+		}
+	}
+}
+
+// pngImage returns an encoded PNG image with the given dimensions.
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encoding image: %s", err)
+	}
+	return buf.Bytes()
+}
@@ -24,6 +24,18 @@ type ArtworkManager interface {
 
 	// RemoveAlbumArtwork removes the stored artwork for particular album.
 	RemoveAlbumArtwork(ctx context.Context, albumID int64) error
+
+	// GetArtworkMisses returns the albums and artists for which no images have
+	// been found.
+	GetArtworkMisses(ctx context.Context) (ArtworkMisses, error)
+
+	// GetAlbumArtworkCandidates lists the images which could be used as the
+	// artwork of particular album.
+	GetAlbumArtworkCandidates(ctx context.Context, albumID int64) ([]ArtworkCandidate, error)
+
+	// PinAlbumArtwork makes one of the candidates from GetAlbumArtworkCandidates
+	// the artwork of particular album.
+	PinAlbumArtwork(ctx context.Context, albumID int64, candidateID string) error
 }
 
 //counterfeiter:generate . ArtistImageManager
@@ -56,4 +68,21 @@ const (
 	SmallImage
 )
 
+// Sources of album artwork and artist images. Every stored image is recorded along
+// with its source. Images from the internet are recorded with the name of the
+// art.Provider which found them when it is known.
+const (
+	// ArtworkSourceLocal is for images in the directories of the albums.
+	ArtworkSourceLocal = "local"
+
+	// ArtworkSourceEmbedded is for images in the tags of the media files.
+	ArtworkSourceEmbedded = "embedded"
+
+	// ArtworkSourceInternet is for images found by the art.Finder.
+	ArtworkSourceInternet = "internet"
+
+	// ArtworkSourceUpload is for images uploaded by the users.
+	ArtworkSourceUpload = "upload"
+)
+
 var notFoundCacheTTL = 24 * 7 * time.Hour
@@ -0,0 +1,284 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// notFoundRetryBase is the time after the first failed attempt to find an
+	// image before it is searched for again. It doubles with every next one.
+	notFoundRetryBase = time.Hour
+
+	// notFoundRetryMax is the longest time between two attempts.
+	notFoundRetryMax = 30 * 24 * time.Hour
+
+	// artworkRetryBatch is the most albums and artists which are retried at once
+	// by the background job.
+	artworkRetryBatch = 50
+)
+
+// ArtworkMiss is an album or an artist for which no image has been found.
+type ArtworkMiss struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+
+	// Attempts is the number of failed attempts to find an image.
+	Attempts int64 `json:"attempts"`
+
+	// LastAttempt is the Unix timestamp of the last failed attempt.
+	LastAttempt int64 `json:"last_attempt"`
+
+	// NextRetry is the Unix timestamp after which the image will be searched
+	// for again.
+	NextRetry int64 `json:"next_retry"`
+}
+
+// ArtworkMisses lists the albums and artists for which no images have been found.
+type ArtworkMisses struct {
+	Albums  []ArtworkMiss `json:"albums"`
+	Artists []ArtworkMiss `json:"artists"`
+}
+
+// imageTable describes a database table in which images are stored.
+type imageTable struct {
+	table    string
+	idColumn string
+
+	// imageColumn and smallColumn hold the blob store hashes of the image and
+	// of its small version.
+	imageColumn string
+	smallColumn string
+
+	// namesTable is the table with the names of the albums or artists.
+	namesTable string
+}
+
+var (
+	albumArtworkTable = imageTable{
+		table:       "albums_artworks",
+		idColumn:    "album_id",
+		imageColumn: "artwork_cover_hash",
+		smallColumn: "artwork_cover_small_hash",
+		namesTable:  "albums",
+	}
+	artistImageTable = imageTable{
+		table:       "artists_images",
+		idColumn:    "artist_id",
+		imageColumn: "image_hash",
+		smallColumn: "image_small_hash",
+		namesTable:  "artists",
+	}
+)
+
+// notFoundRetryDelay returns the time to wait before searching for an image again
+// after `attempts` failed attempts.
+func notFoundRetryDelay(attempts int64) time.Duration {
+	delay := notFoundRetryBase
+	for i := int64(1); i < attempts && delay < notFoundRetryMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, notFoundRetryMax)
+}
+
+// saveImageNotFound replaces the images for `id` in `t` with a record that there
+// are none. When `miss` is true the record is for a failed attempt to find an image
+// and the attempts are counted. Otherwise the image has been removed on purpose
+// and it is not searched for until notFoundCacheTTL passes.
+func (lib *LocalLibrary) saveImageNotFound(t imageTable, id int64, miss bool) error {
+	work := func(db *sql.DB) error {
+		var (
+			now       = time.Now()
+			attempts  int64
+			nextRetry = now.Add(notFoundCacheTTL)
+		)
+
+		if miss {
+			err := db.QueryRow(fmt.Sprintf(`
+				SELECT
+					not_found_attempts
+				FROM
+					%s
+				WHERE
+					%s = ? AND
+					%s IS NULL
+			`, t.table, t.idColumn, t.imageColumn), id).Scan(&attempts)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			attempts++
+			nextRetry = now.Add(notFoundRetryDelay(attempts))
+		}
+
+		_, err := db.Exec(fmt.Sprintf(`
+			INSERT INTO
+				%[1]s (%[2]s, updated_at, not_found_attempts, next_retry_at)
+			VALUES
+				($1, $2, $3, $4)
+			ON CONFLICT (%[2]s) DO
+			UPDATE SET
+				%[3]s = NULL,
+				%[4]s = NULL,
+				source = NULL,
+				updated_at = $2,
+				not_found_attempts = $3,
+				next_retry_at = $4
+		`, t.table, t.idColumn, t.imageColumn, t.smallColumn),
+			id, now.Unix(), attempts, nextRetry.Unix())
+		return err
+	}
+
+	return lib.ExecuteDBJobAndWait(work)
+}
+
+// GetArtworkMisses returns the albums and artists for which no images have been
+// found. They are ordered by the time of their next retry.
+func (lib *LocalLibrary) GetArtworkMisses(ctx context.Context) (ArtworkMisses, error) {
+	var (
+		misses ArtworkMisses
+		err    error
+	)
+
+	misses.Albums, err = lib.imageMisses(ctx, albumArtworkTable, time.Time{}, -1)
+	if err != nil {
+		return misses, fmt.Errorf("getting album misses: %w", err)
+	}
+
+	misses.Artists, err = lib.imageMisses(ctx, artistImageTable, time.Time{}, -1)
+	if err != nil {
+		return misses, fmt.Errorf("getting artist misses: %w", err)
+	}
+
+	return misses, nil
+}
+
+// imageMisses returns up to `limit` misses from `t`. A negative limit means all of
+// them. When `dueBy` is not zero only the misses which should be retried by then
+// are returned.
+func (lib *LocalLibrary) imageMisses(
+	ctx context.Context,
+	t imageTable,
+	dueBy time.Time,
+	limit int,
+) ([]ArtworkMiss, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			i.%[2]s,
+			n.name,
+			i.not_found_attempts,
+			i.updated_at,
+			i.next_retry_at
+		FROM
+			%[1]s AS i
+		JOIN %[4]s AS n ON n.id = i.%[2]s
+		WHERE
+			i.not_found_attempts > 0 AND
+			i.%[3]s IS NULL AND
+			(? = 0 OR i.next_retry_at <= ?)
+		ORDER BY
+			i.next_retry_at, i.%[2]s
+		LIMIT ?
+	`, t.table, t.idColumn, t.imageColumn, t.namesTable)
+
+	var dueByUnix int64
+	if !dueBy.IsZero() {
+		dueByUnix = dueBy.Unix()
+	}
+
+	misses := []ArtworkMiss{}
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, dueByUnix, dueByUnix, limit)
+		if err != nil {
+			return fmt.Errorf("query database: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var miss ArtworkMiss
+			if err := rows.Scan(
+				&miss.ID,
+				&miss.Name,
+				&miss.Attempts,
+				&miss.LastAttempt,
+				&miss.NextRetry,
+			); err != nil {
+				return fmt.Errorf("scanning db result: %w", err)
+			}
+			misses = append(misses, miss)
+		}
+
+		return rows.Err()
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	return misses, nil
+}
+
+// RetryArtworkMisses searches again for the images of the albums and artists whose
+// retry time has come. No more than `limit` of each are retried. Images which are
+// not found again are retried later after a longer wait.
+func (lib *LocalLibrary) RetryArtworkMisses(ctx context.Context, limit int) error {
+	albums, err := lib.imageMisses(ctx, albumArtworkTable, time.Now(), limit)
+	if err != nil {
+		return fmt.Errorf("getting album misses: %w", err)
+	}
+
+	for _, album := range albums {
+		r, err := lib.FindAndSaveAlbumArtwork(ctx, album.ID, OriginalImage)
+		if err == nil {
+			_ = r.Close()
+		} else if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		} else if !errors.Is(err, ErrArtworkNotFound) {
+			log.Printf("Retrying album %d artwork error: %s\n", album.ID, err)
+		}
+	}
+
+	artists, err := lib.imageMisses(ctx, artistImageTable, time.Now(), limit)
+	if err != nil {
+		return fmt.Errorf("getting artist misses: %w", err)
+	}
+
+	for _, artist := range artists {
+		r, err := lib.FindAndSaveArtistImage(ctx, artist.ID, OriginalImage)
+		if err == nil {
+			_ = r.Close()
+		} else if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		} else if !errors.Is(err, ErrArtworkNotFound) {
+			log.Printf("Retrying artist %d image error: %s\n", artist.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// StartArtworkRetries starts a background job which calls RetryArtworkMisses every
+// `interval` until the library is closed.
+func (lib *LocalLibrary) StartArtworkRetries(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-lib.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := lib.RetryArtworkMisses(lib.ctx, artworkRetryBatch)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Retrying artwork misses: %s\n", err)
+			}
+		}
+	}()
+}
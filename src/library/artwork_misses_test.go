@@ -0,0 +1,188 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/art/artfakes"
+)
+
+// TestArtworkMissesRetry checks that failed attempts to find images are recorded
+// and retried with increasing delays until an image is found.
+func TestArtworkMissesRetry(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		cover = []byte("found-at-last")
+	)
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	finder := &artfakes.FakeFinder{}
+	finder.GetFrontImageReturns(nil, art.ErrImageNotFound)
+	finder.GetArtistImageReturns(nil, art.ErrImageNotFound)
+	lib.SetArtFinder(finder)
+
+	const filePath = "path/to/missing/track.mp3"
+	lib.fs = fstest.MapFS{
+		filePath: &fstest.MapFile{
+			Data:    []byte("track"),
+			ModTime: time.Now(),
+		},
+	}
+
+	media := MockMedia{
+		artist: "Missing Artist",
+		album:  "Missing Album",
+		title:  "Track",
+		track:  1,
+		length: 123,
+	}
+	info := fileInfo{
+		Size:     1024,
+		FilePath: filePath,
+		Modified: time.Now(),
+	}
+	if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+		t.Fatalf("inserting media file failed: %s", err)
+	}
+
+	albumID, err := lib.GetAlbumID(media.album, "path/to/missing")
+	if err != nil {
+		t.Fatalf("error getting albumID: %s", err)
+	}
+	artistID, err := lib.GetArtistID(media.artist)
+	if err != nil {
+		t.Fatalf("error getting artistID: %s", err)
+	}
+
+	assertMisses := func(albums, artists int) ArtworkMisses {
+		t.Helper()
+
+		misses, err := lib.GetArtworkMisses(ctx)
+		if err != nil {
+			t.Fatalf("error getting misses: %s", err)
+		}
+		if len(misses.Albums) != albums || len(misses.Artists) != artists {
+			t.Fatalf("expected %d album and %d artist misses but got %+v",
+				albums, artists, misses)
+		}
+		return misses
+	}
+
+	makeDue := func() {
+		t.Helper()
+
+		work := func(db *sql.DB) error {
+			for _, table := range []string{"albums_artworks", "artists_images"} {
+				_, err := db.Exec(
+					"UPDATE "+table+" SET next_retry_at = ? WHERE not_found_attempts > 0",
+					time.Now().Add(-time.Minute).Unix(),
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := lib.ExecuteDBJobAndWait(work); err != nil {
+			t.Fatalf("error making misses due: %s", err)
+		}
+	}
+
+	_, err = lib.FindAndSaveAlbumArtwork(ctx, albumID, OriginalImage)
+	if !errors.Is(err, ErrArtworkNotFound) {
+		t.Fatalf("expected artwork not found but got: %v", err)
+	}
+	_, err = lib.FindAndSaveArtistImage(ctx, artistID, OriginalImage)
+	if !errors.Is(err, ErrArtworkNotFound) {
+		t.Fatalf("expected artist image not found but got: %v", err)
+	}
+
+	misses := assertMisses(1, 1)
+	miss := misses.Albums[0]
+	if miss.ID != albumID || miss.Name != media.album || miss.Attempts != 1 {
+		t.Errorf("wrong album miss: %+v", miss)
+	}
+	if delay := miss.NextRetry - miss.LastAttempt; delay != int64(notFoundRetryBase.Seconds()) {
+		t.Errorf("expected first retry after %s but it is after %ds",
+			notFoundRetryBase, delay)
+	}
+	if misses.Artists[0].ID != artistID || misses.Artists[0].Name != media.artist {
+		t.Errorf("wrong artist miss: %+v", misses.Artists[0])
+	}
+
+	if err := lib.RetryArtworkMisses(ctx, 10); err != nil {
+		t.Fatalf("retrying misses: %s", err)
+	}
+	if finder.GetFrontImageCallCount() != 1 || finder.GetArtistImageCallCount() != 1 {
+		t.Errorf("misses were retried before their time")
+	}
+
+	makeDue()
+	if err := lib.RetryArtworkMisses(ctx, 10); err != nil {
+		t.Fatalf("retrying misses: %s", err)
+	}
+	if finder.GetFrontImageCallCount() != 2 || finder.GetArtistImageCallCount() != 2 {
+		t.Errorf("due misses were not retried")
+	}
+
+	misses = assertMisses(1, 1)
+	miss = misses.Albums[0]
+	if miss.Attempts != 2 {
+		t.Errorf("expected two attempts but got %d", miss.Attempts)
+	}
+	if delay := miss.NextRetry - miss.LastAttempt; delay != int64(2*notFoundRetryBase.Seconds()) {
+		t.Errorf("expected the delay to double but it is %ds", delay)
+	}
+
+	finder.GetFrontImageReturns(cover, nil)
+	makeDue()
+	if err := lib.RetryArtworkMisses(ctx, 10); err != nil {
+		t.Fatalf("retrying misses: %s", err)
+	}
+
+	assertMisses(0, 1)
+	assertAlbumImage(t, lib, albumID, OriginalImage, cover)
+
+	if err := lib.RemoveArtistImage(ctx, artistID); err != nil {
+		t.Fatalf("removing artist image: %s", err)
+	}
+	assertMisses(0, 0)
+}
+
+// TestNotFoundRetryDelay checks that the delay between retries doubles up to
+// its maximum.
+func TestNotFoundRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int64
+		expected time.Duration
+	}{
+		{1, time.Hour},
+		{2, 2 * time.Hour},
+		{4, 8 * time.Hour},
+		{10, 512 * time.Hour},
+		{11, notFoundRetryMax},
+		{1000, notFoundRetryMax},
+	}
+
+	for _, test := range tests {
+		if delay := notFoundRetryDelay(test.attempts); delay != test.expected {
+			t.Errorf("attempt %d: expected %s but got %s",
+				test.attempts, test.expected, delay)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package library
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"io"
@@ -310,3 +311,213 @@ func assertAlbumImage(
 		t.Errorf("expected image `%s` but got `%s`", expectedImage, foundImgBytes)
 	}
 }
+
+// TestAlbumArtworkFromEmbeddedPictures checks that pictures embedded in the tags of
+// the album's tracks are used as its artwork and that front covers are preferred
+// over other pictures.
+func TestAlbumArtworkFromEmbeddedPictures(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		frontCover = []byte("embedded-front-cover")
+		backCover  = []byte("embedded-back-cover")
+		albumName  = "Embedded Pictures"
+	)
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	const (
+		firstFilePath  = "path/to/embedded/first.mp3"
+		secondFilePath = "path/to/embedded/second.mp3"
+	)
+	lib.fs = fstest.MapFS{
+		firstFilePath: &fstest.MapFile{
+			Data:    id3v2WithPictures(map[byte][]byte{0x04: backCover}),
+			ModTime: time.Now(),
+		},
+		secondFilePath: &fstest.MapFile{
+			Data: id3v2WithPictures(map[byte][]byte{
+				0x00: []byte("some-other-picture"),
+				0x03: frontCover,
+			}),
+			ModTime: time.Now(),
+		},
+	}
+
+	for i, filePath := range []string{firstFilePath, secondFilePath} {
+		media := MockMedia{
+			artist: "Tag Writer",
+			album:  albumName,
+			title:  fmt.Sprintf("Track %d", i+1),
+			track:  i + 1,
+			length: 123,
+		}
+		info := fileInfo{
+			Size:     1024,
+			FilePath: filePath,
+			Modified: time.Now(),
+		}
+		if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+			t.Fatalf("inserting media file %s failed: %s", filePath, err)
+		}
+	}
+
+	albumID, err := lib.GetAlbumID(albumName, path.Dir(firstFilePath))
+	if err != nil {
+		t.Fatalf("error getting albumID: %s", err)
+	}
+
+	assertAlbumImage(t, lib, albumID, OriginalImage, frontCover)
+}
+
+// TestAlbumArtworkSources checks that the artwork sources are tried in the
+// configured order and that the source of every stored image is recorded.
+func TestAlbumArtworkSources(t *testing.T) {
+	var (
+		ctx           = context.Background()
+		localCover    = []byte("local-cover")
+		internetCover = []byte("internet-cover")
+	)
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	provider := &artfakes.FakeProvider{}
+	provider.NameReturns("fake-provider")
+	provider.GetFrontImageReturns(internetCover, nil)
+	lib.SetArtFinder(art.NewChain(provider))
+	fakeScaler := &scalerfakes.FakeScaler{}
+	fakeScaler.ScaleReturns([]byte("small-cover"), nil)
+	lib.SetScaler(fakeScaler)
+
+	lib.fs = fstest.MapFS{
+		"path/to/first/cover.jpg": &fstest.MapFile{
+			Data:    localCover,
+			ModTime: time.Now(),
+		},
+		"path/to/second/cover.jpg": &fstest.MapFile{
+			Data:    localCover,
+			ModTime: time.Now(),
+		},
+	}
+
+	albumIDs := make(map[string]int64)
+	for _, albumName := range []string{"first", "second"} {
+		filePath := fmt.Sprintf("path/to/%s/track.mp3", albumName)
+		media := MockMedia{
+			artist: "Source Tester",
+			album:  albumName,
+			title:  "Track",
+			track:  1,
+			length: 123,
+		}
+		info := fileInfo{
+			Size:     1024,
+			FilePath: filePath,
+			Modified: time.Now(),
+		}
+		if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+			t.Fatalf("inserting media file %s failed: %s", filePath, err)
+		}
+
+		albumID, err := lib.GetAlbumID(albumName, path.Dir(filePath))
+		if err != nil {
+			t.Fatalf("error getting albumID: %s", err)
+		}
+		albumIDs[albumName] = albumID
+	}
+
+	albumSource := func(albumID int64) string {
+		t.Helper()
+
+		var source sql.NullString
+		work := func(db *sql.DB) error {
+			return db.QueryRow(`
+				SELECT source FROM albums_artworks WHERE album_id = ?
+			`, albumID).Scan(&source)
+		}
+		if err := lib.ExecuteDBJobAndWait(work); err != nil {
+			t.Fatalf("error getting artwork source: %s", err)
+		}
+		return source.String
+	}
+
+	assertAlbumImage(t, lib, albumIDs["first"], OriginalImage, localCover)
+	if source := albumSource(albumIDs["first"]); source != ArtworkSourceLocal {
+		t.Errorf("expected source %s but got %s", ArtworkSourceLocal, source)
+	}
+	if provider.GetFrontImageCallCount() != 0 {
+		t.Errorf("the internet was searched although there was a local image")
+	}
+
+	lib.SetArtworkSources([]string{ArtworkSourceInternet, ArtworkSourceLocal})
+
+	assertAlbumImage(t, lib, albumIDs["second"], OriginalImage, internetCover)
+	if source := albumSource(albumIDs["second"]); source != "fake-provider" {
+		t.Errorf("expected source fake-provider but got %s", source)
+	}
+
+	assertAlbumImage(t, lib, albumIDs["second"], SmallImage, []byte("small-cover"))
+	if source := albumSource(albumIDs["second"]); source != "fake-provider" {
+		t.Errorf("storing the small image changed the source to %s", source)
+	}
+
+	err = lib.SaveAlbumArtwork(ctx, albumIDs["second"], bytes.NewReader([]byte("uploaded")))
+	if err != nil {
+		t.Fatalf("error saving artwork: %s", err)
+	}
+	if source := albumSource(albumIDs["second"]); source != ArtworkSourceUpload {
+		t.Errorf("expected source %s but got %s", ArtworkSourceUpload, source)
+	}
+}
+
+// id3v2WithPictures returns an ID3v2.3 tag with an attached picture frame for
+// every element of `pictures`. Keys are the picture types and values are the
+// picture data.
+func id3v2WithPictures(pictures map[byte][]byte) []byte {
+	var frames bytes.Buffer
+	for picType, data := range pictures {
+		var body bytes.Buffer
+		body.WriteByte(0x00) // ISO-8859-1 encoding
+		body.WriteString("image/png\x00")
+		body.WriteByte(picType)
+		body.WriteByte(0x00) // empty description
+		body.Write(data)
+
+		size := body.Len()
+		frames.WriteString("APIC")
+		frames.Write([]byte{
+			byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size),
+			0x00, 0x00, // flags
+		})
+		frames.Write(body.Bytes())
+	}
+
+	size := frames.Len()
+	var tagBuff bytes.Buffer
+	tagBuff.WriteString("ID3")
+	tagBuff.Write([]byte{
+		0x03, 0x00, // version 2.3.0
+		0x00, // flags
+		byte(size>>21) & 0x7f, byte(size>>14) & 0x7f, byte(size>>7) & 0x7f, byte(size) & 0x7f,
+	})
+	tagBuff.Write(frames.Bytes())
+
+	return tagBuff.Bytes()
+}
@@ -12,6 +12,13 @@ import (
 // it to the databaseWorker for execution.
 type DatabaseExecutable func(db *sql.DB) error
 
+// sqlExecer is implemented by both *sql.DB and *sql.Tx. It is used by functions which
+// may be called from within a transaction as well as outside of one.
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // Reads from the media channel and saves into the database every file
 // received.
 //
@@ -0,0 +1,317 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Play is a single time a track was played.
+type Play struct {
+	// TrackID is the ID of the played track.
+	TrackID int64
+
+	// At is the time at which the track started playing. The current time is
+	// used when it is zero.
+	At time.Time
+
+	// Client is the name of the application which played the track.
+	Client string
+
+	// User is the name of the user who played the track. It is empty when not
+	// known.
+	User string
+
+	// Duration is for how long the track was played. It is zero when not known.
+	Duration time.Duration
+}
+
+// HistoryArgs selects which part of the listening history is returned.
+type HistoryArgs struct {
+	// User limits the history to the plays of this user when not empty.
+	User string
+
+	// From is the inclusive lower limit for the time of the plays. It is not
+	// used when zero.
+	From time.Time
+
+	// To is the exclusive upper limit for the time of the plays. It is not used
+	// when zero.
+	To time.Time
+
+	// Offset is the number of plays to skip.
+	Offset uint64
+
+	// PerPage is the maximum number of returned plays.
+	PerPage uint
+}
+
+// HistoryEntry is a single play from the listening history together with the
+// played track.
+type HistoryEntry struct {
+	// ID is the unique ID of the play.
+	ID int64 `json:"id"`
+
+	// PlayedAt is the Unix timestamp at which the track started playing.
+	PlayedAt int64 `json:"played_at"`
+
+	// Client is the application which played the track.
+	Client string `json:"client,omitempty"`
+
+	// User is the user who played the track.
+	User string `json:"user,omitempty"`
+
+	// DurationPlayed is for how long the track was played in milliseconds. It
+	// is zero when not known.
+	DurationPlayed int64 `json:"duration_played,omitempty"`
+
+	Track TrackInfo `json:"track"`
+}
+
+// RecordTrackPlay appends a play to the listening history and updates the play
+// count and last played time of its track in the `user_stats` table.
+//
+// A play of the same track by the same user and client which comes while the
+// previous one could still be playing is considered a part of it. Then only the
+// duration of the previous play is extended. Clients which stream a track with
+// multiple requests do not create more than one play this way.
+func (lib *LocalLibrary) RecordTrackPlay(ctx context.Context, play Play) error {
+	if play.At.IsZero() {
+		play.At = time.Now()
+	}
+
+	work := func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("starting transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if err := recordPlay(ctx, tx, play); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return fmt.Errorf("recording play: %w", err)
+	}
+
+	return nil
+}
+
+// recordPlay stores `play` in the database using `tx`.
+func recordPlay(ctx context.Context, tx *sql.Tx, play Play) error {
+	var trackDuration sql.NullInt64
+	err := tx.QueryRowContext(ctx, `
+		SELECT duration FROM tracks WHERE id = ?
+	`, play.TrackID).Scan(&trackDuration)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return fmt.Errorf("getting track duration: %w", err)
+	}
+
+	playedMs := play.Duration.Milliseconds()
+	if trackDuration.Valid {
+		playedMs = min(playedMs, trackDuration.Int64)
+	}
+
+	var (
+		prevID       int64
+		prevPlayedAt int64
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, played_at FROM plays
+		WHERE
+			track_id = @trackID AND
+			COALESCE(user, '') = @user AND
+			COALESCE(client, '') = @client
+		ORDER BY played_at DESC
+		LIMIT 1
+	`,
+		sql.Named("trackID", play.TrackID),
+		sql.Named("user", play.User),
+		sql.Named("client", play.Client),
+	).Scan(&prevID, &prevPlayedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("getting previous play: %w", err)
+	}
+
+	at := play.At.Unix()
+	stillPlaying := err == nil && trackDuration.Valid &&
+		at >= prevPlayedAt && at < prevPlayedAt+trackDuration.Int64/1000
+	if stillPlaying {
+		if playedMs <= 0 {
+			return nil
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE plays SET
+				duration_played = MIN(COALESCE(duration_played, 0) + @played, @trackDuration)
+			WHERE id = @id
+		`,
+			sql.Named("played", playedMs),
+			sql.Named("trackDuration", trackDuration.Int64),
+			sql.Named("id", prevID),
+		)
+		if err != nil {
+			return fmt.Errorf("updating play duration: %w", err)
+		}
+		return nil
+	}
+
+	playedArg := sql.Named("played", playedMs)
+	if playedMs <= 0 {
+		playedArg = sql.Named("played", nil)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO plays (track_id, played_at, client, user, duration_played)
+		VALUES (@trackID, @at, @client, @user, @played)
+	`,
+		sql.Named("trackID", play.TrackID),
+		sql.Named("at", at),
+		sql.Named("client", nullIfEmpty(play.Client)),
+		sql.Named("user", nullIfEmpty(play.User)),
+		playedArg,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting play: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_stats (track_id, last_played, play_count)
+		VALUES (@trackID, @at, 1)
+		ON CONFLICT(track_id) DO UPDATE SET
+			last_played = MAX(COALESCE(last_played, 0), @at),
+			play_count = play_count + 1
+	`,
+		sql.Named("trackID", play.TrackID),
+		sql.Named("at", at),
+	)
+	if err != nil {
+		return fmt.Errorf("updating track stats: %w", err)
+	}
+
+	return nil
+}
+
+// GetHistory returns the plays selected by `args` from the listening history. The
+// most recent are first. The total number of plays selected by `args` regardless
+// of the offset and per page limit is returned as well.
+func (lib *LocalLibrary) GetHistory(
+	ctx context.Context,
+	args HistoryArgs,
+) ([]HistoryEntry, int, error) {
+	var (
+		where     []string
+		queryArgs []any
+	)
+	if args.User != "" {
+		where = append(where, "p.user = @user")
+		queryArgs = append(queryArgs, sql.Named("user", args.User))
+	}
+	if !args.From.IsZero() {
+		where = append(where, "p.played_at >= @from")
+		queryArgs = append(queryArgs, sql.Named("from", args.From.Unix()))
+	}
+	if !args.To.IsZero() {
+		where = append(where, "p.played_at < @to")
+		queryArgs = append(queryArgs, sql.Named("to", args.To.Unix()))
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var (
+		entries []HistoryEntry
+		count   int
+	)
+	work := func(db *sql.DB) error {
+		err := db.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT COUNT(*) FROM plays p %s
+		`, whereClause), queryArgs...).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("counting plays: %w", err)
+		}
+
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT
+				p.id, p.track_id, p.played_at, p.client, p.user, p.duration_played
+			FROM plays p
+			%s
+			ORDER BY p.played_at DESC, p.id DESC
+			LIMIT @limit OFFSET @offset
+		`, whereClause), append(
+			queryArgs,
+			sql.Named("limit", args.PerPage),
+			sql.Named("offset", args.Offset),
+		)...)
+		if err != nil {
+			return fmt.Errorf("getting plays: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				entry    HistoryEntry
+				client   sql.NullString
+				user     sql.NullString
+				duration sql.NullInt64
+			)
+			err := rows.Scan(
+				&entry.ID,
+				&entry.Track.ID,
+				&entry.PlayedAt,
+				&client,
+				&user,
+				&duration,
+			)
+			if err != nil {
+				return fmt.Errorf("scanning play: %w", err)
+			}
+
+			entry.Client = client.String
+			entry.User = user.String
+			entry.DurationPlayed = duration.Int64
+			entries = append(entries, entry)
+		}
+
+		return rows.Err()
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return nil, 0, err
+	}
+
+	trackIDs := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		if !slices.Contains(trackIDs, entry.Track.ID) {
+			trackIDs = append(trackIDs, entry.Track.ID)
+		}
+	}
+
+	tracks, err := lib.tracksByIDs(ctx, trackIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	found := make(map[int64]TrackInfo, len(tracks))
+	for _, track := range tracks {
+		found[track.ID] = track
+	}
+	for i := range entries {
+		if track, ok := found[entries[i].Track.ID]; ok {
+			entries[i].Track = track
+		}
+	}
+
+	return entries, count, nil
+}
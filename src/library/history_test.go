@@ -0,0 +1,153 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHistory checks that plays are appended to the listening history, that plays
+// which continue previous ones only extend their duration and that the history
+// could be filtered and paginated.
+func TestHistory(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	tmpDir := t.TempDir()
+	var trackIDs []int64
+	for i, title := range []string{"Invaders", "Gangland"} {
+		media := MockMedia{
+			artist: "Iron Maiden",
+			album:  "The Number of the Beast",
+			title:  title,
+			track:  i + 1,
+			length: 120 * time.Second,
+		}
+		info := fileInfo{
+			Size:     1024,
+			FilePath: filepath.Join(tmpDir, title+".mp3"),
+			Modified: time.Now(),
+		}
+		if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", i, err)
+		}
+
+		var trackID int64
+		err := lib.db.QueryRow(
+			`SELECT id FROM tracks WHERE fs_path = ?`, info.FilePath,
+		).Scan(&trackID)
+		if err != nil {
+			t.Fatalf("getting track ID for %s: %s", title, err)
+		}
+		trackIDs = append(trackIDs, trackID)
+	}
+
+	start := time.Unix(1700000000, 0)
+	alicePlay := func(trackID int64, after, played time.Duration) Play {
+		return Play{
+			TrackID:  trackID,
+			At:       start.Add(after),
+			Client:   "web",
+			User:     "alice",
+			Duration: played,
+		}
+	}
+	plays := []Play{
+		alicePlay(trackIDs[0], 0, 30*time.Second),
+
+		// These continue the first play since the track could still be playing.
+		alicePlay(trackIDs[0], 10*time.Second, 40*time.Second),
+		alicePlay(trackIDs[0], 20*time.Second, time.Hour),
+
+		{TrackID: trackIDs[1], At: start.Add(5 * time.Second), Client: "DSub", User: "bob"},
+		alicePlay(trackIDs[0], 200*time.Second, 0),
+	}
+	for i, play := range plays {
+		if err := lib.RecordTrackPlay(ctx, play); err != nil {
+			t.Fatalf("recording play %d: %s", i, err)
+		}
+	}
+
+	track, err := lib.GetTrack(ctx, trackIDs[0])
+	if err != nil {
+		t.Fatalf("getting track: %s", err)
+	}
+	if track.Plays != 2 || track.LastPlayed != start.Add(200*time.Second).Unix() {
+		t.Errorf("unexpected track stats: plays %d, last played %d",
+			track.Plays, track.LastPlayed)
+	}
+
+	history, count, err := lib.GetHistory(ctx, HistoryArgs{PerPage: 10})
+	if err != nil {
+		t.Fatalf("getting history: %s", err)
+	}
+	if count != 3 || len(history) != 3 {
+		t.Fatalf("expected 3 plays but got %d (count %d)", len(history), count)
+	}
+
+	expected := []struct {
+		trackID  int64
+		playedAt int64
+		user     string
+		duration int64
+	}{
+		{trackIDs[0], start.Unix() + 200, "alice", 0},
+		{trackIDs[1], start.Unix() + 5, "bob", 0},
+		{trackIDs[0], start.Unix(), "alice", 120000},
+	}
+	for i, entry := range history {
+		exp := expected[i]
+		if entry.Track.ID != exp.trackID || entry.PlayedAt != exp.playedAt ||
+			entry.User != exp.user || entry.DurationPlayed != exp.duration {
+			t.Errorf("play %d: expected %+v but got %+v", i, exp, entry)
+		}
+		if entry.Track.Title == "" {
+			t.Errorf("play %d: track information is missing", i)
+		}
+	}
+
+	history, count, err = lib.GetHistory(ctx, HistoryArgs{User: "bob", PerPage: 10})
+	if err != nil {
+		t.Fatalf("getting history of user: %s", err)
+	}
+	if count != 1 || len(history) != 1 || history[0].Client != "DSub" {
+		t.Errorf("unexpected history of user: %+v", history)
+	}
+
+	history, count, err = lib.GetHistory(ctx, HistoryArgs{
+		From:    start.Add(time.Second),
+		To:      start.Add(100 * time.Second),
+		PerPage: 10,
+	})
+	if err != nil {
+		t.Fatalf("getting history in time range: %s", err)
+	}
+	if count != 1 || len(history) != 1 || history[0].Track.ID != trackIDs[1] {
+		t.Errorf("unexpected history in time range: %+v", history)
+	}
+
+	history, count, err = lib.GetHistory(ctx, HistoryArgs{Offset: 1, PerPage: 1})
+	if err != nil {
+		t.Fatalf("getting history page: %s", err)
+	}
+	if count != 3 || len(history) != 1 || history[0].Track.ID != trackIDs[1] {
+		t.Errorf("unexpected history page: %+v (count %d)", history, count)
+	}
+
+	err = lib.RecordTrackPlay(ctx, Play{TrackID: 8888})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error for missing track but got %v", err)
+	}
+}
@@ -7,7 +7,6 @@ package library
 
 import (
 	"context"
-	"time"
 )
 
 // SearchResult contains a result for a search term. Contains all the necessary
@@ -142,6 +141,41 @@ type Favourites struct {
 	TrackIDs  []int64
 }
 
+// ScanStatus describes the state of the current or the last finished library scan.
+type ScanStatus struct {
+	// Running is true while the scan is in progress.
+	Running bool `json:"running"`
+
+	// FullRescan is true for scans which read again the tags of every file
+	// in the library regardless of whether it was changed.
+	FullRescan bool `json:"full_rescan"`
+
+	// FilesSeen is the number of media files found by the scan so far.
+	FilesSeen int64 `json:"files_seen"`
+
+	// FilesAdded is the number of media files which were not in the library
+	// and were added by the scan.
+	FilesAdded int64 `json:"files_added"`
+
+	// FilesUpdated is the number of media files which were read again by the scan.
+	FilesUpdated int64 `json:"files_updated"`
+
+	// FilesRemoved is the number of tracks removed from the library because their
+	// files no longer exist.
+	FilesRemoved int64 `json:"files_removed"`
+
+	// Errors is the number of media files which could not be read or stored.
+	Errors int64 `json:"errors"`
+
+	// StartedAt is the Unix timestamp (in seconds) at which the scan started. It
+	// is zero when no scan has been started since Euterpe was started.
+	StartedAt int64 `json:"started_at,omitempty"`
+
+	// FinishedAt is the Unix timestamp (in seconds) at which the scan finished. It
+	// is zero while the scan is running.
+	FinishedAt int64 `json:"finished_at,omitempty"`
+}
+
 //counterfeiter:generate . Library
 
 // Library represents the media library which is played using the HTTPMS.
@@ -184,13 +218,48 @@ type Library interface {
 	// GetArtist returns information for particular artist in the database.
 	GetArtist(ctx context.Context, artistID int64) (Artist, error)
 
+	// GetArtistInfo returns what is known about particular artist from sources
+	// on the internet such as their biography and similar artists.
+	GetArtistInfo(ctx context.Context, artistID int64) (ArtistInfo, error)
+
 	// GetAlbum returns information for particular album in the database.
 	GetAlbum(ctx context.Context, albumID int64) (Album, error)
 
-	// RecordTrackPlay stores the fact that this track has been played
-	// at this particular time. This means updating its "last played" property
+	// GetAlbumInfo returns what is known about the release of particular album
+	// such as its label and original release date. It does not search for
+	// anything on the internet.
+	GetAlbumInfo(ctx context.Context, albumID int64) (AlbumInfo, error)
+
+	// FindAlbumInfo is like GetAlbumInfo but also searches for the information
+	// on the internet when what is stored is missing or old.
+	FindAlbumInfo(ctx context.Context, albumID int64) (AlbumInfo, error)
+
+	// SimilarTracks returns tracks which are similar to a track, an album or an
+	// artist. They are picked at random with the more similar being more likely
+	// so that every call returns a different list.
+	SimilarTracks(ctx context.Context, args SimilarArgs) ([]TrackInfo, error)
+
+	// GetTrackLyrics returns the lyrics for particular track identified by its
+	// media ID. ErrLyricsNotFound is returned when the track has no lyrics.
+	GetTrackLyrics(ctx context.Context, mediaID int64) (Lyrics, error)
+
+	// RecordTrackPlay stores the fact that a track has been played in the
+	// listening history. This also means updating its "last played" property
 	// and increasing its play count in the stats database.
-	RecordTrackPlay(ctx context.Context, mediaID int64, atTime time.Time) error
+	RecordTrackPlay(ctx context.Context, play Play) error
+
+	// GetHistory returns plays from the listening history with the most recent
+	// first together with the total number of plays selected by `args`.
+	GetHistory(ctx context.Context, args HistoryArgs) ([]HistoryEntry, int, error)
+
+	// GetStats computes listening statistics such as top artists, albums, tracks
+	// and genres from the plays in the listening history selected by `args`.
+	GetStats(ctx context.Context, args StatsArgs) (Stats, error)
+
+	// TopAlbums returns the most played or the most recently played albums
+	// depending on `orderBy` which must be either OrderByFrequentlyPlayed or
+	// OrderByRecentlyPlayed.
+	TopAlbums(ctx context.Context, args StatsArgs, orderBy BrowseOrderBy) ([]Album, error)
 
 	// SetTrackRating sets the rating for particular track. Only values in the
 	// [0-5] range are accepted. 0 unsets the rating.
@@ -217,6 +286,14 @@ type Library interface {
 	// they are not scanned already.
 	Scan()
 
+	// StartScan starts a library scan in the background. When `full` is true the
+	// tags of every file in the library are read again. Returns ErrScanRunning
+	// when there is a scan in progress already.
+	StartScan(full bool) error
+
+	// ScanStatus returns the state of the current or the last finished scan.
+	ScanStatus() ScanStatus
+
 	// Adds this media (file) to the library.
 	AddMedia(fileName string) error
 
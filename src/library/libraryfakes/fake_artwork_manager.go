@@ -25,6 +25,46 @@ type FakeArtworkManager struct {
 		result1 io.ReadCloser
 		result2 error
 	}
+	GetAlbumArtworkCandidatesStub        func(context.Context, int64) ([]library.ArtworkCandidate, error)
+	getAlbumArtworkCandidatesMutex       sync.RWMutex
+	getAlbumArtworkCandidatesArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getAlbumArtworkCandidatesReturns struct {
+		result1 []library.ArtworkCandidate
+		result2 error
+	}
+	getAlbumArtworkCandidatesReturnsOnCall map[int]struct {
+		result1 []library.ArtworkCandidate
+		result2 error
+	}
+	GetArtworkMissesStub        func(context.Context) (library.ArtworkMisses, error)
+	getArtworkMissesMutex       sync.RWMutex
+	getArtworkMissesArgsForCall []struct {
+		arg1 context.Context
+	}
+	getArtworkMissesReturns struct {
+		result1 library.ArtworkMisses
+		result2 error
+	}
+	getArtworkMissesReturnsOnCall map[int]struct {
+		result1 library.ArtworkMisses
+		result2 error
+	}
+	PinAlbumArtworkStub        func(context.Context, int64, string) error
+	pinAlbumArtworkMutex       sync.RWMutex
+	pinAlbumArtworkArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+	}
+	pinAlbumArtworkReturns struct {
+		result1 error
+	}
+	pinAlbumArtworkReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RemoveAlbumArtworkStub        func(context.Context, int64) error
 	removeAlbumArtworkMutex       sync.RWMutex
 	removeAlbumArtworkArgsForCall []struct {
@@ -120,6 +160,198 @@ func (fake *FakeArtworkManager) FindAndSaveAlbumArtworkReturnsOnCall(i int, resu
 	}{result1, result2}
 }
 
+func (fake *FakeArtworkManager) GetAlbumArtworkCandidates(arg1 context.Context, arg2 int64) ([]library.ArtworkCandidate, error) {
+	fake.getAlbumArtworkCandidatesMutex.Lock()
+	ret, specificReturn := fake.getAlbumArtworkCandidatesReturnsOnCall[len(fake.getAlbumArtworkCandidatesArgsForCall)]
+	fake.getAlbumArtworkCandidatesArgsForCall = append(fake.getAlbumArtworkCandidatesArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetAlbumArtworkCandidatesStub
+	fakeReturns := fake.getAlbumArtworkCandidatesReturns
+	fake.recordInvocation("GetAlbumArtworkCandidates", []interface{}{arg1, arg2})
+	fake.getAlbumArtworkCandidatesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeArtworkManager) GetAlbumArtworkCandidatesCallCount() int {
+	fake.getAlbumArtworkCandidatesMutex.RLock()
+	defer fake.getAlbumArtworkCandidatesMutex.RUnlock()
+	return len(fake.getAlbumArtworkCandidatesArgsForCall)
+}
+
+func (fake *FakeArtworkManager) GetAlbumArtworkCandidatesCalls(stub func(context.Context, int64) ([]library.ArtworkCandidate, error)) {
+	fake.getAlbumArtworkCandidatesMutex.Lock()
+	defer fake.getAlbumArtworkCandidatesMutex.Unlock()
+	fake.GetAlbumArtworkCandidatesStub = stub
+}
+
+func (fake *FakeArtworkManager) GetAlbumArtworkCandidatesArgsForCall(i int) (context.Context, int64) {
+	fake.getAlbumArtworkCandidatesMutex.RLock()
+	defer fake.getAlbumArtworkCandidatesMutex.RUnlock()
+	argsForCall := fake.getAlbumArtworkCandidatesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeArtworkManager) GetAlbumArtworkCandidatesReturns(result1 []library.ArtworkCandidate, result2 error) {
+	fake.getAlbumArtworkCandidatesMutex.Lock()
+	defer fake.getAlbumArtworkCandidatesMutex.Unlock()
+	fake.GetAlbumArtworkCandidatesStub = nil
+	fake.getAlbumArtworkCandidatesReturns = struct {
+		result1 []library.ArtworkCandidate
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeArtworkManager) GetAlbumArtworkCandidatesReturnsOnCall(i int, result1 []library.ArtworkCandidate, result2 error) {
+	fake.getAlbumArtworkCandidatesMutex.Lock()
+	defer fake.getAlbumArtworkCandidatesMutex.Unlock()
+	fake.GetAlbumArtworkCandidatesStub = nil
+	if fake.getAlbumArtworkCandidatesReturnsOnCall == nil {
+		fake.getAlbumArtworkCandidatesReturnsOnCall = make(map[int]struct {
+			result1 []library.ArtworkCandidate
+			result2 error
+		})
+	}
+	fake.getAlbumArtworkCandidatesReturnsOnCall[i] = struct {
+		result1 []library.ArtworkCandidate
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeArtworkManager) GetArtworkMisses(arg1 context.Context) (library.ArtworkMisses, error) {
+	fake.getArtworkMissesMutex.Lock()
+	ret, specificReturn := fake.getArtworkMissesReturnsOnCall[len(fake.getArtworkMissesArgsForCall)]
+	fake.getArtworkMissesArgsForCall = append(fake.getArtworkMissesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.GetArtworkMissesStub
+	fakeReturns := fake.getArtworkMissesReturns
+	fake.recordInvocation("GetArtworkMisses", []interface{}{arg1})
+	fake.getArtworkMissesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeArtworkManager) GetArtworkMissesCallCount() int {
+	fake.getArtworkMissesMutex.RLock()
+	defer fake.getArtworkMissesMutex.RUnlock()
+	return len(fake.getArtworkMissesArgsForCall)
+}
+
+func (fake *FakeArtworkManager) GetArtworkMissesCalls(stub func(context.Context) (library.ArtworkMisses, error)) {
+	fake.getArtworkMissesMutex.Lock()
+	defer fake.getArtworkMissesMutex.Unlock()
+	fake.GetArtworkMissesStub = stub
+}
+
+func (fake *FakeArtworkManager) GetArtworkMissesArgsForCall(i int) context.Context {
+	fake.getArtworkMissesMutex.RLock()
+	defer fake.getArtworkMissesMutex.RUnlock()
+	argsForCall := fake.getArtworkMissesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeArtworkManager) GetArtworkMissesReturns(result1 library.ArtworkMisses, result2 error) {
+	fake.getArtworkMissesMutex.Lock()
+	defer fake.getArtworkMissesMutex.Unlock()
+	fake.GetArtworkMissesStub = nil
+	fake.getArtworkMissesReturns = struct {
+		result1 library.ArtworkMisses
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeArtworkManager) GetArtworkMissesReturnsOnCall(i int, result1 library.ArtworkMisses, result2 error) {
+	fake.getArtworkMissesMutex.Lock()
+	defer fake.getArtworkMissesMutex.Unlock()
+	fake.GetArtworkMissesStub = nil
+	if fake.getArtworkMissesReturnsOnCall == nil {
+		fake.getArtworkMissesReturnsOnCall = make(map[int]struct {
+			result1 library.ArtworkMisses
+			result2 error
+		})
+	}
+	fake.getArtworkMissesReturnsOnCall[i] = struct {
+		result1 library.ArtworkMisses
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeArtworkManager) PinAlbumArtwork(arg1 context.Context, arg2 int64, arg3 string) error {
+	fake.pinAlbumArtworkMutex.Lock()
+	ret, specificReturn := fake.pinAlbumArtworkReturnsOnCall[len(fake.pinAlbumArtworkArgsForCall)]
+	fake.pinAlbumArtworkArgsForCall = append(fake.pinAlbumArtworkArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.PinAlbumArtworkStub
+	fakeReturns := fake.pinAlbumArtworkReturns
+	fake.recordInvocation("PinAlbumArtwork", []interface{}{arg1, arg2, arg3})
+	fake.pinAlbumArtworkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeArtworkManager) PinAlbumArtworkCallCount() int {
+	fake.pinAlbumArtworkMutex.RLock()
+	defer fake.pinAlbumArtworkMutex.RUnlock()
+	return len(fake.pinAlbumArtworkArgsForCall)
+}
+
+func (fake *FakeArtworkManager) PinAlbumArtworkCalls(stub func(context.Context, int64, string) error) {
+	fake.pinAlbumArtworkMutex.Lock()
+	defer fake.pinAlbumArtworkMutex.Unlock()
+	fake.PinAlbumArtworkStub = stub
+}
+
+func (fake *FakeArtworkManager) PinAlbumArtworkArgsForCall(i int) (context.Context, int64, string) {
+	fake.pinAlbumArtworkMutex.RLock()
+	defer fake.pinAlbumArtworkMutex.RUnlock()
+	argsForCall := fake.pinAlbumArtworkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeArtworkManager) PinAlbumArtworkReturns(result1 error) {
+	fake.pinAlbumArtworkMutex.Lock()
+	defer fake.pinAlbumArtworkMutex.Unlock()
+	fake.PinAlbumArtworkStub = nil
+	fake.pinAlbumArtworkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeArtworkManager) PinAlbumArtworkReturnsOnCall(i int, result1 error) {
+	fake.pinAlbumArtworkMutex.Lock()
+	defer fake.pinAlbumArtworkMutex.Unlock()
+	fake.PinAlbumArtworkStub = nil
+	if fake.pinAlbumArtworkReturnsOnCall == nil {
+		fake.pinAlbumArtworkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pinAlbumArtworkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeArtworkManager) RemoveAlbumArtwork(arg1 context.Context, arg2 int64) error {
 	fake.removeAlbumArtworkMutex.Lock()
 	ret, specificReturn := fake.removeAlbumArtworkReturnsOnCall[len(fake.removeAlbumArtworkArgsForCall)]
@@ -250,6 +482,12 @@ func (fake *FakeArtworkManager) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.findAndSaveAlbumArtworkMutex.RLock()
 	defer fake.findAndSaveAlbumArtworkMutex.RUnlock()
+	fake.getAlbumArtworkCandidatesMutex.RLock()
+	defer fake.getAlbumArtworkCandidatesMutex.RUnlock()
+	fake.getArtworkMissesMutex.RLock()
+	defer fake.getArtworkMissesMutex.RUnlock()
+	fake.pinAlbumArtworkMutex.RLock()
+	defer fake.pinAlbumArtworkMutex.RUnlock()
 	fake.removeAlbumArtworkMutex.RLock()
 	defer fake.removeAlbumArtworkMutex.RUnlock()
 	fake.saveAlbumArtworkMutex.RLock()
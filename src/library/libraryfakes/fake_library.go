@@ -4,7 +4,6 @@ package libraryfakes
 import (
 	"context"
 	"sync"
-	"time"
 
 	"github.com/ironsmile/euterpe/src/library"
 )
@@ -30,6 +29,20 @@ type FakeLibrary struct {
 	closeMutex       sync.RWMutex
 	closeArgsForCall []struct {
 	}
+	FindAlbumInfoStub        func(context.Context, int64) (library.AlbumInfo, error)
+	findAlbumInfoMutex       sync.RWMutex
+	findAlbumInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	findAlbumInfoReturns struct {
+		result1 library.AlbumInfo
+		result2 error
+	}
+	findAlbumInfoReturnsOnCall map[int]struct {
+		result1 library.AlbumInfo
+		result2 error
+	}
 	GetAlbumStub        func(context.Context, int64) (library.Album, error)
 	getAlbumMutex       sync.RWMutex
 	getAlbumArgsForCall []struct {
@@ -56,6 +69,20 @@ type FakeLibrary struct {
 	getAlbumFilesReturnsOnCall map[int]struct {
 		result1 []library.SearchResult
 	}
+	GetAlbumInfoStub        func(context.Context, int64) (library.AlbumInfo, error)
+	getAlbumInfoMutex       sync.RWMutex
+	getAlbumInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getAlbumInfoReturns struct {
+		result1 library.AlbumInfo
+		result2 error
+	}
+	getAlbumInfoReturnsOnCall map[int]struct {
+		result1 library.AlbumInfo
+		result2 error
+	}
 	GetArtistStub        func(context.Context, int64) (library.Artist, error)
 	getArtistMutex       sync.RWMutex
 	getArtistArgsForCall []struct {
@@ -82,6 +109,20 @@ type FakeLibrary struct {
 	getArtistAlbumsReturnsOnCall map[int]struct {
 		result1 []library.Album
 	}
+	GetArtistInfoStub        func(context.Context, int64) (library.ArtistInfo, error)
+	getArtistInfoMutex       sync.RWMutex
+	getArtistInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getArtistInfoReturns struct {
+		result1 library.ArtistInfo
+		result2 error
+	}
+	getArtistInfoReturnsOnCall map[int]struct {
+		result1 library.ArtistInfo
+		result2 error
+	}
 	GetFilePathStub        func(context.Context, int64) string
 	getFilePathMutex       sync.RWMutex
 	getFilePathArgsForCall []struct {
@@ -94,6 +135,36 @@ type FakeLibrary struct {
 	getFilePathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	GetHistoryStub        func(context.Context, library.HistoryArgs) ([]library.HistoryEntry, int, error)
+	getHistoryMutex       sync.RWMutex
+	getHistoryArgsForCall []struct {
+		arg1 context.Context
+		arg2 library.HistoryArgs
+	}
+	getHistoryReturns struct {
+		result1 []library.HistoryEntry
+		result2 int
+		result3 error
+	}
+	getHistoryReturnsOnCall map[int]struct {
+		result1 []library.HistoryEntry
+		result2 int
+		result3 error
+	}
+	GetStatsStub        func(context.Context, library.StatsArgs) (library.Stats, error)
+	getStatsMutex       sync.RWMutex
+	getStatsArgsForCall []struct {
+		arg1 context.Context
+		arg2 library.StatsArgs
+	}
+	getStatsReturns struct {
+		result1 library.Stats
+		result2 error
+	}
+	getStatsReturnsOnCall map[int]struct {
+		result1 library.Stats
+		result2 error
+	}
 	GetTrackStub        func(context.Context, int64) (library.SearchResult, error)
 	getTrackMutex       sync.RWMutex
 	getTrackArgsForCall []struct {
@@ -108,6 +179,20 @@ type FakeLibrary struct {
 		result1 library.SearchResult
 		result2 error
 	}
+	GetTrackLyricsStub        func(context.Context, int64) (library.Lyrics, error)
+	getTrackLyricsMutex       sync.RWMutex
+	getTrackLyricsArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getTrackLyricsReturns struct {
+		result1 library.Lyrics
+		result2 error
+	}
+	getTrackLyricsReturnsOnCall map[int]struct {
+		result1 library.Lyrics
+		result2 error
+	}
 	InitializeStub        func() error
 	initializeMutex       sync.RWMutex
 	initializeArgsForCall []struct {
@@ -130,12 +215,11 @@ type FakeLibrary struct {
 	recordFavouriteReturnsOnCall map[int]struct {
 		result1 error
 	}
-	RecordTrackPlayStub        func(context.Context, int64, time.Time) error
+	RecordTrackPlayStub        func(context.Context, library.Play) error
 	recordTrackPlayMutex       sync.RWMutex
 	recordTrackPlayArgsForCall []struct {
 		arg1 context.Context
-		arg2 int64
-		arg3 time.Time
+		arg2 library.Play
 	}
 	recordTrackPlayReturns struct {
 		result1 error
@@ -159,6 +243,16 @@ type FakeLibrary struct {
 	scanMutex       sync.RWMutex
 	scanArgsForCall []struct {
 	}
+	ScanStatusStub        func() library.ScanStatus
+	scanStatusMutex       sync.RWMutex
+	scanStatusArgsForCall []struct {
+	}
+	scanStatusReturns struct {
+		result1 library.ScanStatus
+	}
+	scanStatusReturnsOnCall map[int]struct {
+		result1 library.ScanStatus
+	}
 	SearchStub        func(context.Context, library.SearchArgs) []library.SearchResult
 	searchMutex       sync.RWMutex
 	searchArgsForCall []struct {
@@ -234,6 +328,46 @@ type FakeLibrary struct {
 	setTrackRatingReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SimilarTracksStub        func(context.Context, library.SimilarArgs) ([]library.SearchResult, error)
+	similarTracksMutex       sync.RWMutex
+	similarTracksArgsForCall []struct {
+		arg1 context.Context
+		arg2 library.SimilarArgs
+	}
+	similarTracksReturns struct {
+		result1 []library.SearchResult
+		result2 error
+	}
+	similarTracksReturnsOnCall map[int]struct {
+		result1 []library.SearchResult
+		result2 error
+	}
+	StartScanStub        func(bool) error
+	startScanMutex       sync.RWMutex
+	startScanArgsForCall []struct {
+		arg1 bool
+	}
+	startScanReturns struct {
+		result1 error
+	}
+	startScanReturnsOnCall map[int]struct {
+		result1 error
+	}
+	TopAlbumsStub        func(context.Context, library.StatsArgs, library.BrowseOrderBy) ([]library.Album, error)
+	topAlbumsMutex       sync.RWMutex
+	topAlbumsArgsForCall []struct {
+		arg1 context.Context
+		arg2 library.StatsArgs
+		arg3 library.BrowseOrderBy
+	}
+	topAlbumsReturns struct {
+		result1 []library.Album
+		result2 error
+	}
+	topAlbumsReturnsOnCall map[int]struct {
+		result1 []library.Album
+		result2 error
+	}
 	TruncateStub        func() error
 	truncateMutex       sync.RWMutex
 	truncateArgsForCall []struct {
@@ -365,6 +499,71 @@ func (fake *FakeLibrary) CloseCalls(stub func()) {
 	fake.CloseStub = stub
 }
 
+func (fake *FakeLibrary) FindAlbumInfo(arg1 context.Context, arg2 int64) (library.AlbumInfo, error) {
+	fake.findAlbumInfoMutex.Lock()
+	ret, specificReturn := fake.findAlbumInfoReturnsOnCall[len(fake.findAlbumInfoArgsForCall)]
+	fake.findAlbumInfoArgsForCall = append(fake.findAlbumInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.FindAlbumInfoStub
+	fakeReturns := fake.findAlbumInfoReturns
+	fake.recordInvocation("FindAlbumInfo", []interface{}{arg1, arg2})
+	fake.findAlbumInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrary) FindAlbumInfoCallCount() int {
+	fake.findAlbumInfoMutex.RLock()
+	defer fake.findAlbumInfoMutex.RUnlock()
+	return len(fake.findAlbumInfoArgsForCall)
+}
+
+func (fake *FakeLibrary) FindAlbumInfoCalls(stub func(context.Context, int64) (library.AlbumInfo, error)) {
+	fake.findAlbumInfoMutex.Lock()
+	defer fake.findAlbumInfoMutex.Unlock()
+	fake.FindAlbumInfoStub = stub
+}
+
+func (fake *FakeLibrary) FindAlbumInfoArgsForCall(i int) (context.Context, int64) {
+	fake.findAlbumInfoMutex.RLock()
+	defer fake.findAlbumInfoMutex.RUnlock()
+	argsForCall := fake.findAlbumInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrary) FindAlbumInfoReturns(result1 library.AlbumInfo, result2 error) {
+	fake.findAlbumInfoMutex.Lock()
+	defer fake.findAlbumInfoMutex.Unlock()
+	fake.FindAlbumInfoStub = nil
+	fake.findAlbumInfoReturns = struct {
+		result1 library.AlbumInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) FindAlbumInfoReturnsOnCall(i int, result1 library.AlbumInfo, result2 error) {
+	fake.findAlbumInfoMutex.Lock()
+	defer fake.findAlbumInfoMutex.Unlock()
+	fake.FindAlbumInfoStub = nil
+	if fake.findAlbumInfoReturnsOnCall == nil {
+		fake.findAlbumInfoReturnsOnCall = make(map[int]struct {
+			result1 library.AlbumInfo
+			result2 error
+		})
+	}
+	fake.findAlbumInfoReturnsOnCall[i] = struct {
+		result1 library.AlbumInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeLibrary) GetAlbum(arg1 context.Context, arg2 int64) (library.Album, error) {
 	fake.getAlbumMutex.Lock()
 	ret, specificReturn := fake.getAlbumReturnsOnCall[len(fake.getAlbumArgsForCall)]
@@ -492,6 +691,71 @@ func (fake *FakeLibrary) GetAlbumFilesReturnsOnCall(i int, result1 []library.Sea
 	}{result1}
 }
 
+func (fake *FakeLibrary) GetAlbumInfo(arg1 context.Context, arg2 int64) (library.AlbumInfo, error) {
+	fake.getAlbumInfoMutex.Lock()
+	ret, specificReturn := fake.getAlbumInfoReturnsOnCall[len(fake.getAlbumInfoArgsForCall)]
+	fake.getAlbumInfoArgsForCall = append(fake.getAlbumInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetAlbumInfoStub
+	fakeReturns := fake.getAlbumInfoReturns
+	fake.recordInvocation("GetAlbumInfo", []interface{}{arg1, arg2})
+	fake.getAlbumInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrary) GetAlbumInfoCallCount() int {
+	fake.getAlbumInfoMutex.RLock()
+	defer fake.getAlbumInfoMutex.RUnlock()
+	return len(fake.getAlbumInfoArgsForCall)
+}
+
+func (fake *FakeLibrary) GetAlbumInfoCalls(stub func(context.Context, int64) (library.AlbumInfo, error)) {
+	fake.getAlbumInfoMutex.Lock()
+	defer fake.getAlbumInfoMutex.Unlock()
+	fake.GetAlbumInfoStub = stub
+}
+
+func (fake *FakeLibrary) GetAlbumInfoArgsForCall(i int) (context.Context, int64) {
+	fake.getAlbumInfoMutex.RLock()
+	defer fake.getAlbumInfoMutex.RUnlock()
+	argsForCall := fake.getAlbumInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrary) GetAlbumInfoReturns(result1 library.AlbumInfo, result2 error) {
+	fake.getAlbumInfoMutex.Lock()
+	defer fake.getAlbumInfoMutex.Unlock()
+	fake.GetAlbumInfoStub = nil
+	fake.getAlbumInfoReturns = struct {
+		result1 library.AlbumInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) GetAlbumInfoReturnsOnCall(i int, result1 library.AlbumInfo, result2 error) {
+	fake.getAlbumInfoMutex.Lock()
+	defer fake.getAlbumInfoMutex.Unlock()
+	fake.GetAlbumInfoStub = nil
+	if fake.getAlbumInfoReturnsOnCall == nil {
+		fake.getAlbumInfoReturnsOnCall = make(map[int]struct {
+			result1 library.AlbumInfo
+			result2 error
+		})
+	}
+	fake.getAlbumInfoReturnsOnCall[i] = struct {
+		result1 library.AlbumInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeLibrary) GetArtist(arg1 context.Context, arg2 int64) (library.Artist, error) {
 	fake.getArtistMutex.Lock()
 	ret, specificReturn := fake.getArtistReturnsOnCall[len(fake.getArtistArgsForCall)]
@@ -619,6 +883,71 @@ func (fake *FakeLibrary) GetArtistAlbumsReturnsOnCall(i int, result1 []library.A
 	}{result1}
 }
 
+func (fake *FakeLibrary) GetArtistInfo(arg1 context.Context, arg2 int64) (library.ArtistInfo, error) {
+	fake.getArtistInfoMutex.Lock()
+	ret, specificReturn := fake.getArtistInfoReturnsOnCall[len(fake.getArtistInfoArgsForCall)]
+	fake.getArtistInfoArgsForCall = append(fake.getArtistInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetArtistInfoStub
+	fakeReturns := fake.getArtistInfoReturns
+	fake.recordInvocation("GetArtistInfo", []interface{}{arg1, arg2})
+	fake.getArtistInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrary) GetArtistInfoCallCount() int {
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	return len(fake.getArtistInfoArgsForCall)
+}
+
+func (fake *FakeLibrary) GetArtistInfoCalls(stub func(context.Context, int64) (library.ArtistInfo, error)) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = stub
+}
+
+func (fake *FakeLibrary) GetArtistInfoArgsForCall(i int) (context.Context, int64) {
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	argsForCall := fake.getArtistInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrary) GetArtistInfoReturns(result1 library.ArtistInfo, result2 error) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = nil
+	fake.getArtistInfoReturns = struct {
+		result1 library.ArtistInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) GetArtistInfoReturnsOnCall(i int, result1 library.ArtistInfo, result2 error) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = nil
+	if fake.getArtistInfoReturnsOnCall == nil {
+		fake.getArtistInfoReturnsOnCall = make(map[int]struct {
+			result1 library.ArtistInfo
+			result2 error
+		})
+	}
+	fake.getArtistInfoReturnsOnCall[i] = struct {
+		result1 library.ArtistInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeLibrary) GetFilePath(arg1 context.Context, arg2 int64) string {
 	fake.getFilePathMutex.Lock()
 	ret, specificReturn := fake.getFilePathReturnsOnCall[len(fake.getFilePathArgsForCall)]
@@ -681,6 +1010,139 @@ func (fake *FakeLibrary) GetFilePathReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeLibrary) GetHistory(arg1 context.Context, arg2 library.HistoryArgs) ([]library.HistoryEntry, int, error) {
+	fake.getHistoryMutex.Lock()
+	ret, specificReturn := fake.getHistoryReturnsOnCall[len(fake.getHistoryArgsForCall)]
+	fake.getHistoryArgsForCall = append(fake.getHistoryArgsForCall, struct {
+		arg1 context.Context
+		arg2 library.HistoryArgs
+	}{arg1, arg2})
+	stub := fake.GetHistoryStub
+	fakeReturns := fake.getHistoryReturns
+	fake.recordInvocation("GetHistory", []interface{}{arg1, arg2})
+	fake.getHistoryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeLibrary) GetHistoryCallCount() int {
+	fake.getHistoryMutex.RLock()
+	defer fake.getHistoryMutex.RUnlock()
+	return len(fake.getHistoryArgsForCall)
+}
+
+func (fake *FakeLibrary) GetHistoryCalls(stub func(context.Context, library.HistoryArgs) ([]library.HistoryEntry, int, error)) {
+	fake.getHistoryMutex.Lock()
+	defer fake.getHistoryMutex.Unlock()
+	fake.GetHistoryStub = stub
+}
+
+func (fake *FakeLibrary) GetHistoryArgsForCall(i int) (context.Context, library.HistoryArgs) {
+	fake.getHistoryMutex.RLock()
+	defer fake.getHistoryMutex.RUnlock()
+	argsForCall := fake.getHistoryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrary) GetHistoryReturns(result1 []library.HistoryEntry, result2 int, result3 error) {
+	fake.getHistoryMutex.Lock()
+	defer fake.getHistoryMutex.Unlock()
+	fake.GetHistoryStub = nil
+	fake.getHistoryReturns = struct {
+		result1 []library.HistoryEntry
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeLibrary) GetHistoryReturnsOnCall(i int, result1 []library.HistoryEntry, result2 int, result3 error) {
+	fake.getHistoryMutex.Lock()
+	defer fake.getHistoryMutex.Unlock()
+	fake.GetHistoryStub = nil
+	if fake.getHistoryReturnsOnCall == nil {
+		fake.getHistoryReturnsOnCall = make(map[int]struct {
+			result1 []library.HistoryEntry
+			result2 int
+			result3 error
+		})
+	}
+	fake.getHistoryReturnsOnCall[i] = struct {
+		result1 []library.HistoryEntry
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeLibrary) GetStats(arg1 context.Context, arg2 library.StatsArgs) (library.Stats, error) {
+	fake.getStatsMutex.Lock()
+	ret, specificReturn := fake.getStatsReturnsOnCall[len(fake.getStatsArgsForCall)]
+	fake.getStatsArgsForCall = append(fake.getStatsArgsForCall, struct {
+		arg1 context.Context
+		arg2 library.StatsArgs
+	}{arg1, arg2})
+	stub := fake.GetStatsStub
+	fakeReturns := fake.getStatsReturns
+	fake.recordInvocation("GetStats", []interface{}{arg1, arg2})
+	fake.getStatsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrary) GetStatsCallCount() int {
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
+	return len(fake.getStatsArgsForCall)
+}
+
+func (fake *FakeLibrary) GetStatsCalls(stub func(context.Context, library.StatsArgs) (library.Stats, error)) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = stub
+}
+
+func (fake *FakeLibrary) GetStatsArgsForCall(i int) (context.Context, library.StatsArgs) {
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
+	argsForCall := fake.getStatsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrary) GetStatsReturns(result1 library.Stats, result2 error) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	fake.getStatsReturns = struct {
+		result1 library.Stats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) GetStatsReturnsOnCall(i int, result1 library.Stats, result2 error) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	if fake.getStatsReturnsOnCall == nil {
+		fake.getStatsReturnsOnCall = make(map[int]struct {
+			result1 library.Stats
+			result2 error
+		})
+	}
+	fake.getStatsReturnsOnCall[i] = struct {
+		result1 library.Stats
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeLibrary) GetTrack(arg1 context.Context, arg2 int64) (library.SearchResult, error) {
 	fake.getTrackMutex.Lock()
 	ret, specificReturn := fake.getTrackReturnsOnCall[len(fake.getTrackArgsForCall)]
@@ -746,6 +1208,71 @@ func (fake *FakeLibrary) GetTrackReturnsOnCall(i int, result1 library.SearchResu
 	}{result1, result2}
 }
 
+func (fake *FakeLibrary) GetTrackLyrics(arg1 context.Context, arg2 int64) (library.Lyrics, error) {
+	fake.getTrackLyricsMutex.Lock()
+	ret, specificReturn := fake.getTrackLyricsReturnsOnCall[len(fake.getTrackLyricsArgsForCall)]
+	fake.getTrackLyricsArgsForCall = append(fake.getTrackLyricsArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetTrackLyricsStub
+	fakeReturns := fake.getTrackLyricsReturns
+	fake.recordInvocation("GetTrackLyrics", []interface{}{arg1, arg2})
+	fake.getTrackLyricsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrary) GetTrackLyricsCallCount() int {
+	fake.getTrackLyricsMutex.RLock()
+	defer fake.getTrackLyricsMutex.RUnlock()
+	return len(fake.getTrackLyricsArgsForCall)
+}
+
+func (fake *FakeLibrary) GetTrackLyricsCalls(stub func(context.Context, int64) (library.Lyrics, error)) {
+	fake.getTrackLyricsMutex.Lock()
+	defer fake.getTrackLyricsMutex.Unlock()
+	fake.GetTrackLyricsStub = stub
+}
+
+func (fake *FakeLibrary) GetTrackLyricsArgsForCall(i int) (context.Context, int64) {
+	fake.getTrackLyricsMutex.RLock()
+	defer fake.getTrackLyricsMutex.RUnlock()
+	argsForCall := fake.getTrackLyricsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrary) GetTrackLyricsReturns(result1 library.Lyrics, result2 error) {
+	fake.getTrackLyricsMutex.Lock()
+	defer fake.getTrackLyricsMutex.Unlock()
+	fake.GetTrackLyricsStub = nil
+	fake.getTrackLyricsReturns = struct {
+		result1 library.Lyrics
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) GetTrackLyricsReturnsOnCall(i int, result1 library.Lyrics, result2 error) {
+	fake.getTrackLyricsMutex.Lock()
+	defer fake.getTrackLyricsMutex.Unlock()
+	fake.GetTrackLyricsStub = nil
+	if fake.getTrackLyricsReturnsOnCall == nil {
+		fake.getTrackLyricsReturnsOnCall = make(map[int]struct {
+			result1 library.Lyrics
+			result2 error
+		})
+	}
+	fake.getTrackLyricsReturnsOnCall[i] = struct {
+		result1 library.Lyrics
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeLibrary) Initialize() error {
 	fake.initializeMutex.Lock()
 	ret, specificReturn := fake.initializeReturnsOnCall[len(fake.initializeArgsForCall)]
@@ -861,20 +1388,19 @@ func (fake *FakeLibrary) RecordFavouriteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeLibrary) RecordTrackPlay(arg1 context.Context, arg2 int64, arg3 time.Time) error {
+func (fake *FakeLibrary) RecordTrackPlay(arg1 context.Context, arg2 library.Play) error {
 	fake.recordTrackPlayMutex.Lock()
 	ret, specificReturn := fake.recordTrackPlayReturnsOnCall[len(fake.recordTrackPlayArgsForCall)]
 	fake.recordTrackPlayArgsForCall = append(fake.recordTrackPlayArgsForCall, struct {
 		arg1 context.Context
-		arg2 int64
-		arg3 time.Time
-	}{arg1, arg2, arg3})
+		arg2 library.Play
+	}{arg1, arg2})
 	stub := fake.RecordTrackPlayStub
 	fakeReturns := fake.recordTrackPlayReturns
-	fake.recordInvocation("RecordTrackPlay", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("RecordTrackPlay", []interface{}{arg1, arg2})
 	fake.recordTrackPlayMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -888,17 +1414,17 @@ func (fake *FakeLibrary) RecordTrackPlayCallCount() int {
 	return len(fake.recordTrackPlayArgsForCall)
 }
 
-func (fake *FakeLibrary) RecordTrackPlayCalls(stub func(context.Context, int64, time.Time) error) {
+func (fake *FakeLibrary) RecordTrackPlayCalls(stub func(context.Context, library.Play) error) {
 	fake.recordTrackPlayMutex.Lock()
 	defer fake.recordTrackPlayMutex.Unlock()
 	fake.RecordTrackPlayStub = stub
 }
 
-func (fake *FakeLibrary) RecordTrackPlayArgsForCall(i int) (context.Context, int64, time.Time) {
+func (fake *FakeLibrary) RecordTrackPlayArgsForCall(i int) (context.Context, library.Play) {
 	fake.recordTrackPlayMutex.RLock()
 	defer fake.recordTrackPlayMutex.RUnlock()
 	argsForCall := fake.recordTrackPlayArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeLibrary) RecordTrackPlayReturns(result1 error) {
@@ -1010,6 +1536,59 @@ func (fake *FakeLibrary) ScanCalls(stub func()) {
 	fake.ScanStub = stub
 }
 
+func (fake *FakeLibrary) ScanStatus() library.ScanStatus {
+	fake.scanStatusMutex.Lock()
+	ret, specificReturn := fake.scanStatusReturnsOnCall[len(fake.scanStatusArgsForCall)]
+	fake.scanStatusArgsForCall = append(fake.scanStatusArgsForCall, struct {
+	}{})
+	stub := fake.ScanStatusStub
+	fakeReturns := fake.scanStatusReturns
+	fake.recordInvocation("ScanStatus", []interface{}{})
+	fake.scanStatusMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLibrary) ScanStatusCallCount() int {
+	fake.scanStatusMutex.RLock()
+	defer fake.scanStatusMutex.RUnlock()
+	return len(fake.scanStatusArgsForCall)
+}
+
+func (fake *FakeLibrary) ScanStatusCalls(stub func() library.ScanStatus) {
+	fake.scanStatusMutex.Lock()
+	defer fake.scanStatusMutex.Unlock()
+	fake.ScanStatusStub = stub
+}
+
+func (fake *FakeLibrary) ScanStatusReturns(result1 library.ScanStatus) {
+	fake.scanStatusMutex.Lock()
+	defer fake.scanStatusMutex.Unlock()
+	fake.ScanStatusStub = nil
+	fake.scanStatusReturns = struct {
+		result1 library.ScanStatus
+	}{result1}
+}
+
+func (fake *FakeLibrary) ScanStatusReturnsOnCall(i int, result1 library.ScanStatus) {
+	fake.scanStatusMutex.Lock()
+	defer fake.scanStatusMutex.Unlock()
+	fake.ScanStatusStub = nil
+	if fake.scanStatusReturnsOnCall == nil {
+		fake.scanStatusReturnsOnCall = make(map[int]struct {
+			result1 library.ScanStatus
+		})
+	}
+	fake.scanStatusReturnsOnCall[i] = struct {
+		result1 library.ScanStatus
+	}{result1}
+}
+
 func (fake *FakeLibrary) Search(arg1 context.Context, arg2 library.SearchArgs) []library.SearchResult {
 	fake.searchMutex.Lock()
 	ret, specificReturn := fake.searchReturnsOnCall[len(fake.searchArgsForCall)]
@@ -1385,6 +1964,198 @@ func (fake *FakeLibrary) SetTrackRatingReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeLibrary) SimilarTracks(arg1 context.Context, arg2 library.SimilarArgs) ([]library.SearchResult, error) {
+	fake.similarTracksMutex.Lock()
+	ret, specificReturn := fake.similarTracksReturnsOnCall[len(fake.similarTracksArgsForCall)]
+	fake.similarTracksArgsForCall = append(fake.similarTracksArgsForCall, struct {
+		arg1 context.Context
+		arg2 library.SimilarArgs
+	}{arg1, arg2})
+	stub := fake.SimilarTracksStub
+	fakeReturns := fake.similarTracksReturns
+	fake.recordInvocation("SimilarTracks", []interface{}{arg1, arg2})
+	fake.similarTracksMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrary) SimilarTracksCallCount() int {
+	fake.similarTracksMutex.RLock()
+	defer fake.similarTracksMutex.RUnlock()
+	return len(fake.similarTracksArgsForCall)
+}
+
+func (fake *FakeLibrary) SimilarTracksCalls(stub func(context.Context, library.SimilarArgs) ([]library.SearchResult, error)) {
+	fake.similarTracksMutex.Lock()
+	defer fake.similarTracksMutex.Unlock()
+	fake.SimilarTracksStub = stub
+}
+
+func (fake *FakeLibrary) SimilarTracksArgsForCall(i int) (context.Context, library.SimilarArgs) {
+	fake.similarTracksMutex.RLock()
+	defer fake.similarTracksMutex.RUnlock()
+	argsForCall := fake.similarTracksArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrary) SimilarTracksReturns(result1 []library.SearchResult, result2 error) {
+	fake.similarTracksMutex.Lock()
+	defer fake.similarTracksMutex.Unlock()
+	fake.SimilarTracksStub = nil
+	fake.similarTracksReturns = struct {
+		result1 []library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) SimilarTracksReturnsOnCall(i int, result1 []library.SearchResult, result2 error) {
+	fake.similarTracksMutex.Lock()
+	defer fake.similarTracksMutex.Unlock()
+	fake.SimilarTracksStub = nil
+	if fake.similarTracksReturnsOnCall == nil {
+		fake.similarTracksReturnsOnCall = make(map[int]struct {
+			result1 []library.SearchResult
+			result2 error
+		})
+	}
+	fake.similarTracksReturnsOnCall[i] = struct {
+		result1 []library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) StartScan(arg1 bool) error {
+	fake.startScanMutex.Lock()
+	ret, specificReturn := fake.startScanReturnsOnCall[len(fake.startScanArgsForCall)]
+	fake.startScanArgsForCall = append(fake.startScanArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.StartScanStub
+	fakeReturns := fake.startScanReturns
+	fake.recordInvocation("StartScan", []interface{}{arg1})
+	fake.startScanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLibrary) StartScanCallCount() int {
+	fake.startScanMutex.RLock()
+	defer fake.startScanMutex.RUnlock()
+	return len(fake.startScanArgsForCall)
+}
+
+func (fake *FakeLibrary) StartScanCalls(stub func(bool) error) {
+	fake.startScanMutex.Lock()
+	defer fake.startScanMutex.Unlock()
+	fake.StartScanStub = stub
+}
+
+func (fake *FakeLibrary) StartScanArgsForCall(i int) bool {
+	fake.startScanMutex.RLock()
+	defer fake.startScanMutex.RUnlock()
+	argsForCall := fake.startScanArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLibrary) StartScanReturns(result1 error) {
+	fake.startScanMutex.Lock()
+	defer fake.startScanMutex.Unlock()
+	fake.StartScanStub = nil
+	fake.startScanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLibrary) StartScanReturnsOnCall(i int, result1 error) {
+	fake.startScanMutex.Lock()
+	defer fake.startScanMutex.Unlock()
+	fake.StartScanStub = nil
+	if fake.startScanReturnsOnCall == nil {
+		fake.startScanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.startScanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLibrary) TopAlbums(arg1 context.Context, arg2 library.StatsArgs, arg3 library.BrowseOrderBy) ([]library.Album, error) {
+	fake.topAlbumsMutex.Lock()
+	ret, specificReturn := fake.topAlbumsReturnsOnCall[len(fake.topAlbumsArgsForCall)]
+	fake.topAlbumsArgsForCall = append(fake.topAlbumsArgsForCall, struct {
+		arg1 context.Context
+		arg2 library.StatsArgs
+		arg3 library.BrowseOrderBy
+	}{arg1, arg2, arg3})
+	stub := fake.TopAlbumsStub
+	fakeReturns := fake.topAlbumsReturns
+	fake.recordInvocation("TopAlbums", []interface{}{arg1, arg2, arg3})
+	fake.topAlbumsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrary) TopAlbumsCallCount() int {
+	fake.topAlbumsMutex.RLock()
+	defer fake.topAlbumsMutex.RUnlock()
+	return len(fake.topAlbumsArgsForCall)
+}
+
+func (fake *FakeLibrary) TopAlbumsCalls(stub func(context.Context, library.StatsArgs, library.BrowseOrderBy) ([]library.Album, error)) {
+	fake.topAlbumsMutex.Lock()
+	defer fake.topAlbumsMutex.Unlock()
+	fake.TopAlbumsStub = stub
+}
+
+func (fake *FakeLibrary) TopAlbumsArgsForCall(i int) (context.Context, library.StatsArgs, library.BrowseOrderBy) {
+	fake.topAlbumsMutex.RLock()
+	defer fake.topAlbumsMutex.RUnlock()
+	argsForCall := fake.topAlbumsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLibrary) TopAlbumsReturns(result1 []library.Album, result2 error) {
+	fake.topAlbumsMutex.Lock()
+	defer fake.topAlbumsMutex.Unlock()
+	fake.TopAlbumsStub = nil
+	fake.topAlbumsReturns = struct {
+		result1 []library.Album
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrary) TopAlbumsReturnsOnCall(i int, result1 []library.Album, result2 error) {
+	fake.topAlbumsMutex.Lock()
+	defer fake.topAlbumsMutex.Unlock()
+	fake.TopAlbumsStub = nil
+	if fake.topAlbumsReturnsOnCall == nil {
+		fake.topAlbumsReturnsOnCall = make(map[int]struct {
+			result1 []library.Album
+			result2 error
+		})
+	}
+	fake.topAlbumsReturnsOnCall[i] = struct {
+		result1 []library.Album
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeLibrary) Truncate() error {
 	fake.truncateMutex.Lock()
 	ret, specificReturn := fake.truncateReturnsOnCall[len(fake.truncateArgsForCall)]
@@ -1447,18 +2218,30 @@ func (fake *FakeLibrary) Invocations() map[string][][]interface{} {
 	defer fake.addMediaMutex.RUnlock()
 	fake.closeMutex.RLock()
 	defer fake.closeMutex.RUnlock()
+	fake.findAlbumInfoMutex.RLock()
+	defer fake.findAlbumInfoMutex.RUnlock()
 	fake.getAlbumMutex.RLock()
 	defer fake.getAlbumMutex.RUnlock()
 	fake.getAlbumFilesMutex.RLock()
 	defer fake.getAlbumFilesMutex.RUnlock()
+	fake.getAlbumInfoMutex.RLock()
+	defer fake.getAlbumInfoMutex.RUnlock()
 	fake.getArtistMutex.RLock()
 	defer fake.getArtistMutex.RUnlock()
 	fake.getArtistAlbumsMutex.RLock()
 	defer fake.getArtistAlbumsMutex.RUnlock()
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
 	fake.getFilePathMutex.RLock()
 	defer fake.getFilePathMutex.RUnlock()
+	fake.getHistoryMutex.RLock()
+	defer fake.getHistoryMutex.RUnlock()
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
 	fake.getTrackMutex.RLock()
 	defer fake.getTrackMutex.RUnlock()
+	fake.getTrackLyricsMutex.RLock()
+	defer fake.getTrackLyricsMutex.RUnlock()
 	fake.initializeMutex.RLock()
 	defer fake.initializeMutex.RUnlock()
 	fake.recordFavouriteMutex.RLock()
@@ -1469,6 +2252,8 @@ func (fake *FakeLibrary) Invocations() map[string][][]interface{} {
 	defer fake.removeFavouriteMutex.RUnlock()
 	fake.scanMutex.RLock()
 	defer fake.scanMutex.RUnlock()
+	fake.scanStatusMutex.RLock()
+	defer fake.scanStatusMutex.RUnlock()
 	fake.searchMutex.RLock()
 	defer fake.searchMutex.RUnlock()
 	fake.searchAlbumsMutex.RLock()
@@ -1481,6 +2266,12 @@ func (fake *FakeLibrary) Invocations() map[string][][]interface{} {
 	defer fake.setArtistRatingMutex.RUnlock()
 	fake.setTrackRatingMutex.RLock()
 	defer fake.setTrackRatingMutex.RUnlock()
+	fake.similarTracksMutex.RLock()
+	defer fake.similarTracksMutex.RUnlock()
+	fake.startScanMutex.RLock()
+	defer fake.startScanMutex.RUnlock()
+	fake.topAlbumsMutex.RLock()
+	defer fake.topAlbumsMutex.RUnlock()
 	fake.truncateMutex.RLock()
 	defer fake.truncateMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
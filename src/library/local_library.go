@@ -21,7 +21,10 @@ import (
 	// from the golang documentation.
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/ironsmile/euterpe/src/albuminfo"
 	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/artistinfo"
+	"github.com/ironsmile/euterpe/src/blobstore"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/helpers"
 	"github.com/ironsmile/euterpe/src/scaler"
@@ -118,6 +121,9 @@ type LocalLibrary struct {
 	db       *sql.DB        // Database handler
 	walkWG   sync.WaitGroup // Used to log how much time scanning took
 
+	// pathsLock guards paths and ScanConfig once the library is in use.
+	pathsLock sync.RWMutex
+
 	// If something needs to work with the database it has to construct
 	// a DatabaseExecutable and send it through this channel.
 	dbExecutes chan DatabaseExecutable
@@ -137,19 +143,46 @@ type LocalLibrary struct {
 
 	artFinder art.Finder
 
+	// artworkSources is the order in which album artwork is searched for.
+	artworkSources []string
+
+	// artworkSelection is how artwork is chosen among the images in the album
+	// directories.
+	artworkSelection config.ArtworkSelection
+
+	// artistInfoFinder is used for searching for information about artists on
+	// the internet. It is searched for again once it is older than artistInfoTTL.
+	artistInfoFinder artistinfo.Finder
+	artistInfoTTL    time.Duration
+
+	// albumInfoFinder is used for searching for information about album
+	// releases on the internet. It is searched for again once it is older
+	// than albumInfoTTL.
+	albumInfoFinder albuminfo.Finder
+	albumInfoTTL    time.Duration
+
 	fs         fs.FS
 	sqlFilesFS fs.FS
 
 	imageScaler scaler.Scaler
 
+	// blobs is where album artwork, artist and playlist images are stored. The
+	// database keeps only their hashes.
+	blobs *blobstore.Store
+
 	// cleanupLock is used to secure a thread safe access to the runningCleanup property.
 	cleanupLock *sync.RWMutex
 
 	// runningCleanup shows whether there is an already running clean-up.
 	runningCleanup bool
 
-	// runningRescan shows that at the moment a complete rescan is running.
-	runningRescan bool
+	// scanStateLock guards scanState and scanCounts.
+	scanStateLock sync.Mutex
+
+	// scanState is the state of the current or last finished scan. Its file
+	// counters are kept up to date in scanCounts while the scan is running.
+	scanState  ScanStatus
+	scanCounts *scanCounts
 
 	// When noWatch is set then no file system watchers will be created
 	// for the scanned directories.
@@ -170,7 +203,100 @@ func (lib *LocalLibrary) AddLibraryPath(path string) {
 		return
 	}
 
+	lib.pathsLock.Lock()
 	lib.paths = append(lib.paths, path)
+	lib.pathsLock.Unlock()
+}
+
+// RemoveLibraryPath removes a library directory from the list of libraries. Its
+// directories are no longer watched and its media files are removed from the
+// database, together with the albums and artists left without tracks. Files which
+// are also in one of the other library directories are kept. The files are removed
+// only after the running scans have finished so that they could not add them again.
+func (lib *LocalLibrary) RemoveLibraryPath(path string) {
+	var remaining []string
+
+	lib.pathsLock.Lock()
+	for _, libPath := range lib.paths {
+		if libPath != path {
+			remaining = append(remaining, libPath)
+		}
+	}
+	lib.paths = remaining
+	lib.pathsLock.Unlock()
+
+	var nested []string
+	for _, libPath := range remaining {
+		if isInDirectory(path, libPath) {
+			// The whole directory is still part of the library.
+			return
+		}
+		if isInDirectory(libPath, path) {
+			nested = append(nested, libPath)
+		}
+	}
+
+	lib.unwatchDirectory(path, nested)
+
+	// Walks which are still running stop going into the removed directory. But the
+	// files which they have found already could still be added.
+	lib.waitScanLock.RLock()
+	lib.walkWG.Wait()
+	lib.waitScanLock.RUnlock()
+
+	lib.removeDirectoryExcept(path, nested)
+	lib.cleanupAlbums()
+	lib.cleanupArtists()
+}
+
+// SetScanConfig changes the configuration for scanning the library. It is used
+// from the next scan on.
+func (lib *LocalLibrary) SetScanConfig(cfg config.ScanSection) {
+	lib.pathsLock.Lock()
+	defer lib.pathsLock.Unlock()
+
+	lib.ScanConfig = cfg
+}
+
+// scanConfig returns the current configuration for scanning the library.
+func (lib *LocalLibrary) scanConfig() config.ScanSection {
+	lib.pathsLock.RLock()
+	defer lib.pathsLock.RUnlock()
+
+	return lib.ScanConfig
+}
+
+// libraryPaths returns a copy of the library directories.
+func (lib *LocalLibrary) libraryPaths() []string {
+	lib.pathsLock.RLock()
+	defer lib.pathsLock.RUnlock()
+
+	return append([]string(nil), lib.paths...)
+}
+
+// inLibrary returns true when `path` is in one of the library directories.
+func (lib *LocalLibrary) inLibrary(path string) bool {
+	lib.pathsLock.RLock()
+	defer lib.pathsLock.RUnlock()
+
+	for _, libPath := range lib.paths {
+		if isInDirectory(path, libPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isInDirectory returns true when `path` is `dir` itself or somewhere in it.
+func isInDirectory(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator)))
 }
 
 // Search searches in the library. Will match against the track's name, artist and album.
@@ -624,50 +750,6 @@ func (lib *LocalLibrary) GetAlbum(
 	return res, nil
 }
 
-// RecordTrackPlay updates the `user_stats` table in the database.
-//
-// play_count and last_played are updated only if a sufficient time has
-// passed since the previous value of last_played. This sufficient time is
-// calculated based on the length of the media file.
-func (lib *LocalLibrary) RecordTrackPlay(
-	ctx context.Context,
-	mediaID int64,
-	atTime time.Time,
-) error {
-	work := func(db *sql.DB) error {
-		query := `
-			INSERT INTO user_stats (track_id, last_played, play_count)
-			VALUES (@mediaID, @unixTime, 1)
-			ON CONFLICT(track_id) DO UPDATE SET
-				last_played = @unixTime,
-				play_count = play_count + 1
-			WHERE
-				last_played + (
-					SELECT
-						duration / 1000 as dur
-					FROM
-						tracks
-					WHERE
-						id = @mediaID
-				) / 3 < @unixTime;
-		`
-		unixTime := atTime.Unix()
-
-		_, err := db.ExecContext(
-			ctx, query,
-			sql.Named("mediaID", mediaID),
-			sql.Named("unixTime", unixTime),
-		)
-		return err
-	}
-
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return fmt.Errorf("sql query error: %w", err)
-	}
-
-	return nil
-}
-
 // GetArtistAlbums returns all the albums which this artist has an at least
 // one track in.
 func (lib *LocalLibrary) GetArtistAlbums(
@@ -819,6 +901,34 @@ func (lib *LocalLibrary) removeDirectory(dirPath string) {
 	}
 }
 
+// removeDirectoryExcept removes from the library the files in `dirPath` which are
+// not in any of the `except` directories.
+func (lib *LocalLibrary) removeDirectoryExcept(dirPath string, except []string) {
+	query := `
+		DELETE FROM tracks
+		WHERE fs_path LIKE ?
+	`
+	args := []any{
+		fmt.Sprintf("%s/%%", strings.TrimRight(dirPath, "/")),
+	}
+	for _, exceptPath := range except {
+		query += ` AND fs_path NOT LIKE ?`
+		args = append(args, fmt.Sprintf("%s/%%", strings.TrimRight(exceptPath, "/")))
+	}
+
+	work := func(db *sql.DB) error {
+		if _, err := db.Exec(query, args...); err != nil {
+			log.Printf("Error removing %s: %s\n", dirPath, err.Error())
+		}
+
+		return nil
+	}
+
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		log.Printf("Error executing remove dir db work: %s", err)
+	}
+}
+
 // Determines if the file will be saved to the database. Only media files which
 // jplayer can use are saved.
 func (lib *LocalLibrary) isSupportedFormat(path string) bool {
@@ -853,30 +963,146 @@ func (lib *LocalLibrary) isSupportedFormat(path string) bool {
 }
 
 // AddMedia adds a file specified by its file system name to the library. Will create the
-// needed Artist, Album if necessary.
+// needed Artist, Album if necessary. Files which are already in the library are read
+// again only when their size or modification time have changed since they were added.
 func (lib *LocalLibrary) AddMedia(filename string) error {
-	filename = filepath.Clean(filename)
+	_, err := lib.addMedia(filename)
+	return err
+}
 
-	if lib.MediaExistsInLibrary(filename) {
-		return nil
+// addMediaResult describes what happened to the database while adding a file with
+// addMedia.
+type addMediaResult int
+
+const (
+	// mediaUnchanged means that the file was in the library already and it has not
+	// been changed since then.
+	mediaUnchanged addMediaResult = iota
+
+	// mediaAdded means that the file was not in the library and it was added.
+	mediaAdded
+
+	// mediaUpdated means that the file was in the library but it was changed
+	// and its data in the database was updated.
+	mediaUpdated
+)
+
+// addMedia is the actual implementation of AddMedia. It returns what was done
+// with the file.
+func (lib *LocalLibrary) addMedia(filename string) (addMediaResult, error) {
+	media, err := lib.readMediaIfChanged(filename)
+	if err != nil || media.result == mediaUnchanged {
+		return mediaUnchanged, err
 	}
 
+	if err := lib.insertMediaIntoDatabase(media.file, media.info); err != nil {
+		return mediaUnchanged, err
+	}
+
+	return media.result, nil
+}
+
+// changedMedia is a media file which has been read from the file system because
+// it is new or was changed since it was last added into the library.
+type changedMedia struct {
+	file   MediaFile
+	info   fileInfo
+	result addMediaResult
+}
+
+// readMediaIfChanged parses the tags of the media file at `filename` unless it
+// is already in the library and its size and modification time are the same as
+// when it was added. Then the result of the returned changedMedia is mediaUnchanged
+// and its file is nil. The modification time of its LRC file is taken into
+// account too, see mediaModTime.
+func (lib *LocalLibrary) readMediaIfChanged(filename string) (changedMedia, error) {
+	filename = filepath.Clean(filename)
+
 	st, err := fs.Stat(lib.fs, filename)
 	if err != nil {
-		return err
+		return changedMedia{}, err
+	}
+
+	stored, found, err := lib.storedFileInfo(filename)
+	if err != nil {
+		return changedMedia{}, err
+	}
+
+	modified := lib.mediaModTime(filename, st)
+	unchanged := stored.Size == st.Size() &&
+		stored.Modified.Unix() == modified.Unix()
+	if found && unchanged {
+		return changedMedia{}, nil
 	}
 
 	file, err := parseFileTags(filename)
 	if err != nil {
-		return fmt.Errorf("parsing tags error for %s: %s", filename, err.Error())
+		return changedMedia{}, fmt.Errorf(
+			"parsing tags error for %s: %s", filename, err.Error(),
+		)
+	}
+
+	media := changedMedia{
+		file: file,
+		info: fileInfo{
+			FilePath: filename,
+			Size:     st.Size(),
+			Modified: modified,
+		},
+		result: mediaAdded,
+	}
+	if found {
+		media.result = mediaUpdated
+	}
+
+	return media, nil
+}
+
+// storedFileInfo returns the size and modification time of the media file at
+// `filename` as they were when it was last read into the library. The second
+// returned value is false when the file is not in the library. Modified is the
+// zero time for files which were added before modification times were stored.
+func (lib *LocalLibrary) storedFileInfo(filename string) (fileInfo, bool, error) {
+	var (
+		size     sql.NullInt64
+		modified sql.NullInt64
+		found    bool
+	)
+
+	work := func(db *sql.DB) error {
+		row := db.QueryRow(`
+			SELECT
+				size,
+				fs_modified
+			FROM
+				tracks
+			WHERE
+				fs_path = ?
+		`, filename)
+
+		err := row.Scan(&size, &modified)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error checking whether media exists already: %w", err)
+		}
+
+		found = true
+		return nil
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return fileInfo{}, false, err
 	}
 
-	fi := fileInfo{
+	info := fileInfo{
+		Size:     size.Int64,
 		FilePath: filename,
-		Size:     st.Size(),
-		Modified: st.ModTime(),
 	}
-	return lib.insertMediaIntoDatabase(file, fi)
+	if modified.Valid {
+		info.Modified = time.Unix(modified.Int64, 0)
+	}
+
+	return info, found, nil
 }
 
 type fileInfo struct {
@@ -886,45 +1112,11 @@ type fileInfo struct {
 }
 
 // insertMediaIntoDatabase accepts an already parsed media info object, its path.
-// The method inserts this media into the library database.
+// The method inserts this media into the library database together with its
+// lyrics and release tags.
 func (lib *LocalLibrary) insertMediaIntoDatabase(file MediaFile, info fileInfo) error {
-	artist := strings.TrimSpace(file.Artist())
-	artistID, err := lib.setArtistID(artist)
-	if err != nil {
-		return err
-	}
-
-	fileDir := filepath.Dir(info.FilePath)
-
-	album := strings.TrimSpace(file.Album())
-	albumID, err := lib.setAlbumID(album, fileDir)
-	if err != nil {
-		return err
-	}
-
-	trackNumber := int64(file.Track())
-	if trackNumber == 0 {
-		trackNumber = helpers.GuessTrackNumber(info.FilePath)
-	}
-
-	title := strings.TrimSpace(file.Title())
-	if len(title) < 1 {
-		title = filepath.Base(info.FilePath)
-	}
-
-	_, err = lib.setTrackID(
-		title,
-		info.FilePath,
-		trackNumber,
-		artistID,
-		albumID,
-		file.Length().Milliseconds(),
-		file.Year(),
-		file.Bitrate()*1024,
-		info.Size,
-		info.Modified,
-	)
-	return err
+	media := lib.readLyricsAndReleaseTags(changedMedia{file: file, info: info})
+	return lib.writeScannedMedia(media)
 }
 
 // MediaExistsInLibrary checks if the media file with file system path "filename" has
@@ -1004,69 +1196,6 @@ func (lib *LocalLibrary) GetArtistID(artist string) (int64, error) {
 	return artistID, nil
 }
 
-// Sets a new ID for this artist if it is new to the library. If not, returns
-// its current id.
-func (lib *LocalLibrary) setArtistID(artist string) (int64, error) {
-	if len(artist) < 1 {
-		artist = UnknownLabel
-	}
-
-	id, err := lib.GetArtistID(artist)
-	if err == nil {
-		return id, nil
-	}
-
-	var lastInsertID int64
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(`
-				INSERT INTO
-					artists (name)
-				VALUES
-					(?)
-		`)
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		res, err := stmt.Exec(artist)
-		if err != nil {
-			return err
-		}
-
-		lastInsertID, _ = res.LastInsertId()
-		return nil
-	}
-
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return 0, err
-	}
-
-	newID, err := lib.GetArtistID(artist)
-	if err != nil {
-		return lastInsertID, fmt.Errorf(
-			"getting the ID of inserted artist failed: %w", err)
-	}
-
-	log.Printf("Inserted artist id: %d, name: %s\n", newID, artist)
-	if lastInsertID != newID {
-		// In case this log is never seen for a long time it would mean that
-		// the LastInsertId() bug has been fixed and it is probably safe to
-		// remove the second SQL request which explicitly queries the DB for
-		// the ID.
-		log.Printf(
-			"Wrong ID returned for artist `%s` by .LastInsertId(). "+
-				"Returned: %d, actual: %d.",
-			artist,
-			lastInsertID,
-			newID,
-		)
-	}
-
-	return newID, nil
-}
-
 // GetAlbumID returns the id for this album. When missing or on error
 // returns that error.
 func (lib *LocalLibrary) GetAlbumID(album string, fsPath string) (int64, error) {
@@ -1104,72 +1233,6 @@ func (lib *LocalLibrary) GetAlbumID(album string, fsPath string) (int64, error)
 	return albumID, nil
 }
 
-// Sets a new ID for this album if it is new to the library. If not, returns
-// its current id. Albums with the same name but by different locations need to have
-// separate IDs hence the fsPath parameter.
-func (lib *LocalLibrary) setAlbumID(album string, fsPath string) (int64, error) {
-	if len(album) < 1 {
-		album = UnknownLabel
-	}
-
-	id, err := lib.GetAlbumID(album, fsPath)
-	if err == nil {
-		return id, nil
-	}
-
-	var lastInsertID int64
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(`
-				INSERT INTO
-					albums (name, fs_path)
-				VALUES
-					(?, ?)
-		`)
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		res, err := stmt.Exec(album, fsPath)
-		if err != nil {
-			return fmt.Errorf("executing album insert: %w", err)
-		}
-
-		lastInsertID, _ = res.LastInsertId()
-		return nil
-	}
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return 0, err
-	}
-
-	// For some reason the sql.Result.LastInsertId() function does not always
-	// return the correct ID. This might be a problem with the particular SQL
-	// driver used. In any case, explicitly selecting it is the safest option.
-	newID, err := lib.GetAlbumID(album, fsPath)
-	if err != nil {
-		return 0, fmt.Errorf("could not get ID of inserted album: %s", err)
-	}
-
-	log.Printf("Inserted album id: %d, name: %s, path: %s\n", newID, album, fsPath)
-	if lastInsertID != newID {
-		// In case this log is never seen for a long time it would mean that
-		// the LastInsertId() bug has been fixed and it is probably safe to
-		// remove the second SQL request which explicitly queries the DB for
-		// the ID.
-		log.Printf(
-			"Wrong ID returned for album `%s` (%s) by .LastInsertId(). "+
-				"Returned: %d, actual: %d.",
-			album,
-			fsPath,
-			lastInsertID,
-			newID,
-		)
-	}
-
-	return newID, nil
-}
-
 // GetAlbumFSPathByName returns all the file paths which contain versions of an album.
 func (lib *LocalLibrary) GetAlbumFSPathByName(albumName string) ([]string, error) {
 	var paths []string
@@ -1290,141 +1353,103 @@ func (lib *LocalLibrary) GetTrackID(
 	return newID, nil
 }
 
-// Sets a new ID for this track if it is new to the library. If not, returns
-// its current id. Tracks with the same name but by different artists and/or album
-// need to have separate IDs hence the artistID and albumID parameters.
-// Additionally trackNumber and file system path (fsPath) are required. They are
-// used when retrieving this particular song for playing.
-//
-// In case the track with this file system path already exists in the library it
-// is updated with new values for the test of the properties.
-func (lib *LocalLibrary) setTrackID(
-	title, fsPath string,
-	trackNumber, artistID, albumID, duration int64,
-	year, bitrate int,
-	size int64,
-	lastModified time.Time,
-) (int64, error) {
-	var lastInsertID int64
-	work := func(db *sql.DB) error {
-		stmt, err := db.Prepare(`
-			INSERT INTO
-				tracks (
-					name, album_id, artist_id, fs_path, number, duration,
-					year, bitrate, size, created_at
-				)
-			VALUES
-				(
-					@title, @albumID, @artistID, @fsPath, @trackNumber, @duration,
-					@year, @bitrate, @size, strftime('%s')
-				)
-			ON CONFLICT (fs_path) DO
-			UPDATE SET
-				name = @title,
-				album_id = @albumID,
-				artist_id = @artistID,
-				number = @trackNumber,
-				duration = @duration,
-				year = @year,
-				size = @size,
-				bitrate = @bitrate,
-				created_at = COALESCE(created_at, @lastModified)
-		`)
-		if err != nil {
-			return err
-		}
-
-		defer stmt.Close()
-
-		bitrateArg := sql.Named("bitrate", bitrate)
-		if bitrate == 0 {
-			bitrateArg = sql.Named("bitrate", nil)
-		}
-
-		yearArg := sql.Named("year", year)
-		if year == 0 {
-			yearArg = sql.Named("year", nil)
-		}
-
-		durationArg := sql.Named("duration", duration)
-		if duration == 0 {
-			durationArg = sql.Named("duration", nil)
-		}
-
-		res, err := stmt.Exec(
-			sql.Named("title", title),
-			sql.Named("albumID", albumID),
-			sql.Named("artistID", artistID),
-			sql.Named("fsPath", fsPath),
-			sql.Named("trackNumber", trackNumber),
-			durationArg,
-			yearArg,
-			sql.Named("size", size),
-			bitrateArg,
-			sql.Named("lastModified", lastModified.Unix()),
+// upsertTrackQuery inserts a track or updates it when a track with the same file
+// system path is already in the database. Its arguments are returned by
+// trackRecord.upsertArgs.
+const upsertTrackQuery = `
+	INSERT INTO
+		tracks (
+			name, album_id, artist_id, fs_path, number, duration,
+			year, bitrate, size, fs_modified, genre, created_at
 		)
-		if err != nil {
-			return err
-		}
+	VALUES
+		(
+			@title, @albumID, @artistID, @fsPath, @trackNumber, @duration,
+			@year, @bitrate, @size, @lastModified, @genre, strftime('%s')
+		)
+	ON CONFLICT (fs_path) DO
+	UPDATE SET
+		name = @title,
+		album_id = @albumID,
+		artist_id = @artistID,
+		number = @trackNumber,
+		duration = @duration,
+		year = @year,
+		size = @size,
+		bitrate = @bitrate,
+		fs_modified = @lastModified,
+		genre = @genre,
+		created_at = COALESCE(created_at, @lastModified)
+`
+
+// trackRecord holds the values stored into the tracks table for a single track.
+type trackRecord struct {
+	title, fsPath, genre                     string
+	trackNumber, artistID, albumID, duration int64
+	year, bitrate                            int
+	size                                     int64
+	lastModified                             time.Time
+}
 
-		lastInsertID, _ = res.LastInsertId()
-		return nil
+// newTrackRecord returns the values which are stored in the database for the
+// media `file`. Missing titles and track numbers are guessed from the file name.
+func newTrackRecord(file MediaFile, info fileInfo, artistID, albumID int64) trackRecord {
+	trackNumber := int64(file.Track())
+	if trackNumber == 0 {
+		trackNumber = helpers.GuessTrackNumber(info.FilePath)
 	}
 
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return 0, err
+	title := strings.TrimSpace(file.Title())
+	if len(title) < 1 {
+		title = filepath.Base(info.FilePath)
 	}
 
-	// Getting the track by its fs_path.
-	var trackID int64
-	work = func(db *sql.DB) error {
-		smt, err := db.Prepare(`
-			SELECT
-				id
-			FROM
-				tracks
-			WHERE
-				fs_path = ?
-		`)
-		if err != nil {
-			return err
-		}
-
-		defer smt.Close()
-
-		var id int64
-		err = smt.QueryRow(fsPath).Scan(&id)
-		if err != nil {
-			return err
-		}
+	return trackRecord{
+		title:        title,
+		fsPath:       info.FilePath,
+		trackNumber:  trackNumber,
+		artistID:     artistID,
+		albumID:      albumID,
+		duration:     file.Length().Milliseconds(),
+		year:         file.Year(),
+		bitrate:      file.Bitrate() * 1024,
+		size:         info.Size,
+		lastModified: info.Modified,
+		genre:        strings.TrimSpace(file.Genre()),
+	}
+}
 
-		trackID = id
-		return nil
+// upsertArgs returns the arguments for the upsertTrackQuery for this track. Zero
+// durations, years and bitrates and empty genres are stored as NULL.
+func (tr trackRecord) upsertArgs() []any {
+	bitrateArg := sql.Named("bitrate", tr.bitrate)
+	if tr.bitrate == 0 {
+		bitrateArg = sql.Named("bitrate", nil)
 	}
 
-	if err := lib.ExecuteDBJobAndWait(work); err != nil {
-		return 0, err
+	yearArg := sql.Named("year", tr.year)
+	if tr.year == 0 {
+		yearArg = sql.Named("year", nil)
 	}
 
-	log.Printf("Inserted id: %d, name: %s, album ID: %d, artist ID: %d, "+
-		"number: %d, dur: %d, fs_path: %s\n", trackID, title, albumID, artistID,
-		trackNumber, duration, fsPath)
-
-	if !lib.runningRescan && lastInsertID != trackID {
-		// In case this log is never seen for a long time it would mean that
-		// the LastInsertId() bug has been fixed and it is probably safe to
-		// remove the second SQL request which explicitly queries the DB for
-		// the ID.
-		log.Printf(
-			"Wrong ID returned for track `%s` by .LastInsertId(). "+
-				"Returned: %d, actual: %d.",
-			fsPath,
-			lastInsertID,
-			trackID,
-		)
+	durationArg := sql.Named("duration", tr.duration)
+	if tr.duration == 0 {
+		durationArg = sql.Named("duration", nil)
 	}
 
-	return trackID, nil
+	return []any{
+		sql.Named("title", tr.title),
+		sql.Named("albumID", tr.albumID),
+		sql.Named("artistID", tr.artistID),
+		sql.Named("fsPath", tr.fsPath),
+		sql.Named("trackNumber", tr.trackNumber),
+		durationArg,
+		yearArg,
+		sql.Named("size", tr.size),
+		bitrateArg,
+		sql.Named("lastModified", tr.lastModified.Unix()),
+		sql.Named("genre", nullIfEmpty(tr.genre)),
+	}
 }
 
 // Initialize should be run once every time a library is created. It checks for the
@@ -1517,6 +1542,19 @@ func (lib *LocalLibrary) SetArtFinder(caf art.Finder) {
 	lib.artFinder = caf
 }
 
+// SetArtworkSources sets the order in which album artwork is searched for. The
+// items are one of ArtworkSourceLocal, ArtworkSourceEmbedded and
+// ArtworkSourceInternet. Sources which are not in `sources` are not used.
+func (lib *LocalLibrary) SetArtworkSources(sources []string) {
+	lib.artworkSources = sources
+}
+
+// SetArtworkSelection sets how album artwork is chosen among the images in the
+// album directories.
+func (lib *LocalLibrary) SetArtworkSelection(selection config.ArtworkSelection) {
+	lib.artworkSelection = selection
+}
+
 // SetScaler bind a particular image scaler to this loca library.
 func (lib *LocalLibrary) SetScaler(scl scaler.Scaler) {
 	lib.imageScaler = scl
@@ -1555,6 +1593,7 @@ func NewLocalLibrary(
 	lib.database = databasePath
 	lib.sqlFilesFS = sqlFilesFS
 	lib.fs = &osFS{}
+	lib.blobs = defaultBlobStore(databasePath)
 
 	libContext, cancelFunc := context.WithCancel(ctx)
 
@@ -1574,6 +1613,14 @@ func NewLocalLibrary(
 
 	lib.watchLock = &sync.RWMutex{}
 	lib.artworkSem = make(chan struct{}, 10)
+	lib.artworkSources = []string{
+		ArtworkSourceLocal,
+		ArtworkSourceEmbedded,
+		ArtworkSourceInternet,
+	}
+	lib.artworkSelection = config.DefaultArtworkSelection()
+	lib.artistInfoTTL = defaultArtistInfoTTL
+	lib.albumInfoTTL = defaultAlbumInfoTTL
 
 	lib.cleanupLock = &sync.RWMutex{}
 
@@ -20,14 +20,15 @@ const batchLimit = 100
 
 // cleanUpDatabase walks through all database records and removes those which point
 // to files which no longer exist. It also removes albums with no tracks into them.
-func (lib *LocalLibrary) cleanUpDatabase() {
+// It returns the number of removed tracks.
+func (lib *LocalLibrary) cleanUpDatabase() int64 {
 	lib.cleanupLock.RLock()
 	alreadyRunning := lib.runningCleanup
 	lib.cleanupLock.RUnlock()
 
 	if alreadyRunning {
 		log.Println("Previous cleanup operation is already running.")
-		return
+		return 0
 	}
 
 	lib.cleanupLock.Lock()
@@ -40,22 +41,29 @@ func (lib *LocalLibrary) cleanUpDatabase() {
 		lib.cleanupLock.Unlock()
 	}()
 
-	lib.cleanupTracks()
+	removed := lib.cleanupTracks()
 	lib.cleanupAlbums()
 	lib.cleanupArtists()
+
+	if err := lib.collectBlobGarbage(lib.ctx); err != nil {
+		log.Printf("Error removing unused images: %s\n", err)
+	}
+
+	return removed
 }
 
 // cleanupTracks walks through all tracks in the database and cleanups from it any
 // which are not present on the filesystem. It does that in batches with some rest
-// between batches.
-func (lib *LocalLibrary) cleanupTracks() {
+// between batches. It returns the number of removed tracks.
+func (lib *LocalLibrary) cleanupTracks() int64 {
 	var (
-		cursor int
-		total  = lib.getTableSize("tracks")
+		cursor  int
+		removed int64
+		total   = lib.getTableSize("tracks")
 	)
 
 	if total == 0 {
-		return
+		return removed
 	}
 
 	for {
@@ -93,14 +101,16 @@ func (lib *LocalLibrary) cleanupTracks() {
 
 		if err := lib.ExecuteDBJobAndWait(getTracks); err != nil {
 			log.Printf("Error getting tracks during cleanup: %s", err)
-			return
+			return removed
 		}
 
 		cursor += batchLimit
 
-		if err := lib.checkAndRemoveTracks(tracks); err != nil {
+		batchRemoved, err := lib.checkAndRemoveTracks(tracks)
+		removed += batchRemoved
+		if err != nil {
 			log.Printf("Error cleaning up tracks: %s", err)
-			return
+			return removed
 		}
 
 		if cursor >= total {
@@ -109,6 +119,8 @@ func (lib *LocalLibrary) cleanupTracks() {
 
 		time.Sleep(cleanupBreak)
 	}
+
+	return removed
 }
 
 // cleanupAlbums walks through all albums in the database and cleanups from it any
@@ -352,12 +364,16 @@ func (lib *LocalLibrary) checkAndRemoveArtists(artistIDs []int64) error {
 //   - Tracks which no longer exist on disk.
 //   - Tracks with unclean file system path. They will be inserted again
 //     with their clean path by the normal scan.
-func (lib *LocalLibrary) checkAndRemoveTracks(tracks []track) error {
+//
+// It returns the number of removed tracks.
+func (lib *LocalLibrary) checkAndRemoveTracks(tracks []track) (int64, error) {
+	var removed int64
 	for _, track := range tracks {
 		cleanedPath := filepath.Clean(track.fsPath)
 		if cleanedPath != track.fsPath {
 			log.Printf("Removing duplicate %d - '%s'\n", track.id, track.fsPath)
 			lib.removeFile(track.fsPath)
+			removed++
 			continue
 		}
 
@@ -367,9 +383,10 @@ func (lib *LocalLibrary) checkAndRemoveTracks(tracks []track) error {
 
 		log.Printf("Removing non existent %d - '%s'\n", track.id, track.fsPath)
 		lib.removeFile(track.fsPath)
+		removed++
 	}
 
-	return nil
+	return removed, nil
 }
 
 type track struct {
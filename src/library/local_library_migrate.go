@@ -26,14 +26,17 @@ func (lib *LocalLibrary) applyMigrations() error {
 	}
 
 	_, err = migrate.ExecMax(lib.db, "sqlite3", migrations, migrate.Up, 0)
-	if err == nil {
-		return nil
-	}
-
 	if _, ok := err.(*migrate.PlanError); ok {
 		log.Printf("Error applying database migrations: %s\n", err)
-		return nil
+	} else if err != nil {
+		return fmt.Errorf("executing db migration failed: %w", err)
+	}
+
+	// Images used to be stored in the database itself. The migrations only add
+	// the columns for their hashes so the images are moved here.
+	if err := lib.moveBlobsToStore(); err != nil {
+		return fmt.Errorf("moving images into the blob store: %w", err)
 	}
 
-	return fmt.Errorf("executing db migration failed: %w", err)
+	return nil
 }
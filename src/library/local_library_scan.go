@@ -3,16 +3,113 @@ package library
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 )
 
+// ErrScanRunning is returned when a scan is requested while another one is still
+// running.
+var ErrScanRunning = errors.New("library scan is already running")
+
 // Scan scans all of the folders in paths for media files. New files will be added to the
-// database.
+// database. Files which are in the database already are read again only when their
+// size or modification time have changed. Nothing is done when there is a scan
+// running already.
 func (lib *LocalLibrary) Scan() {
+	counts, err := lib.scanStarted(false)
+	if err != nil {
+		log.Printf("Library scan not started: %s\n", err)
+		return
+	}
+
+	lib.scan(counts, true)
+}
+
+// StartScan implements the Library interface. It starts a scan in the background
+// and returns ErrScanRunning when there is already one running. A full scan reads
+// the tags of every file in the library again, see Rescan.
+func (lib *LocalLibrary) StartScan(full bool) error {
+	counts, err := lib.scanStarted(full)
+	if err != nil {
+		return err
+	}
+
+	if !full {
+		go lib.scan(counts, false)
+		return nil
+	}
+
+	go func() {
+		if err := lib.rescan(lib.ctx, counts); err != nil {
+			log.Printf("Error rescanning library: %s\n", err)
+		}
+	}()
+	return nil
+}
+
+// ScanStatus implements the Library interface. It returns the state of the current
+// or the last finished scan.
+func (lib *LocalLibrary) ScanStatus() ScanStatus {
+	lib.scanStateLock.Lock()
+	defer lib.scanStateLock.Unlock()
+
+	status := lib.scanState
+	if counts := lib.scanCounts; counts != nil {
+		status.FilesSeen = counts.seen.Load()
+		status.FilesAdded = counts.added.Load()
+		status.FilesUpdated = counts.updated.Load()
+		status.FilesRemoved = counts.removed.Load()
+		status.Errors = counts.errors.Load()
+	}
+
+	return status
+}
+
+// scanStarted marks a new scan as running and returns the counts in which its
+// progress must be recorded. ErrScanRunning is returned when there is a scan
+// running already.
+func (lib *LocalLibrary) scanStarted(full bool) (*scanCounts, error) {
+	lib.scanStateLock.Lock()
+	defer lib.scanStateLock.Unlock()
+
+	if lib.scanState.Running {
+		return nil, ErrScanRunning
+	}
+
+	lib.scanCounts = &scanCounts{}
+	lib.scanState = ScanStatus{
+		Running:    true,
+		FullRescan: full,
+		StartedAt:  time.Now().Unix(),
+	}
+
+	return lib.scanCounts, nil
+}
+
+// scanFinished marks the scan which records its progress in `counts` as finished.
+// Nothing is done if another scan has been started since then.
+func (lib *LocalLibrary) scanFinished(counts *scanCounts) {
+	lib.scanStateLock.Lock()
+	defer lib.scanStateLock.Unlock()
+
+	if lib.scanCounts != counts {
+		return
+	}
+
+	lib.scanState.Running = false
+	lib.scanState.FinishedAt = time.Now().Unix()
+}
+
+// scan is the actual implementation of Scan. Its progress is recorded in `counts`.
+// The configured initial wait is honoured only when `initialWait` is true.
+func (lib *LocalLibrary) scan(counts *scanCounts, initialWait bool) {
+	defer lib.scanFinished(counts)
+
 	// Make sure there are no other scans working at the moment
 	lib.waitScanLock.RLock()
 	lib.walkWG.Wait()
@@ -21,16 +118,16 @@ func (lib *LocalLibrary) Scan() {
 	start := time.Now()
 
 	lib.initializeWatcher()
-	initialWait := lib.ScanConfig.InitialWait
-	if !LibraryFastScan && initialWait > 0 {
-		log.Printf("Pausing initial library scan for %s as configured", initialWait)
-		time.Sleep(initialWait)
+	initialWaitDur := lib.scanConfig().InitialWait
+	if initialWait && !LibraryFastScan && initialWaitDur > 0 {
+		log.Printf("Pausing initial library scan for %s as configured", initialWaitDur)
+		time.Sleep(initialWaitDur)
 	}
 
 	lib.waitScanLock.Lock()
-	for _, path := range lib.paths {
+	for _, path := range lib.libraryPaths() {
 		lib.walkWG.Add(1)
-		go lib.scanPath(path)
+		go lib.scanPath(path, counts)
 	}
 	lib.waitScanLock.Unlock()
 
@@ -40,15 +137,65 @@ func (lib *LocalLibrary) Scan() {
 	log.Printf("Scaning took %s", time.Since(start))
 
 	start = time.Now()
-	lib.cleanUpDatabase()
+	counts.removed.Add(lib.cleanUpDatabase())
 	log.Printf("Cleaning up took %s", time.Since(start))
+
+	log.Printf(
+		"Library scan finished: %d added, %d updated, %d removed, %d errors",
+		counts.added.Load(),
+		counts.updated.Load(),
+		counts.removed.Load(),
+		counts.errors.Load(),
+	)
+}
+
+// scanCounts keeps track of the files seen and the changes made to the database
+// during a scan.
+type scanCounts struct {
+	seen    atomic.Int64
+	added   atomic.Int64
+	updated atomic.Int64
+	removed atomic.Int64
+	errors  atomic.Int64
+}
+
+// recordSeen counts a media file found during a scan. It is safe to call it on
+// a nil *scanCounts.
+func (c *scanCounts) recordSeen() {
+	if c != nil {
+		c.seen.Add(1)
+	}
+}
+
+// recordError counts a media file which could not be added during a scan. It is
+// safe to call it on a nil *scanCounts.
+func (c *scanCounts) recordError() {
+	if c != nil {
+		c.errors.Add(1)
+	}
+}
+
+// record counts the outcome of adding a single file. It is safe to call it on
+// a nil *scanCounts in which case nothing is counted.
+func (c *scanCounts) record(res addMediaResult) {
+	if c == nil {
+		return
+	}
+
+	switch res {
+	case mediaAdded:
+		c.added.Add(1)
+	case mediaUpdated:
+		c.updated.Add(1)
+	}
 }
 
 // This is the goroutine which actually scans a library path.
 // For now it ignores everything but the list of supported files. It is so
-// because jplayer cannot play anything else. Sends every suitable
-// file into the media channel
-func (lib *LocalLibrary) scanPath(scannedPath string) {
+// because jplayer cannot play anything else. Every suitable file is sent to
+// a scan pipeline which reads its tags and stores it in the database. The result
+// for every file is recorded in `counts` when it is not nil.
+func (lib *LocalLibrary) scanPath(scannedPath string, counts *scanCounts) {
 	start := time.Now()
 
 	defer func() {
@@ -56,8 +203,12 @@ func (lib *LocalLibrary) scanPath(scannedPath string) {
 		lib.walkWG.Done()
 	}()
 
-	filesPerOperation := lib.ScanConfig.FilesPerOperation
-	sleepPerOperation := lib.ScanConfig.SleepPerOperation
+	scanConfig := lib.scanConfig()
+	filesPerOperation := scanConfig.FilesPerOperation
+	sleepPerOperation := scanConfig.SleepPerOperation
+
+	pipeline := lib.newScanPipeline(counts)
+	defer pipeline.wait()
 
 	var scannedFiles int64
 
@@ -68,11 +219,15 @@ func (lib *LocalLibrary) scanPath(scannedPath string) {
 			return nil
 		}
 
+		if info.IsDir() && !lib.inLibrary(path) {
+			// The directory has been removed from the library since the walk
+			// has started.
+			return filepath.SkipDir
+		}
+
 		if !info.IsDir() && lib.isSupportedFormat(path) {
-			err := lib.AddMedia(path)
-			if err != nil {
-				log.Printf("Error adding `%s`: %s\n", path, err)
-			}
+			counts.recordSeen()
+			pipeline.add(path)
 		}
 
 		lib.watchLock.RLock()
@@ -106,12 +261,26 @@ func (lib *LocalLibrary) scanPath(scannedPath string) {
 }
 
 // Rescan goes through the database and for every file reads the meta data again from
-// the disk and updates it.
+// the disk and updates it. ErrScanRunning is returned when there is a scan running
+// already.
 func (lib *LocalLibrary) Rescan(ctx context.Context) error {
-	lib.runningRescan = true
-	defer func() {
-		lib.runningRescan = false
-	}()
+	counts, err := lib.scanStarted(true)
+	if err != nil {
+		return err
+	}
+
+	return lib.rescan(ctx, counts)
+}
+
+// rescan is the actual implementation of Rescan. Its progress is recorded in
+// `counts`.
+func (lib *LocalLibrary) rescan(ctx context.Context, counts *scanCounts) error {
+	defer lib.scanFinished(counts)
+
+	// Make sure the files found by other walks are in the database already
+	lib.waitScanLock.RLock()
+	lib.walkWG.Wait()
+	lib.waitScanLock.RUnlock()
 
 	const batchSize = 500
 	var cursor int64
@@ -127,26 +296,33 @@ func (lib *LocalLibrary) Rescan(ctx context.Context) error {
 		cursor += int64(len(mediaFiles))
 
 		for _, fileName := range mediaFiles {
+			counts.recordSeen()
+
 			st, err := os.Stat(fileName)
 			if err != nil {
 				log.Printf("Filesystem error (stat) for %s: %s\n", fileName, err)
+				counts.recordError()
 				continue
 			}
 
 			file, err := parseFileTags(fileName)
 			if err != nil {
 				log.Printf("Parsing tags error for %s: %s\n", fileName, err)
+				counts.recordError()
 				continue
 			}
 
 			fi := fileInfo{
 				Size:     st.Size(),
 				FilePath: fileName,
-				Modified: st.ModTime(),
+				Modified: lib.mediaModTime(fileName, st),
 			}
 			if err := lib.insertMediaIntoDatabase(file, fi); err != nil {
 				log.Printf("failed updating file %s: %s\n", fileName, err)
+				counts.recordError()
+				continue
 			}
+			counts.record(mediaUpdated)
 		}
 	}
 
@@ -0,0 +1,319 @@
+package library
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWriteBatchSize is the number of tracks inserted in a single transaction
+// during scanning when ScanSection.WriteBatchSize is not set.
+const defaultWriteBatchSize = 200
+
+// scanBatchFlushInterval is the maximum time the scan writer waits for a batch to
+// be filled before writing whatever it has. This makes sure files are not stuck in
+// the writer while the walking of the directories is paused between operations.
+const scanBatchFlushInterval = time.Second
+
+// scannedMedia is a changed media file ready to be written into the database
+// together with its lyrics and release tags.
+type scannedMedia struct {
+	changedMedia
+
+	lyricsPlain  string
+	lyricsSynced string
+	lyricsLang   string
+
+	release releaseTags
+}
+
+// scanPipeline reads the files found during a scan and stores them in the database.
+//
+// Files sent to it are parsed concurrently by a number of workers. The results are
+// then collected by a single writer which inserts them into the database in batches,
+// each one in its own transaction.
+type scanPipeline struct {
+	lib    *LocalLibrary
+	counts *scanCounts
+
+	paths    chan string
+	parsed   chan scannedMedia
+	parseWG  sync.WaitGroup
+	writerWG sync.WaitGroup
+}
+
+// newScanPipeline starts the workers and writer of a scan pipeline. The results
+// are recorded in `counts` when it is not nil. The caller must call wait once it
+// has added all files.
+func (lib *LocalLibrary) newScanPipeline(counts *scanCounts) *scanPipeline {
+	workers := lib.scanConfig().ParseWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	sp := &scanPipeline{
+		lib:    lib,
+		counts: counts,
+		paths:  make(chan string, workers),
+		parsed: make(chan scannedMedia, workers),
+	}
+
+	sp.parseWG.Add(workers)
+	for range workers {
+		go sp.parseWorker()
+	}
+
+	sp.writerWG.Add(1)
+	go sp.writer()
+
+	return sp
+}
+
+// add sends the media file at `path` for reading. It blocks while all workers
+// are busy.
+func (sp *scanPipeline) add(path string) {
+	sp.paths <- path
+}
+
+// wait blocks until all of the added files are stored in the database. No more
+// files may be added after calling it.
+func (sp *scanPipeline) wait() {
+	close(sp.paths)
+	sp.parseWG.Wait()
+	close(sp.parsed)
+	sp.writerWG.Wait()
+}
+
+func (sp *scanPipeline) parseWorker() {
+	defer sp.parseWG.Done()
+
+	for path := range sp.paths {
+		media, err := sp.lib.readMediaIfChanged(path)
+		if err != nil {
+			log.Printf("Error adding `%s`: %s\n", path, err)
+			sp.counts.recordError()
+			continue
+		}
+		if media.result == mediaUnchanged {
+			continue
+		}
+
+		sp.parsed <- sp.lib.readLyricsAndReleaseTags(media)
+	}
+}
+
+func (sp *scanPipeline) writer() {
+	defer sp.writerWG.Done()
+
+	batchSize := sp.lib.scanConfig().WriteBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultWriteBatchSize
+	}
+
+	batch := make([]scannedMedia, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		sp.lib.insertMediaBatch(batch, sp.counts)
+		batch = batch[:0]
+	}
+
+	ticker := time.NewTicker(scanBatchFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case media, ok := <-sp.parsed:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, media)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// readLyricsAndReleaseTags reads the lyrics and release tags of the changed media
+// file. They are not in the tags parsed by readMediaIfChanged.
+func (lib *LocalLibrary) readLyricsAndReleaseTags(media changedMedia) scannedMedia {
+	scanned := scannedMedia{changedMedia: media}
+	scanned.lyricsPlain, scanned.lyricsSynced, scanned.lyricsLang =
+		lib.readLyrics(media.info.FilePath)
+	scanned.release = lib.readReleaseTags(media.info.FilePath)
+
+	return scanned
+}
+
+// insertMediaBatch stores all media files in `batch` into the database using
+// a single transaction. When the transaction fails the files are inserted one
+// by one so that a single bad file does not prevent the rest from being added.
+func (lib *LocalLibrary) insertMediaBatch(batch []scannedMedia, counts *scanCounts) {
+	err := lib.writeScannedMedia(batch...)
+	if err == nil {
+		for _, media := range batch {
+			counts.record(media.result)
+		}
+		return
+	}
+
+	if lib.ctx.Err() != nil {
+		return
+	}
+
+	log.Printf("Writing batch of %d files failed, adding them one by one: %s\n",
+		len(batch), err)
+
+	for _, media := range batch {
+		if err := lib.writeScannedMedia(media); err != nil {
+			log.Printf("Error adding `%s`: %s\n", media.info.FilePath, err)
+			counts.recordError()
+			continue
+		}
+		counts.record(media.result)
+	}
+}
+
+// writeScannedMedia stores all of the media files into the database using a single
+// transaction. Nothing is stored when any of them fails.
+func (lib *LocalLibrary) writeScannedMedia(media ...scannedMedia) error {
+	work := func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("starting transaction: %w", err)
+		}
+
+		for _, m := range media {
+			if err := insertScannedMedia(tx, m); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("inserting %s: %w", m.info.FilePath, err)
+			}
+		}
+
+		return tx.Commit()
+	}
+
+	return lib.ExecuteDBJobAndWait(work)
+}
+
+// insertScannedMedia stores a single media file with its artist, album, lyrics and
+// release tags using `db`, which could be either a database or a transaction.
+func insertScannedMedia(db sqlExecer, media scannedMedia) error {
+	artistID, err := setArtistID(db, strings.TrimSpace(media.file.Artist()))
+	if err != nil {
+		return fmt.Errorf("artist: %w", err)
+	}
+
+	albumID, err := setAlbumID(db,
+		strings.TrimSpace(media.file.Album()),
+		filepath.Dir(media.info.FilePath),
+	)
+	if err != nil {
+		return fmt.Errorf("album: %w", err)
+	}
+
+	track := newTrackRecord(media.file, media.info, artistID, albumID)
+	trackID, err := setTrackID(db, track)
+	if err != nil {
+		return fmt.Errorf("track: %w", err)
+	}
+
+	err = storeLyrics(db, trackID,
+		media.lyricsPlain, media.lyricsSynced, media.lyricsLang,
+	)
+	if err != nil {
+		return fmt.Errorf("lyrics: %w", err)
+	}
+
+	if err := storeReleaseTags(db, trackID, media.release); err != nil {
+		return fmt.Errorf("release tags: %w", err)
+	}
+
+	return nil
+}
+
+// setArtistID returns the ID of the artist with name `artist`. The artist is
+// inserted when it is new to the library.
+func setArtistID(db sqlExecer, artist string) (int64, error) {
+	if len(artist) < 1 {
+		artist = UnknownLabel
+	}
+
+	return selectOrInsertID(db,
+		`SELECT id FROM artists WHERE name = ?`,
+		`INSERT INTO artists (name) VALUES (?)`,
+		artist,
+	)
+}
+
+// setAlbumID returns the ID of the album with name `album` in the directory
+// `fsPath`. The album is inserted when it is new to the library. Albums with the
+// same name but in different directories have separate IDs.
+func setAlbumID(db sqlExecer, album string, fsPath string) (int64, error) {
+	if len(album) < 1 {
+		album = UnknownLabel
+	}
+
+	return selectOrInsertID(db,
+		`SELECT id FROM albums WHERE name = ? AND fs_path = ?`,
+		`INSERT INTO albums (name, fs_path) VALUES (?, ?)`,
+		album, fsPath,
+	)
+}
+
+// setTrackID inserts the track or updates it when a track with the same file
+// system path is already in the library. Returns the ID of the track.
+func setTrackID(db sqlExecer, track trackRecord) (int64, error) {
+	if _, err := db.Exec(upsertTrackQuery, track.upsertArgs()...); err != nil {
+		return 0, err
+	}
+
+	var trackID int64
+	row := db.QueryRow(`SELECT id FROM tracks WHERE fs_path = ?`, track.fsPath)
+	if err := row.Scan(&trackID); err != nil {
+		return 0, fmt.Errorf("getting track ID: %w", err)
+	}
+
+	return trackID, nil
+}
+
+// selectOrInsertID returns the ID found by `selectQuery`. When nothing is found
+// then `insertQuery` is executed and the ID is selected again. Both queries are
+// executed with the same arguments.
+func selectOrInsertID(
+	db sqlExecer,
+	selectQuery, insertQuery string,
+	args ...any,
+) (int64, error) {
+	var id int64
+	err := db.QueryRow(selectQuery, args...).Scan(&id)
+	if err == nil {
+		return id, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	if _, err := db.Exec(insertQuery, args...); err != nil {
+		return 0, err
+	}
+
+	// The ID is selected instead of using sql.Result.LastInsertId() since it is
+	// not always correct with the SQL driver used.
+	if err := db.QueryRow(selectQuery, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
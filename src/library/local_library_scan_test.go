@@ -0,0 +1,372 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestScanSkipsUnchangedFiles checks that scanning the library again reads only the
+// files which were changed since the last scan.
+func TestScanSkipsUnchangedFiles(t *testing.T) {
+	ctx := context.Background()
+
+	testLibraryPath, err := getTestLibraryPath()
+	if err != nil {
+		t.Fatalf("Failed to get test library path: %s", err)
+	}
+
+	tmpDir := t.TempDir()
+	trackPath := filepath.Join(tmpDir, "track.mp3")
+	err = copyFile(filepath.Join(testLibraryPath, "test_file_one.mp3"), trackPath)
+	if err != nil {
+		t.Fatalf("copying test file: %s", err)
+	}
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	lib.DisableWatching()
+	lib.AddLibraryPath(tmpDir)
+	lib.Scan()
+
+	trackName := func() string {
+		var name string
+		row := lib.db.QueryRow(`SELECT name FROM tracks WHERE fs_path = ?`, trackPath)
+		if err := row.Scan(&name); err != nil {
+			t.Fatalf("getting track name: %s", err)
+		}
+		return name
+	}
+
+	expectedName := trackName()
+	if expectedName == "" {
+		t.Fatalf("track name was not read from the file")
+	}
+
+	// Change the track in the database only. A scan must not overwrite it as long
+	// as the file itself has not changed.
+	_, err = lib.db.Exec(`UPDATE tracks SET name = 'Changed' WHERE fs_path = ?`, trackPath)
+	if err != nil {
+		t.Fatalf("changing track name: %s", err)
+	}
+
+	lib.Scan()
+
+	if name := trackName(); name != "Changed" {
+		t.Errorf("expected unchanged file to be skipped but its name is `%s`", name)
+	}
+
+	newModTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(trackPath, newModTime, newModTime); err != nil {
+		t.Fatalf("changing file modification time: %s", err)
+	}
+
+	lib.Scan()
+
+	if name := trackName(); name != expectedName {
+		t.Errorf("expected modified file name to be `%s` but it was `%s`",
+			expectedName, name)
+	}
+
+	// Adding lyrics next to the file while it is not watched must be noticed on
+	// the next scan even though the media file itself has not changed.
+	lrcPath := filepath.Join(tmpDir, "track.lrc")
+	if err := os.WriteFile(lrcPath, []byte("[00:01.00]One\n"), 0600); err != nil {
+		t.Fatalf("creating LRC file: %s", err)
+	}
+	lrcModTime := newModTime.Add(time.Hour)
+	if err := os.Chtimes(lrcPath, lrcModTime, lrcModTime); err != nil {
+		t.Fatalf("changing LRC file modification time: %s", err)
+	}
+
+	lib.Scan()
+
+	var trackID int64
+	row := lib.db.QueryRow(`SELECT id FROM tracks WHERE fs_path = ?`, trackPath)
+	if err := row.Scan(&trackID); err != nil {
+		t.Fatalf("getting track ID: %s", err)
+	}
+
+	lyrics, err := lib.GetTrackLyrics(ctx, trackID)
+	if err != nil {
+		t.Fatalf("expected lyrics from the new LRC file but got: %s", err)
+	}
+	if lyrics.Plain != "One" {
+		t.Errorf("unexpected lyrics from the new LRC file: %q", lyrics.Plain)
+	}
+}
+
+// TestRemoveLibraryPath checks that removing a library directory removes its files
+// from the database but keeps the ones which are in another library directory.
+func TestRemoveLibraryPath(t *testing.T) {
+	ctx := context.Background()
+
+	testLibraryPath, err := getTestLibraryPath()
+	if err != nil {
+		t.Fatalf("Failed to get test library path: %s", err)
+	}
+
+	var (
+		removedDir = t.TempDir()
+		nestedDir  = filepath.Join(removedDir, "nested")
+		keptDir    = t.TempDir()
+	)
+	if err := os.Mkdir(nestedDir, 0700); err != nil {
+		t.Fatalf("creating nested directory: %s", err)
+	}
+
+	var (
+		removedTrack = filepath.Join(removedDir, "removed.mp3")
+		nestedTrack  = filepath.Join(nestedDir, "nested.mp3")
+		keptTrack    = filepath.Join(keptDir, "kept.mp3")
+	)
+	for _, trackPath := range []string{removedTrack, nestedTrack, keptTrack} {
+		err := copyFile(filepath.Join(testLibraryPath, "test_file_one.mp3"), trackPath)
+		if err != nil {
+			t.Fatalf("copying test file: %s", err)
+		}
+	}
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	lib.DisableWatching()
+	lib.AddLibraryPath(removedDir)
+	lib.AddLibraryPath(nestedDir)
+	lib.AddLibraryPath(keptDir)
+	lib.Scan()
+
+	lib.RemoveLibraryPath(removedDir)
+
+	if paths := lib.libraryPaths(); len(paths) != 2 {
+		t.Errorf("expected two library paths after removing but got %v", paths)
+	}
+
+	tracks := make(map[string]bool)
+	rows, err := lib.db.Query(`SELECT fs_path FROM tracks`)
+	if err != nil {
+		t.Fatalf("selecting tracks: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fsPath string
+		if err := rows.Scan(&fsPath); err != nil {
+			t.Fatalf("scanning track: %s", err)
+		}
+		tracks[fsPath] = true
+	}
+
+	if tracks[removedTrack] {
+		t.Errorf("track in the removed library path was not removed")
+	}
+	if !tracks[nestedTrack] {
+		t.Errorf("track in the nested library path was removed")
+	}
+	if !tracks[keptTrack] {
+		t.Errorf("track in another library path was removed")
+	}
+}
+
+// TestScanPathSkipsRemovedPaths checks that walks do not add files from directories
+// which are no longer in the library.
+func TestScanPathSkipsRemovedPaths(t *testing.T) {
+	ctx := context.Background()
+
+	testLibraryPath, err := getTestLibraryPath()
+	if err != nil {
+		t.Fatalf("Failed to get test library path: %s", err)
+	}
+
+	removedDir := t.TempDir()
+	err = copyFile(
+		filepath.Join(testLibraryPath, "test_file_one.mp3"),
+		filepath.Join(removedDir, "removed.mp3"),
+	)
+	if err != nil {
+		t.Fatalf("copying test file: %s", err)
+	}
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	lib.DisableWatching()
+	lib.AddLibraryPath(removedDir)
+	lib.RemoveLibraryPath(removedDir)
+
+	lib.walkWG.Add(1)
+	lib.scanPath(removedDir, nil)
+
+	var count int
+	if err := lib.db.QueryRow(`SELECT COUNT(*) FROM tracks`).Scan(&count); err != nil {
+		t.Fatalf("counting tracks: %s", err)
+	}
+	if count != 0 {
+		t.Errorf("expected no tracks from the removed path but got %d", count)
+	}
+}
+
+// BenchmarkScan measures the time needed for the initial scan of a library with
+// different number of parse workers and write batch sizes. The library consists of
+// many copies of the files in the test_files directory.
+func BenchmarkScan(b *testing.B) {
+	const copies = 50
+
+	testLibraryPath, err := getTestLibraryPath()
+	if err != nil {
+		b.Fatalf("Failed to get test library path: %s", err)
+	}
+
+	fixtures := []string{
+		filepath.Join(testLibraryPath, "test_file_one.mp3"),
+		filepath.Join(testLibraryPath, "test_file_two.mp3"),
+		filepath.Join(testLibraryPath, "folder_one", "third_file.mp3"),
+	}
+
+	libraryDir := b.TempDir()
+	for i := range copies {
+		albumDir := filepath.Join(libraryDir, fmt.Sprintf("album_%d", i))
+		if err := os.Mkdir(albumDir, 0700); err != nil {
+			b.Fatalf("creating album directory: %s", err)
+		}
+
+		for _, fixture := range fixtures {
+			err := copyFile(fixture, filepath.Join(albumDir, filepath.Base(fixture)))
+			if err != nil {
+				b.Fatalf("copying test file: %s", err)
+			}
+		}
+	}
+
+	// The clean-up after the scan should not be resting between its batches.
+	defer func(prev time.Duration) { cleanupBreak = prev }(cleanupBreak)
+	cleanupBreak = 0
+
+	tests := []struct {
+		workers   int
+		batchSize int
+	}{
+		{workers: 1, batchSize: 1},
+		{workers: 1, batchSize: defaultWriteBatchSize},
+		{workers: 4, batchSize: 1},
+		{workers: 4, batchSize: defaultWriteBatchSize},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("workers=%d/batch=%d", test.workers, test.batchSize)
+		b.Run(name, func(b *testing.B) {
+			for range b.N {
+				b.StopTimer()
+				lib, err := NewLocalLibrary(
+					context.Background(),
+					SQLiteMemoryFile,
+					getTestMigrationFiles(),
+				)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if err := lib.Initialize(); err != nil {
+					b.Fatalf("Initializing library: %s", err)
+				}
+
+				lib.ScanConfig.ParseWorkers = test.workers
+				lib.ScanConfig.WriteBatchSize = test.batchSize
+				lib.DisableWatching()
+				lib.AddLibraryPath(libraryDir)
+				b.StartTimer()
+
+				lib.Scan()
+
+				b.StopTimer()
+				_ = lib.Truncate()
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// TestStartScanAndStatus checks that scans started on demand are reflected in the
+// scan status and that only one of them may run at a time.
+func TestStartScanAndStatus(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib := getPathedLibrary(ctx, t)
+	defer func() { _ = lib.Truncate() }()
+	lib.DisableWatching()
+
+	if status := lib.ScanStatus(); status.Running || status.StartedAt != 0 {
+		t.Fatalf("expected no scan before starting one but got %+v", status)
+	}
+
+	if err := lib.StartScan(false); err != nil {
+		t.Fatalf("starting scan: %s", err)
+	}
+
+	if err := lib.StartScan(true); !errors.Is(err, ErrScanRunning) {
+		t.Errorf("expected scan running error but got: %v", err)
+	}
+	if err := lib.Rescan(ctx); !errors.Is(err, ErrScanRunning) {
+		t.Errorf("expected scan running error for rescan but got: %v", err)
+	}
+
+	var status ScanStatus
+	for {
+		status = lib.ScanStatus()
+		if !status.Running {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("scan did not finish in time")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if status.FullRescan {
+		t.Errorf("expected a normal scan but it was a full rescan")
+	}
+	if status.FilesSeen != 3 || status.FilesAdded != 3 {
+		t.Errorf("expected 3 files seen and added but got %+v", status)
+	}
+	if status.StartedAt == 0 || status.FinishedAt < status.StartedAt {
+		t.Errorf("wrong scan start and finish times: %+v", status)
+	}
+
+	if err := lib.Rescan(ctx); err != nil {
+		t.Fatalf("rescanning: %s", err)
+	}
+
+	status = lib.ScanStatus()
+	if !status.FullRescan || status.FilesUpdated != 3 || status.Running {
+		t.Errorf("unexpected status after full rescan: %+v", status)
+	}
+}
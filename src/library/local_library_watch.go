@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
+	"path/filepath"
 
 	"github.com/howeyc/fsnotify"
 )
@@ -78,6 +79,7 @@ func (lib *LocalLibrary) watchEventRoutine() {
 //  * deleted files should be removed from the library
 //  * deleted directories should be unwatched
 //  * modfied files should be updated in the database
+//  * created, modified or deleted LRC files should update the tracks' lyrics
 //  * renamed ...
 func (lib *LocalLibrary) handleWatchEvent(event *fsnotify.FileEvent) {
 
@@ -86,6 +88,11 @@ func (lib *LocalLibrary) handleWatchEvent(event *fsnotify.FileEvent) {
 		return
 	}
 
+	if isLyricsFile(event.Name) {
+		lib.updateSidecarLyrics(event.Name)
+		return
+	}
+
 	st, stErr := fs.Stat(lib.fs, event.Name)
 	if stErr != nil && !event.IsRename() && !event.IsDelete() {
 		log.Printf("Watch event stat received error: %s\n", stErr.Error())
@@ -119,7 +126,7 @@ func (lib *LocalLibrary) handleWatchEvent(event *fsnotify.FileEvent) {
 		lib.walkWG.Add(1)
 		lib.waitScanLock.Unlock()
 
-		lib.scanPath(event.Name)
+		lib.scanPath(event.Name, nil)
 		return
 	}
 
@@ -143,6 +150,37 @@ func (lib *LocalLibrary) handleWatchEvent(event *fsnotify.FileEvent) {
 	}
 }
 
+// unwatchDirectory stops watching `dirPath` and all directories in it except the
+// ones in `except` directories.
+func (lib *LocalLibrary) unwatchDirectory(dirPath string, except []string) {
+	lib.watchLock.RLock()
+	defer lib.watchLock.RUnlock()
+
+	if lib.watch == nil {
+		return
+	}
+
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+
+		for _, exceptPath := range except {
+			if isInDirectory(path, exceptPath) {
+				return filepath.SkipDir
+			}
+		}
+
+		// Not every directory has been watched. For example when the scan which
+		// adds the watches has not reached it yet.
+		_ = lib.watch.RemoveWatch(path)
+		return nil
+	})
+	if err != nil {
+		log.Printf("error removing watchers for %s: %s\n", dirPath, err)
+	}
+}
+
 // DisableWatching makes it so that the library will no longer add file system
 // watching for new directories.
 func (lib *LocalLibrary) DisableWatching() {
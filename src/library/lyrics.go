@@ -0,0 +1,413 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+)
+
+// ErrLyricsNotFound is returned when there are no lyrics stored for a track.
+var ErrLyricsNotFound = fmt.Errorf("Lyrics: %w", ErrNotFound)
+
+// Lyrics contains the words of a single track. They may be synchronised with
+// the playback of the track, plain text or both.
+type Lyrics struct {
+	// TrackID is the ID of the track these lyrics are for.
+	TrackID int64 `json:"track_id"`
+
+	// Language is the ISO 639-2 code for the language of the lyrics. It is
+	// empty when the language is not known.
+	Language string `json:"lang,omitempty"`
+
+	// Plain is the lyrics text without any timing information. For tracks
+	// which only have synchronised lyrics this is the text of all of their
+	// lines.
+	Plain string `json:"plain"`
+
+	// Synced is the list of lines with their timing. It is empty when only
+	// plain lyrics are known for the track.
+	Synced []LyricsLine `json:"synced,omitempty"`
+}
+
+// LyricsLine is a single line of synchronised lyrics.
+type LyricsLine struct {
+	// Start is the time in milliseconds since the beginning of the track
+	// at which this line starts.
+	Start int64 `json:"start"`
+
+	// Text is the actual words in this line.
+	Text string `json:"text"`
+}
+
+// lyricsSidecarExt is the file extension of the LRC files which hold synchronised
+// lyrics next to the media files.
+const lyricsSidecarExt = ".lrc"
+
+var (
+	lrcTimeTag   = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	lrcIDTag     = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	lrcWordTimes = regexp.MustCompile(`<\d+:\d{1,2}(?:[.:]\d{1,3})?>`)
+)
+
+// GetTrackLyrics returns the lyrics for the track with ID `trackID`. It returns
+// ErrLyricsNotFound when there are no lyrics stored for this track.
+func (lib *LocalLibrary) GetTrackLyrics(
+	ctx context.Context,
+	trackID int64,
+) (Lyrics, error) {
+	var (
+		plain  sql.NullString
+		synced sql.NullString
+		lang   sql.NullString
+	)
+
+	work := func(db *sql.DB) error {
+		row := db.QueryRowContext(ctx, `
+			SELECT
+				plain,
+				synced,
+				language
+			FROM
+				tracks_lyrics
+			WHERE
+				track_id = ?
+		`, trackID)
+
+		err := row.Scan(&plain, &synced, &lang)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLyricsNotFound
+		} else if err != nil {
+			return fmt.Errorf("sql query for lyrics failed: %w", err)
+		}
+
+		return nil
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return Lyrics{}, err
+	}
+
+	lyrics := Lyrics{
+		TrackID:  trackID,
+		Language: lang.String,
+		Plain:    plain.String,
+	}
+	if synced.Valid {
+		lyrics.Synced, _ = parseLRC(synced.String)
+	}
+
+	if lyrics.Plain == "" && len(lyrics.Synced) > 0 {
+		lines := make([]string, 0, len(lyrics.Synced))
+		for _, line := range lyrics.Synced {
+			lines = append(lines, line.Text)
+		}
+		lyrics.Plain = strings.Join(lines, "\n")
+	}
+
+	return lyrics, nil
+}
+
+// findAndSaveLyrics looks for lyrics for the track at `trackPath` and stores them
+// in the database. Lyrics are searched for in the file tags and in a LRC file
+// with the same name next to the track. Previously stored lyrics for the track
+// are removed when none are found any more.
+func (lib *LocalLibrary) findAndSaveLyrics(trackID int64, trackPath string) error {
+	plain, synced, lang := lib.readLyrics(trackPath)
+
+	work := func(db *sql.DB) error {
+		return storeLyrics(db, trackID, plain, synced, lang)
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return fmt.Errorf("storing lyrics: %w", err)
+	}
+
+	return nil
+}
+
+// storeLyrics saves the lyrics for a track using `db`, which could be either
+// a database or a transaction. Lyrics for the track are removed when both `plain`
+// and `synced` are empty.
+func storeLyrics(db sqlExecer, trackID int64, plain, synced, lang string) error {
+	if plain == "" && synced == "" {
+		_, err := db.Exec(`
+			DELETE FROM tracks_lyrics
+			WHERE track_id = ?
+		`, trackID)
+		return err
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO tracks_lyrics (track_id, plain, synced, language, updated_at)
+		VALUES (@trackID, @plain, @synced, @lang, @updatedAt)
+		ON CONFLICT (track_id) DO
+		UPDATE SET
+			plain = @plain,
+			synced = @synced,
+			language = @lang,
+			updated_at = @updatedAt
+	`,
+		sql.Named("trackID", trackID),
+		sql.Named("plain", nullIfEmpty(plain)),
+		sql.Named("synced", nullIfEmpty(synced)),
+		sql.Named("lang", nullIfEmpty(lang)),
+		sql.Named("updatedAt", time.Now().Unix()),
+	)
+	return err
+}
+
+// mediaModTime returns the modification time of the media file at `trackPath`
+// which has file info `st`. When there is a newer LRC file next to it its time is
+// returned instead. So the track is read again on the next scan when its lyrics
+// file changes while Euterpe is not running.
+func (lib *LocalLibrary) mediaModTime(trackPath string, st fs.FileInfo) time.Time {
+	modified := st.ModTime()
+
+	sidecarBase := strings.TrimSuffix(trackPath, filepath.Ext(trackPath))
+	for _, ext := range []string{lyricsSidecarExt, strings.ToUpper(lyricsSidecarExt)} {
+		lrcSt, err := fs.Stat(lib.fs, sidecarBase+ext)
+		if err != nil {
+			continue
+		}
+
+		if lrcSt.ModTime().After(modified) {
+			modified = lrcSt.ModTime()
+		}
+	}
+
+	return modified
+}
+
+// readLyrics returns the plain and synchronised (in LRC format) lyrics for the
+// track at `trackPath` and their language. Empty strings are returned for
+// everything which was not found.
+func (lib *LocalLibrary) readLyrics(trackPath string) (plain, synced, lang string) {
+	sidecarBase := strings.TrimSuffix(trackPath, filepath.Ext(trackPath))
+	for _, ext := range []string{lyricsSidecarExt, strings.ToUpper(lyricsSidecarExt)} {
+		lrc, err := fs.ReadFile(lib.fs, sidecarBase+ext)
+		if err != nil {
+			continue
+		}
+
+		lines, lrcLang := parseLRC(string(lrc))
+		if len(lines) == 0 {
+			continue
+		}
+
+		synced = string(lrc)
+		lang = lrcLang
+		break
+	}
+
+	embedded, embeddedLang := lib.readEmbeddedLyrics(trackPath)
+	if embeddedLang != "" {
+		lang = embeddedLang
+	}
+
+	// Some taggers store synchronised lyrics in the unsynchronised lyrics tags.
+	// They are still useful as long as there is no LRC file next to the track.
+	if lines, _ := parseLRC(embedded); len(lines) > 0 {
+		if synced == "" {
+			synced = embedded
+		}
+		return plain, synced, lang
+	}
+
+	return strings.TrimSpace(embedded), synced, lang
+}
+
+// readEmbeddedLyrics returns the lyrics stored in the tags of the media file
+// and their language if it is known.
+func (lib *LocalLibrary) readEmbeddedLyrics(trackPath string) (string, string) {
+	fh, err := lib.fs.Open(trackPath)
+	if err != nil {
+		return "", ""
+	}
+	defer fh.Close()
+
+	rs, ok := fh.(io.ReadSeeker)
+	if !ok {
+		return "", ""
+	}
+
+	md, err := tag.ReadFrom(rs)
+	if err != nil {
+		return "", ""
+	}
+
+	raw := md.Raw()
+	for _, frame := range []string{"USLT", "ULT"} {
+		comm, ok := raw[frame].(*tag.Comm)
+		if !ok || comm.Text == "" {
+			continue
+		}
+
+		lang := strings.ToLower(comm.Language)
+		if strings.Trim(lang, "x\x00 ") == "" {
+			lang = ""
+		}
+		return comm.Text, lang
+	}
+
+	if lyrics := md.Lyrics(); lyrics != "" {
+		return lyrics, ""
+	}
+
+	for _, key := range []string{"unsyncedlyrics", "UNSYNCEDLYRICS"} {
+		if lyrics, ok := raw[key].(string); ok && lyrics != "" {
+			return lyrics, ""
+		}
+	}
+
+	return "", ""
+}
+
+// updateSidecarLyrics reads again the lyrics for all tracks which could be using
+// the LRC file at `lrcPath`. It is used when such a file is created, changed or
+// removed.
+func (lib *LocalLibrary) updateSidecarLyrics(lrcPath string) {
+	base := strings.TrimSuffix(lrcPath, filepath.Ext(lrcPath))
+
+	tracks := make(map[int64]string)
+	work := func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT
+				id,
+				fs_path
+			FROM
+				tracks
+			WHERE
+				fs_path LIKE ?
+		`, base+".%")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				trackID int64
+				fsPath  string
+			)
+			if err := rows.Scan(&trackID, &fsPath); err != nil {
+				return err
+			}
+
+			if strings.TrimSuffix(fsPath, filepath.Ext(fsPath)) != base {
+				continue
+			}
+			tracks[trackID] = fsPath
+		}
+
+		return rows.Err()
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		log.Printf("Error finding tracks for lyrics file %s: %s\n", lrcPath, err)
+		return
+	}
+
+	for trackID, fsPath := range tracks {
+		if err := lib.findAndSaveLyrics(trackID, fsPath); err != nil {
+			log.Printf("Error updating lyrics for %s: %s\n", fsPath, err)
+		}
+	}
+}
+
+// isLyricsFile returns true when the file at `path` is a LRC file.
+func isLyricsFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), lyricsSidecarExt)
+}
+
+// parseLRC parses lyrics in the LRC format. It returns the lyrics lines ordered
+// by their start time and the language of the lyrics if it is set in the ID tags.
+// The "offset" ID tag is applied to the start times of the returned lines.
+//
+// See https://en.wikipedia.org/wiki/LRC_(file_format) for the format.
+func parseLRC(text string) ([]LyricsLine, string) {
+	var (
+		lines  []LyricsLine
+		offset int64
+		lang   string
+	)
+
+	for _, rawLine := range strings.Split(text, "\n") {
+		rawLine = strings.TrimSpace(rawLine)
+
+		var starts []int64
+		for {
+			match := lrcTimeTag.FindStringSubmatch(rawLine)
+			if match == nil {
+				break
+			}
+
+			starts = append(starts, lrcTimestamp(match[1], match[2], match[3]))
+			rawLine = rawLine[len(match[0]):]
+		}
+
+		if len(starts) == 0 {
+			match := lrcIDTag.FindStringSubmatch(rawLine)
+			if match == nil {
+				continue
+			}
+
+			value := strings.TrimSpace(match[2])
+			switch strings.ToLower(match[1]) {
+			case "offset":
+				offset, _ = strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+			case "la", "lang":
+				lang = value
+			}
+			continue
+		}
+
+		lineText := strings.TrimSpace(lrcWordTimes.ReplaceAllString(rawLine, ""))
+		for _, start := range starts {
+			lines = append(lines, LyricsLine{
+				Start: max(start-offset, 0),
+				Text:  lineText,
+			})
+		}
+	}
+
+	slices.SortStableFunc(lines, func(a, b LyricsLine) int {
+		return int(a.Start - b.Start)
+	})
+
+	return lines, lang
+}
+
+// lrcTimestamp converts the minutes, seconds and fractions of a second from
+// a LRC time tag into milliseconds.
+func lrcTimestamp(minutes, seconds, fraction string) int64 {
+	mins, _ := strconv.ParseInt(minutes, 10, 64)
+	secs, _ := strconv.ParseInt(seconds, 10, 64)
+
+	var millis int64
+	if fraction != "" {
+		// "5" is 500ms, "05" is 50ms and "005" is 5ms.
+		fraction += strings.Repeat("0", 3-len(fraction))
+		millis, _ = strconv.ParseInt(fraction, 10, 64)
+	}
+
+	return (time.Duration(mins)*time.Minute +
+		time.Duration(secs)*time.Second).Milliseconds() + millis
+}
+
+// nullIfEmpty returns nil for empty strings so that they are stored as NULL
+// in the database.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
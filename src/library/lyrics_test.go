@@ -0,0 +1,124 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseLRC checks that LRC files are parsed into ordered lyrics lines with
+// their offset applied.
+func TestParseLRC(t *testing.T) {
+	const lrc = `[ar:Some Artist]
+[ti:Some Title]
+[la:eng]
+[offset:+200]
+
+[00:12.00]First line
+[00:05.5]<00:05.50>Zeroth <00:06.00>line
+[00:20.123][01:02.00]Chorus
+not a lyrics line
+`
+
+	lines, lang := parseLRC(lrc)
+	if lang != "eng" {
+		t.Errorf(`expected language "eng" but got "%s"`, lang)
+	}
+
+	expected := []LyricsLine{
+		{Start: 5300, Text: "Zeroth line"},
+		{Start: 11800, Text: "First line"},
+		{Start: 19923, Text: "Chorus"},
+		{Start: 61800, Text: "Chorus"},
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines but got %d: %+v", len(expected), len(lines), lines)
+	}
+
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("line %d: expected %+v but got %+v", i, expected[i], line)
+		}
+	}
+
+	if lines, _ := parseLRC("just some\nplain lyrics"); len(lines) != 0 {
+		t.Errorf("expected no synced lines for plain text but got %+v", lines)
+	}
+}
+
+// TestLyricsFromSidecarFile makes sure that LRC files next to tracks are stored
+// as their synchronised lyrics.
+func TestLyricsFromSidecarFile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	testLibraryPath, err := getTestLibraryPath()
+	if err != nil {
+		t.Fatalf("Failed to get test library path: %s", err)
+	}
+
+	mp3, err := os.ReadFile(filepath.Join(testLibraryPath, "test_file_one.mp3"))
+	if err != nil {
+		t.Fatalf("reading test mp3: %s", err)
+	}
+
+	tmpDir := t.TempDir()
+	withLyrics := filepath.Join(tmpDir, "with_lyrics.mp3")
+	withoutLyrics := filepath.Join(tmpDir, "without_lyrics.mp3")
+
+	for _, fileName := range []string{withLyrics, withoutLyrics} {
+		if err := os.WriteFile(fileName, mp3, 0600); err != nil {
+			t.Fatalf("creating test file: %s", err)
+		}
+	}
+
+	err = os.WriteFile(
+		filepath.Join(tmpDir, "with_lyrics.lrc"),
+		[]byte("[00:01.00]One\n[00:02.50]Two\n"),
+		0600,
+	)
+	if err != nil {
+		t.Fatalf("creating LRC file: %s", err)
+	}
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	for _, fileName := range []string{withLyrics, withoutLyrics} {
+		if err := lib.AddMedia(fileName); err != nil {
+			t.Fatalf("adding %s: %s", fileName, err)
+		}
+	}
+
+	trackIDs := make(map[string]int64)
+	for _, track := range lib.Search(ctx, SearchArgs{}) {
+		trackIDs[lib.GetFilePath(ctx, track.ID)] = track.ID
+	}
+
+	lyrics, err := lib.GetTrackLyrics(ctx, trackIDs[withLyrics])
+	if err != nil {
+		t.Fatalf("getting lyrics: %s", err)
+	}
+
+	if len(lyrics.Synced) != 2 || lyrics.Synced[1].Start != 2500 {
+		t.Errorf("unexpected synced lyrics: %+v", lyrics.Synced)
+	}
+	if lyrics.Plain != "One\nTwo" {
+		t.Errorf("unexpected plain lyrics: %q", lyrics.Plain)
+	}
+
+	_, err = lib.GetTrackLyrics(ctx, trackIDs[withoutLyrics])
+	if !errors.Is(err, ErrLyricsNotFound) {
+		t.Errorf("expected lyrics not found error but got: %v", err)
+	}
+}
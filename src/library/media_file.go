@@ -33,6 +33,9 @@ type MediaFile interface {
 
 	// Returns the bitrate of the file in kb/s.
 	Bitrate() int
+
+	// Genre returns the genre of this media as written in its tags.
+	Genre() string
 }
 
 // parseFileTags reads a file and returns its metadata tags as a MediaFile object.
@@ -62,6 +65,7 @@ type mediaFile struct {
 	length  time.Duration
 	year    int
 	bitrate int
+	genre   string
 }
 
 func (f *mediaFile) Artist() string        { return f.artist }
@@ -71,6 +75,7 @@ func (f *mediaFile) Track() int            { return f.track }
 func (f *mediaFile) Length() time.Duration { return f.length }
 func (f *mediaFile) Year() int             { return f.year }
 func (f *mediaFile) Bitrate() int          { return f.bitrate }
+func (f *mediaFile) Genre() string         { return f.genre }
 
 // medaFileFromTaglib returns a MediaFile from a taglib parsed file.
 func medaFileFromTaglib(file *taglib.File) MediaFile {
@@ -82,6 +87,7 @@ func medaFileFromTaglib(file *taglib.File) MediaFile {
 		length:  file.Length(),
 		year:    file.Year(),
 		bitrate: file.Bitrate(),
+		genre:   file.Genre(),
 	}
 }
 
@@ -104,6 +110,7 @@ func mediaFileFromTag(fileName string) (MediaFile, error) {
 		title:  md.Title(),
 		track:  track,
 		year:   md.Year(),
+		genre:  md.Genre(),
 	}
 
 	return file, nil
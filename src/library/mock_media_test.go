@@ -11,6 +11,7 @@ type MockMedia struct {
 	length  time.Duration
 	year    int
 	bitrate int
+	genre   string
 }
 
 // Artist satisfies the MediaFile interface and just returns the object attribute.
@@ -55,3 +56,8 @@ func (m *MockMedia) Bitrate() int {
 	}
 	return m.bitrate
 }
+
+// Genre satisfies the MediaFile interface and just returns the object attribute.
+func (m *MockMedia) Genre() string {
+	return m.genre
+}
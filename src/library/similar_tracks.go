@@ -0,0 +1,532 @@
+package library
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+)
+
+// coListeningWindow is the time between plays of two tracks for which they are
+// considered listened to together.
+const coListeningWindow = 30 * time.Minute
+
+// similarExternalArtistWeight is the score added to tracks of artists which are
+// similar to the seed artists according to the artist info finder.
+const similarExternalArtistWeight = 3
+
+// SimilarArgs selects the tracks for which similar ones are searched for. Only
+// one of TrackID, AlbumID and ArtistID is expected to be set.
+type SimilarArgs struct {
+	// TrackID is the ID of the track for which similar ones are returned.
+	TrackID int64
+
+	// AlbumID is set for returning tracks which are similar to the ones from
+	// this album.
+	AlbumID int64
+
+	// ArtistID is set for returning tracks which are similar to the ones of
+	// this artist. Tracks of the artist themselves are returned too.
+	ArtistID int64
+
+	// Count is the maximum number of returned tracks.
+	Count int
+}
+
+// similarCandidatesLimit is the maximum number of tracks returned by each of the
+// similarityRules.
+const similarCandidatesLimit = 1000
+
+// similarTracksChunk is the maximum number of track IDs in a single query.
+const similarTracksChunk = 500
+
+// Weights added to the scores of the candidate tracks which have the same genre
+// as one of the seed tracks or were released in years close to them.
+const (
+	sameGenreWeight  = 3
+	closeYearsWeight = 1 // At most two years apart.
+	nearYearsWeight  = 1 // At most five years apart.
+)
+
+// similarityRule is a single reason for two tracks to be similar. Its query returns
+// the IDs of the tracks related to the seed tracks together with how many times
+// they are related. The score of a track is the sum of the weights for all rules
+// multiplied by its count. Counts are capped at maxCount.
+type similarityRule struct {
+	name     string
+	query    string
+	weight   float64
+	maxCount int64
+}
+
+// similarityRules find the candidates for similar tracks. Only tracks which are
+// directly related to the seed tracks are candidates so that the rules do not
+// have to go through the whole library. The queries are prepended with the `seed`
+// table which holds the seed tracks and are limited to similarCandidatesLimit rows.
+var similarityRules = []similarityRule{
+	{
+		name: "same artist",
+		query: `
+			SELECT id, 1 FROM tracks
+			WHERE artist_id IN (SELECT artist_id FROM seed)
+		`,
+		weight:   4,
+		maxCount: 1,
+	},
+	{
+		name: "same album",
+		query: `
+			SELECT id, 1 FROM tracks
+			WHERE album_id IN (SELECT album_id FROM seed)
+		`,
+		weight:   1,
+		maxCount: 1,
+	},
+	{
+		// Artists which appear on the same albums as the seed artists.
+		name: "album artists",
+		query: `
+			SELECT id, 1 FROM tracks
+			WHERE
+				artist_id IN (
+					SELECT artist_id FROM tracks
+					WHERE album_id IN (SELECT album_id FROM seed)
+				) AND
+				artist_id NOT IN (SELECT artist_id FROM seed)
+		`,
+		weight:   2,
+		maxCount: 1,
+	},
+	{
+		name: "playlists",
+		query: `
+			SELECT pt.track_id, COUNT(DISTINCT pt.playlist_id) FROM playlists_tracks pt
+			WHERE pt.playlist_id IN (
+				SELECT playlist_id FROM playlists_tracks
+				WHERE track_id IN (SELECT id FROM seed)
+			)
+			GROUP BY pt.track_id
+		`,
+		weight:   2,
+		maxCount: 3,
+	},
+	{
+		name: "co-listening",
+		query: `
+			SELECT p.track_id, COUNT(*) FROM plays p
+				JOIN plays seen ON
+					seen.track_id IN (SELECT id FROM seed) AND
+					seen.track_id != p.track_id AND
+					COALESCE(seen.user, '') = COALESCE(p.user, '') AND
+					p.played_at BETWEEN
+						seen.played_at - @window AND seen.played_at + @window
+			GROUP BY p.track_id
+		`,
+		weight:   1,
+		maxCount: 3,
+	},
+}
+
+// similarSeed describes the tracks for which similar ones are searched for.
+type similarSeed struct {
+	tracks  []int64
+	artists []int64
+	genres  []string
+	years   []int64
+}
+
+// SimilarTracks returns tracks which are similar to the ones selected by `args`.
+// They are compared by genre, year, artists and albums, by how often they are in
+// the same playlists and by whether they are listened to together. Artists which
+// are similar according to the artist info finder are used too when there is one.
+//
+// The tracks are picked at random with the more similar ones being more likely to
+// be picked. So every call returns a different list and calling it repeatedly
+// could be used for an endless-play queue. The tracks selected by `args` are not
+// returned unless they are selected by artist.
+func (lib *LocalLibrary) SimilarTracks(
+	ctx context.Context,
+	args SimilarArgs,
+) ([]TrackInfo, error) {
+	var (
+		seedColumn  string
+		seedID      int64
+		notFoundErr = ErrNotFound
+	)
+	switch {
+	case args.TrackID != 0:
+		seedColumn, seedID = "id", args.TrackID
+	case args.AlbumID != 0:
+		seedColumn, seedID, notFoundErr = "album_id", args.AlbumID, ErrAlbumNotFound
+	case args.ArtistID != 0:
+		seedColumn, seedID, notFoundErr = "artist_id", args.ArtistID, ErrArtistNotFound
+	default:
+		return nil, errors.New("one of track, album or artist ID is required")
+	}
+
+	if args.Count <= 0 {
+		return nil, nil
+	}
+
+	seed, err := lib.similaritySeed(ctx, seedColumn, seedID)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed.tracks) == 0 {
+		return nil, notFoundErr
+	}
+
+	scores, err := lib.similarityScores(ctx, seedColumn, seedID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lib.addSimilarArtistsScores(ctx, seed.artists, scores); err != nil {
+		return nil, err
+	}
+
+	if args.ArtistID == 0 {
+		for _, trackID := range seed.tracks {
+			delete(scores, trackID)
+		}
+	}
+
+	if err := lib.addAttributesScores(ctx, seed, scores); err != nil {
+		return nil, err
+	}
+
+	return lib.tracksByIDs(ctx, pickSimilarTracks(scores, args.Count))
+}
+
+// similaritySeed returns the tracks for which `column` is `id` together with
+// their artists, genres and years.
+func (lib *LocalLibrary) similaritySeed(
+	ctx context.Context,
+	column string,
+	id int64,
+) (similarSeed, error) {
+	var seed similarSeed
+
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT id, artist_id, genre, year FROM tracks WHERE %s = ?
+		`, column), id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				trackID, artistID int64
+				genre             sql.NullString
+				year              sql.NullInt64
+			)
+			if err := rows.Scan(&trackID, &artistID, &genre, &year); err != nil {
+				return err
+			}
+
+			seed.tracks = append(seed.tracks, trackID)
+			if !slices.Contains(seed.artists, artistID) {
+				seed.artists = append(seed.artists, artistID)
+			}
+			if g := strings.ToLower(genre.String); g != "" && !slices.Contains(seed.genres, g) {
+				seed.genres = append(seed.genres, g)
+			}
+			if year.Int64 > 0 && !slices.Contains(seed.years, year.Int64) {
+				seed.years = append(seed.years, year.Int64)
+			}
+		}
+
+		return rows.Err()
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return similarSeed{}, fmt.Errorf("getting seed tracks: %w", err)
+	}
+
+	return seed, nil
+}
+
+// similarityScores returns the scores for all tracks which are similar to the
+// seed tracks by at least one of the similarityRules. Every rule is executed in
+// its own database job so that other jobs are not blocked for too long.
+func (lib *LocalLibrary) similarityScores(
+	ctx context.Context,
+	seedColumn string,
+	seedID int64,
+) (map[int64]float64, error) {
+	scores := make(map[int64]float64)
+	seedTable := fmt.Sprintf(`
+		WITH seed AS (
+			SELECT id, artist_id, album_id FROM tracks
+			WHERE %s = @seedID
+		)
+	`, seedColumn)
+
+	for _, rule := range similarityRules {
+		work := func(db *sql.DB) error {
+			rows, err := db.QueryContext(
+				ctx,
+				seedTable+rule.query+"LIMIT @limit",
+				sql.Named("seedID", seedID),
+				sql.Named("window", int64(coListeningWindow.Seconds())),
+				sql.Named("limit", similarCandidatesLimit),
+			)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var trackID, count int64
+				if err := rows.Scan(&trackID, &count); err != nil {
+					return err
+				}
+
+				scores[trackID] += rule.weight * float64(min(count, rule.maxCount))
+			}
+
+			return rows.Err()
+		}
+		if err := lib.ExecuteDBJobAndWait(work); err != nil {
+			return nil, fmt.Errorf("scoring similar tracks by %s: %w", rule.name, err)
+		}
+	}
+
+	return scores, nil
+}
+
+// addAttributesScores adds to the scores of the tracks which are already in
+// `scores` the weights for having the same genre as the seed tracks or for being
+// released in years close to theirs.
+func (lib *LocalLibrary) addAttributesScores(
+	ctx context.Context,
+	seed similarSeed,
+	scores map[int64]float64,
+) error {
+	if len(seed.genres) == 0 && len(seed.years) == 0 {
+		return nil
+	}
+
+	candidates := slices.Collect(maps.Keys(scores))
+	for chunk := range slices.Chunk(candidates, similarTracksChunk) {
+		placeHolders := strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")
+		queryArgs := make([]any, 0, len(chunk))
+		for _, trackID := range chunk {
+			queryArgs = append(queryArgs, trackID)
+		}
+
+		work := func(db *sql.DB) error {
+			rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+				SELECT id, genre, year FROM tracks WHERE id IN (%s)
+			`, placeHolders), queryArgs...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var (
+					trackID int64
+					genre   sql.NullString
+					year    sql.NullInt64
+				)
+				if err := rows.Scan(&trackID, &genre, &year); err != nil {
+					return err
+				}
+
+				scores[trackID] += seed.attributesScore(genre.String, year.Int64)
+			}
+
+			return rows.Err()
+		}
+		if err := lib.ExecuteDBJobAndWait(work); err != nil {
+			return fmt.Errorf("scoring similar tracks by genre and year: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// attributesScore returns the score of a track with `genre` which was released in
+// `year` compared to the seed tracks.
+func (s similarSeed) attributesScore(genre string, year int64) float64 {
+	var score float64
+	if genre != "" && slices.Contains(s.genres, strings.ToLower(genre)) {
+		score += sameGenreWeight
+	}
+
+	if year <= 0 {
+		return score
+	}
+
+	closest := int64(math.MaxInt64)
+	for _, seedYear := range s.years {
+		closest = min(closest, max(year-seedYear, seedYear-year))
+	}
+	if closest <= 2 {
+		score += closeYearsWeight
+	}
+	if closest <= 5 {
+		score += nearYearsWeight
+	}
+
+	return score
+}
+
+// addSimilarArtistsScores adds to `scores` the tracks of artists which are similar
+// to `artistIDs` according to the artist info. Errors while searching for the
+// info are only logged since similar artists are not required.
+func (lib *LocalLibrary) addSimilarArtistsScores(
+	ctx context.Context,
+	artistIDs []int64,
+	scores map[int64]float64,
+) error {
+	if lib.artistInfoFinder == nil {
+		return nil
+	}
+
+	var similar []int64
+	for _, artistID := range artistIDs {
+		info, err := lib.GetArtistInfo(ctx, artistID)
+		if err != nil {
+			log.Printf("Error getting similar artists for artist %d: %s\n", artistID, err)
+			continue
+		}
+
+		for _, artist := range info.Similar {
+			if artist.ID != 0 && !slices.Contains(artistIDs, artist.ID) {
+				similar = append(similar, artist.ID)
+			}
+		}
+	}
+
+	if len(similar) == 0 {
+		return nil
+	}
+
+	placeHolders := strings.TrimSuffix(strings.Repeat("?,", len(similar)), ",")
+	queryArgs := make([]any, 0, len(similar))
+	for _, artistID := range similar {
+		queryArgs = append(queryArgs, artistID)
+	}
+
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT id FROM tracks WHERE artist_id IN (%s)
+		`, placeHolders), queryArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var trackID int64
+			if err := rows.Scan(&trackID); err != nil {
+				return err
+			}
+			scores[trackID] += similarExternalArtistWeight
+		}
+
+		return rows.Err()
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return fmt.Errorf("getting tracks of similar artists: %w", err)
+	}
+
+	return nil
+}
+
+// pickSimilarTracks picks at random at most `count` of the tracks in `scores`.
+// Tracks with higher scores are more likely to be picked and to be first. This
+// is weighted random sampling without replacement as described by Efraimidis and
+// Spirakis.
+func pickSimilarTracks(scores map[int64]float64, count int) []int64 {
+	type pick struct {
+		trackID int64
+		key     float64
+	}
+
+	picks := make([]pick, 0, len(scores))
+	for trackID, score := range scores {
+		if score <= 0 {
+			continue
+		}
+
+		picks = append(picks, pick{
+			trackID: trackID,
+			key:     math.Pow(rand.Float64(), 1/score),
+		})
+	}
+
+	slices.SortFunc(picks, func(a, b pick) int {
+		return cmp.Compare(b.key, a.key)
+	})
+
+	picks = picks[:min(count, len(picks))]
+	trackIDs := make([]int64, 0, len(picks))
+	for _, p := range picks {
+		trackIDs = append(trackIDs, p.trackID)
+	}
+
+	return trackIDs
+}
+
+// tracksByIDs returns the tracks with `trackIDs` in the same order. IDs for which
+// there are no tracks are skipped.
+func (lib *LocalLibrary) tracksByIDs(
+	ctx context.Context,
+	trackIDs []int64,
+) ([]TrackInfo, error) {
+	if len(trackIDs) == 0 {
+		return nil, nil
+	}
+
+	placeHolders := strings.TrimSuffix(strings.Repeat("?,", len(trackIDs)), ",")
+	queryArgs := make([]any, 0, len(trackIDs))
+	for _, trackID := range trackIDs {
+		queryArgs = append(queryArgs, trackID)
+	}
+
+	found := make(map[int64]TrackInfo, len(trackIDs))
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, dbTracksQuery+fmt.Sprintf(`
+			WHERE
+				t.id IN (%s)
+		`, placeHolders), queryArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			track, err := ScanTrack(rows)
+			if err != nil {
+				return fmt.Errorf("scanning error: %w", err)
+			}
+			found[track.ID] = track
+		}
+
+		return rows.Err()
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return nil, fmt.Errorf("getting tracks: %w", err)
+	}
+
+	tracks := make([]TrackInfo, 0, len(found))
+	for _, trackID := range trackIDs {
+		if track, ok := found[trackID]; ok {
+			tracks = append(tracks, track)
+		}
+	}
+
+	return tracks, nil
+}
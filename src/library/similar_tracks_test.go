@@ -0,0 +1,203 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/artistinfo"
+	"github.com/ironsmile/euterpe/src/artistinfo/artistinfofakes"
+)
+
+// TestSimilarTracks checks that tracks which are related to the seed by artist,
+// playlists, co-listening or similar artists are returned and that unrelated
+// tracks are not, even when they are of the same genre.
+func TestSimilarTracks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	tmpDir := t.TempDir()
+	tracks := []MockMedia{
+		{artist: "Iron Maiden", album: "Beast", title: "Invaders", genre: "Metal", year: 1982},
+		{artist: "Iron Maiden", album: "Beast", title: "Gangland", genre: "Metal", year: 1983},
+		{artist: "Judas Priest", album: "Screaming", title: "Electric Eye", genre: "metal", year: 1970},
+		{artist: "ABBA", album: "Arrival", title: "Dancing Queen", genre: "Pop", year: 1976},
+		{artist: "ABBA", album: "Arrival", title: "Money, Money, Money", genre: "Pop", year: 1976},
+		{artist: "Miles Davis", album: "Kind of Blue", title: "So What", genre: "Jazz", year: 1959},
+	}
+
+	trackIDs := make(map[string]int64)
+	for i, media := range tracks {
+		media.track = i + 1
+		media.length = 123 * time.Second
+		info := fileInfo{
+			Size:     1024,
+			FilePath: filepath.Join(tmpDir, media.album, media.title+".mp3"),
+			Modified: time.Now(),
+		}
+		if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", i, err)
+		}
+
+		var trackID int64
+		err := lib.db.QueryRow(
+			`SELECT id FROM tracks WHERE fs_path = ?`, info.FilePath,
+		).Scan(&trackID)
+		if err != nil {
+			t.Fatalf("getting track ID for %s: %s", media.title, err)
+		}
+		trackIDs[media.title] = trackID
+	}
+
+	// "So What" is in the same playlist as "Invaders" while "Dancing Queen" and
+	// "Electric Eye" were listened to right after it.
+	_, err = lib.db.Exec(`
+		INSERT INTO playlists (id, name, created_at, updated_at) VALUES (1, 'mix', 0, 0);
+		INSERT INTO playlists_tracks (playlist_id, track_id, "index")
+		VALUES (1, ?, 0), (1, ?, 1);
+	`, trackIDs["Invaders"], trackIDs["So What"])
+	if err != nil {
+		t.Fatalf("creating playlist: %s", err)
+	}
+
+	playedAt := time.Now().Add(-time.Hour)
+	plays := map[string]time.Time{
+		"Invaders":      playedAt,
+		"Dancing Queen": playedAt.Add(4 * time.Minute),
+		"Electric Eye":  playedAt.Add(8 * time.Minute),
+	}
+	for title, at := range plays {
+		play := Play{TrackID: trackIDs[title], At: at, Client: "test"}
+		if err := lib.RecordTrackPlay(ctx, play); err != nil {
+			t.Fatalf("recording play: %s", err)
+		}
+	}
+
+	similar, err := lib.SimilarTracks(ctx, SimilarArgs{
+		TrackID: trackIDs["Invaders"],
+		Count:   10,
+	})
+	if err != nil {
+		t.Fatalf("getting similar tracks: %s", err)
+	}
+	assertTrackTitles(t, similar, "Gangland", "Electric Eye", "So What", "Dancing Queen")
+
+	similar, err = lib.SimilarTracks(ctx, SimilarArgs{
+		TrackID: trackIDs["Invaders"],
+		Count:   2,
+	})
+	if err != nil {
+		t.Fatalf("getting similar tracks: %s", err)
+	}
+	if len(similar) != 2 {
+		t.Errorf("expected 2 similar tracks but got %d", len(similar))
+	}
+
+	// Tracks of the artist are returned for artist radio. ABBA is similar to Judas
+	// Priest according to the artist info finder. "Gangland" is of the same genre
+	// but is not related to Judas Priest in any other way.
+	finder := &artistinfofakes.FakeFinder{}
+	finder.GetArtistInfoReturns(artistinfo.Info{
+		Similar: []artistinfo.SimilarArtist{{Name: "ABBA"}},
+	}, nil)
+	lib.SetArtistInfoFinder(finder, time.Hour)
+
+	judasPriestID, err := lib.GetArtistID("Judas Priest")
+	if err != nil {
+		t.Fatalf("getting artist ID: %s", err)
+	}
+
+	similar, err = lib.SimilarTracks(ctx, SimilarArgs{
+		ArtistID: judasPriestID,
+		Count:    10,
+	})
+	if err != nil {
+		t.Fatalf("getting artist radio: %s", err)
+	}
+	assertTrackTitles(t, similar,
+		"Electric Eye", "Invaders", "Dancing Queen", "Money, Money, Money",
+	)
+
+	if _, err := lib.SimilarTracks(ctx, SimilarArgs{TrackID: 8888, Count: 10}); !errors.Is(
+		err, ErrNotFound,
+	) {
+		t.Errorf("expected not found error but got %v", err)
+	}
+	if _, err := lib.SimilarTracks(ctx, SimilarArgs{ArtistID: 8888, Count: 10}); !errors.Is(
+		err, ErrArtistNotFound,
+	) {
+		t.Errorf("expected artist not found error but got %v", err)
+	}
+}
+
+// TestPickSimilarTracks checks that tracks without scores are never picked and
+// that the number of picked tracks is limited.
+func TestPickSimilarTracks(t *testing.T) {
+	scores := map[int64]float64{1: 10, 2: 0.5, 3: 0, 4: 3}
+
+	for range 20 {
+		picked := pickSimilarTracks(scores, 10)
+		slices.Sort(picked)
+		if !slices.Equal(picked, []int64{1, 2, 4}) {
+			t.Fatalf("unexpected picked tracks: %v", picked)
+		}
+	}
+
+	if picked := pickSimilarTracks(scores, 2); len(picked) != 2 {
+		t.Errorf("expected 2 picked tracks but got %v", picked)
+	}
+}
+
+// TestSimilarSeedAttributesScore checks the scores for genres and years of tracks
+// compared to the seed tracks.
+func TestSimilarSeedAttributesScore(t *testing.T) {
+	seed := similarSeed{genres: []string{"metal"}, years: []int64{1970, 1982}}
+
+	tests := []struct {
+		genre    string
+		year     int64
+		expected float64
+	}{
+		{genre: "Metal", year: 1983, expected: 5},
+		{genre: "metal", year: 0, expected: 3},
+		{genre: "Pop", year: 1974, expected: 1},
+		{genre: "", year: 1990, expected: 0},
+		{genre: "Jazz", year: 1959, expected: 0},
+	}
+
+	for _, test := range tests {
+		score := seed.attributesScore(test.genre, test.year)
+		if score != test.expected {
+			t.Errorf("expected score %v for %q from %d but got %v",
+				test.expected, test.genre, test.year, score)
+		}
+	}
+}
+
+func assertTrackTitles(t *testing.T, tracks []TrackInfo, expected ...string) {
+	t.Helper()
+
+	titles := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		titles = append(titles, track.Title)
+	}
+
+	slices.Sort(titles)
+	slices.Sort(expected)
+	if !slices.Equal(titles, expected) {
+		t.Errorf("expected tracks %q but got %q", expected, titles)
+	}
+}
@@ -0,0 +1,500 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultStatsCount is the number of items in the top lists of the listening
+// statistics when StatsArgs does not set one.
+const defaultStatsCount = 10
+
+// playTimeExpr is the SQL expression for how long a play lasted in milliseconds.
+// Plays of unknown duration are counted as if the whole track was played.
+const playTimeExpr = `COALESCE(p.duration_played, t.duration, 0)`
+
+// StatsArgs selects which part of the listening history is used for computing
+// listening statistics.
+type StatsArgs struct {
+	// User limits the statistics to the plays of this user when not empty.
+	User string
+
+	// From is the inclusive lower limit for the time of the plays. It is not
+	// used when zero.
+	From time.Time
+
+	// To is the exclusive upper limit for the time of the plays. It is not used
+	// when zero.
+	To time.Time
+
+	// Count is the maximum number of items in each of the top lists. The default
+	// of 10 is used when it is zero.
+	Count int
+
+	// Offset is the number of items skipped from the start of the top lists.
+	Offset uint64
+}
+
+// PlayStats is how much something has been listened to.
+type PlayStats struct {
+	// Plays is the number of plays.
+	Plays int64 `json:"plays"`
+
+	// TimePlayed is the listening time in milliseconds.
+	TimePlayed int64 `json:"time_played"`
+}
+
+// ArtistStats is how much an artist has been listened to.
+type ArtistStats struct {
+	ID   int64  `json:"artist_id"`
+	Name string `json:"artist"`
+
+	// FirstPlayed is the Unix timestamp of the first ever play of the artist. It
+	// is set only for discoveries.
+	FirstPlayed int64 `json:"first_played,omitempty"`
+
+	PlayStats
+}
+
+// AlbumStats is how much an album has been listened to.
+type AlbumStats struct {
+	ID     int64  `json:"album_id"`
+	Name   string `json:"album"`
+	Artist string `json:"artist"`
+
+	// LastPlayed is the Unix timestamp of the last play of a track from the album.
+	LastPlayed int64 `json:"last_played"`
+
+	PlayStats
+}
+
+// TrackStats is how much a track has been listened to.
+type TrackStats struct {
+	Track TrackInfo `json:"track"`
+
+	PlayStats
+}
+
+// GenreStats is how much tracks from a genre have been listened to.
+type GenreStats struct {
+	Genre string `json:"genre"`
+
+	PlayStats
+}
+
+// DayStats is how much music has been listened to during a day.
+type DayStats struct {
+	// Date is the day in the YYYY-MM-DD format.
+	Date string `json:"date"`
+
+	PlayStats
+}
+
+// HourStats is how much music has been listened to during an hour of the day.
+type HourStats struct {
+	// Hour is the hour of the day in the [0-23] range.
+	Hour int `json:"hour"`
+
+	PlayStats
+}
+
+// Stats are listening statistics computed from the listening history. Days and
+// hours are in the local time zone of the server.
+type Stats struct {
+	// Total is how much music has been listened to in general.
+	Total PlayStats `json:"total"`
+
+	TopArtists []ArtistStats `json:"top_artists"`
+	TopAlbums  []AlbumStats  `json:"top_albums"`
+	TopTracks  []TrackStats  `json:"top_tracks"`
+	TopGenres  []GenreStats  `json:"top_genres"`
+
+	// Days is the listening time for every day with at least one play. The
+	// days are in chronological order.
+	Days []DayStats `json:"days"`
+
+	// Hours is the listening time for every hour of the day. It always has 24
+	// elements starting with midnight.
+	Hours []HourStats `json:"hours"`
+
+	// Discoveries are the artists which were played for the first time ever
+	// during the selected period. The most recent discoveries are first.
+	Discoveries []ArtistStats `json:"discoveries"`
+}
+
+// GetStats computes listening statistics for the plays selected by `args`.
+func (lib *LocalLibrary) GetStats(ctx context.Context, args StatsArgs) (Stats, error) {
+	var stats Stats
+
+	albums, err := lib.topAlbums(ctx, args, OrderByFrequentlyPlayed)
+	if err != nil {
+		return stats, err
+	}
+	stats.TopAlbums = albums
+
+	where, queryArgs := args.filter()
+	queryArgs = append(queryArgs,
+		sql.Named("count", args.count()),
+		sql.Named("offset", args.Offset),
+	)
+
+	var topTracks []TrackStats
+	work := func(db *sql.DB) error {
+		err := db.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT COUNT(*), COALESCE(SUM(%s), 0)
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+			%s
+		`, playTimeExpr, where), queryArgs...).Scan(
+			&stats.Total.Plays,
+			&stats.Total.TimePlayed,
+		)
+		if err != nil {
+			return fmt.Errorf("getting total: %w", err)
+		}
+
+		err = queryPlayStats(ctx, db, fmt.Sprintf(`
+			SELECT ar.id, ar.name, COUNT(*) AS plays, SUM(%s) AS time_played
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+				JOIN artists ar ON ar.id = t.artist_id
+			%s
+			GROUP BY ar.id
+			ORDER BY plays DESC, time_played DESC, ar.id
+			LIMIT @count OFFSET @offset
+		`, playTimeExpr, where), queryArgs, func(rows *sql.Rows) error {
+			var artist ArtistStats
+			err := rows.Scan(&artist.ID, &artist.Name, &artist.Plays, &artist.TimePlayed)
+			stats.TopArtists = append(stats.TopArtists, artist)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("getting top artists: %w", err)
+		}
+
+		err = queryPlayStats(ctx, db, fmt.Sprintf(`
+			SELECT t.id, COUNT(*) AS plays, SUM(%s) AS time_played
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+			%s
+			GROUP BY t.id
+			ORDER BY plays DESC, time_played DESC, t.id
+			LIMIT @count OFFSET @offset
+		`, playTimeExpr, where), queryArgs, func(rows *sql.Rows) error {
+			var track TrackStats
+			err := rows.Scan(&track.Track.ID, &track.Plays, &track.TimePlayed)
+			topTracks = append(topTracks, track)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("getting top tracks: %w", err)
+		}
+
+		err = queryPlayStats(ctx, db, fmt.Sprintf(`
+			SELECT t.genre, COUNT(*) AS plays, SUM(%s) AS time_played
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+			%s
+			GROUP BY t.genre
+			ORDER BY plays DESC, time_played DESC, t.genre
+			LIMIT @count OFFSET @offset
+		`, playTimeExpr, whereWith(where, "t.genre IS NOT NULL AND t.genre != ''"),
+		), queryArgs, func(rows *sql.Rows) error {
+			var genre GenreStats
+			err := rows.Scan(&genre.Genre, &genre.Plays, &genre.TimePlayed)
+			stats.TopGenres = append(stats.TopGenres, genre)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("getting top genres: %w", err)
+		}
+
+		err = queryPlayStats(ctx, db, fmt.Sprintf(`
+			SELECT
+				date(p.played_at, 'unixepoch', 'localtime') AS day,
+				COUNT(*),
+				SUM(%s)
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+			%s
+			GROUP BY day
+			ORDER BY day
+		`, playTimeExpr, where), queryArgs, func(rows *sql.Rows) error {
+			var day DayStats
+			err := rows.Scan(&day.Date, &day.Plays, &day.TimePlayed)
+			stats.Days = append(stats.Days, day)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("getting listening time per day: %w", err)
+		}
+
+		stats.Hours = make([]HourStats, 24)
+		for hour := range stats.Hours {
+			stats.Hours[hour].Hour = hour
+		}
+		err = queryPlayStats(ctx, db, fmt.Sprintf(`
+			SELECT
+				CAST(strftime('%%H', p.played_at, 'unixepoch', 'localtime') AS INTEGER) AS hour,
+				COUNT(*),
+				SUM(%s)
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+			%s
+			GROUP BY hour
+		`, playTimeExpr, where), queryArgs, func(rows *sql.Rows) error {
+			var hour HourStats
+			if err := rows.Scan(&hour.Hour, &hour.Plays, &hour.TimePlayed); err != nil {
+				return err
+			}
+			if hour.Hour >= 0 && hour.Hour < len(stats.Hours) {
+				stats.Hours[hour.Hour] = hour
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("getting listening time per hour: %w", err)
+		}
+
+		userFilter := ""
+		if args.User != "" {
+			userFilter = "WHERE p.user = @user"
+		}
+		err = queryPlayStats(ctx, db, fmt.Sprintf(`
+			WITH first_plays AS (
+				SELECT t.artist_id, MIN(p.played_at) AS first_played
+				FROM plays p
+					JOIN tracks t ON t.id = p.track_id
+				%s
+				GROUP BY t.artist_id
+			)
+			SELECT ar.id, ar.name, fp.first_played, COUNT(*), SUM(%s)
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+				JOIN artists ar ON ar.id = t.artist_id
+				JOIN first_plays fp ON fp.artist_id = t.artist_id
+			%s
+			GROUP BY ar.id
+			ORDER BY fp.first_played DESC, ar.id
+			LIMIT @count OFFSET @offset
+		`, userFilter, playTimeExpr, whereWith(where, args.discoveredFilter()),
+		), queryArgs, func(rows *sql.Rows) error {
+			var artist ArtistStats
+			err := rows.Scan(
+				&artist.ID,
+				&artist.Name,
+				&artist.FirstPlayed,
+				&artist.Plays,
+				&artist.TimePlayed,
+			)
+			stats.Discoveries = append(stats.Discoveries, artist)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("getting discoveries: %w", err)
+		}
+
+		return nil
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return stats, fmt.Errorf("computing stats: %w", err)
+	}
+
+	trackIDs := make([]int64, 0, len(topTracks))
+	for _, track := range topTracks {
+		trackIDs = append(trackIDs, track.Track.ID)
+	}
+	tracks, err := lib.tracksByIDs(ctx, trackIDs)
+	if err != nil {
+		return stats, err
+	}
+	found := make(map[int64]TrackInfo, len(tracks))
+	for _, track := range tracks {
+		found[track.ID] = track
+	}
+	for _, track := range topTracks {
+		if info, ok := found[track.Track.ID]; ok {
+			track.Track = info
+			stats.TopTracks = append(stats.TopTracks, track)
+		}
+	}
+
+	return stats, nil
+}
+
+// TopAlbums returns the albums from the plays selected by `args`. They are
+// ordered by the number of plays for OrderByFrequentlyPlayed or by the time of
+// the last play for OrderByRecentlyPlayed. The plays and last played time of
+// the returned albums are computed from the selected plays only.
+func (lib *LocalLibrary) TopAlbums(
+	ctx context.Context,
+	args StatsArgs,
+	orderBy BrowseOrderBy,
+) ([]Album, error) {
+	top, err := lib.topAlbums(ctx, args, orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := make([]Album, 0, len(top))
+	for _, albumStats := range top {
+		album, err := lib.GetAlbum(ctx, albumStats.ID)
+		if errors.Is(err, ErrAlbumNotFound) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("getting album %d: %w", albumStats.ID, err)
+		}
+
+		album.Plays = albumStats.Plays
+		album.LastPlayed = albumStats.LastPlayed
+		albums = append(albums, album)
+	}
+
+	return albums, nil
+}
+
+func (lib *LocalLibrary) topAlbums(
+	ctx context.Context,
+	args StatsArgs,
+	orderBy BrowseOrderBy,
+) ([]AlbumStats, error) {
+	order := "plays DESC, time_played DESC"
+	switch orderBy {
+	case OrderByFrequentlyPlayed:
+	case OrderByRecentlyPlayed:
+		order = "last_played DESC"
+	default:
+		return nil, fmt.Errorf("albums could not be ordered by %d", orderBy)
+	}
+
+	where, queryArgs := args.filter()
+	queryArgs = append(queryArgs,
+		sql.Named("count", args.count()),
+		sql.Named("offset", args.Offset),
+	)
+
+	var albums []AlbumStats
+	work := func(db *sql.DB) error {
+		return queryPlayStats(ctx, db, fmt.Sprintf(`
+			SELECT
+				al.id,
+				al.name,
+				CASE WHEN COUNT(DISTINCT t.artist_id) = 1
+				THEN MIN(ar.name)
+				ELSE "Various Artists"
+				END AS artist_name,
+				MAX(p.played_at) AS last_played,
+				COUNT(*) AS plays,
+				SUM(%s) AS time_played
+			FROM plays p
+				JOIN tracks t ON t.id = p.track_id
+				JOIN albums al ON al.id = t.album_id
+				LEFT JOIN artists ar ON ar.id = t.artist_id
+			%s
+			GROUP BY al.id
+			ORDER BY %s, al.id
+			LIMIT @count OFFSET @offset
+		`, playTimeExpr, where, order), queryArgs, func(rows *sql.Rows) error {
+			var album AlbumStats
+			err := rows.Scan(
+				&album.ID,
+				&album.Name,
+				&album.Artist,
+				&album.LastPlayed,
+				&album.Plays,
+				&album.TimePlayed,
+			)
+			albums = append(albums, album)
+			return err
+		})
+	}
+	if err := lib.ExecuteDBJobAndWait(work); err != nil {
+		return nil, fmt.Errorf("getting top albums: %w", err)
+	}
+
+	return albums, nil
+}
+
+// queryPlayStats runs `query` and calls `scan` for every row of its result.
+func queryPlayStats(
+	ctx context.Context,
+	db *sql.DB,
+	query string,
+	queryArgs []any,
+	scan func(*sql.Rows) error,
+) error {
+	rows, err := db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("scanning: %w", err)
+		}
+	}
+
+	return rows.Err()
+}
+
+// filter returns the WHERE clause which selects the plays for `args` together
+// with its query arguments.
+func (args StatsArgs) filter() (string, []any) {
+	var (
+		where     []string
+		queryArgs []any
+	)
+	if args.User != "" {
+		where = append(where, "p.user = @user")
+		queryArgs = append(queryArgs, sql.Named("user", args.User))
+	}
+	if !args.From.IsZero() {
+		where = append(where, "p.played_at >= @from")
+		queryArgs = append(queryArgs, sql.Named("from", args.From.Unix()))
+	}
+	if !args.To.IsZero() {
+		where = append(where, "p.played_at < @to")
+		queryArgs = append(queryArgs, sql.Named("to", args.To.Unix()))
+	}
+
+	if len(where) == 0 {
+		return "", queryArgs
+	}
+	return "WHERE " + strings.Join(where, " AND "), queryArgs
+}
+
+// whereWith adds `condition` to the WHERE clause `where` returned by filter.
+func whereWith(where, condition string) string {
+	if condition == "" {
+		return where
+	}
+	if where == "" {
+		return "WHERE " + condition
+	}
+	return where + " AND " + condition
+}
+
+// discoveredFilter returns the condition for artists which were played for the
+// first time during the period selected by `args`.
+func (args StatsArgs) discoveredFilter() string {
+	var where []string
+	if !args.From.IsZero() {
+		where = append(where, "fp.first_played >= @from")
+	}
+	if !args.To.IsZero() {
+		where = append(where, "fp.first_played < @to")
+	}
+	return strings.Join(where, " AND ")
+}
+
+func (args StatsArgs) count() int {
+	if args.Count <= 0 {
+		return defaultStatsCount
+	}
+	return args.Count
+}
@@ -0,0 +1,156 @@
+package library
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestStats checks that the listening statistics are computed from the plays
+// in the listening history and that they could be filtered by user and time.
+func TestStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	tmpDir := t.TempDir()
+	tracks := []MockMedia{
+		{artist: "Iron Maiden", album: "Beast", title: "Invaders", genre: "Metal"},
+		{artist: "Iron Maiden", album: "Beast", title: "Gangland", genre: "Metal"},
+		{artist: "ABBA", album: "Arrival", title: "Dancing Queen", genre: "Pop"},
+		{artist: "Miles Davis", album: "Kind of Blue", title: "So What"},
+	}
+
+	trackIDs := make(map[string]int64)
+	for i, media := range tracks {
+		media.track = i + 1
+		media.length = 100 * time.Second
+		info := fileInfo{
+			Size:     1024,
+			FilePath: filepath.Join(tmpDir, media.album, media.title+".mp3"),
+			Modified: time.Now(),
+		}
+		if err := lib.insertMediaIntoDatabase(&media, info); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", i, err)
+		}
+
+		var trackID int64
+		err := lib.db.QueryRow(
+			`SELECT id FROM tracks WHERE fs_path = ?`, info.FilePath,
+		).Scan(&trackID)
+		if err != nil {
+			t.Fatalf("getting track ID for %s: %s", media.title, err)
+		}
+		trackIDs[media.title] = trackID
+	}
+
+	// Alice listens to Iron Maiden on the first day and discovers ABBA on the
+	// second. Bob listens to Miles Davis on the second day.
+	firstDay := time.Date(2024, time.March, 10, 9, 0, 0, 0, time.Local)
+	secondDay := firstDay.AddDate(0, 0, 1).Add(12 * time.Hour)
+	plays := []Play{
+		{TrackID: trackIDs["Invaders"], At: firstDay, User: "alice"},
+		{TrackID: trackIDs["Gangland"], At: firstDay.Add(2 * time.Minute), User: "alice"},
+		{TrackID: trackIDs["Invaders"], At: secondDay, User: "alice", Duration: 40 * time.Second},
+		{TrackID: trackIDs["Dancing Queen"], At: secondDay.Add(3 * time.Minute), User: "alice"},
+		{TrackID: trackIDs["So What"], At: secondDay, User: "bob"},
+	}
+	for i, play := range plays {
+		if err := lib.RecordTrackPlay(ctx, play); err != nil {
+			t.Fatalf("recording play %d: %s", i, err)
+		}
+	}
+
+	stats, err := lib.GetStats(ctx, StatsArgs{User: "alice"})
+	if err != nil {
+		t.Fatalf("getting stats: %s", err)
+	}
+
+	expectedTotal := PlayStats{Plays: 4, TimePlayed: 340000}
+	if stats.Total != expectedTotal {
+		t.Errorf("expected total %+v but got %+v", expectedTotal, stats.Total)
+	}
+	if len(stats.TopArtists) != 2 || stats.TopArtists[0].Name != "Iron Maiden" ||
+		stats.TopArtists[0].Plays != 3 || stats.TopArtists[0].TimePlayed != 240000 {
+		t.Errorf("unexpected top artists: %+v", stats.TopArtists)
+	}
+	if len(stats.TopAlbums) != 2 || stats.TopAlbums[0].Name != "Beast" ||
+		stats.TopAlbums[0].Artist != "Iron Maiden" {
+		t.Errorf("unexpected top albums: %+v", stats.TopAlbums)
+	}
+	if len(stats.TopTracks) != 3 || stats.TopTracks[0].Track.Title != "Invaders" ||
+		stats.TopTracks[0].Plays != 2 {
+		t.Errorf("unexpected top tracks: %+v", stats.TopTracks)
+	}
+	if len(stats.TopGenres) != 2 || stats.TopGenres[0].Genre != "Metal" ||
+		stats.TopGenres[1].Genre != "Pop" {
+		t.Errorf("unexpected top genres: %+v", stats.TopGenres)
+	}
+
+	expectedDays := []DayStats{
+		{Date: "2024-03-10", PlayStats: PlayStats{Plays: 2, TimePlayed: 200000}},
+		{Date: "2024-03-11", PlayStats: PlayStats{Plays: 2, TimePlayed: 140000}},
+	}
+	if len(stats.Days) != len(expectedDays) {
+		t.Fatalf("expected days %+v but got %+v", expectedDays, stats.Days)
+	}
+	for i, day := range stats.Days {
+		if day != expectedDays[i] {
+			t.Errorf("day %d: expected %+v but got %+v", i, expectedDays[i], day)
+		}
+	}
+
+	if len(stats.Hours) != 24 {
+		t.Fatalf("expected 24 hours but got %d", len(stats.Hours))
+	}
+	if stats.Hours[9].Plays != 2 || stats.Hours[21].Plays != 2 || stats.Hours[0].Plays != 0 {
+		t.Errorf("unexpected listening hours: %+v", stats.Hours)
+	}
+
+	stats, err = lib.GetStats(ctx, StatsArgs{
+		User: "alice",
+		From: secondDay.Add(-time.Hour),
+		To:   secondDay.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("getting stats for the second day: %s", err)
+	}
+	if len(stats.Discoveries) != 1 || stats.Discoveries[0].Name != "ABBA" ||
+		stats.Discoveries[0].FirstPlayed != secondDay.Add(3*time.Minute).Unix() {
+		t.Errorf("unexpected discoveries: %+v", stats.Discoveries)
+	}
+	if stats.Total.Plays != 2 {
+		t.Errorf("expected 2 plays on the second day but got %d", stats.Total.Plays)
+	}
+
+	albums, err := lib.TopAlbums(ctx, StatsArgs{}, OrderByRecentlyPlayed)
+	if err != nil {
+		t.Fatalf("getting recent albums: %s", err)
+	}
+	if len(albums) != 3 || albums[0].Name != "Arrival" ||
+		albums[0].LastPlayed != secondDay.Add(3*time.Minute).Unix() {
+		t.Errorf("unexpected recently played albums: %+v", albums)
+	}
+
+	albums, err = lib.TopAlbums(ctx, StatsArgs{Count: 1, Offset: 1}, OrderByFrequentlyPlayed)
+	if err != nil {
+		t.Fatalf("getting frequent albums: %s", err)
+	}
+	if len(albums) != 1 || albums[0].Plays != 1 {
+		t.Errorf("unexpected frequently played albums: %+v", albums)
+	}
+
+	if _, err := lib.TopAlbums(ctx, StatsArgs{}, OrderByName); err == nil {
+		t.Errorf("expected error for albums ordered by name")
+	}
+}
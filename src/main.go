@@ -7,16 +7,22 @@ package src
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"time"
 
+	"github.com/ironsmile/euterpe/src/albuminfo"
 	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/artistinfo"
+	"github.com/ironsmile/euterpe/src/blobstore"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/daemon"
 	"github.com/ironsmile/euterpe/src/helpers"
@@ -54,10 +60,21 @@ var (
 	// doNotWatchDirs is controlled by the -dont-watch flag and will cause
 	// the program to cease watching music library directories for changes.
 	doNotWatchDirs bool
+
+	// checkConfig is controlled by the -check-config flag and will cause the
+	// program to validate its configuration, print the problems and exit.
+	checkConfig bool
+
+	// setOverrides are the configuration keys overridden with the -set flag.
+	setOverrides config.Overrides
 )
 
 const userAgentFormat = "Euterpe Media Server/%s (github.com/ironsmile/euterpe)"
 
+// artworkRetryInterval is how often albums and artists without images are checked
+// for ones which should be searched for again.
+const artworkRetryInterval = 15 * time.Minute
+
 func init() {
 	flag.StringVar(&pidFile, "p", "pidfile.pid",
 		"Lock file which will be used for making sure only one\n"+
@@ -74,12 +91,21 @@ func init() {
 	flag.BoolVar(&generateConfig, "config-gen", false,
 		"Generates configuration file and then exits. In case there is a\n"+
 			"configuration file already then do nothing.")
+	flag.BoolVar(&checkConfig, "check-config", false,
+		"Validates the configuration, prints all problems found in it and then\n"+
+			"exits. The exit status is non-zero when Euterpe cannot start with it.")
 	flag.BoolVar(&doNotWatchDirs, "dont-watch", false,
 		"Do not watch the library directories for changes. Any changes to\n"+
 			"files within the directories will take effect only after restart.\n"+
 			"Alternatively one could use the -rescan flag.\n\n"+
 			"This option is useful for systems with low open files limit such\n"+
 			"MacOS by default.")
+	flag.Var(&setOverrides, "set",
+		"Overrides a configuration key. Given as key=value where key is the path\n"+
+			"to it in the configuration file with dots between the levels. For\n"+
+			"example -set library_scan.disable=true. Lists are separated with\n"+
+			"commas or given as JSON arrays. Could be used more than once. Takes\n"+
+			"precedence over the EUTERPE_* environment variables.")
 }
 
 // Main is the only thing run in the project's root main.go file.
@@ -107,8 +133,12 @@ func Main(httpRootFS, htmlTemplatesFS, sqlFilesFS fs.FS) {
 		sqlFilesFS = os.DirFS("sqls")
 	}
 
+	if checkConfig {
+		os.Exit(runCheckConfig(appfs, os.Stdout))
+	}
+
 	if generateConfig {
-		if _, err := config.FindAndParse(appfs); err != nil {
+		if _, err := config.FindAndParse(appfs, setOverrides); err != nil {
 			fmt.Fprintf(os.Stderr, "Could not create config file: %s", err)
 			os.Exit(1)
 		}
@@ -150,9 +180,165 @@ func setupPidFileAndSignals(
 	}()
 }
 
+// setupReloadSignal makes the configuration reload on every one of the
+// daemon.ReloadSignals. Euterpe has been started with the configuration `started`.
+func setupReloadSignal(
+	appfs afero.Fs,
+	userPath string,
+	started config.Config,
+	lib *library.LocalLibrary,
+	srv *webserver.Server,
+) {
+	if len(daemon.ReloadSignals) == 0 {
+		return
+	}
+
+	signalChannel := make(chan os.Signal, 2)
+	for _, sig := range daemon.ReloadSignals {
+		signal.Notify(signalChannel, sig)
+	}
+	go func() {
+		running := started
+		for range signalChannel {
+			log.Println("Reload signal received. Reloading configuration.")
+			changed, err := parseConfig(appfs, userPath)
+			if err != nil {
+				log.Printf("Configuration not reloaded: %s\n", err)
+				continue
+			}
+
+			if errs := changed.Validate(); len(errs) > 0 {
+				logValidationErrors(errs)
+				if errs.Fatal() {
+					log.Println("Configuration not reloaded because of errors.")
+					continue
+				}
+			}
+
+			reloadConfig(started, running, changed, lib, srv)
+			running = changed
+		}
+	}()
+}
+
+// reloadConfig applies the differences between the `running` and the `changed`
+// configuration which are possible without a restart. The settings which require
+// a restart are only logged. Euterpe has been started with the `started`
+// configuration.
+func reloadConfig(
+	started config.Config,
+	running config.Config,
+	changed config.Config,
+	lib *library.LocalLibrary,
+	srv *webserver.Server,
+) {
+	for _, name := range config.RestartRequired(started, changed) {
+		log.Printf("Changing %q requires restart. It is not applied.\n", name)
+	}
+
+	if err := srv.Reload(changed); err != nil {
+		log.Printf("Reloading web server configuration failed: %s\n", err)
+	}
+
+	lib.SetScanConfig(changed.LibraryScan)
+
+	for _, path := range running.Libraries {
+		if !slices.Contains(changed.Libraries, path) {
+			log.Printf("Removing library path %s\n", path)
+			lib.RemoveLibraryPath(path)
+		}
+	}
+
+	var added bool
+	for _, path := range changed.Libraries {
+		if !slices.Contains(running.Libraries, path) {
+			log.Printf("Adding library path %s\n", path)
+			lib.AddLibraryPath(path)
+			added = true
+		}
+	}
+
+	if !added {
+		return
+	}
+
+	if changed.LibraryScan.Disable {
+		log.Println("Library scan is disabled. New paths will not be scanned.")
+		return
+	}
+
+	err := lib.StartScan(false)
+	if errors.Is(err, library.ErrScanRunning) {
+		log.Println("New library paths will be scanned with the next library scan.")
+	} else if err != nil {
+		log.Printf("Scanning new library paths failed: %s\n", err)
+	}
+}
+
+// parseConfig finds and parses the configuration. Its relative paths which are not
+// used directly are resolved against `userPath`.
+func parseConfig(appfs afero.Fs, userPath string) (config.Config, error) {
+	cfg, err := config.FindAndParse(appfs, setOverrides)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.SqliteDatabase = helpers.AbsolutePath(cfg.SqliteDatabase, userPath)
+	cfg.ACME.CacheDir = helpers.AbsolutePath(cfg.ACME.CacheDir, userPath)
+	if cfg.LogFile != "" {
+		cfg.LogFile = helpers.AbsolutePath(cfg.LogFile, userPath)
+	}
+
+	return cfg, nil
+}
+
+// logValidationErrors logs all problems found in the configuration.
+func logValidationErrors(errs config.ValidationErrors) {
+	for _, fieldErr := range errs {
+		if fieldErr.Fatal {
+			log.Printf("Configuration error: %s\n", fieldErr)
+		} else {
+			log.Printf("Configuration warning: %s\n", fieldErr)
+		}
+	}
+}
+
+// runCheckConfig validates the configuration and prints the problems found in
+// it to `out`. Returns the exit status for the program which is non-zero when
+// Euterpe cannot start with this configuration.
+func runCheckConfig(appfs afero.Fs, out io.Writer) int {
+	userPath, err := helpers.ProjectUserPath(appfs)
+	if err != nil {
+		fmt.Fprintf(out, "Cannot find [user_path]: %s\n", err)
+		return 1
+	}
+
+	cfg, err := parseConfig(appfs, userPath)
+	if err != nil {
+		fmt.Fprintf(out, "Parsing configuration %s: %s\n", config.UserConfigPath(appfs), err)
+		return 1
+	}
+
+	errs := cfg.Validate()
+	for _, fieldErr := range errs {
+		severity := "warning"
+		if fieldErr.Fatal {
+			severity = "error"
+		}
+		fmt.Fprintf(out, "%s: %s\n", severity, fieldErr)
+	}
+
+	if errs.Fatal() {
+		return 1
+	}
+
+	fmt.Fprintf(out, "Configuration %s is valid.\n", config.UserConfigPath(appfs))
+	return 0
+}
+
 // Returns a new Library object using the application config.
 // For the moment this is a LocalLibrary which will place its sqlite db file
-// in the UserPath directory
+// and the "blobs" directory with images in the UserPath directory
 func getLibrary(
 	ctx context.Context,
 	userPath string,
@@ -168,6 +354,7 @@ func getLibrary(
 	}
 
 	lib.ScanConfig = cfg.LibraryScan
+	lib.SetBlobStore(blobstore.New(afero.NewOsFs(), filepath.Join(userPath, "blobs")))
 
 	err = lib.Initialize()
 
@@ -179,26 +366,162 @@ func getLibrary(
 		lib.AddLibraryPath(path)
 	}
 
+	musicBrainz := musicBrainzClient(cfg)
+
+	sources, providers := artworkProviders(cfg, musicBrainz)
+	lib.SetArtworkSources(sources)
+	lib.SetArtworkSelection(cfg.Artwork.Selection)
+	if len(providers) > 0 {
+		lib.SetArtFinder(art.NewChain(providers...))
+	}
+
+	// Information about artists and albums is searched for on the internet only
+	// when downloading artwork is allowed.
 	if cfg.DownloadArtwork {
-		useragent := fmt.Sprintf(userAgentFormat, version.Version)
-		caf := art.NewClient(useragent, time.Second, cfg.DiscogsAuthToken)
-		lib.SetArtFinder(caf)
+		infoProviders := artistInfoProviders(cfg, musicBrainz)
+		if len(infoProviders) > 0 {
+			chain := artistinfo.NewChain(infoProviders...)
+			lib.SetArtistInfoFinder(chain, cfg.ArtistInfo.TTL)
+		}
+
+		if slices.Contains(cfg.AlbumInfo.Providers, config.AlbumInfoMusicBrainz) {
+			finder := albuminfo.NewMusicBrainz(musicBrainz())
+			lib.SetAlbumInfoFinder(finder, cfg.AlbumInfo.TTL)
+		}
 	}
 
 	return lib, nil
 }
 
+// musicBrainzClient returns a function which creates the MusicBrainz client on
+// its first call. The client is shared by all providers which use it so that
+// their requests to MusicBrainz are throttled together.
+func musicBrainzClient(cfg config.Config) func() *art.Client {
+	useragent := fmt.Sprintf(userAgentFormat, version.Version)
+
+	discogsToken := cfg.Artwork.Discogs.APIKey
+	if discogsToken == "" {
+		discogsToken = cfg.DiscogsAuthToken
+	}
+
+	var mbClient *art.Client
+	return func() *art.Client {
+		if mbClient == nil {
+			mbClient = art.NewClient(useragent, time.Second, discogsToken)
+		}
+		return mbClient
+	}
+}
+
+// artworkProviders returns the sources of artwork for the library and the providers
+// on the internet in the order from the configuration. The internet is tried at the
+// place of the first enabled provider on it.
+func artworkProviders(
+	cfg config.Config,
+	musicBrainz func() *art.Client,
+) ([]string, []art.Provider) {
+	useragent := fmt.Sprintf(userAgentFormat, version.Version)
+
+	var (
+		sources   []string
+		providers []art.Provider
+		seen      = make(map[string]bool)
+	)
+	for _, name := range cfg.Artwork.Providers {
+		providerCfg, ok := cfg.Artwork.Provider(name)
+		if !ok || !providerCfg.Enable || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var provider art.Provider
+		switch name {
+		case config.ArtworkLocal:
+			sources = append(sources, library.ArtworkSourceLocal)
+		case config.ArtworkEmbedded:
+			sources = append(sources, library.ArtworkSourceEmbedded)
+		case config.ArtworkCAA:
+			provider = art.NewCAAProvider(musicBrainz())
+		case config.ArtworkDiscogs:
+			provider = art.NewDiscogsProvider(musicBrainz())
+		case config.ArtworkDeezer:
+			provider = art.NewDeezer(useragent)
+		case config.ArtworkITunes:
+			provider = art.NewITunes(useragent)
+		case config.ArtworkFanartTV:
+			provider = art.NewFanartTV(useragent, providerCfg.APIKey, musicBrainz())
+		case config.ArtworkURLTemplate:
+			provider = art.NewURLTemplate(
+				useragent,
+				providerCfg.AlbumURL,
+				providerCfg.ArtistURL,
+			)
+		}
+
+		if provider == nil || !cfg.DownloadArtwork {
+			continue
+		}
+
+		providers = append(providers, art.Throttle(provider, providerCfg.RateLimit))
+		if !slices.Contains(sources, library.ArtworkSourceInternet) {
+			sources = append(sources, library.ArtworkSourceInternet)
+		}
+	}
+
+	return sources, providers
+}
+
+// artistInfoProviders returns the providers of information about artists in the
+// order from the configuration. Last.fm is skipped when there is no API key for it.
+func artistInfoProviders(
+	cfg config.Config,
+	musicBrainz func() *art.Client,
+) []artistinfo.Provider {
+	useragent := fmt.Sprintf(userAgentFormat, version.Version)
+
+	var providers []artistinfo.Provider
+	for _, name := range cfg.ArtistInfo.Providers {
+		switch name {
+		case config.ArtistInfoMusicBrainz:
+			providers = append(providers, artistinfo.NewMusicBrainz(musicBrainz()))
+		case config.ArtistInfoWikipedia:
+			providers = append(providers, artistinfo.NewWikipedia(
+				useragent,
+				cfg.ArtistInfo.Language,
+			))
+		case config.ArtistInfoLastFM:
+			if cfg.ArtistInfo.LastFMAPIKey == "" {
+				continue
+			}
+			providers = append(providers, artistinfo.NewLastFM(
+				useragent,
+				cfg.ArtistInfo.LastFMAPIURL,
+				cfg.ArtistInfo.LastFMAPIKey,
+			))
+		}
+	}
+
+	return providers
+}
+
 // runServer parses the config, sets the logfile, setups the
 // pidfile, and makes an signal handler goroutine
 func runServer(appfs afero.Fs, httpRootFS, htmlTemplatesFS, sqlFilesFS fs.FS) error {
-	cfg, err := config.FindAndParse(appfs)
+	userPath, err := helpers.ProjectUserPath(appfs)
 	if err != nil {
-		return fmt.Errorf("parsing configuration: %s", err)
+		return fmt.Errorf("cannot find [user_path]: %w", err)
 	}
 
-	userPath, err := helpers.ProjectUserPath(appfs)
+	cfg, err := parseConfig(appfs, userPath)
 	if err != nil {
-		return fmt.Errorf("cannot find [user_path]: %w", err)
+		return fmt.Errorf("parsing configuration: %s", err)
+	}
+
+	if errs := cfg.Validate(); len(errs) > 0 {
+		logValidationErrors(errs)
+		if errs.Fatal() {
+			return errors.New("not starting because of configuration errors")
+		}
 	}
 
 	if !debug {
@@ -236,9 +559,13 @@ func runServer(appfs afero.Fs, httpRootFS, htmlTemplatesFS, sqlFilesFS fs.FS) er
 		go lib.Scan()
 	}
 
+	lib.StartArtworkRetries(artworkRetryInterval)
+
 	log.Printf("Release %s\n", version.Version)
 	srv := webserver.NewServer(ctx, cfg, lib, httpRootFS, htmlTemplatesFS)
+	srv.SetScaler(scl)
 	srv.Serve()
+	setupReloadSignal(appfs, userPath, cfg, lib, srv)
 	srv.Wait()
 	return nil
 }
@@ -258,7 +585,7 @@ func runLibraryRescan(appfs afero.Fs, sqlFilesFS fs.FS) error {
 		}
 	}()
 
-	cfg, err := config.FindAndParse(appfs)
+	cfg, err := config.FindAndParse(appfs, setOverrides)
 	if err != nil {
 		return fmt.Errorf("parsing configuration: %s", err)
 	}
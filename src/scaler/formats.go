@@ -0,0 +1,46 @@
+package scaler
+
+import (
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// Format is an image format in which scaled images could be encoded. Its value
+// is the MIME type of the format.
+type Format string
+
+// All the formats in which images could be encoded. The standard library and
+// the x/image repository have no encoders for formats such as WebP and AVIF so
+// images are never produced in them.
+const (
+	FormatJPEG Format = "image/jpeg"
+	FormatPNG  Format = "image/png"
+)
+
+// ErrUnsupportedFormat is returned when scaling to a format for which there is
+// no encoder.
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Encoder writes `img` into `w` in a particular image format.
+type Encoder func(w io.Writer, img image.Image) error
+
+var encoders = map[Format]Encoder{
+	FormatJPEG: func(w io.Writer, img image.Image) error {
+		return jpeg.Encode(w, img, nil)
+	},
+	FormatPNG: png.Encode,
+}
+
+// CanEncode returns true when images could be scaled to `format`.
+func CanEncode(format Format) bool {
+	_, ok := encoderFor(format)
+	return ok
+}
+
+func encoderFor(format Format) (Encoder, bool) {
+	enc, ok := encoders[format]
+	return enc, ok
+}
@@ -5,15 +5,13 @@ import (
 	"context"
 	"fmt"
 	"image"
-	"image/jpeg"
 	"io"
 	"runtime"
 	"sync"
 
 	// The following are all image formats supported for converting
-	// to other image sizes.
+	// to other image sizes. JPEG and PNG are imported in formats.go.
 	_ "image/gif"
-	_ "image/png"
 
 	// Additional image formats from the x repository.
 	_ "golang.org/x/image/bmp"
@@ -36,6 +34,9 @@ type description struct {
 	// with this width.
 	ToWidth int
 
+	// Encode writes the result image in the requested format.
+	Encode Encoder
+
 	// ImgR is the source of the image which will be scaled.
 	ImgR io.Reader
 
@@ -60,6 +61,15 @@ type Scaler interface {
 	// preserving its aspect ratio.
 	Scale(ctx context.Context, img io.Reader, toWidth int) ([]byte, error)
 
+	// ScaleTo is like Scale but encodes the result in `format`. When toWidth
+	// is zero the image keeps its size and is only converted to `format`.
+	ScaleTo(
+		ctx context.Context,
+		img io.Reader,
+		toWidth int,
+		format Format,
+	) ([]byte, error)
+
 	// Cancel stops the scaler and of its operations. Users may not use
 	// any further methods on cancelled scalers.
 	Cancel()
@@ -81,6 +91,22 @@ func (s *scaler) Scale(
 	img io.Reader,
 	toWidth int,
 ) ([]byte, error) {
+	return s.ScaleTo(ctx, img, toWidth, FormatJPEG)
+}
+
+// ScaleTo is like Scale but encodes the result in `format`. When toWidth
+// is zero the image keeps its size and is only converted to `format`.
+func (s *scaler) ScaleTo(
+	ctx context.Context,
+	img io.Reader,
+	toWidth int,
+	format Format,
+) ([]byte, error) {
+	encode, ok := encoderFor(format)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
 	s.mx.RLock()
 	stopped := s.stopped
 	s.mx.RUnlock()
@@ -92,6 +118,7 @@ func (s *scaler) Scale(
 	desc := description{
 		ImgR:    img,
 		ToWidth: toWidth,
+		Encode:  encode,
 		Result:  make(chan Result),
 	}
 
@@ -111,7 +138,7 @@ func (s *scaler) Scale(
 
 func (s *scaler) worker() error {
 	for desc := range s.work {
-		imgData, err := s.scaleImage(desc.ImgR, desc.ToWidth)
+		imgData, err := s.scaleImage(desc.ImgR, desc.ToWidth, desc.Encode)
 		desc.Result <- Result{
 			ImgData: imgData,
 			Err:     err,
@@ -121,16 +148,24 @@ func (s *scaler) worker() error {
 	return nil
 }
 
-func (s *scaler) scaleImage(imgReader io.Reader, toWidth int) ([]byte, error) {
+func (s *scaler) scaleImage(
+	imgReader io.Reader,
+	toWidth int,
+	encode Encoder,
+) ([]byte, error) {
 	img, _, err := image.Decode(imgReader)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding image: %w", err)
 	}
 
-	toHeight := toWidth
 	imgRect := img.Bounds()
 	imgw := imgRect.Max.X - imgRect.Min.X
 	imgh := imgRect.Max.Y - imgRect.Min.Y
+	if toWidth <= 0 {
+		toWidth = imgw
+	}
+
+	toHeight := toWidth
 	if imgw != imgh {
 		toHeight = int((float32(imgh) / float32(imgw)) * float32(toWidth))
 	}
@@ -146,12 +181,12 @@ func (s *scaler) scaleImage(imgReader io.Reader, toWidth int) ([]byte, error) {
 		nil,
 	)
 
-	var dstJPEG bytes.Buffer
-	if err := jpeg.Encode(&dstJPEG, dst, nil); err != nil {
+	var dstBuf bytes.Buffer
+	if err := encode(&dstBuf, dst); err != nil {
 		return nil, fmt.Errorf("encoding image: %w", err)
 	}
 
-	return dstJPEG.Bytes(), nil
+	return dstBuf.Bytes(), nil
 }
 
 func (s *scaler) watchCtx(ctx context.Context) func() error {
@@ -137,3 +137,60 @@ func TestScalerCancel(t *testing.T) {
 	}
 
 }
+
+// TestScaleToFormats checks that images are encoded in the requested formats and
+// that they keep their size when no width is given.
+func TestScaleToFormats(t *testing.T) {
+	testImg := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	imgBuf := new(bytes.Buffer)
+	if err := png.Encode(imgBuf, testImg); err != nil {
+		t.Fatalf("could not encode test image: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sclr := scaler.New(ctx)
+	defer sclr.Cancel()
+
+	tests := []struct {
+		format        scaler.Format
+		toWidth       int
+		expectedName  string
+		expectedWidth int
+	}{
+		{scaler.FormatPNG, 0, "png", 40},
+		{scaler.FormatPNG, 20, "png", 20},
+		{scaler.FormatJPEG, 10, "jpeg", 10},
+	}
+
+	for _, test := range tests {
+		imgBytes, err := sclr.ScaleTo(
+			ctx,
+			bytes.NewReader(imgBuf.Bytes()),
+			test.toWidth,
+			test.format,
+		)
+		if err != nil {
+			t.Fatalf("scaling to %s: %s", test.format, err)
+		}
+
+		cfg, name, err := image.DecodeConfig(bytes.NewReader(imgBytes))
+		if err != nil {
+			t.Fatalf("decoding %s image: %s", test.format, err)
+		}
+		if name != test.expectedName || cfg.Width != test.expectedWidth {
+			t.Errorf("expected %s with width %d but got %s with width %d",
+				test.expectedName, test.expectedWidth, name, cfg.Width)
+		}
+	}
+
+	avif := scaler.Format("image/avif")
+	if scaler.CanEncode(avif) {
+		t.Fatalf("AVIF is not expected to have an encoder")
+	}
+	_, err := sclr.ScaleTo(ctx, bytes.NewReader(imgBuf.Bytes()), 10, avif)
+	if !errors.Is(err, scaler.ErrUnsupportedFormat) {
+		t.Errorf("expected unsupported format error but got: %v", err)
+	}
+}
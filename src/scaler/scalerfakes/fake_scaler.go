@@ -29,6 +29,22 @@ type FakeScaler struct {
 		result1 []byte
 		result2 error
 	}
+	ScaleToStub        func(context.Context, io.Reader, int, scaler.Format) ([]byte, error)
+	scaleToMutex       sync.RWMutex
+	scaleToArgsForCall []struct {
+		arg1 context.Context
+		arg2 io.Reader
+		arg3 int
+		arg4 scaler.Format
+	}
+	scaleToReturns struct {
+		result1 []byte
+		result2 error
+	}
+	scaleToReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -123,6 +139,73 @@ func (fake *FakeScaler) ScaleReturnsOnCall(i int, result1 []byte, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeScaler) ScaleTo(arg1 context.Context, arg2 io.Reader, arg3 int, arg4 scaler.Format) ([]byte, error) {
+	fake.scaleToMutex.Lock()
+	ret, specificReturn := fake.scaleToReturnsOnCall[len(fake.scaleToArgsForCall)]
+	fake.scaleToArgsForCall = append(fake.scaleToArgsForCall, struct {
+		arg1 context.Context
+		arg2 io.Reader
+		arg3 int
+		arg4 scaler.Format
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ScaleToStub
+	fakeReturns := fake.scaleToReturns
+	fake.recordInvocation("ScaleTo", []interface{}{arg1, arg2, arg3, arg4})
+	fake.scaleToMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeScaler) ScaleToCallCount() int {
+	fake.scaleToMutex.RLock()
+	defer fake.scaleToMutex.RUnlock()
+	return len(fake.scaleToArgsForCall)
+}
+
+func (fake *FakeScaler) ScaleToCalls(stub func(context.Context, io.Reader, int, scaler.Format) ([]byte, error)) {
+	fake.scaleToMutex.Lock()
+	defer fake.scaleToMutex.Unlock()
+	fake.ScaleToStub = stub
+}
+
+func (fake *FakeScaler) ScaleToArgsForCall(i int) (context.Context, io.Reader, int, scaler.Format) {
+	fake.scaleToMutex.RLock()
+	defer fake.scaleToMutex.RUnlock()
+	argsForCall := fake.scaleToArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeScaler) ScaleToReturns(result1 []byte, result2 error) {
+	fake.scaleToMutex.Lock()
+	defer fake.scaleToMutex.Unlock()
+	fake.ScaleToStub = nil
+	fake.scaleToReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScaler) ScaleToReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.scaleToMutex.Lock()
+	defer fake.scaleToMutex.Unlock()
+	fake.ScaleToStub = nil
+	if fake.scaleToReturnsOnCall == nil {
+		fake.scaleToReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.scaleToReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeScaler) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -130,6 +213,8 @@ func (fake *FakeScaler) Invocations() map[string][][]interface{} {
 	defer fake.cancelMutex.RUnlock()
 	fake.scaleMutex.RLock()
 	defer fake.scaleMutex.RUnlock()
+	fake.scaleToMutex.RLock()
+	defer fake.scaleToMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,53 @@
+// Package acme obtains and renews TLS certificates from an ACME certificate
+// authority such as Let's Encrypt. Certificates are requested on the first TLS
+// connection for a configured domain and renewed in the background before they
+// expire. Both the HTTP-01 and the TLS-ALPN-01 challenges are supported.
+package acme
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/ironsmile/euterpe/src/config"
+)
+
+// New returns a certificate manager configured with `cfg`. Requests to the
+// certificate authority are made with `client`. When it is nil the
+// http.DefaultClient is used. Returns nil when there are no domains in the
+// configuration.
+//
+// The returned manager's TLSConfig answers the TLS-ALPN-01 challenges and its
+// HTTPHandler answers the HTTP-01 challenges.
+func New(cfg config.ACME, client *http.Client) (*autocert.Manager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, nil
+	}
+
+	if cfg.CacheDir == "" {
+		return nil, errors.New("cache directory must not be empty")
+	}
+
+	domains := make([]string, 0, len(cfg.Domains))
+	for _, domain := range cfg.Domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if err := config.CheckACMEDomain(domain); err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Email:      cfg.Email,
+		Client: &acme.Client{
+			DirectoryURL: cfg.DirectoryURL,
+			HTTPClient:   client,
+		},
+	}, nil
+}
@@ -0,0 +1,152 @@
+package acme_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/webserver/acme"
+)
+
+// TestNew makes sure that wrong configuration is rejected and that only the
+// configured domains are allowed.
+func TestNew(t *testing.T) {
+	manager, err := acme.New(config.ACME{CacheDir: t.TempDir()}, nil)
+	if err != nil || manager != nil {
+		t.Fatalf("expected nil manager without domains, got %v, %v", manager, err)
+	}
+
+	wrong := []config.ACME{
+		{Domains: []string{"music.example.com"}},
+		{Domains: []string{"music.example.com", ""}, CacheDir: "acme"},
+		{Domains: []string{"127.0.0.1"}, CacheDir: "acme"},
+		{Domains: []string{"localhost"}, CacheDir: "acme"},
+	}
+	for _, cfg := range wrong {
+		if _, err := acme.New(cfg, nil); err == nil {
+			t.Errorf("expected error for config %+v", cfg)
+		}
+	}
+
+	manager, err = acme.New(config.ACME{
+		Domains:      []string{"Music.Example.com "},
+		Email:        "admin@example.com",
+		DirectoryURL: "https://acme.example.com/directory",
+		CacheDir:     t.TempDir(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := manager.HostPolicy(t.Context(), "music.example.com"); err != nil {
+		t.Errorf("configured domain was not allowed: %s", err)
+	}
+	if err := manager.HostPolicy(t.Context(), "other.example.com"); err == nil {
+		t.Errorf("domain which is not configured was allowed")
+	}
+	if manager.Client.DirectoryURL != "https://acme.example.com/directory" {
+		t.Errorf("wrong directory URL: %s", manager.Client.DirectoryURL)
+	}
+
+	hasALPN := false
+	for _, proto := range manager.TLSConfig().NextProtos {
+		if proto == "acme-tls/1" {
+			hasALPN = true
+		}
+	}
+	if !hasALPN {
+		t.Errorf("TLS config does not answer TLS-ALPN-01 challenges")
+	}
+}
+
+// TestPebble obtains a certificate from a locally running Pebble ACME test server.
+// It is skipped unless EUTERPE_PEBBLE_DIRECTORY is set to Pebble's directory URL,
+// for example https://localhost:14000/dir.
+//
+// The challenges are answered on Pebble's default validation ports, 5002 for
+// HTTP-01 and 5001 for TLS-ALPN-01. So Pebble has to either resolve the domain
+// (EUTERPE_PEBBLE_DOMAIN, euterpe.test by default) to this machine or be started
+// with PEBBLE_VA_ALWAYS_VALID=1.
+func TestPebble(t *testing.T) {
+	directoryURL := os.Getenv("EUTERPE_PEBBLE_DIRECTORY")
+	if directoryURL == "" {
+		t.Skip("EUTERPE_PEBBLE_DIRECTORY is not set")
+	}
+
+	domain := os.Getenv("EUTERPE_PEBBLE_DOMAIN")
+	if domain == "" {
+		domain = "euterpe.test"
+	}
+
+	// Pebble serves its API with a certificate signed by its own test CA which
+	// is regenerated on every start.
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	cfg := config.ACME{
+		Domains:      []string{domain},
+		Email:        "admin@example.com",
+		DirectoryURL: directoryURL,
+		CacheDir:     t.TempDir(),
+	}
+
+	manager, err := acme.New(cfg, client)
+	if err != nil {
+		t.Fatalf("creating manager: %s", err)
+	}
+
+	if lsn, err := net.Listen("tcp", ":5002"); err != nil {
+		t.Logf("not answering HTTP-01 challenges: %s", err)
+	} else {
+		srv := &http.Server{Handler: manager.HTTPHandler(nil)}
+		go func() { _ = srv.Serve(lsn) }()
+		defer srv.Close()
+	}
+
+	if lsn, err := tls.Listen("tcp", ":5001", manager.TLSConfig()); err != nil {
+		t.Logf("not answering TLS-ALPN-01 challenges: %s", err)
+	} else {
+		srv := &http.Server{}
+		go func() { _ = srv.Serve(lsn) }()
+		defer srv.Close()
+	}
+
+	cert, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: domain})
+	if err != nil {
+		t.Fatalf("obtaining certificate: %s", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parsing certificate: %s", err)
+	}
+	if err := leaf.VerifyHostname(domain); err != nil {
+		t.Errorf("certificate is not for %s: %s", domain, err)
+	}
+
+	// A new manager with the same cache must not need the certificate authority.
+	cfg.DirectoryURL = "https://127.0.0.1:1/not-an-acme-server"
+	cached, err := acme.New(cfg, client)
+	if err != nil {
+		t.Fatalf("creating second manager: %s", err)
+	}
+
+	cachedCert, err := cached.GetCertificate(&tls.ClientHelloInfo{ServerName: domain})
+	if err != nil {
+		t.Fatalf("getting cached certificate: %s", err)
+	}
+	cachedLeaf, err := x509.ParseCertificate(cachedCert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parsing cached certificate: %s", err)
+	}
+	if cachedLeaf.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		t.Errorf("expected the cached certificate but got a different one")
+	}
+}
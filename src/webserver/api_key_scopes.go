@@ -0,0 +1,50 @@
+package webserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/apikeys"
+	"github.com/ironsmile/euterpe/src/webserver/subsonic"
+)
+
+// apiKeyHeader is the HTTP header in which clients send their API keys.
+const apiKeyHeader = "X-API-Key"
+
+// requiredScope returns the API key scope needed for serving `r`.
+//
+//   - Streaming and downloading media requires the stream scope.
+//   - Changing playlists requires the playlists:write scope.
+//   - All other read-only requests require the read scope.
+//   - Everything else, including managing devices and API keys, requires
+//     the admin scope. This includes all paths outside of the v1 API and the
+//     Subsonic API such as the pages for adding devices.
+func requiredScope(r *http.Request) apikeys.Scope {
+	if !strings.HasPrefix(r.URL.Path, "/v1/") &&
+		!strings.HasPrefix(r.URL.Path, subsonic.Prefix+"/") {
+		return apikeys.ScopeAdmin
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1")
+	readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	switch {
+	case strings.HasPrefix(path, "/devices"), strings.HasPrefix(path, "/api-keys"):
+		return apikeys.ScopeAdmin
+	case strings.HasPrefix(path, "/playlist"):
+		if readOnly {
+			return apikeys.ScopeRead
+		}
+		return apikeys.ScopePlaylistsWrite
+	case !readOnly:
+		return apikeys.ScopeAdmin
+	case strings.HasPrefix(path, "/file/"):
+		return apikeys.ScopeStream
+	case strings.HasPrefix(path, "/album/") && !strings.HasSuffix(path, "/artwork") &&
+		!strings.HasSuffix(path, "/artwork/candidates") &&
+		!strings.HasSuffix(path, "/info"):
+		return apikeys.ScopeStream
+	}
+
+	return apikeys.ScopeRead
+}
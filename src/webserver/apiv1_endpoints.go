@@ -4,32 +4,58 @@ import "net/http"
 
 // The following are URL Path endpoints for certain API calls.
 const (
-	APIv1EndpointAbout          = "/v1/about"
-	APIv1EndpointFile           = "/v1/file/{fileID}"
-	APIv1EndpointAlbumArtwork   = "/v1/album/{albumID}/artwork"
-	APIv1EndpointDownloadAlbum  = "/v1/album/{albumID}"
-	APIv1EndpointArtistImage    = "/v1/artist/{artistID}/image"
-	APIv1EndpointBrowse         = "/v1/browse"
-	APIv1EndpointSearchWithPath = "/v1/search/{searchQuery}"
-	APIv1EndpointSearch         = "/v1/search/"
-	APIv1EndpointLoginToken     = "/v1/login/token/"
-	APIv1EndpointRegisterToken  = "/v1/register/token/"
+	APIv1EndpointAbout             = "/v1/about"
+	APIv1EndpointFile              = "/v1/file/{fileID}"
+	APIv1EndpointFileLyrics        = "/v1/file/{fileID}/lyrics"
+	APIv1EndpointAlbumArtwork      = "/v1/album/{albumID}/artwork"
+	APIv1EndpointArtworkCandidates = "/v1/album/{albumID}/artwork/candidates"
+	APIv1EndpointDownloadAlbum     = "/v1/album/{albumID}"
+	APIv1EndpointAlbumInfo         = "/v1/album/{albumID}/info"
+	APIv1EndpointArtist            = "/v1/artist/{artistID}"
+	APIv1EndpointArtistRadio       = "/v1/radio/artist/{artistID}"
+	APIv1EndpointArtistImage       = "/v1/artist/{artistID}/image"
+	APIv1EndpointArtworkMisses     = "/v1/artwork/misses"
+	APIv1EndpointBrowse            = "/v1/browse"
+	APIv1EndpointSearchWithPath    = "/v1/search/{searchQuery}"
+	APIv1EndpointSearch            = "/v1/search/"
+	APIv1EndpointLoginToken        = "/v1/login/token/"
+	APIv1EndpointLoginRefresh      = "/v1/login/refresh"
+	APIv1EndpointRegisterToken     = "/v1/register/token/"
+	APIv1EndpointLibraryScan       = "/v1/library/scan"
+	APIv1EndpointHistory           = "/v1/history"
+	APIv1EndpointStats             = "/v1/stats"
 
 	APIv1EndpointPlaylists = "/v1/playlists"
 	APIv1EndpointPlaylist  = "/v1/playlist/{playlistID}"
+
+	APIv1EndpointDevices = "/v1/devices"
+	APIv1EndpointDevice  = "/v1/devices/{deviceID}"
+
+	APIv1EndpointAPIKeys = "/v1/api-keys"
+	APIv1EndpointAPIKey  = "/v1/api-keys/{keyID}"
 )
 
 // APIv1Methods defines on which HTTP methods APIv1 endpoints will respond to.
 // It is an uri_path => list of HTTP methods map.
 var APIv1Methods map[string][]string = map[string][]string{
-	APIv1EndpointAbout:          {http.MethodGet},
-	APIv1EndpointFile:           {http.MethodGet},
-	APIv1EndpointDownloadAlbum:  {http.MethodGet},
-	APIv1EndpointBrowse:         {http.MethodGet},
-	APIv1EndpointSearchWithPath: {http.MethodGet},
-	APIv1EndpointSearch:         {http.MethodGet},
-	APIv1EndpointLoginToken:     {http.MethodPost},
-	APIv1EndpointRegisterToken:  {http.MethodPost},
+	APIv1EndpointAbout:             {http.MethodGet},
+	APIv1EndpointFile:              {http.MethodGet},
+	APIv1EndpointFileLyrics:        {http.MethodGet},
+	APIv1EndpointDownloadAlbum:     {http.MethodGet},
+	APIv1EndpointAlbumInfo:         {http.MethodGet},
+	APIv1EndpointBrowse:            {http.MethodGet},
+	APIv1EndpointSearchWithPath:    {http.MethodGet},
+	APIv1EndpointSearch:            {http.MethodGet},
+	APIv1EndpointLoginToken:        {http.MethodPost},
+	APIv1EndpointLoginRefresh:      {http.MethodPost},
+	APIv1EndpointRegisterToken:     {http.MethodPost},
+	APIv1EndpointLibraryScan:       {http.MethodGet, http.MethodPost},
+	APIv1EndpointArtworkMisses:     {http.MethodGet},
+	APIv1EndpointArtworkCandidates: {http.MethodGet, http.MethodPost},
+	APIv1EndpointArtist:            {http.MethodGet},
+	APIv1EndpointArtistRadio:       {http.MethodGet},
+	APIv1EndpointHistory:           {http.MethodGet},
+	APIv1EndpointStats:             {http.MethodGet},
 	APIv1EndpointArtistImage: {
 		http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
 	},
@@ -41,4 +67,10 @@ var APIv1Methods map[string][]string = map[string][]string{
 	APIv1EndpointPlaylist: {
 		http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete,
 	},
+
+	APIv1EndpointDevices: {http.MethodGet},
+	APIv1EndpointDevice:  {http.MethodDelete},
+
+	APIv1EndpointAPIKeys: {http.MethodGet, http.MethodPost},
+	APIv1EndpointAPIKey:  {http.MethodDelete},
 }
@@ -0,0 +1,66 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// routeAPIv1Handler wraps a handler the same way the web server will do for the
+// v1 API `endpoint` when constructing the main application router. This is needed
+// for tests so that the Gorilla mux variables will be parsed.
+func routeAPIv1Handler(endpoint string, h http.Handler) http.Handler {
+	router := mux.NewRouter()
+	router.StrictSlash(true)
+	router.UseEncodedPath()
+	router.Handle(endpoint, h).Methods(webserver.APIv1Methods[endpoint]...)
+
+	return router
+}
+
+// getJSON makes a GET request to `h` for `url` and decodes its JSON response into
+// `v`. The test is stopped when the request is not successful.
+func getJSON(t *testing.T, h http.Handler, url string, v any) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s: expected status %d but got %d: %s",
+			url, http.StatusOK, resp.StatusCode, rec.Body)
+	}
+	assertContentTypeJSON(t, resp.Header.Get("Content-Type"))
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s: decoding response JSON: %s", url, err)
+	}
+}
+
+// statusTest is a GET request for url and the status of its response.
+type statusTest struct {
+	url    string
+	status int
+}
+
+// assertStatuses makes the GET requests from `tests` to `h` and checks the status
+// of their responses.
+func assertStatuses(t *testing.T, h http.Handler, tests []statusTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if status := rec.Result().StatusCode; status != test.status {
+			t.Errorf("%s: expected status %d but got %d", test.url, test.status, status)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package webserver
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+)
+
+var (
+	// accessTokenDuration is the lifetime of the access tokens which are given
+	// together with a refresh token.
+	accessTokenDuration = time.Hour
+
+	// refreshTokenDuration is the lifetime of the refresh tokens. Every refresh
+	// gives a new one so devices in use stay logged in.
+	refreshTokenDuration = rememberMeDuration
+)
+
+// errInvalidRefreshToken is returned when a refresh token could not be exchanged
+// for new tokens.
+var errInvalidRefreshToken = errors.New(invalidRefreshTokenText)
+
+// tokenIDContextKey is the request context key under which the AuthHandler stores
+// the ID of the token used for authenticating the request.
+type tokenIDContextKey struct{}
+
+// tokenIDFromContext returns the ID of the token used for authenticating the request
+// with context `ctx`. It is empty when the request was not authenticated with a
+// token.
+func tokenIDFromContext(ctx context.Context) string {
+	tokenID, _ := ctx.Value(tokenIDContextKey{}).(string)
+	return tokenID
+}
+
+// keyringFromAuth returns a keyring with the current and previous secrets in
+// `auth`.
+func keyringFromAuth(auth config.Auth) *tokens.Keyring {
+	return tokens.NewKeyring(auth.Secret, auth.PreviousSecrets...)
+}
+
+// newDeviceTokenPair registers a new device in `registry` and returns an access
+// and a refresh token for it. Every device has an unique ID which is returned too.
+// All of its tokens carry this ID so that they could be revoked together later.
+// With `rememberMe` the refresh tokens are marked as ones for web UI sessions
+// which outlive the browser session.
+func newDeviceTokenPair(
+	ctx context.Context,
+	registry devices.Registry,
+	keyring *tokens.Keyring,
+	subject string,
+	device devices.Device,
+	rememberMe bool,
+) (access []byte, refresh []byte, deviceID string, err error) {
+	deviceID = rand.Text()
+
+	access, refresh, _, err = signTokenPair(keyring, subject, deviceID, rememberMe)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	device.TokenID = deviceID
+	if _, err := registry.Register(ctx, device); err != nil {
+		return nil, nil, "", fmt.Errorf("registering device: %w", err)
+	}
+
+	return access, refresh, deviceID, nil
+}
+
+// newUnregisteredDeviceToken signs an access token which expires at `expiresAt`
+// for a new device. Unlike newDeviceToken the device is not registered here but
+// when the token is used for the first time. So tokens which are never used do
+// not fill up the list of devices.
+func newUnregisteredDeviceToken(
+	keyring *tokens.Keyring,
+	subject string,
+	expiresAt time.Time,
+) ([]byte, error) {
+	now := time.Now()
+
+	return keyring.Sign(tokens.Claims{
+		Payload: jwt.Payload{
+			Subject:        subject,
+			JWTID:          rand.Text(),
+			IssuedAt:       jwt.NumericDate(now),
+			ExpirationTime: jwt.NumericDate(expiresAt),
+		},
+		Device:        rand.Text(),
+		Use:           tokens.UseAccess,
+		RegisterOnUse: true,
+	})
+}
+
+// signTokenPair returns a short lived access token and a refresh token for the
+// device with `deviceID`. The unique ID of the refresh token is returned too.
+func signTokenPair(
+	keyring *tokens.Keyring,
+	subject string,
+	deviceID string,
+	rememberMe bool,
+) (access []byte, refresh []byte, refreshID string, err error) {
+	now := time.Now()
+
+	access, _, err = signToken(
+		keyring,
+		tokens.Claims{Device: deviceID, Use: tokens.UseAccess},
+		subject,
+		now.Add(accessTokenDuration),
+	)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	refresh, refreshID, err = signToken(
+		keyring,
+		tokens.Claims{Device: deviceID, Use: tokens.UseRefresh, RememberMe: rememberMe},
+		subject,
+		now.Add(refreshTokenDuration),
+	)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return access, refresh, refreshID, nil
+}
+
+// refreshTokenPair exchanges `refreshToken` for new access and refresh tokens
+// for the same device. Returns errInvalidRefreshToken when the token could not be
+// exchanged. Every refresh token could be used only once. A second use means
+// that it was stolen, either by the one using it now or by the one who used it
+// first. Which one is not known so the whole device is revoked.
+func refreshTokenPair(
+	ctx context.Context,
+	keyring *tokens.Keyring,
+	registry devices.Registry,
+	refreshToken []byte,
+) (claims tokens.Claims, access []byte, refresh []byte, err error) {
+	claims, err = keyring.Verify(refreshToken, time.Now())
+	if err != nil || claims.Use != tokens.UseRefresh {
+		return claims, nil, nil, errInvalidRefreshToken
+	}
+
+	err = registry.Check(ctx, claims.Device)
+	if errors.Is(err, devices.ErrNotFound) || errors.Is(err, devices.ErrRevoked) {
+		return claims, nil, nil, errInvalidRefreshToken
+	} else if err != nil {
+		return claims, nil, nil, fmt.Errorf("checking device: %w", err)
+	}
+
+	access, refresh, refreshID, err := signTokenPair(
+		keyring,
+		claims.Subject,
+		claims.Device,
+		claims.RememberMe,
+	)
+	if err != nil {
+		return claims, nil, nil, fmt.Errorf("generating JWT: %w", err)
+	}
+
+	err = registry.RotateRefreshToken(ctx, claims.Device, claims.JWTID, refreshID)
+	if errors.Is(err, devices.ErrRefreshTokenUsed) {
+		revokeErr := registry.RevokeToken(ctx, claims.Device)
+		if revokeErr != nil && !errors.Is(revokeErr, devices.ErrNotFound) {
+			return claims, nil, nil, fmt.Errorf(
+				"revoking device with reused refresh token: %w",
+				revokeErr,
+			)
+		}
+		log.Printf("Refresh token reused, revoked device %s\n", claims.Device)
+		return claims, nil, nil, errInvalidRefreshToken
+	} else if errors.Is(err, devices.ErrNotFound) {
+		return claims, nil, nil, errInvalidRefreshToken
+	} else if err != nil {
+		return claims, nil, nil, fmt.Errorf("rotating refresh token: %w", err)
+	}
+
+	return claims, access, refresh, nil
+}
+
+// signToken signs a new token with an unique ID and the device and use from
+// `claims`. The ID is returned together with the token.
+func signToken(
+	keyring *tokens.Keyring,
+	claims tokens.Claims,
+	subject string,
+	expiresAt time.Time,
+) ([]byte, string, error) {
+	now := time.Now()
+	tokenID := rand.Text()
+
+	claims.Payload = jwt.Payload{
+		Subject:        subject,
+		JWTID:          tokenID,
+		IssuedAt:       jwt.NumericDate(now),
+		ExpirationTime: jwt.NumericDate(expiresAt),
+	}
+
+	token, err := keyring.Sign(claims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return token, tokenID, nil
+}
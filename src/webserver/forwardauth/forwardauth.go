@@ -0,0 +1,116 @@
+// Package forwardauth implements authentication by a reverse proxy in front of
+// Euterpe. Such proxies (Authelia, oauth2-proxy and the like) authenticate the user
+// themselves and then pass the user name to Euterpe in a HTTP header.
+package forwardauth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// anyUser could be used in the list of users in order to accept every user
+// authenticated by the proxy.
+const anyUser = "*"
+
+// Authenticator checks whether a request was authenticated by one of the trusted
+// proxies.
+//
+// All methods of a nil *Authenticator report requests as not authenticated.
+type Authenticator struct {
+	header  string
+	proxies []netip.Prefix
+	users   map[string]struct{}
+	user    string
+}
+
+// New returns an Authenticator configured with `cfg`. The user names set by the
+// proxy are mapped to the Euterpe `user`. Only names listed in the configuration
+// are accepted. When the list is empty only `user` itself is accepted. Returns nil
+// when forward authentication is not enabled in the configuration.
+func New(cfg config.ForwardAuth, user string) (*Authenticator, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+
+	if cfg.Header == "" {
+		return nil, errors.New("header must not be empty")
+	}
+
+	if len(cfg.Proxies) == 0 {
+		return nil, errors.New("at least one proxy address is required")
+	}
+
+	proxies, err := webutils.ParseTrustedProxies(cfg.Proxies)
+	if err != nil {
+		return nil, fmt.Errorf("parsing proxies: %w", err)
+	}
+
+	users := make(map[string]struct{}, len(cfg.Users))
+	for _, name := range cfg.Users {
+		users[name] = struct{}{}
+	}
+	if len(users) == 0 {
+		users[user] = struct{}{}
+	}
+
+	return &Authenticator{
+		header:  http.CanonicalHeaderKey(cfg.Header),
+		proxies: proxies,
+		users:   users,
+		user:    user,
+	}, nil
+}
+
+// Authenticate returns the Euterpe user when `r` was made directly by one of the
+// trusted proxies and the user name set by the proxy is accepted. The header is
+// ignored for requests from any other address.
+func (a *Authenticator) Authenticate(r *http.Request) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+
+	user := strings.TrimSpace(r.Header.Get(a.header))
+	if user == "" || !a.fromProxy(r) {
+		return "", false
+	}
+
+	if _, ok := a.users[user]; ok {
+		return a.user, true
+	}
+	if _, ok := a.users[anyUser]; ok {
+		return a.user, true
+	}
+
+	return "", false
+}
+
+// fromProxy returns true when the immediate peer of `r` is one of the trusted
+// proxies. The X-Forwarded-For header is not consulted on purpose. Only the proxy
+// itself is allowed to vouch for the user.
+func (a *Authenticator) fromProxy(r *http.Request) bool {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+
+	addr, err := netip.ParseAddr(remote)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range a.proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
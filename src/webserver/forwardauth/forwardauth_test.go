@@ -0,0 +1,121 @@
+package forwardauth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/config"
+)
+
+// TestNewErrors makes sure that wrong configuration is rejected and that disabled
+// forward authentication does not accept anything.
+func TestNewErrors(t *testing.T) {
+	auth, err := New(config.ForwardAuth{}, "user")
+	if err != nil || auth != nil {
+		t.Fatalf("expected nil authenticator for disabled config, got %v, %v", auth, err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Remote-User", "user")
+	if _, ok := auth.Authenticate(req); ok {
+		t.Errorf("nil authenticator accepted a request")
+	}
+
+	wrong := []config.ForwardAuth{
+		{Enable: true, Proxies: []string{"127.0.0.1"}},
+		{Enable: true, Header: "Remote-User"},
+		{Enable: true, Header: "Remote-User", Proxies: []string{"not-an-address"}},
+	}
+	for _, cfg := range wrong {
+		if _, err := New(cfg, "user"); err == nil {
+			t.Errorf("expected error for config %+v", cfg)
+		}
+	}
+}
+
+// TestAuthenticate checks which requests are accepted as authenticated by the
+// proxy.
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		desc       string
+		users      []string
+		remoteAddr string
+		header     string
+		expected   bool
+	}{
+		{
+			desc:       "configured user from proxy",
+			remoteAddr: "10.1.2.3:5555",
+			header:     "euterpe",
+			expected:   true,
+		},
+		{
+			desc:       "IPv4-mapped proxy address",
+			remoteAddr: "[::ffff:10.1.2.3]:5555",
+			header:     "euterpe",
+			expected:   true,
+		},
+		{
+			desc:       "request not from proxy",
+			remoteAddr: "192.0.2.10:5555",
+			header:     "euterpe",
+		},
+		{
+			desc:       "empty header",
+			remoteAddr: "10.1.2.3:5555",
+		},
+		{
+			desc:       "other user",
+			remoteAddr: "10.1.2.3:5555",
+			header:     "mallory",
+		},
+		{
+			desc:       "listed user",
+			users:      []string{"alice", "bob"},
+			remoteAddr: "10.1.2.3:5555",
+			header:     "bob",
+			expected:   true,
+		},
+		{
+			desc:       "configured user not in the list",
+			users:      []string{"alice"},
+			remoteAddr: "10.1.2.3:5555",
+			header:     "euterpe",
+		},
+		{
+			desc:       "any user",
+			users:      []string{"*"},
+			remoteAddr: "10.1.2.3:5555",
+			header:     "mallory",
+			expected:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			auth, err := New(config.ForwardAuth{
+				Enable:  true,
+				Header:  "X-Forwarded-User",
+				Proxies: []string{"10.0.0.0/8"},
+				Users:   test.users,
+			}, "euterpe")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.header != "" {
+				req.Header.Set("X-Forwarded-User", test.header)
+			}
+
+			user, ok := auth.Authenticate(req)
+			if ok != test.expected {
+				t.Fatalf("expected authenticated to be %t but it was %t", test.expected, ok)
+			}
+			if ok && user != "euterpe" {
+				t.Errorf("expected the Euterpe user but got `%s`", user)
+			}
+		})
+	}
+}
@@ -1,28 +1,44 @@
 package webserver
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"strings"
 	"time"
+
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
 )
 
+// authFailureMarker is added to the access log lines of requests which failed
+// to authenticate with username and password. It makes it easy to find them
+// with tools such as grep or fail2ban.
+const authFailureMarker = "AUTH_FAILURE"
+
 // AccessHandler is an http.Handler which wraps around another handler and prints
 // access logs.
 type AccessHandler struct {
 	wrapped http.Handler
+	trusted []netip.Prefix
 }
 
 // NewAccessHandler returns an AccessHandler which will call `h` and the log
-// information about the http request and response.
-func NewAccessHandler(h http.Handler) *AccessHandler {
+// information about the http request and response. When `trusted` proxies are
+// configured the log includes the address of the client behind them.
+func NewAccessHandler(h http.Handler, trusted []netip.Prefix) *AccessHandler {
 	return &AccessHandler{
 		wrapped: h,
+		trusted: trusted,
 	}
 }
 
 // ServeHTTP implements the http.Handler interface.
 func (h *AccessHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx, notes := webutils.WithAccessNotes(req.Context())
+	req = req.WithContext(ctx)
+
 	started := time.Now()
 	ww := newLoggedResponseWriter(w)
 	h.wrapped.ServeHTTP(ww, req)
@@ -42,12 +58,23 @@ func (h *AccessHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if t := query.Get("token"); t != "" {
 		query.Set("token", queryRedactedValue)
 	}
+	if k := query.Get("apiKey"); k != "" {
+		query.Set("apiKey", queryRedactedValue)
+	}
 	reqURL.RawQuery = query.Encode()
 
+	var extra strings.Builder
+	if len(h.trusted) > 0 {
+		fmt.Fprintf(&extra, " clientIP=%s", webutils.ClientIP(req, h.trusted))
+	}
+	if user, failed := notes.AuthFailure(); failed {
+		fmt.Fprintf(&extra, " %s user=%q", authFailureMarker, user)
+	}
+
 	log.Printf(
-		"%s %s dur=%s status=%d userAgent=%s remoteAddr=%s\n",
+		"%s %s dur=%s status=%d userAgent=%s remoteAddr=%s%s\n",
 		req.Method, reqURL, elapsed, ww.code, req.Header.Get("User-Agent"),
-		req.RemoteAddr,
+		req.RemoteAddr, extra.String(),
 	)
 }
 
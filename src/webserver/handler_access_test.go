@@ -6,24 +6,27 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
 )
 
 // TestAccessHandler makes sure that the access handler works and also sensitive
 // that strings are not stored into the access log. These are passwords and tokens.
 func TestAccessHandler(t *testing.T) {
 	recorder := &recordingHandler{}
-	accessHandler := webserver.NewAccessHandler(recorder)
+	accessHandler := webserver.NewAccessHandler(recorder, nil)
 
 	const (
 		pass    = "hidden-subsonic-password"
 		ssToken = "hidden-subsonic-token"
 		salt    = "hidden-subsonic-salt"
 		token   = "hidden-token"
+		apiKey  = "hidden-api-key"
 	)
 
 	buffer := &bytes.Buffer{}
@@ -35,8 +38,8 @@ func TestAccessHandler(t *testing.T) {
 	req := httptest.NewRequest(
 		http.MethodGet,
 		fmt.Sprintf(
-			"/v1/api?p=%s&t=%s&s=%s&token=%s&unrelated=5",
-			pass, ssToken, salt, token,
+			"/v1/api?p=%s&t=%s&s=%s&token=%s&apiKey=%s&unrelated=5",
+			pass, ssToken, salt, token, apiKey,
 		),
 		nil,
 	)
@@ -79,6 +82,48 @@ func TestAccessHandler(t *testing.T) {
 	if strings.Contains(logged, token) {
 		t.Errorf("access log did not hide the `token` query value")
 	}
+
+	if strings.Contains(logged, apiKey) {
+		t.Errorf("access log did not hide the `apiKey` query value")
+	}
+
+	if strings.Contains(logged, "AUTH_FAILURE") {
+		t.Errorf("access log marked a request without failed authentication")
+	}
+}
+
+// TestAccessHandlerAuthFailure checks that requests with failed authentication
+// are marked in the access log together with the real client address.
+func TestAccessHandlerAuthFailure(t *testing.T) {
+	wrapped := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webutils.MarkAuthFailure(r.Context(), "mallory")
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	accessHandler := webserver.NewAccessHandler(wrapped, trusted)
+
+	buffer := &bytes.Buffer{}
+	log.Default().SetOutput(buffer)
+	defer func() {
+		log.Default().SetOutput(os.Stdout)
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/login/token/", nil)
+	req.RemoteAddr = "10.0.0.2:4433"
+	req.Header.Set("X-Forwarded-For", "192.0.2.15")
+
+	accessHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	logged := buffer.String()
+	t.Logf("ACCESS LOG BUFFER: %s\n", logged)
+
+	if !strings.Contains(logged, `AUTH_FAILURE user="mallory"`) {
+		t.Errorf("access log did not mark the failed authentication")
+	}
+
+	if !strings.Contains(logged, "clientIP=192.0.2.15") {
+		t.Errorf("access log did not include the client address behind the proxy")
+	}
 }
 
 type recordingHandler struct {
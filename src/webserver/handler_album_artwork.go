@@ -14,6 +14,7 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/imagevariant"
 )
 
 // AlbumArtworkHandler is a http.Handler which will find and serve the artwork of
@@ -22,6 +23,7 @@ type AlbumArtworkHandler struct {
 	artworkManager library.ArtworkManager
 	rootFS         fs.FS
 	notFoundPath   string
+	variants       *imagevariant.Cache
 }
 
 // ServeHTTP is required by the http.Handler's interface
@@ -58,7 +60,7 @@ func (aah AlbumArtworkHandler) ServeHTTP(writer http.ResponseWriter, req *http.R
 }
 
 // Find searches through the library for the artwork of an album and serves
-// it as a raw image
+// it as a raw image in the size and format requested by the client
 func (aah AlbumArtworkHandler) Find(
 	writer http.ResponseWriter,
 	req *http.Request,
@@ -100,18 +102,7 @@ func (aah AlbumArtworkHandler) Find(
 	defer imgReader.Close()
 
 	writer.Header().Set("Cache-Control", "max-age=604800")
-	if req.Method == http.MethodHead {
-		n, _ := io.Copy(io.Discard, imgReader)
-		writer.Header().Set("Content-Length", strconv.FormatInt(n, 10))
-		return nil
-	}
-
-	_, err = io.Copy(writer, imgReader)
-	if err != nil {
-		log.Printf("еrror sending HTTP data for artwork %d: %s", id, err)
-	}
-
-	return nil
+	return aah.variants.Serve(writer, req, imgReader)
 }
 
 func (aah AlbumArtworkHandler) remove(
@@ -150,16 +141,19 @@ func (aah AlbumArtworkHandler) upload(
 }
 
 // NewAlbumArtworkHandler returns a new Album artwork handler.
-// It needs an implementation of the ArtworkManager.
+// It needs an implementation of the ArtworkManager. Images are scaled and
+// converted using `variants` which may be nil.
 func NewAlbumArtworkHandler(
 	am library.ArtworkManager,
 	httpRootFS fs.FS,
 	notFoundImagePath string,
+	variants *imagevariant.Cache,
 ) *AlbumArtworkHandler {
 
 	return &AlbumArtworkHandler{
 		rootFS:         httpRootFS,
 		artworkManager: am,
 		notFoundPath:   notFoundImagePath,
+		variants:       variants,
 	}
 }
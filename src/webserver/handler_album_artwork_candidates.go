@@ -0,0 +1,115 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// artworkCandidatesHandler lists the images which could be used as the artwork of
+// an album and pins one of them as its artwork.
+type artworkCandidatesHandler struct {
+	artworkManager library.ArtworkManager
+}
+
+// pinArtworkRequest is the body of the requests for pinning artwork.
+type pinArtworkRequest struct {
+	ID string `json:"id"`
+}
+
+// NewAlbumArtworkCandidatesHandler returns an HTTP handler which lists the artwork
+// candidates of an album on GET. On POST it makes the candidate with the ID from
+// the request body the artwork of the album.
+func NewAlbumArtworkCandidatesHandler(am library.ArtworkManager) http.Handler {
+	return &artworkCandidatesHandler{
+		artworkManager: am,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *artworkCandidatesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	albumID, err := strconv.ParseInt(mux.Vars(req)["albumID"], 10, 64)
+	if err != nil {
+		webutils.JSONError(w, "album not found", http.StatusNotFound)
+		return
+	}
+
+	// Searching for candidates on the internet could take a while.
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Minute)
+	defer cancel()
+
+	if req.Method == http.MethodPost {
+		h.pin(ctx, w, req, albumID)
+		return
+	}
+
+	candidates, err := h.artworkManager.GetAlbumArtworkCandidates(ctx, albumID)
+	if errors.Is(err, library.ErrAlbumNotFound) {
+		webutils.JSONError(w, "album not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Getting artwork candidates failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(candidates); err != nil {
+		log.Printf("error writing artwork candidates response: %s", err)
+	}
+}
+
+func (h *artworkCandidatesHandler) pin(
+	ctx context.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	albumID int64,
+) {
+	var params pinArtworkRequest
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&params); err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("cannot parse request body: %s", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if params.ID == "" {
+		webutils.JSONError(w, "candidate id is required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.artworkManager.PinAlbumArtwork(ctx, albumID, params.ID)
+	if errors.Is(err, library.ErrAlbumNotFound) {
+		webutils.JSONError(w, "album not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, library.ErrArtworkNotFound) {
+		webutils.JSONError(w, "artwork candidate not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Pinning artwork failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestAlbumArtworkCandidatesHandler checks that artwork candidates are listed and
+// pinned and that errors from the library are reported with the right status.
+func TestAlbumArtworkCandidatesHandler(t *testing.T) {
+	am := &libraryfakes.FakeArtworkManager{}
+	am.GetAlbumArtworkCandidatesReturns([]library.ArtworkCandidate{
+		{ID: "local:cover.jpg", Source: "local", Path: "cover.jpg", Width: 500, Height: 500},
+		{ID: "provider:caa", Source: "caa", Width: 1200, Height: 1200, Current: true},
+	}, nil)
+
+	router := mux.NewRouter()
+	router.Handle(
+		webserver.APIv1EndpointArtworkCandidates,
+		webserver.NewAlbumArtworkCandidatesHandler(am),
+	).Methods(webserver.APIv1Methods[webserver.APIv1EndpointArtworkCandidates]...)
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(
+			method,
+			"/v1/album/42/artwork/candidates",
+			strings.NewReader(body),
+		)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, rec.Code)
+	}
+
+	var candidates []library.ArtworkCandidate
+	if err := json.NewDecoder(rec.Body).Decode(&candidates); err != nil {
+		t.Fatalf("decoding response JSON: %s", err)
+	}
+	if len(candidates) != 2 || candidates[1].Source != "caa" || !candidates[1].Current {
+		t.Errorf("unexpected candidates: %+v", candidates)
+	}
+	if _, albumID := am.GetAlbumArtworkCandidatesArgsForCall(0); albumID != 42 {
+		t.Errorf("candidates for album %d were listed instead of 42", albumID)
+	}
+
+	rec = serve(http.MethodPost, `{"id": "provider:caa"}`)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected status %d for pinning but got %d: %s",
+			http.StatusNoContent, rec.Code, rec.Body)
+	}
+	if _, albumID, id := am.PinAlbumArtworkArgsForCall(0); albumID != 42 || id != "provider:caa" {
+		t.Errorf("wrong pinned candidate %s for album %d", id, albumID)
+	}
+
+	for _, body := range []string{`not json`, `{}`} {
+		if rec := serve(http.MethodPost, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for body %s but got %d",
+				http.StatusBadRequest, body, rec.Code)
+		}
+	}
+
+	am.PinAlbumArtworkReturns(library.ErrArtworkNotFound)
+	if rec := serve(http.MethodPost, `{"id": "local:missing.jpg"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for missing candidate but got %d",
+			http.StatusNotFound, rec.Code)
+	}
+
+	am.GetAlbumArtworkCandidatesReturns(nil, library.ErrAlbumNotFound)
+	if rec := serve(http.MethodGet, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for missing album but got %d",
+			http.StatusNotFound, rec.Code)
+	}
+
+	am.GetAlbumArtworkCandidatesReturns(nil, errors.New("db is gone"))
+	if rec := serve(http.MethodGet, ""); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d on error but got %d",
+			http.StatusInternalServerError, rec.Code)
+	}
+}
@@ -66,6 +66,7 @@ func TestAlbumArtworkHandler(t *testing.T) {
 		fakeAM,
 		testFS,
 		notFoundImage,
+		nil,
 	)
 
 	// Try with malformed URL which should return "not found" when variables are
@@ -204,6 +205,7 @@ func TestAlbumArtworkHandlerDELETE(t *testing.T) {
 		fakeAM,
 		testFS,
 		notFoundImageContents,
+		nil,
 	)
 	router := routeAlbumArtworkHandler(aimgHandler)
 
@@ -292,6 +294,7 @@ func TestAlbumArtworkHandlerPUT(t *testing.T) {
 				test.aim,
 				testFS,
 				notFoundImageContents,
+				nil,
 			)
 			router := routeAlbumArtworkHandler(aimgHandler)
 
@@ -329,6 +332,7 @@ func TestAlbumArtworkHandlerPUT(t *testing.T) {
 		fakeAIM,
 		testFS,
 		notFoundImageContents,
+		nil,
 	)
 	router := routeAlbumArtworkHandler(aimgHandler)
 
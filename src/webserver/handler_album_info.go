@@ -0,0 +1,30 @@
+package webserver
+
+import (
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// albumInfoResponse is the album from the library together with what is known
+// about its release.
+type albumInfoResponse struct {
+	library.Album
+	library.AlbumInfo
+}
+
+// NewAlbumInfoHandler returns an HTTP handler which serves an album identified by
+// its ID together with its label, catalog number, release date and types and notes
+// about it.
+func NewAlbumInfoHandler(lib library.Library) http.Handler {
+	return &itemInfoHandler[library.Album, library.AlbumInfo]{
+		name:     "album",
+		idVar:    "albumID",
+		notFound: library.ErrAlbumNotFound,
+		getItem:  lib.GetAlbum,
+		getInfo:  lib.FindAlbumInfo,
+		response: func(album library.Album, info library.AlbumInfo) any {
+			return albumInfoResponse{Album: album, AlbumInfo: info}
+		},
+	}
+}
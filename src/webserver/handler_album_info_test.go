@@ -0,0 +1,77 @@
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestAlbumInfoHandler checks that an album is returned together with what is
+// known about its release and that errors for missing albums and failed searches
+// are returned.
+func TestAlbumInfoHandler(t *testing.T) {
+	const (
+		albumID        = 42
+		failingInfoID  = 43
+		missingAlbumID = 13
+	)
+
+	lib := &libraryfakes.FakeLibrary{
+		GetAlbumStub: func(_ context.Context, id int64) (library.Album, error) {
+			if id == missingAlbumID {
+				return library.Album{}, library.ErrAlbumNotFound
+			}
+			return library.Album{
+				ID:        id,
+				Name:      "The Number of the Beast",
+				Artist:    "Iron Maiden",
+				SongCount: 8,
+			}, nil
+		},
+		FindAlbumInfoStub: func(_ context.Context, id int64) (library.AlbumInfo, error) {
+			if id == failingInfoID {
+				return library.AlbumInfo{}, errors.New("database is gone")
+			}
+			return library.AlbumInfo{
+				MusicBrainzID:       "6fd8d6e4-3e2f-3ae2-b1ec-7e1d5dc2b3a1",
+				Label:               "EMI",
+				CatalogNumber:       "EMC 3400",
+				OriginalReleaseDate: "1982-03-22",
+				ReleaseTypes:        []string{"album"},
+				UpdatedAt:           1700000000,
+			}, nil
+		},
+	}
+
+	router := routeAPIv1Handler(
+		webserver.APIv1EndpointAlbumInfo,
+		webserver.NewAlbumInfoHandler(lib),
+	)
+
+	var album struct {
+		library.Album
+		library.AlbumInfo
+	}
+	getJSON(t, router, "/v1/album/42/info", &album)
+
+	if album.ID != albumID || album.Name != "The Number of the Beast" ||
+		album.SongCount != 8 {
+		t.Errorf("unexpected album: %+v", album.Album)
+	}
+	if album.Label != "EMI" || album.CatalogNumber != "EMC 3400" ||
+		album.OriginalReleaseDate != "1982-03-22" || album.UpdatedAt != 1700000000 ||
+		!slices.Equal(album.ReleaseTypes, []string{"album"}) {
+		t.Errorf("unexpected album info: %+v", album.AlbumInfo)
+	}
+
+	assertStatuses(t, router, []statusTest{
+		{url: "/v1/album/13/info", status: http.StatusNotFound},
+		{url: "/v1/album/43/info", status: http.StatusInternalServerError},
+	})
+}
@@ -0,0 +1,191 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/apikeys"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// apiKeysHandler lists (GET), creates (POST) and removes (DELETE) API keys.
+type apiKeysHandler struct {
+	keys apikeys.Keys
+}
+
+// NewAPIKeysHandler returns an http.Handler which lists all API keys with a GET
+// request, creates a new one with a POST request and removes the key with ID from
+// the "keyID" path variable with a DELETE request.
+func NewAPIKeysHandler(keys apikeys.Keys) http.Handler {
+	return &apiKeysHandler{
+		keys: keys,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *apiKeysHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	switch req.Method {
+	case http.MethodPost:
+		h.create(w, req)
+	case http.MethodDelete:
+		h.delete(w, req)
+	default:
+		h.list(w, req)
+	}
+}
+
+func (h *apiKeysHandler) create(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&params); err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Cannot decode API key JSON: %s", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	scopes := make([]apikeys.Scope, 0, len(params.Scopes))
+	for _, name := range params.Scopes {
+		scope, err := apikeys.ParseScope(name)
+		if err != nil {
+			webutils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if params.Name == "" || len(scopes) == 0 {
+		webutils.JSONError(
+			w,
+			"name and at least one scope are required",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	key, secret, err := h.keys.Create(req.Context(), params.Name, scopes)
+	if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Failed to create API key: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	resp := createAPIKeyResponse{
+		apiKeyJSON: toAPIKeyJSON(key),
+		Key:        secret,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(resp); err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("API key created but cannot write response JSON: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+}
+
+func (h *apiKeysHandler) list(w http.ResponseWriter, req *http.Request) {
+	keys, err := h.keys.List(req.Context())
+	if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Failed to list API keys: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	resp := apiKeysResponse{
+		Keys: make([]apiKeyJSON, 0, len(keys)),
+	}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, toAPIKeyJSON(key))
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(resp); err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Cannot write response JSON: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+}
+
+func (h *apiKeysHandler) delete(w http.ResponseWriter, req *http.Request) {
+	keyID, err := strconv.ParseInt(mux.Vars(req)["keyID"], 10, 64)
+	if err != nil {
+		webutils.JSONError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	err = h.keys.Delete(req.Context(), keyID)
+	if errors.Is(err, apikeys.ErrNotFound) {
+		webutils.JSONError(w, "not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Failed to delete API key: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toAPIKeyJSON(key apikeys.Key) apiKeyJSON {
+	kj := apiKeyJSON{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    make([]string, 0, len(key.Scopes)),
+		CreatedAt: key.CreatedAt.Unix(),
+	}
+	for _, scope := range key.Scopes {
+		kj.Scopes = append(kj.Scopes, string(scope))
+	}
+	if key.LastUsedAt != nil {
+		kj.LastUsedAt = key.LastUsedAt.Unix()
+	}
+
+	return kj
+}
+
+type apiKeysResponse struct {
+	Keys []apiKeyJSON `json:"api_keys"`
+}
+
+type createAPIKeyResponse struct {
+	apiKeyJSON
+
+	Key string `json:"key"`
+}
+
+type apiKeyJSON struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	LastUsedAt int64    `json:"last_used_at,omitempty"`
+}
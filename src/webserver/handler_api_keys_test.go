@@ -0,0 +1,143 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/apikeys"
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestAPIKeysHandler checks creating, listing and deleting API keys.
+func TestAPIKeysHandler(t *testing.T) {
+	created := time.Unix(1728838802, 0)
+	stored := apikeys.Key{
+		ID:        7,
+		Name:      "Backup script",
+		Prefix:    "eut_ABCD",
+		Scopes:    []apikeys.Scope{apikeys.ScopeRead, apikeys.ScopeStream},
+		CreatedAt: created,
+	}
+
+	keys := &apikeysfakes.FakeKeys{
+		CreateStub: func(
+			_ context.Context,
+			name string,
+			scopes []apikeys.Scope,
+		) (apikeys.Key, string, error) {
+			key := stored
+			key.Name = name
+			key.Scopes = scopes
+			return key, "eut_ABCDEFGH", nil
+		},
+		ListStub: func(_ context.Context) ([]apikeys.Key, error) {
+			return []apikeys.Key{stored}, nil
+		},
+		DeleteStub: func(_ context.Context, keyID int64) error {
+			if keyID != stored.ID {
+				return apikeys.ErrNotFound
+			}
+			return nil
+		},
+	}
+
+	router := mux.NewRouter()
+	handler := webserver.NewAPIKeysHandler(keys)
+	router.Handle(webserver.APIv1EndpointAPIKeys, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointAPIKeys]...,
+	)
+	router.Handle(webserver.APIv1EndpointAPIKey, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointAPIKey]...,
+	)
+
+	type keyJSON struct {
+		ID        int64    `json:"id"`
+		Name      string   `json:"name"`
+		Prefix    string   `json:"prefix"`
+		Scopes    []string `json:"scopes"`
+		CreatedAt int64    `json:"created_at"`
+		Key       string   `json:"key"`
+	}
+
+	body := `{"name": "Playlists sync", "scopes": ["read", "playlists:write"]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/api-keys", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("expected HTTP %d but got %d", http.StatusCreated, resp.Code)
+	}
+	assertContentTypeJSON(t, resp.Result().Header.Get("Content-Type"))
+
+	var createdKey keyJSON
+	if err := json.NewDecoder(resp.Body).Decode(&createdKey); err != nil {
+		t.Fatalf("decoding create response: %s", err)
+	}
+	if createdKey.Key != "eut_ABCDEFGH" || createdKey.Name != "Playlists sync" {
+		t.Errorf("wrong created key: %+v", createdKey)
+	}
+	if len(createdKey.Scopes) != 2 || createdKey.Scopes[1] != "playlists:write" {
+		t.Errorf("wrong scopes for created key: %v", createdKey.Scopes)
+	}
+
+	for _, badBody := range []string{
+		`{"name": "No scopes"}`,
+		`{"name": "Bad scope", "scopes": ["write"]}`,
+		`{"scopes": ["read"]}`,
+		`not JSON`,
+	} {
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/v1/api-keys",
+			strings.NewReader(badBody),
+		)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("body `%s`: expected HTTP %d but got %d",
+				badBody, http.StatusBadRequest, resp.Code)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/api-keys", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var listed struct {
+		Keys []keyJSON `json:"api_keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decoding list response: %s", err)
+	}
+	if len(listed.Keys) != 1 {
+		t.Fatalf("expected one key but got %d", len(listed.Keys))
+	}
+	if key := listed.Keys[0]; key.ID != 7 || key.Key != "" || key.Prefix != "eut_ABCD" {
+		t.Errorf("wrong listed key: %+v", key)
+	}
+
+	for _, test := range []struct {
+		url          string
+		expectedCode int
+	}{
+		{url: "/v1/api-keys/7", expectedCode: http.StatusNoContent},
+		{url: "/v1/api-keys/8", expectedCode: http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodDelete, test.url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != test.expectedCode {
+			t.Errorf("DELETE %s: expected HTTP %d but got %d",
+				test.url, test.expectedCode, resp.Code)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package webserver
+
+import (
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// artistResponse is the artist from the library together with what is known about
+// them from the internet.
+type artistResponse struct {
+	library.Artist
+	library.ArtistInfo
+}
+
+// NewArtistHandler returns an HTTP handler which serves an artist identified by
+// its ID together with their biography, links to pages about them and similar
+// artists.
+func NewArtistHandler(lib library.Library) http.Handler {
+	return &itemInfoHandler[library.Artist, library.ArtistInfo]{
+		name:     "artist",
+		idVar:    "artistID",
+		notFound: library.ErrArtistNotFound,
+		getItem:  lib.GetArtist,
+		getInfo:  lib.GetArtistInfo,
+		response: func(artist library.Artist, info library.ArtistInfo) any {
+			return artistResponse{Artist: artist, ArtistInfo: info}
+		},
+	}
+}
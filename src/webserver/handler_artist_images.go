@@ -3,7 +3,6 @@ package webserver
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -13,12 +12,14 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/imagevariant"
 )
 
 // ArtstImageHandler is a http.Handler which provides CRUD operations for
 // artist images.
 type ArtstImageHandler struct {
 	imageManager library.ArtistImageManager
+	variants     *imagevariant.Cache
 }
 
 // ServeHTTP is required by the http.Handler's interface
@@ -55,7 +56,7 @@ func (aih ArtstImageHandler) ServeHTTP(writer http.ResponseWriter, req *http.Req
 }
 
 // Find searches through the library for the image of an artist and serves
-// it as a raw image
+// it as a raw image in the size and format requested by the client
 func (aih ArtstImageHandler) Find(
 	writer http.ResponseWriter,
 	req *http.Request,
@@ -90,18 +91,7 @@ func (aih ArtstImageHandler) Find(
 	defer imgReader.Close()
 
 	writer.Header().Set("Cache-Control", "max-age=604800")
-	if req.Method == http.MethodHead {
-		n, _ := io.Copy(io.Discard, imgReader)
-		writer.Header().Set("Content-Length", strconv.FormatInt(n, 10))
-		return nil
-	}
-
-	_, err = io.Copy(writer, imgReader)
-	if err != nil {
-		log.Printf("еrror sending HTTP data for artwork %d: %s", id, err)
-	}
-
-	return nil
+	return aih.variants.Serve(writer, req, imgReader)
 }
 
 func (aih ArtstImageHandler) remove(
@@ -140,11 +130,14 @@ func (aih ArtstImageHandler) upload(
 }
 
 // NewArtistImagesHandler returns a new Artist image handler.
-// It needs an implementation of the ArtistImageManager.
+// It needs an implementation of the ArtistImageManager. Images are scaled and
+// converted using `variants` which may be nil.
 func NewArtistImagesHandler(
 	am library.ArtistImageManager,
+	variants *imagevariant.Cache,
 ) *ArtstImageHandler {
 	return &ArtstImageHandler{
 		imageManager: am,
+		variants:     variants,
 	}
 }
@@ -48,7 +48,7 @@ func TestArtstImageHandler(t *testing.T) {
 		},
 	}
 
-	aimgHandler := webserver.NewArtistImagesHandler(fakeIM)
+	aimgHandler := webserver.NewArtistImagesHandler(fakeIM, nil)
 
 	// Try with malformed URL which should return "not found" when variables are
 	// not parsed by the Gorilla muxer.
@@ -161,7 +161,7 @@ func TestArtstImageHandlerDELETE(t *testing.T) {
 		},
 	}
 
-	aimgHandler := webserver.NewArtistImagesHandler(fakeIM)
+	aimgHandler := webserver.NewArtistImagesHandler(fakeIM, nil)
 	router := routeArtistImageHandler(aimgHandler)
 
 	// Test removing an image.
@@ -231,7 +231,7 @@ func TestArtstImageHandlerPUT(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.desc, func(t *testing.T) {
-			aimgHandler := webserver.NewArtistImagesHandler(test.aim)
+			aimgHandler := webserver.NewArtistImagesHandler(test.aim, nil)
 			router := routeArtistImageHandler(aimgHandler)
 
 			resp := httptest.NewRecorder()
@@ -264,7 +264,7 @@ func TestArtstImageHandlerPUT(t *testing.T) {
 			return nil
 		},
 	}
-	aimgHandler := webserver.NewArtistImagesHandler(fakeAIM)
+	aimgHandler := webserver.NewArtistImagesHandler(fakeAIM, nil)
 	router := routeArtistImageHandler(aimgHandler)
 
 	resp := httptest.NewRecorder()
@@ -0,0 +1,93 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+const (
+	// defaultRadioCount is the number of tracks returned by the artist radio
+	// when the request does not set one.
+	defaultRadioCount = 50
+
+	// maxRadioCount is the maximum number of tracks returned by the artist
+	// radio for a single request.
+	maxRadioCount = 500
+)
+
+// artistRadioHandler returns a queue of tracks which are similar to an artist.
+type artistRadioHandler struct {
+	library library.Library
+}
+
+// NewArtistRadioHandler returns an HTTP handler which serves a queue of tracks by
+// an artist and by similar artists. Every request returns a different queue so
+// that clients could request more tracks when the queue is about to end.
+func NewArtistRadioHandler(lib library.Library) http.Handler {
+	return &artistRadioHandler{
+		library: lib,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *artistRadioHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	artistID, err := strconv.ParseInt(mux.Vars(req)["artistID"], 10, 64)
+	if err != nil || artistID <= 0 {
+		webutils.JSONError(w, "artist not found", http.StatusNotFound)
+		return
+	}
+
+	count := defaultRadioCount
+	if countStr := req.URL.Query().Get("count"); countStr != "" {
+		count, err = strconv.Atoi(countStr)
+		if err != nil || count < 1 {
+			webutils.JSONError(
+				w,
+				`"count" must be an integer greater than zero`,
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+
+	// Searching for similar artists on the internet could take a while.
+	ctx, cancel := context.WithTimeout(req.Context(), time.Minute)
+	defer cancel()
+
+	tracks, err := h.library.SimilarTracks(ctx, library.SimilarArgs{
+		ArtistID: artistID,
+		Count:    min(count, maxRadioCount),
+	})
+	if errors.Is(err, library.ErrArtistNotFound) {
+		webutils.JSONError(w, "artist not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("getting artist radio failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	if tracks == nil {
+		tracks = []library.TrackInfo{}
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(tracks); err != nil {
+		log.Printf("error writing artist radio response: %s", err)
+	}
+}
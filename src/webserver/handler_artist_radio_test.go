@@ -0,0 +1,67 @@
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestArtistRadioHandler checks that the artist radio returns the similar tracks
+// for the artist, limits their count and returns errors for missing artists and
+// wrong arguments.
+func TestArtistRadioHandler(t *testing.T) {
+	const (
+		failingArtistID = 43
+		missingArtistID = 13
+	)
+
+	lib := &libraryfakes.FakeLibrary{
+		SimilarTracksStub: func(
+			_ context.Context,
+			args library.SimilarArgs,
+		) ([]library.TrackInfo, error) {
+			switch args.ArtistID {
+			case missingArtistID:
+				return nil, library.ErrArtistNotFound
+			case failingArtistID:
+				return nil, errors.New("database is gone")
+			}
+
+			return []library.TrackInfo{
+				{ID: 1, ArtistID: args.ArtistID, Title: "Invaders"},
+				{ID: 7, ArtistID: 8, Title: "Electric Eye"},
+			}, nil
+		},
+	}
+
+	router := routeAPIv1Handler(
+		webserver.APIv1EndpointArtistRadio,
+		webserver.NewArtistRadioHandler(lib),
+	)
+
+	var tracks []library.TrackInfo
+	getJSON(t, router, "/v1/radio/artist/42?count=1000", &tracks)
+
+	if len(tracks) != 2 || tracks[0].Title != "Invaders" || tracks[1].ID != 7 {
+		t.Errorf("unexpected tracks: %+v", tracks)
+	}
+
+	_, args := lib.SimilarTracksArgsForCall(0)
+	expectedArgs := library.SimilarArgs{ArtistID: 42, Count: 500}
+	if args != expectedArgs {
+		t.Errorf("expected similar tracks for %+v but got %+v", expectedArgs, args)
+	}
+
+	assertStatuses(t, router, []statusTest{
+		{url: "/v1/radio/artist/13", status: http.StatusNotFound},
+		{url: "/v1/radio/artist/baba", status: http.StatusNotFound},
+		{url: "/v1/radio/artist/43", status: http.StatusInternalServerError},
+		{url: "/v1/radio/artist/42?count=0", status: http.StatusBadRequest},
+		{url: "/v1/radio/artist/42?count=many", status: http.StatusBadRequest},
+	})
+}
@@ -0,0 +1,77 @@
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestArtistHandler checks that an artist is returned together with what is known
+// about them and that errors for missing artists and failed searches are returned.
+func TestArtistHandler(t *testing.T) {
+	const (
+		artistID        = 42
+		failingInfoID   = 43
+		missingArtistID = 13
+	)
+
+	lib := &libraryfakes.FakeLibrary{
+		GetArtistStub: func(_ context.Context, id int64) (library.Artist, error) {
+			if id == missingArtistID {
+				return library.Artist{}, library.ErrArtistNotFound
+			}
+			return library.Artist{ID: id, Name: "Iron Maiden", AlbumCount: 2}, nil
+		},
+		GetArtistInfoStub: func(_ context.Context, id int64) (library.ArtistInfo, error) {
+			if id == failingInfoID {
+				return library.ArtistInfo{}, errors.New("database is gone")
+			}
+			return library.ArtistInfo{
+				MusicBrainzID: "ca891d65-d9b0-4258-89f7-e6ba29d83767",
+				Biography:     "An English heavy metal band.",
+				Links: []library.ArtistLink{
+					{Type: "wikipedia", URL: "https://en.wikipedia.org/wiki/Iron_Maiden"},
+				},
+				Similar: []library.SimilarArtist{
+					{ID: 7, Name: "Judas Priest"},
+					{Name: "Saxon"},
+				},
+				UpdatedAt: 1700000000,
+			}, nil
+		},
+	}
+
+	router := routeAPIv1Handler(
+		webserver.APIv1EndpointArtist,
+		webserver.NewArtistHandler(lib),
+	)
+
+	var artist struct {
+		library.Artist
+		library.ArtistInfo
+	}
+	getJSON(t, router, "/v1/artist/42", &artist)
+
+	if artist.ID != artistID || artist.Name != "Iron Maiden" || artist.AlbumCount != 2 {
+		t.Errorf("unexpected artist: %+v", artist.Artist)
+	}
+	if artist.Biography != "An English heavy metal band." || artist.UpdatedAt != 1700000000 {
+		t.Errorf("unexpected artist info: %+v", artist.ArtistInfo)
+	}
+	if len(artist.Links) != 1 || artist.Links[0].Type != "wikipedia" {
+		t.Errorf("unexpected links: %+v", artist.Links)
+	}
+	if len(artist.Similar) != 2 || artist.Similar[0].ID != 7 || artist.Similar[1].ID != 0 {
+		t.Errorf("unexpected similar artists: %+v", artist.Similar)
+	}
+
+	assertStatuses(t, router, []statusTest{
+		{url: "/v1/artist/13", status: http.StatusNotFound},
+		{url: "/v1/artist/43", status: http.StatusInternalServerError},
+	})
+}
@@ -0,0 +1,46 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// artworkMissesHandler lists the albums and artists for which no images have
+// been found.
+type artworkMissesHandler struct {
+	artworkManager library.ArtworkManager
+}
+
+// NewArtworkMissesHandler returns an HTTP handler which lists the albums and
+// artists for which no images have been found together with the times when they
+// will be searched for again.
+func NewArtworkMissesHandler(am library.ArtworkManager) http.Handler {
+	return &artworkMissesHandler{
+		artworkManager: am,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *artworkMissesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	misses, err := h.artworkManager.GetArtworkMisses(req.Context())
+	if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Getting artwork misses failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(misses); err != nil {
+		log.Printf("error writing artwork misses response: %s", err)
+	}
+}
@@ -0,0 +1,57 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestArtworkMissesHandler checks that the artwork misses are listed as JSON and
+// that errors from the library are reported.
+func TestArtworkMissesHandler(t *testing.T) {
+	am := &libraryfakes.FakeArtworkManager{}
+	am.GetArtworkMissesReturns(library.ArtworkMisses{
+		Albums: []library.ArtworkMiss{
+			{ID: 3, Name: "Killers", Attempts: 2, LastAttempt: 100, NextRetry: 7300},
+		},
+		Artists: []library.ArtworkMiss{},
+	}, nil)
+
+	handler := webserver.NewArtworkMissesHandler(am)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/artwork/misses", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var misses library.ArtworkMisses
+	if err := json.NewDecoder(resp.Body).Decode(&misses); err != nil {
+		t.Fatalf("decoding response JSON: %s", err)
+	}
+	if len(misses.Albums) != 1 || misses.Albums[0].Name != "Killers" ||
+		misses.Albums[0].NextRetry != 7300 {
+		t.Errorf("unexpected album misses: %+v", misses.Albums)
+	}
+	if misses.Artists == nil || len(misses.Artists) != 0 {
+		t.Errorf("expected empty artist misses but got %+v", misses.Artists)
+	}
+
+	am.GetArtworkMissesReturns(library.ArtworkMisses{}, errors.New("db is gone"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d on error but got %d",
+			http.StatusInternalServerError, rec.Code)
+	}
+}
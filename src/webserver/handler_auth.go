@@ -1,15 +1,23 @@
 package webserver
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
-	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/ironsmile/euterpe/src/apikeys"
 	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/forwardauth"
+	"github.com/ironsmile/euterpe/src/webserver/loginlimit"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
 )
 
 const (
@@ -24,16 +32,27 @@ const (
 //  * Authorization Bearer JWT token
 //  * JWT token in a session cookie
 //  * JWT token as a query string
+//  * API key in the X-API-Key header
+//  * User name in a header set by a trusted authenticating proxy
 //
 // Basic auth is preserved for backward compatibility. Needless to say, it so not
 // a preferred method for authentication.
+//
+// JWT tokens are accepted only when they are access tokens signed with a key from
+// the keyring and their device is registered and has not been revoked. API keys
+// are accepted only for requests permitted by their scopes.
 type AuthHandler struct {
-	wrapped    http.Handler // The actual handler that does the APP Logic job
-	username   string       // Username to be used for basic authenticate
-	password   string       // Password to be used for basic authenticate
-	templates  Templates    // Template finder
-	secret     string       // Secret used to craft and decode tokens
-	exceptions []string     // Paths which will be exempt from authentication
+	wrapped    http.Handler               // The actual handler that does the APP Logic job
+	username   string                     // Username to be used for basic authenticate
+	password   string                     // Password to be used for basic authenticate
+	templates  Templates                  // Template finder
+	keyring    *tokens.Keyring            // Keys used for verifying tokens
+	devices    devices.Registry           // Registry with the devices allowed to use tokens
+	apiKeys    apikeys.Keys               // Storage for the API keys
+	limiter    *loginlimit.Limiter        // Throttles failed basic authentication attempts
+	proxyAuth  *forwardauth.Authenticator // Accepts users authenticated by a proxy
+	sessions   *sessionRefresher          // Refreshes the tokens of web UI sessions
+	exceptions []string                   // Paths which will be exempt from authentication
 }
 
 // NewAuthHandler returns a new AuthHandler.
@@ -42,7 +61,11 @@ func NewAuthHandler(
 	username string,
 	password string,
 	templatesResolver Templates,
-	secret string,
+	keyring *tokens.Keyring,
+	registry devices.Registry,
+	keys apikeys.Keys,
+	limiter *loginlimit.Limiter,
+	proxyAuth *forwardauth.Authenticator,
 	exceptions []string,
 ) *AuthHandler {
 	return &AuthHandler{
@@ -50,7 +73,12 @@ func NewAuthHandler(
 		username:   username,
 		password:   password,
 		templates:  templatesResolver,
-		secret:     secret,
+		keyring:    keyring,
+		devices:    registry,
+		apiKeys:    keys,
+		limiter:    limiter,
+		proxyAuth:  proxyAuth,
+		sessions:   newSessionRefresher(keyring, registry),
 		exceptions: exceptions,
 	}
 }
@@ -58,11 +86,34 @@ func NewAuthHandler(
 // ServeHTTP implements the http.Handler interface and does the actual basic authenticate
 // check for every request
 func (hl *AuthHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
-	if !hl.authenticated(req) {
+	if apiKey := req.Header.Get(apiKeyHeader); apiKey != "" && !hl.exempt(req) {
+		hl.serveWithAPIKey(writer, req, apiKey)
+		return
+	}
+
+	if user, _, ok := req.BasicAuth(); ok && !hl.exempt(req) {
+		if wait, allowed := hl.limiter.Allow(req, user); !allowed {
+			webutils.SetRetryAfter(writer, wait)
+			webutils.JSONError(writer, tooManyAttemptsText, http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	auth, ok := hl.authenticated(writer, req)
+	if !ok {
 		InternalErrorOnErrorHandler(writer, req, hl.challengeAuthentication)
 		return
 	}
 
+	if auth.tokenID != "" {
+		ctx := context.WithValue(req.Context(), tokenIDContextKey{}, auth.tokenID)
+		req = req.WithContext(ctx)
+	}
+	if auth.user != "" {
+		ctx := context.WithValue(req.Context(), userContextKey{}, auth.user)
+		req = req.WithContext(ctx)
+	}
+
 	hl.wrapped.ServeHTTP(writer, req)
 }
 
@@ -114,37 +165,107 @@ func (hl *AuthHandler) redirectToLogin(w http.ResponseWriter, r *http.Request) e
 	return nil
 }
 
-// Compares the authentication header with the stored user and passwords
-// and returns true if they pass.
-func (hl *AuthHandler) authenticated(r *http.Request) bool {
+// serveWithAPIKey passes the request to the wrapped handler only when `apiKey` is
+// valid and its scopes permit the request.
+func (hl *AuthHandler) serveWithAPIKey(
+	w http.ResponseWriter,
+	req *http.Request,
+	apiKey string,
+) {
+	key, err := hl.apiKeys.Check(req.Context(), apiKey)
+	if errors.Is(err, apikeys.ErrNotFound) {
+		webutils.JSONError(w, "invalid API key", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		log.Printf("Error checking API key: %s\n", err)
+		webutils.JSONError(w, "cannot check API key", http.StatusInternalServerError)
+		return
+	}
+
+	if scope := requiredScope(req); !key.Allows(scope) {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("API key does not have the `%s` scope", scope),
+			http.StatusForbidden,
+		)
+		return
+	}
+
+	hl.wrapped.ServeHTTP(w, req)
+}
+
+// exempt returns true when the request is for a path which does not require
+// authentication.
+func (hl *AuthHandler) exempt(r *http.Request) bool {
 	for _, path := range hl.exceptions {
 		if strings.HasPrefix(r.URL.Path, path) {
 			return true
 		}
 	}
 
+	return false
+}
+
+// requestAuth is how a request was authenticated.
+type requestAuth struct {
+	tokenID string // ID of the token used for authentication, if any
+	user    string // Name of the authenticated user when it is known
+}
+
+// userContextKey is the request context key under which the AuthHandler stores
+// the name of the authenticated user.
+type userContextKey struct{}
+
+// userFromContext returns the name of the user who made the request with context
+// `ctx`. It is empty when the user is not known.
+func userFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userContextKey{}).(string)
+	return user
+}
+
+// Compares the authentication header with the stored user and passwords
+// and returns true if they pass. When a token was used for authentication
+// its ID is returned as well. New session cookies are set in `w` when the
+// tokens of a web UI session were refreshed.
+func (hl *AuthHandler) authenticated(
+	w http.ResponseWriter,
+	r *http.Request,
+) (requestAuth, bool) {
+	if hl.exempt(r) {
+		return requestAuth{}, true
+	}
+
+	if user, ok := hl.proxyAuth.Authenticate(r); ok {
+		return requestAuth{user: user}, true
+	}
+
 	authHeader := r.Header.Get("Authorization")
 
 	if strings.HasPrefix(authHeader, "Bearer ") {
-		return hl.withJWT(strings.TrimPrefix(authHeader, "Bearer "))
+		return hl.withJWT(r.Context(), strings.TrimPrefix(authHeader, "Bearer "))
 	}
 
 	if strings.HasPrefix(authHeader, "Basic ") {
-		return hl.withBasicAuth(strings.TrimPrefix(authHeader, "Basic "))
+		if !hl.withBasicAuth(r, strings.TrimPrefix(authHeader, "Basic ")) {
+			return requestAuth{}, false
+		}
+
+		user, _, _ := r.BasicAuth()
+		return requestAuth{user: user}, true
 	}
 
-	if cookie, err := r.Cookie(sessionCookieName); err == nil {
-		return hl.withJWT(cookie.Value)
+	if auth, ok, found := hl.withSessionCookies(w, r); found {
+		return auth, ok
 	}
 
 	if queryToken := r.URL.Query().Get("token"); queryToken != "" {
-		return hl.withJWT(queryToken)
+		return hl.withJWT(r.Context(), queryToken)
 	}
 
-	return false
+	return requestAuth{}, false
 }
 
-func (hl *AuthHandler) withBasicAuth(encoded string) bool {
+func (hl *AuthHandler) withBasicAuth(r *http.Request, encoded string) bool {
 	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
 	if err != nil {
 		return false
@@ -161,18 +282,86 @@ func (hl *AuthHandler) withBasicAuth(encoded string) bool {
 		Password: hl.password,
 	}
 
-	return checkLoginCreds(pair[0], pair[1], cfg)
+	if !checkLoginCreds(pair[0], pair[1], cfg) {
+		hl.limiter.Failed(r, pair[0])
+		return false
+	}
+
+	hl.limiter.Succeeded(r, pair[0])
+	return true
 }
 
-func (hl *AuthHandler) withJWT(token string) bool {
-	var jot jwt.Payload
+func (hl *AuthHandler) withJWT(ctx context.Context, token string) (requestAuth, bool) {
+	claims, err := hl.keyring.Verify([]byte(token), time.Now())
+	if err != nil || claims.Use != tokens.UseAccess {
+		return requestAuth{}, false
+	}
+
+	err = hl.devices.Check(ctx, claims.Device)
+	if errors.Is(err, devices.ErrNotFound) && claims.RegisterOnUse {
+		err = hl.registerDevice(ctx, claims.Device)
+	}
+	if err != nil {
+		if !errors.Is(err, devices.ErrNotFound) && !errors.Is(err, devices.ErrRevoked) {
+			log.Printf("Error checking device token: %s\n", err)
+		}
+		return requestAuth{}, false
+	}
+
+	return requestAuth{tokenID: claims.Device, user: claims.Subject}, true
+}
+
+// withSessionCookies authenticates requests from the web UI. Their access token is
+// in the session cookie. Once it expires the refresh token from the refresh cookie
+// is exchanged for new tokens which are set as cookies in `w`. The last returned
+// value is false when the request has none of these cookies.
+func (hl *AuthHandler) withSessionCookies(
+	w http.ResponseWriter,
+	r *http.Request,
+) (auth requestAuth, ok bool, found bool) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		found = true
+		if auth, ok := hl.withJWT(r.Context(), cookie.Value); ok {
+			return auth, true, true
+		}
+	}
+
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil {
+		return requestAuth{}, false, found
+	}
 
-	alg := jwt.NewHS256([]byte(hl.secret))
-	exp := jwt.ExpirationTimeValidator(time.Now())
-	validatePayload := jwt.ValidatePayload(&jot, exp)
+	session, err := hl.sessions.refresh(r.Context(), cookie.Value)
+	if err != nil {
+		if !errors.Is(err, errInvalidRefreshToken) {
+			log.Printf("Error refreshing session tokens: %s\n", err)
+		}
+		return requestAuth{}, false, true
+	}
 
-	_, err := jwt.Verify([]byte(token), alg, &jot, validatePayload)
-	return err == nil
+	writeSessionCookies(w, session.access, session.refresh, session.claims.RememberMe)
+
+	auth = requestAuth{
+		tokenID: session.claims.Device,
+		user:    session.claims.Subject,
+	}
+	return auth, true, true
+}
+
+// registerDevice registers the device with token `tokenID` when its token is
+// used for the first time.
+func (hl *AuthHandler) registerDevice(ctx context.Context, tokenID string) error {
+	_, err := hl.devices.Register(ctx, devices.Device{TokenID: tokenID})
+	if err == nil {
+		return nil
+	}
+
+	// The device could have been registered by a concurrent request with the
+	// same token.
+	if checkErr := hl.devices.Check(ctx, tokenID); checkErr != nil {
+		return err
+	}
+	return nil
 }
 
 func contains(haystack []string, needle string) bool {
@@ -1,6 +1,7 @@
 package webserver_test
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
@@ -9,7 +10,14 @@ import (
 	"time"
 
 	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/ironsmile/euterpe/src/apikeys"
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
 	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/forwardauth"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
 )
 
 // TestAuthHandlerDifferentAuthMethods makes sure that the auth handler still supports
@@ -19,11 +27,72 @@ func TestAuthHandlerDifferentAuthMethods(t *testing.T) {
 		username = "auth_user"
 		password = "auth_pass"
 		secret   = "auth_secret_which_is_completely_unknown_to_anyone_promise"
+
+		previousSecret = "auth_secret_which_was_used_before_the_current_one"
+
+		knownTokenID   = "known-token-id"
+		revokedTokenID = "revoked-token-id"
+
+		readAPIKey  = "eut_read-api-key"
+		adminAPIKey = "eut_admin-api-key"
 	)
 
-	getToken := func() string {
+	keys := &apikeysfakes.FakeKeys{
+		CheckStub: func(_ context.Context, secret string) (apikeys.Key, error) {
+			switch secret {
+			case readAPIKey:
+				return apikeys.Key{Scopes: []apikeys.Scope{apikeys.ScopeRead}}, nil
+			case adminAPIKey:
+				return apikeys.Key{Scopes: []apikeys.Scope{apikeys.ScopeAdmin}}, nil
+			default:
+				return apikeys.Key{}, apikeys.ErrNotFound
+			}
+		},
+	}
+	apiKeyRequest := func(method, path, key string) func() *http.Request {
+		return func() *http.Request {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("X-API-Key", key)
+			return req
+		}
+	}
+
+	// httptest.NewRequest uses 192.0.2.1 for the remote address.
+	proxyAuth, err := forwardauth.New(config.ForwardAuth{
+		Enable:  true,
+		Header:  "Remote-User",
+		Proxies: []string{"192.0.2.1"},
+	}, username)
+	if err != nil {
+		t.Fatalf("cannot create forward authenticator: %s", err)
+	}
+	proxyRequest := func(remoteAddr, user string) func() *http.Request {
+		return func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Remote-User", user)
+			req.RemoteAddr = remoteAddr
+			return req
+		}
+	}
+
+	registry := &devicesfakes.FakeRegistry{
+		CheckStub: func(_ context.Context, tokenID string) error {
+			switch tokenID {
+			case knownTokenID:
+				return nil
+			case revokedTokenID:
+				return devices.ErrRevoked
+			default:
+				return devices.ErrNotFound
+			}
+		},
+	}
+
+	getTokenWithID := func(tokenID string) string {
 		now := time.Now()
 		pl := jwt.Payload{
+			JWTID:          tokenID,
 			IssuedAt:       jwt.NumericDate(now),
 			ExpirationTime: jwt.NumericDate(now.Add(10 * time.Minute)),
 		}
@@ -34,6 +103,34 @@ func TestAuthHandlerDifferentAuthMethods(t *testing.T) {
 		}
 		return string(token)
 	}
+	getToken := func() string {
+		return getTokenWithID(knownTokenID)
+	}
+	getKeyringToken := func(signWith string, use tokens.Use) string {
+		now := time.Now()
+		token, err := tokens.NewKeyring(signWith).Sign(tokens.Claims{
+			Payload: jwt.Payload{
+				Subject:        username,
+				JWTID:          "some-unique-id",
+				IssuedAt:       jwt.NumericDate(now),
+				ExpirationTime: jwt.NumericDate(now.Add(10 * time.Minute)),
+			},
+			Device: knownTokenID,
+			Use:    use,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return string(token)
+	}
+	bearerRequest := func(token string) func() *http.Request {
+		return func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			return req
+		}
+	}
 
 	tests := []struct {
 		desc         string
@@ -127,6 +224,87 @@ func TestAuthHandlerDifferentAuthMethods(t *testing.T) {
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
+		{
+			desc:         "API key",
+			newRequest:   apiKeyRequest(http.MethodGet, "/v1/browse", readAPIKey),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "invalid API key",
+			newRequest:   apiKeyRequest(http.MethodGet, "/v1/browse", "eut_wrong"),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "API key without stream scope",
+			newRequest:   apiKeyRequest(http.MethodGet, "/v1/file/5", readAPIKey),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc: "API key without stream scope for artwork candidates",
+			newRequest: apiKeyRequest(
+				http.MethodGet, "/v1/album/5/artwork/candidates", readAPIKey,
+			),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "API key without playlists:write scope",
+			newRequest:   apiKeyRequest(http.MethodPost, "/v1/playlists", readAPIKey),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "API key without admin scope",
+			newRequest:   apiKeyRequest(http.MethodGet, "/v1/devices", readAPIKey),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "API key without admin scope creating QR token",
+			newRequest:   apiKeyRequest(http.MethodGet, "/new_qr_token/", readAPIKey),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "API key without admin scope for web UI",
+			newRequest:   apiKeyRequest(http.MethodGet, "/add_device/", readAPIKey),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "admin API key",
+			newRequest:   apiKeyRequest(http.MethodDelete, "/v1/api-keys/3", adminAPIKey),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "token without ID",
+			newRequest: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("Accept", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", getTokenWithID("")))
+				return req
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc: "token with unknown ID",
+			newRequest: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("Accept", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf(
+					"Bearer %s", getTokenWithID("unknown-token-id"),
+				))
+				return req
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc: "revoked token",
+			newRequest: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("Accept", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf(
+					"Bearer %s", getTokenWithID(revokedTokenID),
+				))
+				return req
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
 		{
 			desc: "token created with different secret",
 			newRequest: func() *http.Request {
@@ -214,6 +392,45 @@ func TestAuthHandlerDifferentAuthMethods(t *testing.T) {
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
+		{
+			desc:         "access token with key ID",
+			newRequest:   bearerRequest(getKeyringToken(secret, tokens.UseAccess)),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "access token signed with the previous secret",
+			newRequest: bearerRequest(
+				getKeyringToken(previousSecret, tokens.UseAccess),
+			),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "access token signed with unknown secret",
+			newRequest: bearerRequest(
+				getKeyringToken("some-unknown-secret", tokens.UseAccess),
+			),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "refresh token used for access",
+			newRequest:   bearerRequest(getKeyringToken(secret, tokens.UseRefresh)),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "authenticated by the proxy",
+			newRequest:   proxyRequest("192.0.2.1:4433", username),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "proxy header from untrusted address",
+			newRequest:   proxyRequest("198.51.100.7:4433", username),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "proxy header with unknown user",
+			newRequest:   proxyRequest("192.0.2.1:4433", "someone-else"),
+			expectedCode: http.StatusUnauthorized,
+		},
 	}
 
 	for _, test := range tests {
@@ -228,7 +445,11 @@ func TestAuthHandlerDifferentAuthMethods(t *testing.T) {
 				username,
 				password,
 				nil,
-				secret,
+				tokens.NewKeyring(secret, previousSecret),
+				registry,
+				keys,
+				nil,
+				proxyAuth,
 				test.exceptions,
 			)
 
@@ -246,3 +467,221 @@ func TestAuthHandlerDifferentAuthMethods(t *testing.T) {
 		})
 	}
 }
+
+// TestAuthHandlerRegistersDevicesOnFirstUse checks that the device of a token from
+// a QR code is registered when the token is used for the first time and that it
+// could be revoked afterwards like any other device.
+func TestAuthHandlerRegistersDevicesOnFirstUse(t *testing.T) {
+	const secret = "auth_secret_which_is_completely_unknown_to_anyone_promise"
+
+	registered := make(map[string]bool)
+	revoked := make(map[string]bool)
+	registry := &devicesfakes.FakeRegistry{
+		CheckStub: func(_ context.Context, tokenID string) error {
+			if revoked[tokenID] {
+				return devices.ErrRevoked
+			}
+			if !registered[tokenID] {
+				return devices.ErrNotFound
+			}
+			return nil
+		},
+		RegisterStub: func(_ context.Context, device devices.Device) (int64, error) {
+			registered[device.TokenID] = true
+			return int64(len(registered)), nil
+		},
+	}
+
+	sign := func(device string, registerOnUse bool) string {
+		now := time.Now()
+		token, err := tokens.NewKeyring(secret).Sign(tokens.Claims{
+			Payload: jwt.Payload{
+				JWTID:          "some-unique-id",
+				IssuedAt:       jwt.NumericDate(now),
+				ExpirationTime: jwt.NumericDate(now.Add(10 * time.Minute)),
+			},
+			Device:        device,
+			Use:           tokens.UseAccess,
+			RegisterOnUse: registerOnUse,
+		})
+		if err != nil {
+			t.Fatalf("signing token: %s", err)
+		}
+		return string(token)
+	}
+
+	wrapped := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "OK")
+	})
+	auh := webserver.NewAuthHandler(
+		wrapped,
+		"auth_user",
+		"auth_pass",
+		nil,
+		tokens.NewKeyring(secret),
+		registry,
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
+		nil,
+	)
+
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		resp := httptest.NewRecorder()
+		auh.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	qrToken := sign("qr-device", true)
+	for i := range 2 {
+		if code := request(qrToken); code != http.StatusOK {
+			t.Errorf("request %d: expected HTTP %d but got %d", i, http.StatusOK, code)
+		}
+	}
+	if calls := registry.RegisterCallCount(); calls != 1 {
+		t.Errorf("expected the device to be registered once but it was %d times", calls)
+	}
+
+	revoked["qr-device"] = true
+	if code := request(qrToken); code != http.StatusUnauthorized {
+		t.Errorf("revoked device: expected HTTP %d but got %d",
+			http.StatusUnauthorized, code)
+	}
+
+	if code := request(sign("unknown-device", false)); code != http.StatusUnauthorized {
+		t.Errorf("unknown device: expected HTTP %d but got %d",
+			http.StatusUnauthorized, code)
+	}
+	if calls := registry.RegisterCallCount(); calls != 1 {
+		t.Errorf("expected only tokens from QR codes to be registered on use")
+	}
+}
+
+// TestAuthHandlerRefreshesSessionCookies checks that the refresh token in the
+// session cookies of the web UI is exchanged for new tokens once the access token
+// expires. Requests sent at the same time with the same refresh token must not be
+// treated as its reuse.
+func TestAuthHandlerRefreshesSessionCookies(t *testing.T) {
+	const (
+		secret   = "auth_secret_which_is_completely_unknown_to_anyone_promise"
+		deviceID = "browser-device"
+	)
+
+	var revoked bool
+	registry := &devicesfakes.FakeRegistry{
+		CheckStub: func(_ context.Context, tokenID string) error {
+			if revoked {
+				return devices.ErrRevoked
+			}
+			return nil
+		},
+		RotateRefreshTokenStub: func(_ context.Context, _, usedID, _ string) error {
+			if usedID != "login-refresh-token" {
+				return devices.ErrRefreshTokenUsed
+			}
+			return nil
+		},
+	}
+
+	keyring := tokens.NewKeyring(secret)
+	sign := func(id string, use tokens.Use, expiresIn time.Duration) string {
+		now := time.Now()
+		token, err := keyring.Sign(tokens.Claims{
+			Payload: jwt.Payload{
+				Subject:        "auth_user",
+				JWTID:          id,
+				IssuedAt:       jwt.NumericDate(now),
+				ExpirationTime: jwt.NumericDate(now.Add(expiresIn)),
+			},
+			Device:     deviceID,
+			Use:        use,
+			RememberMe: true,
+		})
+		if err != nil {
+			t.Fatalf("signing token: %s", err)
+		}
+		return string(token)
+	}
+
+	wrapped := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "OK")
+	})
+	auh := webserver.NewAuthHandler(
+		wrapped,
+		"auth_user",
+		"auth_pass",
+		nil,
+		keyring,
+		registry,
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
+		nil,
+	)
+
+	expiredAccess := sign("expired-access-token", tokens.UseAccess, -time.Minute)
+	loginRefresh := sign("login-refresh-token", tokens.UseRefresh, time.Hour)
+
+	request := func(cookies map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/json")
+		for name, value := range cookies {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+		resp := httptest.NewRecorder()
+		auh.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := request(map[string]string{"session": expiredAccess})
+	if resp.Code != http.StatusUnauthorized {
+		t.Errorf("expired access token: expected HTTP %d but got %d",
+			http.StatusUnauthorized, resp.Code)
+	}
+
+	sessionCookies := map[string]string{
+		"session":         expiredAccess,
+		"session_refresh": loginRefresh,
+	}
+	for i := range 2 {
+		resp := request(sessionCookies)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("request %d: expected HTTP %d but got %d", i, http.StatusOK, resp.Code)
+		}
+
+		newCookies := make(map[string]*http.Cookie)
+		for _, cookie := range resp.Result().Cookies() {
+			newCookies[cookie.Name] = cookie
+		}
+		if len(newCookies) != 2 {
+			t.Fatalf("request %d: expected new session cookies but got %v", i, newCookies)
+		}
+
+		access, err := keyring.Verify([]byte(newCookies["session"].Value), time.Now())
+		if err != nil || access.Use != tokens.UseAccess || access.Device != deviceID {
+			t.Errorf("request %d: wrong new access token %+v: %v", i, access, err)
+		}
+		refresh, err := keyring.Verify([]byte(newCookies["session_refresh"].Value), time.Now())
+		if err != nil || refresh.Use != tokens.UseRefresh || !refresh.RememberMe {
+			t.Errorf("request %d: wrong new refresh token %+v: %v", i, refresh, err)
+		}
+		if newCookies["session_refresh"].Expires.IsZero() {
+			t.Errorf("request %d: remembered session got a browser session cookie", i)
+		}
+	}
+	if calls := registry.RotateRefreshTokenCallCount(); calls != 1 {
+		t.Errorf("expected the refresh token to be rotated once but it was %d times", calls)
+	}
+	if registry.RevokeTokenCallCount() != 0 {
+		t.Errorf("device revoked for requests sent at the same time")
+	}
+
+	revoked = true
+	if resp := request(sessionCookies); resp.Code != http.StatusUnauthorized {
+		t.Errorf("revoked device: expected HTTP %d but got %d",
+			http.StatusUnauthorized, resp.Code)
+	}
+}
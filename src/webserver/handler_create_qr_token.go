@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/skip2/go-qrcode"
 
 	"github.com/ironsmile/euterpe/src/config"
@@ -14,8 +13,15 @@ import (
 
 // NewCreateQRTokenHandler returns a http.Handler which will generate an access token
 // in a QR bar code and serve it as a png image as a response. In the bar code the
-// server address from the query value "address" is included.
-func NewCreateQRTokenHandler(needsAuth bool, auth config.Auth) http.Handler {
+// server address from the query value "address" is included. The device of the
+// token is registered when the token is used for the first time. It is expected
+// to set its name with the register token endpoint then.
+func NewCreateQRTokenHandler(
+	needsAuth bool,
+	auth config.Auth,
+) http.Handler {
+	keyring := keyringFromAuth(auth)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		qrConts := struct {
 			Software string `json:"software"`
@@ -27,19 +33,8 @@ func NewCreateQRTokenHandler(needsAuth bool, auth config.Auth) http.Handler {
 		}
 
 		if needsAuth {
-			now := time.Now()
-			pl := jwt.Payload{
-				IssuedAt:       jwt.NumericDate(now),
-				ExpirationTime: jwt.NumericDate(now.Add(6 * 31 * 24 * time.Hour)),
-			}
-
-			if len(auth.Secret) == 0 {
-				errMsg := "Error generating token: secret is empty."
-				http.Error(w, errMsg, http.StatusInternalServerError)
-				return
-			}
-
-			token, err := jwt.Sign(pl, jwt.NewHS256([]byte(auth.Secret)))
+			expiresAt := time.Now().Add(6 * 31 * 24 * time.Hour)
+			token, err := newUnregisteredDeviceToken(keyring, auth.User, expiresAt)
 			if err != nil {
 				errMsg := fmt.Sprintf("Error generating token: %s.", err)
 				http.Error(w, errMsg, http.StatusInternalServerError)
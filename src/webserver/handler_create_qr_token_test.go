@@ -16,6 +16,7 @@ import (
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
 	"github.com/liyue201/goqr"
 )
 
@@ -134,6 +135,15 @@ func TestCreateQRTokenHandlerGeneratedCodes(t *testing.T) {
 			}
 
 			assertToken(t, qrParsed.Token, test.auth.Secret)
+
+			keyring := tokens.NewKeyring(test.auth.Secret)
+			claims, err := keyring.Verify([]byte(qrParsed.Token), time.Now())
+			if err != nil {
+				t.Fatalf("error verifying token claims: %s", err)
+			}
+			if !claims.RegisterOnUse || claims.Device == "" {
+				t.Errorf("expected the device to be registered on first use: %+v", claims)
+			}
 		})
 	}
 }
@@ -155,4 +165,8 @@ func assertToken(t *testing.T, token, secret string) {
 	if err != nil {
 		t.Fatalf("error verifying JWT token: %s", err)
 	}
+
+	if jot.JWTID == "" {
+		t.Fatalf("JWT token does not have an ID")
+	}
 }
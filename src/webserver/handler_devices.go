@@ -0,0 +1,119 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// devicesHandler lists all devices which were given access tokens (GET) and
+// revokes the access of a single one (DELETE).
+type devicesHandler struct {
+	devices devices.Registry
+}
+
+// NewDevicesHandler returns an http.Handler which lists the registered devices
+// with a GET request and revokes the device with ID from the "deviceID" path
+// variable on a DELETE request.
+func NewDevicesHandler(registry devices.Registry) http.Handler {
+	return &devicesHandler{
+		devices: registry,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *devicesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if req.Method == http.MethodDelete {
+		h.revoke(w, req)
+		return
+	}
+
+	h.list(w, req)
+}
+
+func (h *devicesHandler) list(w http.ResponseWriter, req *http.Request) {
+	all, err := h.devices.List(req.Context())
+	if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Failed to list devices: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	currentTokenID := tokenIDFromContext(req.Context())
+	resp := devicesResponse{
+		Devices: make([]deviceJSON, 0, len(all)),
+	}
+	for _, device := range all {
+		dj := deviceJSON{
+			ID:         device.ID,
+			Name:       device.Name,
+			UserAgent:  device.UserAgent,
+			CreatedAt:  device.CreatedAt.Unix(),
+			LastSeenAt: device.LastSeenAt.Unix(),
+			Current:    currentTokenID != "" && device.TokenID == currentTokenID,
+		}
+		if device.RevokedAt != nil {
+			dj.RevokedAt = device.RevokedAt.Unix()
+		}
+
+		resp.Devices = append(resp.Devices, dj)
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(resp); err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Cannot write response JSON: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+}
+
+func (h *devicesHandler) revoke(w http.ResponseWriter, req *http.Request) {
+	deviceID, err := strconv.ParseInt(mux.Vars(req)["deviceID"], 10, 64)
+	if err != nil {
+		webutils.JSONError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	err = h.devices.Revoke(req.Context(), deviceID)
+	if errors.Is(err, devices.ErrNotFound) {
+		webutils.JSONError(w, "not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Failed to revoke device: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type devicesResponse struct {
+	Devices []deviceJSON `json:"devices"`
+}
+
+type deviceJSON struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	RevokedAt  int64  `json:"revoked_at,omitempty"`
+	Current    bool   `json:"current"`
+}
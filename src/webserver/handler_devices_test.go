@@ -0,0 +1,114 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestDevicesHandler checks listing and revoking devices.
+func TestDevicesHandler(t *testing.T) {
+	created := time.Unix(1728838802, 0)
+	revoked := created.Add(time.Hour)
+
+	registry := &devicesfakes.FakeRegistry{
+		ListStub: func(_ context.Context) ([]devices.Device, error) {
+			return []devices.Device{
+				{
+					ID:         1,
+					TokenID:    "token-one",
+					Name:       "Phone",
+					UserAgent:  "Test Agent/1.0",
+					CreatedAt:  created,
+					LastSeenAt: created,
+				},
+				{
+					ID:         2,
+					TokenID:    "token-two",
+					CreatedAt:  created,
+					LastSeenAt: created,
+					RevokedAt:  &revoked,
+				},
+			}, nil
+		},
+		RevokeStub: func(_ context.Context, deviceID int64) error {
+			if deviceID != 1 {
+				return devices.ErrNotFound
+			}
+			return nil
+		},
+	}
+
+	router := mux.NewRouter()
+	handler := webserver.NewDevicesHandler(registry)
+	router.Handle(webserver.APIv1EndpointDevices, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointDevices]...,
+	)
+	router.Handle(webserver.APIv1EndpointDevice, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointDevice]...,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/devices", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected HTTP %d but got %d", http.StatusOK, resp.Code)
+	}
+	assertContentTypeJSON(t, resp.Result().Header.Get("Content-Type"))
+
+	var listed struct {
+		Devices []struct {
+			ID         int64  `json:"id"`
+			Name       string `json:"name"`
+			UserAgent  string `json:"user_agent"`
+			CreatedAt  int64  `json:"created_at"`
+			LastSeenAt int64  `json:"last_seen_at"`
+			RevokedAt  int64  `json:"revoked_at"`
+		} `json:"devices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decoding devices response: %s", err)
+	}
+
+	if len(listed.Devices) != 2 {
+		t.Fatalf("expected 2 devices but got %d", len(listed.Devices))
+	}
+
+	first := listed.Devices[0]
+	if first.ID != 1 || first.Name != "Phone" || first.UserAgent != "Test Agent/1.0" {
+		t.Errorf("wrong first device: %+v", first)
+	}
+	if first.CreatedAt != created.Unix() || first.RevokedAt != 0 {
+		t.Errorf("wrong times for first device: %+v", first)
+	}
+	if listed.Devices[1].RevokedAt != revoked.Unix() {
+		t.Errorf("expected second device to be revoked: %+v", listed.Devices[1])
+	}
+
+	for _, test := range []struct {
+		url          string
+		expectedCode int
+	}{
+		{url: "/v1/devices/1", expectedCode: http.StatusNoContent},
+		{url: "/v1/devices/5", expectedCode: http.StatusNotFound},
+		{url: "/v1/devices/baba", expectedCode: http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodDelete, test.url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != test.expectedCode {
+			t.Errorf("DELETE %s: expected HTTP %d but got %d",
+				test.url, test.expectedCode, resp.Code)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package webserver
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,6 +14,10 @@ import (
 	"github.com/ironsmile/euterpe/src/library"
 )
 
+// recordPlayTimeout is the maximum time for recording a play after a file has
+// been served.
+const recordPlayTimeout = 10 * time.Second
+
 // FileHandler will find and serve a media file by its ID
 type FileHandler struct {
 	library library.Library
@@ -53,18 +58,69 @@ func (fh FileHandler) find(writer http.ResponseWriter, req *http.Request) error
 		modTime = st.ModTime()
 	}
 
-	err = fh.library.RecordTrackPlay(req.Context(), int64(id), time.Now())
-	if err != nil {
-		log.Printf("failed to update track %d stats: %s", id, err)
-	}
-
+	startedAt := time.Now()
 	baseName := filepath.Base(filePath)
 	writer.Header().Add("Content-Disposition",
 		fmt.Sprintf("filename=\"%s\"", baseName))
-	http.ServeContent(writer, req, baseName, modTime, fileReader)
+
+	cw := &countingResponseWriter{ResponseWriter: writer}
+	http.ServeContent(cw, req, baseName, modTime, fileReader)
+
+	if cw.written > 0 {
+		fh.recordPlay(req, int64(id), startedAt, cw.written, st)
+	}
 	return nil
 }
 
+// recordPlay adds the play of track `id` to the listening history. How much of
+// the track was played is estimated from the part of the file which was served.
+func (fh FileHandler) recordPlay(
+	req *http.Request,
+	id int64,
+	startedAt time.Time,
+	written int64,
+	st os.FileInfo,
+) {
+	// Clients close the connection when the listener stops or skips the track.
+	// Such partial plays are recorded too even though the request is cancelled.
+	ctx, cancel := context.WithTimeout(
+		context.WithoutCancel(req.Context()),
+		recordPlayTimeout,
+	)
+	defer cancel()
+
+	var played time.Duration
+	track, err := fh.library.GetTrack(ctx, id)
+	if err == nil && st != nil && st.Size() > 0 {
+		played = time.Duration(
+			float64(track.Duration)*float64(written)/float64(st.Size()),
+		) * time.Millisecond
+	}
+
+	err = fh.library.RecordTrackPlay(ctx, library.Play{
+		TrackID:  id,
+		At:       startedAt,
+		Client:   req.UserAgent(),
+		User:     userFromContext(ctx),
+		Duration: played,
+	})
+	if err != nil {
+		log.Printf("failed to record play of track %d: %s", id, err)
+	}
+}
+
+// countingResponseWriter counts the number of body bytes written through it.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
 // NewFileHandler returns a new File handler will will be resposible for serving a file
 // from the library identified from its ID.
 func NewFileHandler(lib library.Library) *FileHandler {
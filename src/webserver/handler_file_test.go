@@ -1,11 +1,18 @@
 package webserver_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
 	"github.com/ironsmile/euterpe/src/webserver"
 )
 
@@ -50,6 +57,111 @@ func TestFileHandlerWithWrongPathVars(t *testing.T) {
 	}
 }
 
+// TestFileHandlerRecordsPlays checks that serving a file adds a play to the
+// listening history and that its duration is estimated from the served part of
+// the file.
+func TestFileHandlerRecordsPlays(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "invaders.mp3")
+	if err := os.WriteFile(filePath, make([]byte, 1000), 0o600); err != nil {
+		t.Fatalf("creating media file: %s", err)
+	}
+
+	lib := &libraryfakes.FakeLibrary{
+		GetFilePathStub: func(_ context.Context, _ int64) string {
+			return filePath
+		},
+		GetTrackStub: func(_ context.Context, id int64) (library.TrackInfo, error) {
+			return library.TrackInfo{ID: id, Duration: 200000}, nil
+		},
+	}
+	h := routeFileHandler(webserver.NewFileHandler(lib))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/23", nil)
+	req.Header.Set("Range", "bytes=0-249")
+	req.Header.Set("User-Agent", "test-player")
+	resp := httptest.NewRecorder()
+
+	h.ServeHTTP(resp, req)
+
+	if status := resp.Result().StatusCode; status != http.StatusPartialContent {
+		t.Fatalf("expected status %d but got %d", http.StatusPartialContent, status)
+	}
+
+	if calls := lib.RecordTrackPlayCallCount(); calls != 1 {
+		t.Fatalf("expected one recorded play but got %d", calls)
+	}
+	_, play := lib.RecordTrackPlayArgsForCall(0)
+	if play.TrackID != 23 || play.Client != "test-player" ||
+		play.Duration != 50*time.Second || play.At.IsZero() {
+		t.Errorf("unexpected play: %+v", play)
+	}
+}
+
+// TestFileHandlerRecordsPartialPlays checks that a play is recorded even when
+// the client closes the connection in the middle of the file, which cancels the
+// context of the request.
+func TestFileHandlerRecordsPartialPlays(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "invaders.mp3")
+	if err := os.WriteFile(filePath, make([]byte, 100*1024), 0o600); err != nil {
+		t.Fatalf("creating media file: %s", err)
+	}
+
+	var ctxErrs []error
+	lib := &libraryfakes.FakeLibrary{
+		GetFilePathStub: func(_ context.Context, _ int64) string {
+			return filePath
+		},
+		GetTrackStub: func(ctx context.Context, id int64) (library.TrackInfo, error) {
+			ctxErrs = append(ctxErrs, ctx.Err())
+			return library.TrackInfo{ID: id, Duration: 200000}, nil
+		},
+		RecordTrackPlayStub: func(ctx context.Context, _ library.Play) error {
+			ctxErrs = append(ctxErrs, ctx.Err())
+			return nil
+		},
+	}
+	h := routeFileHandler(webserver.NewFileHandler(lib))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/23", nil).WithContext(ctx)
+	resp := &disconnectingWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		disconnect:       cancel,
+	}
+
+	h.ServeHTTP(resp, req)
+
+	if calls := lib.RecordTrackPlayCallCount(); calls != 1 {
+		t.Fatalf("expected one recorded play but got %d", calls)
+	}
+	_, play := lib.RecordTrackPlayArgsForCall(0)
+	if play.Duration <= 0 || play.Duration >= 200*time.Second {
+		t.Errorf("expected duration of a partial play but got %s", play.Duration)
+	}
+	for i, err := range ctxErrs {
+		if err != nil {
+			t.Errorf("library call %d was made with a done context: %s", i, err)
+		}
+	}
+}
+
+// disconnectingWriter simulates a client which closes the connection after the
+// first part of the response body.
+type disconnectingWriter struct {
+	*httptest.ResponseRecorder
+	disconnect func()
+}
+
+func (w *disconnectingWriter) Write(p []byte) (int, error) {
+	if w.Body.Len() > 0 {
+		w.disconnect()
+		return 0, errors.New("connection reset by peer")
+	}
+	return w.ResponseRecorder.Write(p[:min(len(p), 1024)])
+}
+
 // routeFileHandler wraps a handler the same way the web server will do when
 // constructing the main application router. This is needed for tests so that the
 // Gorilla mux variables will be parsed.
@@ -0,0 +1,137 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// historyHandler returns the listening history with the help of pagination.
+type historyHandler struct {
+	library library.Library
+}
+
+// NewHistoryHandler returns an HTTP handler which serves the listening history.
+// It could be filtered by user and by time range.
+func NewHistoryHandler(lib library.Library) http.Handler {
+	return &historyHandler{
+		library: lib,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *historyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	query := req.URL.Query()
+	page, perPage := 1, 10
+
+	var err error
+	if pageStr := query.Get("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil {
+			webutils.JSONError(
+				w,
+				fmt.Sprintf(`Wrong "page" parameter: %s`, err),
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+	if perPageStr := query.Get("per-page"); perPageStr != "" {
+		perPage, err = strconv.Atoi(perPageStr)
+		if err != nil {
+			webutils.JSONError(
+				w,
+				fmt.Sprintf(`Wrong "per-page" parameter: %s`, err),
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+	if page < 1 || perPage < 1 {
+		webutils.JSONError(
+			w,
+			`"page" and "per-page" must be integers greater than one`,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	args := library.HistoryArgs{
+		User:    query.Get("user"),
+		Offset:  uint64(page-1) * uint64(perPage),
+		PerPage: uint(perPage),
+	}
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{
+		{"from", &args.From},
+		{"to", &args.To},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || unix < 0 {
+			webutils.JSONError(
+				w,
+				fmt.Sprintf(`"%s" must be a Unix timestamp in seconds`, param.name),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		*param.dest = time.Unix(unix, 0)
+	}
+
+	entries, count, err := h.library.GetHistory(req.Context(), args)
+	if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("getting history failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	if entries == nil {
+		entries = []library.HistoryEntry{}
+	}
+
+	pageURI := func(page int) string {
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per-page", strconv.Itoa(perPage))
+		return APIv1EndpointHistory + "?" + query.Encode()
+	}
+
+	retData := struct {
+		Data       []library.HistoryEntry `json:"data"`
+		Next       string                 `json:"next"`
+		Previous   string                 `json:"previous"`
+		PagesCount int                    `json:"pages_count"`
+	}{
+		Data:       entries,
+		PagesCount: int(math.Ceil(float64(count) / float64(perPage))),
+	}
+	if page > 1 {
+		retData.Previous = pageURI(page - 1)
+	}
+	if page*perPage < count {
+		retData.Next = pageURI(page + 1)
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(retData); err != nil {
+		log.Printf("error writing history response: %s", err)
+	}
+}
@@ -0,0 +1,102 @@
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestHistoryHandler checks that the listening history is returned with links to
+// the neighbouring pages, that the filters are passed to the library and that
+// wrong arguments are rejected.
+func TestHistoryHandler(t *testing.T) {
+	lib := &libraryfakes.FakeLibrary{
+		GetHistoryStub: func(
+			_ context.Context,
+			args library.HistoryArgs,
+		) ([]library.HistoryEntry, int, error) {
+			if args.User == "broken" {
+				return nil, 0, errors.New("database is gone")
+			}
+
+			return []library.HistoryEntry{
+				{
+					ID:       5,
+					PlayedAt: 1700000200,
+					User:     args.User,
+					Track:    library.TrackInfo{ID: 1, Title: "Invaders"},
+				},
+			}, 7, nil
+		},
+	}
+
+	router := routeAPIv1Handler(
+		webserver.APIv1EndpointHistory,
+		webserver.NewHistoryHandler(lib),
+	)
+
+	var history struct {
+		Data       []library.HistoryEntry `json:"data"`
+		Next       string                 `json:"next"`
+		Previous   string                 `json:"previous"`
+		PagesCount int                    `json:"pages_count"`
+	}
+	getJSON(
+		t,
+		router,
+		"/v1/history?user=alice&from=1700000000&to=1800000000&page=2&per-page=3",
+		&history,
+	)
+
+	if len(history.Data) != 1 || history.Data[0].Track.Title != "Invaders" {
+		t.Errorf("unexpected history: %+v", history.Data)
+	}
+	if history.PagesCount != 3 {
+		t.Errorf("expected 3 pages but got %d", history.PagesCount)
+	}
+	assertHistoryPage(t, history.Next, "3")
+	assertHistoryPage(t, history.Previous, "1")
+
+	_, args := lib.GetHistoryArgsForCall(0)
+	expectedArgs := library.HistoryArgs{
+		User:    "alice",
+		From:    time.Unix(1700000000, 0),
+		To:      time.Unix(1800000000, 0),
+		Offset:  3,
+		PerPage: 3,
+	}
+	if args != expectedArgs {
+		t.Errorf("expected history for %+v but got %+v", expectedArgs, args)
+	}
+
+	assertStatuses(t, router, []statusTest{
+		{url: "/v1/history?user=broken", status: http.StatusInternalServerError},
+		{url: "/v1/history?page=0", status: http.StatusBadRequest},
+		{url: "/v1/history?per-page=many", status: http.StatusBadRequest},
+		{url: "/v1/history?from=yesterday", status: http.StatusBadRequest},
+		{url: "/v1/history?to=-5", status: http.StatusBadRequest},
+	})
+}
+
+func assertHistoryPage(t *testing.T, pageURI, expectedPage string) {
+	t.Helper()
+
+	u, err := url.Parse(pageURI)
+	if err != nil {
+		t.Fatalf("parsing page URI `%s`: %s", pageURI, err)
+	}
+
+	query := u.Query()
+	if u.Path != webserver.APIv1EndpointHistory || query.Get("page") != expectedPage ||
+		query.Get("per-page") != "3" || query.Get("user") != "alice" ||
+		query.Get("from") != "1700000000" {
+		t.Errorf("unexpected page URI `%s`", pageURI)
+	}
+}
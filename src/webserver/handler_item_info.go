@@ -0,0 +1,77 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// itemInfoHandler returns a single item from the library, such as an album or an
+// artist, together with what is known about it from the internet as JSON.
+type itemInfoHandler[Item, Info any] struct {
+	// name is what the item is. It is used in error messages.
+	name string
+
+	// idVar is the name of the route variable with the ID of the item.
+	idVar string
+
+	// notFound is the error returned by getItem for missing items.
+	notFound error
+
+	getItem  func(ctx context.Context, id int64) (Item, error)
+	getInfo  func(ctx context.Context, id int64) (Info, error)
+	response func(item Item, info Info) any
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *itemInfoHandler[Item, Info]) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	notFoundText := fmt.Sprintf("%s not found", h.name)
+
+	id, err := strconv.ParseInt(mux.Vars(req)[h.idVar], 10, 64)
+	if err != nil {
+		webutils.JSONError(w, notFoundText, http.StatusNotFound)
+		return
+	}
+
+	item, err := h.getItem(req.Context(), id)
+	if errors.Is(err, h.notFound) {
+		webutils.JSONError(w, notFoundText, http.StatusNotFound)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("getting %s failed: %s", h.name, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	// Searching for the info on the internet could take a while.
+	ctx, cancel := context.WithTimeout(req.Context(), time.Minute)
+	defer cancel()
+
+	info, err := h.getInfo(ctx, id)
+	if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("getting %s info failed: %s", h.name, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(h.response(item, info)); err != nil {
+		log.Printf("error writing %s response: %s", h.name, err)
+	}
+}
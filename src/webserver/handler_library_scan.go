@@ -0,0 +1,84 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// libraryScanHandler returns the status of the library scan (GET) and starts
+// a new scan (POST).
+type libraryScanHandler struct {
+	library library.Library
+}
+
+// NewLibraryScanHandler returns an HTTP handler which serves the status of the
+// current or last library scan on GET requests and starts a new scan on POST
+// requests.
+func NewLibraryScanHandler(lib library.Library) http.Handler {
+	return &libraryScanHandler{
+		library: lib,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *libraryScanHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if req.Method == http.MethodPost {
+		h.start(w, req)
+		return
+	}
+
+	h.writeStatus(w, http.StatusOK)
+}
+
+func (h *libraryScanHandler) start(w http.ResponseWriter, req *http.Request) {
+	scanReq := startScanRequest{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&scanReq); err != nil && !errors.Is(err, io.EOF) {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Cannot decode scan request JSON: %s", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	err := h.library.StartScan(scanReq.FullRescan)
+	if errors.Is(err, library.ErrScanRunning) {
+		webutils.JSONError(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("Starting library scan failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	h.writeStatus(w, http.StatusAccepted)
+}
+
+func (h *libraryScanHandler) writeStatus(w http.ResponseWriter, code int) {
+	w.WriteHeader(code)
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(h.library.ScanStatus()); err != nil {
+		log.Printf("error writing scan status response: %s", err)
+	}
+}
+
+// startScanRequest is the optional JSON body of the request for starting
+// a library scan.
+type startScanRequest struct {
+	// FullRescan makes the scan read again the tags of all files in the library.
+	FullRescan bool `json:"full_rescan"`
+}
@@ -0,0 +1,112 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestLibraryScanHandler checks that the scan status is returned and that scans
+// are started with the requested options.
+func TestLibraryScanHandler(t *testing.T) {
+	var running bool
+	lib := &libraryfakes.FakeLibrary{
+		ScanStatusStub: func() library.ScanStatus {
+			return library.ScanStatus{
+				Running:    running,
+				FilesSeen:  12,
+				FilesAdded: 3,
+				StartedAt:  1728838802,
+			}
+		},
+		StartScanStub: func(full bool) error {
+			if running {
+				return library.ErrScanRunning
+			}
+			running = true
+			return nil
+		},
+	}
+
+	router := mux.NewRouter()
+	router.Handle(
+		webserver.APIv1EndpointLibraryScan,
+		webserver.NewLibraryScanHandler(lib),
+	).Methods(webserver.APIv1Methods[webserver.APIv1EndpointLibraryScan]...)
+
+	tests := []struct {
+		desc         string
+		method       string
+		body         string
+		expectedCode int
+		fullRescan   bool
+	}{
+		{
+			desc:         "status",
+			method:       http.MethodGet,
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "start full rescan",
+			method:       http.MethodPost,
+			body:         `{"full_rescan": true}`,
+			expectedCode: http.StatusAccepted,
+			fullRescan:   true,
+		},
+		{
+			desc:         "already running",
+			method:       http.MethodPost,
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			callsBefore := lib.StartScanCallCount()
+
+			req := httptest.NewRequest(
+				test.method,
+				"/v1/library/scan",
+				strings.NewReader(test.body),
+			)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			resp := rec.Result()
+			if resp.StatusCode != test.expectedCode {
+				t.Fatalf("expected status %d but got %d",
+					test.expectedCode, resp.StatusCode)
+			}
+
+			if test.method == http.MethodPost {
+				if lib.StartScanCallCount() != callsBefore+1 {
+					t.Fatalf("expected StartScan to be called")
+				}
+				full := lib.StartScanArgsForCall(callsBefore)
+				if full != test.fullRescan {
+					t.Errorf("expected full rescan to be %t", test.fullRescan)
+				}
+			}
+
+			if resp.StatusCode >= http.StatusBadRequest {
+				return
+			}
+
+			var status library.ScanStatus
+			if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+				t.Fatalf("decoding response JSON: %s", err)
+			}
+
+			if status.FilesSeen != 12 || status.FilesAdded != 3 {
+				t.Errorf("unexpected scan status: %+v", status)
+			}
+		})
+	}
+}
@@ -7,31 +7,43 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gbrlsnchs/jwt/v3"
-
 	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/loginlimit"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
 )
 
-var (
-	// Session cookie is when the user has *not* clicked the "remember me" check box.
-	// It will expire with the browser session. Remember me cookie (!sessionCookie)
-	// on the other hand should live for far longer. We set the session token
-	// optimistically to expire in seven days. Hopefully the session will not be that
-	// long. The remember me cookie on the other hand gets to live for longer.
-	sessionTokenDuration = 7 * 24 * time.Hour
-	rememberMeDuration   = 62 * 24 * time.Hour
-)
+// rememberMeDuration is how long a browser stays logged in when the user has
+// clicked the "remember me" check box. It is the lifetime of the long lived
+// tokens as well.
+var rememberMeDuration = 62 * 24 * time.Hour
+
+// browserDeviceName is the device name used for tokens given to browsers which
+// logged in using the web UI.
+const browserDeviceName = "Web browser"
 
 type loginHandler struct {
-	auth config.Auth
+	auth    config.Auth
+	keyring *tokens.Keyring
+	devices devices.Registry
+	limiter *loginlimit.Limiter
 }
 
 // NewLoginHandler returns a new login handler which will use the information in
 // auth for deciding when user has logged in correctly and also for generating
-// tokens.
-func NewLoginHandler(auth config.Auth) http.Handler {
+// tokens. Every generated token is registered as a device in `registry`. Failed
+// attempts are throttled by `limiter`.
+func NewLoginHandler(
+	auth config.Auth,
+	registry devices.Registry,
+	limiter *loginlimit.Limiter,
+) http.Handler {
 	return &loginHandler{
-		auth: auth,
+		auth:    auth,
+		keyring: keyringFromAuth(auth),
+		devices: registry,
+		limiter: limiter,
 	}
 }
 
@@ -44,12 +56,34 @@ func (h *loginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	user := r.PostFormValue("username")
 	pass := r.PostFormValue("password")
 
+	if wait, ok := h.limiter.Allow(r, user); !ok {
+		h.respondTooManyAttempts(w, r, returnTo, wait)
+		return
+	}
+
 	if !checkLoginCreds(user, pass, h.auth) {
+		h.limiter.Failed(r, user)
 		h.respondWrong(w, r, returnTo)
 		return
 	}
 
-	h.respondCorrect(w, r, returnTo)
+	h.limiter.Succeeded(r, user)
+	h.respondCorrect(w, r, user, returnTo)
+}
+
+func (h *loginHandler) respondTooManyAttempts(
+	w http.ResponseWriter,
+	r *http.Request,
+	returnTo string,
+	wait time.Duration,
+) {
+	query := url.Values{}
+	query.Set(returnToQueryParam, returnTo)
+	query.Set("tooManyAttempts", "1")
+
+	webutils.SetRetryAfter(w, wait)
+	w.Header().Set("Location", fmt.Sprintf("/login/?%s", query.Encode()))
+	w.WriteHeader(http.StatusFound)
 }
 
 func (h *loginHandler) respondWrong(
@@ -68,52 +102,68 @@ func (h *loginHandler) respondWrong(
 func (h *loginHandler) respondCorrect(
 	w http.ResponseWriter,
 	r *http.Request,
+	user string,
 	returnTo string,
 ) {
-	sessionCookie := true
-
-	if r.PostFormValue("remember_me") == "on" {
-		sessionCookie = false
-	}
+	rememberMe := r.PostFormValue("remember_me") == "on"
 
-	var expiresAt time.Time
-	now := time.Now()
-
-	if sessionCookie {
-		expiresAt = now.Add(sessionTokenDuration)
-	} else {
-		expiresAt = now.Add(rememberMeDuration)
-	}
-
-	pl := jwt.Payload{
-		IssuedAt:       jwt.NumericDate(now),
-		ExpirationTime: jwt.NumericDate(expiresAt),
-	}
-
-	if len(h.auth.Secret) == 0 {
-		errMessage := "Error generating JWT: secret is empty"
-		http.Error(w, errMessage, http.StatusInternalServerError)
-		return
-	}
-
-	token, err := jwt.Sign(pl, jwt.NewHS256([]byte(h.auth.Secret)))
+	err := setSessionCookies(w, r, h.devices, h.keyring, user, rememberMe)
 	if err != nil {
 		errMessage := fmt.Sprintf("Error generating JWT: %s.", err)
 		http.Error(w, errMessage, http.StatusInternalServerError)
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:     sessionCookieName,
-		Value:    string(token),
-		Path:     "/",
-		HttpOnly: true,
+	w.Header().Set("Location", returnTo)
+	w.WriteHeader(http.StatusFound)
+}
+
+// setSessionCookies registers a new browser device and sets the session cookies
+// for `subject` in `w`. Without `rememberMe` the cookies expire with the browser
+// session.
+func setSessionCookies(
+	w http.ResponseWriter,
+	r *http.Request,
+	registry devices.Registry,
+	keyring *tokens.Keyring,
+	subject string,
+	rememberMe bool,
+) error {
+	device := devices.Device{
+		Name:      browserDeviceName,
+		UserAgent: r.UserAgent(),
 	}
-	if !sessionCookie {
-		cookie.Expires = expiresAt
+
+	access, refresh, _, err := newDeviceTokenPair(
+		r.Context(),
+		registry,
+		keyring,
+		subject,
+		device,
+		rememberMe,
+	)
+	if err != nil {
+		return err
 	}
-	http.SetCookie(w, cookie)
 
-	w.Header().Set("Location", returnTo)
-	w.WriteHeader(http.StatusFound)
+	writeSessionCookies(w, access, refresh, rememberMe)
+	return nil
+}
+
+// writeSessionCookies sets the cookies of a web UI session in `w`. The session
+// cookie holds the short lived `access` token. The `refresh` token is in its own
+// cookie and is exchanged for new tokens once the access token expires. With
+// `rememberMe` the cookies are kept for as long as the refresh token is valid.
+func writeSessionCookies(w http.ResponseWriter, access, refresh []byte, rememberMe bool) {
+	for _, cookie := range []*http.Cookie{
+		{Name: sessionCookieName, Value: string(access)},
+		{Name: refreshCookieName, Value: string(refresh)},
+	} {
+		cookie.Path = "/"
+		cookie.HttpOnly = true
+		if rememberMe {
+			cookie.Expires = time.Now().Add(refreshTokenDuration)
+		}
+		http.SetCookie(w, cookie)
+	}
 }
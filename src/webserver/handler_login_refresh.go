@@ -0,0 +1,80 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+)
+
+const invalidRefreshTokenText = "invalid or expired refresh token"
+
+type loginRefreshHandler struct {
+	keyring *tokens.Keyring
+	devices devices.Registry
+}
+
+// NewLoginRefreshHandler returns a handler which exchanges refresh tokens for new
+// access and refresh tokens. This works only for as long as the device of the
+// token is registered in `registry` and has not been revoked. Every refresh token
+// could be exchanged only once. Using one for a second time revokes its device.
+func NewLoginRefreshHandler(auth config.Auth, registry devices.Registry) http.Handler {
+	return &loginRefreshHandler{
+		keyring: keyringFromAuth(auth),
+		devices: registry,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *loginRefreshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	reqBody := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+
+	dec := json.NewDecoder(r.Body)
+	if err := dec.Decode(&reqBody); err != nil {
+		respondWithJSONError(
+			w,
+			http.StatusBadRequest,
+			"Error parsing JSON request: %s.",
+			err,
+		)
+		return
+	}
+
+	_, access, refresh, err := refreshTokenPair(
+		r.Context(),
+		h.keyring,
+		h.devices,
+		[]byte(reqBody.RefreshToken),
+	)
+	if errors.Is(err, errInvalidRefreshToken) {
+		respondWithJSONError(w, http.StatusUnauthorized, invalidRefreshTokenText)
+		return
+	} else if err != nil {
+		log.Printf("Error refreshing tokens: %s\n", err)
+		respondWithJSONError(
+			w,
+			http.StatusInternalServerError,
+			"Error refreshing tokens: %s.",
+			err,
+		)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	err = enc.Encode(&tokenResponse{
+		AccessToken:  string(access),
+		RefreshToken: string(refresh),
+		ExpiresIn:    int64(accessTokenDuration.Seconds()),
+	})
+	if err != nil {
+		log.Printf("Error writing refresh token response: %s\n", err)
+	}
+}
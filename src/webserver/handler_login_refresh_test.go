@@ -0,0 +1,285 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+)
+
+// TestLoginRefreshHandler checks that refresh tokens could be exchanged for new
+// tokens only while their device is not revoked and only once.
+func TestLoginRefreshHandler(t *testing.T) {
+	const (
+		knownDevice   = "known-device"
+		revokedDevice = "revoked-device"
+		reusedDevice  = "reused-device"
+	)
+
+	cfg := config.Auth{
+		User:            "test-user",
+		Password:        "test-pass",
+		Secret:          "test-secret",
+		PreviousSecrets: []string{"previous-secret"},
+	}
+
+	registry := &devicesfakes.FakeRegistry{
+		CheckStub: func(_ context.Context, tokenID string) error {
+			switch tokenID {
+			case knownDevice, reusedDevice:
+				return nil
+			case revokedDevice:
+				return devices.ErrRevoked
+			default:
+				return devices.ErrNotFound
+			}
+		},
+		RotateRefreshTokenStub: func(_ context.Context, tokenID, _, _ string) error {
+			if tokenID == reusedDevice {
+				return devices.ErrRefreshTokenUsed
+			}
+			return nil
+		},
+	}
+
+	sign := func(secret, device string, use tokens.Use, expiresIn time.Duration) string {
+		now := time.Now()
+		token, err := tokens.NewKeyring(secret).Sign(tokens.Claims{
+			Payload: jwt.Payload{
+				Subject:        cfg.User,
+				JWTID:          "some-token-id",
+				IssuedAt:       jwt.NumericDate(now),
+				ExpirationTime: jwt.NumericDate(now.Add(expiresIn)),
+			},
+			Device: device,
+			Use:    use,
+		})
+		if err != nil {
+			t.Fatalf("signing token: %s", err)
+		}
+		return string(token)
+	}
+
+	tests := []struct {
+		desc         string
+		body         string
+		expectedCode int
+	}{
+		{
+			desc: "valid refresh token",
+			body: sign(
+				cfg.Secret, knownDevice, tokens.UseRefresh, time.Hour,
+			),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "signed with the previous secret",
+			body: sign(
+				"previous-secret", knownDevice, tokens.UseRefresh, time.Hour,
+			),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "revoked device",
+			body: sign(
+				cfg.Secret, revokedDevice, tokens.UseRefresh, time.Hour,
+			),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc: "used refresh token",
+			body: sign(
+				cfg.Secret, reusedDevice, tokens.UseRefresh, time.Hour,
+			),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc: "access token instead of refresh",
+			body: sign(
+				cfg.Secret, knownDevice, tokens.UseAccess, time.Hour,
+			),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc: "expired refresh token",
+			body: sign(
+				cfg.Secret, knownDevice, tokens.UseRefresh, -time.Hour,
+			),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc: "unknown key",
+			body: sign(
+				"unknown-secret", knownDevice, tokens.UseRefresh, time.Hour,
+			),
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			h := routeLoginRefreshHandler(webserver.NewLoginRefreshHandler(cfg, registry))
+
+			body := fmt.Sprintf(`{"refresh_token": %q}`, test.body)
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/v1/login/refresh",
+				strings.NewReader(body),
+			)
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+
+			if resp.Code != test.expectedCode {
+				t.Fatalf("expected HTTP %d but got %d", test.expectedCode, resp.Code)
+			}
+			assertContentTypeJSON(t, resp.Header().Get("Content-Type"))
+
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			var tokenResp struct {
+				AccessToken  string `json:"access_token"`
+				RefreshToken string `json:"refresh_token"`
+				ExpiresIn    int64  `json:"expires_in"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+				t.Fatalf("decoding response: %s", err)
+			}
+
+			keyring := tokens.NewKeyring(cfg.Secret)
+			access, err := keyring.Verify([]byte(tokenResp.AccessToken), time.Now())
+			if err != nil {
+				t.Fatalf("verifying access token: %s", err)
+			}
+			if access.Use != tokens.UseAccess || access.Device != knownDevice {
+				t.Errorf("wrong access token claims: %+v", access)
+			}
+
+			refresh, err := keyring.Verify([]byte(tokenResp.RefreshToken), time.Now())
+			if err != nil {
+				t.Fatalf("verifying refresh token: %s", err)
+			}
+			if refresh.Use != tokens.UseRefresh || refresh.Device != knownDevice {
+				t.Errorf("wrong refresh token claims: %+v", refresh)
+			}
+
+			calls := registry.RotateRefreshTokenCallCount()
+			_, device, usedID, newID := registry.RotateRefreshTokenArgsForCall(calls - 1)
+			if device != knownDevice || usedID != "some-token-id" || newID != refresh.JWTID {
+				t.Errorf("wrong refresh token rotation: %s, %s -> %s", device, usedID, newID)
+			}
+		})
+	}
+}
+
+// routeLoginRefreshHandler wraps a handler the same way the web server will do when
+// constructing the main application router.
+func routeLoginRefreshHandler(h http.Handler) http.Handler {
+	router := mux.NewRouter()
+	router.StrictSlash(true)
+	router.UseEncodedPath()
+	router.Handle(webserver.APIv1EndpointLoginRefresh, h).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointLoginRefresh]...,
+	)
+
+	return router
+}
+
+// TestLoginRefreshHandlerReuse checks that using a refresh token for a second time
+// revokes its device. So the tokens given out for the first use stop working too.
+func TestLoginRefreshHandlerReuse(t *testing.T) {
+	const deviceID = "some-device"
+
+	cfg := config.Auth{Secret: "test-secret"}
+	keyring := tokens.NewKeyring(cfg.Secret)
+
+	var (
+		lastRefreshID string
+		revoked       bool
+	)
+	registry := &devicesfakes.FakeRegistry{
+		CheckStub: func(_ context.Context, tokenID string) error {
+			if revoked {
+				return devices.ErrRevoked
+			}
+			return nil
+		},
+		RotateRefreshTokenStub: func(_ context.Context, _, usedID, newID string) error {
+			if lastRefreshID != "" && usedID != lastRefreshID {
+				return devices.ErrRefreshTokenUsed
+			}
+			lastRefreshID = newID
+			return nil
+		},
+		RevokeTokenStub: func(_ context.Context, tokenID string) error {
+			if tokenID != deviceID {
+				return devices.ErrNotFound
+			}
+			revoked = true
+			return nil
+		},
+	}
+	h := routeLoginRefreshHandler(webserver.NewLoginRefreshHandler(cfg, registry))
+
+	now := time.Now()
+	loginRefresh, err := keyring.Sign(tokens.Claims{
+		Payload: jwt.Payload{
+			Subject:        "test-user",
+			JWTID:          "login-refresh-token",
+			IssuedAt:       jwt.NumericDate(now),
+			ExpirationTime: jwt.NumericDate(now.Add(time.Hour)),
+		},
+		Device: deviceID,
+		Use:    tokens.UseRefresh,
+	})
+	if err != nil {
+		t.Fatalf("signing token: %s", err)
+	}
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"refresh_token": %q}`, token)
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/v1/login/refresh",
+			strings.NewReader(body),
+		)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := refresh(string(loginRefresh))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("first refresh: expected HTTP 200 but got %d", resp.Code)
+	}
+	var tokenResp struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if resp := refresh(string(loginRefresh)); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token: expected HTTP 401 but got %d", resp.Code)
+	}
+	if !revoked {
+		t.Errorf("expected the device to be revoked after reusing its refresh token")
+	}
+
+	if resp := refresh(tokenResp.RefreshToken); resp.Code != http.StatusUnauthorized {
+		t.Errorf("rotated refresh token: expected HTTP 401 but got %d", resp.Code)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
 	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
 )
 
 // TestLoginHandlerSuccessful checks the golden path for logging in with the
@@ -60,7 +62,7 @@ func TestLoginHandlerSuccessful(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.desc, func(t *testing.T) {
-			h := webserver.NewLoginHandler(cfg)
+			h := webserver.NewLoginHandler(cfg, &devicesfakes.FakeRegistry{}, nil)
 
 			formSting := fmt.Sprintf(
 				"username=%s&password=%s", cfg.User, cfg.Password,
@@ -86,16 +88,18 @@ func TestLoginHandlerSuccessful(t *testing.T) {
 				)
 			}
 
-			var sessionCookie *http.Cookie
+			var sessionCookie, refreshCookie *http.Cookie
 			for _, cookie := range resp.Result().Cookies() {
-				if cookie.Name != "session" {
-					continue
+				switch cookie.Name {
+				case "session":
+					sessionCookie = cookie
+				case "session_refresh":
+					refreshCookie = cookie
 				}
-				sessionCookie = cookie
 			}
 
-			if sessionCookie == nil {
-				t.Fatal("login handler did not return a session cookie")
+			if sessionCookie == nil || refreshCookie == nil {
+				t.Fatal("login handler did not return session and refresh cookies")
 			}
 
 			locationHeader := resp.Result().Header.Get("Location")
@@ -109,26 +113,49 @@ func TestLoginHandlerSuccessful(t *testing.T) {
 
 			assertToken(t, sessionCookie.Value, cfg.Secret)
 
-			if test.rememberMe {
-				if !sessionCookie.Expires.After(time.Now().Add(744 * time.Hour)) {
-					t.Errorf(
-						"expected cookies to expire at least in a month but it does in %s",
-						time.Until(sessionCookie.Expires),
-					)
-				}
-			} else if !sessionCookie.Expires.IsZero() {
-				t.Errorf("session cookies without remember me should not have expiration")
+			keyring := tokens.NewKeyring(cfg.Secret)
+			access, err := keyring.Verify([]byte(sessionCookie.Value), time.Now())
+			if err != nil {
+				t.Fatalf("verifying access token: %s", err)
+			}
+			if access.ExpirationTime.After(time.Now().Add(time.Hour)) {
+				t.Errorf("expected a short lived access token but it expires at %s",
+					access.ExpirationTime)
 			}
 
-			if !sessionCookie.HttpOnly {
-				t.Error("expected session cookie to be HTTP Only")
+			refresh, err := keyring.Verify([]byte(refreshCookie.Value), time.Now())
+			if err != nil {
+				t.Fatalf("verifying refresh token: %s", err)
+			}
+			if refresh.Use != tokens.UseRefresh || refresh.Device != access.Device ||
+				refresh.RememberMe != test.rememberMe {
+				t.Errorf("wrong refresh token claims: %+v", refresh)
 			}
 
-			if sessionCookie.Path != "/" {
-				t.Errorf(
-					"expected session cookie path to be for Path / but it was `%s`",
-					sessionCookie.Path,
-				)
+			for _, cookie := range []*http.Cookie{sessionCookie, refreshCookie} {
+				if test.rememberMe {
+					if !cookie.Expires.After(time.Now().Add(744 * time.Hour)) {
+						t.Errorf(
+							"expected cookie %s to expire at least in a month but it does in %s",
+							cookie.Name,
+							time.Until(cookie.Expires),
+						)
+					}
+				} else if !cookie.Expires.IsZero() {
+					t.Errorf("session cookies without remember me should not have expiration")
+				}
+
+				if !cookie.HttpOnly {
+					t.Errorf("expected cookie %s to be HTTP Only", cookie.Name)
+				}
+
+				if cookie.Path != "/" {
+					t.Errorf(
+						"expected cookie %s path to be for Path / but it was `%s`",
+						cookie.Name,
+						cookie.Path,
+					)
+				}
 			}
 		})
 	}
@@ -145,7 +172,7 @@ func TestLoginHandlerWrong(t *testing.T) {
 
 	const returnTo = "/a/test/place?with=query"
 
-	h := webserver.NewLoginHandler(cfg)
+	h := webserver.NewLoginHandler(cfg, &devicesfakes.FakeRegistry{}, nil)
 	req := httptest.NewRequest(
 		http.MethodPost,
 		"/?return_to="+returnTo,
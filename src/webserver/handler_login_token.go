@@ -6,25 +6,53 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/gbrlsnchs/jwt/v3"
-
 	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/loginlimit"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
 )
 
 const (
-	wrongLoginText = "wrong username or password"
+	wrongLoginText      = "wrong username or password"
+	tooManyAttemptsText = "too many failed attempts, try again later"
 )
 
+// tokenResponse is the response of the endpoints which issue tokens.
+type tokenResponse struct {
+	// Token is a long lived access token. It is given only when legacy tokens
+	// are enabled in the configuration for clients which do not know about
+	// refresh tokens.
+	Token string `json:"token,omitempty"`
+
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+
+	// ExpiresIn is the lifetime of the AccessToken in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
 type loginTokenHandler struct {
-	auth config.Auth
+	auth    config.Auth
+	keyring *tokens.Keyring
+	devices devices.Registry
+	limiter *loginlimit.Limiter
 }
 
 // NewLoginTokenHandler returns a new login handler which will use the information in
 // auth for deciding when device or program was logged in correctly by entering
-// username and password.
-func NewLoginTokenHandler(auth config.Auth) http.Handler {
+// username and password. Every generated token is registered as a device in
+// `registry`. Failed attempts are throttled by `limiter`.
+func NewLoginTokenHandler(
+	auth config.Auth,
+	registry devices.Registry,
+	limiter *loginlimit.Limiter,
+) http.Handler {
 	return &loginTokenHandler{
-		auth: auth,
+		auth:    auth,
+		keyring: keyringFromAuth(auth),
+		devices: registry,
+		limiter: limiter,
 	}
 }
 
@@ -32,8 +60,9 @@ func (h *loginTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
 	reqBody := struct {
-		User string `json:"username"`
-		Pass string `json:"password"`
+		User       string `json:"username"`
+		Pass       string `json:"password"`
+		DeviceName string `json:"device_name"`
 	}{}
 
 	dec := json.NewDecoder(r.Body)
@@ -47,27 +76,32 @@ func (h *loginTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if wait, ok := h.limiter.Allow(r, reqBody.User); !ok {
+		webutils.SetRetryAfter(w, wait)
+		respondWithJSONError(w, http.StatusTooManyRequests, tooManyAttemptsText)
+		return
+	}
+
 	if !checkLoginCreds(reqBody.User, reqBody.Pass, h.auth) {
+		h.limiter.Failed(r, reqBody.User)
 		respondWithJSONError(w, http.StatusUnauthorized, wrongLoginText)
 		return
 	}
+	h.limiter.Succeeded(r, reqBody.User)
 
-	now := time.Now()
-	pl := jwt.Payload{
-		IssuedAt:       jwt.NumericDate(now),
-		ExpirationTime: jwt.NumericDate(time.Now().Add(rememberMeDuration)),
+	device := devices.Device{
+		Name:      reqBody.DeviceName,
+		UserAgent: r.UserAgent(),
 	}
 
-	if len(h.auth.Secret) == 0 {
-		respondWithJSONError(
-			w,
-			http.StatusInternalServerError,
-			"Error generating JWT: secret is empty.",
-		)
-		return
-	}
-
-	token, err := jwt.Sign(pl, jwt.NewHS256([]byte(h.auth.Secret)))
+	access, refresh, deviceID, err := newDeviceTokenPair(
+		r.Context(),
+		h.devices,
+		h.keyring,
+		reqBody.User,
+		device,
+		false,
+	)
 	if err != nil {
 		respondWithJSONError(
 			w,
@@ -78,12 +112,33 @@ func (h *loginTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	resp := tokenResponse{
+		AccessToken:  string(access),
+		RefreshToken: string(refresh),
+		ExpiresIn:    int64(accessTokenDuration.Seconds()),
+	}
+
+	if h.auth.LegacyTokens {
+		token, _, err := signToken(
+			h.keyring,
+			tokens.Claims{Device: deviceID, Use: tokens.UseAccess},
+			reqBody.User,
+			time.Now().Add(rememberMeDuration),
+		)
+		if err != nil {
+			respondWithJSONError(
+				w,
+				http.StatusInternalServerError,
+				"Error generating JWT: %s.",
+				err,
+			)
+			return
+		}
+		resp.Token = string(token)
+	}
+
 	enc := json.NewEncoder(w)
-	err = enc.Encode(&struct {
-		Token string `json:"token"`
-	}{
-		Token: string(token),
-	})
+	err = enc.Encode(&resp)
 
 	if err != nil {
 		respondWithJSONError(
@@ -3,14 +3,19 @@ package webserver_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
 	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/loginlimit"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
 )
 
 // TestLoginTokenHandler uses the login-with-token HTTP handler and makes sure the
@@ -22,29 +27,45 @@ func TestLoginTokenHandler(t *testing.T) {
 		Secret:   "test-secret",
 	}
 
+	const (
+		deviceName = "Test Phone"
+		userAgent  = "Test Agent/1.0"
+	)
+
+	correctLogin := func(t *testing.T) io.Reader {
+		reqBodyJSON := struct {
+			User       string `json:"username"`
+			Pass       string `json:"password"`
+			DeviceName string `json:"device_name"`
+		}{
+			User:       cfg.User,
+			Pass:       cfg.Password,
+			DeviceName: deviceName,
+		}
+		var reqBody bytes.Buffer
+		enc := json.NewEncoder(&reqBody)
+		if err := enc.Encode(reqBodyJSON); err != nil {
+			t.Fatalf("encoding request JSON failed: %s", err)
+		}
+
+		return &reqBody
+	}
+
 	tests := []struct {
 		desc               string
+		legacyTokens       bool
 		reqBody            func(t *testing.T) io.Reader
 		expectedStatusCode int
 	}{
 		{
-			desc: "successful login",
-			reqBody: func(t *testing.T) io.Reader {
-				reqBodyJSON := struct {
-					User string `json:"username"`
-					Pass string `json:"password"`
-				}{
-					User: cfg.User,
-					Pass: cfg.Password,
-				}
-				var reqBody bytes.Buffer
-				enc := json.NewEncoder(&reqBody)
-				if err := enc.Encode(reqBodyJSON); err != nil {
-					t.Fatalf("encoding request JSON failed: %s", err)
-				}
-
-				return &reqBody
-			},
+			desc:               "successful login",
+			reqBody:            correctLogin,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "successful login with legacy tokens",
+			legacyTokens:       true,
+			reqBody:            correctLogin,
 			expectedStatusCode: http.StatusOK,
 		},
 		{
@@ -79,12 +100,16 @@ func TestLoginTokenHandler(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.desc, func(t *testing.T) {
-			h := routeLoginTokenHandler(webserver.NewLoginTokenHandler(cfg))
+			registry := &devicesfakes.FakeRegistry{}
+			testCfg := cfg
+			testCfg.LegacyTokens = test.legacyTokens
+			h := routeLoginTokenHandler(webserver.NewLoginTokenHandler(testCfg, registry, nil))
 			req := httptest.NewRequest(
 				http.MethodPost,
 				"/v1/login/token/",
 				test.reqBody(t),
 			)
+			req.Header.Set("User-Agent", userAgent)
 			resp := httptest.NewRecorder()
 
 			h.ServeHTTP(resp, req)
@@ -104,7 +129,10 @@ func TestLoginTokenHandler(t *testing.T) {
 			}
 
 			tokenResponse := struct {
-				Token string `json:"token"`
+				Token        string `json:"token"`
+				AccessToken  string `json:"access_token"`
+				RefreshToken string `json:"refresh_token"`
+				ExpiresIn    int64  `json:"expires_in"`
 			}{}
 
 			dec := json.NewDecoder(resp.Result().Body)
@@ -112,11 +140,95 @@ func TestLoginTokenHandler(t *testing.T) {
 				t.Fatalf("failed to JSON decode token response: %s", err)
 			}
 
-			assertToken(t, tokenResponse.Token, cfg.Secret)
+			assertToken(t, tokenResponse.AccessToken, cfg.Secret)
+			if test.legacyTokens {
+				assertToken(t, tokenResponse.Token, cfg.Secret)
+			} else if tokenResponse.Token != "" {
+				t.Errorf("expected no long lived token without legacy tokens")
+			}
+
+			if tokenResponse.ExpiresIn <= 0 || tokenResponse.ExpiresIn > 3600 {
+				t.Errorf("expected short lived access token but expires_in is %d",
+					tokenResponse.ExpiresIn)
+			}
+
+			keyring := tokens.NewKeyring(cfg.Secret)
+			refresh, err := keyring.Verify([]byte(tokenResponse.RefreshToken), time.Now())
+			if err != nil {
+				t.Fatalf("verifying refresh token: %s", err)
+			}
+			if refresh.Use != tokens.UseRefresh || refresh.Subject != cfg.User {
+				t.Errorf("wrong refresh token claims: %+v", refresh)
+			}
+
+			if registry.RegisterCallCount() != 1 {
+				t.Fatalf("expected the token to be registered as a device")
+			}
+			_, device := registry.RegisterArgsForCall(0)
+			if device.Name != deviceName || device.UserAgent != userAgent {
+				t.Errorf("device registered with wrong name or user agent: %+v", device)
+			}
+			if device.TokenID == "" {
+				t.Errorf("device registered without a token ID")
+			}
+			if refresh.Device != device.TokenID {
+				t.Errorf(
+					"refresh token for device `%s` instead of `%s`",
+					refresh.Device,
+					device.TokenID,
+				)
+			}
 		})
 	}
 }
 
+// TestLoginTokenHandlerLockout makes sure that repeated failed attempts lock out
+// the client, even when it later uses the correct credentials.
+func TestLoginTokenHandlerLockout(t *testing.T) {
+	cfg := config.Auth{
+		User:     "test-user",
+		Password: "test-pass",
+		Secret:   "test-secret",
+	}
+	limiter := loginlimit.New(config.LoginLimits{
+		FreeAttempts:    1,
+		LockoutAttempts: 2,
+		LockoutDuration: time.Hour,
+	}, nil)
+	h := routeLoginTokenHandler(webserver.NewLoginTokenHandler(
+		cfg,
+		&devicesfakes.FakeRegistry{},
+		limiter,
+	))
+
+	login := func(pass string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"username": %q, "password": %q}`, cfg.User, pass)
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/v1/login/token/",
+			bytes.NewBufferString(body),
+		)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp := login("wrong"); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected HTTP 401 but got %d", i+1, resp.Code)
+		}
+	}
+
+	resp := login(cfg.Password)
+	if resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected HTTP 429 after lockout but got %d", resp.Code)
+	}
+	assertContentTypeJSON(t, resp.Result().Header.Get("Content-Type"))
+	if resp.Header().Get("Retry-After") == "" {
+		t.Errorf("expected the Retry-After header to be set")
+	}
+}
+
 // routeLoginTokenHandler wraps a handler the same way the web server will do when
 // constructing the main application router. This is needed for tests so that the
 // Gorilla mux variables will be parsed.
@@ -1,19 +1,34 @@
 package webserver
 
-import "net/http"
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/devices"
+)
 
 // NewLogoutHandler returns a handler which will logout the user form his HTTP
-// session by unsetting his session cookie.
-func NewLogoutHandler() http.Handler {
+// session by unsetting his session cookies. The device of the session token is
+// revoked in `registry` so that the token could not be used any more.
+func NewLogoutHandler(registry devices.Registry) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie := &http.Cookie{
-			Name:     sessionCookieName,
-			Value:    "",
-			Path:     "/",
-			HttpOnly: true,
-			MaxAge:   -1,
+		if tokenID := tokenIDFromContext(r.Context()); tokenID != "" {
+			err := registry.RevokeToken(r.Context(), tokenID)
+			if err != nil && !errors.Is(err, devices.ErrNotFound) {
+				log.Printf("Error revoking device on logout: %s\n", err)
+			}
+		}
+
+		for _, name := range []string{sessionCookieName, refreshCookieName} {
+			http.SetCookie(w, &http.Cookie{
+				Name:     name,
+				Value:    "",
+				Path:     "/",
+				HttpOnly: true,
+				MaxAge:   -1,
+			})
 		}
-		http.SetCookie(w, cookie)
 
 		w.Header().Set("Location", "/login/")
 		w.WriteHeader(http.StatusFound)
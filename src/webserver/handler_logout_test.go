@@ -4,14 +4,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
 	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
 )
 
-// TestLogoutHandler make sure that the logout handler clears the session cookie
+// TestLogoutHandler make sure that the logout handler clears the session cookies
 // and redirects back to another rpage.
 func TestLogoutHandler(t *testing.T) {
-	h := webserver.NewLogoutHandler()
+	h := webserver.NewLogoutHandler(&devicesfakes.FakeRegistry{})
 
 	req := httptest.NewRequest(http.MethodGet, "/logout/", nil)
 	req.AddCookie(&http.Cookie{
@@ -34,37 +39,93 @@ func TestLogoutHandler(t *testing.T) {
 		t.Errorf("expected location header `/login/` but got `%s`", locationHeader)
 	}
 
-	var sessionCookie *http.Cookie
-	for _, cookie := range resp.Result().Cookies() {
-		if cookie.Name == "session" {
-			sessionCookie = cookie
-			break
+	for _, name := range []string{"session", "session_refresh"} {
+		var sessionCookie *http.Cookie
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Name == name {
+				sessionCookie = cookie
+				break
+			}
 		}
-	}
 
-	if sessionCookie == nil {
-		t.Fatal("no session cookie was unset by the logout handler")
-	}
+		if sessionCookie == nil {
+			t.Fatalf("no %s cookie was unset by the logout handler", name)
+		}
+
+		if sessionCookie.Value != "" {
+			t.Errorf("%s cookie value was not set to empty string", name)
+		}
 
-	if sessionCookie.Value != "" {
-		t.Error("session cookie value was not set to empty string")
+		if sessionCookie.MaxAge >= 0 {
+			t.Errorf(
+				"%s cookie is not expired, its max-mage is %d",
+				name,
+				sessionCookie.MaxAge,
+			)
+		}
+
+		if sessionCookie.Path != "/" {
+			t.Errorf(
+				"expected %s cookie path to be `/` but it was `%s`",
+				name,
+				sessionCookie.Path,
+			)
+		}
+
+		if !sessionCookie.HttpOnly {
+			t.Errorf("%s cookie was not http-only", name)
+		}
 	}
+}
+
+// TestLogoutHandlerRevokesDevice makes sure that the token of the session is
+// revoked on logout so that it could not be used even if it was copied.
+func TestLogoutHandlerRevokesDevice(t *testing.T) {
+	const secret = "logout-secret"
 
-	if sessionCookie.MaxAge >= 0 {
-		t.Errorf(
-			"session cookie is not expired, its max-mage is %d",
-			sessionCookie.MaxAge,
-		)
+	now := time.Now()
+	keyring := tokens.NewKeyring(secret)
+	token, err := keyring.Sign(tokens.Claims{
+		Payload: jwt.Payload{
+			JWTID:          "some-unique-id",
+			IssuedAt:       jwt.NumericDate(now),
+			ExpirationTime: jwt.NumericDate(now.Add(10 * time.Minute)),
+		},
+		Device: "browser-device",
+		Use:    tokens.UseAccess,
+	})
+	if err != nil {
+		t.Fatalf("signing token: %s", err)
 	}
 
-	if sessionCookie.Path != "/" {
-		t.Errorf(
-			"expected session cookie path to be `/` but it was `%s`",
-			sessionCookie.Path,
-		)
+	registry := &devicesfakes.FakeRegistry{}
+	h := webserver.NewAuthHandler(
+		webserver.NewLogoutHandler(registry),
+		"user",
+		"pass",
+		nil,
+		keyring,
+		registry,
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
+		nil,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/logout/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: string(token)})
+	resp := httptest.NewRecorder()
+
+	h.ServeHTTP(resp, req)
+
+	if respCode := resp.Result().StatusCode; respCode != http.StatusFound {
+		t.Fatalf("expected redirect but got HTTP status %d", respCode)
 	}
 
-	if !sessionCookie.HttpOnly {
-		t.Error("session cookie was not http-only")
+	if calls := registry.RevokeTokenCallCount(); calls != 1 {
+		t.Fatalf("expected the device to be revoked once but it was %d times", calls)
+	}
+	if _, tokenID := registry.RevokeTokenArgsForCall(0); tokenID != "browser-device" {
+		t.Errorf("expected device `browser-device` to be revoked but got `%s`", tokenID)
 	}
 }
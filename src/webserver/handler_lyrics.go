@@ -0,0 +1,57 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// lyricsHandler returns the lyrics of a single track as JSON.
+type lyricsHandler struct {
+	library library.Library
+}
+
+// NewLyricsHandler returns an HTTP handler which serves the plain and synchronised
+// lyrics of a track identified by its ID.
+func NewLyricsHandler(lib library.Library) http.Handler {
+	return &lyricsHandler{
+		library: lib,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *lyricsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	vars := mux.Vars(req)
+	trackID, err := strconv.ParseInt(vars["fileID"], 10, 64)
+	if err != nil {
+		webutils.JSONError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	lyrics, err := h.library.GetTrackLyrics(req.Context(), trackID)
+	if errors.Is(err, library.ErrNotFound) {
+		webutils.JSONError(w, "lyrics not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("getting lyrics failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(lyrics); err != nil {
+		log.Printf("error writing lyrics response: %s", err)
+	}
+}
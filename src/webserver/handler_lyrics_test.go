@@ -0,0 +1,79 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestLyricsHandler checks that lyrics are returned for tracks which have them
+// and that "not found" is returned for tracks without lyrics.
+func TestLyricsHandler(t *testing.T) {
+	const trackWithLyrics = 42
+
+	lib := &libraryfakes.FakeLibrary{
+		GetTrackLyricsStub: func(
+			_ context.Context,
+			trackID int64,
+		) (library.Lyrics, error) {
+			if trackID != trackWithLyrics {
+				return library.Lyrics{}, library.ErrLyricsNotFound
+			}
+
+			return library.Lyrics{
+				TrackID:  trackID,
+				Language: "eng",
+				Plain:    "first line\nsecond line",
+				Synced: []library.LyricsLine{
+					{Start: 1200, Text: "first line"},
+					{Start: 3400, Text: "second line"},
+				},
+			}, nil
+		},
+	}
+
+	router := mux.NewRouter()
+	router.Handle(
+		webserver.APIv1EndpointFileLyrics,
+		webserver.NewLyricsHandler(lib),
+	).Methods(webserver.APIv1Methods[webserver.APIv1EndpointFileLyrics]...)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/42/lyrics", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var lyrics library.Lyrics
+	if err := json.NewDecoder(resp.Body).Decode(&lyrics); err != nil {
+		t.Fatalf("decoding response JSON: %s", err)
+	}
+
+	if lyrics.TrackID != trackWithLyrics {
+		t.Errorf("expected track ID %d but got %d", trackWithLyrics, lyrics.TrackID)
+	}
+	if lyrics.Language != "eng" {
+		t.Errorf(`expected language "eng" but got "%s"`, lyrics.Language)
+	}
+	if len(lyrics.Synced) != 2 || lyrics.Synced[1].Start != 3400 {
+		t.Errorf("unexpected synced lyrics: %+v", lyrics.Synced)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/file/13/lyrics", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if status := rec.Result().StatusCode; status != http.StatusNotFound {
+		t.Errorf("expected status %d but got %d", http.StatusNotFound, status)
+	}
+}
@@ -0,0 +1,259 @@
+package webserver
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/oidc"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+const (
+	// oidcFlowCookieName is the name of the cookie which keeps the state of a
+	// log in with the OIDC provider while the browser is away.
+	oidcFlowCookieName = "oidc_flow"
+	oidcFlowCookiePath = "/login/oidc/"
+
+	// oidcFlowAudience is used for the tokens in the flow cookie so that they
+	// could never be mistaken for anything else signed with the same secret.
+	oidcFlowAudience = "euterpe-oidc-flow"
+	oidcFlowDuration = 10 * time.Minute
+)
+
+// oidcFlowClaims is what is stored in the flow cookie.
+type oidcFlowClaims struct {
+	jwt.Payload
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+}
+
+type oidcLoginHandler struct {
+	provider *oidc.Provider
+	secret   string
+}
+
+// NewOIDCLoginHandler returns a handler which starts logging in with the OIDC
+// `provider` by redirecting the browser to it. The state of the log in is kept in
+// a cookie signed with `secret`. Responds with 404 when `provider` is nil.
+func NewOIDCLoginHandler(provider *oidc.Provider, secret string) http.Handler {
+	return &oidcLoginHandler{
+		provider: provider,
+		secret:   secret,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *oidcLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.provider == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	returnTo := r.URL.Query().Get(returnToQueryParam)
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") {
+		returnTo = "/"
+	}
+
+	now := time.Now()
+	flow := oidcFlowClaims{
+		Payload: jwt.Payload{
+			Audience:       jwt.Audience{oidcFlowAudience},
+			IssuedAt:       jwt.NumericDate(now),
+			ExpirationTime: jwt.NumericDate(now.Add(oidcFlowDuration)),
+		},
+		State:    rand.Text(),
+		Nonce:    rand.Text(),
+		Verifier: oidc.NewVerifier(),
+		ReturnTo: returnTo,
+	}
+
+	authURL, err := h.provider.AuthCodeURL(
+		r.Context(),
+		flow.State,
+		flow.Nonce,
+		flow.Verifier,
+	)
+	if err != nil {
+		log.Printf("Error starting OIDC log in: %s\n", err)
+		http.Error(w, "cannot reach the identity provider", http.StatusBadGateway)
+		return
+	}
+
+	token, err := jwt.Sign(flow, jwt.NewHS256([]byte(h.secret)))
+	if err != nil {
+		errMessage := fmt.Sprintf("Error generating JWT: %s.", err)
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookieName,
+		Value:    string(token),
+		Path:     oidcFlowCookiePath,
+		MaxAge:   int(oidcFlowDuration.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.Header().Set("Location", authURL)
+	w.WriteHeader(http.StatusFound)
+}
+
+type oidcCallbackHandler struct {
+	provider *oidc.Provider
+	auth     config.Auth
+	keyring  *tokens.Keyring
+	devices  devices.Registry
+}
+
+// NewOIDCCallbackHandler returns the handler to which the OIDC `provider` sends
+// the browser back after logging in. On success it sets the same session cookie
+// as the login handler. Every such session is registered as a device in
+// `registry`. Responds with 404 when `provider` is nil.
+func NewOIDCCallbackHandler(
+	provider *oidc.Provider,
+	auth config.Auth,
+	registry devices.Registry,
+) http.Handler {
+	return &oidcCallbackHandler{
+		provider: provider,
+		auth:     auth,
+		keyring:  keyringFromAuth(auth),
+		devices:  registry,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *oidcCallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.provider == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookieName,
+		Value:    "",
+		Path:     oidcFlowCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	flow, err := h.flowFromCookie(r)
+	if err != nil {
+		log.Printf("OIDC log in: %s\n", err)
+		respondOIDCFailed(w, "/")
+		return
+	}
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		log.Printf("OIDC log in: provider returned error %q\n", errCode)
+		respondOIDCFailed(w, flow.ReturnTo)
+		return
+	}
+
+	state := query.Get("state")
+	if subtle.ConstantTimeCompare([]byte(state), []byte(flow.State)) != 1 {
+		log.Printf("OIDC log in: state does not match\n")
+		respondOIDCFailed(w, flow.ReturnTo)
+		return
+	}
+
+	identity, err := h.provider.Exchange(
+		r.Context(),
+		query.Get("code"),
+		flow.Verifier,
+		flow.Nonce,
+	)
+	if errors.Is(err, oidc.ErrNotAllowed) {
+		webutils.MarkAuthFailure(r.Context(), identity.Subject)
+		log.Printf("OIDC log in: subject %q is not allowed\n", identity.Subject)
+		respondOIDCFailed(w, flow.ReturnTo)
+		return
+	} else if err != nil {
+		log.Printf("OIDC log in: %s\n", err)
+		respondOIDCFailed(w, flow.ReturnTo)
+		return
+	}
+
+	err = setSessionCookies(
+		w,
+		r,
+		h.devices,
+		h.keyring,
+		identity.Subject,
+		false,
+	)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error generating JWT: %s.", err)
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", flow.ReturnTo)
+	w.WriteHeader(http.StatusFound)
+}
+
+func (h *oidcCallbackHandler) flowFromCookie(r *http.Request) (oidcFlowClaims, error) {
+	var flow oidcFlowClaims
+
+	cookie, err := r.Cookie(oidcFlowCookieName)
+	if err != nil {
+		return flow, errors.New("flow cookie is missing")
+	}
+
+	_, err = jwt.Verify(
+		[]byte(cookie.Value),
+		jwt.NewHS256([]byte(h.auth.Secret)),
+		&flow,
+		jwt.ValidateHeader,
+		jwt.ValidatePayload(
+			&flow.Payload,
+			jwt.AudienceValidator(jwt.Audience{oidcFlowAudience}),
+			jwt.ExpirationTimeValidator(time.Now()),
+		),
+	)
+	if err != nil {
+		return flow, fmt.Errorf("wrong flow cookie: %w", err)
+	}
+
+	return flow, nil
+}
+
+func respondOIDCFailed(w http.ResponseWriter, returnTo string) {
+	query := url.Values{}
+	query.Set(returnToQueryParam, returnTo)
+	query.Set("ssoFailed", "1")
+
+	w.Header().Set("Location", fmt.Sprintf("/login/?%s", query.Encode()))
+	w.WriteHeader(http.StatusFound)
+}
+
+// NewLoginOptionsHandler returns a handler which tells the log in page which of
+// the optional log in methods are available.
+func NewLoginOptionsHandler(provider *oidc.Provider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(struct {
+			OIDC bool `json:"oidc"`
+		}{
+			OIDC: provider != nil,
+		})
+	})
+}
@@ -0,0 +1,210 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/oidc"
+	"github.com/ironsmile/euterpe/src/webserver/oidc/oidctest"
+)
+
+// TestOIDCLogin goes through logging in with a stub OIDC provider and makes sure
+// that a session cookie is set only for allowed users.
+func TestOIDCLogin(t *testing.T) {
+	const (
+		clientID    = "euterpe"
+		redirectURL = "https://music.example.com/login/oidc/callback"
+		returnTo    = "/some/page"
+	)
+
+	auth := config.Auth{
+		User:     "user",
+		Password: "pass",
+		Secret:   "oidc-test-secret",
+	}
+
+	tests := []struct {
+		desc       string
+		subject    string
+		noCookie   bool
+		wrongState bool
+		success    bool
+	}{
+		{
+			desc:    "allowed user",
+			subject: "allowed-user",
+			success: true,
+		},
+		{
+			desc:    "not allowed user",
+			subject: "stranger",
+		},
+		{
+			desc:     "missing flow cookie",
+			subject:  "allowed-user",
+			noCookie: true,
+		},
+		{
+			desc:       "wrong state",
+			subject:    "allowed-user",
+			wrongState: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			issuer := oidctest.NewIssuer(clientID, "")
+			defer issuer.Close()
+			issuer.SetUser(test.subject)
+
+			provider, err := oidc.New(config.OIDC{
+				Issuer:          issuer.URL,
+				ClientID:        clientID,
+				RedirectURL:     redirectURL,
+				AllowedSubjects: []string{"allowed-user"},
+			}, issuer.Client())
+			if err != nil {
+				t.Fatalf("creating provider: %s", err)
+			}
+
+			registry := &devicesfakes.FakeRegistry{}
+			loginHandler := webserver.NewOIDCLoginHandler(provider, auth.Secret)
+			callbackHandler := webserver.NewOIDCCallbackHandler(provider, auth, registry)
+
+			// Start logging in.
+			req := httptest.NewRequest(
+				http.MethodGet,
+				"/login/oidc/?return_to="+url.QueryEscape(returnTo),
+				nil,
+			)
+			resp := httptest.NewRecorder()
+			loginHandler.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusFound {
+				t.Fatalf("expected redirect to the provider but got HTTP %d", resp.Code)
+			}
+			authURL := resp.Header().Get("Location")
+			if !strings.HasPrefix(authURL, issuer.URL) {
+				t.Fatalf("expected redirect to the provider but got `%s`", authURL)
+			}
+			flowCookies := resp.Result().Cookies()
+
+			// Visit the provider which redirects back immediately.
+			client := &http.Client{
+				CheckRedirect: func(*http.Request, []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}
+			issuerResp, err := client.Get(authURL)
+			if err != nil {
+				t.Fatalf("visiting the provider: %s", err)
+			}
+			issuerResp.Body.Close()
+
+			callbackURL, err := url.Parse(issuerResp.Header.Get("Location"))
+			if err != nil || callbackURL.Query().Get("code") == "" {
+				t.Fatalf("provider did not redirect back with a code")
+			}
+			if test.wrongState {
+				query := callbackURL.Query()
+				query.Set("state", "some-other-state")
+				callbackURL.RawQuery = query.Encode()
+			}
+
+			// Come back to Euterpe.
+			req = httptest.NewRequest(
+				http.MethodGet,
+				"/login/oidc/callback?"+callbackURL.RawQuery,
+				nil,
+			)
+			if !test.noCookie {
+				for _, cookie := range flowCookies {
+					req.AddCookie(cookie)
+				}
+			}
+			resp = httptest.NewRecorder()
+			callbackHandler.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusFound {
+				t.Fatalf("expected redirect after log in but got HTTP %d", resp.Code)
+			}
+
+			var session *http.Cookie
+			for _, cookie := range resp.Result().Cookies() {
+				if cookie.Name == "session" && cookie.Value != "" {
+					session = cookie
+				}
+			}
+
+			location := resp.Header().Get("Location")
+			if !test.success {
+				if session != nil {
+					t.Errorf("session cookie was set for failed log in")
+				}
+				if !strings.Contains(location, "ssoFailed=1") {
+					t.Errorf("expected redirect to the failure page but got `%s`", location)
+				}
+				if registry.RegisterCallCount() != 0 {
+					t.Errorf("device was registered for failed log in")
+				}
+				return
+			}
+
+			if location != returnTo {
+				t.Errorf("expected redirect to `%s` but got `%s`", returnTo, location)
+			}
+			if session == nil {
+				t.Fatalf("session cookie was not set")
+			}
+			assertToken(t, session.Value, auth.Secret)
+			if registry.RegisterCallCount() != 1 {
+				t.Errorf("expected the session to be registered as a device")
+			}
+		})
+	}
+}
+
+// TestOIDCDisabled checks that the OIDC handlers are not found when there is no
+// provider configured.
+func TestOIDCDisabled(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"/login/oidc/": webserver.NewOIDCLoginHandler(nil, "secret"),
+		"/login/oidc/callback": webserver.NewOIDCCallbackHandler(
+			nil,
+			config.Auth{Secret: "secret"},
+			&devicesfakes.FakeRegistry{},
+		),
+	}
+
+	for path, handler := range handlers {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("%s: expected HTTP 404 but got %d", path, resp.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/login/options/", nil)
+	resp := httptest.NewRecorder()
+	webserver.NewLoginOptionsHandler(nil).ServeHTTP(resp, req)
+
+	assertContentTypeJSON(t, resp.Header().Get("Content-Type"))
+	var options struct {
+		OIDC bool `json:"oidc"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&options); err != nil {
+		t.Fatalf("decoding options: %s", err)
+	}
+	if options.OIDC {
+		t.Errorf("OIDC was reported as enabled")
+	}
+}
@@ -1,13 +1,59 @@
 package webserver
 
-import "net/http"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 
-// NewRigisterTokenHandler returns a handler resposible for checking and eventually
-// registering registering in the database token generated to a device.
-// !TODO: actually store the device token in the database once it has a unique ID
-func NewRigisterTokenHandler() http.Handler {
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// NewRigisterTokenHandler returns a handler resposible for registering the device
+// which uses the token the request was authenticated with. The request body may
+// be a JSON object with the device name. The user agent of the request is stored
+// alongside it.
+func NewRigisterTokenHandler(registry devices.Registry) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
+
+		reqBody := struct {
+			DeviceName string `json:"device_name"`
+		}{}
+
+		dec := json.NewDecoder(r.Body)
+		if err := dec.Decode(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+			webutils.JSONError(
+				w,
+				fmt.Sprintf("Error parsing JSON request: %s.", err),
+				http.StatusBadRequest,
+			)
+			return
+		}
+
+		tokenID := tokenIDFromContext(r.Context())
+		if tokenID == "" {
+			// The server does not require authentication or the request was
+			// authenticated without a token. There is nothing to register.
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		err := registry.Update(r.Context(), tokenID, reqBody.DeviceName, r.UserAgent())
+		if errors.Is(err, devices.ErrNotFound) {
+			webutils.JSONError(w, "device not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			webutils.JSONError(
+				w,
+				fmt.Sprintf("Error registering device: %s.", err),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
 		w.WriteHeader(http.StatusNoContent)
 	})
 }
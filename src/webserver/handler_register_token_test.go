@@ -1,18 +1,26 @@
 package webserver_test
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
+	"github.com/ironsmile/euterpe/src/devices/devicesfakes"
 	"github.com/ironsmile/euterpe/src/webserver"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
 )
 
 // TestRegisterTokenHandler makes sure that the handler returns well formatted JSON
 // and responds with HTTP 204.
 func TestRegisterTokenHandler(t *testing.T) {
-	h := routeRegisterTokenHandler(webserver.NewRigisterTokenHandler())
+	registry := &devicesfakes.FakeRegistry{}
+	h := routeRegisterTokenHandler(webserver.NewRigisterTokenHandler(registry))
 
 	req := httptest.NewRequest(http.MethodPost, "/v1/register/token/", nil)
 	resp := httptest.NewRecorder()
@@ -27,6 +35,75 @@ func TestRegisterTokenHandler(t *testing.T) {
 			responseCode,
 		)
 	}
+
+	if registry.UpdateCallCount() != 0 {
+		t.Errorf("no device was expected to be updated for request without a token")
+	}
+}
+
+// TestRegisterTokenHandlerWithToken checks that the device of the token used for
+// authenticating the request is updated with the name from the request body and
+// its user agent.
+func TestRegisterTokenHandlerWithToken(t *testing.T) {
+	const (
+		secret     = "register-token-test-secret"
+		tokenID    = "register-token-id"
+		deviceName = "Living Room Speaker"
+		userAgent  = "Test Agent/2.0"
+	)
+
+	registry := &devicesfakes.FakeRegistry{}
+	h := webserver.NewAuthHandler(
+		routeRegisterTokenHandler(webserver.NewRigisterTokenHandler(registry)),
+		"user",
+		"pass",
+		nil,
+		tokens.NewKeyring(secret),
+		registry,
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
+		nil,
+	)
+
+	now := time.Now()
+	token, err := jwt.Sign(jwt.Payload{
+		JWTID:          tokenID,
+		IssuedAt:       jwt.NumericDate(now),
+		ExpirationTime: jwt.NumericDate(now.Add(10 * time.Minute)),
+	}, jwt.NewHS256([]byte(secret)))
+	if err != nil {
+		t.Fatalf("signing token: %s", err)
+	}
+
+	body := fmt.Sprintf(`{"device_name": "%s"}`, deviceName)
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/v1/register/token/",
+		strings.NewReader(body),
+	)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("User-Agent", userAgent)
+	resp := httptest.NewRecorder()
+
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNoContent {
+		t.Fatalf("expected HTTP %d but got %d", http.StatusNoContent, resp.Code)
+	}
+
+	if registry.UpdateCallCount() != 1 {
+		t.Fatalf("expected device to be updated once but it was %d times",
+			registry.UpdateCallCount())
+	}
+
+	_, actualTokenID, actualName, actualUA := registry.UpdateArgsForCall(0)
+	if actualTokenID != tokenID || actualName != deviceName || actualUA != userAgent {
+		t.Errorf(
+			"device updated with wrong arguments: token `%s`, name `%s`, UA `%s`",
+			actualTokenID, actualName, actualUA,
+		)
+	}
 }
 
 // routeRegisterTokenHandler wraps a handler the same way the web server will do when
@@ -0,0 +1,110 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// maxStatsCount is the maximum number of items in the top lists of the
+// listening statistics.
+const maxStatsCount = 100
+
+// statsHandler returns listening statistics computed from the listening history.
+type statsHandler struct {
+	library library.Library
+}
+
+// NewStatsHandler returns an HTTP handler which serves listening statistics such
+// as top artists, albums, tracks and genres. They could be computed for a user
+// and for a time range.
+func NewStatsHandler(lib library.Library) http.Handler {
+	return &statsHandler{
+		library: lib,
+	}
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (h *statsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	args, err := parseStatsArgs(req.URL.Query())
+	if err != nil {
+		webutils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.library.GetStats(req.Context(), args)
+	if err != nil {
+		webutils.JSONError(
+			w,
+			fmt.Sprintf("getting stats failed: %s", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(stats); err != nil {
+		log.Printf("error writing stats response: %s", err)
+	}
+}
+
+// parseStatsArgs returns the arguments for computing listening statistics from
+// the query of a request. The time range is set either with `from` and `to` as
+// Unix timestamps in seconds or with `year` for a whole year in the local time
+// zone.
+func parseStatsArgs(query url.Values) (library.StatsArgs, error) {
+	args := library.StatsArgs{
+		User: query.Get("user"),
+	}
+
+	if yearStr := query.Get("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year < 1 || year > 9999 {
+			return args, errors.New(`"year" must be a four digit year`)
+		}
+
+		args.From = time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+		args.To = args.From.AddDate(1, 0, 0)
+	}
+
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{
+		{"from", &args.From},
+		{"to", &args.To},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || unix < 0 {
+			return args, fmt.Errorf(
+				`"%s" must be a Unix timestamp in seconds`, param.name,
+			)
+		}
+		*param.dest = time.Unix(unix, 0)
+	}
+
+	if countStr := query.Get("count"); countStr != "" {
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 1 {
+			return args, errors.New(`"count" must be an integer greater than zero`)
+		}
+		args.Count = min(count, maxStatsCount)
+	}
+
+	return args, nil
+}
@@ -0,0 +1,104 @@
+package webserver
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/version"
+)
+
+// statsTemplateFuncs are the functions available in the listening statistics
+// report template.
+var statsTemplateFuncs = template.FuncMap{
+	"duration": formatListeningTime,
+	"percent": func(value, maxValue int64) int64 {
+		if maxValue <= 0 {
+			return 0
+		}
+		return value * 100 / maxValue
+	},
+	"unixDate": func(unix int64) string {
+		return time.Unix(unix, 0).Format("2 January 2006")
+	},
+}
+
+// NewStatsPageHandler returns a handler which renders the listening statistics
+// into an HTML report using the `tpl` template. The statistics are selected
+// with the same query parameters as for the stats API. With `year` the report
+// is a "year in review".
+func NewStatsPageHandler(tpl *template.Template, lib library.Library) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		args, err := parseStatsArgs(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		stats, err := lib.GetStats(r.Context(), args)
+		if err != nil {
+			errorMessage := fmt.Sprintf("Error getting stats: %s.\n", err)
+			log.Print(errorMessage)
+			http.Error(w, errorMessage, http.StatusInternalServerError)
+			return
+		}
+
+		title := "Statistics"
+		heading := "Listening Statistics"
+		if year := r.URL.Query().Get("year"); year != "" {
+			title = year + " in Review"
+			heading = "Your " + year + " in Review"
+		}
+
+		var maxHourPlays, maxDayTime int64
+		for _, hour := range stats.Hours {
+			maxHourPlays = max(maxHourPlays, hour.Plays)
+		}
+		for _, day := range stats.Days {
+			maxDayTime = max(maxDayTime, day.TimePlayed)
+		}
+
+		data := struct {
+			Title        string
+			Version      string
+			Req          *http.Request
+			Menu         []menu
+			Heading      string
+			Args         library.StatsArgs
+			Stats        library.Stats
+			MaxHourPlays int64
+			MaxDayTime   int64
+		}{
+			Title:        title,
+			Version:      version.Version,
+			Req:          r,
+			Menu:         pageMenu(r),
+			Heading:      heading,
+			Args:         args,
+			Stats:        stats,
+			MaxHourPlays: maxHourPlays,
+			MaxDayTime:   maxDayTime,
+		}
+		if err := tpl.Execute(w, data); err != nil {
+			errorMessage := fmt.Sprintf("Error executing template: %s.\n", err)
+			log.Print(errorMessage)
+			http.Error(w, errorMessage, http.StatusInternalServerError)
+		}
+	})
+}
+
+// formatListeningTime returns a human readable form of `ms` milliseconds of
+// listening time such as "3h 25m".
+func formatListeningTime(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	hours := int64(d.Hours())
+	minutes := int64(d.Minutes()) % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
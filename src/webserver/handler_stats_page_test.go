@@ -0,0 +1,69 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+)
+
+// TestStatsPageHandler checks that the listening statistics report is rendered
+// with the HTML templates.
+func TestStatsPageHandler(t *testing.T) {
+	tpls, err := NewFSTemplates(os.DirFS("../../templates")).All()
+	if err != nil {
+		t.Fatalf("parsing templates: %s", err)
+	}
+
+	lib := &libraryfakes.FakeLibrary{
+		GetStatsStub: func(
+			_ context.Context,
+			args library.StatsArgs,
+		) (library.Stats, error) {
+			return library.Stats{
+				Total: library.PlayStats{Plays: 3, TimePlayed: 3900000},
+				TopArtists: []library.ArtistStats{
+					{ID: 4, Name: "Iron Maiden", PlayStats: library.PlayStats{Plays: 3}},
+				},
+				Hours: []library.HourStats{
+					{Hour: 21, PlayStats: library.PlayStats{Plays: 3}},
+				},
+			}, nil
+		},
+	}
+	h := NewStatsPageHandler(tpls.stats, lib)
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/?year=2024", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if status := rec.Result().StatusCode; status != http.StatusOK {
+		t.Fatalf("expected status %d but got %d: %s", http.StatusOK, status, rec.Body)
+	}
+
+	body := rec.Body.String()
+	for _, expected := range []string{
+		"Your 2024 in Review",
+		"Iron Maiden",
+		"1h 5m",
+		"21:00",
+		"width: 100%",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected `%s` in the report", expected)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/stats/?year=last", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if status := rec.Result().StatusCode; status != http.StatusBadRequest {
+		t.Errorf("expected status %d but got %d", http.StatusBadRequest, status)
+	}
+}
@@ -0,0 +1,74 @@
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestStatsHandler checks that the listening statistics are returned for the
+// selected user and time range and that wrong arguments are rejected.
+func TestStatsHandler(t *testing.T) {
+	lib := &libraryfakes.FakeLibrary{
+		GetStatsStub: func(
+			_ context.Context,
+			args library.StatsArgs,
+		) (library.Stats, error) {
+			if args.User == "broken" {
+				return library.Stats{}, errors.New("database is gone")
+			}
+
+			return library.Stats{
+				Total: library.PlayStats{Plays: 3, TimePlayed: 360000},
+				TopArtists: []library.ArtistStats{
+					{ID: 4, Name: "Iron Maiden", PlayStats: library.PlayStats{Plays: 3}},
+				},
+			}, nil
+		},
+	}
+
+	router := routeAPIv1Handler(
+		webserver.APIv1EndpointStats,
+		webserver.NewStatsHandler(lib),
+	)
+
+	var stats library.Stats
+	getJSON(t, router, "/v1/stats?user=alice&year=2024&count=500", &stats)
+	if stats.Total.Plays != 3 || len(stats.TopArtists) != 1 ||
+		stats.TopArtists[0].Name != "Iron Maiden" || stats.TopArtists[0].Plays != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	_, args := lib.GetStatsArgsForCall(0)
+	expectedArgs := library.StatsArgs{
+		User:  "alice",
+		From:  time.Date(2024, time.January, 1, 0, 0, 0, 0, time.Local),
+		To:    time.Date(2025, time.January, 1, 0, 0, 0, 0, time.Local),
+		Count: 100,
+	}
+	if !args.From.Equal(expectedArgs.From) || !args.To.Equal(expectedArgs.To) ||
+		args.User != expectedArgs.User || args.Count != expectedArgs.Count {
+		t.Errorf("expected stats for %+v but got %+v", expectedArgs, args)
+	}
+
+	getJSON(t, router, "/v1/stats?from=1700000000&to=1800000000", &stats)
+
+	_, args = lib.GetStatsArgsForCall(1)
+	if args.From.Unix() != 1700000000 || args.To.Unix() != 1800000000 {
+		t.Errorf("unexpected time range: %s - %s", args.From, args.To)
+	}
+
+	assertStatuses(t, router, []statusTest{
+		{url: "/v1/stats?user=broken", status: http.StatusInternalServerError},
+		{url: "/v1/stats?year=last", status: http.StatusBadRequest},
+		{url: "/v1/stats?from=yesterday", status: http.StatusBadRequest},
+		{url: "/v1/stats?to=-5", status: http.StatusBadRequest},
+		{url: "/v1/stats?count=0", status: http.StatusBadRequest},
+	})
+}
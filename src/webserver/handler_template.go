@@ -22,18 +22,7 @@ func NewTemplateHandler(tpl *template.Template, title string) http.Handler {
 			Title:   title,
 			Version: version.Version,
 			Req:     r,
-			Menu: []menu{
-				{
-					Name:   "Player",
-					URI:    "/",
-					Active: r.URL.Path == "/",
-				},
-				{
-					Name:   "Add Device",
-					URI:    "/add_device/",
-					Active: r.URL.Path == "/add_device/",
-				},
-			},
+			Menu:    pageMenu(r),
 		}
 		if err := tpl.Execute(w, data); err != nil {
 			errorMessage := fmt.Sprintf("Error executing template: %s.\n", err)
@@ -43,6 +32,28 @@ func NewTemplateHandler(tpl *template.Template, title string) http.Handler {
 	})
 }
 
+// pageMenu returns the items of the navigation menu for the page requested
+// with `r`.
+func pageMenu(r *http.Request) []menu {
+	return []menu{
+		{
+			Name:   "Player",
+			URI:    "/",
+			Active: r.URL.Path == "/",
+		},
+		{
+			Name:   "Statistics",
+			URI:    "/stats/",
+			Active: r.URL.Path == "/stats/",
+		},
+		{
+			Name:   "Add Device",
+			URI:    "/add_device/",
+			Active: r.URL.Path == "/add_device/",
+		},
+	}
+}
+
 type menu struct {
 	URI    string
 	Name   string
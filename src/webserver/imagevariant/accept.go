@@ -0,0 +1,103 @@
+package imagevariant
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/scaler"
+)
+
+// fallbackFormats are used when the client does not accept the original format
+// of an image.
+var fallbackFormats = []scaler.Format{
+	scaler.FormatJPEG,
+	scaler.FormatPNG,
+}
+
+// negotiate returns the format in which an image should be served to a client
+// which sent the `accept` header. The `original` format of the image is one of
+// the candidates only when `keepOriginal` is true. The original format is
+// returned when the client accepts none of the candidates.
+func negotiate(accept string, original scaler.Format, keepOriginal bool) scaler.Format {
+	if strings.TrimSpace(accept) == "" {
+		if keepOriginal {
+			return original
+		}
+		return scaler.FormatJPEG
+	}
+
+	var candidates []scaler.Format
+	if keepOriginal {
+		candidates = append(candidates, original)
+	}
+	candidates = append(candidates, fallbackFormats...)
+
+	ranges := parseAccept(accept)
+	best, bestQuality := original, 0.0
+	for _, format := range candidates {
+		if q := quality(ranges, format); q > bestQuality {
+			best, bestQuality = format, q
+		}
+	}
+
+	if bestQuality == 0 && !keepOriginal {
+		return scaler.FormatJPEG
+	}
+	return best
+}
+
+// mediaRange is a single media range from an Accept header with its quality.
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if qValue, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(qValue, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: q})
+	}
+
+	return ranges
+}
+
+// quality returns the quality of `format` according to the most specific of the
+// media ranges which match it.
+func quality(ranges []mediaRange, format scaler.Format) float64 {
+	mediaType := string(format)
+	major, _, _ := strings.Cut(mediaType, "/")
+
+	var q float64
+	specificity := -1
+	for _, r := range ranges {
+		var s int
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case major + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			q, specificity = r.quality, s
+		}
+	}
+
+	return q
+}
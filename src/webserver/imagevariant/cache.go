@@ -0,0 +1,214 @@
+// Package imagevariant serves album artwork and artist images in the sizes and
+// formats requested by the HTTP clients. Images which were scaled or converted
+// are kept in a bounded in-memory cache.
+package imagevariant
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/scaler"
+)
+
+// Cache scales and converts images according to the requests for them and keeps
+// the results so that they are not generated again for every request. It holds at
+// most the configured number of bytes and removes the least recently used images
+// when it is full.
+//
+// A nil *Cache serves all images as they are.
+type Cache struct {
+	scaler   scaler.Scaler
+	sizes    []int
+	maxBytes int64
+
+	mu       sync.Mutex
+	variants map[string]*list.Element
+	lru      *list.List
+	bytes    int64
+
+	// now is used instead of time.Now so that tests could control the time.
+	now func() time.Time
+}
+
+// variant is an image generated from an original one.
+type variant struct {
+	key      string
+	data     []byte
+	format   scaler.Format
+	etag     string
+	modified time.Time
+}
+
+// New returns a Cache which uses `scl` for generating images in the sizes from
+// `cfg`. Returns nil when `scl` is nil.
+func New(cfg config.Artwork, scl scaler.Scaler) *Cache {
+	if scl == nil {
+		return nil
+	}
+
+	sizes := slices.Clone(cfg.Sizes)
+	slices.Sort(sizes)
+
+	return &Cache{
+		scaler:   scl,
+		sizes:    sizes,
+		maxBytes: cfg.CacheBytes,
+		variants: make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+// Serve writes the image read from `img` as a response to `req`. The image is
+// scaled to the width from the "size" query parameter rounded up to one of the
+// configured sizes. It is never made bigger than it is. Its format is the one
+// most preferred by the Accept header of the request out of the formats which
+// the scaler could encode. Images are served as they are when neither is needed.
+//
+// Conditional requests are answered using the ETag and Last-Modified headers.
+func (c *Cache) Serve(w http.ResponseWriter, req *http.Request, img io.Reader) error {
+	data, err := io.ReadAll(img)
+	if err != nil {
+		return fmt.Errorf("reading image: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	original := scaler.Format(http.DetectContentType(data))
+
+	if c == nil {
+		serveOriginal(w, req, data, original, hash)
+		return nil
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	imgCfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		serveOriginal(w, req, data, original, hash)
+		return nil
+	}
+
+	width := c.width(req.URL.Query().Get("size"), imgCfg.Width)
+	format := negotiate(
+		req.Header.Get("Accept"),
+		original,
+		width == 0 || scaler.CanEncode(original),
+	)
+	if width == 0 && format == original {
+		serveOriginal(w, req, data, original, hash)
+		return nil
+	}
+
+	key := fmt.Sprintf("%s-%d-%s", hash, width, strings.TrimPrefix(string(format), "image/"))
+	v, ok := c.get(key)
+	if !ok {
+		scaled, err := c.scaler.ScaleTo(req.Context(), bytes.NewReader(data), width, format)
+		if err != nil {
+			log.Printf("Serving the original image because scaling failed: %s\n", err)
+			serveOriginal(w, req, data, original, hash)
+			return nil
+		}
+
+		v = c.add(key, scaled, format)
+	}
+
+	w.Header().Set("Content-Type", string(v.format))
+	w.Header().Set("ETag", v.etag)
+	http.ServeContent(w, req, "", v.modified, bytes.NewReader(v.data))
+	return nil
+}
+
+// width returns the width to which an image `imgWidth` pixels wide should be
+// scaled when `size` is requested. Zero means that it should keep its size.
+func (c *Cache) width(size string, imgWidth int) int {
+	requested, err := strconv.Atoi(size)
+	if err != nil || requested <= 0 || len(c.sizes) == 0 {
+		return 0
+	}
+
+	width := c.sizes[len(c.sizes)-1]
+	if i, _ := slices.BinarySearch(c.sizes, requested); i < len(c.sizes) {
+		width = c.sizes[i]
+	}
+
+	if width >= imgWidth {
+		return 0
+	}
+	return width
+}
+
+func (c *Cache) get(key string) (*variant, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.variants[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	return el.Value.(*variant), true
+}
+
+// add stores a new variant and removes the least recently used ones until the
+// cache fits in its size. Variants bigger than the whole cache are not stored.
+func (c *Cache) add(key string, data []byte, format scaler.Format) *variant {
+	v := &variant{
+		key:      key,
+		data:     data,
+		format:   format,
+		etag:     strconv.Quote(key),
+		modified: c.now(),
+	}
+
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return v
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.variants[key]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*variant)
+	}
+
+	c.variants[key] = c.lru.PushFront(v)
+	c.bytes += size
+
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Remove(c.lru.Back()).(*variant)
+		delete(c.variants, oldest.key)
+		c.bytes -= int64(len(oldest.data))
+	}
+
+	return v
+}
+
+// serveOriginal writes an image which was not scaled or converted.
+func serveOriginal(
+	w http.ResponseWriter,
+	req *http.Request,
+	data []byte,
+	format scaler.Format,
+	hash string,
+) {
+	w.Header().Set("Content-Type", string(format))
+	w.Header().Set("ETag", strconv.Quote(hash))
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(data))
+}
@@ -0,0 +1,187 @@
+package imagevariant
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/scaler"
+	"github.com/ironsmile/euterpe/src/scaler/scalerfakes"
+)
+
+// TestCacheServe checks that images are scaled to the configured sizes, converted
+// to the accepted formats and that the results are cached.
+func TestCacheServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	realScaler := scaler.New(ctx)
+	defer realScaler.Cancel()
+
+	fakeScaler := &scalerfakes.FakeScaler{
+		ScaleToStub: realScaler.ScaleTo,
+	}
+
+	var original bytes.Buffer
+	if err := png.Encode(&original, image.NewRGBA(image.Rect(0, 0, 400, 400))); err != nil {
+		t.Fatalf("encoding test image: %s", err)
+	}
+
+	cache := New(config.Artwork{
+		Sizes:      []int{300, 100, 500},
+		CacheBytes: 1024 * 1024,
+	}, fakeScaler)
+	modified := time.Unix(1728838802, 0)
+	cache.now = func() time.Time { return modified }
+
+	serve := func(query, accept string, headers ...string) *http.Response {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/image"+query, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		rec := httptest.NewRecorder()
+		if err := cache.Serve(rec, req, bytes.NewReader(original.Bytes())); err != nil {
+			t.Fatalf("serving image: %s", err)
+		}
+		return rec.Result()
+	}
+
+	assertImage := func(resp *http.Response, format string, width int) {
+		t.Helper()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status %d but got %d", http.StatusOK, resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		cfg, name, err := image.DecodeConfig(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("decoding served image: %s", err)
+		}
+		if name != format || cfg.Width != width {
+			t.Errorf("expected %s image %d wide but got %s %d wide",
+				format, width, name, cfg.Width)
+		}
+	}
+
+	resp := serve("", "")
+	assertImage(resp, "png", 400)
+	if fakeScaler.ScaleToCallCount() != 0 {
+		t.Errorf("the original image was scaled")
+	}
+	if resp.Header.Get("ETag") == "" {
+		t.Errorf("no ETag for the original image")
+	}
+
+	resp = serve("?size=150", "image/webp,*/*")
+	assertImage(resp, "png", 300)
+	etag := resp.Header.Get("ETag")
+	if resp.Header.Get("Last-Modified") != modified.UTC().Format(http.TimeFormat) {
+		t.Errorf("wrong Last-Modified: %s", resp.Header.Get("Last-Modified"))
+	}
+	if resp.Header.Get("Vary") != "Accept" {
+		t.Errorf("expected Vary: Accept but got %q", resp.Header.Get("Vary"))
+	}
+
+	assertImage(serve("?size=150", ""), "png", 300)
+	if fakeScaler.ScaleToCallCount() != 1 {
+		t.Errorf("expected the cached image to be used but scaler was called %d times",
+			fakeScaler.ScaleToCallCount())
+	}
+
+	resp = serve("?size=150", "", "If-None-Match", etag)
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected status %d for matching ETag but got %d",
+			http.StatusNotModified, resp.StatusCode)
+	}
+
+	resp = serve("?size=50", "image/jpeg, image/png;q=0.5")
+	assertImage(resp, "jpeg", 100)
+	if resp.Header.Get("Content-Type") != "image/jpeg" {
+		t.Errorf("wrong content type: %s", resp.Header.Get("Content-Type"))
+	}
+
+	assertImage(serve("?size=1000", ""), "png", 400)
+	assertImage(serve("?size=small", ""), "png", 400)
+}
+
+// TestCacheEviction checks that the cache does not grow beyond its size.
+func TestCacheEviction(t *testing.T) {
+	cache := New(config.Artwork{CacheBytes: 10}, &scalerfakes.FakeScaler{})
+
+	cache.add("first", make([]byte, 4), scaler.FormatJPEG)
+	cache.add("second", make([]byte, 4), scaler.FormatJPEG)
+	if _, ok := cache.get("first"); !ok {
+		t.Fatalf("first variant was not cached")
+	}
+
+	cache.add("third", make([]byte, 4), scaler.FormatJPEG)
+	if _, ok := cache.get("second"); ok {
+		t.Errorf("the least recently used variant was not removed")
+	}
+	if _, ok := cache.get("first"); !ok {
+		t.Errorf("recently used variant was removed")
+	}
+
+	cache.add("huge", make([]byte, 11), scaler.FormatJPEG)
+	if _, ok := cache.get("huge"); ok {
+		t.Errorf("variant bigger than the cache was stored")
+	}
+	if cache.bytes != 8 {
+		t.Errorf("expected 8 bytes in the cache but there are %d", cache.bytes)
+	}
+}
+
+// TestNilCache checks that a nil *Cache serves images as they are.
+func TestNilCache(t *testing.T) {
+	var cache *Cache
+
+	req := httptest.NewRequest(http.MethodGet, "/image?size=100", nil)
+	rec := httptest.NewRecorder()
+	if err := cache.Serve(rec, req, bytes.NewReader([]byte("not an image"))); err != nil {
+		t.Fatalf("serving image: %s", err)
+	}
+	if rec.Body.String() != "not an image" {
+		t.Errorf("the image was changed: %s", rec.Body.String())
+	}
+}
+
+// TestNegotiate checks the choice of image format according to Accept headers.
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept       string
+		original     scaler.Format
+		keepOriginal bool
+		expected     scaler.Format
+	}{
+		{"", scaler.FormatPNG, true, scaler.FormatPNG},
+		{"", "image/gif", false, scaler.FormatJPEG},
+		{"*/*", scaler.FormatPNG, true, scaler.FormatPNG},
+		{"image/webp,*/*;q=0.8", scaler.FormatPNG, true, scaler.FormatPNG},
+		{"image/png;q=0.2, image/jpeg", scaler.FormatPNG, true, scaler.FormatJPEG},
+		{"image/*;q=0.1, image/png;q=0", scaler.FormatPNG, true, scaler.FormatJPEG},
+		{"text/html", scaler.FormatPNG, true, scaler.FormatPNG},
+		{"text/html", "image/gif", false, scaler.FormatJPEG},
+		{"image/png", "image/gif", false, scaler.FormatPNG},
+	}
+
+	for _, test := range tests {
+		actual := negotiate(test.accept, test.original, test.keepOriginal)
+		if actual != test.expected {
+			t.Errorf("Accept %q for %s: expected %s but got %s",
+				test.accept, test.original, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Package loginlimit throttles failed authentication attempts in order to make
+// brute-forcing credentials impractical.
+package loginlimit
+
+import (
+	"log"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+const (
+	// pruneInterval is how often forgotten attempts are removed from memory.
+	pruneInterval = time.Minute
+
+	// maxDoublings is the maximum number of times the backoff is doubled.
+	maxDoublings = 24
+
+	// maxTracked is the maximum number of pairs of client address and username
+	// for which failed attempts are kept in memory.
+	maxTracked = 10000
+
+	// maxUsersPerAddr is the maximum number of usernames tracked separately for
+	// a single client address. Failed attempts for more usernames are counted
+	// together.
+	maxUsersPerAddr = 16
+
+	// maxUserLength is the maximum number of bytes of the usernames which are kept
+	// in memory.
+	maxUserLength = 128
+)
+
+// Limiter keeps track of the failed authentication attempts for every pair of
+// client address and username. After a number of free attempts every next one is
+// allowed only after an exponentially growing delay. After even more failed
+// attempts the pair is locked out for a while. Clients which try too many usernames
+// have the attempts for the rest of them counted together.
+//
+// All methods of a nil *Limiter allow every attempt.
+type Limiter struct {
+	cfg     config.LoginLimits
+	trusted []netip.Prefix
+
+	mu        sync.Mutex
+	attempts  map[attemptKey]*attempts
+	users     map[string]int // Number of tracked usernames by client address.
+	lastPrune time.Time
+
+	// maxTracked and maxUsersPerAddr are fields so that tests could lower them.
+	maxTracked      int
+	maxUsersPerAddr int
+
+	// now is used instead of time.Now so that tests could control the time.
+	now func() time.Time
+}
+
+type attemptKey struct {
+	addr string
+	user string
+
+	// others is true for the attempts of a client address for all usernames
+	// after the first maxUsersPerAddr.
+	others bool
+}
+
+type attempts struct {
+	failed      int
+	lastFailure time.Time
+	blockedTill time.Time
+}
+
+// New returns a Limiter configured with `cfg`. Clients connecting through the
+// `trusted` proxies are identified using the X-Forwarded-For header. Returns nil
+// when limiting is disabled in the configuration.
+func New(cfg config.LoginLimits, trusted []netip.Prefix) *Limiter {
+	if cfg.Disable {
+		return nil
+	}
+
+	return &Limiter{
+		cfg:             cfg,
+		trusted:         trusted,
+		attempts:        make(map[attemptKey]*attempts),
+		users:           make(map[string]int),
+		maxTracked:      maxTracked,
+		maxUsersPerAddr: maxUsersPerAddr,
+		now:             time.Now,
+	}
+}
+
+// Allow checks whether the client making the request `r` may try to authenticate
+// as `user` now. When it may not, the returned duration is the time the client
+// has to wait before trying again.
+func (l *Limiter) Allow(r *http.Request, user string) (time.Duration, bool) {
+	if l == nil {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := l.key(r, user)
+	att, ok := l.attempts[key]
+	if !ok {
+		att, ok = l.attempts[othersKey(key.addr)]
+	}
+	if !ok {
+		return 0, true
+	}
+
+	now := l.now()
+	if now.Before(att.blockedTill) {
+		return att.blockedTill.Sub(now), false
+	}
+
+	return 0, true
+}
+
+// Failed records a failed attempt of the client making the request `r` to
+// authenticate as `user`. The failure is noted in the access log as well.
+func (l *Limiter) Failed(r *http.Request, user string) {
+	webutils.MarkAuthFailure(r.Context(), user)
+
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	key := l.key(r, user)
+	att, ok := l.attempts[key]
+	if !ok && l.users[key.addr] >= l.maxUsersPerAddr {
+		key = othersKey(key.addr)
+		att, ok = l.attempts[key]
+	}
+	if !ok {
+		l.makeRoom(now)
+		if !key.others {
+			l.users[key.addr]++
+		}
+	}
+	if !ok || l.forgotten(att, now) {
+		att = &attempts{}
+		l.attempts[key] = att
+	}
+
+	att.failed++
+	att.lastFailure = now
+
+	if l.cfg.LockoutAttempts > 0 && att.failed >= l.cfg.LockoutAttempts {
+		att.blockedTill = now.Add(l.cfg.LockoutDuration)
+		if att.failed == l.cfg.LockoutAttempts && key.others {
+			log.Printf(
+				"Locking out %s for too many users for %s after %d failed attempts\n",
+				key.addr, l.cfg.LockoutDuration, att.failed,
+			)
+		} else if att.failed == l.cfg.LockoutAttempts {
+			log.Printf(
+				"Locking out %s for user `%s` for %s after %d failed attempts\n",
+				key.addr, key.user, l.cfg.LockoutDuration, att.failed,
+			)
+		}
+		return
+	}
+
+	over := att.failed - l.cfg.FreeAttempts
+	if over <= 0 || l.cfg.Backoff <= 0 {
+		return
+	}
+
+	// The number of doublings is limited so that the duration does not overflow
+	// when there is no maximum backoff configured.
+	backoff := l.cfg.Backoff
+	for i := 1; i < over && i < maxDoublings; i++ {
+		backoff *= 2
+		if l.cfg.MaxBackoff > 0 && backoff >= l.cfg.MaxBackoff {
+			backoff = l.cfg.MaxBackoff
+			break
+		}
+	}
+	att.blockedTill = now.Add(backoff)
+}
+
+// Succeeded forgets all failed attempts of the client making the request `r` to
+// authenticate as `user`.
+func (l *Limiter) Succeeded(r *http.Request, user string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := l.key(r, user)
+	if _, ok := l.attempts[key]; ok {
+		l.forget(key)
+	}
+}
+
+func (l *Limiter) key(r *http.Request, user string) attemptKey {
+	if len(user) > maxUserLength {
+		user = user[:maxUserLength]
+	}
+
+	return attemptKey{
+		addr: webutils.ClientIP(r, l.trusted),
+		user: user,
+	}
+}
+
+// othersKey returns the key for the attempts of `addr` for the usernames which
+// are over the maxUsersPerAddr limit.
+func othersKey(addr string) attemptKey {
+	return attemptKey{addr: addr, others: true}
+}
+
+// forget removes the attempts for `key` which must be tracked. Must be called
+// with l.mu held.
+func (l *Limiter) forget(key attemptKey) {
+	delete(l.attempts, key)
+	if key.others {
+		return
+	}
+
+	l.users[key.addr]--
+	if l.users[key.addr] <= 0 {
+		delete(l.users, key.addr)
+	}
+}
+
+// makeRoom makes sure there is space for tracking one more key. Forgotten attempts
+// are removed first and when they are not enough the ones with the oldest failure
+// are removed. Must be called with l.mu held.
+func (l *Limiter) makeRoom(now time.Time) {
+	if len(l.attempts) < l.maxTracked {
+		return
+	}
+	l.removeForgotten(now)
+
+	for len(l.attempts) >= l.maxTracked {
+		var (
+			oldestKey attemptKey
+			oldest    *attempts
+		)
+		for key, att := range l.attempts {
+			if oldest == nil || att.lastFailure.Before(oldest.lastFailure) {
+				oldestKey, oldest = key, att
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		l.forget(oldestKey)
+	}
+}
+
+// prune removes the attempts which are old enough to be forgotten. Must be called
+// with l.mu held.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	l.removeForgotten(now)
+}
+
+// removeForgotten removes all attempts which are old enough to be forgotten. Must
+// be called with l.mu held.
+func (l *Limiter) removeForgotten(now time.Time) {
+	for key, att := range l.attempts {
+		if l.forgotten(att, now) {
+			l.forget(key)
+		}
+	}
+}
+
+// forgotten returns true when `att` is old enough so that it does not matter
+// anymore.
+func (l *Limiter) forgotten(att *attempts, now time.Time) bool {
+	return now.After(att.blockedTill) &&
+		now.Sub(att.lastFailure) > l.cfg.LockoutDuration
+}
@@ -0,0 +1,177 @@
+package loginlimit
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/config"
+)
+
+// TestLimiterBackoffAndLockout checks that failed attempts are free at first, then
+// throttled with an exponential backoff and finally locked out.
+func TestLimiterBackoffAndLockout(t *testing.T) {
+	now := time.Unix(1728838802, 0)
+	l := New(config.LoginLimits{
+		FreeAttempts:    2,
+		Backoff:         time.Second,
+		MaxBackoff:      4 * time.Second,
+		LockoutAttempts: 7,
+		LockoutDuration: time.Hour,
+	}, nil)
+	l.now = func() time.Time { return now }
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.10:4242"
+
+	// Expected waits after each failed attempt.
+	expectedWaits := []time.Duration{
+		0,               // free
+		0,               // free
+		time.Second,     // backoff starts
+		2 * time.Second, // doubled
+		4 * time.Second, // doubled
+		4 * time.Second, // capped at the maximum backoff
+		time.Hour,       // locked out
+	}
+
+	for i, expected := range expectedWaits {
+		if wait, ok := l.Allow(req, "user"); !ok {
+			t.Fatalf("attempt %d: expected to be allowed but wait is %s", i+1, wait)
+		}
+
+		l.Failed(req, "user")
+
+		wait, ok := l.Allow(req, "user")
+		if ok != (expected == 0) || wait != expected {
+			t.Errorf("attempt %d: expected wait %s but got %s (allowed: %t)",
+				i+1, expected, wait, ok)
+		}
+
+		now = now.Add(wait)
+	}
+
+	other := httptest.NewRequest("GET", "/", nil)
+	other.RemoteAddr = "192.0.2.11:4242"
+	if _, ok := l.Allow(other, "user"); !ok {
+		t.Errorf("expected other client addresses not to be affected")
+	}
+	if _, ok := l.Allow(req, "other-user"); !ok {
+		t.Errorf("expected other users not to be affected")
+	}
+
+	now = now.Add(time.Hour)
+	l.Failed(req, "user")
+	if _, ok := l.Allow(req, "user"); !ok {
+		t.Errorf("expected old failures to be forgotten after the lockout")
+	}
+
+	l.Failed(req, "user")
+	l.Succeeded(req, "user")
+	if len(l.attempts) != 0 {
+		t.Errorf("expected successful attempt to reset the failures")
+	}
+}
+
+// TestLimiterTrustedProxies checks that clients behind trusted proxies are told
+// apart by their forwarded address.
+func TestLimiterTrustedProxies(t *testing.T) {
+	l := New(config.LoginLimits{
+		LockoutAttempts: 1,
+		LockoutDuration: time.Hour,
+	}, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+	first := httptest.NewRequest("GET", "/", nil)
+	first.RemoteAddr = "10.0.0.1:1234"
+	first.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	second := httptest.NewRequest("GET", "/", nil)
+	second.RemoteAddr = "10.0.0.1:1234"
+	second.Header.Set("X-Forwarded-For", "198.51.100.2")
+
+	l.Failed(first, "user")
+
+	if _, ok := l.Allow(first, "user"); ok {
+		t.Errorf("expected first client to be locked out")
+	}
+	if _, ok := l.Allow(second, "user"); !ok {
+		t.Errorf("expected second client behind the same proxy to be allowed")
+	}
+}
+
+// TestLimiterMemory checks that the number of tracked attempts and the length of
+// the stored usernames are limited and that clients which try many usernames are
+// still throttled.
+func TestLimiterMemory(t *testing.T) {
+	now := time.Unix(1728838802, 0)
+	l := New(config.LoginLimits{
+		LockoutAttempts: 3,
+		LockoutDuration: time.Hour,
+	}, nil)
+	l.now = func() time.Time { return now }
+	l.maxTracked = 5
+	l.maxUsersPerAddr = 2
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.10:4242"
+
+	for i := range 10 {
+		l.Failed(req, fmt.Sprintf("user-%d-%s", i, strings.Repeat("x", 1000)))
+	}
+
+	if len(l.attempts) != 3 {
+		t.Errorf("expected 2 usernames and the rest together but got %d", len(l.attempts))
+	}
+	for key := range l.attempts {
+		if len(key.user) > maxUserLength {
+			t.Errorf("expected usernames to be truncated but got %d bytes", len(key.user))
+		}
+	}
+	if _, ok := l.Allow(req, "yet-another-user"); ok {
+		t.Errorf("expected the rest of the usernames to be locked out together")
+	}
+
+	l.Succeeded(req, "user-0-"+strings.Repeat("x", 1000))
+	if l.users["192.0.2.10"] != 1 {
+		t.Errorf("expected the successful username to be forgotten: %v", l.users)
+	}
+
+	for i := range 10 {
+		other := httptest.NewRequest("GET", "/", nil)
+		other.RemoteAddr = fmt.Sprintf("192.0.2.%d:4242", 100+i)
+		now = now.Add(time.Second)
+		l.Failed(other, "user")
+	}
+
+	if len(l.attempts) > l.maxTracked {
+		t.Errorf("expected at most %d tracked attempts but got %d",
+			l.maxTracked, len(l.attempts))
+	}
+
+	newest := httptest.NewRequest("GET", "/", nil)
+	newest.RemoteAddr = "192.0.2.109:4242"
+	if _, ok := l.attempts[l.key(newest, "user")]; !ok {
+		t.Errorf("expected the newest attempts to be kept")
+	}
+}
+
+// TestNilLimiter makes sure a nil limiter allows everything.
+func TestNilLimiter(t *testing.T) {
+	l := New(config.LoginLimits{Disable: true}, nil)
+	if l != nil {
+		t.Fatalf("expected disabled limiter to be nil")
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	for range 100 {
+		l.Failed(req, "user")
+	}
+
+	if _, ok := l.Allow(req, "user"); !ok {
+		t.Errorf("expected nil limiter to allow attempts")
+	}
+	l.Succeeded(req, "user")
+}
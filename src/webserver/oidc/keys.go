@@ -0,0 +1,133 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// keysRefreshInterval is the minimum time between downloading the provider keys.
+// It protects the provider from being hammered with tokens signed by unknown keys.
+const keysRefreshInterval = time.Minute
+
+// keySet holds the RSA signing keys of the provider by their ID.
+type keySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// jsonWebKey is a single key in a JWK Set document as described in RFC 7517.
+// Only the fields needed for RSA keys are decoded.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// signingKey returns the provider key with ID `keyID`. The keys are downloaded
+// again when no such key is known. Tokens without a key ID are accepted only
+// when the provider has a single key.
+func (p *Provider) signingKey(
+	ctx context.Context,
+	disc discovery,
+	keyID string,
+) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys.find(keyID); ok {
+		return key, nil
+	}
+
+	if !p.keys.fetchedAt.IsZero() && p.now().Sub(p.keys.fetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key `%s`", keyID)
+	}
+
+	keys, err := p.fetchKeys(ctx, disc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keySet{
+		keys:      keys,
+		fetchedAt: p.now(),
+	}
+
+	if key, ok := p.keys.find(keyID); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key `%s`", keyID)
+}
+
+func (ks keySet) find(keyID string) (*rsa.PublicKey, bool) {
+	if keyID == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+
+	key, ok := ks.keys[keyID]
+	return key, ok
+}
+
+func (p *Provider) fetchKeys(
+	ctx context.Context,
+	jwksURI string,
+) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating keys request: %w", err)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.doJSON(req, &jwks); err != nil {
+		return nil, fmt.Errorf("getting keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key `%s`: %w", jwk.KeyID, err)
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	return keys, nil
+}
+
+func (jwk jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("decoding modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("decoding exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 ||
+		exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("malformed RSA key")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
@@ -0,0 +1,414 @@
+// Package oidc implements logging in with an OpenID Connect provider using the
+// authorization code flow with PKCE. Only ID tokens signed with RS256 are
+// supported since this is the algorithm every provider is required to support.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+
+	"github.com/ironsmile/euterpe/src/config"
+)
+
+// discoveryPath is the path under the issuer URL of its configuration document.
+const discoveryPath = "/.well-known/openid-configuration"
+
+// maxResponseSize limits the size of the responses read from the provider.
+const maxResponseSize = 1 << 20
+
+// ErrNotAllowed is returned by Exchange when the user was authenticated by the
+// provider but is not among the allowed subjects or groups.
+var ErrNotAllowed = errors.New("user is not allowed to log in")
+
+// Identity describes the user as reported in the ID token.
+type Identity struct {
+	Subject string
+	Name    string
+	Groups  []string
+}
+
+// Provider logs in users with an OpenID Connect provider. Its configuration is
+// discovered the first time it is needed and kept for the lifetime of the
+// Provider. The same goes for its signing keys which are only downloaded again
+// when a token is signed with an unknown key.
+type Provider struct {
+	cfg    config.OIDC
+	client *http.Client
+
+	subjects map[string]struct{}
+	groups   map[string]struct{}
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      keySet
+
+	// now is used instead of time.Now so that tests could control the time.
+	now func() time.Time
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// New returns a Provider configured with `cfg` which will use `client` for talking
+// to the provider. Returns nil when OIDC is not configured.
+func New(cfg config.OIDC, client *http.Client) (*Provider, error) {
+	if cfg.Issuer == "" {
+		return nil, nil
+	}
+
+	if cfg.ClientID == "" {
+		return nil, errors.New("client_id is required")
+	}
+
+	if cfg.RedirectURL == "" {
+		return nil, errors.New("redirect_url is required")
+	}
+
+	if len(cfg.AllowedSubjects) == 0 && len(cfg.AllowedGroups) == 0 {
+		return nil, errors.New("allowed_subjects or allowed_groups is required")
+	}
+
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Provider{
+		cfg:      cfg,
+		client:   client,
+		subjects: toSet(cfg.AllowedSubjects),
+		groups:   toSet(cfg.AllowedGroups),
+		now:      time.Now,
+	}, nil
+}
+
+// NewVerifier returns a random PKCE code verifier.
+func NewVerifier() string {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// AuthCodeURL returns the URL at the provider to which the browser must be sent
+// in order to log in. The `state` and `nonce` are returned back by the provider
+// and must be checked on the way back. The `verifier` is the PKCE code verifier
+// which must be used in Exchange later.
+func (p *Provider) AuthCodeURL(
+	ctx context.Context,
+	state, nonce, verifier string,
+) (string, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(disc.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("malformed authorization endpoint: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.scopes(), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange trades the authorization `code` for an ID token and returns the
+// identity of the user in it. ErrNotAllowed is returned for users which are not
+// allowed by the configuration.
+func (p *Provider) Exchange(
+	ctx context.Context,
+	code, verifier, nonce string,
+) (Identity, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		disc.TokenEndpoint,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return Identity{}, fmt.Errorf("creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(
+			url.QueryEscape(p.cfg.ClientID),
+			url.QueryEscape(p.cfg.ClientSecret),
+		)
+	}
+
+	tokenResp := struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}{}
+	if err := p.doJSON(req, &tokenResp); err != nil {
+		if tokenResp.Error != "" {
+			return Identity{}, fmt.Errorf("token request: %s", tokenResp.Error)
+		}
+		return Identity{}, fmt.Errorf("token request: %w", err)
+	}
+
+	if tokenResp.IDToken == "" {
+		return Identity{}, errors.New("no ID token in the token response")
+	}
+
+	identity, err := p.verifyIDToken(ctx, disc, tokenResp.IDToken, nonce)
+	if err != nil {
+		return Identity{}, fmt.Errorf("verifying ID token: %w", err)
+	}
+
+	if !p.allowed(identity) {
+		return identity, ErrNotAllowed
+	}
+
+	return identity, nil
+}
+
+func (p *Provider) verifyIDToken(
+	ctx context.Context,
+	disc discovery,
+	token string,
+	nonce string,
+) (Identity, error) {
+	header, err := tokenHeader(token)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	key, err := p.signingKey(ctx, disc, header.KeyID)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var claims struct {
+		jwt.Payload
+		Nonce string `json:"nonce"`
+		Name  string `json:"name"`
+	}
+	now := p.now()
+	_, err = jwt.Verify(
+		[]byte(token),
+		jwt.NewRS256(jwt.RSAPublicKey(key)),
+		&claims,
+		jwt.ValidateHeader,
+		jwt.ValidatePayload(
+			&claims.Payload,
+			jwt.IssuerValidator(disc.Issuer),
+			jwt.AudienceValidator(jwt.Audience{p.cfg.ClientID}),
+			jwt.ExpirationTimeValidator(now),
+			jwt.NotBeforeValidator(now),
+		),
+	)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if claims.Nonce != nonce {
+		return Identity{}, errors.New("nonce does not match")
+	}
+
+	if claims.Subject == "" {
+		return Identity{}, errors.New("subject is empty")
+	}
+
+	groups, err := groupsClaim(token, p.cfg.GroupsClaim)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return Identity{
+		Subject: claims.Subject,
+		Name:    claims.Name,
+		Groups:  groups,
+	}, nil
+}
+
+func (p *Provider) allowed(identity Identity) bool {
+	if _, ok := p.subjects[identity.Subject]; ok {
+		return true
+	}
+
+	for _, group := range identity.Groups {
+		if _, ok := p.groups[group]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Provider) scopes() []string {
+	scopes := []string{"openid"}
+	for _, scope := range p.cfg.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// getDiscovery returns the provider configuration, fetching it if this was not
+// done already.
+func (p *Provider) getDiscovery(ctx context.Context) (discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return *p.discovery, nil
+	}
+
+	discURL := strings.TrimSuffix(p.cfg.Issuer, "/") + discoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discURL, nil)
+	if err != nil {
+		return discovery{}, fmt.Errorf("creating discovery request: %w", err)
+	}
+
+	var disc discovery
+	if err := p.doJSON(req, &disc); err != nil {
+		return discovery{}, fmt.Errorf("discovery: %w", err)
+	}
+
+	if disc.Issuer != p.cfg.Issuer {
+		return discovery{}, fmt.Errorf(
+			"discovery: issuer `%s` does not match the configured one",
+			disc.Issuer,
+		)
+	}
+
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" ||
+		disc.JWKSURI == "" {
+		return discovery{}, errors.New("discovery: required endpoints are missing")
+	}
+
+	p.discovery = &disc
+	return disc, nil
+}
+
+// doJSON makes the request `req` and decodes its JSON response into `dest`. The
+// response is decoded even for unsuccessful responses since they may contain
+// an error description.
+func (p *Provider) doJSON(req *http.Request, dest any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decErr := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(dest)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	if decErr != nil {
+		return fmt.Errorf("decoding response: %w", decErr)
+	}
+
+	return nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, val := range values {
+		set[val] = struct{}{}
+	}
+	return set
+}
+
+// tokenHeader decodes the JOSE header of `token` without verifying it.
+func tokenHeader(token string) (jwt.Header, error) {
+	var header jwt.Header
+	encoded, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return header, jwt.ErrMalformed
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return header, fmt.Errorf("decoding token header: %w", err)
+	}
+
+	if err := json.Unmarshal(decoded, &header); err != nil {
+		return header, fmt.Errorf("decoding token header: %w", err)
+	}
+
+	return header, nil
+}
+
+// groupsClaim returns the list of groups in the claim `name` of an already verified
+// `token`. Providers put groups under different claims so it could not be a field
+// of the claims struct.
+func groupsClaim(token string, name string) ([]string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, jwt.ErrMalformed
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decoding token payload: %w", err)
+	}
+
+	var claims map[string]json.RawMessage
+	if err := json.Unmarshal(decoded, &claims); err != nil {
+		return nil, fmt.Errorf("decoding token payload: %w", err)
+	}
+
+	raw, ok := claims[name]
+	if !ok {
+		return nil, nil
+	}
+
+	var groups []string
+	if err := json.Unmarshal(raw, &groups); err == nil {
+		return groups, nil
+	}
+
+	var group string
+	if err := json.Unmarshal(raw, &group); err != nil {
+		return nil, fmt.Errorf("claim %s is neither a string nor a list", name)
+	}
+
+	return []string{group}, nil
+}
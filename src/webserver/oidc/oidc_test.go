@@ -0,0 +1,217 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/webserver/oidc"
+	"github.com/ironsmile/euterpe/src/webserver/oidc/oidctest"
+)
+
+const (
+	clientID     = "euterpe"
+	clientSecret = "euterpe-secret"
+	redirectURL  = "https://music.example.com/login/oidc/callback"
+)
+
+// TestNewErrors makes sure that incomplete configuration is rejected.
+func TestNewErrors(t *testing.T) {
+	provider, err := oidc.New(config.OIDC{}, nil)
+	if provider != nil || err != nil {
+		t.Fatalf("expected nil provider without issuer but got %v, %v", provider, err)
+	}
+
+	valid := config.OIDC{
+		Issuer:          "https://sso.example.com",
+		ClientID:        clientID,
+		RedirectURL:     redirectURL,
+		AllowedSubjects: []string{"someone"},
+	}
+	if _, err := oidc.New(valid, nil); err != nil {
+		t.Fatalf("unexpected error for valid config: %s", err)
+	}
+
+	noClient := valid
+	noClient.ClientID = ""
+
+	noRedirect := valid
+	noRedirect.RedirectURL = ""
+
+	noAllowed := valid
+	noAllowed.AllowedSubjects = nil
+
+	for _, cfg := range []config.OIDC{noClient, noRedirect, noAllowed} {
+		if _, err := oidc.New(cfg, nil); err == nil {
+			t.Errorf("expected error for config %+v", cfg)
+		}
+	}
+}
+
+// TestLogin goes through the whole authorization code flow against a stub issuer.
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		desc          string
+		subject       string
+		groups        []string
+		claims        map[string]any
+		exchangeNonce string
+		verifier      string
+		expectedErr   error
+		expectAnyErr  bool
+	}{
+		{
+			desc:    "allowed subject",
+			subject: "allowed-user",
+		},
+		{
+			desc:    "allowed group",
+			subject: "group-member",
+			groups:  []string{"other", "music"},
+		},
+		{
+			desc:        "not allowed",
+			subject:     "stranger",
+			groups:      []string{"other"},
+			expectedErr: oidc.ErrNotAllowed,
+		},
+		{
+			desc:          "wrong nonce",
+			subject:       "allowed-user",
+			exchangeNonce: "some-other-nonce",
+			expectAnyErr:  true,
+		},
+		{
+			desc:         "wrong code verifier",
+			subject:      "allowed-user",
+			verifier:     oidc.NewVerifier(),
+			expectAnyErr: true,
+		},
+		{
+			desc:         "wrong audience",
+			subject:      "allowed-user",
+			claims:       map[string]any{"aud": "some-other-client"},
+			expectAnyErr: true,
+		},
+		{
+			desc:    "expired token",
+			subject: "allowed-user",
+			claims: map[string]any{
+				"exp": time.Now().Add(-time.Minute).Unix(),
+			},
+			expectAnyErr: true,
+		},
+		{
+			desc:         "wrong issuer",
+			subject:      "allowed-user",
+			claims:       map[string]any{"iss": "https://evil.example.com"},
+			expectAnyErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			issuer := oidctest.NewIssuer(clientID, clientSecret)
+			defer issuer.Close()
+			issuer.SetUser(test.subject, test.groups...)
+			issuer.SetClaims(test.claims)
+
+			provider, err := oidc.New(config.OIDC{
+				Issuer:          issuer.URL,
+				ClientID:        clientID,
+				ClientSecret:    clientSecret,
+				RedirectURL:     redirectURL,
+				AllowedSubjects: []string{"allowed-user"},
+				AllowedGroups:   []string{"music"},
+			}, issuer.Client())
+			if err != nil {
+				t.Fatalf("creating provider: %s", err)
+			}
+
+			ctx := context.Background()
+			const (
+				state = "some-state"
+				nonce = "some-nonce"
+			)
+			verifier := oidc.NewVerifier()
+
+			authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+			if err != nil {
+				t.Fatalf("getting auth code URL: %s", err)
+			}
+
+			code := authorize(t, authURL, state)
+
+			exchangeNonce := nonce
+			if test.exchangeNonce != "" {
+				exchangeNonce = test.exchangeNonce
+			}
+			if test.verifier != "" {
+				verifier = test.verifier
+			}
+
+			identity, err := provider.Exchange(ctx, code, verifier, exchangeNonce)
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Fatalf("expected error `%s` but got `%v`", test.expectedErr, err)
+				}
+				return
+			}
+			if test.expectAnyErr {
+				if err == nil {
+					t.Fatalf("expected an error but got identity %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("exchange failed: %s", err)
+			}
+
+			if identity.Subject != test.subject {
+				t.Errorf(
+					"expected subject `%s` but got `%s`",
+					test.subject,
+					identity.Subject,
+				)
+			}
+			if len(identity.Groups) != len(test.groups) {
+				t.Errorf("expected groups %v but got %v", test.groups, identity.Groups)
+			}
+		})
+	}
+}
+
+// authorize visits the `authURL` of the stub issuer and returns the code it
+// redirected back with.
+func authorize(t *testing.T, authURL string, state string) string {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorization request failed: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected authorization redirect but got HTTP %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("malformed redirect location: %s", err)
+	}
+
+	if location.Query().Get("state") != state {
+		t.Fatalf("state was not returned by the issuer")
+	}
+
+	return location.Query().Get("code")
+}
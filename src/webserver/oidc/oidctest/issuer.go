@@ -0,0 +1,229 @@
+// Package oidctest provides a stub OpenID Connect provider for use in tests. It
+// logs in a preconfigured user without asking anything as soon as the browser
+// reaches its authorization endpoint.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+)
+
+const keyID = "oidctest-key"
+
+// Issuer is a stub OpenID Connect provider running on a local HTTP server. Its
+// URL is the issuer identifier.
+type Issuer struct {
+	*httptest.Server
+
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu      sync.Mutex
+	subject string
+	groups  []string
+	claims  map[string]any
+	codes   map[string]authRequest
+}
+
+type authRequest struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// NewIssuer starts a new stub provider which accepts only the client with
+// `clientID` and `clientSecret`. The secret is not checked when empty. It must be
+// closed by the caller when no longer needed.
+func NewIssuer(clientID, clientSecret string) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	iss := &Issuer{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		subject:      "oidctest-user",
+		codes:        make(map[string]authRequest),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", iss.serveDiscovery)
+	mux.HandleFunc("GET /authorize", iss.serveAuthorize)
+	mux.HandleFunc("POST /token", iss.serveToken)
+	mux.HandleFunc("GET /jwks", iss.serveKeys)
+	iss.Server = httptest.NewServer(mux)
+
+	return iss
+}
+
+// SetUser changes the user which is logged in by the provider.
+func (iss *Issuer) SetUser(subject string, groups ...string) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	iss.subject = subject
+	iss.groups = groups
+}
+
+// SetClaims sets additional claims for the issued ID tokens. They override the
+// ones set by the provider so they could be used for issuing wrong tokens.
+func (iss *Issuer) SetClaims(claims map[string]any) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	iss.claims = claims
+}
+
+func (iss *Issuer) serveDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                iss.URL,
+		"authorization_endpoint":                iss.URL + "/authorize",
+		"token_endpoint":                        iss.URL + "/token",
+		"jwks_uri":                              iss.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (iss *Issuer) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != iss.clientID ||
+		query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := rand.Text()
+	iss.mu.Lock()
+	iss.codes[code] = authRequest{
+		redirectURI: redirectURI.String(),
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+	}
+	iss.mu.Unlock()
+
+	back := redirectURI.Query()
+	back.Set("code", code)
+	back.Set("state", query.Get("state"))
+	redirectURI.RawQuery = back.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (iss *Issuer) serveToken(w http.ResponseWriter, r *http.Request) {
+	if iss.clientSecret != "" {
+		user, pass, _ := r.BasicAuth()
+		if user != iss.clientID || pass != iss.clientSecret {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{
+				"error": "invalid_client",
+			})
+			return
+		}
+	}
+
+	iss.mu.Lock()
+	code := r.PostFormValue("code")
+	req, ok := iss.codes[code]
+	delete(iss.codes, code)
+	iss.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok ||
+		r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != req.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != req.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "invalid_grant",
+		})
+		return
+	}
+
+	idToken, err := iss.signIDToken(req.nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (iss *Issuer) serveKeys(w http.ResponseWriter, _ *http.Request) {
+	pub := iss.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": keyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(
+					big.NewInt(int64(pub.E)).Bytes(),
+				),
+			},
+		},
+	})
+}
+
+func (iss *Issuer) signIDToken(nonce string) (string, error) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   iss.URL,
+		"sub":   iss.subject,
+		"aud":   iss.clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	if len(iss.groups) > 0 {
+		claims["groups"] = iss.groups
+	}
+	for name, value := range iss.claims {
+		claims[name] = value
+	}
+
+	token, err := jwt.Sign(
+		claims,
+		jwt.NewRS256(jwt.RSAPrivateKey(iss.key)),
+		jwt.KeyID(keyID),
+	)
+	return string(token), err
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,92 @@
+package webserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/devices"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+)
+
+// sessionRefreshGrace is for how long the tokens given out for a refresh token of
+// a web UI session are given out again to other requests with the same token.
+const sessionRefreshGrace = 30 * time.Second
+
+// sessionRefresher exchanges the refresh tokens of web UI sessions for new tokens.
+//
+// Once the access token in the session cookie expires browsers send many requests
+// at once, all of them with the same refresh cookie. Every refresh token could be
+// used only once and a second use revokes its device. So the tokens given out for
+// the first of these requests are given to the rest of them for a short while.
+type sessionRefresher struct {
+	keyring *tokens.Keyring
+	devices devices.Registry
+
+	mu        sync.Mutex
+	refreshed map[string]refreshedSession // Keyed by the used refresh token
+}
+
+// refreshedSession holds the tokens given out for a refresh token.
+type refreshedSession struct {
+	claims  tokens.Claims // Claims of the used refresh token
+	access  []byte
+	refresh []byte
+	at      time.Time
+}
+
+func newSessionRefresher(
+	keyring *tokens.Keyring,
+	registry devices.Registry,
+) *sessionRefresher {
+	return &sessionRefresher{
+		keyring:   keyring,
+		devices:   registry,
+		refreshed: make(map[string]refreshedSession),
+	}
+}
+
+// refresh exchanges `refreshToken` for new access and refresh tokens. Returns
+// errInvalidRefreshToken when the token could not be exchanged.
+func (s *sessionRefresher) refresh(
+	ctx context.Context,
+	refreshToken string,
+) (refreshedSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for used, session := range s.refreshed {
+		if now.Sub(session.at) > sessionRefreshGrace {
+			delete(s.refreshed, used)
+		}
+	}
+
+	if session, ok := s.refreshed[refreshToken]; ok {
+		// The device could have been revoked in the meantime.
+		if err := s.devices.Check(ctx, session.claims.Device); err != nil {
+			return refreshedSession{}, errInvalidRefreshToken
+		}
+		return session, nil
+	}
+
+	claims, access, refresh, err := refreshTokenPair(
+		ctx,
+		s.keyring,
+		s.devices,
+		[]byte(refreshToken),
+	)
+	if err != nil {
+		return refreshedSession{}, err
+	}
+
+	session := refreshedSession{
+		claims:  claims,
+		access:  access,
+		refresh: refresh,
+		at:      now,
+	}
+	s.refreshed[refreshToken] = session
+
+	return session, nil
+}
@@ -4,11 +4,36 @@ import (
 	"crypto/md5"
 	"crypto/subtle"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
+
+	"github.com/ironsmile/euterpe/src/apikeys"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
 )
 
+// apiKeyScopes maps API methods to the API key scope required for calling them.
+// Methods which are not in the map require the read scope.
+var apiKeyScopes = map[string]apikeys.Scope{
+	"stream":    apikeys.ScopeStream,
+	"download":  apikeys.ScopeStream,
+	"scrobble":  apikeys.ScopeStream,
+	"star":      apikeys.ScopeStream,
+	"unstar":    apikeys.ScopeStream,
+	"setRating": apikeys.ScopeStream,
+
+	"createPlaylist": apikeys.ScopePlaylistsWrite,
+	"updatePlaylist": apikeys.ScopePlaylistsWrite,
+	"deletePlaylist": apikeys.ScopePlaylistsWrite,
+
+	"startScan":                  apikeys.ScopeAdmin,
+	"createInternetRadioStation": apikeys.ScopeAdmin,
+	"updateInternetRadioStation": apikeys.ScopeAdmin,
+	"deleteInternetRadioStation": apikeys.ScopeAdmin,
+}
+
 func (s *subsonic) authHandler(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(
 		w http.ResponseWriter,
@@ -33,6 +58,27 @@ func (s *subsonic) authHandler(handler http.Handler) http.Handler {
 		token := r.Form.Get("t")
 		salt := r.Form.Get("s")
 
+		if apiKey := r.Form.Get("apiKey"); apiKey != "" {
+			if user != "" {
+				resp := responseError(
+					errCodeAuthConflict,
+					"Multiple conflicting authentication mechanisms provided",
+				)
+
+				w.WriteHeader(http.StatusUnauthorized)
+				encodeResponse(w, r, resp)
+				return
+			}
+
+			s.serveWithAPIKey(w, r, handler, apiKey)
+			return
+		}
+
+		if _, ok := s.proxyAuth.Authenticate(r); ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
 		if user == "" || (pass == "" && (token == "" || salt == "")) {
 			resp := responseError(
 				errCodeMissingParameter,
@@ -44,6 +90,18 @@ func (s *subsonic) authHandler(handler http.Handler) http.Handler {
 			return
 		}
 
+		if wait, ok := s.limiter.Allow(r, user); !ok {
+			resp := responseError(
+				errCodeGeneric,
+				"Too many failed attempts, try again later",
+			)
+
+			webutils.SetRetryAfter(w, wait)
+			w.WriteHeader(http.StatusTooManyRequests)
+			encodeResponse(w, r, resp)
+			return
+		}
+
 		var authSuccess bool
 
 		if pass != "" {
@@ -51,6 +109,7 @@ func (s *subsonic) authHandler(handler http.Handler) http.Handler {
 				pass = strings.TrimPrefix(pass, "enc:")
 				decPass, err := hex.DecodeString(pass)
 				if err != nil {
+					s.limiter.Failed(r, user)
 					resp := responseError(
 						errCodeWrongUserOrPass,
 						fmt.Sprintf(
@@ -88,6 +147,7 @@ func (s *subsonic) authHandler(handler http.Handler) http.Handler {
 		}
 
 		if !authSuccess {
+			s.limiter.Failed(r, user)
 			resp := responseError(
 				errCodeWrongUserOrPass,
 				"Wrong username or password",
@@ -97,7 +157,53 @@ func (s *subsonic) authHandler(handler http.Handler) http.Handler {
 			encodeResponse(w, r, resp)
 			return
 		}
+		s.limiter.Succeeded(r, user)
 
 		handler.ServeHTTP(w, r)
 	})
 }
+
+// serveWithAPIKey calls `handler` only when `apiKey` is a valid API key which
+// permits calling the requested method.
+func (s *subsonic) serveWithAPIKey(
+	w http.ResponseWriter,
+	r *http.Request,
+	handler http.Handler,
+	apiKey string,
+) {
+	key, err := s.apiKeys.Check(r.Context(), apiKey)
+	if errors.Is(err, apikeys.ErrNotFound) {
+		resp := responseError(errCodeInvalidAPIKey, "Invalid API key")
+
+		w.WriteHeader(http.StatusUnauthorized)
+		encodeResponse(w, r, resp)
+		return
+	} else if err != nil {
+		log.Printf("Error checking Subsonic API key: %s\n", err)
+		resp := responseError(errCodeGeneric, "Cannot check API key")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		encodeResponse(w, r, resp)
+		return
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, Prefix)
+	method = strings.Trim(strings.TrimSuffix(method, ".view"), "/")
+	scope, ok := apiKeyScopes[method]
+	if !ok {
+		scope = apikeys.ScopeRead
+	}
+
+	if !key.Allows(scope) {
+		resp := responseError(
+			errCodeNotAuthorized,
+			fmt.Sprintf("API key does not have the `%s` scope", scope),
+		)
+
+		w.WriteHeader(http.StatusForbidden)
+		encodeResponse(w, r, resp)
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
@@ -1,6 +1,7 @@
 package subsonic_test
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/xml"
@@ -10,10 +11,13 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/ironsmile/euterpe/src/apikeys"
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/library/libraryfakes"
 	"github.com/ironsmile/euterpe/src/playlists/playlistsfakes"
 	"github.com/ironsmile/euterpe/src/radio/radiofakes"
+	"github.com/ironsmile/euterpe/src/webserver/forwardauth"
 	"github.com/ironsmile/euterpe/src/webserver/subsonic"
 	"github.com/ironsmile/euterpe/src/webserver/subsonic/subsonicfakes"
 )
@@ -25,6 +29,7 @@ func TestAuthHandler(t *testing.T) {
 		username = "the-real-user"
 		password = "the-real-password"
 		salt     = "random-salt"
+		apiKey   = "eut_read-only-api-key"
 	)
 
 	tokenMD5 := md5.New()
@@ -34,9 +39,12 @@ func TestAuthHandler(t *testing.T) {
 	tests := []struct {
 		Desc         string
 		SkipAuth     bool
+		Method       string
 		Query        map[string]string
+		Headers      map[string]string
 		Success      bool
 		ExpectedCode int
+		ExpectedHTTP int
 	}{
 		{
 			Desc:     "no authentication",
@@ -145,6 +153,56 @@ func TestAuthHandler(t *testing.T) {
 			Success:      false,
 			ExpectedCode: 40,
 		},
+		{
+			Desc: "with API key",
+			Query: map[string]string{
+				"apiKey": apiKey,
+			},
+			Success: true,
+		},
+		{
+			Desc: "invalid API key",
+			Query: map[string]string{
+				"apiKey": "eut_wrong-key",
+			},
+			Success:      false,
+			ExpectedCode: 44,
+		},
+		{
+			Desc: "API key together with username",
+			Query: map[string]string{
+				"apiKey": apiKey,
+				"u":      username,
+				"p":      password,
+			},
+			Success:      false,
+			ExpectedCode: 43,
+		},
+		{
+			Desc:   "API key without the required scope",
+			Method: "startScan",
+			Query: map[string]string{
+				"apiKey": apiKey,
+			},
+			Success:      false,
+			ExpectedCode: 50,
+			ExpectedHTTP: http.StatusForbidden,
+		},
+		{
+			Desc: "authenticated by the proxy",
+			Headers: map[string]string{
+				"Remote-User": username,
+			},
+			Success: true,
+		},
+		{
+			Desc: "proxy header with unknown user",
+			Headers: map[string]string{
+				"Remote-User": "someone-else",
+			},
+			Success:      false,
+			ExpectedCode: 10,
+		},
 	}
 
 	for _, test := range tests {
@@ -174,9 +232,14 @@ func TestAuthHandler(t *testing.T) {
 				t.Fatalf("failed to decode XML response: %s", err)
 			}
 
-			if resp.StatusCode != http.StatusUnauthorized {
+			expectedHTTP := test.ExpectedHTTP
+			if expectedHTTP == 0 {
+				expectedHTTP = http.StatusUnauthorized
+			}
+			if resp.StatusCode != expectedHTTP {
 				t.Errorf(
-					"expected HTTP status for unauthorized but got %d",
+					"expected HTTP status %d but got %d",
+					expectedHTTP,
 					resp.StatusCode,
 				)
 			}
@@ -203,12 +266,38 @@ func TestAuthHandler(t *testing.T) {
 				},
 			}
 
+			keys := &apikeysfakes.FakeKeys{
+				CheckStub: func(_ context.Context, secret string) (apikeys.Key, error) {
+					if secret != apiKey {
+						return apikeys.Key{}, apikeys.ErrNotFound
+					}
+					return apikeys.Key{
+						ID:     1,
+						Scopes: []apikeys.Scope{apikeys.ScopeRead},
+					}, nil
+				},
+			}
+
+			// The test server is on the loopback interface so it acts as the
+			// authenticating proxy.
+			proxyAuth, err := forwardauth.New(config.ForwardAuth{
+				Enable:  true,
+				Header:  "Remote-User",
+				Proxies: []string{"127.0.0.0/8", "::1"},
+			}, username)
+			if err != nil {
+				t.Fatalf("cannot create forward authenticator: %s", err)
+			}
+
 			sh := subsonic.NewHandler(
 				subsonic.Prefix,
 				&libraryfakes.FakeLibrary{},
 				&libraryfakes.FakeBrowser{},
 				&radiofakes.FakeStations{},
 				&playlistsfakes.FakePlaylister{},
+				keys,
+				nil,
+				proxyAuth,
 				cfg,
 				&subsonicfakes.FakeCoverArtHandler{},
 				&subsonicfakes.FakeCoverArtHandler{},
@@ -226,7 +315,11 @@ func TestAuthHandler(t *testing.T) {
 			for qk, qv := range test.Query {
 				query.Set(qk, qv)
 			}
-			reqURL.Path = subsonic.Prefix + "/ping/"
+			method := test.Method
+			if method == "" {
+				method = "ping"
+			}
+			reqURL.Path = subsonic.Prefix + "/" + method + "/"
 			reqURL.RawQuery = query.Encode()
 
 			t.Logf("Making HTTP request %s", reqURL.String())
@@ -239,6 +332,9 @@ func TestAuthHandler(t *testing.T) {
 			if err != nil {
 				t.Fatalf("cannot create request: %s", err)
 			}
+			for hk, hv := range test.Headers {
+				req.Header.Set(hk, hv)
+			}
 
 			resp, err := http.DefaultClient.Do(req)
 			if err != nil {
@@ -10,6 +10,9 @@ const (
 	errCodeVersionServer    apiErrorCode = 30
 	errCodeWrongUserOrPass  apiErrorCode = 40
 	errCodeTokenAuthLDAP    apiErrorCode = 41
+	errCodeAuthNotSupported apiErrorCode = 42
+	errCodeAuthConflict     apiErrorCode = 43
+	errCodeInvalidAPIKey    apiErrorCode = 44
 	errCodeNotAuthorized    apiErrorCode = 50
 	errCodeNotFound         apiErrorCode = 70
 )
@@ -1,6 +1,7 @@
 package subsonic
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 )
@@ -27,6 +28,12 @@ func (s *subsonic) getAlbum(w http.ResponseWriter, req *http.Request) {
 		xsdAlbumID3: dbAlbumToAlbumID3Entry(album),
 	}
 
+	info, err := s.lib.GetAlbumInfo(req.Context(), albumID)
+	if err != nil {
+		log.Printf("Error getting info for album %d: %s\n", albumID, err)
+	}
+	alEntry.setAlbumInfo(info)
+
 	tracks := s.lib.GetAlbumFiles(req.Context(), albumID)
 	for _, track := range tracks {
 		alEntry.Children = append(alEntry.Children, trackToChild(
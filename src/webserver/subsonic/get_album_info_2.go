@@ -1,10 +1,13 @@
 package subsonic
 
 import (
+	"context"
 	"errors"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/ironsmile/euterpe/src/library"
 )
@@ -31,11 +34,23 @@ func (s *subsonic) getAlbumInfo2(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Searching for the info on the internet could take a while. Errors are only
+	// logged since the response is useful even without the info.
+	ctx, cancel := context.WithTimeout(req.Context(), time.Minute)
+	defer cancel()
+
+	info, err := s.lib.FindAlbumInfo(ctx, albumID)
+	if err != nil {
+		log.Printf("Error getting info for album %d: %s\n", albumID, err)
+	}
+
 	artURL, query := s.getAlbumImageURL(req, album.ID)
 
 	resp := albumInfoResponse{
 		baseResponse: responseOk(),
 		AlbumInfo: xsdAlbumInfo{
+			Notes:         info.Notes,
+			MusicBrainzID: info.MusicBrainzID,
 			LastfmURL: "https://last.fm/music/" + url.PathEscape(album.Artist) + "/" +
 				url.PathEscape(album.Name),
 		},
@@ -1,6 +1,7 @@
 package subsonic
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -84,7 +85,12 @@ func (s *subsonic) getAlbumList(w http.ResponseWriter, req *http.Request) {
 		browseArgs.Offset = offset
 	}
 
-	albums, _ := s.libBrowser.BrowseAlbums(browseArgs)
+	albums, err := s.browseAlbumList(req.Context(), browseArgs)
+	if err != nil {
+		resp := responseError(errCodeGeneric, err.Error())
+		encodeResponse(w, req, resp)
+		return
+	}
 
 	var albumList []xsdChild
 	for _, album := range albums {
@@ -104,6 +110,24 @@ func (s *subsonic) getAlbumList(w http.ResponseWriter, req *http.Request) {
 	encodeResponse(w, req, resp)
 }
 
+// browseAlbumList returns the albums for an album list. The lists of frequently
+// and recently played albums are computed from the listening history.
+func (s *subsonic) browseAlbumList(
+	ctx context.Context,
+	browseArgs library.BrowseArgs,
+) ([]library.Album, error) {
+	switch browseArgs.OrderBy {
+	case library.OrderByFrequentlyPlayed, library.OrderByRecentlyPlayed:
+		return s.lib.TopAlbums(ctx, library.StatsArgs{
+			Count:  int(browseArgs.PerPage),
+			Offset: browseArgs.Offset,
+		}, browseArgs.OrderBy)
+	}
+
+	albums, _ := s.libBrowser.BrowseAlbums(browseArgs)
+	return albums, nil
+}
+
 type albumListResponse struct {
 	baseResponse
 
@@ -84,7 +84,12 @@ func (s *subsonic) getAlbumList2(w http.ResponseWriter, req *http.Request) {
 		browseArgs.Offset = offset
 	}
 
-	albums, _ := s.libBrowser.BrowseAlbums(browseArgs)
+	albums, err := s.browseAlbumList(req.Context(), browseArgs)
+	if err != nil {
+		resp := responseError(errCodeGeneric, err.Error())
+		encodeResponse(w, req, resp)
+		return
+	}
 
 	var albumList []xsdAlbumID3
 	for _, album := range albums {
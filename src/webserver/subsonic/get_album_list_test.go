@@ -0,0 +1,116 @@
+package subsonic_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/playlists/playlistsfakes"
+	"github.com/ironsmile/euterpe/src/radio/radiofakes"
+	"github.com/ironsmile/euterpe/src/webserver/subsonic"
+)
+
+// TestGetAlbumListFromHistory checks that the lists of frequently and recently
+// played albums are computed from the listening history.
+func TestGetAlbumListFromHistory(t *testing.T) {
+	lib := &libraryfakes.FakeLibrary{
+		TopAlbumsStub: func(
+			_ context.Context,
+			args library.StatsArgs,
+			orderBy library.BrowseOrderBy,
+		) ([]library.Album, error) {
+			if args.Offset == 13 {
+				return nil, errors.New("database is gone")
+			}
+
+			return []library.Album{
+				{ID: 3, Name: "The Number of the Beast", Artist: "Iron Maiden", Plays: 12},
+			}, nil
+		},
+	}
+	browser := &libraryfakes.FakeBrowser{}
+
+	ssHandler := subsonic.NewHandler(
+		subsonic.Prefix,
+		lib,
+		browser,
+		&radiofakes.FakeStations{},
+		&playlistsfakes.FakePlaylister{},
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
+		config.Config{},
+		nil, nil,
+	)
+
+	tests := []struct {
+		url     string
+		orderBy library.BrowseOrderBy
+		args    library.StatsArgs
+	}{
+		{
+			url:     "/getAlbumList2?f=json&type=frequent&size=5&offset=10",
+			orderBy: library.OrderByFrequentlyPlayed,
+			args:    library.StatsArgs{Count: 5, Offset: 10},
+		},
+		{
+			url:     "/getAlbumList?f=json&type=recent",
+			orderBy: library.OrderByRecentlyPlayed,
+			args:    library.StatsArgs{Count: 10},
+		},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, subsonic.Prefix+test.url, nil)
+		rec := httptest.NewRecorder()
+		ssHandler.ServeHTTP(rec, req)
+
+		var resp struct {
+			Response struct {
+				Status string `json:"status"`
+			} `json:"subsonic-response"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decoding response JSON: %s", test.url, err)
+		}
+		if resp.Response.Status != "ok" {
+			t.Errorf("%s: expected OK response but got `%s`", test.url, resp.Response.Status)
+		}
+
+		_, args, orderBy := lib.TopAlbumsArgsForCall(i)
+		if args != test.args || orderBy != test.orderBy {
+			t.Errorf("%s: expected top albums for %+v by %d but got %+v by %d",
+				test.url, test.args, test.orderBy, args, orderBy)
+		}
+	}
+
+	if calls := browser.BrowseAlbumsCallCount(); calls != 0 {
+		t.Errorf("expected albums not to be browsed but they were %d times", calls)
+	}
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		subsonic.Prefix+"/getAlbumList?f=json&type=frequent&offset=13",
+		nil,
+	)
+	rec := httptest.NewRecorder()
+	ssHandler.ServeHTTP(rec, req)
+
+	var resp struct {
+		Response struct {
+			Status string `json:"status"`
+		} `json:"subsonic-response"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding error response JSON: %s", err)
+	}
+	if resp.Response.Status != "failed" {
+		t.Errorf("expected failed response but got `%s`", resp.Response.Status)
+	}
+}
@@ -0,0 +1,104 @@
+package subsonic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/playlists/playlistsfakes"
+	"github.com/ironsmile/euterpe/src/radio/radiofakes"
+	"github.com/ironsmile/euterpe/src/webserver/subsonic"
+)
+
+// TestGetAlbumReleaseInfo checks that the OpenSubsonic release fields of albums
+// are set from what is known about their releases.
+func TestGetAlbumReleaseInfo(t *testing.T) {
+	lib := &libraryfakes.FakeLibrary{
+		GetAlbumStub: func(_ context.Context, id int64) (library.Album, error) {
+			return library.Album{
+				ID:     id,
+				Name:   "The Number of the Beast",
+				Artist: "Iron Maiden",
+			}, nil
+		},
+		GetAlbumInfoStub: func(_ context.Context, _ int64) (library.AlbumInfo, error) {
+			return library.AlbumInfo{
+				MusicBrainzID:       "6fd8d6e4-3e2f-3ae2-b1ec-7e1d5dc2b3a1",
+				Label:               "EMI",
+				OriginalReleaseDate: "1982-03",
+				ReleaseTypes:        []string{"album", "ep", "live"},
+			}, nil
+		},
+	}
+
+	ssHandler := subsonic.NewHandler(
+		subsonic.Prefix,
+		lib,
+		&libraryfakes.FakeBrowser{},
+		&radiofakes.FakeStations{},
+		&playlistsfakes.FakePlaylister{},
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
+		config.Config{},
+		nil, nil,
+	)
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		subsonic.Prefix+"/getAlbum?f=json&id=10",
+		nil,
+	)
+	rec := httptest.NewRecorder()
+	ssHandler.ServeHTTP(rec, req)
+
+	if status := rec.Result().StatusCode; status != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, status)
+	}
+
+	var resp struct {
+		Response struct {
+			Status string `json:"status"`
+			Album  struct {
+				MusicBrainzID string `json:"musicBrainzId"`
+				RecordLabels  []struct {
+					Name string `json:"name"`
+				} `json:"recordLabels"`
+				ReleaseTypes        []string `json:"releaseTypes"`
+				OriginalReleaseDate struct {
+					Year  int `json:"year"`
+					Month int `json:"month"`
+					Day   int `json:"day"`
+				} `json:"originalReleaseDate"`
+			} `json:"album"`
+		} `json:"subsonic-response"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response JSON: %s", err)
+	}
+
+	album := resp.Response.Album
+	if resp.Response.Status != "ok" {
+		t.Fatalf("expected OK response but got `%s`", resp.Response.Status)
+	}
+	if album.MusicBrainzID != "6fd8d6e4-3e2f-3ae2-b1ec-7e1d5dc2b3a1" {
+		t.Errorf("unexpected MusicBrainz ID: %s", album.MusicBrainzID)
+	}
+	if len(album.RecordLabels) != 1 || album.RecordLabels[0].Name != "EMI" {
+		t.Errorf("unexpected record labels: %+v", album.RecordLabels)
+	}
+	if !slices.Equal(album.ReleaseTypes, []string{"Album", "EP", "Live"}) {
+		t.Errorf("unexpected release types: %q", album.ReleaseTypes)
+	}
+	date := album.OriginalReleaseDate
+	if date.Year != 1982 || date.Month != 3 || date.Day != 0 {
+		t.Errorf("unexpected original release date: %+v", date)
+	}
+}
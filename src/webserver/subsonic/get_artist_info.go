@@ -30,10 +30,23 @@ func (s *subsonic) getArtistInfo(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	baseArtistInfo := s.getArtistInfoBase(req, artist)
+	info := s.getLibraryArtistInfo(req, artistID)
 	resp := artistInfoResponse{
-		baseResponse: baseArtistInfo.baseResponse,
-		ArtistInfo:   baseArtistInfo.ArtistInfo2,
+		baseResponse: responseOk(),
+		ArtistInfo: xsdArtistInfo{
+			xsdArtistInfoBase: s.getArtistInfoBase(req, artist, info),
+		},
+	}
+
+	artURL, query := s.getAristImageURL(req, 0)
+	for _, similar := range s.getSimilarArtists(req, info) {
+		query.Set("id", artistCoverArtID(similar.ID))
+		artURL.RawQuery = query.Encode()
+
+		resp.ArtistInfo.SimilarArtists = append(
+			resp.ArtistInfo.SimilarArtists,
+			toXSDArtist(similar, artURL),
+		)
 	}
 
 	encodeResponse(w, req, resp)
@@ -42,7 +55,5 @@ func (s *subsonic) getArtistInfo(w http.ResponseWriter, req *http.Request) {
 type artistInfoResponse struct {
 	baseResponse
 
-	// ArtistInfo reuses the aristInfo2Element element since for the moment
-	// it is exactly the same as what would've been the artistInfoElement.
-	ArtistInfo xsdArtistInfoBase `xml:"artistInfo"`
+	ArtistInfo xsdArtistInfo `xml:"artistInfo"`
 }
@@ -1,10 +1,13 @@
 package subsonic
 
 import (
+	"context"
 	"errors"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/ironsmile/euterpe/src/library"
 )
@@ -31,36 +34,102 @@ func (s *subsonic) getArtistInfo2(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	resp := s.getArtistInfoBase(req, artist)
+	info := s.getLibraryArtistInfo(req, artistID)
+	resp := artistInfo2Response{
+		baseResponse: responseOk(),
+		ArtistInfo2: xsdArtistInfo2{
+			xsdArtistInfoBase: s.getArtistInfoBase(req, artist, info),
+		},
+	}
+
+	artURL, query := s.getAristImageURL(req, 0)
+	for _, similar := range s.getSimilarArtists(req, info) {
+		query.Set("id", artistCoverArtID(similar.ID))
+		artURL.RawQuery = query.Encode()
+
+		resp.ArtistInfo2.SimilarArtists = append(
+			resp.ArtistInfo2.SimilarArtists,
+			dbArtistToArtistID3(similar, artURL),
+		)
+	}
+
 	encodeResponse(w, req, resp)
 }
 
+// getLibraryArtistInfo returns what the library knows about the artist. Errors are
+// only logged since the artist info responses are useful even without it.
+func (s *subsonic) getLibraryArtistInfo(
+	req *http.Request,
+	artistID int64,
+) library.ArtistInfo {
+	// Searching for the info on the internet could take a while.
+	ctx, cancel := context.WithTimeout(req.Context(), time.Minute)
+	defer cancel()
+
+	info, err := s.lib.GetArtistInfo(ctx, artistID)
+	if err != nil {
+		log.Printf("Error getting info for artist %d: %s\n", artistID, err)
+	}
+	return info
+}
+
 func (s *subsonic) getArtistInfoBase(
 	req *http.Request,
 	artist library.Artist,
-) artistInfo2Response {
+	info library.ArtistInfo,
+) xsdArtistInfoBase {
 	artURL, query := s.getAristImageURL(req, artist.ID)
 
-	resp := artistInfo2Response{
-		baseResponse: responseOk(),
-		ArtistInfo2: xsdArtistInfoBase{
-			LastfmURL: "https://last.fm/music/" + url.PathEscape(artist.Name),
-		},
+	infoBase := xsdArtistInfoBase{
+		Biography:     info.Biography,
+		MusicBrainzID: info.MusicBrainzID,
+		LastfmURL:     info.LastFMURL,
+	}
+	if infoBase.LastfmURL == "" {
+		infoBase.LastfmURL = "https://last.fm/music/" + url.PathEscape(artist.Name)
 	}
 
 	query.Set("size", "150")
 	artURL.RawQuery = query.Encode()
-	resp.ArtistInfo2.SmallImageURL = artURL.String()
+	infoBase.SmallImageURL = artURL.String()
 
 	query.Set("size", "300")
 	artURL.RawQuery = query.Encode()
-	resp.ArtistInfo2.MediumImageURL = artURL.String()
+	infoBase.MediumImageURL = artURL.String()
 
 	query.Set("size", "600")
 	artURL.RawQuery = query.Encode()
-	resp.ArtistInfo2.LargeImageURL = artURL.String()
+	infoBase.LargeImageURL = artURL.String()
+
+	return infoBase
+}
+
+// getSimilarArtists returns up to "count" of the artists similar to the one in
+// `info` which are in the library. Artists which are not in the library are never
+// returned, whatever "includeNotPresent" is, since there are no IDs for them.
+func (s *subsonic) getSimilarArtists(
+	req *http.Request,
+	info library.ArtistInfo,
+) []library.Artist {
+	count := int(parseIntOrDefault(req.Form.Get("count"), 20))
+
+	var artists []library.Artist
+	for _, similar := range info.Similar {
+		if len(artists) >= count {
+			break
+		}
+		if similar.ID == 0 {
+			continue
+		}
+
+		artist, err := s.lib.GetArtist(req.Context(), similar.ID)
+		if err != nil {
+			continue
+		}
+		artists = append(artists, artist)
+	}
 
-	return resp
+	return artists
 }
 
 // getAristImageURL returns a URL for artist image with query parameters
@@ -109,5 +178,5 @@ func setQueryFromReq(query url.Values, req *http.Request) {
 type artistInfo2Response struct {
 	baseResponse
 
-	ArtistInfo2 xsdArtistInfoBase `xml:"artistInfo2"`
+	ArtistInfo2 xsdArtistInfo2 `xml:"artistInfo2"`
 }
@@ -41,9 +41,11 @@ func (s *subsonic) getCoverArt(w http.ResponseWriter, req *http.Request) {
         return
     }
 
-    if sizePx, err := strconv.ParseInt(size, 10, 64); err == nil && sizePx < 200 {
-        query := req.Form
-        query.Set("size", "small")
+    // The artwork handlers scale the images to the requested size in pixels
+    // so it is passed to them as it is, even when it came in a POST body.
+    if _, err := strconv.ParseInt(size, 10, 64); err == nil {
+        query := req.URL.Query()
+        query.Set("size", size)
         req.URL.RawQuery = query.Encode()
         req.Method = http.MethodGet
     }
@@ -0,0 +1,57 @@
+package subsonic
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+func (s *subsonic) getLyrics(w http.ResponseWriter, req *http.Request) {
+	artist := req.Form.Get("artist")
+	title := req.Form.Get("title")
+
+	resp := lyricsResponse{
+		baseResponse: responseOk(),
+	}
+
+	if title == "" {
+		// Subsonic returns an empty lyrics element when nothing could be found.
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	tracks := s.lib.Search(req.Context(), library.SearchArgs{
+		Query: title,
+		Count: 50,
+	})
+
+	for _, track := range tracks {
+		if !strings.EqualFold(track.Title, title) {
+			continue
+		}
+		if artist != "" && !strings.EqualFold(track.Artist, artist) {
+			continue
+		}
+
+		lyrics, err := s.lib.GetTrackLyrics(req.Context(), track.ID)
+		if err != nil {
+			continue
+		}
+
+		resp.Lyrics = xsdLyrics{
+			Artist: track.Artist,
+			Title:  track.Title,
+			Value:  lyrics.Plain,
+		}
+		break
+	}
+
+	encodeResponse(w, req, resp)
+}
+
+type lyricsResponse struct {
+	baseResponse
+
+	Lyrics xsdLyrics `xml:"lyrics" json:"lyrics"`
+}
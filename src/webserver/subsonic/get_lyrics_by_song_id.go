@@ -0,0 +1,117 @@
+package subsonic
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// getLyricsBySongID implements the Open Subsonic extension "songLyrics". See
+// https://opensubsonic.netlify.app/docs/endpoints/getlyricsbysongid/
+func (s *subsonic) getLyricsBySongID(w http.ResponseWriter, req *http.Request) {
+	idString := req.Form.Get("id")
+	subsonicID, err := strconv.ParseInt(idString, 10, 64)
+	if idString == "" || err != nil || !isTrackID(subsonicID) {
+		resp := responseError(errCodeNotFound, "song not found")
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	trackID := toTrackDBID(subsonicID)
+
+	track, err := s.lib.GetTrack(req.Context(), trackID)
+	if errors.Is(err, library.ErrNotFound) {
+		resp := responseError(errCodeNotFound, "song not found")
+		encodeResponse(w, req, resp)
+		return
+	} else if err != nil {
+		resp := responseError(errCodeGeneric, err.Error())
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	resp := lyricsListResponse{
+		baseResponse: responseOk(),
+	}
+
+	lyrics, err := s.lib.GetTrackLyrics(req.Context(), trackID)
+	if errors.Is(err, library.ErrNotFound) {
+		encodeResponse(w, req, resp)
+		return
+	} else if err != nil {
+		resp := responseError(errCodeGeneric, err.Error())
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	lang := lyrics.Language
+	if lang == "" {
+		lang = "und"
+	}
+
+	if len(lyrics.Synced) > 0 {
+		synced := osStructuredLyrics{
+			DisplayArtist: track.Artist,
+			DisplayTitle:  track.Title,
+			Lang:          lang,
+			Synced:        true,
+		}
+		for _, line := range lyrics.Synced {
+			start := line.Start
+			synced.Lines = append(synced.Lines, osLyricsLine{
+				Start: &start,
+				Value: line.Text,
+			})
+		}
+		resp.LyricsList.StructuredLyrics = append(
+			resp.LyricsList.StructuredLyrics,
+			synced,
+		)
+	}
+
+	if lyrics.Plain != "" {
+		plain := osStructuredLyrics{
+			DisplayArtist: track.Artist,
+			DisplayTitle:  track.Title,
+			Lang:          lang,
+		}
+		for _, line := range strings.Split(lyrics.Plain, "\n") {
+			plain.Lines = append(plain.Lines, osLyricsLine{
+				Value: line,
+			})
+		}
+		resp.LyricsList.StructuredLyrics = append(
+			resp.LyricsList.StructuredLyrics,
+			plain,
+		)
+	}
+
+	encodeResponse(w, req, resp)
+}
+
+type lyricsListResponse struct {
+	baseResponse
+
+	LyricsList osLyricsList `xml:"lyricsList" json:"lyricsList"`
+}
+
+type osLyricsList struct {
+	StructuredLyrics []osStructuredLyrics `xml:"structuredLyrics" json:"structuredLyrics"`
+}
+
+type osStructuredLyrics struct {
+	DisplayArtist string         `xml:"displayArtist,attr,omitempty" json:"displayArtist,omitempty"`
+	DisplayTitle  string         `xml:"displayTitle,attr,omitempty" json:"displayTitle,omitempty"`
+	Lang          string         `xml:"lang,attr" json:"lang"`
+	Offset        int64          `xml:"offset,attr" json:"offset"`
+	Synced        bool           `xml:"synced,attr" json:"synced"`
+	Lines         []osLyricsLine `xml:"line" json:"line"`
+}
+
+type osLyricsLine struct {
+	Start *int64 `xml:"start,attr,omitempty" json:"start,omitempty"`
+	Value string `xml:",chardata" json:"value"`
+}
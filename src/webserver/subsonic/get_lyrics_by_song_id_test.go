@@ -0,0 +1,156 @@
+package subsonic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/playlists/playlistsfakes"
+	"github.com/ironsmile/euterpe/src/radio/radiofakes"
+	"github.com/ironsmile/euterpe/src/webserver/subsonic"
+)
+
+// TestGetLyricsBySongID checks that synchronised and plain lyrics are returned as
+// structured lyrics and that missing songs and lyrics are handled.
+func TestGetLyricsBySongID(t *testing.T) {
+	const (
+		syncedTrackID  = 5
+		plainTrackID   = 6
+		missingTrackID = 13
+	)
+
+	lib := &libraryfakes.FakeLibrary{
+		GetTrackStub: func(_ context.Context, id int64) (library.TrackInfo, error) {
+			if id == missingTrackID {
+				return library.TrackInfo{}, library.ErrNotFound
+			}
+			return library.TrackInfo{ID: id, Artist: "Iron Maiden", Title: "Invaders"}, nil
+		},
+		GetTrackLyricsStub: func(_ context.Context, id int64) (library.Lyrics, error) {
+			switch id {
+			case syncedTrackID:
+				return library.Lyrics{
+					TrackID:  id,
+					Language: "eng",
+					Plain:    "Invaders\nFighting",
+					Synced: []library.LyricsLine{
+						{Start: 1000, Text: "Invaders"},
+						{Start: 2500, Text: "Fighting"},
+					},
+				}, nil
+			case plainTrackID:
+				return library.Lyrics{TrackID: id, Plain: "Invaders\nFighting"}, nil
+			default:
+				return library.Lyrics{}, library.ErrLyricsNotFound
+			}
+		},
+	}
+
+	ssHandler := subsonic.NewHandler(
+		subsonic.Prefix,
+		lib,
+		&libraryfakes.FakeBrowser{},
+		&radiofakes.FakeStations{},
+		&playlistsfakes.FakePlaylister{},
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
+		config.Config{},
+		nil, nil,
+	)
+
+	type line struct {
+		Start *int64 `json:"start"`
+		Value string `json:"value"`
+	}
+	type structuredLyrics struct {
+		DisplayArtist string `json:"displayArtist"`
+		DisplayTitle  string `json:"displayTitle"`
+		Lang          string `json:"lang"`
+		Synced        bool   `json:"synced"`
+		Lines         []line `json:"line"`
+	}
+	getLyrics := func(id string) (string, int, []structuredLyrics) {
+		req := httptest.NewRequest(
+			http.MethodGet,
+			subsonic.Prefix+"/getLyricsBySongId?f=json&id="+id,
+			nil,
+		)
+		rec := httptest.NewRecorder()
+		ssHandler.ServeHTTP(rec, req)
+
+		var resp struct {
+			Response struct {
+				Status string `json:"status"`
+				Error  struct {
+					Code int `json:"code"`
+				} `json:"error"`
+				LyricsList struct {
+					StructuredLyrics []structuredLyrics `json:"structuredLyrics"`
+				} `json:"lyricsList"`
+			} `json:"subsonic-response"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("song %s: decoding response JSON: %s", id, err)
+		}
+
+		return resp.Response.Status, resp.Response.Error.Code,
+			resp.Response.LyricsList.StructuredLyrics
+	}
+
+	status, _, lyrics := getLyrics("2000000005")
+	if status != "ok" {
+		t.Fatalf("synced lyrics: expected OK response but got `%s`", status)
+	}
+	if len(lyrics) != 2 {
+		t.Fatalf("synced lyrics: expected synced and plain lyrics but got %+v", lyrics)
+	}
+	synced := lyrics[0]
+	if !synced.Synced || synced.Lang != "eng" || synced.DisplayArtist != "Iron Maiden" ||
+		synced.DisplayTitle != "Invaders" {
+		t.Errorf("synced lyrics: unexpected lyrics %+v", synced)
+	}
+	if len(synced.Lines) != 2 || synced.Lines[1].Start == nil ||
+		*synced.Lines[1].Start != 2500 || synced.Lines[1].Value != "Fighting" {
+		t.Errorf("synced lyrics: unexpected lines %+v", synced.Lines)
+	}
+	if lyrics[1].Synced || len(lyrics[1].Lines) != 2 {
+		t.Errorf("synced lyrics: unexpected plain lyrics %+v", lyrics[1])
+	}
+
+	status, _, lyrics = getLyrics("2000000006")
+	if status != "ok" {
+		t.Fatalf("plain lyrics: expected OK response but got `%s`", status)
+	}
+	if len(lyrics) != 1 {
+		t.Fatalf("plain lyrics: expected only plain lyrics but got %+v", lyrics)
+	}
+	plain := lyrics[0]
+	if plain.Synced || plain.Lang != "und" {
+		t.Errorf("plain lyrics: unexpected lyrics %+v", plain)
+	}
+	if len(plain.Lines) != 2 || plain.Lines[0].Start != nil ||
+		plain.Lines[0].Value != "Invaders" || plain.Lines[1].Value != "Fighting" {
+		t.Errorf("plain lyrics: unexpected lines %+v", plain.Lines)
+	}
+
+	status, _, lyrics = getLyrics("2000000007")
+	if status != "ok" || len(lyrics) != 0 {
+		t.Errorf("no lyrics: expected OK response without lyrics but got `%s`: %+v",
+			status, lyrics)
+	}
+
+	for _, id := range []string{"2000000013", "baba", "5"} {
+		status, code, _ := getLyrics(id)
+		if status != "failed" || code != 70 {
+			t.Errorf("song %s: expected not found error but got `%s` with code %d",
+				id, status, code)
+		}
+	}
+}
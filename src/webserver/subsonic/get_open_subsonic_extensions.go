@@ -10,6 +10,14 @@ func (s *subsonic) getOpenSubsonicExtensions(w http.ResponseWriter, req *http.Re
 				Name:     "formPost",
 				Versions: []int{1},
 			},
+			{
+				Name:     "songLyrics",
+				Versions: []int{1},
+			},
+			{
+				Name:     "apiKeyAuthentication",
+				Versions: []int{1},
+			},
 		},
 	}
 
@@ -0,0 +1,18 @@
+package subsonic
+
+import "net/http"
+
+func (s *subsonic) getScanStatus(w http.ResponseWriter, req *http.Request) {
+	resp := scanStatusResponse{
+		baseResponse: responseOk(),
+		ScanStatus:   toXsdScanStatus(s.lib.ScanStatus()),
+	}
+
+	encodeResponse(w, req, resp)
+}
+
+type scanStatusResponse struct {
+	baseResponse
+
+	ScanStatus xsdScanStatus `xml:"scanStatus" json:"scanStatus"`
+}
@@ -0,0 +1,95 @@
+package subsonic
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+func (s *subsonic) getSimilarSongs(w http.ResponseWriter, req *http.Request) {
+	idString := req.Form.Get("id")
+	if idString == "" {
+		resp := responseError(errCodeMissingParameter, "id is required")
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	subsonicID, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil || subsonicID <= 0 {
+		resp := responseError(errCodeNotFound, "not found")
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	args := library.SimilarArgs{
+		Count: similarSongsCount(req),
+	}
+	switch {
+	case isTrackID(subsonicID):
+		args.TrackID = toTrackDBID(subsonicID)
+	case isArtistID(subsonicID):
+		args.ArtistID = toArtistDBID(subsonicID)
+	case isAlbumID(subsonicID):
+		args.AlbumID = toAlbumDBID(subsonicID)
+	default:
+		resp := responseError(errCodeNotFound, "not found")
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	songs, ok := s.findSimilarSongs(w, req, args)
+	if !ok {
+		return
+	}
+
+	resp := similarSongsResponse{
+		baseResponse: responseOk(),
+	}
+	resp.SimilarSongs.Songs = songs
+
+	encodeResponse(w, req, resp)
+}
+
+// findSimilarSongs returns the songs which are similar to the ones selected by
+// `args`. On error it writes the error response and returns false.
+func (s *subsonic) findSimilarSongs(
+	w http.ResponseWriter,
+	req *http.Request,
+	args library.SimilarArgs,
+) ([]xsdChild, bool) {
+	tracks, err := s.lib.SimilarTracks(req.Context(), args)
+	if errors.Is(err, library.ErrNotFound) {
+		resp := responseError(errCodeNotFound, "not found")
+		encodeResponse(w, req, resp)
+		return nil, false
+	} else if err != nil {
+		resp := responseError(errCodeGeneric, err.Error())
+		encodeResponse(w, req, resp)
+		return nil, false
+	}
+
+	songs := make([]xsdChild, 0, len(tracks))
+	for _, track := range tracks {
+		songs = append(songs, trackToChild(track, s.getLastModified()))
+	}
+
+	return songs, true
+}
+
+// similarSongsCount returns the number of similar songs requested with the
+// "count" parameter.
+func similarSongsCount(req *http.Request) int {
+	count := parseIntOrDefault(req.Form.Get("count"), 50)
+	if count > 500 {
+		count = 500
+	}
+	return int(count)
+}
+
+type similarSongsResponse struct {
+	baseResponse
+
+	SimilarSongs xsdSimilarSongs `xml:"similarSongs" json:"similarSongs"`
+}
@@ -0,0 +1,45 @@
+package subsonic
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+func (s *subsonic) getSimilarSongs2(w http.ResponseWriter, req *http.Request) {
+	idString := req.Form.Get("id")
+	if idString == "" {
+		resp := responseError(errCodeMissingParameter, "id is required")
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	subsonicID, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil || !isArtistID(subsonicID) {
+		resp := responseError(errCodeNotFound, "artist not found")
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	songs, ok := s.findSimilarSongs(w, req, library.SimilarArgs{
+		ArtistID: toArtistDBID(subsonicID),
+		Count:    similarSongsCount(req),
+	})
+	if !ok {
+		return
+	}
+
+	resp := similarSongs2Response{
+		baseResponse: responseOk(),
+	}
+	resp.SimilarSongs.Songs = songs
+
+	encodeResponse(w, req, resp)
+}
+
+type similarSongs2Response struct {
+	baseResponse
+
+	SimilarSongs xsdSimilarSongs `xml:"similarSongs2" json:"similarSongs2"`
+}
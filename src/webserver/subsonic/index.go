@@ -5,10 +5,13 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/apikeys"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/library"
 	"github.com/ironsmile/euterpe/src/playlists"
 	"github.com/ironsmile/euterpe/src/radio"
+	"github.com/ironsmile/euterpe/src/webserver/forwardauth"
+	"github.com/ironsmile/euterpe/src/webserver/loginlimit"
 )
 
 type subsonic struct {
@@ -17,6 +20,9 @@ type subsonic struct {
 	lib        library.Library
 	radio      radio.Stations
 	playlists  playlists.Playlister
+	apiKeys    apikeys.Keys
+	limiter    *loginlimit.Limiter
+	proxyAuth  *forwardauth.Authenticator
 	needsAuth  bool
 	auth       config.Auth
 
@@ -42,6 +48,9 @@ func NewHandler(
 	libBrowser library.Browser,
 	stations radio.Stations,
 	playlister playlists.Playlister,
+	keys apikeys.Keys,
+	limiter *loginlimit.Limiter,
+	proxyAuth *forwardauth.Authenticator,
 	cfg config.Config,
 	albumArt CoverArtHandler,
 	artistArt CoverArtHandler,
@@ -52,6 +61,9 @@ func NewHandler(
 		libBrowser:       libBrowser,
 		radio:            stations,
 		playlists:        playlister,
+		apiKeys:          keys,
+		limiter:          limiter,
+		proxyAuth:        proxyAuth,
 		needsAuth:        cfg.Auth,
 		auth:             cfg.Authenticate,
 		albumArtHandler:  albumArt,
@@ -101,6 +113,10 @@ func (s *subsonic) initRouter() {
 	setUpHandler("/stream", s.stream, "GET", "HEAD")
 	setUpHandler("/download", s.stream, "GET", "HEAD")
 	setUpHandler("/getSong", s.getSong)
+	setUpHandler("/getLyrics", s.getLyrics)
+	setUpHandler("/getLyricsBySongId", s.getLyricsBySongID)
+	setUpHandler("/getScanStatus", s.getScanStatus)
+	setUpHandler("/startScan", s.startScan)
 	setUpHandler("/getGenres", s.getGenres)
 	setUpHandler("/getVideos", s.getVideos)
 	setUpHandler("/getVideoInfo", s.getVideoInfo)
@@ -114,6 +130,8 @@ func (s *subsonic) initRouter() {
 	setUpHandler("/getStarred", s.getStarred)
 	setUpHandler("/getStarred2", s.getStarred2)
 	setUpHandler("/getTopSongs", s.getTopSongs)
+	setUpHandler("/getSimilarSongs", s.getSimilarSongs)
+	setUpHandler("/getSimilarSongs2", s.getSimilarSongs2)
 	setUpHandler("/getAlbumInfo", s.getAlbumInfo)
 	setUpHandler("/getAlbumInfo2", s.getAlbumInfo2)
 	setUpHandler("/getInternetRadioStations", s.getInternetRadionStations)
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/library/libraryfakes"
 	"github.com/ironsmile/euterpe/src/playlists/playlistsfakes"
@@ -35,6 +36,9 @@ func TestOpenSubsonicFromPostExtension(t *testing.T) {
 		browser,
 		stations,
 		playlister,
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
 		config.Config{
 			Auth: true,
 			Authenticate: config.Auth{
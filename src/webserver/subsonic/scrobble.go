@@ -1,11 +1,14 @@
 package subsonic
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/ironsmile/euterpe/src/library"
 )
 
 func (s *subsonic) scrobble(w http.ResponseWriter, req *http.Request) {
@@ -64,9 +67,23 @@ func (s *subsonic) scrobble(w http.ResponseWriter, req *http.Request) {
 		scrobbleTime = time.Unix(unixTimeMs/1000, 0)
 	}
 
+	user := req.Form.Get("u")
+	if proxyUser, ok := s.proxyAuth.Authenticate(req); ok {
+		user = proxyUser
+	}
+
 	for _, trackID := range idInts {
-		err := s.lib.RecordTrackPlay(ctx, trackID, scrobbleTime)
-		if err != nil {
+		err := s.lib.RecordTrackPlay(ctx, library.Play{
+			TrackID: trackID,
+			At:      scrobbleTime,
+			Client:  req.Form.Get("c"),
+			User:    user,
+		})
+		if errors.Is(err, library.ErrNotFound) {
+			resp := responseError(errCodeNotFound, "song not found")
+			encodeResponse(w, req, resp)
+			return
+		} else if err != nil {
 			log.Printf("failed to update track %d stats: %s", trackID, err)
 			resp := responseError(errCodeGeneric, err.Error())
 			encodeResponse(w, req, resp)
@@ -0,0 +1,29 @@
+package subsonic
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// startScan starts a library scan unless there is one running already. In both
+// cases the current scan status is returned. The Open Subsonic `fullScan` parameter
+// may be used for reading again all files in the library.
+func (s *subsonic) startScan(w http.ResponseWriter, req *http.Request) {
+	fullScan := req.Form.Get("fullScan") == "true"
+
+	err := s.lib.StartScan(fullScan)
+	if err != nil && !errors.Is(err, library.ErrScanRunning) {
+		resp := responseError(errCodeGeneric, err.Error())
+		encodeResponse(w, req, resp)
+		return
+	}
+
+	resp := scanStatusResponse{
+		baseResponse: responseOk(),
+		ScanStatus:   toXsdScanStatus(s.lib.ScanStatus()),
+	}
+
+	encodeResponse(w, req, resp)
+}
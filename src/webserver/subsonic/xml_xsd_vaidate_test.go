@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ironsmile/euterpe/src/apikeys/apikeysfakes"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/library"
 	"github.com/ironsmile/euterpe/src/library/libraryfakes"
@@ -189,6 +190,25 @@ func TestSubsonicXMLResponses(t *testing.T) {
 				Rating:     3,
 			}, nil
 		},
+		FindAlbumInfoStub: func(ctx context.Context, i int64) (library.AlbumInfo, error) {
+			return library.AlbumInfo{
+				MusicBrainzID: "6fd8d6e4-3e2f-3ae2-b1ec-7e1d5dc2b3a1",
+				Notes:         "First album with Bruce Dickinson.",
+				Label:         "EMI",
+			}, nil
+		},
+		GetTrackLyricsStub: func(ctx context.Context, i int64) (library.Lyrics, error) {
+			return library.Lyrics{
+				TrackID: i,
+				Plain:   "First line\nSecond line",
+			}, nil
+		},
+		ScanStatusStub: func() library.ScanStatus {
+			return library.ScanStatus{
+				Running:   true,
+				FilesSeen: 42,
+			}
+		},
 		GetArtistStub: func(ctx context.Context, i int64) (library.Artist, error) {
 			return library.Artist{
 				ID:         11,
@@ -198,6 +218,51 @@ func TestSubsonicXMLResponses(t *testing.T) {
 				Rating:     5,
 			}, nil
 		},
+		GetArtistInfoStub: func(ctx context.Context, i int64) (library.ArtistInfo, error) {
+			return library.ArtistInfo{
+				MusicBrainzID: "ca891d65-d9b0-4258-89f7-e6ba29d83767",
+				Biography:     "An English heavy metal band.",
+				LastFMURL:     "https://www.last.fm/music/Iron+Maiden",
+				Similar: []library.SimilarArtist{
+					{ID: 12, Name: "Judas Priest"},
+					{Name: "Saxon"},
+				},
+			}, nil
+		},
+		SimilarTracksStub: func(
+			ctx context.Context,
+			sa library.SimilarArgs,
+		) ([]library.TrackInfo, error) {
+			return []library.TrackInfo{
+				{
+					ID:          31,
+					ArtistID:    12,
+					Artist:      "Judas Priest",
+					AlbumID:     14,
+					Album:       "Screaming for Vengeance",
+					Title:       "Electric Eye",
+					TrackNumber: 2,
+					Format:      "mp3",
+					Duration:    219000,
+				},
+			}, nil
+		},
+		TopAlbumsStub: func(
+			ctx context.Context,
+			sa library.StatsArgs,
+			orderBy library.BrowseOrderBy,
+		) ([]library.Album, error) {
+			return []library.Album{
+				{
+					ID:         3,
+					Name:       "The Number of the Beast",
+					Artist:     "Iron Maiden",
+					SongCount:  8,
+					Plays:      12,
+					LastPlayed: 1714856348,
+				},
+			}, nil
+		},
 	}
 	browser := &libraryfakes.FakeBrowser{
 		BrowseArtistsStub: func(ba library.BrowseArgs) ([]library.Artist, int) {
@@ -345,6 +410,9 @@ func TestSubsonicXMLResponses(t *testing.T) {
 		browser,
 		stations,
 		playlister,
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
 		config.Config{
 			Authenticate: config.Auth{
 				User: "test-user",
@@ -401,6 +469,14 @@ func TestSubsonicXMLResponses(t *testing.T) {
 			desc: "getAlbumList",
 			url:  testURL("/getAlbumList?type=random&id=%d", 10),
 		},
+		{
+			desc: "getAlbumList frequent",
+			url:  testURL("/getAlbumList?type=frequent"),
+		},
+		{
+			desc: "getAlbumList2 recent",
+			url:  testURL("/getAlbumList2?type=recent"),
+		},
 		{
 			desc: "getArtistInfo2",
 			url:  testURL("/getArtistInfo2?id=%d", int64(1e9+10)),
@@ -417,10 +493,22 @@ func TestSubsonicXMLResponses(t *testing.T) {
 			desc: "getSong",
 			url:  testURL("/getSong?id=%d", int64(2e9+66)),
 		},
+		{
+			desc: "getLyrics",
+			url:  testURL("/getLyrics?artist=First+Artist&title=First+Song"),
+		},
 		{
 			desc: "getGenres",
 			url:  testURL("/getGenres"),
 		},
+		{
+			desc: "getScanStatus",
+			url:  testURL("/getScanStatus"),
+		},
+		{
+			desc: "startScan",
+			url:  testURL("/startScan"),
+		},
 		{
 			desc: "getVideos",
 			url:  testURL("/getVideos"),
@@ -519,6 +607,14 @@ func TestSubsonicXMLResponses(t *testing.T) {
 			desc: "getTopSongs",
 			url:  testURL("/getTopSongs?artist=First+Artist&count=3"),
 		},
+		{
+			desc: "getSimilarSongs",
+			url:  testURL("/getSimilarSongs?id=%d&count=3", int64(2e9+11)),
+		},
+		{
+			desc: "getSimilarSongs2",
+			url:  testURL("/getSimilarSongs2?id=%d", int64(1e9+1)),
+		},
 		{
 			desc: "getAlbumInfo",
 			url:  testURL("/getAlbumInfo?id=55"),
@@ -666,6 +762,12 @@ func TestSubsonicXMLErrors(t *testing.T) {
 		GetArtistStub: func(ctx context.Context, i int64) (library.Artist, error) {
 			return library.Artist{}, library.ErrArtistNotFound
 		},
+		SimilarTracksStub: func(
+			ctx context.Context,
+			sa library.SimilarArgs,
+		) ([]library.TrackInfo, error) {
+			return nil, library.ErrArtistNotFound
+		},
 	}
 	browser := &libraryfakes.FakeBrowser{}
 	stations := &radiofakes.FakeStations{
@@ -713,6 +815,9 @@ func TestSubsonicXMLErrors(t *testing.T) {
 		browser,
 		stations,
 		playlister,
+		&apikeysfakes.FakeKeys{},
+		nil,
+		nil,
 		config.Config{},
 		nil, nil,
 	)
@@ -881,6 +986,31 @@ func TestSubsonicXMLErrors(t *testing.T) {
 			url:       testURL("/getTopSongs?artist=Not+Found"),
 			errorCode: 70,
 		},
+		{
+			desc:      "getSimilarSongs no arguments",
+			url:       testURL("/getSimilarSongs"),
+			errorCode: 10,
+		},
+		{
+			desc:      "getSimilarSongs not found",
+			url:       testURL("/getSimilarSongs?id=%d", int64(1e9+99)),
+			errorCode: 70,
+		},
+		{
+			desc:      "getSimilarSongs2 no arguments",
+			url:       testURL("/getSimilarSongs2"),
+			errorCode: 10,
+		},
+		{
+			desc:      "getSimilarSongs2 not an artist ID",
+			url:       testURL("/getSimilarSongs2?id=55"),
+			errorCode: 70,
+		},
+		{
+			desc:      "getSimilarSongs2 artist not found",
+			url:       testURL("/getSimilarSongs2?id=%d", int64(1e9+99)),
+			errorCode: 70,
+		},
 		{
 			desc:      "getAlbumInfo strange arguments",
 			url:       testURL("/getAlbumInfo?id=Not+Found"),
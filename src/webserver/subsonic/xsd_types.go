@@ -5,6 +5,8 @@ import (
 	"mime"
 	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ironsmile/euterpe/src/library"
@@ -191,6 +193,79 @@ type xsdAlbumID3 struct {
 	Starred    *time.Time `xml:"starred,attr,omitempty" json:"starred,omitempty"`
 	Year       int16      `xml:"year,attr" json:"year"`
 	Genre      string     `xml:"genre,attr,omitempty" json:"gener,omitempty"`
+
+	// The following are OpenSubsonic additions. They are set only for
+	// single albums.
+	MusicBrainzID       string           `xml:"musicBrainzId,attr,omitempty" json:"musicBrainzId,omitempty"`
+	RecordLabels        []xsdRecordLabel `xml:"recordLabels" json:"recordLabels,omitempty"`
+	ReleaseTypes        []string         `xml:"releaseTypes" json:"releaseTypes,omitempty"`
+	OriginalReleaseDate *xsdItemDate     `xml:"originalReleaseDate" json:"originalReleaseDate,omitempty"`
+}
+
+// setAlbumInfo sets the OpenSubsonic fields of the album from what is known about
+// its release.
+func (a *xsdAlbumID3) setAlbumInfo(info library.AlbumInfo) {
+	a.MusicBrainzID = info.MusicBrainzID
+	if info.Label != "" {
+		a.RecordLabels = []xsdRecordLabel{{Name: info.Label}}
+	}
+	for _, releaseType := range info.ReleaseTypes {
+		a.ReleaseTypes = append(a.ReleaseTypes, toReleaseTypeName(releaseType))
+	}
+	a.OriginalReleaseDate = toItemDate(info.OriginalReleaseDate)
+}
+
+// toReleaseTypeName returns the lower case release type as it is named by
+// MusicBrainz. For example "Album", "EP" or "Compilation".
+func toReleaseTypeName(releaseType string) string {
+	if releaseType == "ep" {
+		return "EP"
+	}
+	if releaseType == "" {
+		return releaseType
+	}
+	return strings.ToUpper(releaseType[:1]) + releaseType[1:]
+}
+
+type xsdRecordLabel struct {
+	Name string `xml:"name,attr" json:"name"`
+}
+
+// xsdItemDate is a date of which any part could be missing.
+type xsdItemDate struct {
+	Year  int `xml:"year,attr,omitempty" json:"year,omitempty"`
+	Month int `xml:"month,attr,omitempty" json:"month,omitempty"`
+	Day   int `xml:"day,attr,omitempty" json:"day,omitempty"`
+}
+
+// toItemDate returns the item date for `date` in the YYYY[-MM[-DD]] format. It
+// returns nil when there is no date.
+func toItemDate(date string) *xsdItemDate {
+	if date == "" {
+		return nil
+	}
+
+	var itemDate xsdItemDate
+	for i, part := range strings.SplitN(date, "-", 3) {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+
+		switch i {
+		case 0:
+			itemDate.Year = value
+		case 1:
+			itemDate.Month = value
+		case 2:
+			itemDate.Day = value
+		}
+	}
+
+	if itemDate.Year == 0 {
+		return nil
+	}
+	return &itemDate
 }
 
 func toAlbumID3Entry(child xsdChild) xsdAlbumID3 {
@@ -312,15 +387,29 @@ type xsdAlbumWithSongsID3 struct {
 }
 
 type xsdArtistInfoBase struct {
-	Notes          string `xml:"notes,omitempty" json:"notes,omitempty"`
+	Biography      string `xml:"biography,omitempty" json:"biography,omitempty"`
+	MusicBrainzID  string `xml:"musicBrainzId,omitempty" json:"musicBrainzId,omitempty"`
 	LastfmURL      string `xml:"lastFmUrl,omitempty" json:"lastFmUrl,omitempty"`
 	SmallImageURL  string `xml:"smallImageUrl" json:"smallImageUrl"`
 	MediumImageURL string `xml:"mediumImageUrl" json:"mediumImageUrl"`
 	LargeImageURL  string `xml:"largeImageUrl" json:"largeImageUrl"`
 }
 
+type xsdArtistInfo struct {
+	xsdArtistInfoBase
+
+	SimilarArtists []xsdArtist `xml:"similarArtist" json:"similarArtist,omitempty"`
+}
+
+type xsdArtistInfo2 struct {
+	xsdArtistInfoBase
+
+	SimilarArtists []xsdArtistID3 `xml:"similarArtist" json:"similarArtist,omitempty"`
+}
+
 type xsdAlbumInfo struct {
 	Notes          string `xml:"notes,omitempty" json:"notes,omitempty"`
+	MusicBrainzID  string `xml:"musicBrainzId,omitempty" json:"musicBrainzId,omitempty"`
 	LastfmURL      string `xml:"lastFmUrl,omitempty" json:"lastFmUrl,omitempty"`
 	SmallImageURL  string `xml:"smallImageUrl" json:"smallImageUrl"`
 	MediumImageURL string `xml:"mediumImageUrl" json:"mediumImageUrl"`
@@ -366,6 +455,10 @@ type xsdTopSongs struct {
 	Songs []xsdChild `xml:"song,omitempty" json:"song,omitempty"`
 }
 
+type xsdSimilarSongs struct {
+	Songs []xsdChild `xml:"song,omitempty" json:"song,omitempty"`
+}
+
 type xsdInternetRadioStations struct {
 	Stations []xsdInternetRadioStation `xml:"internetRadioStation,omitempty" json:"internetRadioStation,omitempty"`
 }
@@ -473,3 +566,21 @@ type xsdPlaylists struct {
 type xsdSongs struct {
 	Songs []xsdChild `xml:"song" json:"song"`
 }
+
+type xsdLyrics struct {
+	Artist string `xml:"artist,attr,omitempty" json:"artist,omitempty"`
+	Title  string `xml:"title,attr,omitempty" json:"title,omitempty"`
+	Value  string `xml:",chardata" json:"value"`
+}
+
+type xsdScanStatus struct {
+	Scanning bool  `xml:"scanning,attr" json:"scanning"`
+	Count    int64 `xml:"count,attr" json:"count"`
+}
+
+func toXsdScanStatus(status library.ScanStatus) xsdScanStatus {
+	return xsdScanStatus{
+		Scanning: status.Running,
+		Count:    status.FilesSeen,
+	}
+}
@@ -74,9 +74,25 @@ func (t *FSTemplates) All() (*AllTemplates, error) {
 		return nil, fmt.Errorf("finding add_device template: %s", err)
 	}
 
+	sfh, err := t.fs.Open("stats.html")
+	if err != nil {
+		return nil, fmt.Errorf("could not find stats.html template: %s", err)
+	}
+	defer sfh.Close()
+	tplContents, err = io.ReadAll(sfh)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stats.html: %s", err)
+	}
+
+	stats := template.Must(layout.Clone()).Funcs(statsTemplateFuncs)
+	if _, err := stats.New("content").Parse(string(tplContents)); err != nil {
+		return nil, fmt.Errorf("finding stats template: %s", err)
+	}
+
 	return &AllTemplates{
 		index:     index,
 		addDevice: addDevice,
+		stats:     stats,
 	}, nil
 }
 
@@ -93,4 +109,5 @@ func NewFSTemplates(fs fs.FS) *FSTemplates {
 type AllTemplates struct {
 	index     *template.Template
 	addDevice *template.Template
+	stats     *template.Template
 }
@@ -0,0 +1,213 @@
+// Package tokens signs and verifies the JWTs given to users and devices. Tokens are
+// signed with HS256 using keys from a Keyring. Every key has an ID which is stored
+// in the "kid" header of the tokens so that the signing secret could be rotated
+// without invalidating the tokens signed with the previous one.
+package tokens
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+)
+
+// Audience is the "aud" claim of all tokens issued by Euterpe.
+const Audience = "euterpe"
+
+// Use says what a token could be used for. It is stored in the "token_use" claim.
+type Use string
+
+// All the possible token uses.
+const (
+	// UseAccess tokens are used for authenticating requests.
+	UseAccess Use = "access"
+
+	// UseRefresh tokens could only be exchanged for new access tokens.
+	UseRefresh Use = "refresh"
+)
+
+var (
+	// ErrUnknownKey is returned by Verify for tokens signed with a key which is
+	// not in the keyring.
+	ErrUnknownKey = errors.New("token signed with unknown key")
+
+	// ErrNoKey is returned by Sign when the keyring has no current key.
+	ErrNoKey = errors.New("secret is empty")
+)
+
+// Claims are the claims in the tokens issued by Euterpe.
+type Claims struct {
+	jwt.Payload
+
+	// Device is the ID of the device to which the token was issued. All tokens
+	// of a device stop working once it is revoked.
+	Device string `json:"device,omitempty"`
+
+	// Use is what the token could be used for.
+	Use Use `json:"token_use,omitempty"`
+
+	// RegisterOnUse is true for tokens whose device is registered only when
+	// the token is used for the first time. Such tokens are given out in QR
+	// codes which may never be scanned.
+	RegisterOnUse bool `json:"register_on_use,omitempty"`
+
+	// RememberMe is true for refresh tokens of web UI sessions which outlive
+	// the browser session.
+	RememberMe bool `json:"remember_me,omitempty"`
+}
+
+// Keyring holds the keys used for signing and verifying tokens. New tokens are
+// always signed with the current key. The previous keys are only used for
+// verifying tokens issued before the secret was rotated.
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+
+	// order lists the key IDs with the current one first.
+	order []string
+}
+
+// NewKeyring returns a keyring with `current` as its signing key. The `previous`
+// secrets are only used for verification. Empty secrets are ignored.
+func NewKeyring(current string, previous ...string) *Keyring {
+	kr := &Keyring{
+		keys: make(map[string][]byte, len(previous)+1),
+	}
+
+	for i, secret := range append([]string{current}, previous...) {
+		if secret == "" {
+			continue
+		}
+
+		id := KeyID(secret)
+		if _, ok := kr.keys[id]; ok {
+			continue
+		}
+		if i == 0 {
+			kr.current = id
+		}
+		kr.keys[id] = []byte(secret)
+		kr.order = append(kr.order, id)
+	}
+
+	return kr
+}
+
+// KeyID returns the ID of the key with `secret`. It is derived from the secret so
+// that it does not need configuring.
+func KeyID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Sign returns a new token with `claims` signed by the current key. The audience
+// is always set to Audience.
+func (kr *Keyring) Sign(claims Claims) ([]byte, error) {
+	if kr.current == "" {
+		return nil, ErrNoKey
+	}
+
+	claims.Audience = jwt.Audience{Audience}
+	alg := jwt.NewHS256(kr.keys[kr.current])
+
+	return jwt.Sign(claims, alg, jwt.KeyID(kr.current))
+}
+
+// Verify checks the signature and the validity of `token` at the time `now`
+// and returns its claims.
+//
+// Tokens issued before key IDs were introduced have no "kid" header, audience,
+// device or use claims. They are checked against every key in the ring and are
+// considered access tokens for the device with ID equal to their "jti" claim.
+func (kr *Keyring) Verify(token []byte, now time.Time) (Claims, error) {
+	var claims Claims
+
+	keyID, err := tokenKeyID(token)
+	if err != nil {
+		return claims, err
+	}
+
+	validators := []jwt.Validator{
+		jwt.ExpirationTimeValidator(now),
+		jwt.NotBeforeValidator(now),
+	}
+
+	if keyID == "" {
+		for _, id := range kr.order {
+			claims, err = verify(token, kr.keys[id], validators)
+			if err == nil {
+				break
+			}
+		}
+		if len(kr.order) == 0 {
+			err = ErrUnknownKey
+		}
+		if err != nil {
+			return claims, err
+		}
+
+		// Other tokens signed with the same secret have an audience.
+		if claims.JWTID == "" || len(claims.Audience) != 0 {
+			return claims, errors.New("not an Euterpe token")
+		}
+
+		claims.Device = claims.JWTID
+		claims.Use = UseAccess
+		return claims, nil
+	}
+
+	secret, ok := kr.keys[keyID]
+	if !ok {
+		return claims, ErrUnknownKey
+	}
+
+	validators = append(validators, jwt.AudienceValidator(jwt.Audience{Audience}))
+	claims, err = verify(token, secret, validators)
+	if err != nil {
+		return claims, err
+	}
+
+	if claims.Device == "" || claims.Use == "" {
+		return claims, errors.New("token is missing the device or use claims")
+	}
+
+	return claims, nil
+}
+
+func verify(token []byte, secret []byte, validators []jwt.Validator) (Claims, error) {
+	var claims Claims
+	_, err := jwt.Verify(
+		token,
+		jwt.NewHS256(secret),
+		&claims,
+		jwt.ValidateHeader,
+		jwt.ValidatePayload(&claims.Payload, validators...),
+	)
+	return claims, err
+}
+
+// tokenKeyID returns the "kid" header of `token` without verifying it.
+func tokenKeyID(token []byte) (string, error) {
+	encoded, _, ok := strings.Cut(string(token), ".")
+	if !ok {
+		return "", jwt.ErrMalformed
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decoding token header: %w", err)
+	}
+
+	var header jwt.Header
+	if err := json.Unmarshal(decoded, &header); err != nil {
+		return "", fmt.Errorf("decoding token header: %w", err)
+	}
+
+	return header.KeyID, nil
+}
@@ -0,0 +1,112 @@
+package tokens_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/ironsmile/euterpe/src/webserver/tokens"
+)
+
+// TestKeyringRotation makes sure that tokens signed before rotating the secret are
+// still accepted and that new ones are signed with the new secret.
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	claims := tokens.Claims{
+		Payload: jwt.Payload{
+			Subject:        "user",
+			JWTID:          "token-id",
+			ExpirationTime: jwt.NumericDate(now.Add(time.Hour)),
+		},
+		Device: "device-id",
+		Use:    tokens.UseAccess,
+	}
+
+	oldToken, err := tokens.NewKeyring("old-secret").Sign(claims)
+	if err != nil {
+		t.Fatalf("signing with old keyring: %s", err)
+	}
+
+	rotated := tokens.NewKeyring("new-secret", "old-secret")
+
+	verified, err := rotated.Verify(oldToken, now)
+	if err != nil {
+		t.Fatalf("token signed with the previous secret was rejected: %s", err)
+	}
+	if verified.Subject != "user" || verified.Device != "device-id" ||
+		verified.Use != tokens.UseAccess {
+		t.Errorf("wrong claims after verification: %+v", verified)
+	}
+	if len(verified.Audience) != 1 || verified.Audience[0] != tokens.Audience {
+		t.Errorf("expected audience `%s` but got %v", tokens.Audience, verified.Audience)
+	}
+
+	newToken, err := rotated.Sign(claims)
+	if err != nil {
+		t.Fatalf("signing with rotated keyring: %s", err)
+	}
+
+	_, err = tokens.NewKeyring("old-secret").Verify(newToken, now)
+	if !errors.Is(err, tokens.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey for the old keyring but got %v", err)
+	}
+
+	if _, err := rotated.Verify(newToken, now.Add(2*time.Hour)); err == nil {
+		t.Errorf("expired token was accepted")
+	}
+}
+
+// TestKeyringLegacyTokens checks that tokens issued without key IDs are still
+// accepted as access tokens.
+func TestKeyringLegacyTokens(t *testing.T) {
+	now := time.Now()
+	secret := "legacy-secret"
+
+	legacy, err := jwt.Sign(jwt.Payload{
+		JWTID:          "legacy-device",
+		ExpirationTime: jwt.NumericDate(now.Add(time.Hour)),
+	}, jwt.NewHS256([]byte(secret)))
+	if err != nil {
+		t.Fatalf("signing legacy token: %s", err)
+	}
+
+	claims, err := tokens.NewKeyring("new-secret", secret).Verify(legacy, now)
+	if err != nil {
+		t.Fatalf("legacy token was rejected: %s", err)
+	}
+	if claims.Device != "legacy-device" || claims.Use != tokens.UseAccess {
+		t.Errorf("wrong claims for legacy token: %+v", claims)
+	}
+
+	withAudience, err := jwt.Sign(jwt.Payload{
+		JWTID:          "legacy-device",
+		Audience:       jwt.Audience{"something-else"},
+		ExpirationTime: jwt.NumericDate(now.Add(time.Hour)),
+	}, jwt.NewHS256([]byte(secret)))
+	if err != nil {
+		t.Fatalf("signing token: %s", err)
+	}
+
+	if _, err := tokens.NewKeyring(secret).Verify(withAudience, now); err == nil {
+		t.Errorf("token without key ID but with foreign audience was accepted")
+	}
+}
+
+// TestKeyringEmpty makes sure that a keyring without secrets cannot sign or
+// verify anything.
+func TestKeyringEmpty(t *testing.T) {
+	keyring := tokens.NewKeyring("")
+
+	if _, err := keyring.Sign(tokens.Claims{}); !errors.Is(err, tokens.ErrNoKey) {
+		t.Errorf("expected ErrNoKey but got %v", err)
+	}
+
+	token, err := tokens.NewKeyring("secret").Sign(tokens.Claims{})
+	if err != nil {
+		t.Fatalf("signing token: %s", err)
+	}
+	if _, err := keyring.Verify(token, time.Now()); err == nil {
+		t.Errorf("empty keyring verified a token")
+	}
+}
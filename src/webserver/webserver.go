@@ -5,21 +5,35 @@ package webserver
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"io/fs"
 	"log"
 	"net"
 	"net/http"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/ironsmile/euterpe/src/apikeys"
 	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/devices"
 	"github.com/ironsmile/euterpe/src/library"
 	"github.com/ironsmile/euterpe/src/playlists"
 	"github.com/ironsmile/euterpe/src/radio"
+	"github.com/ironsmile/euterpe/src/scaler"
+	"github.com/ironsmile/euterpe/src/webserver/acme"
+	"github.com/ironsmile/euterpe/src/webserver/forwardauth"
+	"github.com/ironsmile/euterpe/src/webserver/imagevariant"
+	"github.com/ironsmile/euterpe/src/webserver/loginlimit"
+	"github.com/ironsmile/euterpe/src/webserver/oidc"
 	"github.com/ironsmile/euterpe/src/webserver/subsonic"
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
 	"github.com/ironsmile/wrapfs"
 )
 
@@ -29,7 +43,11 @@ const (
 	notFoundAlbumImage = "images/unknownAlbum.png"
 
 	sessionCookieName  = "session"
+	refreshCookieName  = "session_refresh"
 	returnToQueryParam = "return_to"
+
+	// oidcRequestTimeout limits the requests made to the OpenID Connect provider.
+	oidcRequestTimeout = 15 * time.Second
 )
 
 // Server represents our web server. It will be controlled from here
@@ -52,9 +70,20 @@ type Server struct {
 	// The server's net.Listener. Used in the Server.Stop func
 	listener net.Listener
 
+	// handler serves all requests. It is replaced on every Reload.
+	handler atomic.Pointer[serverHandler]
+
+	// challengeSrv answers the ACME HTTP-01 challenges. It is nil when ACME is
+	// not used.
+	challengeSrv *http.Server
+
 	// This server's library with media
 	library *library.LocalLibrary
 
+	// variants keeps the scaled and converted images. It is shared by all
+	// handlers so that reloading does not empty it.
+	variants *imagevariant.Cache
+
 	// htmlTemplatesFS is the directory with HTML templates.
 	htmlTemplatesFS fs.FS
 
@@ -81,37 +110,95 @@ func (srv *Server) Serve() {
 	srv.startWG.Wait()
 }
 
-func (srv *Server) serveGoroutine() {
+// newHandler returns a handler for all requests served with the configuration `cfg`.
+// The login attempts limiter of `prev` is kept when its configuration has not
+// changed so that reloading does not reset the throttling. `prev` may be nil.
+func (srv *Server) newHandler(cfg config.Config, prev *serverHandler) (*serverHandler, error) {
 	templatesResolver := NewFSTemplates(srv.htmlTemplatesFS)
 	allTpls, err := templatesResolver.All()
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("parsing templates: %w", err)
 	}
 	playlistsManager := playlists.NewManager(srv.library.ExecuteDBJobAndWait)
+	devicesRegistry := devices.NewManager(srv.library.ExecuteDBJobAndWait)
+	apiKeys := apikeys.NewManager(srv.library.ExecuteDBJobAndWait)
+
+	trustedProxies, err := webutils.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Printf("Ignoring trusted proxies: %s\n", err)
+		trustedProxies = nil
+	}
+
+	var limiter *loginlimit.Limiter
+	if prev != nil && reflect.DeepEqual(prev.cfg.LoginLimits, cfg.LoginLimits) &&
+		slices.Equal(prev.cfg.TrustedProxies, cfg.TrustedProxies) {
+		limiter = prev.limiter
+	} else {
+		limiter = loginlimit.New(cfg.LoginLimits, trustedProxies)
+	}
+
+	proxyAuth, err := forwardauth.New(cfg.ForwardAuth, cfg.Authenticate.User)
+	if err != nil {
+		log.Printf("Forward authentication disabled: %s\n", err)
+		proxyAuth = nil
+	}
+
+	oidcProvider, err := oidc.New(cfg.OIDC, &http.Client{
+		Timeout: oidcRequestTimeout,
+	})
+	if err != nil {
+		log.Printf("OpenID Connect log in disabled: %s\n", err)
+		oidcProvider = nil
+	}
 
 	staticFilesHandler := http.FileServer(http.FS(
 		wrapfs.WithModTime(srv.httpRootFS, time.Now()),
 	))
 	searchHandler := NewSearchHandler(srv.library)
 	albumHandler := NewAlbumHandler(srv.library)
+	albumInfoHandler := NewAlbumInfoHandler(srv.library)
 	artoworkHandler := NewAlbumArtworkHandler(
 		srv.library,
 		srv.httpRootFS,
 		notFoundAlbumImage,
+		srv.variants,
 	)
-	artistImageHandler := NewArtistImagesHandler(srv.library)
+	artistImageHandler := NewArtistImagesHandler(srv.library, srv.variants)
+	artistHandler := NewArtistHandler(srv.library)
+	artistRadioHandler := NewArtistRadioHandler(srv.library)
+	historyHandler := NewHistoryHandler(srv.library)
+	statsHandler := NewStatsHandler(srv.library)
+	artworkMissesHandler := NewArtworkMissesHandler(srv.library)
+	artworkCandidatesHandler := NewAlbumArtworkCandidatesHandler(srv.library)
 	browseHandler := NewBrowseHandler(srv.library)
 	mediaFileHandler := NewFileHandler(srv.library)
+	lyricsHandler := NewLyricsHandler(srv.library)
+	libraryScanHandler := NewLibraryScanHandler(srv.library)
 	aboutHandler := NewAboutHandler()
-	loginHandler := NewLoginHandler(srv.cfg.Authenticate)
-	loginTokenHandler := NewLoginTokenHandler(srv.cfg.Authenticate)
-	logoutHandler := NewLogoutHandler()
-	createQRTokenHandler := NewCreateQRTokenHandler(srv.cfg.Auth, srv.cfg.Authenticate)
+	loginHandler := NewLoginHandler(cfg.Authenticate, devicesRegistry, limiter)
+	loginTokenHandler := NewLoginTokenHandler(
+		cfg.Authenticate,
+		devicesRegistry,
+		limiter,
+	)
+	loginRefreshHandler := NewLoginRefreshHandler(cfg.Authenticate, devicesRegistry)
+	logoutHandler := NewLogoutHandler(devicesRegistry)
+	oidcLoginHandler := NewOIDCLoginHandler(oidcProvider, cfg.Authenticate.Secret)
+	oidcCallbackHandler := NewOIDCCallbackHandler(
+		oidcProvider,
+		cfg.Authenticate,
+		devicesRegistry,
+	)
+	loginOptionsHandler := NewLoginOptionsHandler(oidcProvider)
+	createQRTokenHandler := NewCreateQRTokenHandler(cfg.Auth, cfg.Authenticate)
 	indexHandler := NewTemplateHandler(allTpls.index, "")
 	addDeviceHandler := NewTemplateHandler(allTpls.addDevice, "Add Device")
-	registerTokenHandler := NewRigisterTokenHandler()
+	statsPageHandler := NewStatsPageHandler(allTpls.stats, srv.library)
+	registerTokenHandler := NewRigisterTokenHandler(devicesRegistry)
 	playlistsHandler := NewPlaylistsHandler(playlistsManager)
 	singlePlaylistHandler := NewSinglePlaylistHandler(playlistsManager)
+	devicesHandler := NewDevicesHandler(devicesRegistry)
+	apiKeysHandler := NewAPIKeysHandler(apiKeys)
 
 	subsonicHandler := subsonic.NewHandler(
 		subsonic.Prefix,
@@ -119,7 +206,10 @@ func (srv *Server) serveGoroutine() {
 		srv.library,
 		radio.NewManager(srv.library.ExecuteDBJobAndWait),
 		playlistsManager,
-		srv.cfg,
+		apiKeys,
+		limiter,
+		proxyAuth,
+		cfg,
 		artoworkHandler,
 		artistImageHandler,
 	)
@@ -135,15 +225,39 @@ func (srv *Server) serveGoroutine() {
 	router.Handle(APIv1EndpointFile, mediaFileHandler).Methods(
 		APIv1Methods[APIv1EndpointFile]...,
 	)
+	router.Handle(APIv1EndpointFileLyrics, lyricsHandler).Methods(
+		APIv1Methods[APIv1EndpointFileLyrics]...,
+	)
 	router.Handle(APIv1EndpointAlbumArtwork, artoworkHandler).Methods(
 		APIv1Methods[APIv1EndpointAlbumArtwork]...,
 	)
+	router.Handle(APIv1EndpointArtworkCandidates, artworkCandidatesHandler).Methods(
+		APIv1Methods[APIv1EndpointArtworkCandidates]...,
+	)
 	router.Handle(APIv1EndpointDownloadAlbum, albumHandler).Methods(
 		APIv1Methods[APIv1EndpointDownloadAlbum]...,
 	)
+	router.Handle(APIv1EndpointAlbumInfo, albumInfoHandler).Methods(
+		APIv1Methods[APIv1EndpointAlbumInfo]...,
+	)
+	router.Handle(APIv1EndpointArtist, artistHandler).Methods(
+		APIv1Methods[APIv1EndpointArtist]...,
+	)
+	router.Handle(APIv1EndpointArtistRadio, artistRadioHandler).Methods(
+		APIv1Methods[APIv1EndpointArtistRadio]...,
+	)
+	router.Handle(APIv1EndpointHistory, historyHandler).Methods(
+		APIv1Methods[APIv1EndpointHistory]...,
+	)
+	router.Handle(APIv1EndpointStats, statsHandler).Methods(
+		APIv1Methods[APIv1EndpointStats]...,
+	)
 	router.Handle(APIv1EndpointArtistImage, artistImageHandler).Methods(
 		APIv1Methods[APIv1EndpointArtistImage]...,
 	)
+	router.Handle(APIv1EndpointArtworkMisses, artworkMissesHandler).Methods(
+		APIv1Methods[APIv1EndpointArtworkMisses]...,
+	)
 	router.Handle(APIv1EndpointBrowse, browseHandler).Methods(
 		APIv1Methods[APIv1EndpointBrowse]...,
 	)
@@ -156,15 +270,33 @@ func (srv *Server) serveGoroutine() {
 	router.Handle(APIv1EndpointLoginToken, loginTokenHandler).Methods(
 		APIv1Methods[APIv1EndpointLoginToken]...,
 	)
+	router.Handle(APIv1EndpointLoginRefresh, loginRefreshHandler).Methods(
+		APIv1Methods[APIv1EndpointLoginRefresh]...,
+	)
 	router.Handle(APIv1EndpointRegisterToken, registerTokenHandler).Methods(
 		APIv1Methods[APIv1EndpointRegisterToken]...,
 	)
+	router.Handle(APIv1EndpointLibraryScan, libraryScanHandler).Methods(
+		APIv1Methods[APIv1EndpointLibraryScan]...,
+	)
 	router.Handle(APIv1EndpointPlaylists, playlistsHandler).Methods(
 		APIv1Methods[APIv1EndpointPlaylists]...,
 	)
 	router.Handle(APIv1EndpointPlaylist, singlePlaylistHandler).Methods(
 		APIv1Methods[APIv1EndpointPlaylist]...,
 	)
+	router.Handle(APIv1EndpointDevices, devicesHandler).Methods(
+		APIv1Methods[APIv1EndpointDevices]...,
+	)
+	router.Handle(APIv1EndpointDevice, devicesHandler).Methods(
+		APIv1Methods[APIv1EndpointDevice]...,
+	)
+	router.Handle(APIv1EndpointAPIKeys, apiKeysHandler).Methods(
+		APIv1Methods[APIv1EndpointAPIKeys]...,
+	)
+	router.Handle(APIv1EndpointAPIKey, apiKeysHandler).Methods(
+		APIv1Methods[APIv1EndpointAPIKey]...,
+	)
 
 	// Kept for backward compatibility with older clients created before the
 	// API v1 compatibility promise. Although no promise has been made for
@@ -185,16 +317,20 @@ func (srv *Server) serveGoroutine() {
 
 	// Static resources and web UI.
 	router.Handle("/login/", loginHandler).Methods("POST")
+	router.Handle("/login/options/", loginOptionsHandler).Methods("GET")
+	router.Handle("/login/oidc/", oidcLoginHandler).Methods("GET")
+	router.Handle("/login/oidc/callback", oidcCallbackHandler).Methods("GET")
 	router.Handle("/logout/", logoutHandler).Methods("GET")
 	router.Handle("/", indexHandler).Methods("GET")
 	router.Handle("/add_device/", addDeviceHandler).Methods("GET")
+	router.Handle("/stats/", statsPageHandler).Methods("GET")
 	router.Handle("/new_qr_token/", createQRTokenHandler).Methods("GET")
 	router.PathPrefix(subsonic.Prefix).Handler(subsonicHandler).Methods("GET", "POST", "HEAD")
 	router.PathPrefix("/").Handler(staticFilesHandler).Methods("GET")
 
 	handler := NewTerryHandler(router)
 
-	if srv.cfg.Gzip {
+	if cfg.Gzip {
 		handler = NewGzipHandler(
 			handler,
 			[]string{
@@ -206,15 +342,20 @@ func (srv *Server) serveGoroutine() {
 		)
 	}
 
-	if srv.cfg.Auth {
+	if cfg.Auth {
 		handler = NewAuthHandler(
 			handler,
-			srv.cfg.Authenticate.User,
-			srv.cfg.Authenticate.Password,
+			cfg.Authenticate.User,
+			cfg.Authenticate.Password,
 			templatesResolver,
-			srv.cfg.Authenticate.Secret,
+			keyringFromAuth(cfg.Authenticate),
+			devicesRegistry,
+			apiKeys,
+			limiter,
+			proxyAuth,
 			[]string{
 				"/v1/login/token/",
+				APIv1EndpointLoginRefresh,
 				"/login/",
 				"/css/",
 				"/js/",
@@ -227,29 +368,67 @@ func (srv *Server) serveGoroutine() {
 
 	handler = func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx, closeRequest := context.WithCancel(srv.ctx)
+			// The request context is kept so that values stored in it by the
+			// outer handlers are not lost. It is cancelled together with the
+			// server context.
+			ctx, closeRequest := context.WithCancel(r.Context())
+			stop := context.AfterFunc(srv.ctx, closeRequest)
 			h.ServeHTTP(w, r.WithContext(ctx))
+			stop()
 			closeRequest()
 		})
 	}(handler)
 
-	if srv.cfg.AccessLog {
-		handler = NewAccessHandler(handler)
+	if cfg.AccessLog {
+		handler = NewAccessHandler(handler, trustedProxies)
+	}
+
+	return &serverHandler{
+		Handler: handler,
+		cfg:     cfg,
+		limiter: limiter,
+	}, nil
+}
+
+// serverHandler is the handler for all requests together with the configuration
+// it was created with.
+type serverHandler struct {
+	http.Handler
+
+	cfg     config.Config
+	limiter *loginlimit.Limiter
+}
+
+func (srv *Server) serveGoroutine() {
+	handler, err := srv.newHandler(srv.cfg, nil)
+	if err != nil {
+		panic(err)
 	}
+	srv.handler.Store(handler)
 
 	srv.httpSrv = &http.Server{
-		Addr:           srv.cfg.Listen,
-		Handler:        handler,
+		Addr: srv.cfg.Listen,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			srv.handler.Load().ServeHTTP(w, r)
+		}),
 		ReadTimeout:    time.Duration(srv.cfg.ReadTimeout) * time.Second,
 		WriteTimeout:   time.Duration(srv.cfg.WriteTimeout) * time.Second,
 		MaxHeaderBytes: srv.cfg.MaxHeadersSize,
 	}
 
+	// Falling back to plain HTTP when certificates were configured is not safe.
+	certManager, err := acme.New(srv.cfg.ACME, nil)
+	if err != nil {
+		panic(fmt.Errorf("setting up ACME certificates: %w", err))
+	}
+
 	var reason error
 
-	if srv.cfg.SSL {
-		reason = srv.listenAndServeTLS(srv.cfg.SSLCertificate.Crt,
-			srv.cfg.SSLCertificate.Key)
+	if certManager != nil {
+		srv.serveACMEChallenges(certManager.HTTPHandler(nil))
+		reason = srv.listenAndServeTLS(certManager.TLSConfig())
+	} else if srv.cfg.SSL {
+		reason = srv.listenAndServeTLS(nil)
 	} else {
 		reason = srv.listenAndServe()
 	}
@@ -283,33 +462,33 @@ func (srv *Server) listenAndServe() error {
 
 // Uses our own listener to make our server stoppable. Similar to
 // net.http.Server.ListenAndServerTLS only this version saves a reference
-// to the listener
-func (srv *Server) listenAndServeTLS(certFile, keyFile string) error {
+// to the listener. When `config` is nil the certificate configured in
+// cfg.SSLCertificate is used.
+func (srv *Server) listenAndServeTLS(config *tls.Config) error {
 	addr := srv.httpSrv.Addr
 	if addr == "" {
 		addr = "localhost:https"
 	}
 
-	var config *tls.Config
-
-	if srv.httpSrv.TLSConfig != nil {
-		config = srv.httpSrv.TLSConfig
-	} else {
-		config = &tls.Config{}
+	if config == nil {
+		cert, err := tls.LoadX509KeyPair(
+			srv.cfg.SSLCertificate.Crt,
+			srv.cfg.SSLCertificate.Key,
+		)
+		if err != nil {
+			srv.startWG.Done()
+			return err
+		}
+
+		config = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+		}
 	}
 
 	if config.NextProtos == nil {
 		config.NextProtos = []string{"http/1.1"}
 	}
 
-	var err error
-	config.Certificates = make([]tls.Certificate, 1)
-	config.Certificates[0], err = tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		srv.startWG.Done()
-		return err
-	}
-
 	conn, err := net.Listen("tcp", addr)
 	if err != nil {
 		srv.startWG.Done()
@@ -323,6 +502,62 @@ func (srv *Server) listenAndServeTLS(certFile, keyFile string) error {
 	return srv.httpSrv.Serve(tlsListener)
 }
 
+// serveACMEChallenges starts answering the ACME HTTP-01 challenges with `handler`
+// on the address from the ACME configuration. Failing to do so is not fatal since
+// the TLS-ALPN-01 challenge could still be used.
+func (srv *Server) serveACMEChallenges(handler http.Handler) {
+	addr := srv.cfg.ACME.HTTPListen
+	if addr == "" {
+		return
+	}
+
+	lsn, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("Not answering ACME HTTP-01 challenges: %s\n", err)
+		return
+	}
+
+	srv.challengeSrv = &http.Server{
+		Handler:        handler,
+		ReadTimeout:    srv.httpSrv.ReadTimeout,
+		WriteTimeout:   srv.httpSrv.WriteTimeout,
+		MaxHeaderBytes: srv.httpSrv.MaxHeaderBytes,
+	}
+	log.Printf("Answering ACME HTTP-01 challenges on http://%s\n", addr)
+
+	go func(challengeSrv *http.Server) {
+		err := challengeSrv.Serve(lsn)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("ACME challenges server stopped: %s\n", err)
+		}
+	}(srv.challengeSrv)
+}
+
+// Reload makes the server use the configuration `cfg` for all requests which arrive
+// after it returns. Requests which are being served at the moment are not affected.
+// Settings which are needed for starting the server, such as the listen address
+// and TLS, are not changed. See config.RestartRequired for the full list.
+func (srv *Server) Reload(cfg config.Config) error {
+	prev := srv.handler.Load()
+	if prev == nil {
+		return errors.New("server is not started")
+	}
+
+	handler, err := srv.newHandler(cfg, prev)
+	if err != nil {
+		return err
+	}
+
+	srv.handler.Store(handler)
+	return nil
+}
+
+// SetScaler makes the server use `scl` for serving images in the sizes and formats
+// requested by the clients. It must be called before Serve.
+func (srv *Server) SetScaler(scl scaler.Scaler) {
+	srv.variants = imagevariant.New(srv.cfg.Artwork, scl)
+}
+
 // Stop stops the webserver
 func (srv *Server) Stop() {
 	srv.Lock()
@@ -331,6 +566,10 @@ func (srv *Server) Stop() {
 		srv.listener.Close()
 		srv.listener = nil
 	}
+	if srv.challengeSrv != nil {
+		srv.challengeSrv.Close()
+		srv.challengeSrv = nil
+	}
 }
 
 // Wait syncs whoever called this with the server's stop
@@ -276,6 +276,56 @@ func TestUserAuthentication(t *testing.T) {
 	}
 }
 
+// TestReload makes sure that changing the authentication with Reload affects the
+// requests which follow without restarting the server.
+func TestReload(t *testing.T) {
+	srv := setUpServer()
+	srv.Serve()
+	defer tearDownServer(srv)
+
+	resp, err := http.Get(testURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 before reloading but got: %d", resp.StatusCode)
+	}
+
+	reloaded := srv.cfg
+	reloaded.Auth = true
+	reloaded.Authenticate = config.Auth{
+		User:     "testuser",
+		Password: "testpass",
+	}
+	if err := srv.Reload(reloaded); err != nil {
+		t.Fatalf("Reloading configuration: %s", err)
+	}
+
+	resp, err = http.Get(testURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after reloading but got: %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("GET", testURL(), nil)
+	req.SetBasicAuth("testuser", "testpass")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 with the reloaded credentials but got: %d", resp.StatusCode)
+	}
+}
+
 func TestSearchUrl(t *testing.T) {
 	projRoot, _ := getProjectRoot()
 
@@ -0,0 +1,45 @@
+package webutils
+
+import (
+	"context"
+	"sync"
+)
+
+// AccessNotes holds information gathered while handling a request which is to be
+// printed in the access log line for it.
+type AccessNotes struct {
+	mu          sync.Mutex
+	authFailure bool
+	user        string
+}
+
+type accessNotesKey struct{}
+
+// WithAccessNotes returns a context which carries a new AccessNotes. Handlers
+// called with it could add notes using functions such as MarkAuthFailure.
+func WithAccessNotes(ctx context.Context) (context.Context, *AccessNotes) {
+	notes := &AccessNotes{}
+	return context.WithValue(ctx, accessNotesKey{}, notes), notes
+}
+
+// MarkAuthFailure notes that the request with context `ctx` failed to authenticate
+// as `user`. Does nothing when the context has no AccessNotes.
+func MarkAuthFailure(ctx context.Context, user string) {
+	notes, ok := ctx.Value(accessNotesKey{}).(*AccessNotes)
+	if !ok {
+		return
+	}
+
+	notes.mu.Lock()
+	defer notes.mu.Unlock()
+	notes.authFailure = true
+	notes.user = user
+}
+
+// AuthFailure returns whether an authentication failure was noted and for which
+// user.
+func (n *AccessNotes) AuthFailure() (string, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.user, n.authFailure
+}
@@ -0,0 +1,77 @@
+package webutils_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// TestClientIP checks that the X-Forwarded-For header is used only for requests
+// coming from trusted proxies.
+func TestClientIP(t *testing.T) {
+	trusted, err := webutils.ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("parsing trusted proxies: %s", err)
+	}
+
+	tests := []struct {
+		desc         string
+		remoteAddr   string
+		forwardedFor string
+		expected     string
+	}{
+		{
+			desc:       "direct connection",
+			remoteAddr: "198.51.100.7:5555",
+			expected:   "198.51.100.7",
+		},
+		{
+			desc:         "untrusted proxy",
+			remoteAddr:   "198.51.100.7:5555",
+			forwardedFor: "203.0.113.5",
+			expected:     "198.51.100.7",
+		},
+		{
+			desc:         "trusted proxy",
+			remoteAddr:   "10.1.2.3:5555",
+			forwardedFor: "203.0.113.5",
+			expected:     "203.0.113.5",
+		},
+		{
+			desc:         "chain of trusted proxies",
+			remoteAddr:   "192.0.2.1:5555",
+			forwardedFor: "198.51.100.1, 203.0.113.5, 10.0.0.2",
+			expected:     "203.0.113.5",
+		},
+		{
+			desc:         "malformed header",
+			remoteAddr:   "10.1.2.3:5555",
+			forwardedFor: "baba",
+			expected:     "10.1.2.3",
+		},
+		{
+			desc:       "trusted proxy without header",
+			remoteAddr: "10.1.2.3:5555",
+			expected:   "10.1.2.3",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", test.forwardedFor)
+			}
+
+			if actual := webutils.ClientIP(req, trusted); actual != test.expected {
+				t.Errorf("expected client IP %s but got %s", test.expected, actual)
+			}
+		})
+	}
+
+	if _, err := webutils.ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Errorf("expected error for invalid trusted proxy")
+	}
+}
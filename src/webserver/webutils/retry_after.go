@@ -0,0 +1,14 @@
+package webutils
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SetRetryAfter sets the Retry-After HTTP header to `wait` rounded up to seconds.
+func SetRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
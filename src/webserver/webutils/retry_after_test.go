@@ -0,0 +1,32 @@
+package webutils_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/webserver/webutils"
+)
+
+// TestSetRetryAfter checks that the Retry-After header is rounded up to seconds.
+func TestSetRetryAfter(t *testing.T) {
+	tests := []struct {
+		wait     time.Duration
+		expected string
+	}{
+		{wait: 0, expected: "0"},
+		{wait: 300 * time.Millisecond, expected: "1"},
+		{wait: 2 * time.Second, expected: "2"},
+		{wait: 2*time.Second + time.Millisecond, expected: "3"},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		webutils.SetRetryAfter(rec, test.wait)
+
+		if actual := rec.Header().Get("Retry-After"); actual != test.expected {
+			t.Errorf("wait %s: expected Retry-After `%s` but got `%s`",
+				test.wait, test.expected, actual)
+		}
+	}
+}